@@ -0,0 +1,358 @@
+package ann
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+
+	"../distance"
+	tsr "../tensor"
+)
+
+// DistanceType is the identifying type of the distance used to compare embeddings.
+type DistanceType string
+
+const (
+	// DistanceTypeEuclidean compares embeddings by their straight line distance.
+	DistanceTypeEuclidean = DistanceType("euclidean")
+
+	// DistanceTypeManhattan compares embeddings by the sum of their absolute differences.
+	DistanceTypeManhattan = DistanceType("manhattan")
+
+	// DistanceTypeCosine compares embeddings by the angle between them.
+	DistanceTypeCosine = DistanceType("cosine")
+)
+
+// Neighbor is an embedding found by a search of the index.
+type Neighbor struct {
+	ID       int
+	Distance float32
+}
+
+// Index is an approximate nearest neighbor index over embeddings, built from a forest of random
+// projection trees. Each tree splits the embeddings by random hyperplanes until the leaves are
+// small, and a search only compares the query to the embeddings in the leaves it falls into.
+type Index struct {
+	trees      []*node
+	ids        []int
+	embeddings []*tsr.Tensor
+	built      bool
+	TreeCount  int
+	LeafSize   int
+	Distance   DistanceType
+}
+
+// node is a node of a random projection tree. A leaf holds the items that fall into it, and any
+// other node splits its items by a hyperplane between its left and right nodes.
+type node struct {
+	Leaf   bool      `json:"leaf"`
+	Normal []float32 `json:"normal,omitempty"`
+	Offset float32   `json:"offset,omitempty"`
+	Left   *node     `json:"left,omitempty"`
+	Right  *node     `json:"right,omitempty"`
+	Items  []int     `json:"items,omitempty"`
+}
+
+// NewIndex creates a new instance of an approximate nearest neighbor index. More trees and larger
+// leaves make searches more accurate and slower.
+func NewIndex(treeCount int, leafSize int, distanceType DistanceType) *Index {
+	return &Index{
+		trees:      []*node{},
+		ids:        []int{},
+		embeddings: []*tsr.Tensor{},
+		TreeCount:  treeCount,
+		LeafSize:   leafSize,
+		Distance:   distanceType,
+	}
+}
+
+// Size returns the number of embeddings in the index.
+func (index *Index) Size() int {
+	return len(index.embeddings)
+}
+
+// Add adds an embedding to the index with an identifier that is returned by searches.
+func (index *Index) Add(id int, embedding *tsr.Tensor) error {
+	if len(index.embeddings) > 0 {
+		first := index.embeddings[0]
+		if embedding.Frames != first.Frames || embedding.Rows != first.Rows || embedding.Cols != first.Cols {
+			return fmt.Errorf(
+				"Embedding dimensions must match the index: (%d, %d, %d) != (%d, %d, %d)",
+				embedding.Frames, embedding.Rows, embedding.Cols, first.Frames, first.Rows, first.Cols,
+			)
+		}
+	}
+	index.ids = append(index.ids, id)
+	index.embeddings = append(index.embeddings, embedding.Copy())
+	index.built = false
+	return nil
+}
+
+// Build builds the trees of the index. It is called by Search when embeddings were added since the
+// last build.
+func (index *Index) Build() {
+	items := make([]int, len(index.embeddings))
+	for i := range items {
+		items[i] = i
+	}
+	index.trees = make([]*node, index.TreeCount)
+	for i := range index.trees {
+		index.trees[i] = index.buildNode(items)
+	}
+	index.built = true
+}
+
+func (index *Index) buildNode(items []int) *node {
+	if len(items) <= index.LeafSize {
+		return &node{Leaf: true, Items: items}
+	}
+	for attempt := 0; attempt < 5; attempt++ {
+		point1 := flatten(index.embeddings[items[rand.Intn(len(items))]])
+		point2 := flatten(index.embeddings[items[rand.Intn(len(items))]])
+		normal := make([]float32, len(point1))
+		offset := float32(0.0)
+		for i := range normal {
+			normal[i] = point1[i] - point2[i]
+			offset += normal[i] * (point1[i] + point2[i]) / 2
+		}
+		left := []int{}
+		right := []int{}
+		for _, item := range items {
+			if dot(normal, flatten(index.embeddings[item])) < offset {
+				left = append(left, item)
+			} else {
+				right = append(right, item)
+			}
+		}
+		if len(left) > 0 && len(right) > 0 {
+			return &node{
+				Normal: normal,
+				Offset: offset,
+				Left:   index.buildNode(left),
+				Right:  index.buildNode(right),
+			}
+		}
+	}
+	return &node{Leaf: true, Items: items}
+}
+
+// Search finds the approximate nearest neighbors of a query embedding, from nearest to furthest.
+// The query must have the dimensions of the embeddings in the index.
+func (index *Index) Search(query *tsr.Tensor, count int) ([]Neighbor, error) {
+	if len(index.embeddings) > 0 {
+		first := index.embeddings[0]
+		if query.Frames != first.Frames || query.Rows != first.Rows || query.Cols != first.Cols {
+			return nil, fmt.Errorf(
+				"Query dimensions must match the index: (%d, %d, %d) != (%d, %d, %d)",
+				query.Frames, query.Rows, query.Cols, first.Frames, first.Rows, first.Cols,
+			)
+		}
+	}
+	if !index.built {
+		index.Build()
+	}
+	distanceFunction, err := distanceFunctionOfType(index.Distance)
+	if err != nil {
+		return nil, err
+	}
+	flatQuery := flatten(query)
+	candidates := map[int]bool{}
+	for _, tree := range index.trees {
+		current := tree
+		for !current.Leaf {
+			if dot(current.Normal, flatQuery) < current.Offset {
+				current = current.Left
+			} else {
+				current = current.Right
+			}
+		}
+		for _, item := range current.Items {
+			candidates[item] = true
+		}
+	}
+	if len(candidates) < count {
+		for item := range index.embeddings {
+			candidates[item] = true
+		}
+	}
+	neighbors := []Neighbor{}
+	for item := range candidates {
+		itemDistance, err := distanceFunction(query, index.embeddings[item])
+		if err != nil {
+			return nil, err
+		}
+		neighbors = append(neighbors, Neighbor{ID: index.ids[item], Distance: itemDistance})
+	}
+	sort.Slice(neighbors, func(i int, j int) bool {
+		return neighbors[i].Distance < neighbors[j].Distance
+	})
+	if len(neighbors) > count {
+		neighbors = neighbors[:count]
+	}
+	return neighbors, nil
+}
+
+func distanceFunctionOfType(distanceType DistanceType) (distance.Function, error) {
+	switch distanceType {
+	case DistanceTypeEuclidean:
+		return distance.Euclidean, nil
+	case DistanceTypeManhattan:
+		return distance.Manhattan, nil
+	case DistanceTypeCosine:
+		return distance.Cosine, nil
+	default:
+		return nil, fmt.Errorf("Invalid distance type: %s", distanceType)
+	}
+}
+
+func flatten(tensor *tsr.Tensor) []float32 {
+	values := make([]float32, 0, tensor.Frames*tensor.Rows*tensor.Cols)
+	for frame := 0; frame < tensor.Frames; frame++ {
+		for row := 0; row < tensor.Rows; row++ {
+			values = append(values, tensor.GetFrame(frame)[row]...)
+		}
+	}
+	return values
+}
+
+func dot(values1 []float32, values2 []float32) float32 {
+	sum := float32(0.0)
+	for i := range values1 {
+		sum += values1[i] * values2[i]
+	}
+	return sum
+}
+
+// IndexData represents a serialized index that can be saved to a file.
+type IndexData struct {
+	TreeCount  int             `json:"treeCount"`
+	LeafSize   int             `json:"leafSize"`
+	Distance   DistanceType    `json:"distance"`
+	IDs        []int           `json:"ids"`
+	Embeddings [][][][]float32 `json:"embeddings"`
+	Trees      []*node         `json:"trees"`
+}
+
+// SaveToFile saves an index to a file, building it first if needed.
+func (index *Index) SaveToFile(fileName string) error {
+	if !index.built {
+		index.Build()
+	}
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	embeddings := make([][][][]float32, len(index.embeddings))
+	for i, embedding := range index.embeddings {
+		embeddings[i] = embedding.GetAll()
+	}
+	indexData := IndexData{
+		TreeCount:  index.TreeCount,
+		LeafSize:   index.LeafSize,
+		Distance:   index.Distance,
+		IDs:        index.ids,
+		Embeddings: embeddings,
+		Trees:      index.trees,
+	}
+	return json.NewEncoder(file).Encode(indexData)
+}
+
+// LoadFromFile loads an index from a file. The embeddings must all have the same dimensions, and
+// the trees must only refer to embeddings in the file.
+func (index *Index) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	indexData := IndexData{}
+	err = json.NewDecoder(file).Decode(&indexData)
+	if err != nil {
+		return err
+	}
+	if len(indexData.IDs) != len(indexData.Embeddings) {
+		return fmt.Errorf("Number of ids and embeddings must match: %d != %d", len(indexData.IDs), len(indexData.Embeddings))
+	}
+	_, err = distanceFunctionOfType(indexData.Distance)
+	if err != nil {
+		return err
+	}
+	embeddings := make([]*tsr.Tensor, len(indexData.Embeddings))
+	for i, embedding := range indexData.Embeddings {
+		err = checkEmbedding(embedding, indexData.Embeddings[0])
+		if err != nil {
+			return fmt.Errorf("Invalid embedding %d: %s", i, err.Error())
+		}
+		embeddings[i] = tsr.NewValueTensor3D(embedding)
+	}
+	size := 0
+	if len(embeddings) > 0 {
+		size = len(flatten(embeddings[0]))
+	}
+	for i, tree := range indexData.Trees {
+		err = tree.validate(size, len(embeddings))
+		if err != nil {
+			return fmt.Errorf("Invalid tree %d: %s", i, err.Error())
+		}
+	}
+	index.TreeCount = indexData.TreeCount
+	index.LeafSize = indexData.LeafSize
+	index.Distance = indexData.Distance
+	index.ids = indexData.IDs
+	index.embeddings = embeddings
+	index.trees = indexData.Trees
+	index.built = true
+	return nil
+}
+
+// checkEmbedding makes sure the values of a loaded embedding are not empty and have the same
+// dimensions as the first embedding.
+func checkEmbedding(embedding [][][]float32, first [][][]float32) error {
+	if len(first) == 0 || len(first[0]) == 0 || len(first[0][0]) == 0 {
+		return fmt.Errorf("Embeddings must not be empty")
+	}
+	if len(embedding) != len(first) {
+		return fmt.Errorf("Frames must be %d, are: %d", len(first), len(embedding))
+	}
+	for frame, rows := range embedding {
+		if len(rows) != len(first[0]) {
+			return fmt.Errorf("Rows of frame %d must be %d, are: %d", frame, len(first[0]), len(rows))
+		}
+		for row, cols := range rows {
+			if len(cols) != len(first[0][0]) {
+				return fmt.Errorf("Columns of row %d of frame %d must be %d, are: %d", row, frame, len(first[0][0]), len(cols))
+			}
+		}
+	}
+	return nil
+}
+
+// validate makes sure a loaded tree only has leaves at its ends, that every split has a normal of
+// the size of the embeddings, and that every item refers to one of the embeddings.
+func (tree *node) validate(size int, count int) error {
+	if tree == nil {
+		return fmt.Errorf("Tree is missing a node")
+	}
+	if tree.Leaf {
+		if tree.Left != nil || tree.Right != nil {
+			return fmt.Errorf("Leaf must not have children")
+		}
+		for _, item := range tree.Items {
+			if item < 0 || item >= count {
+				return fmt.Errorf("Item must be between 0 and %d, is: %d", count-1, item)
+			}
+		}
+		return nil
+	}
+	if len(tree.Normal) != size {
+		return fmt.Errorf("Size of normal must be %d, is: %d", size, len(tree.Normal))
+	}
+	err := tree.Left.validate(size, count)
+	if err != nil {
+		return err
+	}
+	return tree.Right.validate(size, count)
+}
@@ -0,0 +1,140 @@
+package ann
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tsr "../tensor"
+)
+
+func randomEmbeddings(count int, size int) []*tsr.Tensor {
+	embeddings := make([]*tsr.Tensor, count)
+	for i := range embeddings {
+		embeddings[i] = tsr.NewEmptyTensor1D(size)
+		embeddings[i].SetRandom(-1, 1)
+	}
+	return embeddings
+}
+
+func TestIndexSearch(t *testing.T) {
+	rand.Seed(time.Now().Unix())
+	embeddings := randomEmbeddings(200, 8)
+
+	index := NewIndex(8, 10, DistanceTypeEuclidean)
+	for i, embedding := range embeddings {
+		err := index.Add(i*10, embedding)
+		if err != nil {
+			t.Fatalf("Error in Add: %s", err.Error())
+		}
+	}
+	if index.Size() != 200 {
+		t.Errorf("Index size should be 200, is: %d", index.Size())
+	}
+
+	for i := 0; i < 20; i++ {
+		neighbors, err := index.Search(embeddings[i], 3)
+		if err != nil {
+			t.Fatalf("Error in Search: %s", err.Error())
+		}
+		if len(neighbors) != 3 {
+			t.Fatalf("Search should find 3 neighbors, found: %d", len(neighbors))
+		}
+		if neighbors[0].ID != i*10 || neighbors[0].Distance != 0 {
+			t.Errorf("Nearest neighbor of an indexed embedding should be itself, is: %+v", neighbors[0])
+		}
+		if neighbors[1].Distance < neighbors[0].Distance || neighbors[2].Distance < neighbors[1].Distance {
+			t.Errorf("Neighbors should be sorted by distance: %+v", neighbors)
+		}
+	}
+
+	err := index.Add(0, tsr.NewEmptyTensor1D(4))
+	if err == nil {
+		t.Errorf("Did not trigger error on embedding with different dimensions")
+	}
+	_, err = index.Search(tsr.NewEmptyTensor1D(2), 3)
+	if err == nil {
+		t.Errorf("Did not trigger error on query with different dimensions")
+	}
+}
+
+func TestIndexSaveLoad(t *testing.T) {
+	index := NewIndex(4, 5, DistanceTypeCosine)
+	embeddings := randomEmbeddings(50, 4)
+	for i, embedding := range embeddings {
+		index.Add(i, embedding)
+	}
+	neighbors, _ := index.Search(embeddings[7], 5)
+
+	err := index.SaveToFile("index.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+
+	loadedIndex := NewIndex(1, 1, DistanceTypeEuclidean)
+	err = loadedIndex.LoadFromFile("index.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+
+	loadedNeighbors, err := loadedIndex.Search(embeddings[7], 5)
+	if err != nil {
+		t.Fatalf("Error in Search: %s", err.Error())
+	}
+	for i := range neighbors {
+		if loadedNeighbors[i].ID != neighbors[i].ID {
+			t.Errorf("Loaded index search does not match original: %+v != %+v", loadedNeighbors, neighbors)
+			break
+		}
+	}
+
+	err = os.Remove("index.json")
+	if err != nil {
+		t.Fatalf("Error removing test file: %s", err.Error())
+	}
+}
+
+func TestIndexSaveLoadEmpty(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "index.json")
+	err := NewIndex(2, 5, DistanceTypeEuclidean).SaveToFile(fileName)
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+	loadedIndex := NewIndex(1, 1, DistanceTypeEuclidean)
+	err = loadedIndex.LoadFromFile(fileName)
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+	neighbors, err := loadedIndex.Search(tsr.NewEmptyTensor1D(2), 3)
+	if err != nil {
+		t.Fatalf("Error in Search: %s", err.Error())
+	}
+	if len(neighbors) != 0 {
+		t.Errorf("Search of an empty index should find no neighbors, found: %d", len(neighbors))
+	}
+}
+
+func TestIndexLoadInvalid(t *testing.T) {
+	embeddings := `"ids": [0, 1], "embeddings": [[[[0, 1]]], [[[1, 0]]]]`
+	for _, data := range []string{
+		`{"distance": "euclidean", ` + embeddings + `, "trees": [{"leaf": false, "normal": [1, 0]}]}`,
+		`{"distance": "euclidean", ` + embeddings + `, "trees": [{"normal": [1], "left": {"leaf": true}, "right": {"leaf": true}}]}`,
+		`{"distance": "euclidean", ` + embeddings + `, "trees": [{"leaf": true, "items": [0, 2]}]}`,
+		`{"distance": "euclidean", ` + embeddings + `, "trees": [null]}`,
+		`{"distance": "euclidean", "ids": [0, 1], "embeddings": [[[[0, 1]]], [[[1]]]], "trees": []}`,
+		`{"distance": "unknown", ` + embeddings + `, "trees": []}`,
+	} {
+		fileName := filepath.Join(t.TempDir(), "index.json")
+		err := ioutil.WriteFile(fileName, []byte(data), 0644)
+		if err != nil {
+			t.Fatalf("Error writing test file: %s", err.Error())
+		}
+		err = NewIndex(1, 1, DistanceTypeEuclidean).LoadFromFile(fileName)
+		if err == nil {
+			t.Errorf("Invalid index did not trigger error: %s", data)
+		}
+	}
+}
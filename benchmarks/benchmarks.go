@@ -0,0 +1,120 @@
+package benchmarks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+
+	"../nn"
+	tsr "../tensor"
+)
+
+// RandomTensor creates a tensor of values between -1 and 1 from a random source, so a benchmark or
+// regression test with the same seed always gets the same inputs.
+func RandomTensor(frames int, rows int, cols int, random *rand.Rand) *tsr.Tensor {
+	tensor := tsr.NewEmptyTensor3D(frames, rows, cols)
+	tensor.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return random.Float32()*2 - 1
+	})
+	return tensor
+}
+
+// RandomizeParameters sets each parameter of a layer to a value between -1 and 1 divided by the
+// square root of its number of values, from a random source. The initializers of the layers use the
+// shared random source, so this makes the parameters reproducible.
+func RandomizeParameters(layer nn.Layer, random *rand.Rand) {
+	for _, parameter := range layer.Parameters() {
+		scale := float32(1 / math.Sqrt(float64(parameter.Frames*parameter.Rows*parameter.Cols)))
+		parameter.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			return (random.Float32()*2 - 1) * scale
+		})
+	}
+}
+
+// DenseNetwork creates a neural network with a hidden dense layer with a rectified linear unit and
+// a sigmoid output layer, with parameters from a random source.
+func DenseNetwork(inputSize int, hiddenSize int, outputSize int, random *rand.Rand) (*nn.NeuralNetwork, error) {
+	neuralNetwork := nn.NewNeuralNetwork()
+	err := neuralNetwork.Add(
+		nn.NewDenseLayer(inputSize, hiddenSize, nn.ActivationRELU),
+		nn.NewDenseLayer(hiddenSize, outputSize, nn.ActivationSigmoid),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < neuralNetwork.LayerCount(); i++ {
+		RandomizeParameters(neuralNetwork.LayerAt(i), random)
+	}
+	return neuralNetwork, nil
+}
+
+// ConvolutionLayer creates a convolutional layer for square single frame inputs of a size, with a
+// number of square filters of an odd kernel size, with parameters from a random source.
+func ConvolutionLayer(size int, numFilters int, kernelSize int, random *rand.Rand) (*nn.ConvolutionLayer, error) {
+	layer, err := nn.NewRandomConvolutionLayer(size, size, 1, numFilters, kernelSize, nn.ActivationRELU)
+	if err != nil {
+		return nil, err
+	}
+	RandomizeParameters(layer, random)
+	return layer, nil
+}
+
+// Golden holds named outputs of the kernels that are known to be correct, so later changes to the
+// kernels can be checked against them.
+type Golden map[string][]float32
+
+// LoadGolden loads golden outputs from a JSON file.
+func LoadGolden(fileName string) (Golden, error) {
+	bytes, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	golden := Golden{}
+	err = json.Unmarshal(bytes, &golden)
+	if err != nil {
+		return nil, err
+	}
+	return golden, nil
+}
+
+// SaveGolden saves golden outputs to a JSON file.
+func SaveGolden(golden Golden, fileName string) error {
+	bytes, err := json.MarshalIndent(golden, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, append(bytes, '\n'), 0644)
+}
+
+// Compare checks outputs against the golden outputs of a name, allowing a relative tolerance for
+// each value, since the order of floating point operations can change between kernels and
+// architectures.
+func (golden Golden) Compare(name string, outputs []float32, tolerance float32) error {
+	expected, ok := golden[name]
+	if !ok {
+		return fmt.Errorf("No golden outputs for: %s", name)
+	}
+	if len(outputs) != len(expected) {
+		return fmt.Errorf("Number of outputs for %s must be %d, is: %d", name, len(expected), len(outputs))
+	}
+	for i, value := range outputs {
+		difference := math.Abs(float64(value - expected[i]))
+		if difference > float64(tolerance)*math.Max(1, math.Abs(float64(expected[i]))) {
+			return fmt.Errorf("Output %d of %s should be %g, is: %g", i, name, expected[i], value)
+		}
+	}
+	return nil
+}
+
+// Values returns the values of a tensor in order of frames, rows and columns.
+func Values(tensor *tsr.Tensor) []float32 {
+	values := make([]float32, 0, tensor.Frames*tensor.Rows*tensor.Cols)
+	for frame := 0; frame < tensor.Frames; frame++ {
+		for _, row := range tensor.GetFrame(frame) {
+			values = append(values, row...)
+		}
+	}
+	return values
+}
@@ -0,0 +1,168 @@
+package benchmarks
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"../nn"
+	tsr "../tensor"
+)
+
+// Run the tests with -update to save the current outputs as the golden outputs, after checking
+// that a change to the outputs is expected.
+var update = flag.Bool("update", false, "update the golden outputs")
+
+const goldenFile = "testdata/golden.json"
+
+func matrixMultiplyOutputs() ([]float32, error) {
+	random := rand.New(rand.NewSource(1))
+	outputs, err := tsr.MatrixMultiply(RandomTensor(1, 6, 8, random), RandomTensor(1, 8, 5, random), nil)
+	if err != nil {
+		return nil, err
+	}
+	return Values(outputs), nil
+}
+
+func convolutionOutputs() ([]float32, error) {
+	random := rand.New(rand.NewSource(2))
+	layer, err := ConvolutionLayer(6, 2, 3, random)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := layer.FeedForward(RandomTensor(1, 6, 6, random))
+	if err != nil {
+		return nil, err
+	}
+	return Values(outputs), nil
+}
+
+// trainingStepOutputs trains a small neural network for a few steps and returns its predictions,
+// which covers the forward pass, the backward pass and the optimizer.
+func trainingStepOutputs() ([]float32, error) {
+	random := rand.New(rand.NewSource(3))
+	neuralNetwork, err := DenseNetwork(4, 6, 2, random)
+	if err != nil {
+		return nil, err
+	}
+	inputs := RandomTensor(1, 1, 4, random).GetFrame(0)
+	targets := [][][]float32{{{1, 0}}}
+	optimizer := nn.NewAdamOptimizer(0.01)
+	for i := 0; i < 3; i++ {
+		err = neuralNetwork.Train([][][]float32{inputs}, targets, optimizer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	prediction, err := neuralNetwork.Predict([][][]float32{inputs})
+	if err != nil {
+		return nil, err
+	}
+	return prediction[0][0], nil
+}
+
+func TestGoldenOutputs(t *testing.T) {
+	cases := map[string]func() ([]float32, error){
+		"matrixMultiply": matrixMultiplyOutputs,
+		"convolution":    convolutionOutputs,
+		"trainingStep":   trainingStepOutputs,
+	}
+	outputs := Golden{}
+	for name, compute := range cases {
+		values, err := compute()
+		if err != nil {
+			t.Fatalf("Error computing %s: %s", name, err.Error())
+		}
+		outputs[name] = values
+	}
+	if *update {
+		err := SaveGolden(outputs, goldenFile)
+		if err != nil {
+			t.Fatalf("Error in SaveGolden: %s", err.Error())
+		}
+	}
+
+	golden, err := LoadGolden(goldenFile)
+	if err != nil {
+		t.Fatalf("Error in LoadGolden: %s", err.Error())
+	}
+	for name, values := range outputs {
+		err = golden.Compare(name, values, 1e-4)
+		if err != nil {
+			t.Errorf("Outputs do not match golden outputs: %s", err.Error())
+		}
+	}
+}
+
+func TestGoldenCompare(t *testing.T) {
+	golden := Golden{"outputs": {1, -200, 0.5}}
+	err := golden.Compare("outputs", []float32{1.00001, -200.01, 0.5}, 1e-4)
+	if err != nil {
+		t.Errorf("Outputs within tolerance should match: %s", err.Error())
+	}
+	err = golden.Compare("outputs", []float32{1, -200, 0.6}, 1e-4)
+	if err == nil {
+		t.Errorf("Outputs outside tolerance did not trigger error")
+	}
+	err = golden.Compare("outputs", []float32{1, -200}, 1e-4)
+	if err == nil {
+		t.Errorf("Wrong number of outputs did not trigger error")
+	}
+	err = golden.Compare("missing", []float32{1}, 1e-4)
+	if err == nil {
+		t.Errorf("Missing golden outputs did not trigger error")
+	}
+}
+
+func BenchmarkMatrixMultiply(b *testing.B) {
+	for _, size := range []int{16, 64, 256} {
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			random := rand.New(rand.NewSource(1))
+			tensor1 := RandomTensor(1, size, size, random)
+			tensor2 := RandomTensor(1, size, size, random)
+			target := tsr.NewEmptyTensor2D(size, size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tsr.MatrixMultiply(tensor1, tensor2, target)
+			}
+		})
+	}
+}
+
+func BenchmarkConvolution(b *testing.B) {
+	for _, size := range []int{16, 32, 64} {
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			random := rand.New(rand.NewSource(2))
+			layer, err := ConvolutionLayer(size, 4, 3, random)
+			if err != nil {
+				b.Fatalf("Error in ConvolutionLayer: %s", err.Error())
+			}
+			inputs := RandomTensor(1, size, size, random)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				layer.FeedForward(inputs)
+			}
+		})
+	}
+}
+
+func BenchmarkTrainingStep(b *testing.B) {
+	for _, size := range []int{16, 64, 256} {
+		b.Run(fmt.Sprintf("hidden%d", size), func(b *testing.B) {
+			random := rand.New(rand.NewSource(3))
+			neuralNetwork, err := DenseNetwork(size, size, 10, random)
+			if err != nil {
+				b.Fatalf("Error in DenseNetwork: %s", err.Error())
+			}
+			inputs := [][][]float32{RandomTensor(1, 1, size, random).GetFrame(0)}
+			targets := [][][]float32{{make([]float32, 10)}}
+			targets[0][0][0] = 1
+			optimizer := nn.NewAdamOptimizer(0.001)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				neuralNetwork.Train(inputs, targets, optimizer)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package distance
+
+import (
+	"fmt"
+	"math"
+
+	tsr "../tensor"
+)
+
+// Function computes the distance between two tensors of the same dimensions.
+type Function func(*tsr.Tensor, *tsr.Tensor) (float32, error)
+
+// Euclidean computes the straight line distance between two tensors.
+func Euclidean(tensor1 *tsr.Tensor, tensor2 *tsr.Tensor) (float32, error) {
+	return Minkowski(2)(tensor1, tensor2)
+}
+
+// Manhattan computes the sum of the absolute differences between two tensors.
+func Manhattan(tensor1 *tsr.Tensor, tensor2 *tsr.Tensor) (float32, error) {
+	return Minkowski(1)(tensor1, tensor2)
+}
+
+// Minkowski creates a function computing the p-norm of the differences between two tensors.
+func Minkowski(p float64) Function {
+	return func(tensor1 *tsr.Tensor, tensor2 *tsr.Tensor) (float32, error) {
+		sum := 0.0
+		err := forEachPair(tensor1, tensor2, func(value1 float32, value2 float32) {
+			sum += math.Pow(math.Abs(float64(value1-value2)), p)
+		})
+		if err != nil {
+			return 0, err
+		}
+		return float32(math.Pow(sum, 1/p)), nil
+	}
+}
+
+// Hamming computes the fraction of values that differ between two tensors.
+func Hamming(tensor1 *tsr.Tensor, tensor2 *tsr.Tensor) (float32, error) {
+	differences := 0
+	err := forEachPair(tensor1, tensor2, func(value1 float32, value2 float32) {
+		if value1 != value2 {
+			differences++
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return float32(differences) / float32(tensor1.Frames*tensor1.Rows*tensor1.Cols), nil
+}
+
+// CosineSimilarity computes the cosine of the angle between two tensors, ranging from -1 for
+// opposite directions to 1 for the same direction. It is 0 if either tensor is all zeros.
+func CosineSimilarity(tensor1 *tsr.Tensor, tensor2 *tsr.Tensor) (float32, error) {
+	dot := 0.0
+	norm1 := 0.0
+	norm2 := 0.0
+	err := forEachPair(tensor1, tensor2, func(value1 float32, value2 float32) {
+		dot += float64(value1 * value2)
+		norm1 += float64(value1 * value1)
+		norm2 += float64(value2 * value2)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if norm1 == 0 || norm2 == 0 {
+		return 0, nil
+	}
+	return float32(dot / math.Sqrt(norm1*norm2)), nil
+}
+
+// Cosine computes the cosine distance between two tensors, which is one minus their cosine
+// similarity.
+func Cosine(tensor1 *tsr.Tensor, tensor2 *tsr.Tensor) (float32, error) {
+	similarity, err := CosineSimilarity(tensor1, tensor2)
+	if err != nil {
+		return 0, err
+	}
+	return 1 - similarity, nil
+}
+
+// Pairwise computes the distances between every pair of tensors from two sets. The result has a
+// row for each tensor of the first set and a column for each tensor of the second set.
+func Pairwise(tensors1 []*tsr.Tensor, tensors2 []*tsr.Tensor, function Function) (*tsr.Tensor, error) {
+	if len(tensors1) == 0 || len(tensors2) == 0 {
+		return nil, fmt.Errorf("Both sets must contain at least one tensor")
+	}
+	distances := tsr.NewEmptyTensor2D(len(tensors1), len(tensors2))
+	for row, tensor1 := range tensors1 {
+		for col, tensor2 := range tensors2 {
+			distance, err := function(tensor1, tensor2)
+			if err != nil {
+				return nil, err
+			}
+			distances.Set(0, row, col, distance)
+		}
+	}
+	return distances, nil
+}
+
+func forEachPair(tensor1 *tsr.Tensor, tensor2 *tsr.Tensor, function func(float32, float32)) error {
+	if tensor1.Frames != tensor2.Frames || tensor1.Rows != tensor2.Rows || tensor1.Cols != tensor2.Cols {
+		return fmt.Errorf(
+			"Dimensions must match: (%d, %d, %d) != (%d, %d, %d)",
+			tensor1.Frames, tensor1.Rows, tensor1.Cols, tensor2.Frames, tensor2.Rows, tensor2.Cols,
+		)
+	}
+	for frame := 0; frame < tensor1.Frames; frame++ {
+		for row := 0; row < tensor1.Rows; row++ {
+			for col := 0; col < tensor1.Cols; col++ {
+				function(tensor1.Get(frame, row, col), tensor2.Get(frame, row, col))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package distance
+
+import (
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestDistances(t *testing.T) {
+	tensor1 := tsr.NewValueTensor1D([]float32{0, 0, 1})
+	tensor2 := tsr.NewValueTensor1D([]float32{3, 4, 1})
+
+	tests := []struct {
+		name     string
+		function Function
+		solution float32
+	}{
+		{"Euclidean", Euclidean, 5},
+		{"Manhattan", Manhattan, 7},
+		{"Minkowski", Minkowski(3), float32(math.Cbrt(91))},
+		{"Hamming", Hamming, 2.0 / 3},
+		{"Cosine", Cosine, float32(1 - 1/math.Sqrt(26))},
+	}
+	for _, test := range tests {
+		distance, err := test.function(tensor1, tensor2)
+		if err != nil {
+			t.Fatalf("Error in %s: %s", test.name, err.Error())
+		}
+		if math.Abs(float64(distance-test.solution)) > 1e-5 {
+			t.Errorf("%s distance should be %.5f, is: %.5f", test.name, test.solution, distance)
+		}
+	}
+
+	_, err := Euclidean(tensor1, tsr.NewValueTensor1D([]float32{1, 2}))
+	if err == nil {
+		t.Errorf("Did not trigger error on mismatched dimensions")
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	similarity, _ := CosineSimilarity(tsr.NewValueTensor1D([]float32{1, 2}), tsr.NewValueTensor1D([]float32{-2, -4}))
+	if math.Abs(float64(similarity+1)) > 1e-5 {
+		t.Errorf("Similarity of opposite tensors should be -1, is: %.5f", similarity)
+	}
+
+	similarity, _ = CosineSimilarity(tsr.NewValueTensor1D([]float32{0, 0}), tsr.NewValueTensor1D([]float32{1, 2}))
+	if similarity != 0 {
+		t.Errorf("Similarity with zero tensor should be 0, is: %.5f", similarity)
+	}
+}
+
+func TestPairwise(t *testing.T) {
+	tensors1 := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{0, 0}),
+		tsr.NewValueTensor1D([]float32{1, 1}),
+	}
+	tensors2 := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{0, 1}),
+		tsr.NewValueTensor1D([]float32{2, 2}),
+		tsr.NewValueTensor1D([]float32{1, 1}),
+	}
+
+	distances, err := Pairwise(tensors1, tensors2, Manhattan)
+	if err != nil {
+		t.Fatalf("Error in Pairwise: %s", err.Error())
+	}
+
+	solution := tsr.NewValueTensor2D([][]float32{
+		{1, 4, 2},
+		{1, 2, 0},
+	})
+	if !distances.Equals(solution) {
+		t.Errorf("Pairwise distances should be:\n%swhen result is:\n%s", solution.String(), distances.String())
+	}
+}
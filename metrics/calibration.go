@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+
+	tsr "../tensor"
+)
+
+const probabilityEpsilon = 1e-7
+
+// CalibrationBin is a range of predicted probabilities in a calibration curve.
+type CalibrationBin struct {
+	Lower            float32
+	Upper            float32
+	MeanPredicted    float32
+	FractionPositive float32
+	Count            int
+}
+
+// LogLoss computes the mean negative log-likelihood of the targets under the predicted
+// probabilities. Predictions with a single column are treated as binary probabilities of the
+// positive class, and predictions with multiple columns as a distribution over classes.
+func LogLoss(predictions []*tsr.Tensor, targets []*tsr.Tensor) (float32, error) {
+	err := checkSamples(predictions, targets)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for i, prediction := range predictions {
+		binary := prediction.Frames*prediction.Rows*prediction.Cols == 1
+		for frame := 0; frame < prediction.Frames; frame++ {
+			for row := 0; row < prediction.Rows; row++ {
+				for col := 0; col < prediction.Cols; col++ {
+					probability := clipProbability(prediction.Get(frame, row, col))
+					target := float64(targets[i].Get(frame, row, col))
+					total -= target * math.Log(probability)
+					if binary {
+						total -= (1 - target) * math.Log(1-probability)
+					}
+				}
+			}
+		}
+	}
+	return float32(total / float64(len(predictions))), nil
+}
+
+// BrierScore computes the mean squared difference between the predicted probabilities and the
+// targets, summed over the classes of each sample.
+func BrierScore(predictions []*tsr.Tensor, targets []*tsr.Tensor) (float32, error) {
+	err := checkSamples(predictions, targets)
+	if err != nil {
+		return 0, err
+	}
+	total := float32(0.0)
+	for i, prediction := range predictions {
+		difference := prediction.Copy()
+		difference.SubtractTensor(targets[i])
+		difference.ScaleTensor(difference.Copy())
+		total += difference.Sum()
+	}
+	return total / float32(len(predictions)), nil
+}
+
+// CalibrationCurve groups binary probabilities into equally sized bins between 0 and 1 and
+// computes the mean predicted probability and the fraction of positive labels in each bin. A well
+// calibrated model has a mean predicted probability close to the fraction of positives. Empty bins
+// are left out of the curve.
+func CalibrationCurve(probabilities []float32, labels []bool, bins int) ([]CalibrationBin, error) {
+	if len(probabilities) != len(labels) {
+		return nil, fmt.Errorf("Number of probabilities and labels must match: %d != %d", len(probabilities), len(labels))
+	}
+	if bins < 1 {
+		return nil, fmt.Errorf("Number of bins must be at least 1, is: %d", bins)
+	}
+	sums := make([]float32, bins)
+	positives := make([]int, bins)
+	counts := make([]int, bins)
+	for i, probability := range probabilities {
+		bin := int(probability * float32(bins))
+		if bin >= bins {
+			bin = bins - 1
+		} else if bin < 0 {
+			bin = 0
+		}
+		sums[bin] += probability
+		counts[bin]++
+		if labels[i] {
+			positives[bin]++
+		}
+	}
+	curve := []CalibrationBin{}
+	for bin := 0; bin < bins; bin++ {
+		if counts[bin] == 0 {
+			continue
+		}
+		curve = append(curve, CalibrationBin{
+			Lower:            float32(bin) / float32(bins),
+			Upper:            float32(bin+1) / float32(bins),
+			MeanPredicted:    sums[bin] / float32(counts[bin]),
+			FractionPositive: float32(positives[bin]) / float32(counts[bin]),
+			Count:            counts[bin],
+		})
+	}
+	return curve, nil
+}
+
+func clipProbability(probability float32) float64 {
+	return math.Min(math.Max(float64(probability), probabilityEpsilon), 1-probabilityEpsilon)
+}
+
+func checkSamples(predictions []*tsr.Tensor, targets []*tsr.Tensor) error {
+	if len(predictions) != len(targets) {
+		return fmt.Errorf("Number of predictions and targets must match: %d != %d", len(predictions), len(targets))
+	}
+	if len(predictions) == 0 {
+		return fmt.Errorf("No predictions to evaluate")
+	}
+	for i, prediction := range predictions {
+		target := targets[i]
+		if prediction.Frames != target.Frames || prediction.Rows != target.Rows || prediction.Cols != target.Cols {
+			return fmt.Errorf(
+				"Dimensions must match: (%d, %d, %d) != (%d, %d, %d)",
+				prediction.Frames, prediction.Rows, prediction.Cols, target.Frames, target.Rows, target.Cols,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestLogLoss(t *testing.T) {
+	predictions := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{0.8}),
+		tsr.NewValueTensor1D([]float32{0.4}),
+	}
+	targets := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{1}),
+		tsr.NewValueTensor1D([]float32{0}),
+	}
+
+	loss, err := LogLoss(predictions, targets)
+	if err != nil {
+		t.Fatalf("Error in LogLoss: %s", err.Error())
+	}
+	solution := float32(-(math.Log(0.8) + math.Log(0.6)) / 2)
+	if math.Abs(float64(loss-solution)) > 1e-5 {
+		t.Errorf("Binary log loss should be %.5f, is: %.5f", solution, loss)
+	}
+
+	predictions = []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{0.7, 0.2, 0.1}),
+		tsr.NewValueTensor1D([]float32{0.1, 0.1, 0.8}),
+	}
+	targets = []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{1, 0, 0}),
+		tsr.NewValueTensor1D([]float32{0, 0, 1}),
+	}
+
+	loss, err = LogLoss(predictions, targets)
+	if err != nil {
+		t.Fatalf("Error in LogLoss: %s", err.Error())
+	}
+	solution = float32(-(math.Log(0.7) + math.Log(0.8)) / 2)
+	if math.Abs(float64(loss-solution)) > 1e-5 {
+		t.Errorf("Categorical log loss should be %.5f, is: %.5f", solution, loss)
+	}
+
+	_, err = LogLoss(predictions, targets[:1])
+	if err == nil {
+		t.Errorf("Did not trigger error on mismatched number of samples")
+	}
+}
+
+func TestBrierScore(t *testing.T) {
+	predictions := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{0.75}),
+		tsr.NewValueTensor1D([]float32{0.5}),
+	}
+	targets := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{1}),
+		tsr.NewValueTensor1D([]float32{0}),
+	}
+
+	score, err := BrierScore(predictions, targets)
+	if err != nil {
+		t.Fatalf("Error in BrierScore: %s", err.Error())
+	}
+	if score != 0.15625 {
+		t.Errorf("Brier score should be 0.15625, is: %.5f", score)
+	}
+}
+
+func TestCalibrationCurve(t *testing.T) {
+	probabilities := []float32{0.1, 0.2, 0.3, 0.7, 0.8, 0.9, 1.0}
+	labels := []bool{false, false, true, true, true, false, true}
+
+	curve, err := CalibrationCurve(probabilities, labels, 2)
+	if err != nil {
+		t.Fatalf("Error in CalibrationCurve: %s", err.Error())
+	}
+	if len(curve) != 2 {
+		t.Fatalf("Calibration curve should have 2 bins, has: %d", len(curve))
+	}
+	if curve[0].Count != 3 || math.Abs(float64(curve[0].MeanPredicted-0.2)) > 1e-5 || math.Abs(float64(curve[0].FractionPositive-1.0/3)) > 1e-5 {
+		t.Errorf("Incorrect first bin: %+v", curve[0])
+	}
+	if curve[1].Count != 4 || math.Abs(float64(curve[1].MeanPredicted-0.85)) > 1e-5 || curve[1].FractionPositive != 0.75 {
+		t.Errorf("Incorrect second bin: %+v", curve[1])
+	}
+
+	_, err = CalibrationCurve(probabilities, labels, 0)
+	if err == nil {
+		t.Errorf("Did not trigger error on invalid number of bins")
+	}
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	tsr "../tensor"
+)
+
+// ConfusionMatrix counts how often samples of each class are predicted as each class, with the
+// counts of the actual class in the rows and the predicted class in the columns.
+type ConfusionMatrix struct {
+	Counts [][]int
+}
+
+// ClassScores is the precision, recall and F1 score of a class, with the number of samples of the
+// class as its support.
+type ClassScores struct {
+	Precision float32
+	Recall    float32
+	F1        float32
+	Support   int
+}
+
+// NewConfusionMatrix counts the predicted and actual classes of samples. Predictions with a single
+// value are binary, with class 1 when the value is at least 0.5 and class 0 otherwise, and other
+// predictions are the class of their largest value, as are the targets.
+func NewConfusionMatrix(predictions []*tsr.Tensor, targets []*tsr.Tensor) (*ConfusionMatrix, error) {
+	err := checkSamples(predictions, targets)
+	if err != nil {
+		return nil, err
+	}
+	classes := predictions[0].Frames * predictions[0].Rows * predictions[0].Cols
+	if classes == 1 {
+		classes = 2
+	}
+	counts := make([][]int, classes)
+	for i := range counts {
+		counts[i] = make([]int, classes)
+	}
+	for i, prediction := range predictions {
+		actual := classOf(targets[i])
+		predicted := classOf(prediction)
+		if actual >= classes || predicted >= classes {
+			continue
+		}
+		counts[actual][predicted]++
+	}
+	return &ConfusionMatrix{Counts: counts}, nil
+}
+
+// Accuracy returns the fraction of samples that are predicted as their actual class.
+func (matrix *ConfusionMatrix) Accuracy() float32 {
+	correct := 0
+	total := 0
+	for actual, row := range matrix.Counts {
+		for predicted, count := range row {
+			if actual == predicted {
+				correct += count
+			}
+			total += count
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float32(correct) / float32(total)
+}
+
+// Scores returns the precision, recall and F1 score of each class. The precision of a class is the
+// fraction of samples predicted as the class that are of the class, and the recall is the fraction
+// of samples of the class that are predicted as the class. Scores without any samples to divide by
+// are 0.
+func (matrix *ConfusionMatrix) Scores() []ClassScores {
+	scores := make([]ClassScores, len(matrix.Counts))
+	for class := range matrix.Counts {
+		truePositives := matrix.Counts[class][class]
+		predicted := 0
+		actual := 0
+		for other := range matrix.Counts {
+			predicted += matrix.Counts[other][class]
+			actual += matrix.Counts[class][other]
+		}
+		score := ClassScores{Support: actual}
+		if predicted > 0 {
+			score.Precision = float32(truePositives) / float32(predicted)
+		}
+		if actual > 0 {
+			score.Recall = float32(truePositives) / float32(actual)
+		}
+		if score.Precision+score.Recall > 0 {
+			score.F1 = 2 * score.Precision * score.Recall / (score.Precision + score.Recall)
+		}
+		scores[class] = score
+	}
+	return scores
+}
+
+// Accuracy computes the fraction of samples whose predicted class is their actual class, with
+// classes as in NewConfusionMatrix.
+func Accuracy(predictions []*tsr.Tensor, targets []*tsr.Tensor) (float32, error) {
+	matrix, err := NewConfusionMatrix(predictions, targets)
+	if err != nil {
+		return 0, err
+	}
+	return matrix.Accuracy(), nil
+}
+
+// PrecisionRecallF1 computes the precision, recall and F1 score of each class, with classes as in
+// NewConfusionMatrix.
+func PrecisionRecallF1(predictions []*tsr.Tensor, targets []*tsr.Tensor) ([]ClassScores, error) {
+	matrix, err := NewConfusionMatrix(predictions, targets)
+	if err != nil {
+		return nil, err
+	}
+	return matrix.Scores(), nil
+}
+
+func classOf(tensor *tsr.Tensor) int {
+	if tensor.Frames*tensor.Rows*tensor.Cols == 1 {
+		if tensor.Get(0, 0, 0) >= 0.5 {
+			return 1
+		}
+		return 0
+	}
+	best := 0
+	bestValue := tensor.Get(0, 0, 0)
+	index := 0
+	tensor.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		if current > bestValue {
+			best = index
+			bestValue = current
+		}
+		index++
+		return current
+	})
+	return best
+}
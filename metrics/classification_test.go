@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestConfusionMatrix(t *testing.T) {
+	predictions := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{0.8, 0.1, 0.1}),
+		tsr.NewValueTensor1D([]float32{0.2, 0.7, 0.1}),
+		tsr.NewValueTensor1D([]float32{0.6, 0.3, 0.1}),
+		tsr.NewValueTensor1D([]float32{0.1, 0.2, 0.7}),
+		tsr.NewValueTensor1D([]float32{0.1, 0.6, 0.3}),
+	}
+	targets := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{1, 0, 0}),
+		tsr.NewValueTensor1D([]float32{0, 1, 0}),
+		tsr.NewValueTensor1D([]float32{0, 1, 0}),
+		tsr.NewValueTensor1D([]float32{0, 0, 1}),
+		tsr.NewValueTensor1D([]float32{0, 0, 1}),
+	}
+
+	matrix, err := NewConfusionMatrix(predictions, targets)
+	if err != nil {
+		t.Fatalf("Error in NewConfusionMatrix: %s", err.Error())
+	}
+	solution := [][]int{{1, 0, 0}, {1, 1, 0}, {0, 1, 1}}
+	for i := range solution {
+		for j := range solution[i] {
+			if matrix.Counts[i][j] != solution[i][j] {
+				t.Fatalf("Confusion matrix should be %v, is: %v", solution, matrix.Counts)
+			}
+		}
+	}
+
+	accuracy, err := Accuracy(predictions, targets)
+	if err != nil {
+		t.Fatalf("Error in Accuracy: %s", err.Error())
+	}
+	if math.Abs(float64(accuracy-0.6)) > 1e-6 {
+		t.Errorf("Accuracy should be 0.6, is: %f", accuracy)
+	}
+
+	scores, err := PrecisionRecallF1(predictions, targets)
+	if err != nil {
+		t.Fatalf("Error in PrecisionRecallF1: %s", err.Error())
+	}
+	expected := []ClassScores{
+		{Precision: 0.5, Recall: 1, F1: 2.0 / 3, Support: 1},
+		{Precision: 0.5, Recall: 0.5, F1: 0.5, Support: 2},
+		{Precision: 1, Recall: 0.5, F1: 2.0 / 3, Support: 2},
+	}
+	for i, score := range scores {
+		if math.Abs(float64(score.Precision-expected[i].Precision)) > 1e-6 ||
+			math.Abs(float64(score.Recall-expected[i].Recall)) > 1e-6 ||
+			math.Abs(float64(score.F1-expected[i].F1)) > 1e-6 ||
+			score.Support != expected[i].Support {
+			t.Errorf("Scores of class %d should be %+v, are: %+v", i, expected[i], score)
+		}
+	}
+}
+
+func TestBinaryConfusionMatrix(t *testing.T) {
+	predictions := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{0.9}),
+		tsr.NewValueTensor1D([]float32{0.2}),
+		tsr.NewValueTensor1D([]float32{0.6}),
+	}
+	targets := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{1}),
+		tsr.NewValueTensor1D([]float32{0}),
+		tsr.NewValueTensor1D([]float32{0}),
+	}
+	matrix, err := NewConfusionMatrix(predictions, targets)
+	if err != nil {
+		t.Fatalf("Error in NewConfusionMatrix: %s", err.Error())
+	}
+	if matrix.Counts[0][0] != 1 || matrix.Counts[0][1] != 1 || matrix.Counts[1][1] != 1 {
+		t.Errorf("Binary confusion matrix should be [[1 1] [0 1]], is: %v", matrix.Counts)
+	}
+}
@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+
+	"../distance"
+	tsr "../tensor"
+)
+
+// SilhouetteScore computes the mean silhouette coefficient of clustered samples. The coefficient
+// of a sample compares its mean distance to the other samples of its cluster with its mean
+// distance to the samples of the nearest other cluster, ranging from -1 for a wrong cluster to 1
+// for a dense, well separated cluster. Samples alone in their cluster, or as close to the nearest
+// other cluster as to their own, have a coefficient of 0.
+func SilhouetteScore(samples []*tsr.Tensor, labels []int) (float32, error) {
+	clusters, err := groupClusters(samples, labels)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for i, sample := range samples {
+		if len(clusters[labels[i]]) == 1 {
+			continue
+		}
+		ownDistance := 0.0
+		otherDistance := math.Inf(1)
+		for label, members := range clusters {
+			sum := 0.0
+			for _, member := range members {
+				memberDistance, err := euclideanDistance(sample, samples[member])
+				if err != nil {
+					return 0, err
+				}
+				sum += memberDistance
+			}
+			if label == labels[i] {
+				ownDistance = sum / float64(len(members)-1)
+			} else {
+				otherDistance = math.Min(otherDistance, sum/float64(len(members)))
+			}
+		}
+		if ownDistance == otherDistance {
+			continue
+		}
+		total += (otherDistance - ownDistance) / math.Max(ownDistance, otherDistance)
+	}
+	return float32(total / float64(len(samples))), nil
+}
+
+// DaviesBouldinScore computes the mean similarity of each cluster with its most similar cluster,
+// where similarity is the ratio of the spread within the clusters to the distance between their
+// centroids. Lower scores mean better separated clusters. It returns an error if the centroids of
+// two clusters coincide, since their similarity is then undefined.
+func DaviesBouldinScore(samples []*tsr.Tensor, labels []int) (float32, error) {
+	clusters, err := groupClusters(samples, labels)
+	if err != nil {
+		return 0, err
+	}
+	centroids := map[int]*tsr.Tensor{}
+	spreads := map[int]float64{}
+	for label, members := range clusters {
+		first := samples[members[0]]
+		centroid := tsr.NewEmptyTensor3D(first.Frames, first.Rows, first.Cols)
+		for _, member := range members {
+			centroid.AddTensor(samples[member])
+		}
+		centroid.Scale(1 / float32(len(members)))
+		centroids[label] = centroid
+		for _, member := range members {
+			memberDistance, err := euclideanDistance(samples[member], centroid)
+			if err != nil {
+				return 0, err
+			}
+			spreads[label] += memberDistance
+		}
+		spreads[label] /= float64(len(members))
+	}
+	total := 0.0
+	for label := range clusters {
+		maxSimilarity := 0.0
+		for otherLabel := range clusters {
+			if otherLabel == label {
+				continue
+			}
+			centroidDistance, err := euclideanDistance(centroids[label], centroids[otherLabel])
+			if err != nil {
+				return 0, err
+			}
+			if centroidDistance == 0 {
+				return 0, fmt.Errorf("Centroids of clusters %d and %d must not coincide", label, otherLabel)
+			}
+			similarity := (spreads[label] + spreads[otherLabel]) / centroidDistance
+			maxSimilarity = math.Max(maxSimilarity, similarity)
+		}
+		total += maxSimilarity
+	}
+	return float32(total / float64(len(clusters))), nil
+}
+
+// AdjustedRandIndex computes the agreement between two clusterings of the same samples, adjusted
+// for the agreement expected by chance. Identical clusterings score 1 regardless of how their
+// clusters are numbered, and random clusterings score close to 0. It needs at least 2 samples,
+// since it counts pairs of samples.
+func AdjustedRandIndex(labels1 []int, labels2 []int) (float32, error) {
+	if len(labels1) != len(labels2) {
+		return 0, fmt.Errorf("Number of labels must match: %d != %d", len(labels1), len(labels2))
+	}
+	if len(labels1) < 2 {
+		return 0, fmt.Errorf("Number of labels must be at least 2, is: %d", len(labels1))
+	}
+	contingency := map[[2]int]int{}
+	counts1 := map[int]int{}
+	counts2 := map[int]int{}
+	for i := range labels1 {
+		contingency[[2]int{labels1[i], labels2[i]}]++
+		counts1[labels1[i]]++
+		counts2[labels2[i]]++
+	}
+	index := 0.0
+	for _, count := range contingency {
+		index += pairs(count)
+	}
+	pairs1 := 0.0
+	for _, count := range counts1 {
+		pairs1 += pairs(count)
+	}
+	pairs2 := 0.0
+	for _, count := range counts2 {
+		pairs2 += pairs(count)
+	}
+	expectedIndex := pairs1 * pairs2 / pairs(len(labels1))
+	maxIndex := (pairs1 + pairs2) / 2
+	if maxIndex == expectedIndex {
+		return 1, nil
+	}
+	return float32((index - expectedIndex) / (maxIndex - expectedIndex)), nil
+}
+
+func groupClusters(samples []*tsr.Tensor, labels []int) (map[int][]int, error) {
+	if len(samples) != len(labels) {
+		return nil, fmt.Errorf("Number of samples and labels must match: %d != %d", len(samples), len(labels))
+	}
+	for i, sample := range samples {
+		first := samples[0]
+		if sample.Frames != first.Frames || sample.Rows != first.Rows || sample.Cols != first.Cols {
+			return nil, fmt.Errorf(
+				"Dimensions of sample %d must match the first sample: (%d, %d, %d) != (%d, %d, %d)",
+				i, sample.Frames, sample.Rows, sample.Cols, first.Frames, first.Rows, first.Cols,
+			)
+		}
+	}
+	clusters := map[int][]int{}
+	for i, label := range labels {
+		clusters[label] = append(clusters[label], i)
+	}
+	if len(clusters) < 2 {
+		return nil, fmt.Errorf("Samples must belong to at least 2 clusters, belong to: %d", len(clusters))
+	}
+	return clusters, nil
+}
+
+func euclideanDistance(tensor1 *tsr.Tensor, tensor2 *tsr.Tensor) (float64, error) {
+	euclidean, err := distance.Euclidean(tensor1, tensor2)
+	return float64(euclidean), err
+}
+
+func pairs(count int) float64 {
+	return float64(count) * float64(count-1) / 2
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func clusteringSamples() []*tsr.Tensor {
+	return []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{0, 0}),
+		tsr.NewValueTensor1D([]float32{0, 1}),
+		tsr.NewValueTensor1D([]float32{10, 0}),
+		tsr.NewValueTensor1D([]float32{10, 1}),
+	}
+}
+
+func TestSilhouetteScore(t *testing.T) {
+	samples := clusteringSamples()
+
+	score, err := SilhouetteScore(samples, []int{0, 0, 1, 1})
+	if err != nil {
+		t.Fatalf("Error in SilhouetteScore: %s", err.Error())
+	}
+	nearest := (10 + math.Sqrt(101)) / 2
+	solution := (nearest - 1) / nearest
+	if math.Abs(float64(score)-solution) > 1e-5 {
+		t.Errorf("Silhouette score should be %.5f, is: %.5f", solution, score)
+	}
+
+	wrong, _ := SilhouetteScore(samples, []int{0, 1, 0, 1})
+	if wrong >= 0 {
+		t.Errorf("Silhouette score of wrong clusters should be negative, is: %.5f", wrong)
+	}
+
+	_, err = SilhouetteScore(samples, []int{0, 0, 0, 0})
+	if err == nil {
+		t.Errorf("Did not trigger error on a single cluster")
+	}
+
+	mismatched := append(clusteringSamples(), tsr.NewValueTensor1D([]float32{10, 1, 0}))
+	_, err = SilhouetteScore(mismatched, []int{0, 0, 1, 1, 1})
+	if err == nil {
+		t.Errorf("Did not trigger error on samples of different dimensions")
+	}
+
+	duplicates := []*tsr.Tensor{tsr.NewValueTensor1D([]float32{1, 1}), tsr.NewValueTensor1D([]float32{1, 1})}
+	score, err = SilhouetteScore(append(duplicates, duplicates...), []int{0, 0, 1, 1})
+	if err != nil {
+		t.Fatalf("Error in SilhouetteScore: %s", err.Error())
+	}
+	if score != 0 {
+		t.Errorf("Silhouette score of coinciding clusters should be 0, is: %.5f", score)
+	}
+}
+
+func TestDaviesBouldinScore(t *testing.T) {
+	score, err := DaviesBouldinScore(clusteringSamples(), []int{0, 0, 1, 1})
+	if err != nil {
+		t.Fatalf("Error in DaviesBouldinScore: %s", err.Error())
+	}
+	if math.Abs(float64(score)-0.1) > 1e-5 {
+		t.Errorf("Davies-Bouldin score should be 0.1, is: %.5f", score)
+	}
+
+	_, err = DaviesBouldinScore(clusteringSamples(), []int{0, 1, 1, 0})
+	if err == nil {
+		t.Errorf("Did not trigger error on coinciding centroids")
+	}
+
+	mismatched := append(clusteringSamples(), tsr.NewValueTensor1D([]float32{10, 1, 0}))
+	_, err = DaviesBouldinScore(mismatched, []int{0, 0, 1, 1, 1})
+	if err == nil {
+		t.Errorf("Did not trigger error on samples of different dimensions")
+	}
+}
+
+func TestAdjustedRandIndex(t *testing.T) {
+	index, err := AdjustedRandIndex([]int{0, 0, 1, 1, 2, 2}, []int{5, 5, 3, 3, 4, 4})
+	if err != nil {
+		t.Fatalf("Error in AdjustedRandIndex: %s", err.Error())
+	}
+	if index != 1 {
+		t.Errorf("Adjusted Rand index of identical clusterings should be 1, is: %.5f", index)
+	}
+
+	index, _ = AdjustedRandIndex([]int{0, 0, 1, 1}, []int{0, 1, 0, 1})
+	if index >= 0 {
+		t.Errorf("Adjusted Rand index of disagreeing clusterings should be negative, is: %.5f", index)
+	}
+
+	_, err = AdjustedRandIndex([]int{0, 1}, []int{0})
+	if err == nil {
+		t.Errorf("Did not trigger error on mismatched number of labels")
+	}
+
+	_, err = AdjustedRandIndex([]int{0}, []int{0})
+	if err == nil {
+		t.Errorf("Did not trigger error on a single label")
+	}
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ConformalRegressor wraps the predictions of any regression model with intervals that contain the
+// targets of new samples with a chosen coverage. It is calibrated on held out samples that the
+// model was not trained on, and the coverage holds when new samples come from the same distribution
+// as those samples.
+type ConformalRegressor struct {
+	predict  PredictFunction
+	alpha    float32
+	quantile float32
+}
+
+// NewConformalRegressor creates a conformal regressor for a model, with intervals that miss the
+// targets for at most a fraction alpha of samples, such as 0.1 for a coverage of 90%.
+func NewConformalRegressor(predict PredictFunction, alpha float32) (*ConformalRegressor, error) {
+	if alpha <= 0 || alpha >= 1 {
+		return nil, fmt.Errorf("Alpha must be between 0 and 1, is: %f", alpha)
+	}
+	return &ConformalRegressor{predict: predict, alpha: alpha, quantile: float32(math.Inf(1))}, nil
+}
+
+// Calibrate computes the width of the intervals from the largest absolute error of the outputs of
+// the model for each held out sample.
+func (regressor *ConformalRegressor) Calibrate(samples [][]float32, targets [][]float32) error {
+	if len(samples) != len(targets) {
+		return fmt.Errorf("Number of samples and targets must match: %d != %d", len(samples), len(targets))
+	}
+	scores := make([]float32, len(samples))
+	for i, sample := range samples {
+		outputs, err := regressor.predict(sample)
+		if err != nil {
+			return err
+		}
+		if len(outputs) != len(targets[i]) {
+			return fmt.Errorf("Number of outputs and targets must match: %d != %d", len(outputs), len(targets[i]))
+		}
+		for j, output := range outputs {
+			scores[i] = float32(math.Max(float64(scores[i]), math.Abs(float64(targets[i][j]-output))))
+		}
+	}
+	quantile, err := conformalQuantile(scores, regressor.alpha)
+	if err != nil {
+		return err
+	}
+	regressor.quantile = quantile
+	return nil
+}
+
+// PredictInterval returns the lower and upper bounds of the interval of each output of the model
+// for a sample. The intervals are infinite until the regressor is calibrated, or when there are too
+// few calibration samples for the coverage.
+func (regressor *ConformalRegressor) PredictInterval(features []float32) ([]float32, []float32, error) {
+	outputs, err := regressor.predict(features)
+	if err != nil {
+		return nil, nil, err
+	}
+	lower := make([]float32, len(outputs))
+	upper := make([]float32, len(outputs))
+	for i, output := range outputs {
+		lower[i] = output - regressor.quantile
+		upper[i] = output + regressor.quantile
+	}
+	return lower, upper, nil
+}
+
+// ConformalClassifier wraps the predicted probabilities of any classification model with sets of
+// classes that contain the true class of new samples with a chosen coverage. Like a
+// ConformalRegressor, it is calibrated on held out samples.
+type ConformalClassifier struct {
+	predict  PredictFunction
+	alpha    float32
+	quantile float32
+}
+
+// NewConformalClassifier creates a conformal classifier for a model that outputs the probability of
+// each class, with sets that miss the true class for at most a fraction alpha of samples.
+func NewConformalClassifier(predict PredictFunction, alpha float32) (*ConformalClassifier, error) {
+	if alpha <= 0 || alpha >= 1 {
+		return nil, fmt.Errorf("Alpha must be between 0 and 1, is: %f", alpha)
+	}
+	return &ConformalClassifier{predict: predict, alpha: alpha, quantile: float32(math.Inf(1))}, nil
+}
+
+// Calibrate computes the threshold of the sets from 1 minus the probability that the model gives the
+// true class of each held out sample.
+func (classifier *ConformalClassifier) Calibrate(samples [][]float32, labels []int) error {
+	if len(samples) != len(labels) {
+		return fmt.Errorf("Number of samples and labels must match: %d != %d", len(samples), len(labels))
+	}
+	scores := make([]float32, len(samples))
+	for i, sample := range samples {
+		probabilities, err := classifier.predict(sample)
+		if err != nil {
+			return err
+		}
+		if labels[i] < 0 || labels[i] >= len(probabilities) {
+			return fmt.Errorf("Invalid label of sample %d: %d", i, labels[i])
+		}
+		scores[i] = 1 - probabilities[labels[i]]
+	}
+	quantile, err := conformalQuantile(scores, classifier.alpha)
+	if err != nil {
+		return err
+	}
+	classifier.quantile = quantile
+	return nil
+}
+
+// PredictSet returns the classes that are likely enough to be in the set for a sample, from the
+// most to the least likely. The set has every class until the classifier is calibrated.
+func (classifier *ConformalClassifier) PredictSet(features []float32) ([]int, error) {
+	probabilities, err := classifier.predict(features)
+	if err != nil {
+		return nil, err
+	}
+	classes := []int{}
+	for class, probability := range probabilities {
+		if 1-probability <= classifier.quantile {
+			classes = append(classes, class)
+		}
+	}
+	sort.SliceStable(classes, func(i int, j int) bool {
+		return probabilities[classes[i]] > probabilities[classes[j]]
+	})
+	return classes, nil
+}
+
+// conformalQuantile returns the score at the rank ceil((n + 1) * (1 - alpha)) of n calibration
+// scores, which is infinite when the rank is above n.
+func conformalQuantile(scores []float32, alpha float32) (float32, error) {
+	if len(scores) == 0 {
+		return 0, fmt.Errorf("Must have at least 1 calibration sample")
+	}
+	sorted := append([]float32(nil), scores...)
+	sort.Slice(sorted, func(i int, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	rank := int(math.Ceil(float64(float32(len(sorted)+1) * (1 - alpha))))
+	if rank > len(sorted) {
+		return float32(math.Inf(1)), nil
+	}
+	return sorted[rank-1], nil
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestConformalRegressor(t *testing.T) {
+	rand.Seed(1)
+	predict := func(features []float32) ([]float32, error) {
+		return []float32{2 * features[0]}, nil
+	}
+	sample := func() ([]float32, []float32) {
+		x := rand.Float32()
+		return []float32{x}, []float32{2*x + float32(rand.NormFloat64())}
+	}
+	regressor, err := NewConformalRegressor(predict, 0.1)
+	if err != nil {
+		t.Fatalf("Error in NewConformalRegressor: %s", err.Error())
+	}
+	lower, upper, _ := regressor.PredictInterval([]float32{0.5})
+	if !math.IsInf(float64(upper[0]-lower[0]), 1) {
+		t.Errorf("Interval should be infinite before calibrating, is: [%f, %f]", lower[0], upper[0])
+	}
+
+	samples := make([][]float32, 1000)
+	targets := make([][]float32, 1000)
+	for i := range samples {
+		samples[i], targets[i] = sample()
+	}
+	err = regressor.Calibrate(samples, targets)
+	if err != nil {
+		t.Fatalf("Error in Calibrate: %s", err.Error())
+	}
+
+	covered := 0
+	for i := 0; i < 2000; i++ {
+		features, target := sample()
+		lower, upper, err := regressor.PredictInterval(features)
+		if err != nil {
+			t.Fatalf("Error in PredictInterval: %s", err.Error())
+		}
+		if target[0] >= lower[0] && target[0] <= upper[0] {
+			covered++
+		}
+	}
+	coverage := float32(covered) / 2000
+	if coverage < 0.88 || coverage > 0.93 {
+		t.Errorf("Coverage should be close to 0.9, is: %f", coverage)
+	}
+
+	_, err = NewConformalRegressor(predict, 1)
+	if err == nil {
+		t.Errorf("Alpha of 1 should have an error")
+	}
+}
+
+func TestConformalClassifier(t *testing.T) {
+	predict := func(features []float32) ([]float32, error) {
+		return features, nil
+	}
+	classifier, _ := NewConformalClassifier(predict, 0.2)
+	samples := [][]float32{
+		{0.9, 0.1, 0}, {0.8, 0.2, 0}, {0.6, 0.4, 0}, {0.3, 0.7, 0},
+		{0.5, 0.5, 0}, {0.1, 0.2, 0.7}, {0.7, 0.3, 0}, {0.4, 0.6, 0}, {0.2, 0.8, 0},
+	}
+	labels := []int{0, 0, 0, 1, 1, 2, 0, 0, 1}
+	err := classifier.Calibrate(samples, labels)
+	if err != nil {
+		t.Fatalf("Error in Calibrate: %s", err.Error())
+	}
+	// The sorted scores are 0.1, 0.2, 0.2, 0.3, 0.3, 0.3, 0.4, 0.5, 0.6, and the rank is ceil(10 * 0.8) = 8.
+	if math.Abs(float64(classifier.quantile-0.5)) > 1e-6 {
+		t.Errorf("Quantile should be 0.5, is: %f", classifier.quantile)
+	}
+
+	classes, err := classifier.PredictSet([]float32{0.35, 0.6, 0.05})
+	if err != nil {
+		t.Fatalf("Error in PredictSet: %s", err.Error())
+	}
+	if len(classes) != 1 || classes[0] != 1 {
+		t.Errorf("Set should be [1], is: %v", classes)
+	}
+	classes, _ = classifier.PredictSet([]float32{0.5, 0.5, 0})
+	if len(classes) != 2 {
+		t.Errorf("Set should have 2 classes, has: %v", classes)
+	}
+
+	err = classifier.Calibrate(samples, []int{0, 0, 0, 1, 1, 3, 0, 0, 1})
+	if err == nil {
+		t.Errorf("Invalid label should have an error")
+	}
+}
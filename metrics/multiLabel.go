@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"fmt"
+
+	tsr "../tensor"
+)
+
+// HammingLoss computes the fraction of labels that are predicted wrong, where each value of a
+// prediction is the probability of a label and is predicted on when it is at least the threshold of
+// its label. A single threshold applies to every label, and targets are on when at least 0.5.
+func HammingLoss(predictions []*tsr.Tensor, targets []*tsr.Tensor, thresholds []float32) (float32, error) {
+	wrong := 0
+	total := 0
+	err := forEachLabel(predictions, targets, thresholds, func(sample int, predicted bool, actual bool) {
+		if predicted != actual {
+			wrong++
+		}
+		total++
+	})
+	if err != nil {
+		return 0, err
+	}
+	return float32(wrong) / float32(total), nil
+}
+
+// SubsetAccuracy computes the fraction of samples where every label is predicted right, with
+// labels and thresholds as in HammingLoss.
+func SubsetAccuracy(predictions []*tsr.Tensor, targets []*tsr.Tensor, thresholds []float32) (float32, error) {
+	correct := make([]bool, len(predictions))
+	for i := range correct {
+		correct[i] = true
+	}
+	err := forEachLabel(predictions, targets, thresholds, func(sample int, predicted bool, actual bool) {
+		if predicted != actual {
+			correct[sample] = false
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, isCorrect := range correct {
+		if isCorrect {
+			count++
+		}
+	}
+	return float32(count) / float32(len(predictions)), nil
+}
+
+func forEachLabel(predictions []*tsr.Tensor, targets []*tsr.Tensor, thresholds []float32, function func(int, bool, bool)) error {
+	err := checkSamples(predictions, targets)
+	if err != nil {
+		return err
+	}
+	labels := predictions[0].Frames * predictions[0].Rows * predictions[0].Cols
+	if len(thresholds) != 1 && len(thresholds) != labels {
+		return fmt.Errorf("Number of thresholds must be 1 or match labels: %d != %d", len(thresholds), labels)
+	}
+	for i, prediction := range predictions {
+		index := 0
+		var labelErr error
+		prediction.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			if index >= labels {
+				labelErr = fmt.Errorf("Samples must all have %d labels", labels)
+				return current
+			}
+			function(i, current >= thresholds[index%len(thresholds)], targets[i].Get(frame, row, col) >= 0.5)
+			index++
+			return current
+		})
+		if labelErr != nil {
+			return labelErr
+		}
+	}
+	return nil
+}
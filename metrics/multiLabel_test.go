@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestMultiLabelMetrics(t *testing.T) {
+	predictions := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{0.9, 0.2, 0.6}),
+		tsr.NewValueTensor1D([]float32{0.4, 0.8, 0.1}),
+		tsr.NewValueTensor1D([]float32{0.7, 0.3, 0.3}),
+	}
+	targets := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{1, 0, 1}),
+		tsr.NewValueTensor1D([]float32{1, 1, 0}),
+		tsr.NewValueTensor1D([]float32{1, 0, 1}),
+	}
+
+	loss, err := HammingLoss(predictions, targets, []float32{0.5})
+	if err != nil {
+		t.Fatalf("Error in HammingLoss: %s", err.Error())
+	}
+	if math.Abs(float64(loss)-2.0/9) > 1e-6 {
+		t.Errorf("Hamming loss should be %f, is: %f", 2.0/9, loss)
+	}
+	accuracy, err := SubsetAccuracy(predictions, targets, []float32{0.5})
+	if err != nil {
+		t.Fatalf("Error in SubsetAccuracy: %s", err.Error())
+	}
+	if math.Abs(float64(accuracy)-1.0/3) > 1e-6 {
+		t.Errorf("Subset accuracy should be %f, is: %f", 1.0/3, accuracy)
+	}
+
+	// Lower thresholds for the first and last labels get every sample right.
+	accuracy, _ = SubsetAccuracy(predictions, targets, []float32{0.35, 0.5, 0.25})
+	if accuracy != 1 {
+		t.Errorf("Subset accuracy with per-label thresholds should be 1, is: %f", accuracy)
+	}
+
+	_, err = HammingLoss(predictions, targets, []float32{0.5, 0.5})
+	if err == nil {
+		t.Errorf("Wrong number of thresholds should have an error")
+	}
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import "fmt"
+
+// PredictFunction computes the outputs of a model for a single sample of features.
+type PredictFunction func(features []float32) ([]float32, error)
+
+// PartialDependence computes the average outputs of a model over all samples when one feature is
+// set to each value of the grid, showing how the model responds to that feature on its own. The
+// result holds the averaged outputs for each grid value.
+func PartialDependence(predict PredictFunction, samples [][]float32, feature int, grid []float32) ([][]float32, error) {
+	err := checkFeatures(samples, feature)
+	if err != nil {
+		return nil, err
+	}
+	dependence := make([][]float32, len(grid))
+	for i, value := range grid {
+		dependence[i], err = averageResponse(predict, samples, map[int]float32{feature: value})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dependence, nil
+}
+
+// PartialDependence2D computes the average outputs of a model over all samples when two features
+// are set to every combination of values of their grids, showing how the features interact. The
+// result holds the averaged outputs for each value of the first grid, then each value of the
+// second grid.
+func PartialDependence2D(predict PredictFunction, samples [][]float32, feature1 int, feature2 int, grid1 []float32, grid2 []float32) ([][][]float32, error) {
+	err := checkFeatures(samples, feature1, feature2)
+	if err != nil {
+		return nil, err
+	}
+	dependence := make([][][]float32, len(grid1))
+	for i, value1 := range grid1 {
+		dependence[i] = make([][]float32, len(grid2))
+		for j, value2 := range grid2 {
+			dependence[i][j], err = averageResponse(predict, samples, map[int]float32{feature1: value1, feature2: value2})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return dependence, nil
+}
+
+// FeatureGrid creates a grid of evenly spaced values between the smallest and largest values of a
+// feature in the samples.
+func FeatureGrid(samples [][]float32, feature int, points int) ([]float32, error) {
+	err := checkFeatures(samples, feature)
+	if err != nil {
+		return nil, err
+	}
+	if points < 2 {
+		return nil, fmt.Errorf("Grid must have at least 2 points, has: %d", points)
+	}
+	min := samples[0][feature]
+	max := samples[0][feature]
+	for _, sample := range samples {
+		if sample[feature] < min {
+			min = sample[feature]
+		}
+		if sample[feature] > max {
+			max = sample[feature]
+		}
+	}
+	grid := make([]float32, points)
+	for i := range grid {
+		grid[i] = min + (max-min)*float32(i)/float32(points-1)
+	}
+	return grid, nil
+}
+
+func averageResponse(predict PredictFunction, samples [][]float32, values map[int]float32) ([]float32, error) {
+	var average []float32
+	features := make([]float32, len(samples[0]))
+	for _, sample := range samples {
+		copy(features, sample)
+		for feature, value := range values {
+			features[feature] = value
+		}
+		outputs, err := predict(features)
+		if err != nil {
+			return nil, err
+		}
+		if average == nil {
+			average = make([]float32, len(outputs))
+		}
+		for i, output := range outputs {
+			average[i] += output / float32(len(samples))
+		}
+	}
+	return average, nil
+}
+
+func checkFeatures(samples [][]float32, features ...int) error {
+	if len(samples) == 0 {
+		return fmt.Errorf("No samples to evaluate")
+	}
+	for _, feature := range features {
+		if feature < 0 || feature >= len(samples[0]) {
+			return fmt.Errorf("Feature out of bounds: %d", feature)
+		}
+	}
+	return nil
+}
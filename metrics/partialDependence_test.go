@@ -0,0 +1,54 @@
+package metrics
+
+import "testing"
+
+func TestPartialDependence(t *testing.T) {
+	predict := func(features []float32) ([]float32, error) {
+		return []float32{2*features[0] + features[0]*features[1]}, nil
+	}
+	samples := [][]float32{
+		{1, 0},
+		{2, 2},
+		{3, 4},
+	}
+
+	dependence, err := PartialDependence(predict, samples, 0, []float32{0, 1})
+	if err != nil {
+		t.Fatalf("Error in PartialDependence: %s", err.Error())
+	}
+	if dependence[0][0] != 0 || dependence[1][0] != 4 {
+		t.Errorf("Incorrect partial dependence: %v", dependence)
+	}
+
+	dependence2D, err := PartialDependence2D(predict, samples, 0, 1, []float32{1, 2}, []float32{0, 1})
+	if err != nil {
+		t.Fatalf("Error in PartialDependence2D: %s", err.Error())
+	}
+	solution := [][]float32{{2, 3}, {4, 6}}
+	for i := range solution {
+		for j := range solution[i] {
+			if dependence2D[i][j][0] != solution[i][j] {
+				t.Errorf("Incorrect partial dependence at (%d, %d): %.3f != %.3f", i, j, dependence2D[i][j][0], solution[i][j])
+			}
+		}
+	}
+
+	_, err = PartialDependence(predict, samples, 2, []float32{0})
+	if err == nil {
+		t.Errorf("Did not trigger error on feature out of bounds")
+	}
+}
+
+func TestFeatureGrid(t *testing.T) {
+	grid, err := FeatureGrid([][]float32{{3, 0}, {1, 0}, {2, 0}}, 0, 3)
+	if err != nil {
+		t.Fatalf("Error in FeatureGrid: %s", err.Error())
+	}
+	solution := []float32{1, 2, 3}
+	for i, value := range grid {
+		if value != solution[i] {
+			t.Errorf("Incorrect grid: %v != %v", grid, solution)
+			break
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PrecisionRecallPoint is the precision and recall of binary scores at a decision threshold.
+type PrecisionRecallPoint struct {
+	Threshold float32
+	Precision float32
+	Recall    float32
+}
+
+// PrecisionRecallCurve computes the precision and recall of binary scores at every distinct score
+// used as a threshold, where samples with scores at or above the threshold are predicted positive.
+// The points are ordered from the highest threshold to the lowest.
+func PrecisionRecallCurve(scores []float32, labels []bool) ([]PrecisionRecallPoint, error) {
+	if len(scores) != len(labels) {
+		return nil, fmt.Errorf("Number of scores and labels must match: %d != %d", len(scores), len(labels))
+	}
+	totalPositives := 0
+	for _, label := range labels {
+		if label {
+			totalPositives++
+		}
+	}
+	if totalPositives == 0 {
+		return nil, fmt.Errorf("Labels must contain at least one positive")
+	}
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i int, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+	curve := []PrecisionRecallPoint{}
+	truePositives := 0
+	for i, index := range order {
+		if labels[index] {
+			truePositives++
+		}
+		if i+1 < len(order) && scores[order[i+1]] == scores[index] {
+			continue
+		}
+		curve = append(curve, PrecisionRecallPoint{
+			Threshold: scores[index],
+			Precision: float32(truePositives) / float32(i+1),
+			Recall:    float32(truePositives) / float32(totalPositives),
+		})
+	}
+	return curve, nil
+}
+
+// AveragePrecision summarizes the precision-recall curve as the mean of the precisions at each
+// threshold, weighted by the increase in recall from the previous threshold.
+func AveragePrecision(scores []float32, labels []bool) (float32, error) {
+	curve, err := PrecisionRecallCurve(scores, labels)
+	if err != nil {
+		return 0, err
+	}
+	averagePrecision := float32(0.0)
+	previousRecall := float32(0.0)
+	for _, point := range curve {
+		averagePrecision += (point.Recall - previousRecall) * point.Precision
+		previousRecall = point.Recall
+	}
+	return averagePrecision, nil
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPrecisionRecallCurve(t *testing.T) {
+	scores := []float32{0.1, 0.4, 0.35, 0.8}
+	labels := []bool{false, false, true, true}
+
+	curve, err := PrecisionRecallCurve(scores, labels)
+	if err != nil {
+		t.Fatalf("Error in PrecisionRecallCurve: %s", err.Error())
+	}
+
+	solution := []PrecisionRecallPoint{
+		{Threshold: 0.8, Precision: 1, Recall: 0.5},
+		{Threshold: 0.4, Precision: 0.5, Recall: 0.5},
+		{Threshold: 0.35, Precision: 2.0 / 3, Recall: 1},
+		{Threshold: 0.1, Precision: 0.5, Recall: 1},
+	}
+	if len(curve) != len(solution) {
+		t.Fatalf("Curve should have %d points, has: %d", len(solution), len(curve))
+	}
+	for i, point := range curve {
+		if point != solution[i] {
+			t.Errorf("Incorrect point %d: %+v != %+v", i, point, solution[i])
+		}
+	}
+
+	_, err = PrecisionRecallCurve(scores, []bool{false, false, false, false})
+	if err == nil {
+		t.Errorf("Did not trigger error on labels without positives")
+	}
+}
+
+func TestPrecisionRecallCurveTies(t *testing.T) {
+	curve, err := PrecisionRecallCurve([]float32{0.5, 0.5, 0.2}, []bool{true, false, true})
+	if err != nil {
+		t.Fatalf("Error in PrecisionRecallCurve: %s", err.Error())
+	}
+	if len(curve) != 2 {
+		t.Fatalf("Tied scores should share one point, curve has: %d", len(curve))
+	}
+	if curve[0].Precision != 0.5 || curve[0].Recall != 0.5 {
+		t.Errorf("Incorrect point for tied scores: %+v", curve[0])
+	}
+}
+
+func TestAveragePrecision(t *testing.T) {
+	scores := []float32{0.1, 0.4, 0.35, 0.8}
+	labels := []bool{false, false, true, true}
+
+	averagePrecision, err := AveragePrecision(scores, labels)
+	if err != nil {
+		t.Fatalf("Error in AveragePrecision: %s", err.Error())
+	}
+	solution := 0.5*1 + 0.5*(2.0/3)
+	if math.Abs(float64(averagePrecision)-solution) > 1e-5 {
+		t.Errorf("Average precision should be %.5f, is: %.5f", solution, averagePrecision)
+	}
+
+	perfect, _ := AveragePrecision([]float32{0.9, 0.8, 0.1}, []bool{true, true, false})
+	if perfect != 1 {
+		t.Errorf("Average precision of perfect ranking should be 1, is: %.5f", perfect)
+	}
+}
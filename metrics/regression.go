@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+
+	tsr "../tensor"
+)
+
+// MeanAbsoluteError computes the absolute difference between the predictions and targets averaged
+// over every value of every sample.
+func MeanAbsoluteError(predictions []*tsr.Tensor, targets []*tsr.Tensor) (float32, error) {
+	err := checkSamples(predictions, targets)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	count := 0
+	forEachValue(predictions, targets, func(index int, predicted float64, actual float64) {
+		total += math.Abs(predicted - actual)
+		count++
+	})
+	return float32(total / float64(count)), nil
+}
+
+// RootMeanSquaredError computes the square root of the squared difference between the predictions
+// and targets averaged over every value of every sample.
+func RootMeanSquaredError(predictions []*tsr.Tensor, targets []*tsr.Tensor) (float32, error) {
+	err := checkSamples(predictions, targets)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	count := 0
+	forEachValue(predictions, targets, func(index int, predicted float64, actual float64) {
+		total += (predicted - actual) * (predicted - actual)
+		count++
+	})
+	return float32(math.Sqrt(total / float64(count))), nil
+}
+
+// R2Score computes the coefficient of determination, which is 1 minus the squared error of the
+// predictions divided by the variance of the targets. A score of 1 is a perfect fit, while 0 is no
+// better than predicting the mean of the targets. With several outputs, the score of each output
+// is averaged. Outputs with constant targets have a score of 1 if predicted exactly and 0 otherwise.
+func R2Score(predictions []*tsr.Tensor, targets []*tsr.Tensor) (float32, error) {
+	err := checkSamples(predictions, targets)
+	if err != nil {
+		return 0, err
+	}
+	size := predictions[0].Frames * predictions[0].Rows * predictions[0].Cols
+	for _, prediction := range predictions {
+		if prediction.Frames*prediction.Rows*prediction.Cols != size {
+			return 0, fmt.Errorf("Samples must all have %d values, has: %d", size, prediction.Frames*prediction.Rows*prediction.Cols)
+		}
+	}
+	means := make([]float64, size)
+	forEachValue(predictions, targets, func(index int, predicted float64, actual float64) {
+		means[index] += actual / float64(len(targets))
+	})
+	residuals := make([]float64, size)
+	variances := make([]float64, size)
+	forEachValue(predictions, targets, func(index int, predicted float64, actual float64) {
+		residuals[index] += (actual - predicted) * (actual - predicted)
+		variances[index] += (actual - means[index]) * (actual - means[index])
+	})
+	total := 0.0
+	for i := range residuals {
+		if variances[i] > 0 {
+			total += 1 - residuals[i]/variances[i]
+		} else if residuals[i] == 0 {
+			total++
+		}
+	}
+	return float32(total / float64(size)), nil
+}
+
+// forEachValue calls a function with the index of each value of the samples, counting across the
+// frames, rows and columns in order, along with the predicted and actual value.
+func forEachValue(predictions []*tsr.Tensor, targets []*tsr.Tensor, function func(int, float64, float64)) {
+	for i, prediction := range predictions {
+		index := 0
+		for frame := 0; frame < prediction.Frames; frame++ {
+			for row := 0; row < prediction.Rows; row++ {
+				for col := 0; col < prediction.Cols; col++ {
+					function(index, float64(prediction.Get(frame, row, col)), float64(targets[i].Get(frame, row, col)))
+					index++
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestRegressionMetrics(t *testing.T) {
+	predictions := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{2.5}),
+		tsr.NewValueTensor1D([]float32{0}),
+		tsr.NewValueTensor1D([]float32{2}),
+		tsr.NewValueTensor1D([]float32{8}),
+	}
+	targets := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{3}),
+		tsr.NewValueTensor1D([]float32{-0.5}),
+		tsr.NewValueTensor1D([]float32{2}),
+		tsr.NewValueTensor1D([]float32{7}),
+	}
+
+	mae, err := MeanAbsoluteError(predictions, targets)
+	if err != nil {
+		t.Fatalf("Error in MeanAbsoluteError: %s", err.Error())
+	}
+	if math.Abs(float64(mae)-0.5) > 1e-6 {
+		t.Errorf("Mean absolute error should be 0.5, is: %f", mae)
+	}
+
+	rmse, err := RootMeanSquaredError(predictions, targets)
+	if err != nil {
+		t.Fatalf("Error in RootMeanSquaredError: %s", err.Error())
+	}
+	if math.Abs(float64(rmse)-math.Sqrt(0.375)) > 1e-6 {
+		t.Errorf("Root mean squared error should be %f, is: %f", math.Sqrt(0.375), rmse)
+	}
+
+	r2, err := R2Score(predictions, targets)
+	if err != nil {
+		t.Fatalf("Error in R2Score: %s", err.Error())
+	}
+	if math.Abs(float64(r2)-0.948608) > 1e-5 {
+		t.Errorf("R2 score should be 0.948608, is: %f", r2)
+	}
+
+	_, err = R2Score(predictions, targets[:2])
+	if err == nil {
+		t.Errorf("Mismatched predictions and targets should have an error")
+	}
+}
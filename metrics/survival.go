@@ -0,0 +1,36 @@
+package metrics
+
+import "fmt"
+
+// ConcordanceIndex computes Harrell's concordance index of risk scores for time to event data,
+// with the time of each sample and whether its event was observed or the sample was censored at
+// that time. It is the fraction of comparable pairs, where one sample has an observed event before
+// the time of the other, in which the earlier sample has the higher risk. Ties in risk count as
+// half. An index of 1 orders every pair correctly, while 0.5 is no better than chance.
+func ConcordanceIndex(risks []float32, times []float32, events []bool) (float32, error) {
+	if len(risks) != len(times) || len(risks) != len(events) {
+		return 0, fmt.Errorf("Number of risks, times and events must match: %d, %d, %d", len(risks), len(times), len(events))
+	}
+	concordant := 0.0
+	comparable := 0
+	for i := range risks {
+		if !events[i] {
+			continue
+		}
+		for j := range risks {
+			if times[i] >= times[j] {
+				continue
+			}
+			comparable++
+			if risks[i] > risks[j] {
+				concordant++
+			} else if risks[i] == risks[j] {
+				concordant += 0.5
+			}
+		}
+	}
+	if comparable == 0 {
+		return 0, fmt.Errorf("No comparable pairs of samples")
+	}
+	return float32(concordant / float64(comparable)), nil
+}
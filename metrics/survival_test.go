@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConcordanceIndex(t *testing.T) {
+	risks := []float32{0.9, 0.5, 0.7, 0.1}
+	times := []float32{1, 2, 3, 4}
+	events := []bool{true, true, false, true}
+
+	// The comparable pairs are (0, 1), (0, 2), (0, 3), (1, 2) and (1, 3), and only (1, 2) is discordant.
+	index, err := ConcordanceIndex(risks, times, events)
+	if err != nil {
+		t.Fatalf("Error in ConcordanceIndex: %s", err.Error())
+	}
+	if math.Abs(float64(index-0.8)) > 1e-6 {
+		t.Errorf("Concordance index should be 0.8, is: %f", index)
+	}
+
+	_, err = ConcordanceIndex(risks, times, []bool{false, false, false, false})
+	if err == nil {
+		t.Errorf("Samples without events should have an error")
+	}
+}
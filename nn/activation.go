@@ -1,14 +1,19 @@
 package nn
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
 
 	tsr "../tensor"
 )
 
-// ActivationFunction represents a function used to activate neural network outputs.
+// ActivationFunction represents a function used to activate neural network outputs. Activation
+// functions with settings, such as the slope of a leaky rectified linear unit, keep them in their
+// parameters, so they can be saved along with the layer.
 type ActivationFunction struct {
 	Type       ActivationType
+	Parameters map[string]float32
 	Function   func(*tsr.Tensor) *tsr.Tensor
 	Derivative func(*tsr.Tensor) *tsr.Tensor
 }
@@ -28,6 +33,12 @@ const (
 
 	// ActivationTypeSoftmax is the type for a soft max activation function.
 	ActivationTypeSoftmax = ActivationType("softmax")
+
+	// ActivationTypeLinear is the type for a linear activation function.
+	ActivationTypeLinear = ActivationType("linear")
+
+	// ActivationTypeLeakyRELU is the type for a leaky rectified linear unit activation function.
+	ActivationTypeLeakyRELU = ActivationType("leakyRelu")
 )
 
 // ActivationRELU is the rectified linear unit activation function.
@@ -87,50 +98,168 @@ var ActivationTanh = ActivationFunction{
 	},
 }
 
-// ActivationSoftmax is the softmax activation function.
+// ActivationSoftmax is the softmax activation function. The largest value is subtracted before
+// taking exponents, which gives the same result without overflowing. The derivative is only the
+// diagonal of the Jacobian, since layers multiply the derivative by their deltas value by value, so
+// a SoftmaxLayer should be used where the exact gradient is needed.
 var ActivationSoftmax = ActivationFunction{
 	Type: ActivationTypeSoftmax,
 	Function: func(matrix *tsr.Tensor) *tsr.Tensor {
-		matrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
-			return float32(math.Exp(float64(current)))
-		})
-		sum := matrix.Sum()
-		matrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
-			return current / sum
-		})
-		return matrix
+		return softmax(matrix, 1)
 	},
 	Derivative: func(matrix *tsr.Tensor) *tsr.Tensor {
-		newMatrix := matrix.Copy()
-		newMatrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
-			sum := float32(0.0)
-			for f := 0; f < matrix.Frames; f++ {
-				for r := 0; r < matrix.Rows; r++ {
-					for c := 0; c < matrix.Cols; c++ {
-						if col == c {
-							sum += matrix.Get(f, r, c) * (1 - current)
-						}
-						sum += matrix.Get(f, r, c) * -current
-					}
+		return softmaxDerivative(matrix, 1)
+	},
+}
+
+// NewSoftmaxActivation creates a softmax activation function that divides the values by a
+// temperature first. Temperatures above 1 spread the probabilities out, while temperatures below 1
+// sharpen them. The temperature must be positive.
+func NewSoftmaxActivation(temperature float32) (ActivationFunction, error) {
+	if !(temperature > 0) || math.IsInf(float64(temperature), 1) {
+		return ActivationFunction{}, fmt.Errorf("Temperature of softmax must be positive, is: %g", temperature)
+	}
+	return ActivationFunction{
+		Type:       ActivationTypeSoftmax,
+		Parameters: map[string]float32{"temperature": temperature},
+		Function: func(matrix *tsr.Tensor) *tsr.Tensor {
+			return softmax(matrix, temperature)
+		},
+		Derivative: func(matrix *tsr.Tensor) *tsr.Tensor {
+			return softmaxDerivative(matrix, temperature)
+		},
+	}, nil
+}
+
+func softmax(matrix *tsr.Tensor, temperature float32) *tsr.Tensor {
+	max := float32(math.Inf(-1))
+	matrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		if current > max {
+			max = current
+		}
+		return current
+	})
+	matrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return float32(math.Exp(float64((current - max) / temperature)))
+	})
+	sum := matrix.Sum()
+	matrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return current / sum
+	})
+	return matrix
+}
+
+func softmaxDerivative(matrix *tsr.Tensor, temperature float32) *tsr.Tensor {
+	matrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return current * (1 - current) / temperature
+	})
+	return matrix
+}
+
+// NewLeakyRELUActivation creates a leaky rectified linear unit activation function, which scales
+// negative values by a small slope instead of setting them to 0, so their gradient does not vanish.
+// The slope must be positive.
+func NewLeakyRELUActivation(alpha float32) (ActivationFunction, error) {
+	if !(alpha > 0) || math.IsInf(float64(alpha), 1) {
+		return ActivationFunction{}, fmt.Errorf("Slope of leaky RELU must be positive, is: %g", alpha)
+	}
+	return ActivationFunction{
+		Type:       ActivationTypeLeakyRELU,
+		Parameters: map[string]float32{"alpha": alpha},
+		Function: func(matrix *tsr.Tensor) *tsr.Tensor {
+			matrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+				if current > 0 {
+					return current
 				}
-			}
-			return sum
+				return alpha * current
+			})
+			return matrix
+		},
+		Derivative: func(matrix *tsr.Tensor) *tsr.Tensor {
+			matrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+				if current > 0 {
+					return 1
+				}
+				return alpha
+			})
+			return matrix
+		},
+	}, nil
+}
+
+// ActivationLinear is the linear activation function, which leaves the values unchanged, such as
+// for the scores given to a SoftmaxLayer.
+var ActivationLinear = ActivationFunction{
+	Type: ActivationTypeLinear,
+	Function: func(matrix *tsr.Tensor) *tsr.Tensor {
+		return matrix
+	},
+	Derivative: func(matrix *tsr.Tensor) *tsr.Tensor {
+		matrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			return 1
 		})
-		return newMatrix
+		return matrix
 	},
 }
 
-func activationFunctionOfType(activationType ActivationType) ActivationFunction {
+// ActivationData represents a serialized activation function with parameters.
+type ActivationData struct {
+	Type       ActivationType     `json:"type"`
+	Parameters map[string]float32 `json:"parameters"`
+}
+
+// MarshalJSON converts the activation function to JSON. Activation functions without parameters
+// are saved as just their type.
+func (activation ActivationFunction) MarshalJSON() ([]byte, error) {
+	if len(activation.Parameters) == 0 {
+		return json.Marshal(activation.Type)
+	}
+	return json.Marshal(ActivationData{Type: activation.Type, Parameters: activation.Parameters})
+}
+
+// UnmarshalJSON creates an activation function from JSON, either from just its type or from its
+// type and parameters. An unknown type or invalid parameters produce an error.
+func (activation *ActivationFunction) UnmarshalJSON(b []byte) error {
+	data := ActivationData{}
+	err := json.Unmarshal(b, &data.Type)
+	if err != nil {
+		err = json.Unmarshal(b, &data)
+		if err != nil {
+			return err
+		}
+	}
+	*activation, err = activationFunctionOf(data.Type, data.Parameters)
+	return err
+}
+
+func activationFunctionOf(activationType ActivationType, parameters map[string]float32) (ActivationFunction, error) {
+	switch activationType {
+	case ActivationTypeSoftmax:
+		if temperature, ok := parameters["temperature"]; ok {
+			return NewSoftmaxActivation(temperature)
+		}
+	case ActivationTypeLeakyRELU:
+		if alpha, ok := parameters["alpha"]; ok {
+			return NewLeakyRELUActivation(alpha)
+		}
+		return NewLeakyRELUActivation(0.01)
+	}
+	return activationFunctionOfType(activationType)
+}
+
+func activationFunctionOfType(activationType ActivationType) (ActivationFunction, error) {
 	switch activationType {
 	case ActivationTypeRELU:
-		return ActivationRELU
+		return ActivationRELU, nil
 	case ActivationTypeSigmoid:
-		return ActivationSigmoid
+		return ActivationSigmoid, nil
 	case ActivationTypeTanh:
-		return ActivationTanh
+		return ActivationTanh, nil
 	case ActivationTypeSoftmax:
-		return ActivationSoftmax
+		return ActivationSoftmax, nil
+	case ActivationTypeLinear:
+		return ActivationLinear, nil
 	default:
-		return ActivationRELU
+		return ActivationFunction{}, fmt.Errorf("Unsupported activation type: %q", activationType)
 	}
 }
@@ -0,0 +1,103 @@
+package nn
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestActivationLeakyRELU(t *testing.T) {
+	activation, err := NewLeakyRELUActivation(0.1)
+	if err != nil {
+		t.Fatalf("Error in NewLeakyRELUActivation: %s", err.Error())
+	}
+	result := activation.Function(tsr.NewValueTensor1D([]float32{-2, 0.5, 3}))
+	solution := tsr.NewValueTensor1D([]float32{-0.2, 0.5, 3})
+	if !result.Equals(solution) {
+		t.Errorf("Leaky RELU should be:\n%swhen result is:\n%s", solution.String(), result.String())
+	}
+	derivative := activation.Derivative(result)
+	solution = tsr.NewValueTensor1D([]float32{0.1, 1, 1})
+	if !derivative.Equals(solution) {
+		t.Errorf("Leaky RELU derivative should be:\n%swhen result is:\n%s", solution.String(), derivative.String())
+	}
+}
+
+func TestActivationSoftmaxTemperature(t *testing.T) {
+	activation, err := NewSoftmaxActivation(2)
+	if err != nil {
+		t.Fatalf("Error in NewSoftmaxActivation: %s", err.Error())
+	}
+	result := activation.Function(tsr.NewValueTensor1D([]float32{0, float32(2 * math.Log(3))}))
+	solution := []float32{0.25, 0.75}
+	for col, value := range solution {
+		if math.Abs(float64(result.Get(0, 0, col)-value)) > 1e-5 {
+			t.Errorf("Softmax output %d should be: %.4f, is: %.4f", col, value, result.Get(0, 0, col))
+		}
+	}
+}
+
+func TestActivationJSON(t *testing.T) {
+	leakyRELU, _ := NewLeakyRELUActivation(0.2)
+	layer := NewDenseLayer(2, 3, leakyRELU)
+	data, err := json.Marshal(layer)
+	if err != nil {
+		t.Fatalf("Error in MarshalJSON: %s", err.Error())
+	}
+	loaded := &DenseLayer{}
+	err = json.Unmarshal(data, loaded)
+	if err != nil {
+		t.Fatalf("Error in UnmarshalJSON: %s", err.Error())
+	}
+	if loaded.Activation.Type != ActivationTypeLeakyRELU || loaded.Activation.Parameters["alpha"] != 0.2 {
+		t.Errorf("Loaded activation should be leaky RELU with alpha 0.2, is: %s %v", loaded.Activation.Type, loaded.Activation.Parameters)
+	}
+	result := loaded.Activation.Function(tsr.NewValueTensor1D([]float32{-1}))
+	if result.Get(0, 0, 0) != -0.2 {
+		t.Errorf("Loaded activation of -1 should be: -0.2, is: %.4f", result.Get(0, 0, 0))
+	}
+
+	// Activation functions without parameters are saved as just their type, like before.
+	activation := ActivationFunction{}
+	err = json.Unmarshal([]byte(`"sigmoid"`), &activation)
+	if err != nil {
+		t.Fatalf("Error in UnmarshalJSON: %s", err.Error())
+	}
+	if activation.Type != ActivationTypeSigmoid {
+		t.Errorf("Loaded activation should be: sigmoid, is: %s", activation.Type)
+	}
+	data, _ = json.Marshal(ActivationTanh)
+	if string(data) != `"tanh"` {
+		t.Errorf("Saved activation should be: \"tanh\", is: %s", string(data))
+	}
+}
+
+func TestActivationInvalid(t *testing.T) {
+	for _, temperature := range []float32{0, -1, float32(math.NaN()), float32(math.Inf(1))} {
+		_, err := NewSoftmaxActivation(temperature)
+		if err == nil {
+			t.Errorf("Softmax temperature of %g did not trigger error", temperature)
+		}
+	}
+	for _, alpha := range []float32{0, -0.1, float32(math.NaN())} {
+		_, err := NewLeakyRELUActivation(alpha)
+		if err == nil {
+			t.Errorf("Leaky RELU slope of %g did not trigger error", alpha)
+		}
+	}
+	activations := []string{
+		`""`,
+		`"swish"`,
+		`{"type":"softmax","parameters":{"temperature":0}}`,
+		`{"type":"leakyRelu","parameters":{"alpha":-1}}`,
+	}
+	for _, data := range activations {
+		activation := ActivationFunction{}
+		err := json.Unmarshal([]byte(data), &activation)
+		if err == nil {
+			t.Errorf("Loading activation %s did not trigger error", data)
+		}
+	}
+}
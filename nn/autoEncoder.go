@@ -2,17 +2,27 @@ package nn
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"math/rand"
 	"os"
 
 	tsr "../tensor"
 )
 
-// AutoEncoder is a neural network that trains against its own inputs to encode features.
+// AutoEncoder is a neural network that trains against its own inputs to encode features. It can
+// train as a sparse auto encoder, a denoising auto encoder or both, which helps it learn useful
+// features when its coding layers are not smaller than its inputs.
 type AutoEncoder struct {
 	inputSize      int
 	encodingLayers []*DenseLayer
 	decodingLayers []*DenseLayer
+	sparse         bool
+	dropFraction   float32
+	sparsityTarget float32
+	noiseStdDev    float32
+	averages       []*tsr.Tensor
+	masks          map[*DenseLayer]*tsr.Tensor
 }
 
 // NewAutoEncoder Creates a new instance of an AutoEncoder.
@@ -24,18 +34,49 @@ func NewAutoEncoder(inputSize int) *AutoEncoder {
 	}
 }
 
-// Copy creates a deep copy of the auto encoder.
+// Copy creates a deep copy of the auto encoder. Decoding layers with tied weights are tied to the
+// copies of their encoding layers.
 func (autoEncoder *AutoEncoder) Copy() *AutoEncoder {
 	newAutoEncoder := NewAutoEncoder(autoEncoder.inputSize)
+	copies := map[*DenseLayer]*DenseLayer{}
 	for _, layer := range autoEncoder.encodingLayers {
-		newAutoEncoder.encodingLayers = append(newAutoEncoder.encodingLayers, layer)
+		newLayer := layer.Copy().(*DenseLayer)
+		copies[layer] = newLayer
+		newAutoEncoder.encodingLayers = append(newAutoEncoder.encodingLayers, newLayer)
 	}
 	for _, layer := range autoEncoder.decodingLayers {
-		newAutoEncoder.decodingLayers = append(newAutoEncoder.decodingLayers, layer)
+		newLayer := layer.Copy().(*DenseLayer)
+		if tied, ok := copies[layer.tied]; ok {
+			newLayer.tied = tied
+		}
+		newAutoEncoder.decodingLayers = append(newAutoEncoder.decodingLayers, newLayer)
 	}
+	newAutoEncoder.sparse = autoEncoder.sparse
+	newAutoEncoder.dropFraction = autoEncoder.dropFraction
+	newAutoEncoder.sparsityTarget = autoEncoder.sparsityTarget
+	newAutoEncoder.noiseStdDev = autoEncoder.noiseStdDev
 	return newAutoEncoder
 }
 
+// WithSparsity trains the auto encoder to be sparse. While training, each output of the coding
+// layers is set to 0 with the probability of the drop fraction, and the others are scaled up to
+// make up for it, as with dropout. The average of each output of the encoding layers over the
+// recent samples is also pushed towards a target, by adding the difference between them to the
+// gradient of the output, so most features stay close to a small target for any one input.
+func (autoEncoder *AutoEncoder) WithSparsity(dropFraction float32, target float32) *AutoEncoder {
+	autoEncoder.sparse = true
+	autoEncoder.dropFraction = dropFraction
+	autoEncoder.sparsityTarget = target
+	return autoEncoder
+}
+
+// WithDenoising trains the auto encoder to remove noise, by adding normally distributed noise with
+// a standard deviation to the inputs while training, while still reproducing the clean inputs.
+func (autoEncoder *AutoEncoder) WithDenoising(noiseStdDev float32) *AutoEncoder {
+	autoEncoder.noiseStdDev = noiseStdDev
+	return autoEncoder
+}
+
 // LayerCount returns the number of layers in the neural network.
 func (autoEncoder *AutoEncoder) LayerCount() int {
 	return len(autoEncoder.encodingLayers) + len(autoEncoder.decodingLayers)
@@ -51,6 +92,17 @@ func (autoEncoder *AutoEncoder) LayerAt(index int) Layer {
 
 // AddCodingLayer adds an intermediate layer of features to the auto encoder.
 func (autoEncoder *AutoEncoder) AddCodingLayer(coded int, activation ActivationFunction) error {
+	return autoEncoder.addCodingLayer(coded, activation, false)
+}
+
+// AddTiedCodingLayer adds an intermediate layer of features to the auto encoder, whose decoding
+// layer uses the transpose of the weights of its encoding layer rather than learning its own, which
+// halves the number of weights to learn.
+func (autoEncoder *AutoEncoder) AddTiedCodingLayer(coded int, activation ActivationFunction) error {
+	return autoEncoder.addCodingLayer(coded, activation, true)
+}
+
+func (autoEncoder *AutoEncoder) addCodingLayer(coded int, activation ActivationFunction, tied bool) error {
 	var inputSize int
 	if len(autoEncoder.encodingLayers) > 0 {
 		inputSize = autoEncoder.encodingLayers[len(autoEncoder.encodingLayers)-1].OutputShape().Cols
@@ -59,6 +111,12 @@ func (autoEncoder *AutoEncoder) AddCodingLayer(coded int, activation ActivationF
 	}
 	encodingLayer := NewDenseLayer(inputSize, coded, activation)
 	decodingLayer := NewDenseLayer(coded, inputSize, activation)
+	if tied {
+		err := decodingLayer.tieWeights(encodingLayer)
+		if err != nil {
+			return err
+		}
+	}
 	autoEncoder.encodingLayers = append(autoEncoder.encodingLayers, encodingLayer)
 	autoEncoder.decodingLayers = append(autoEncoder.decodingLayers, nil)
 	copy(autoEncoder.decodingLayers[1:], autoEncoder.decodingLayers)
@@ -66,20 +124,6 @@ func (autoEncoder *AutoEncoder) AddCodingLayer(coded int, activation ActivationF
 	return nil
 }
 
-func (autoEncoder *AutoEncoder) isSparse() bool {
-	for _, layer := range autoEncoder.encodingLayers {
-		if layer.OutputShape().Cols >= autoEncoder.inputSize {
-			return true
-		}
-	}
-	for _, layer := range autoEncoder.decodingLayers {
-		if layer.OutputShape().Cols >= autoEncoder.inputSize {
-			return true
-		}
-	}
-	return false
-}
-
 // Encode generates an encoded representation for a certain set of inputs.
 func (autoEncoder *AutoEncoder) Encode(inputs []float32) ([]float32, error) {
 	inputsTensor := tsr.NewValueTensor1D(inputs)
@@ -108,24 +152,38 @@ func (autoEncoder *AutoEncoder) Decode(coded []float32) ([]float32, error) {
 	return outputs, nil
 }
 
-// Train takes a set of inputs and their respective targets, and adjusts the layers to produce the
-// given outputs through supervised learning.
-func (autoEncoder *AutoEncoder) Train(inputs []float32, learningRate float32, momentum float32) error {
+// Train takes a set of inputs and adjusts the layers to reproduce them through their encoding,
+// using an optimizer to update the parameters.
+func (autoEncoder *AutoEncoder) Train(inputs []float32, optimizer Optimizer) error {
+	if autoEncoder.dropFraction < 0 || autoEncoder.dropFraction >= 1 {
+		return fmt.Errorf("Drop fraction must be at least 0 and less than 1, is: %f", autoEncoder.dropFraction)
+	}
+	if autoEncoder.noiseStdDev < 0 {
+		return fmt.Errorf("Standard deviation of noise must not be negative, is: %f", autoEncoder.noiseStdDev)
+	}
 	inputsTensor := tsr.NewValueTensor1D(inputs)
+	if autoEncoder.noiseStdDev > 0 {
+		inputsTensor.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			return current + float32(rand.NormFloat64())*autoEncoder.noiseStdDev
+		})
+	}
+	autoEncoder.masks = nil
 	coded, err := autoEncoder.feedForward(inputsTensor, autoEncoder.encodingLayers, true)
 	if err != nil {
 		return err
 	}
+	if autoEncoder.sparse {
+		autoEncoder.updateAverages()
+	}
 	outputs, err := autoEncoder.feedForward(coded, autoEncoder.decodingLayers, true)
 	if err != nil {
 		return err
 	}
-	deltas := tsr.NewValueTensor1D(inputs)
-	err = deltas.SubtractTensor(outputs)
+	deltas, err := LossMSE.Derivative(outputs, tsr.NewValueTensor1D(inputs))
 	if err != nil {
 		return err
 	}
-	return autoEncoder.backPropagate(deltas, learningRate, momentum)
+	return autoEncoder.backPropagate(deltas, optimizer)
 }
 
 func (autoEncoder *AutoEncoder) feedForward(inputs *tsr.Tensor, layers []*DenseLayer, train bool) (*tsr.Tensor, error) {
@@ -136,17 +194,50 @@ func (autoEncoder *AutoEncoder) feedForward(inputs *tsr.Tensor, layers []*DenseL
 		if err != nil {
 			return nil, err
 		}
-		if train && autoEncoder.isSparse() {
-			for i := 0; i < nextInputs.Cols/2; i++ {
-				col := rand.Intn(nextInputs.Cols)
-				nextInputs.Set(0, 0, col, 0.0)
+		// The outputs of the last decoding layer are the reconstructed inputs, so they are not
+		// dropped.
+		isOutput := layer == autoEncoder.decodingLayers[len(autoEncoder.decodingLayers)-1]
+		if train && autoEncoder.dropFraction > 0 && !isOutput {
+			mask := tsr.NewEmptyTensor3D(nextInputs.Frames, nextInputs.Rows, nextInputs.Cols)
+			scale := 1 / (1 - autoEncoder.dropFraction)
+			mask.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+				if rand.Float32() < autoEncoder.dropFraction {
+					return 0
+				}
+				return scale
+			})
+			nextInputs = nextInputs.Copy()
+			err = nextInputs.ScaleTensor(mask)
+			if err != nil {
+				return nil, err
+			}
+			if autoEncoder.masks == nil {
+				autoEncoder.masks = map[*DenseLayer]*tsr.Tensor{}
 			}
+			autoEncoder.masks[layer] = mask
 		}
 	}
 	return nextInputs, nil
 }
 
-func (autoEncoder *AutoEncoder) backPropagate(deltas *tsr.Tensor, learningRate float32, momentum float32) error {
+// updateAverages updates the average of each output of the encoding layers over recent samples,
+// which decays by 0.9 for each sample.
+func (autoEncoder *AutoEncoder) updateAverages() {
+	if len(autoEncoder.averages) != len(autoEncoder.encodingLayers) {
+		autoEncoder.averages = make([]*tsr.Tensor, len(autoEncoder.encodingLayers))
+	}
+	for i, layer := range autoEncoder.encodingLayers {
+		if autoEncoder.averages[i] == nil || autoEncoder.averages[i].Cols != layer.outputs.Cols {
+			autoEncoder.averages[i] = layer.outputs.Copy()
+			continue
+		}
+		autoEncoder.averages[i].ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			return 0.9*current + 0.1*layer.outputs.Get(frame, row, col)
+		})
+	}
+}
+
+func (autoEncoder *AutoEncoder) backPropagate(deltas *tsr.Tensor, optimizer Optimizer) error {
 	nextDeltas := deltas
 	var err error
 	for i := autoEncoder.LayerCount() - 1; i >= 0; i-- {
@@ -156,7 +247,35 @@ func (autoEncoder *AutoEncoder) backPropagate(deltas *tsr.Tensor, learningRate f
 		} else {
 			layer = autoEncoder.decodingLayers[i-len(autoEncoder.encodingLayers)]
 		}
-		nextDeltas, err = layer.BackPropagate(nextDeltas, learningRate, momentum)
+		if mask, ok := autoEncoder.masks[layer]; ok {
+			nextDeltas = nextDeltas.Copy()
+			err = nextDeltas.ScaleTensor(mask)
+			if err != nil {
+				return err
+			}
+		}
+		if autoEncoder.sparse && i < len(autoEncoder.encodingLayers) {
+			average := autoEncoder.averages[i]
+			nextDeltas = nextDeltas.Copy()
+			nextDeltas.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+				return current + average.Get(frame, row, col) - autoEncoder.sparsityTarget
+			})
+		}
+		nextDeltas, err = layer.BackPropagate(nextDeltas)
+		if err != nil {
+			return err
+		}
+		for j, parameter := range layer.Parameters() {
+			err = optimizer.Update(parameter, layer.Gradients()[j], 1)
+			if err != nil {
+				return err
+			}
+			layer.Gradients()[j].Scale(0)
+		}
+	}
+	// The encoding layers are updated last, so the decoding layers tied to them are updated after.
+	for _, layer := range autoEncoder.decodingLayers {
+		err = layer.syncTiedWeights()
 		if err != nil {
 			return err
 		}
@@ -164,37 +283,56 @@ func (autoEncoder *AutoEncoder) backPropagate(deltas *tsr.Tensor, learningRate f
 	return nil
 }
 
-// SaveToFile saves an auto encoder to a file.
-func (autoEncoder *AutoEncoder) SaveToFile(fileName string) error {
-	file, err := os.Create(fileName)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+// WriteTo writes an auto encoder to a writer as JSON. It returns the number of bytes written.
+func (autoEncoder *AutoEncoder) WriteTo(writer io.Writer) (int64, error) {
 	autoEncoderData := struct {
 		EncodingLayers []*DenseLayer `json:"encodingLayers"`
 		DecodingLayers []*DenseLayer `json:"decodingLayers"`
+		TiedLayers     []bool        `json:"tiedLayers,omitempty"`
 	}{
 		EncodingLayers: autoEncoder.encodingLayers,
 		DecodingLayers: autoEncoder.decodingLayers,
 	}
-	return json.NewEncoder(file).Encode(autoEncoderData)
+	for i, layer := range autoEncoder.encodingLayers {
+		if autoEncoder.decodingLayers[len(autoEncoder.decodingLayers)-1-i].tied == layer {
+			if autoEncoderData.TiedLayers == nil {
+				autoEncoderData.TiedLayers = make([]bool, len(autoEncoder.encodingLayers))
+			}
+			autoEncoderData.TiedLayers[i] = true
+		}
+	}
+	counter := &countingWriter{writer: writer}
+	err := json.NewEncoder(counter).Encode(autoEncoderData)
+	return counter.count, err
 }
 
-// LoadFromFile loads an auto encoder from a file.
-func (autoEncoder *AutoEncoder) LoadFromFile(fileName string) error {
-	file, err := os.Open(fileName)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+// ReadFrom reads an auto encoder from JSON in a reader. It returns the number of bytes read, which
+// can include bytes after the auto encoder that were buffered.
+func (autoEncoder *AutoEncoder) ReadFrom(reader io.Reader) (int64, error) {
 	autoEncoderData := struct {
 		EncodingLayers []*DenseLayer `json:"encodingLayers"`
 		DecodingLayers []*DenseLayer `json:"decodingLayers"`
+		TiedLayers     []bool        `json:"tiedLayers,omitempty"`
 	}{}
-	err = json.NewDecoder(file).Decode(&autoEncoderData)
+	counter := &countingReader{reader: reader}
+	err := json.NewDecoder(counter).Decode(&autoEncoderData)
 	if err != nil {
-		return err
+		return counter.count, err
+	}
+	if autoEncoderData.TiedLayers != nil {
+		if len(autoEncoderData.TiedLayers) != len(autoEncoderData.EncodingLayers) || len(autoEncoderData.DecodingLayers) != len(autoEncoderData.EncodingLayers) {
+			return counter.count, fmt.Errorf("Number of tied layers must match the number of coding layers")
+		}
+		// The decoding layers are in the reverse order of the encoding layers they are tied to.
+		for i, tied := range autoEncoderData.TiedLayers {
+			if tied {
+				decodingLayer := autoEncoderData.DecodingLayers[len(autoEncoderData.DecodingLayers)-1-i]
+				err = decodingLayer.tieWeights(autoEncoderData.EncodingLayers[i])
+				if err != nil {
+					return counter.count, err
+				}
+			}
+		}
 	}
 	for _, layer := range autoEncoderData.EncodingLayers {
 		autoEncoder.encodingLayers = append(autoEncoder.encodingLayers, layer)
@@ -202,5 +340,27 @@ func (autoEncoder *AutoEncoder) LoadFromFile(fileName string) error {
 	for _, layer := range autoEncoderData.DecodingLayers {
 		autoEncoder.decodingLayers = append(autoEncoder.decodingLayers, layer)
 	}
-	return nil
+	return counter.count, nil
+}
+
+// SaveToFile saves an auto encoder to a file.
+func (autoEncoder *AutoEncoder) SaveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = autoEncoder.WriteTo(file)
+	return err
+}
+
+// LoadFromFile loads an auto encoder from a file.
+func (autoEncoder *AutoEncoder) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = autoEncoder.ReadFrom(file)
+	return err
 }
@@ -1,9 +1,12 @@
 package nn
 
 import (
+	"bytes"
 	"math/rand"
 	"testing"
 	"time"
+
+	tsr "../tensor"
 )
 
 func TestAutoEncoderCopy(t *testing.T) {
@@ -26,6 +29,62 @@ func TestAutoEncoderCopy(t *testing.T) {
 	}
 }
 
+func TestAutoEncoderCopyTiedWeights(t *testing.T) {
+	autoEncoder := NewAutoEncoder(4)
+	err := autoEncoder.AddTiedCodingLayer(3, ActivationSigmoid)
+	if err != nil {
+		t.Fatalf("Error in AddTiedCodingLayer: %s", err.Error())
+	}
+	deep := autoEncoder.Copy()
+	encodingLayer := deep.encodingLayers[0]
+	decodingLayer := deep.decodingLayers[0]
+	if encodingLayer == autoEncoder.encodingLayers[0] || decodingLayer == autoEncoder.decodingLayers[0] {
+		t.Fatalf("Deep copy should not share layers with the original")
+	}
+	if len(decodingLayer.Parameters()) != 1 {
+		t.Errorf("Copied decoding layer should keep its tied weights")
+	}
+
+	// Changing the copied encoding weights changes the copied decoding weights, but not the original.
+	encodingLayer.Weights.Set(0, 0, 1, 5)
+	_, err = decodingLayer.FeedForward(tsr.NewValueTensor1D([]float32{0, 0, 0}))
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+	if decodingLayer.Weights.Get(0, 1, 0) != 5 {
+		t.Errorf("Copied decoding weights should be the transpose of the copied encoding weights")
+	}
+	if autoEncoder.encodingLayers[0].Weights.Get(0, 0, 1) == 5 {
+		t.Errorf("Changing the copy should not change the original encoding weights")
+	}
+
+	// A copy of the decoding layer alone has weights of its own, which the original does not change.
+	original := autoEncoder.decodingLayers[0]
+	layer := original.Copy().(*DenseLayer)
+	if len(layer.Parameters()) != 2 {
+		t.Errorf("Copied layer with tied weights should have weights of its own")
+	}
+	transposed, _ := tsr.MatrixTranspose(autoEncoder.encodingLayers[0].Weights, nil)
+	if !layer.Weights.Equals(transposed) {
+		t.Errorf("Copied layer should start from the transpose of the tied weights")
+	}
+	autoEncoder.encodingLayers[0].Weights.Set(0, 0, 1, 7)
+	_, err = layer.FeedForward(tsr.NewValueTensor1D([]float32{1, 1, 1}))
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+	_, err = layer.BackPropagate(tsr.NewValueTensor1D([]float32{1, 1, 1, 1}))
+	if err != nil {
+		t.Fatalf("Error in BackPropagate: %s", err.Error())
+	}
+	if layer.Weights.Get(0, 1, 0) == 7 {
+		t.Errorf("Copied layer should not take its weights from the original tied layer")
+	}
+	if !autoEncoder.encodingLayers[0].weightGradients.Equals(tsr.NewEmptyTensor2D(4, 3)) {
+		t.Errorf("Copied layer should not add gradients to the original tied layer")
+	}
+}
+
 func TestAutoEncoderAddGetLayers(t *testing.T) {
 	autoEncoder := NewAutoEncoder(4)
 	autoEncoder.AddCodingLayer(2, ActivationSigmoid)
@@ -41,6 +100,39 @@ func TestAutoEncoderAddGetLayers(t *testing.T) {
 	}
 }
 
+func TestAutoEncoderWriteToReadFrom(t *testing.T) {
+	autoEncoder := NewAutoEncoder(4)
+	autoEncoder.AddCodingLayer(3, ActivationSigmoid)
+	autoEncoder.AddCodingLayer(2, ActivationSigmoid)
+
+	buffer := bytes.Buffer{}
+	written, err := autoEncoder.WriteTo(&buffer)
+	if err != nil {
+		t.Fatalf("Error in WriteTo: %s", err.Error())
+	}
+	if written != int64(buffer.Len()) {
+		t.Errorf("Number of bytes written should be %d, is: %d", buffer.Len(), written)
+	}
+
+	loaded := NewAutoEncoder(4)
+	_, err = loaded.ReadFrom(&buffer)
+	if err != nil {
+		t.Fatalf("Error in ReadFrom: %s", err.Error())
+	}
+	if loaded.LayerCount() != autoEncoder.LayerCount() {
+		t.Errorf("Read auto encoder layers do not match original: %d != %d", loaded.LayerCount(), autoEncoder.LayerCount())
+	}
+	inputs := []float32{0.1, 0.2, 0.3, 0.4}
+	expected, _ := autoEncoder.Encode(inputs)
+	result, err := loaded.Encode(inputs)
+	if err != nil {
+		t.Fatalf("Error in Encode: %s", err.Error())
+	}
+	if result[0] != expected[0] || result[1] != expected[1] {
+		t.Errorf("Encoding of read auto encoder should match: %v != %v", result, expected)
+	}
+}
+
 func TestAutoEncoderEncodeDecode(t *testing.T) {
 	rand.Seed(time.Now().Unix())
 	inputs := [][]float32{
@@ -51,10 +143,11 @@ func TestAutoEncoderEncodeDecode(t *testing.T) {
 	autoEncoder := NewAutoEncoder(4)
 	autoEncoder.AddCodingLayer(2, ActivationSigmoid)
 
+	optimizer := NewSGDOptimizer(0.6, 0.2)
 	for i := 0; i < 10000; i++ {
 		index := rand.Intn(len(inputs))
 		data := inputs[index]
-		err := autoEncoder.Train(data, 0.3, 0.2)
+		err := autoEncoder.Train(data, optimizer)
 		if err != nil {
 			t.Fatalf("Error in Train: %s", err.Error())
 		}
@@ -79,3 +172,140 @@ func TestAutoEncoderEncodeDecode(t *testing.T) {
 		}
 	}
 }
+
+func TestAutoEncoderSparsity(t *testing.T) {
+	rand.Seed(1)
+	inputs := [][]float32{
+		{0.0, 0.0, 1.0, 1.0},
+		{0.0, 1.0, 1.0, 0.0},
+		{1.0, 0.0, 0.0, 1.0},
+	}
+	averageCode := func(autoEncoder *AutoEncoder) float32 {
+		sum := float32(0.0)
+		for _, input := range inputs {
+			encoded, _ := autoEncoder.Encode(input)
+			for _, value := range encoded {
+				sum += value / float32(len(encoded)*len(inputs))
+			}
+		}
+		return sum
+	}
+
+	dense := NewAutoEncoder(4)
+	dense.AddCodingLayer(8, ActivationSigmoid)
+	sparse := dense.Copy()
+	sparse.encodingLayers[0] = sparse.encodingLayers[0].Copy().(*DenseLayer)
+	sparse.decodingLayers[0] = sparse.decodingLayers[0].Copy().(*DenseLayer)
+	sparse.WithSparsity(0.2, 0.05)
+	optimizer := NewSGDOptimizer(0.5, 0)
+	for i := 0; i < 3000; i++ {
+		input := inputs[i%len(inputs)]
+		err := dense.Train(input, optimizer)
+		if err != nil {
+			t.Fatalf("Error in Train: %s", err.Error())
+		}
+		err = sparse.Train(input, optimizer)
+		if err != nil {
+			t.Fatalf("Error in Train: %s", err.Error())
+		}
+	}
+	if averageCode(sparse) >= averageCode(dense)/2 {
+		t.Errorf("Sparse codes should average less than half of dense codes: %f, %f", averageCode(sparse), averageCode(dense))
+	}
+
+	invalid := NewAutoEncoder(4).WithSparsity(1, 0.05)
+	invalid.AddCodingLayer(2, ActivationSigmoid)
+	err := invalid.Train(inputs[0], optimizer)
+	if err == nil {
+		t.Errorf("Drop fraction of 1 did not trigger error")
+	}
+}
+
+func TestAutoEncoderDenoising(t *testing.T) {
+	rand.Seed(1)
+	inputs := [][]float32{
+		{0.0, 0.0, 1.0, 1.0},
+		{0.0, 1.0, 1.0, 0.0},
+	}
+	autoEncoder := NewAutoEncoder(4).WithDenoising(0.1)
+	autoEncoder.AddCodingLayer(2, ActivationSigmoid)
+	optimizer := NewSGDOptimizer(0.6, 0.2)
+	for i := 0; i < 10000; i++ {
+		err := autoEncoder.Train(inputs[i%len(inputs)], optimizer)
+		if err != nil {
+			t.Fatalf("Error in Train: %s", err.Error())
+		}
+	}
+	for i, input := range inputs {
+		noisy := make([]float32, len(input))
+		for j, value := range input {
+			noisy[j] = value + float32(rand.NormFloat64())*0.1
+		}
+		encoded, _ := autoEncoder.Encode(noisy)
+		decoded, _ := autoEncoder.Decode(encoded)
+		for j := range decoded {
+			if decoded[j] < input[j]-0.2 || decoded[j] > input[j]+0.2 {
+				t.Errorf("Incorrect denoised decode for input %d at index %d: %.3f", i, j, decoded[j])
+			}
+		}
+	}
+
+	err := NewAutoEncoder(4).WithDenoising(-1).Train(inputs[0], optimizer)
+	if err == nil {
+		t.Errorf("Negative standard deviation of noise did not trigger error")
+	}
+}
+
+func TestAutoEncoderTiedWeights(t *testing.T) {
+	rand.Seed(1)
+	inputs := [][]float32{
+		{0.0, 0.0, 1.0, 1.0},
+		{0.0, 1.0, 1.0, 0.0},
+		{1.0, 0.0, 0.0, 1.0},
+	}
+	autoEncoder := NewAutoEncoder(4)
+	err := autoEncoder.AddTiedCodingLayer(3, ActivationSigmoid)
+	if err != nil {
+		t.Fatalf("Error in AddTiedCodingLayer: %s", err.Error())
+	}
+	encodingLayer := autoEncoder.encodingLayers[0]
+	decodingLayer := autoEncoder.decodingLayers[0]
+	if len(decodingLayer.Parameters()) != 1 || len(decodingLayer.Gradients()) != 1 {
+		t.Errorf("Decoding layer with tied weights should only have a bias parameter")
+	}
+
+	optimizer := NewAdamOptimizer(0.05)
+	for i := 0; i < 3000; i++ {
+		err := autoEncoder.Train(inputs[rand.Intn(len(inputs))], optimizer)
+		if err != nil {
+			t.Fatalf("Error in Train: %s", err.Error())
+		}
+	}
+	transposed, _ := tsr.MatrixTranspose(encodingLayer.Weights, nil)
+	if !decodingLayer.Weights.Equals(transposed) {
+		t.Errorf("Decoding weights should be the transpose of the encoding weights after training")
+	}
+	for i, input := range inputs {
+		encoded, _ := autoEncoder.Encode(input)
+		decoded, _ := autoEncoder.Decode(encoded)
+		for j := range decoded {
+			if decoded[j] < input[j]-0.2 || decoded[j] > input[j]+0.2 {
+				t.Errorf("Incorrect decode for input %d at index %d: %.3f", i, j, decoded[j])
+			}
+		}
+	}
+
+	buffer := bytes.Buffer{}
+	_, err = autoEncoder.WriteTo(&buffer)
+	if err != nil {
+		t.Fatalf("Error in WriteTo: %s", err.Error())
+	}
+	loaded := NewAutoEncoder(4)
+	_, err = loaded.ReadFrom(&buffer)
+	if err != nil {
+		t.Fatalf("Error in ReadFrom: %s", err.Error())
+	}
+	if loaded.decodingLayers[0].tied != loaded.encodingLayers[0] {
+		t.Errorf("Read auto encoder should keep its tied weights")
+	}
+}
@@ -0,0 +1,83 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// BeamStepFunction computes the log-probabilities of every token in the vocabulary following the
+// given sequence of tokens.
+type BeamStepFunction func(sequence []int) ([]float32, error)
+
+// BeamHypothesis is a candidate sequence of tokens found during a beam search.
+type BeamHypothesis struct {
+	Tokens         []int
+	LogProbability float32
+	Score          float32
+	Finished       bool
+}
+
+// BeamSearch decodes the most likely sequences produced by a step function, starting from the
+// start token and keeping the best beamWidth candidates at every step. Candidates finish when they
+// produce the end token or reach the maximum length. Scores are the log-probabilities divided by
+// the sequence length raised to the length penalty, so a length penalty of 0 disables length
+// normalization. The hypotheses are returned from best to worst score, and their tokens do not
+// include the start or end tokens.
+func BeamSearch(step BeamStepFunction, startToken int, endToken int, beamWidth int, maxLength int, lengthPenalty float32) ([]BeamHypothesis, error) {
+	if beamWidth < 1 {
+		return nil, fmt.Errorf("Beam width must be at least 1, is: %d", beamWidth)
+	}
+	if maxLength < 1 {
+		return nil, fmt.Errorf("Maximum length must be at least 1, is: %d", maxLength)
+	}
+	beams := []BeamHypothesis{{Tokens: []int{}}}
+	finished := []BeamHypothesis{}
+	for length := 1; length <= maxLength && len(beams) > 0 && len(finished) < beamWidth; length++ {
+		candidates := []BeamHypothesis{}
+		for _, beam := range beams {
+			sequence := append([]int{startToken}, beam.Tokens...)
+			logProbabilities, err := step(sequence)
+			if err != nil {
+				return nil, err
+			}
+			for token, logProbability := range logProbabilities {
+				candidate := BeamHypothesis{
+					Tokens:         beam.Tokens,
+					LogProbability: beam.LogProbability + logProbability,
+					Finished:       token == endToken,
+				}
+				if !candidate.Finished {
+					candidate.Tokens = append(append([]int{}, beam.Tokens...), token)
+				}
+				candidate.Score = lengthNormalizedScore(candidate.LogProbability, length, lengthPenalty)
+				candidates = append(candidates, candidate)
+			}
+		}
+		sortHypotheses(candidates)
+		beams = []BeamHypothesis{}
+		for _, candidate := range candidates {
+			if len(beams)+len(finished) >= beamWidth {
+				break
+			}
+			if candidate.Finished {
+				finished = append(finished, candidate)
+			} else {
+				beams = append(beams, candidate)
+			}
+		}
+	}
+	results := append(finished, beams...)
+	sortHypotheses(results)
+	return results, nil
+}
+
+func lengthNormalizedScore(logProbability float32, length int, lengthPenalty float32) float32 {
+	return logProbability / float32(math.Pow(float64(length), float64(lengthPenalty)))
+}
+
+func sortHypotheses(hypotheses []BeamHypothesis) {
+	sort.SliceStable(hypotheses, func(i int, j int) bool {
+		return hypotheses[i].Score > hypotheses[j].Score
+	})
+}
@@ -0,0 +1,62 @@
+package nn
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBeamSearch(t *testing.T) {
+	const (
+		tokenStart = 0
+		tokenEnd   = 1
+		tokenA     = 2
+		tokenB     = 3
+	)
+	logs := func(probabilities ...float64) []float32 {
+		logProbabilities := make([]float32, len(probabilities))
+		for i, probability := range probabilities {
+			logProbabilities[i] = float32(math.Log(probability))
+		}
+		return logProbabilities
+	}
+	step := func(sequence []int) ([]float32, error) {
+		switch sequence[len(sequence)-1] {
+		case tokenStart:
+			return logs(1e-9, 0.1, 0.5, 0.4), nil
+		case tokenA:
+			return logs(1e-9, 0.34, 0.33, 0.33), nil
+		default:
+			return logs(1e-9, 0.9, 0.05, 0.05), nil
+		}
+	}
+
+	greedy, err := BeamSearch(step, tokenStart, tokenEnd, 1, 5, 0.0)
+	if err != nil {
+		t.Fatalf("Error in BeamSearch: %s", err.Error())
+	}
+	if len(greedy[0].Tokens) != 1 || greedy[0].Tokens[0] != tokenA {
+		t.Errorf("Greedy search should find [%d], found: %v", tokenA, greedy[0].Tokens)
+	}
+
+	beams, err := BeamSearch(step, tokenStart, tokenEnd, 2, 5, 0.0)
+	if err != nil {
+		t.Fatalf("Error in BeamSearch: %s", err.Error())
+	}
+	if len(beams) != 2 {
+		t.Fatalf("Beam search should return 2 hypotheses, returned: %d", len(beams))
+	}
+	if len(beams[0].Tokens) != 1 || beams[0].Tokens[0] != tokenB {
+		t.Errorf("Beam search should find [%d], found: %v", tokenB, beams[0].Tokens)
+	}
+	if !beams[0].Finished {
+		t.Errorf("Best hypothesis should have finished with the end token")
+	}
+	if beams[0].Score < beams[1].Score {
+		t.Errorf("Hypotheses should be sorted by score: %.3f < %.3f", beams[0].Score, beams[1].Score)
+	}
+
+	_, err = BeamSearch(step, tokenStart, tokenEnd, 0, 5, 0.0)
+	if err == nil {
+		t.Errorf("Did not trigger error on invalid beam width")
+	}
+}
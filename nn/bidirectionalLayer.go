@@ -0,0 +1,214 @@
+package nn
+
+import (
+	"encoding/json"
+	"fmt"
+
+	tsr "../tensor"
+)
+
+// BidirectionalLayer is a layer that runs a sequence layer over the timesteps of its inputs in
+// both directions and concatenates the columns of their outputs. Each row of the input data is a
+// timestep.
+type BidirectionalLayer struct {
+	inputShape  LayerShape
+	outputShape LayerShape
+	outputs     *tsr.Tensor
+	Forward     Layer
+	Backward    Layer
+}
+
+// NewBidirectionalLayer creates a new instance of a bidirectional layer around a sequence layer,
+// such as a recurrent or time distributed layer. The backward layer starts as a copy of the given
+// layer.
+func NewBidirectionalLayer(layer Layer) (*BidirectionalLayer, error) {
+	return newBidirectionalLayer(layer, layer.Copy())
+}
+
+func newBidirectionalLayer(forward Layer, backward Layer) (*BidirectionalLayer, error) {
+	if _, ok := forward.(sequenceLayer); !ok {
+		return nil, fmt.Errorf("Inner layer must be a sequence layer, is: %s", typeOfLayer(forward))
+	}
+	inputShape := forward.InputShape()
+	outputShape := forward.OutputShape()
+	if inputShape.Frames != 1 || outputShape.Frames != 1 {
+		return nil, fmt.Errorf("Inner layer must take and produce a single frame: %d -> %d", inputShape.Frames, outputShape.Frames)
+	}
+	if backward.InputShape() != inputShape || backward.OutputShape() != outputShape {
+		return nil, fmt.Errorf("Forward and backward layer shapes must match")
+	}
+	return &BidirectionalLayer{
+		inputShape:  inputShape,
+		outputShape: LayerShape{outputShape.Rows, outputShape.Cols * 2, 1},
+		outputs:     tsr.NewEmptyTensor2D(outputShape.Rows, outputShape.Cols*2),
+		Forward:     forward,
+		Backward:    backward,
+	}, nil
+}
+
+// Copy creates a deep copy of the layer.
+func (layer *BidirectionalLayer) Copy() Layer {
+	newLayer, _ := newBidirectionalLayer(layer.Forward.Copy(), layer.Backward.Copy())
+	return newLayer
+}
+
+// InputShape returns the rows, columns and frames of the inputs to the layer.
+func (layer *BidirectionalLayer) InputShape() LayerShape {
+	return layer.inputShape
+}
+
+// OutputShape returns the rows, columns and frames of outputs from the layer.
+func (layer *BidirectionalLayer) OutputShape() LayerShape {
+	return layer.outputShape
+}
+
+// SetMask passes the mask to the inner layers if they can ignore masked timesteps.
+func (layer *BidirectionalLayer) SetMask(mask []bool) {
+	if forward, ok := layer.Forward.(MaskableLayer); ok {
+		forward.SetMask(mask)
+	}
+	if backward, ok := layer.Backward.(MaskableLayer); ok {
+		if mask == nil {
+			backward.SetMask(nil)
+			return
+		}
+		reversedMask := make([]bool, len(mask))
+		for i, masked := range mask {
+			reversedMask[len(mask)-1-i] = masked
+		}
+		backward.SetMask(reversedMask)
+	}
+}
+
+// FeedForward runs the forward layer on the inputs and the backward layer on the reversed inputs,
+// and concatenates their outputs.
+func (layer *BidirectionalLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error) {
+	forwardOutputs, err := layer.Forward.FeedForward(inputs)
+	if err != nil {
+		return nil, err
+	}
+	backwardOutputs, err := layer.Backward.FeedForward(reverseTimesteps(inputs))
+	if err != nil {
+		return nil, err
+	}
+	if layer.returnsSequences() {
+		backwardOutputs = reverseTimesteps(backwardOutputs)
+	}
+	cols := forwardOutputs.Cols
+	for row := 0; row < layer.outputShape.Rows; row++ {
+		for col := 0; col < cols; col++ {
+			layer.outputs.Set(0, row, col, forwardOutputs.Get(0, row, col))
+			layer.outputs.Set(0, row, cols+col, backwardOutputs.Get(0, row, col))
+		}
+	}
+	return layer.outputs, nil
+}
+
+// BackPropagate splits the deltas between the forward and backward layers and sums the deltas
+// they produce for their inputs.
+func (layer *BidirectionalLayer) BackPropagate(outputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := checkShape(outputs, layer.OutputShape(), "delta")
+	if err != nil {
+		return nil, err
+	}
+	cols := layer.outputShape.Cols / 2
+	forwardDeltas := tsr.NewEmptyTensor2D(layer.outputShape.Rows, cols)
+	backwardDeltas := tsr.NewEmptyTensor2D(layer.outputShape.Rows, cols)
+	for row := 0; row < layer.outputShape.Rows; row++ {
+		for col := 0; col < cols; col++ {
+			forwardDeltas.Set(0, row, col, outputs.Get(0, row, col))
+			backwardDeltas.Set(0, row, col, outputs.Get(0, row, cols+col))
+		}
+	}
+	if layer.returnsSequences() {
+		backwardDeltas = reverseTimesteps(backwardDeltas)
+	}
+	nextForwardDeltas, err := layer.Forward.BackPropagate(forwardDeltas)
+	if err != nil {
+		return nil, err
+	}
+	nextBackwardDeltas, err := layer.Backward.BackPropagate(backwardDeltas)
+	if err != nil {
+		return nil, err
+	}
+	nextDeltas := nextForwardDeltas.Copy()
+	err = nextDeltas.AddTensor(reverseTimesteps(nextBackwardDeltas))
+	if err != nil {
+		return nil, err
+	}
+	return nextDeltas, nil
+}
+
+// Parameters returns the parameters of the forward layer followed by those of the backward layer.
+func (layer *BidirectionalLayer) Parameters() []*tsr.Tensor {
+	return append(layer.Forward.Parameters(), layer.Backward.Parameters()...)
+}
+
+// Gradients returns the gradients of the parameters of both layers.
+func (layer *BidirectionalLayer) Gradients() []*tsr.Tensor {
+	return append(layer.Forward.Gradients(), layer.Backward.Gradients()...)
+}
+
+// returnsSequences is true when the inner layers produce a row for each timestep, in which case
+// the outputs of the backward layer are reversed to line up with the timesteps of the inputs.
+// Otherwise the backward layer produces its state after reading the sequence back to the first
+// timestep.
+func (layer *BidirectionalLayer) returnsSequences() bool {
+	return layer.Forward.(sequenceLayer).returnsSequences()
+}
+
+func reverseTimesteps(sequence *tsr.Tensor) *tsr.Tensor {
+	reversed := tsr.NewEmptyTensor2D(sequence.Rows, sequence.Cols)
+	reversed.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return sequence.Get(0, sequence.Rows-1-row, col)
+	})
+	return reversed
+}
+
+// BidirectionalLayerData represents a serialized layer that can be saved to a file.
+type BidirectionalLayerData struct {
+	Type     LayerType       `json:"type"`
+	Forward  json.RawMessage `json:"forward"`
+	Backward json.RawMessage `json:"backward"`
+}
+
+// MarshalJSON converts the layer to JSON.
+func (layer *BidirectionalLayer) MarshalJSON() ([]byte, error) {
+	forwardData, err := json.Marshal(layer.Forward)
+	if err != nil {
+		return nil, err
+	}
+	backwardData, err := json.Marshal(layer.Backward)
+	if err != nil {
+		return nil, err
+	}
+	data := BidirectionalLayerData{
+		Type:     LayerTypeBidirectional,
+		Forward:  forwardData,
+		Backward: backwardData,
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new layer from JSON.
+func (layer *BidirectionalLayer) UnmarshalJSON(b []byte) error {
+	data := BidirectionalLayerData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	forward, err := unmarshalLayer(data.Forward)
+	if err != nil {
+		return err
+	}
+	backward, err := unmarshalLayer(data.Backward)
+	if err != nil {
+		return err
+	}
+	newLayer, err := newBidirectionalLayer(forward, backward)
+	if err != nil {
+		return err
+	}
+	*layer = *newLayer
+	return nil
+}
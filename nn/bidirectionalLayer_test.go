@@ -0,0 +1,192 @@
+package nn
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestBidirectionalLayer(t *testing.T) {
+	timeDistributed, _ := NewTimeDistributedLayer(3, NewDenseLayer(2, 2, ActivationSigmoid))
+	layer, err := NewBidirectionalLayer(timeDistributed)
+	if err != nil {
+		t.Fatalf("Error in NewBidirectionalLayer: %s", err.Error())
+	}
+	if layer.OutputShape() != (LayerShape{3, 4, 1}) {
+		t.Fatalf("Incorrect output shape: %v", layer.OutputShape())
+	}
+
+	inputs := tsr.NewValueTensor2D([][]float32{
+		{1, 0},
+		{0, 1},
+		{0.5, 0.5},
+	})
+
+	outputs, err := layer.FeedForward(inputs)
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+
+	// Both directions start with the same weights, so each timestep is encoded the same way.
+	for row := 0; row < outputs.Rows; row++ {
+		for col := 0; col < 2; col++ {
+			if outputs.Get(0, row, col) != outputs.Get(0, row, col+2) {
+				t.Errorf("Backward outputs are not aligned with forward outputs at timestep %d:\n%s", row, outputs.String())
+			}
+		}
+	}
+
+	deltas, err := layer.BackPropagate(tsr.NewValueTensor2D([][]float32{
+		{1, 0, 0, 0},
+		{0, 0, 0, 1},
+		{0, 1, 1, 0},
+	}))
+	if err != nil {
+		t.Fatalf("Error in BackPropagate: %s", err.Error())
+	}
+	if deltas.Rows != 3 || deltas.Cols != 2 {
+		t.Fatalf("Deltas have incorrect shape: (%d, %d) != (%d, %d)", deltas.Rows, deltas.Cols, 3, 2)
+	}
+
+	forwardGradients := layer.Forward.(*TimeDistributedLayer).Layer.(*DenseLayer).weightGradients
+	backwardGradients := layer.Backward.(*TimeDistributedLayer).Layer.(*DenseLayer).weightGradients
+	if forwardGradients.Equals(backwardGradients) {
+		t.Errorf("Forward and backward weights should train independently")
+	}
+	if len(layer.Parameters()) != 4 || len(layer.Gradients()) != 4 {
+		t.Errorf("Layer should have the weights and bias of both directions, has: %d", len(layer.Parameters()))
+	}
+}
+
+// tanhStates runs a simple recurrent network over a sequence and returns its state after each
+// timestep, as a reference for the recurrent layers wrapped by a bidirectional layer.
+func tanhStates(sequence [][]float32, inputWeights [][]float32, recurrentWeights [][]float32, bias []float32) [][]float32 {
+	states := [][]float32{}
+	state := make([]float32, len(bias))
+	for _, inputs := range sequence {
+		next := make([]float32, len(bias))
+		for unit := range next {
+			sum := float64(bias[unit])
+			for i, input := range inputs {
+				sum += float64(input * inputWeights[i][unit])
+			}
+			for i, previous := range state {
+				sum += float64(previous * recurrentWeights[i][unit])
+			}
+			next[unit] = float32(math.Tanh(sum))
+		}
+		states = append(states, next)
+		state = next
+	}
+	return states
+}
+
+func TestBidirectionalLayerRecurrent(t *testing.T) {
+	inputWeights := [][]float32{{0.5, -1}, {1, 0.25}}
+	recurrentWeights := [][]float32{{0.5, 0.2}, {-0.3, 0.8}}
+	bias := []float32{0.1, -0.2}
+	sequence := [][]float32{{1, 0}, {0, 1}, {-0.5, 0.5}}
+	reversed := [][]float32{sequence[2], sequence[1], sequence[0]}
+	forwardStates := tanhStates(sequence, inputWeights, recurrentWeights, bias)
+	backwardStates := tanhStates(reversed, inputWeights, recurrentWeights, bias)
+
+	for _, returnSequences := range []bool{true, false} {
+		recurrent := newRecurrentLayer(
+			3,
+			tsr.NewValueTensor2D(inputWeights),
+			tsr.NewValueTensor2D(recurrentWeights),
+			tsr.NewValueTensor1D(bias),
+			ActivationTanh,
+			returnSequences,
+		)
+		layer, err := NewBidirectionalLayer(recurrent)
+		if err != nil {
+			t.Fatalf("Error in NewBidirectionalLayer: %s", err.Error())
+		}
+		outputs, err := layer.FeedForward(tsr.NewValueTensor2D(sequence))
+		if err != nil {
+			t.Fatalf("Error in FeedForward: %s", err.Error())
+		}
+
+		// With sequences, the backward state at each timestep is the state after reading the
+		// sequence from the end back to that timestep. Otherwise each direction gives its state after
+		// reading the whole sequence.
+		solution := [][]float32{}
+		if returnSequences {
+			for row := range sequence {
+				solution = append(solution, append(append([]float32{}, forwardStates[row]...), backwardStates[len(sequence)-1-row]...))
+			}
+		} else {
+			solution = append(solution, append(append([]float32{}, forwardStates[2]...), backwardStates[2]...))
+		}
+		if outputs.Rows != len(solution) {
+			t.Fatalf("Outputs with sequences %t should have %d rows, have: %d", returnSequences, len(solution), outputs.Rows)
+		}
+		for row := range solution {
+			for col, value := range solution[row] {
+				if math.Abs(float64(outputs.Get(0, row, col)-value)) > 1e-5 {
+					t.Errorf("Output (%d, %d) with sequences %t should be %.5f, is: %.5f", row, col, returnSequences, value, outputs.Get(0, row, col))
+				}
+			}
+		}
+	}
+
+	_, err := NewBidirectionalLayer(NewDenseLayer(2, 2, ActivationTanh))
+	if err == nil {
+		t.Errorf("Layer that is not a sequence layer did not trigger error")
+	}
+}
+
+func TestBidirectionalLayerRecurrentGradients(t *testing.T) {
+	for _, returnSequences := range []bool{true, false} {
+		bidirectional, _ := NewBidirectionalLayer(NewRecurrentLayer(3, 2, 2, ActivationTanh, returnSequences))
+		outputRows := bidirectional.OutputShape().Rows
+		neuralNetwork := NewNeuralNetwork()
+		err := neuralNetwork.Add(NewMaskingLayer(3, 2, 0), bidirectional)
+		if err != nil {
+			t.Fatalf("Error in Add: %s", err.Error())
+		}
+
+		// The last timestep of the second sample is padding, which the backward layer reads first.
+		inputs := [][][][]float32{
+			{{{0.5, -0.2}, {0.1, 0.8}, {-0.4, 0.3}}},
+			{{{-0.6, 0.2}, {0.7, 0.4}, {0, 0}}},
+		}
+		targets := make([][][][]float32, 2)
+		for i := range targets {
+			targets[i] = [][][]float32{make([][]float32, outputRows)}
+			for row := range targets[i][0] {
+				targets[i][0][row] = []float32{0.5, -0.5, 0.25, -0.25}
+			}
+		}
+		checks, err := CheckGradients(neuralNetwork, inputs, targets, 1e-2)
+		if err != nil {
+			t.Fatalf("Error in CheckGradients: %s", err.Error())
+		}
+		for _, check := range checks {
+			if check.MaxRelativeError > 0.05 {
+				t.Errorf("Relative error with sequences %t should be small, is: %f", returnSequences, check.MaxRelativeError)
+			}
+		}
+	}
+}
+
+func TestBidirectionalLayerSaveLoad(t *testing.T) {
+	timeDistributed, _ := NewTimeDistributedLayer(3, NewDenseLayer(2, 2, ActivationSigmoid))
+	layer, _ := NewBidirectionalLayer(timeDistributed)
+
+	data, err := json.Marshal(layer)
+	if err != nil {
+		t.Fatalf("Error in MarshalJSON: %s", err.Error())
+	}
+
+	loadedLayer, err := unmarshalLayer(data)
+	if err != nil {
+		t.Fatalf("Error in UnmarshalJSON: %s", err.Error())
+	}
+	if loadedLayer.InputShape() != layer.InputShape() || loadedLayer.OutputShape() != layer.OutputShape() {
+		t.Errorf("Loaded layer shape does not match original")
+	}
+}
@@ -0,0 +1,208 @@
+package nn
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// binaryMagic starts every neural network saved in the binary format, so it can be told apart from
+// JSON when loading.
+const binaryMagic = "MLGB"
+
+// binaryVersion is the version of the binary format that is written. Version 2 added a checksum
+// of the parameters.
+const binaryVersion = 2
+
+// SaveToFileBinary saves a neural network to a file in a compact binary format, which is much
+// smaller and faster to load than JSON for large layers. The file starts with a header and the
+// layers as compressed JSON with their parameters set to 0, followed by the shape and the little
+// endian float32 values of each parameter of the layers, and a CRC-32 checksum of the parameters.
+func (neuralNetwork *NeuralNetwork) SaveToFileBinary(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	err = neuralNetwork.writeBinary(writer)
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// LoadFromFileBinary loads a neural network from a file in the binary format.
+func (neuralNetwork *NeuralNetwork) LoadFromFileBinary(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return neuralNetwork.readBinary(bufio.NewReader(file))
+}
+
+func (neuralNetwork *NeuralNetwork) writeBinary(writer io.Writer) error {
+	// The parameters are written in binary, so they are left out of the JSON of the layers.
+	skeleton := neuralNetwork.Copy()
+	for _, parameter := range skeleton.Parameters() {
+		parameter.Scale(0)
+	}
+	config, err := json.Marshal(skeleton)
+	if err != nil {
+		return err
+	}
+	compressed := bytes.Buffer{}
+	compressor := gzip.NewWriter(&compressed)
+	_, err = compressor.Write(config)
+	if err != nil {
+		return err
+	}
+	err = compressor.Close()
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(writer, binaryMagic)
+	if err != nil {
+		return err
+	}
+	parameters := neuralNetwork.Parameters()
+	header := []uint32{binaryVersion, uint32(compressed.Len())}
+	err = binary.Write(writer, binary.LittleEndian, header)
+	if err != nil {
+		return err
+	}
+	_, err = compressed.WriteTo(writer)
+	if err != nil {
+		return err
+	}
+	checksum := crc32.NewIEEE()
+	parameterWriter := io.MultiWriter(writer, checksum)
+	err = binary.Write(parameterWriter, binary.LittleEndian, uint32(len(parameters)))
+	if err != nil {
+		return err
+	}
+	for _, parameter := range parameters {
+		shape := []uint32{uint32(parameter.Frames), uint32(parameter.Rows), uint32(parameter.Cols)}
+		err = binary.Write(parameterWriter, binary.LittleEndian, shape)
+		if err != nil {
+			return err
+		}
+		values := make([]float32, 0, parameter.Frames*parameter.Rows*parameter.Cols)
+		for frame := 0; frame < parameter.Frames; frame++ {
+			for row := 0; row < parameter.Rows; row++ {
+				for col := 0; col < parameter.Cols; col++ {
+					values = append(values, parameter.Get(frame, row, col))
+				}
+			}
+		}
+		err = binary.Write(parameterWriter, binary.LittleEndian, values)
+		if err != nil {
+			return err
+		}
+	}
+	return binary.Write(writer, binary.LittleEndian, checksum.Sum32())
+}
+
+func (neuralNetwork *NeuralNetwork) readBinary(reader io.Reader) error {
+	config, version, err := readBinaryConfig(reader)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(config, neuralNetwork)
+	if err != nil {
+		return err
+	}
+	parameters := neuralNetwork.Parameters()
+	checksum := crc32.NewIEEE()
+	parameterReader := io.TeeReader(reader, checksum)
+	var count uint32
+	err = binary.Read(parameterReader, binary.LittleEndian, &count)
+	if err != nil {
+		return err
+	}
+	if int(count) != len(parameters) {
+		return fmt.Errorf("Number of parameters does not match layers: %d != %d", count, len(parameters))
+	}
+	for i, parameter := range parameters {
+		shape := make([]uint32, 3)
+		err = binary.Read(parameterReader, binary.LittleEndian, shape)
+		if err != nil {
+			return err
+		}
+		if int(shape[0]) != parameter.Frames || int(shape[1]) != parameter.Rows || int(shape[2]) != parameter.Cols {
+			return fmt.Errorf(
+				"Shape of parameter %d does not match layers: (%d, %d, %d) != (%d, %d, %d)",
+				i, shape[0], shape[1], shape[2], parameter.Frames, parameter.Rows, parameter.Cols,
+			)
+		}
+		values := make([]float32, parameter.Frames*parameter.Rows*parameter.Cols)
+		err = binary.Read(parameterReader, binary.LittleEndian, values)
+		if err != nil {
+			return err
+		}
+		index := 0
+		for frame := 0; frame < parameter.Frames; frame++ {
+			for row := 0; row < parameter.Rows; row++ {
+				for col := 0; col < parameter.Cols; col++ {
+					parameter.Set(frame, row, col, values[index])
+					index++
+				}
+			}
+		}
+	}
+	if version < 2 {
+		return nil
+	}
+	var savedChecksum uint32
+	err = binary.Read(reader, binary.LittleEndian, &savedChecksum)
+	if err != nil {
+		return err
+	}
+	if savedChecksum != checksum.Sum32() {
+		return fmt.Errorf("Checksum of parameters does not match, the neural network may be corrupted: %08x != %08x", checksum.Sum32(), savedChecksum)
+	}
+	return nil
+}
+
+// readBinaryConfig reads the header of the binary format and returns the JSON of the layers and the
+// version of the format.
+func readBinaryConfig(reader io.Reader) ([]byte, uint32, error) {
+	magic := make([]byte, len(binaryMagic))
+	_, err := io.ReadFull(reader, magic)
+	if err != nil {
+		return nil, 0, err
+	}
+	if string(magic) != binaryMagic {
+		return nil, 0, fmt.Errorf("File is not a neural network in the binary format")
+	}
+	header := make([]uint32, 2)
+	err = binary.Read(reader, binary.LittleEndian, header)
+	if err != nil {
+		return nil, 0, err
+	}
+	if header[0] > binaryVersion {
+		return nil, 0, fmt.Errorf("Unsupported binary format version: %d", header[0])
+	}
+	decompressor, err := gzip.NewReader(io.LimitReader(reader, int64(header[1])))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer decompressor.Close()
+	config, err := ioutil.ReadAll(decompressor)
+	return config, header[0], err
+}
+
+// isBinary checks whether the data of a reader starts with the header of the binary format.
+func isBinary(reader *bufio.Reader) bool {
+	magic, err := reader.Peek(len(binaryMagic))
+	return err == nil && string(magic) == binaryMagic
+}
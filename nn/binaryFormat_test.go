@@ -0,0 +1,93 @@
+package nn
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNeuralNetworkSaveLoadBinary(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	convolutionLayer, _ := NewRandomConvolutionLayer(4, 4, 1, 2, 3, ActivationRELU)
+	neuralNetwork.Add(
+		convolutionLayer,
+		NewFlattenLayer(4, 4, 2),
+		NewDenseLayer(32, 64, ActivationSigmoid),
+		NewDenseLayer(64, 3, ActivationLinear),
+		NewSoftmaxLayer(3),
+	)
+	neuralNetwork.SetMetadata(NewMetadata(neuralNetwork, "binary", "1.0.0"))
+
+	err := neuralNetwork.SaveToFileBinary("nn.bin")
+	if err != nil {
+		t.Fatalf("Error in SaveToFileBinary: %s", err.Error())
+	}
+	defer os.Remove("nn.bin")
+	err = neuralNetwork.SaveToFile("nn.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+	defer os.Remove("nn.json")
+
+	binaryInfo, _ := os.Stat("nn.bin")
+	jsonInfo, _ := os.Stat("nn.json")
+	if binaryInfo.Size()*2 > jsonInfo.Size() {
+		t.Errorf("Binary file should be less than half the size of JSON: %d, %d", binaryInfo.Size(), jsonInfo.Size())
+	}
+
+	inputs := [][][]float32{{{1, 0, 1, 0}, {0, 1, 0, 1}, {1, 1, 0, 0}, {0, 0, 1, 1}}}
+	expected, _ := neuralNetwork.Predict(inputs)
+	loaders := map[string]func(*NeuralNetwork) error{
+		"LoadFromFileBinary": func(loaded *NeuralNetwork) error { return loaded.LoadFromFileBinary("nn.bin") },
+		"LoadFromFile":       func(loaded *NeuralNetwork) error { return loaded.LoadFromFile("nn.bin") },
+	}
+	for name, load := range loaders {
+		loaded := NewNeuralNetwork()
+		err = load(loaded)
+		if err != nil {
+			t.Fatalf("Error in %s: %s", name, err.Error())
+		}
+		result, err := loaded.Predict(inputs)
+		if err != nil {
+			t.Fatalf("Error in Predict: %s", err.Error())
+		}
+		for i := range expected[0][0] {
+			if result[0][0][i] != expected[0][0][i] {
+				t.Errorf("Prediction of network loaded with %s should match: %v != %v", name, result, expected)
+				break
+			}
+		}
+		if loaded.Metadata() == nil || loaded.Metadata().Name != "binary" {
+			t.Errorf("Network loaded with %s should have its metadata", name)
+		}
+	}
+
+	metadata, err := LoadMetadataFromFile("nn.bin")
+	if err != nil {
+		t.Fatalf("Error in LoadMetadataFromFile: %s", err.Error())
+	}
+	if metadata.Version != "1.0.0" {
+		t.Errorf("Metadata loaded from binary file should have version 1.0.0, has: %s", metadata.Version)
+	}
+
+	err = NewNeuralNetwork().LoadFromFileBinary("nn.json")
+	if err == nil {
+		t.Errorf("Loading JSON as the binary format should have an error")
+	}
+}
+
+func TestNeuralNetworkLoadBinaryTruncated(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(8, 8, ActivationSigmoid))
+	err := neuralNetwork.SaveToFileBinary("truncated.bin")
+	if err != nil {
+		t.Fatalf("Error in SaveToFileBinary: %s", err.Error())
+	}
+	defer os.Remove("truncated.bin")
+	info, _ := os.Stat("truncated.bin")
+	os.Truncate("truncated.bin", info.Size()-10)
+
+	err = NewNeuralNetwork().LoadFromFile("truncated.bin")
+	if err == nil {
+		t.Errorf("Loading a truncated file should have an error")
+	}
+}
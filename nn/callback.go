@@ -0,0 +1,121 @@
+package nn
+
+import (
+	"errors"
+	"math"
+)
+
+// Callback is notified of the progress of a neural network while it trains over a number of
+// epochs. The metrics of an epoch include the average loss of its samples as "loss" and the
+// learning rate of its last batch as "learningRate". Returning an error stops training with that
+// error, while returning ErrStopTraining from OnEpochEnd ends training early without an error.
+type Callback interface {
+	OnTrainBegin(neuralNetwork *NeuralNetwork) error
+	OnEpochEnd(neuralNetwork *NeuralNetwork, epoch int, metrics map[string]float32) error
+	OnTrainEnd(neuralNetwork *NeuralNetwork) error
+}
+
+// ErrStopTraining is returned by a callback at the end of an epoch to end training successfully
+// before the last epoch.
+var ErrStopTraining = errors.New("Training stopped")
+
+// monitoredMetric returns the value of a metric to monitor, which defaults to the validation loss
+// if there is one and the training loss otherwise.
+func monitoredMetric(metrics map[string]float32, monitor string) (float32, bool) {
+	if monitor == "" {
+		if value, ok := metrics["validationLoss"]; ok {
+			return value, true
+		}
+		monitor = "loss"
+	}
+	value, ok := metrics[monitor]
+	return value, ok
+}
+
+// EarlyStopping is a callback that stops training once a metric to minimize, such as the
+// validation loss, has not improved by more than a minimum delta for a number of epochs.
+type EarlyStopping struct {
+	Monitor      string
+	Patience     int
+	MinDelta     float32
+	StoppedEpoch int
+	best         float32
+	wait         int
+}
+
+// NewEarlyStopping creates an early stopping callback that monitors the validation loss, or the
+// training loss when there is no validation data.
+func NewEarlyStopping(patience int, minDelta float32) *EarlyStopping {
+	return &EarlyStopping{Patience: patience, MinDelta: minDelta, StoppedEpoch: -1}
+}
+
+// OnTrainBegin resets the best value of the metric.
+func (callback *EarlyStopping) OnTrainBegin(neuralNetwork *NeuralNetwork) error {
+	callback.best = float32(math.Inf(1))
+	callback.wait = 0
+	callback.StoppedEpoch = -1
+	return nil
+}
+
+// OnEpochEnd stops training once the metric has not improved for as many epochs as the patience.
+func (callback *EarlyStopping) OnEpochEnd(neuralNetwork *NeuralNetwork, epoch int, metrics map[string]float32) error {
+	value, ok := monitoredMetric(metrics, callback.Monitor)
+	if !ok {
+		return nil
+	}
+	if value < callback.best-callback.MinDelta {
+		callback.best = value
+		callback.wait = 0
+		return nil
+	}
+	callback.wait++
+	if callback.wait >= callback.Patience {
+		callback.StoppedEpoch = epoch
+		return ErrStopTraining
+	}
+	return nil
+}
+
+// OnTrainEnd does nothing for early stopping.
+func (callback *EarlyStopping) OnTrainEnd(neuralNetwork *NeuralNetwork) error {
+	return nil
+}
+
+// ModelCheckpoint is a callback that saves the neural network to a file at the end of each epoch,
+// or only when a metric to minimize, such as the validation loss, reaches its best value.
+type ModelCheckpoint struct {
+	Monitor      string
+	Path         string
+	SaveBestOnly bool
+	best         float32
+}
+
+// NewModelCheckpoint creates a checkpoint callback that saves to a path, monitoring the validation
+// loss, or the training loss when there is no validation data.
+func NewModelCheckpoint(path string, saveBestOnly bool) *ModelCheckpoint {
+	return &ModelCheckpoint{Path: path, SaveBestOnly: saveBestOnly}
+}
+
+// OnTrainBegin resets the best value of the metric.
+func (callback *ModelCheckpoint) OnTrainBegin(neuralNetwork *NeuralNetwork) error {
+	callback.best = float32(math.Inf(1))
+	return nil
+}
+
+// OnEpochEnd saves the neural network if it is the best so far, or always if not saving only the
+// best.
+func (callback *ModelCheckpoint) OnEpochEnd(neuralNetwork *NeuralNetwork, epoch int, metrics map[string]float32) error {
+	if callback.SaveBestOnly {
+		value, ok := monitoredMetric(metrics, callback.Monitor)
+		if !ok || value >= callback.best {
+			return nil
+		}
+		callback.best = value
+	}
+	return neuralNetwork.SaveToFile(callback.Path)
+}
+
+// OnTrainEnd does nothing for checkpoints.
+func (callback *ModelCheckpoint) OnTrainEnd(neuralNetwork *NeuralNetwork) error {
+	return nil
+}
@@ -0,0 +1,111 @@
+package nn
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+type recordingCallback struct {
+	events []string
+	stopAt int
+}
+
+func (callback *recordingCallback) OnTrainBegin(neuralNetwork *NeuralNetwork) error {
+	callback.events = append(callback.events, "begin")
+	return nil
+}
+
+func (callback *recordingCallback) OnEpochEnd(neuralNetwork *NeuralNetwork, epoch int, metrics map[string]float32) error {
+	callback.events = append(callback.events, fmt.Sprintf("epoch %d", epoch))
+	if epoch == callback.stopAt {
+		return fmt.Errorf("Stopped at epoch %d", epoch)
+	}
+	return nil
+}
+
+func (callback *recordingCallback) OnTrainEnd(neuralNetwork *NeuralNetwork) error {
+	callback.events = append(callback.events, "end")
+	return nil
+}
+
+func TestNeuralNetworkCallbacks(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+	callback := &recordingCallback{stopAt: -1}
+	neuralNetwork.AddCallback(callback)
+
+	inputs := [][][][]float32{{{{0, 1}}}, {{{1, 0}}}}
+	targets := [][][][]float32{{{{1}}}, {{{0}}}}
+	err := neuralNetwork.TrainBatchSchedule(inputs, targets, 2, 2, ConstantSchedule(0.1), NewSGDOptimizer(0.1, 0))
+	if err != nil {
+		t.Fatalf("Error in TrainBatchSchedule: %s", err.Error())
+	}
+	solution := "[begin epoch 0 epoch 1 end]"
+	if fmt.Sprint(callback.events) != solution {
+		t.Errorf("Callback events should be %s, are: %v", solution, callback.events)
+	}
+
+	callback.events = nil
+	callback.stopAt = 0
+	err = neuralNetwork.TrainBatchSchedule(inputs, targets, 2, 2, ConstantSchedule(0.1), NewSGDOptimizer(0.1, 0))
+	if err == nil {
+		t.Errorf("Callback error did not stop training")
+	}
+	solution = "[begin epoch 0]"
+	if fmt.Sprint(callback.events) != solution {
+		t.Errorf("Callback events should be %s, are: %v", solution, callback.events)
+	}
+}
+
+func TestEarlyStopping(t *testing.T) {
+	callback := NewEarlyStopping(3, 0.1)
+	callback.OnTrainBegin(nil)
+	losses := []float32{1, 0.5, 0.45, 0.42, 0.3, 0.3, 0.29, 0.35}
+	stoppedAt := -1
+	for epoch, loss := range losses {
+		err := callback.OnEpochEnd(nil, epoch, map[string]float32{"loss": 10, "validationLoss": loss})
+		if err == ErrStopTraining {
+			stoppedAt = epoch
+			break
+		}
+	}
+	if stoppedAt != 7 || callback.StoppedEpoch != 7 {
+		t.Errorf("Early stopping should stop at epoch 7, stopped at: %d", stoppedAt)
+	}
+}
+
+func TestTrainerEarlyStoppingAndCheckpoint(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+	dataset, _ := NewDataset(
+		[][][][]float32{{{{0, 0}}}, {{{0, 1}}}, {{{1, 0}}}, {{{1, 1}}}},
+		[][][][]float32{{{{0}}}, {{{1}}}, {{{1}}}, {{{0}}}},
+	)
+	trainer := NewTrainer(neuralNetwork, NewSGDOptimizer(0.1, 0), LossMSE)
+	trainer.ValidationData = dataset
+	checkpoint := NewModelCheckpoint("checkpoint.json", true)
+	trainer.AddCallback(NewEarlyStopping(3, 0.5))
+	trainer.AddCallback(checkpoint)
+	defer os.Remove("checkpoint.json")
+
+	history, err := trainer.Fit(dataset, 100, 4)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+	if len(history) != 4 {
+		t.Errorf("Training should stop after 4 epochs without a large enough improvement, stopped after: %d", len(history))
+	}
+	if _, ok := history[0]["validationLoss"]; !ok {
+		t.Errorf("Metrics should include the validation loss, are: %v", history[0])
+	}
+
+	loaded := NewNeuralNetwork()
+	err = loaded.LoadFromFile("checkpoint.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+	if loaded.LayerCount() != 1 {
+		t.Errorf("Checkpoint should have 1 layer, has: %d", loaded.LayerCount())
+	}
+}
@@ -0,0 +1,220 @@
+package nn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+
+	tsr "../tensor"
+)
+
+// trainingState is the position of a training loop, along with a seed that starts a random source
+// of the training loop again at each epoch, or 0 to sample from the global random source.
+type trainingState struct {
+	epoch  int
+	step   int
+	seed   int64
+	random *rand.Rand
+}
+
+// TrainingCheckpointData represents the progress of fitting saved to a file: the next epoch to
+// train, the number of batches trained so far, the seed of the trainer, the values of the
+// parameters of the neural network, and the state the optimizer keeps for each parameter by the
+// name of the state.
+type TrainingCheckpointData struct {
+	Epoch          int                        `json:"epoch"`
+	Step           int                        `json:"step"`
+	Seed           int64                      `json:"seed"`
+	Parameters     [][][][]float32            `json:"parameters"`
+	OptimizerState map[string][][][][]float32 `json:"optimizerState"`
+	OptimizerSteps map[string][]int           `json:"optimizerSteps"`
+}
+
+// ResumeFrom loads a checkpoint saved while fitting, so the next call to Fit continues from the
+// epoch after the checkpoint instead of the first epoch. The neural network and optimizer of the
+// trainer must be built the same way as when the checkpoint was saved, and get the saved parameters
+// and optimizer state. The learning rate schedule continues from the saved step, and with a seed
+// each epoch shuffles the samples the same way as if training had not stopped. Callbacks start
+// again from their initial state.
+func (trainer *Trainer) ResumeFrom(fileName string) error {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	checkpoint := TrainingCheckpointData{}
+	err = json.Unmarshal(data, &checkpoint)
+	if err != nil {
+		return err
+	}
+	if checkpoint.Epoch < 0 || checkpoint.Step < 0 {
+		return fmt.Errorf("Epoch and step of checkpoint must be at least 0, are: %d, %d", checkpoint.Epoch, checkpoint.Step)
+	}
+	parameters := trainer.NeuralNetwork.Parameters()
+	if len(checkpoint.Parameters) != len(parameters) {
+		return fmt.Errorf("Number of parameters in checkpoint must be %d, is: %d", len(parameters), len(checkpoint.Parameters))
+	}
+	for i, values := range checkpoint.Parameters {
+		parameter, err := loadedTensorLike(values, parameters[i], fmt.Sprintf("parameter %d", i))
+		if err != nil {
+			return err
+		}
+		parameters[i].SetTensor(parameter)
+	}
+	err = restoreOptimizerState(trainer.Optimizer, trainer.NeuralNetwork.optimizedParameters(), checkpoint)
+	if err != nil {
+		return err
+	}
+	trainer.resumed = &trainingState{epoch: checkpoint.Epoch, step: checkpoint.Step, seed: checkpoint.Seed}
+	return nil
+}
+
+// saveCheckpoint saves the parameters of the neural network, the state of the optimizer and the
+// position of training. The file is written in full before it replaces an older checkpoint, so a
+// training that stops while saving still leaves the older checkpoint.
+func (trainer *Trainer) saveCheckpoint(fileName string, state trainingState) error {
+	checkpoint := TrainingCheckpointData{
+		Epoch:          state.epoch,
+		Step:           state.step,
+		Seed:           state.seed,
+		Parameters:     [][][][]float32{},
+		OptimizerState: map[string][][][][]float32{},
+		OptimizerSteps: map[string][]int{},
+	}
+	for _, parameter := range trainer.NeuralNetwork.Parameters() {
+		checkpoint.Parameters = append(checkpoint.Parameters, parameter.GetAll())
+	}
+	if optimizer, ok := trainer.Optimizer.(statefulOptimizer); ok {
+		parts := trainer.NeuralNetwork.optimizedParameters()
+		for name, states := range optimizer.tensorStates() {
+			values := make([][][][]float32, len(parts))
+			for i, part := range parts {
+				if state, ok := states[part]; ok {
+					values[i] = state.GetAll()
+				}
+			}
+			checkpoint.OptimizerState[name] = values
+		}
+		for name, states := range optimizer.stepStates() {
+			steps := make([]int, len(parts))
+			for i, part := range parts {
+				steps[i] = states[part]
+			}
+			checkpoint.OptimizerSteps[name] = steps
+		}
+	}
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(fileName+".tmp", data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(fileName+".tmp", fileName)
+}
+
+func restoreOptimizerState(optimizer Optimizer, parts []*tsr.Tensor, checkpoint TrainingCheckpointData) error {
+	if len(checkpoint.OptimizerState) == 0 && len(checkpoint.OptimizerSteps) == 0 {
+		return nil
+	}
+	stateful, ok := optimizer.(statefulOptimizer)
+	if !ok {
+		return fmt.Errorf("Optimizer cannot restore the state saved in checkpoint")
+	}
+	tensorStates := stateful.tensorStates()
+	for name, values := range checkpoint.OptimizerState {
+		states, ok := tensorStates[name]
+		if !ok {
+			return fmt.Errorf("Optimizer has no state named: %s", name)
+		}
+		if len(values) != len(parts) {
+			return fmt.Errorf("Number of values of optimizer state %s must be %d, is: %d", name, len(parts), len(values))
+		}
+		for i, value := range values {
+			if value == nil {
+				delete(states, parts[i])
+				continue
+			}
+			state, err := loadedTensorLike(value, parts[i], "optimizer state "+name)
+			if err != nil {
+				return err
+			}
+			states[parts[i]] = state
+		}
+	}
+	stepStates := stateful.stepStates()
+	for name, steps := range checkpoint.OptimizerSteps {
+		states, ok := stepStates[name]
+		if !ok {
+			return fmt.Errorf("Optimizer has no step count named: %s", name)
+		}
+		if len(steps) != len(parts) {
+			return fmt.Errorf("Number of step counts of optimizer %s must be %d, is: %d", name, len(parts), len(steps))
+		}
+		for i, step := range steps {
+			if step < 0 {
+				return fmt.Errorf("Step count of optimizer %s must be at least 0, is: %d", name, step)
+			}
+			if step == 0 {
+				delete(states, parts[i])
+			} else {
+				states[parts[i]] = step
+			}
+		}
+	}
+	return nil
+}
+
+// loadedTensorLike creates a tensor from saved values, which must have the same dimensions as
+// another tensor.
+func loadedTensorLike(values [][][]float32, like *tsr.Tensor, name string) (*tsr.Tensor, error) {
+	matches := len(values) == like.Frames
+	for _, frame := range values {
+		matches = matches && len(frame) == like.Rows
+		for _, row := range frame {
+			matches = matches && len(row) == like.Cols
+		}
+	}
+	if !matches {
+		return nil, fmt.Errorf("Dimensions of %s in checkpoint must be (%d, %d, %d)", name, like.Frames, like.Rows, like.Cols)
+	}
+	return tsr.NewValueTensor3D(values), nil
+}
+
+// optimizedParameters returns every tensor an optimizer may keep state for, which are the
+// parameters of each layer, or their parts for sparse layers.
+func (neuralNetwork *NeuralNetwork) optimizedParameters() []*tsr.Tensor {
+	parts := []*tsr.Tensor{}
+	for _, layer := range neuralNetwork.layers {
+		if sparse, ok := layer.(sparseLayer); ok {
+			parts = append(parts, sparse.parameterParts()...)
+		} else {
+			parts = append(parts, layer.Parameters()...)
+		}
+	}
+	return parts
+}
+
+// checkpointCallback saves a checkpoint of the trainer at the end of each epoch, after the other
+// callbacks.
+type checkpointCallback struct {
+	trainer  *Trainer
+	fileName string
+	state    *trainingState
+}
+
+func (callback *checkpointCallback) OnTrainBegin(neuralNetwork *NeuralNetwork) error {
+	return nil
+}
+
+func (callback *checkpointCallback) OnEpochEnd(neuralNetwork *NeuralNetwork, epoch int, metrics map[string]float32) error {
+	next := *callback.state
+	next.epoch = epoch + 1
+	return callback.trainer.saveCheckpoint(callback.fileName, next)
+}
+
+func (callback *checkpointCallback) OnTrainEnd(neuralNetwork *NeuralNetwork) error {
+	return nil
+}
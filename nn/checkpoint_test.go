@@ -0,0 +1,122 @@
+package nn
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func checkpointNeuralNetwork() *NeuralNetwork {
+	rand.Seed(1)
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(
+		NewEmbeddingLayer(2, 4, 3),
+		NewFlattenLayer(2, 3, 1),
+		NewDenseLayer(6, 1, ActivationSigmoid),
+	)
+	return neuralNetwork
+}
+
+func checkpointTrainer(neuralNetwork *NeuralNetwork) *Trainer {
+	optimizer, _ := NewLookaheadOptimizer(NewAdamOptimizer(0.05), 2, 0.5)
+	trainer := NewTrainer(neuralNetwork, optimizer, LossBinaryCrossEntropy)
+	trainer.Schedule = func(step int) float32 {
+		return 0.05 / float32(1+step)
+	}
+	trainer.Seed = 7
+	return trainer
+}
+
+func TestTrainerResumeFrom(t *testing.T) {
+	dataset, _ := NewDataset(
+		[][][][]float32{{{{0, 1}}}, {{{1, 2}}}, {{{2, 3}}}, {{{3, 0}}}, {{{1, 1}}}},
+		[][][][]float32{{{{0}}}, {{{1}}}, {{{0}}}, {{{1}}}, {{{1}}}},
+	)
+	fileName := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	// Fitting with a seed does not change the numbers drawn from the global random source.
+	uninterrupted := checkpointNeuralNetwork()
+	rand.Seed(3)
+	expected := rand.Int63()
+	rand.Seed(3)
+	_, err := checkpointTrainer(uninterrupted).Fit(dataset, 6, 2)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+	if rand.Int63() != expected {
+		t.Errorf("Fitting with a seed should not use the global random source")
+	}
+
+	interrupted := checkpointNeuralNetwork()
+	trainer := checkpointTrainer(interrupted)
+	trainer.CheckpointPath = fileName
+	_, err = trainer.Fit(dataset, 3, 2)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+
+	// A new process builds the same neural network and optimizer, with its own initial weights.
+	rand.Seed(2)
+	resumed := checkpointNeuralNetwork()
+	resumed.LayerAt(2).(*DenseLayer).Weights.SetRandom(-1, 1)
+	trainer = checkpointTrainer(resumed)
+	err = trainer.ResumeFrom(fileName)
+	if err != nil {
+		t.Fatalf("Error in ResumeFrom: %s", err.Error())
+	}
+	history, err := trainer.Fit(dataset, 6, 2)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+	if len(history) != 3 {
+		t.Errorf("Resumed training should run the last 3 epochs, ran: %d", len(history))
+	}
+	parameters := resumed.Parameters()
+	for i, parameter := range uninterrupted.Parameters() {
+		if !parameters[i].Equals(parameter) {
+			t.Errorf("Parameter %d of resumed training should be:\n%swhen result is:\n%s", i, parameter.String(), parameters[i].String())
+		}
+	}
+
+	// Fitting again after resuming starts from the first epoch.
+	history, _ = trainer.Fit(dataset, 2, 2)
+	if len(history) != 2 {
+		t.Errorf("Training after a resumed training should start from the first epoch, ran: %d epochs", len(history))
+	}
+}
+
+func TestTrainerResumeFromInvalid(t *testing.T) {
+	dataset, _ := NewDataset([][][][]float32{{{{0, 1}}}}, [][][][]float32{{{{1}}}})
+	fileName := filepath.Join(t.TempDir(), "checkpoint.json")
+	trainer := checkpointTrainer(checkpointNeuralNetwork())
+	trainer.CheckpointPath = fileName
+	_, err := trainer.Fit(dataset, 1, 1)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+
+	other := NewNeuralNetwork()
+	other.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+	err = NewTrainer(other, NewAdamOptimizer(0.1), LossMSE).ResumeFrom(fileName)
+	if err == nil {
+		t.Errorf("Resuming a different neural network did not trigger error")
+	}
+	err = NewTrainer(checkpointNeuralNetwork(), NewSGDOptimizer(0.1, 0), LossMSE).ResumeFrom(fileName)
+	if err == nil {
+		t.Errorf("Resuming with a different optimizer did not trigger error")
+	}
+
+	checkpoints := []string{
+		`not a checkpoint`,
+		`{"epoch":-1}`,
+		`{"parameters":[[[[1]]],[[[1]]],[[[1]]]]}`,
+	}
+	for _, checkpoint := range checkpoints {
+		ioutil.WriteFile(fileName, []byte(checkpoint), 0644)
+		err = checkpointTrainer(checkpointNeuralNetwork()).ResumeFrom(fileName)
+		if err == nil {
+			t.Errorf("Resuming from %s did not trigger error", checkpoint)
+		}
+	}
+}
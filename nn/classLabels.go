@@ -0,0 +1,83 @@
+package nn
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ClassProbability is the probability that a sample belongs to a class.
+type ClassProbability struct {
+	Label       string
+	Probability float32
+}
+
+// SetClassLabels sets the labels of the classes that the outputs of the neural network stand for,
+// in the order of the outputs. The labels are kept in the metadata, so they are saved along with
+// the neural network, and metadata is created if the neural network has none.
+func (neuralNetwork *NeuralNetwork) SetClassLabels(labels []string) error {
+	if len(neuralNetwork.layers) == 0 {
+		return fmt.Errorf("Neural network must have layers before setting class labels")
+	}
+	outputShape := neuralNetwork.layers[len(neuralNetwork.layers)-1].OutputShape()
+	outputs := outputShape.Rows * outputShape.Cols * outputShape.Frames
+	if len(labels) != outputs {
+		return fmt.Errorf("Number of class labels must match number of outputs: %d != %d", len(labels), outputs)
+	}
+	if neuralNetwork.metadata == nil {
+		neuralNetwork.metadata = NewMetadata(neuralNetwork, "", "")
+	}
+	neuralNetwork.metadata.ClassLabels = append([]string(nil), labels...)
+	return nil
+}
+
+// ClassLabels returns the labels of the classes that the outputs of the neural network stand for,
+// or nil if it has none.
+func (neuralNetwork *NeuralNetwork) ClassLabels() []string {
+	if neuralNetwork.metadata == nil {
+		return nil
+	}
+	return neuralNetwork.metadata.ClassLabels
+}
+
+// PredictClasses makes a prediction and returns the probability of each class label, from the most
+// to the least likely class.
+func (neuralNetwork *NeuralNetwork) PredictClasses(inputs [][][]float32) ([]ClassProbability, error) {
+	labels := neuralNetwork.ClassLabels()
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("Neural network has no class labels")
+	}
+	outputs, err := neuralNetwork.Predict(inputs)
+	if err != nil {
+		return nil, err
+	}
+	values := []float32{}
+	for _, frame := range outputs {
+		for _, row := range frame {
+			values = append(values, row...)
+		}
+	}
+	return classProbabilities(values, labels)
+}
+
+// PredictLabel makes a prediction and returns the label of the most likely class.
+func (neuralNetwork *NeuralNetwork) PredictLabel(inputs [][][]float32) (string, error) {
+	probabilities, err := neuralNetwork.PredictClasses(inputs)
+	if err != nil {
+		return "", err
+	}
+	return probabilities[0].Label, nil
+}
+
+func classProbabilities(values []float32, labels []string) ([]ClassProbability, error) {
+	if len(values) != len(labels) {
+		return nil, fmt.Errorf("Number of class labels must match number of outputs: %d != %d", len(labels), len(values))
+	}
+	probabilities := make([]ClassProbability, len(labels))
+	for i, label := range labels {
+		probabilities[i] = ClassProbability{Label: label, Probability: values[i]}
+	}
+	sort.SliceStable(probabilities, func(i, j int) bool {
+		return probabilities[i].Probability > probabilities[j].Probability
+	})
+	return probabilities, nil
+}
@@ -0,0 +1,62 @@
+package nn
+
+import (
+	"os"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestNeuralNetworkPredictLabel(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	layer := NewDenseLayer(2, 3, ActivationLinear)
+	neuralNetwork.Add(layer, NewSoftmaxLayer(3))
+	layer.Weights = tsr.NewValueTensor2D([][]float32{{1, 0, 0}, {0, 0, 2}})
+	layer.Bias = tsr.NewEmptyTensor1D(3)
+
+	_, err := neuralNetwork.PredictLabel([][][]float32{{{1, 0}}})
+	if err == nil {
+		t.Errorf("Predicting a label without class labels did not trigger error")
+	}
+	err = neuralNetwork.SetClassLabels([]string{"cat", "dog"})
+	if err == nil {
+		t.Errorf("Wrong number of class labels did not trigger error")
+	}
+	err = neuralNetwork.SetClassLabels([]string{"cat", "dog", "bird"})
+	if err != nil {
+		t.Fatalf("Error in SetClassLabels: %s", err.Error())
+	}
+
+	label, err := neuralNetwork.PredictLabel([][][]float32{{{1, 0}}})
+	if err != nil {
+		t.Fatalf("Error in PredictLabel: %s", err.Error())
+	}
+	if label != "cat" {
+		t.Errorf("Predicted label should be: cat, is: %s", label)
+	}
+	probabilities, err := neuralNetwork.PredictClasses([][][]float32{{{0, 1}}})
+	if err != nil {
+		t.Fatalf("Error in PredictClasses: %s", err.Error())
+	}
+	if probabilities[0].Label != "bird" || probabilities[0].Probability < probabilities[1].Probability {
+		t.Errorf("Most likely class should be: bird, is: %s", probabilities[0].Label)
+	}
+
+	err = neuralNetwork.SaveToFile("classLabels.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+	defer os.Remove("classLabels.json")
+	loaded := NewNeuralNetwork()
+	err = loaded.LoadFromFile("classLabels.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+	label, err = loaded.PredictLabel([][][]float32{{{0, 1}}})
+	if err != nil {
+		t.Fatalf("Error in PredictLabel: %s", err.Error())
+	}
+	if label != "bird" {
+		t.Errorf("Loaded neural network should predict label: bird, is: %s", label)
+	}
+}
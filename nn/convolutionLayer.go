@@ -2,44 +2,96 @@ package nn
 
 import (
 	"encoding/json"
+	"fmt"
 
 	tsr "../tensor"
 )
 
-// ConvolutionLayer is a layer that performs convolutional filters on data.
+// ConvolutionLayer is a layer that performs convolutional filters on data. A regularizer of the
+// layer penalizes its filters but not its bias while training.
 type ConvolutionLayer struct {
-	inputShape  LayerShape
-	outputShape LayerShape
-	inputs      *tsr.Tensor
-	outputs     *tsr.Tensor
-	Filters     []*tsr.Tensor
-	Activation  ActivationFunction
+	inputShape      LayerShape
+	outputShape     LayerShape
+	inputs          *tsr.Tensor
+	outputs         *tsr.Tensor
+	filterGradients []*tsr.Tensor
+	biasGradients   *tsr.Tensor
+	Filters         []*tsr.Tensor
+	Bias            *tsr.Tensor
+	Activation      ActivationFunction
+	Regularizer     Regularizer
 }
 
-// NewConvolutionLayer creates a new instance of a convolutional layer.
-func NewConvolutionLayer(inputRows int, inputCols int, inputFrames int, filters []*tsr.Tensor, activation ActivationFunction) *ConvolutionLayer {
+// NewConvolutionLayer creates a new instance of a convolutional layer. The filters are copied, so
+// training the layer does not change the given filters. Each filter must be square with an odd
+// size so it has a center. The bias of each filter starts at 0.
+func NewConvolutionLayer(inputRows int, inputCols int, inputFrames int, filters []*tsr.Tensor, activation ActivationFunction) (*ConvolutionLayer, error) {
+	for _, filter := range filters {
+		err := checkFilterSize(filter.Rows, filter.Cols)
+		if err != nil {
+			return nil, err
+		}
+	}
 	inputs := tsr.NewEmptyTensor3D(inputFrames, inputRows, inputCols)
 	outputFrames := inputFrames * len(filters)
 	outputs := tsr.NewEmptyTensor3D(outputFrames, inputRows, inputCols)
-	return &ConvolutionLayer{
-		inputShape:  LayerShape{inputRows, inputCols, inputFrames},
-		outputShape: LayerShape{inputRows, inputCols, outputFrames},
-		inputs:      inputs,
-		outputs:     outputs,
-		Filters:     filters,
-		Activation:  activation,
+	layerFilters := make([]*tsr.Tensor, len(filters))
+	filterGradients := make([]*tsr.Tensor, len(filters))
+	for i, filter := range filters {
+		layerFilters[i] = filter.Copy()
+		filterGradients[i] = tsr.NewEmptyTensor2D(filter.Rows, filter.Cols)
+	}
+	layer := &ConvolutionLayer{
+		inputShape:      LayerShape{inputRows, inputCols, inputFrames},
+		outputShape:     LayerShape{inputRows, inputCols, outputFrames},
+		inputs:          inputs,
+		outputs:         outputs,
+		filterGradients: filterGradients,
+		biasGradients:   tsr.NewEmptyTensor1D(len(filters)),
+		Filters:         layerFilters,
+		Bias:            tsr.NewEmptyTensor1D(len(filters)),
+		Activation:      activation,
 	}
+	return layer, nil
+}
+
+// NewRandomConvolutionLayer creates a new instance of a convolutional layer with a number of
+// square filters of a kernel size, which must be odd so each filter has a center. The filters
+// start with random values from the initializer that suits the activation, so they can be learned
+// while training.
+func NewRandomConvolutionLayer(inputRows int, inputCols int, inputFrames int, numFilters int, kernelSize int, activation ActivationFunction) (*ConvolutionLayer, error) {
+	return NewConvolutionLayerWithInitializer(inputRows, inputCols, inputFrames, numFilters, kernelSize, activation, defaultInitializer(activation))
+}
+
+// NewConvolutionLayerWithInitializer creates a new instance of a convolutional layer with a number
+// of square filters of an odd kernel size, with values set by an initializer. Each value of the
+// outputs comes from the values of the kernel around it, so the fan in and fan out are the size of
+// the kernel.
+func NewConvolutionLayerWithInitializer(inputRows int, inputCols int, inputFrames int, numFilters int, kernelSize int, activation ActivationFunction, initializer Initializer) (*ConvolutionLayer, error) {
+	if kernelSize < 1 || kernelSize%2 == 0 {
+		return nil, fmt.Errorf("Kernel size must be odd, is: %d", kernelSize)
+	}
+	filters := make([]*tsr.Tensor, numFilters)
+	for i := range filters {
+		filters[i] = tsr.NewEmptyTensor2D(kernelSize, kernelSize)
+		initializer.Initialize(filters[i], kernelSize*kernelSize, kernelSize*kernelSize)
+	}
+	return NewConvolutionLayer(inputRows, inputCols, inputFrames, filters, activation)
 }
 
 // Copy creates a deep copy of the layer.
 func (layer *ConvolutionLayer) Copy() Layer {
-	return NewConvolutionLayer(
+	// The filters of the layer were already checked, so copying them cannot fail.
+	newLayer, _ := NewConvolutionLayer(
 		layer.InputShape().Rows,
 		layer.InputShape().Cols,
 		layer.InputShape().Frames,
 		layer.Filters,
 		layer.Activation,
 	)
+	newLayer.Bias.SetTensor(layer.Bias)
+	newLayer.Regularizer = layer.Regularizer
+	return newLayer
 }
 
 // InputShape returns the rows, columns and frames of the inputs to the layer.
@@ -52,15 +104,21 @@ func (layer *ConvolutionLayer) OutputShape() LayerShape {
 	return layer.outputShape
 }
 
-// FeedForward applies convolutions to the input for each of the filters.
+// FeedForward applies convolutions to the input for each of the filters and adds their bias. The
+// outputs of each filter take up one frame for each frame of the inputs.
 func (layer *ConvolutionLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error) {
-	layer.inputs.SetTensor(inputs)
-	for _, filter := range layer.Filters {
+	err := layer.inputs.SetTensor(inputs)
+	if err != nil {
+		return nil, err
+	}
+	for i, filter := range layer.Filters {
+		bias := layer.Bias.Get(0, 0, i)
 		for frame := 0; frame < inputs.Frames; frame++ {
+			outputFrame := i*inputs.Frames + frame
 			for row := 0; row < inputs.Rows; row++ {
 				for col := 0; col < inputs.Cols; col++ {
 					value := layer.convolution(inputs, frame, row, col, filter)
-					layer.outputs.Set(frame, row, col, value)
+					layer.outputs.Set(outputFrame, row, col, value+bias)
 				}
 			}
 		}
@@ -69,9 +127,83 @@ func (layer *ConvolutionLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, err
 	return layer.outputs, nil
 }
 
-// BackPropagate does not operate on the data in a convolution layer.
-func (layer *ConvolutionLayer) BackPropagate(outputs *tsr.Tensor, learningRate float32, momentum float32) (*tsr.Tensor, error) {
-	return layer.inputs, nil
+// BackPropagate computes the gradients of the filters by cross-correlating the inputs with the
+// gradient of the outputs, and the gradient of each bias as the sum of the gradient of the outputs
+// of its filter. It returns the gradient of the inputs, which is the full convolution of
+// the gradient of the outputs with the flipped filters.
+func (layer *ConvolutionLayer) BackPropagate(outputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := checkShape(outputs, layer.outputShape, "delta")
+	if err != nil {
+		return nil, err
+	}
+	gradient := layer.Activation.Derivative(layer.outputs.Copy())
+	err = gradient.ScaleTensor(outputs)
+	if err != nil {
+		return nil, err
+	}
+	inputShape := layer.InputShape()
+	nextDeltas := tsr.NewEmptyTensor3D(inputShape.Frames, inputShape.Rows, inputShape.Cols)
+	for i, filter := range layer.Filters {
+		filterGradient := layer.filterGradients[i]
+		biasGradient := float32(0.0)
+		centerRow, centerCol := filter.Rows/2, filter.Cols/2
+		for frame := 0; frame < inputShape.Frames; frame++ {
+			outputFrame := i*inputShape.Frames + frame
+			for row := 0; row < inputShape.Rows; row++ {
+				for col := 0; col < inputShape.Cols; col++ {
+					delta := gradient.Get(outputFrame, row, col)
+					if delta == 0 {
+						continue
+					}
+					biasGradient += delta
+					for or := -centerRow; or <= centerRow; or++ {
+						convRow := row + or
+						if convRow < 0 || convRow >= inputShape.Rows {
+							continue
+						}
+						for oc := -centerCol; oc <= centerCol; oc++ {
+							convCol := col + oc
+							if convCol < 0 || convCol >= inputShape.Cols {
+								continue
+							}
+							filterRow, filterCol := or+centerRow, oc+centerCol
+							current := filterGradient.Get(0, filterRow, filterCol)
+							filterGradient.Set(0, filterRow, filterCol, current+delta*layer.inputs.Get(frame, convRow, convCol))
+							current = nextDeltas.Get(frame, convRow, convCol)
+							nextDeltas.Set(frame, convRow, convCol, current+delta*filter.Get(0, filterRow, filterCol))
+						}
+					}
+				}
+			}
+		}
+		layer.biasGradients.Set(0, 0, i, layer.biasGradients.Get(0, 0, i)+biasGradient)
+	}
+	return nextDeltas, nil
+}
+
+// Parameters returns the filters of the layer followed by the bias.
+func (layer *ConvolutionLayer) Parameters() []*tsr.Tensor {
+	parameters := append([]*tsr.Tensor{}, layer.Filters...)
+	return append(parameters, layer.Bias)
+}
+
+// Gradients returns the gradients of the filters and bias.
+func (layer *ConvolutionLayer) Gradients() []*tsr.Tensor {
+	gradients := append([]*tsr.Tensor{}, layer.filterGradients...)
+	return append(gradients, layer.biasGradients)
+}
+
+func (layer *ConvolutionLayer) regularizedParameters() (Regularizer, []*tsr.Tensor) {
+	return layer.Regularizer, layer.Filters
+}
+
+// checkFilterSize makes sure a filter is square with an odd size, since the convolution centers
+// each filter on a value of the inputs.
+func checkFilterSize(rows int, cols int) error {
+	if rows < 1 || rows%2 == 0 || rows != cols {
+		return fmt.Errorf("Filters must be square with an odd size, are: %dx%d", rows, cols)
+	}
+	return nil
 }
 
 func (layer *ConvolutionLayer) convolution(matrix *tsr.Tensor, frame int, row int, col int, filter *tsr.Tensor) float32 {
@@ -86,7 +218,7 @@ func (layer *ConvolutionLayer) convolution(matrix *tsr.Tensor, frame int, row in
 			if convCol < 0 || convCol >= matrix.Cols {
 				continue
 			}
-			sum += matrix.Get(frame, convRow, convCol) * filter.Get(0, or+filter.Rows/2, oc+filter.Cols/2)
+			sum = tsr.MultiplyAdd(sum, matrix.Get(frame, convRow, convCol), filter.Get(0, or+filter.Rows/2, oc+filter.Cols/2))
 		}
 	}
 	return sum
@@ -94,12 +226,13 @@ func (layer *ConvolutionLayer) convolution(matrix *tsr.Tensor, frame int, row in
 
 // ConvolutionLayerData represents a serialized layer that can be saved to a file.
 type ConvolutionLayerData struct {
-	Type        LayerType      `json:"type"`
-	InputRows   int            `json:"inputRows"`
-	InputCols   int            `json:"inputCols"`
-	InputFrames int            `json:"inputFrames"`
-	Filters     [][][]float32  `json:"filters"`
-	Activation  ActivationType `json:"activation"`
+	Type        LayerType          `json:"type"`
+	InputRows   int                `json:"inputRows"`
+	InputCols   int                `json:"inputCols"`
+	InputFrames int                `json:"inputFrames"`
+	Filters     [][][]float32      `json:"filters"`
+	Bias        []float32          `json:"bias,omitempty"`
+	Activation  ActivationFunction `json:"activation"`
 }
 
 // MarshalJSON converts the layer to JSON.
@@ -114,7 +247,8 @@ func (layer *ConvolutionLayer) MarshalJSON() ([]byte, error) {
 		InputCols:   layer.InputShape().Cols,
 		InputFrames: layer.InputShape().Frames,
 		Filters:     filters,
-		Activation:  layer.Activation.Type,
+		Bias:        layer.Bias.GetFrame(0)[0],
+		Activation:  layer.Activation,
 	}
 	return json.Marshal(data)
 }
@@ -126,14 +260,50 @@ func (layer *ConvolutionLayer) UnmarshalJSON(b []byte) error {
 	if err != nil {
 		return err
 	}
-	layer.inputs = tsr.NewEmptyTensor3D(data.InputFrames, data.InputRows, data.InputCols)
+	err = checkLoadedShape(
+		LayerShape{data.InputRows, data.InputCols, data.InputFrames},
+		LayerShape{data.InputRows, data.InputCols, len(data.Filters)},
+	)
+	if err != nil {
+		return err
+	}
+	for _, filter := range data.Filters {
+		err = checkLoadedMatrix(filter, "filters")
+		if err != nil {
+			return err
+		}
+		err = checkFilterSize(len(filter), len(filter[0]))
+		if err != nil {
+			return err
+		}
+	}
+	if data.Bias != nil && len(data.Bias) != len(data.Filters) {
+		return fmt.Errorf("Number of biases of layer must be %d, is: %d", len(data.Filters), len(data.Bias))
+	}
+	err = checkLoadedActivation(data.Activation)
+	if err != nil {
+		return err
+	}
 	outputFrames := data.InputFrames * len(data.Filters)
-	layer.outputs = tsr.NewEmptyTensor3D(data.InputFrames, data.InputRows, data.InputCols)
+	err = checkLoadedShape(LayerShape{data.InputRows, data.InputCols, outputFrames})
+	if err != nil {
+		return err
+	}
+	layer.inputs = tsr.NewEmptyTensor3D(data.InputFrames, data.InputRows, data.InputCols)
+	layer.outputs = tsr.NewEmptyTensor3D(outputFrames, data.InputRows, data.InputCols)
 	layer.Filters = make([]*tsr.Tensor, len(data.Filters))
+	layer.filterGradients = make([]*tsr.Tensor, len(data.Filters))
 	for i, filter := range data.Filters {
 		layer.Filters[i] = tsr.NewValueTensor2D(filter)
+		layer.filterGradients[i] = tsr.NewEmptyTensor2D(layer.Filters[i].Rows, layer.Filters[i].Cols)
+	}
+	// Layers saved before filters had a bias start with a bias of 0.
+	layer.Bias = tsr.NewEmptyTensor1D(len(data.Filters))
+	if data.Bias != nil {
+		layer.Bias = tsr.NewValueTensor1D(data.Bias)
 	}
-	layer.Activation = activationFunctionOfType(data.Activation)
+	layer.biasGradients = tsr.NewEmptyTensor1D(len(data.Filters))
+	layer.Activation = data.Activation
 	layer.inputShape = LayerShape{data.InputRows, data.InputCols, data.InputFrames}
 	layer.outputShape = LayerShape{data.InputRows, data.InputCols, outputFrames}
 	return nil
@@ -1,6 +1,7 @@
 package nn
 
 import (
+	"math"
 	"testing"
 
 	tsr "../tensor"
@@ -8,7 +9,10 @@ import (
 
 func TestConvolutionLayer(t *testing.T) {
 	filters := []*tsr.Tensor{FilterVerticalEdges}
-	layer := NewConvolutionLayer(5, 5, 1, filters, ActivationRELU)
+	layer, err := NewConvolutionLayer(5, 5, 1, filters, ActivationRELU)
+	if err != nil {
+		t.Fatalf("Error in NewConvolutionLayer: %s", err.Error())
+	}
 
 	inputs := tsr.NewValueTensor2D([][]float32{
 		{0, 1, 0.5, 1, 0},
@@ -38,15 +42,172 @@ func TestConvolutionLayer(t *testing.T) {
 		t.Errorf("Matrix after feed forward should be:\n%swhen result is:\n%s", solution.String(), convolutions.String())
 	}
 
-	deconvolutions, err := layer.BackPropagate(convolutions, 0.0, 0.0)
+	if !FilterVerticalEdges.Equals(layer.Filters[0]) || FilterVerticalEdges == layer.Filters[0] {
+		t.Errorf("Layer should keep a copy of the given filters")
+	}
+}
+
+func TestConvolutionLayerBackPropagate(t *testing.T) {
+	filters := []*tsr.Tensor{
+		tsr.NewValueTensor2D([][]float32{{1, 0.5, 0}, {0, 1, 0.5}, {0.5, 0, 1}}),
+		tsr.NewValueTensor2D([][]float32{{0.5, 0.5, 0.5}, {0, 1, 0}, {0.5, 0, 0.5}}),
+	}
+	layer, err := NewConvolutionLayer(4, 4, 2, filters, ActivationRELU)
+	if err != nil {
+		t.Fatalf("Error in NewConvolutionLayer: %s", err.Error())
+	}
+
+	inputs := tsr.NewValueTensor3D([][][]float32{
+		{{1, 0.5, 1, 0.25}, {0.5, 1, 0.25, 1}, {1, 0.5, 0.5, 1}, {0.5, 1, 1, 0.5}},
+		{{0.5, 1, 1, 0.5}, {1, 0.25, 0.5, 1}, {0.5, 1, 0.25, 0.5}, {1, 0.5, 1, 1}},
+	})
+	deltas := tsr.NewEmptyTensor3D(4, 4, 4)
+	deltas.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return float32(frame+row-col) / 4
+	})
+
+	_, err = layer.FeedForward(inputs)
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+	inputDeltas, err := layer.BackPropagate(deltas)
 	if err != nil {
 		t.Fatalf("Error in BackPropagate: %s", err.Error())
 	}
-	if deconvolutions.Frames != inputs.Frames {
-		t.Fatalf("Convolution inputs have incorrect frame length: %d != %d", deconvolutions.Frames, inputs.Frames)
+	if inputDeltas.Frames != inputs.Frames {
+		t.Fatalf("Convolution input deltas have incorrect frame length: %d != %d", inputDeltas.Frames, inputs.Frames)
 	}
 
-	if !deconvolutions.Equals(inputs) {
-		t.Errorf("Matrix after back propagate should be:\n%swhen result is:\n%s", inputs.String(), deconvolutions.String())
+	// Every output is positive, so the gradients match the change of the weighted outputs.
+	weightedOutputs := func() float32 {
+		outputs, err := layer.FeedForward(inputs)
+		if err != nil {
+			t.Fatalf("Error in FeedForward: %s", err.Error())
+		}
+		weighted := outputs.Copy()
+		weighted.ScaleTensor(deltas)
+		return weighted.Sum()
+	}
+	numericalGradient := func(tensor *tsr.Tensor, frame int, row int, col int) float32 {
+		epsilon := float32(0.01)
+		value := tensor.Get(frame, row, col)
+		tensor.Set(frame, row, col, value+epsilon)
+		plus := weightedOutputs()
+		tensor.Set(frame, row, col, value-epsilon)
+		minus := weightedOutputs()
+		tensor.Set(frame, row, col, value)
+		return (plus - minus) / (2 * epsilon)
+	}
+
+	inputDeltas.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		solution := numericalGradient(inputs, frame, row, col)
+		if math.Abs(float64(current-solution)) > 1e-3 {
+			t.Errorf("Input delta (%d, %d, %d) should be %.4f, is: %.4f", frame, row, col, solution, current)
+		}
+		return current
+	})
+	for i, filter := range layer.Filters {
+		layer.Gradients()[i].ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			solution := numericalGradient(filter, frame, row, col)
+			if math.Abs(float64(current-solution)) > 1e-3 {
+				t.Errorf("Filter %d gradient (%d, %d) should be %.4f, is: %.4f", i, row, col, solution, current)
+			}
+			return current
+		})
+	}
+	layer.biasGradients.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		solution := numericalGradient(layer.Bias, frame, row, col)
+		if math.Abs(float64(current-solution)) > 1e-3 {
+			t.Errorf("Bias %d gradient should be %.4f, is: %.4f", col, solution, current)
+		}
+		return current
+	})
+
+	_, err = layer.BackPropagate(tsr.NewEmptyTensor3D(4, 4, 2))
+	if err == nil {
+		t.Errorf("Deltas with incorrect shape did not trigger error")
+	}
+}
+
+func TestRandomConvolutionLayer(t *testing.T) {
+	layer, err := NewRandomConvolutionLayer(6, 5, 2, 4, 3, ActivationRELU)
+	if err != nil {
+		t.Fatalf("Error in NewRandomConvolutionLayer: %s", err.Error())
+	}
+	if len(layer.Filters) != 4 || layer.Bias.Cols != 4 {
+		t.Fatalf("Layer should have 4 filters and biases, has: %d, %d", len(layer.Filters), layer.Bias.Cols)
+	}
+	if layer.OutputShape() != (LayerShape{6, 5, 8}) {
+		t.Errorf("Output shape should be (6, 5, 8), is: %v", layer.OutputShape())
+	}
+	for _, filter := range layer.Filters {
+		if filter.Rows != 3 || filter.Cols != 3 {
+			t.Errorf("Filter should be 3x3, is: %dx%d", filter.Rows, filter.Cols)
+		}
+	}
+	layer.Bias.SetRandom(-1, 1)
+
+	data, err := layer.MarshalJSON()
+	if err != nil {
+		t.Fatalf("Error in MarshalJSON: %s", err.Error())
+	}
+	loaded := &ConvolutionLayer{}
+	err = loaded.UnmarshalJSON(data)
+	if err != nil {
+		t.Fatalf("Error in UnmarshalJSON: %s", err.Error())
+	}
+	if !loaded.Bias.Equals(layer.Bias) {
+		t.Errorf("Loaded bias should be:\n%swhen result is:\n%s", layer.Bias.String(), loaded.Bias.String())
+	}
+
+	_, err = NewRandomConvolutionLayer(6, 5, 2, 4, 2, ActivationRELU)
+	if err == nil {
+		t.Errorf("Even kernel size did not trigger error")
+	}
+}
+
+func TestConvolutionLayerFilterSize(t *testing.T) {
+	filters := [][]*tsr.Tensor{
+		{tsr.NewEmptyTensor2D(2, 2)},
+		{tsr.NewEmptyTensor2D(1, 2)},
+		{tsr.NewEmptyTensor2D(3, 1)},
+		{FilterVerticalEdges, tsr.NewEmptyTensor2D(3, 5)},
+	}
+	for _, filter := range filters {
+		_, err := NewConvolutionLayer(4, 4, 1, filter, ActivationRELU)
+		if err == nil {
+			t.Errorf("Filter of size %dx%d did not trigger error", filter[len(filter)-1].Rows, filter[len(filter)-1].Cols)
+		}
+	}
+}
+
+func TestConvolutionLayerTrain(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	conv, err := NewConvolutionLayer(3, 3, 1, []*tsr.Tensor{tsr.NewEmptyTensor2D(3, 3)}, ActivationSigmoid)
+	if err != nil {
+		t.Fatalf("Error in NewConvolutionLayer: %s", err.Error())
+	}
+	neuralNetwork.Add(conv)
+
+	inputs := [][][]float32{{{1, 0, 1}, {0, 1, 0}, {1, 0, 1}}}
+	targets := [][][]float32{{{0.9, 0.1, 0.9}, {0.1, 0.9, 0.1}, {0.9, 0.1, 0.9}}}
+	optimizer := NewSGDOptimizer(0.5, 0.5)
+	for i := 0; i < 500; i++ {
+		err := neuralNetwork.Train(inputs, targets, optimizer)
+		if err != nil {
+			t.Fatalf("Error in Train: %s", err.Error())
+		}
+	}
+
+	result, err := neuralNetwork.Predict(inputs)
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			if math.Abs(float64(result[0][row][col]-targets[0][row][col])) > 0.1 {
+				t.Errorf("Incorrect prediction at (%d, %d): %.3f != %.3f", row, col, result[0][row][col], targets[0][row][col])
+			}
+		}
 	}
 }
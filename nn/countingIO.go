@@ -0,0 +1,27 @@
+package nn
+
+import "io"
+
+// countingWriter counts the bytes written to a writer.
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (counter *countingWriter) Write(b []byte) (int, error) {
+	n, err := counter.writer.Write(b)
+	counter.count += int64(n)
+	return n, err
+}
+
+// countingReader counts the bytes read from a reader.
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (counter *countingReader) Read(b []byte) (int, error) {
+	n, err := counter.reader.Read(b)
+	counter.count += int64(n)
+	return n, err
+}
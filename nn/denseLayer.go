@@ -7,44 +7,60 @@ import (
 	tsr "../tensor"
 )
 
-// DenseLayer is a fully connected layer for a neural network.
+// DenseLayer is a fully connected layer for a neural network. A regularizer of the layer, such as
+// an L2 weight decay, penalizes its weights but not its bias while training.
 type DenseLayer struct {
-	inputShape  LayerShape
-	outputShape LayerShape
-	inputs      *tsr.Tensor
-	outputs     *tsr.Tensor
-	Weights     *tsr.Tensor
-	Bias        *tsr.Tensor
-	PrevUpdate  *tsr.Tensor
-	Activation  ActivationFunction
+	inputShape      LayerShape
+	outputShape     LayerShape
+	inputs          *tsr.Tensor
+	outputs         *tsr.Tensor
+	weightGradients *tsr.Tensor
+	biasGradients   *tsr.Tensor
+	Weights         *tsr.Tensor
+	Bias            *tsr.Tensor
+	Activation      ActivationFunction
+	Regularizer     Regularizer
+	tied            *DenseLayer
 }
 
-// NewDenseLayer creates a new instance of a fully connected layer.
+// NewDenseLayer creates a new instance of a fully connected layer. The weights are initialized to
+// suit the activation, with InitHe for rectified linear units and InitXavier otherwise.
 func NewDenseLayer(inputSize int, outputSize int, activation ActivationFunction) *DenseLayer {
+	return NewDenseLayerWithInitializer(inputSize, outputSize, activation, defaultInitializer(activation))
+}
+
+// NewDenseLayerWithInitializer creates a new instance of a fully connected layer with weights set
+// by an initializer. The bias starts at 0, as in a convolution layer.
+func NewDenseLayerWithInitializer(inputSize int, outputSize int, activation ActivationFunction, initializer Initializer) *DenseLayer {
 	inputs := tsr.NewEmptyTensor1D(inputSize)
 	outputs := tsr.NewEmptyTensor1D(outputSize)
 	weights := tsr.NewEmptyTensor2D(inputSize, outputSize)
-	weights.SetRandom(-1.0, 1.0)
-	bias := tsr.NewEmptyTensor1D(outputSize)
-	bias.SetRandom(-1.0, 1.0)
-	prevUpdate := tsr.NewEmptyTensor2D(inputSize, outputSize)
+	initializer.Initialize(weights, inputSize, outputSize)
 	return &DenseLayer{
-		inputShape:  LayerShape{1, inputSize, 1},
-		outputShape: LayerShape{1, outputSize, 1},
-		inputs:      inputs,
-		outputs:     outputs,
-		Weights:     weights,
-		Bias:        bias,
-		PrevUpdate:  prevUpdate,
-		Activation:  activation,
+		inputShape:      LayerShape{1, inputSize, 1},
+		outputShape:     LayerShape{1, outputSize, 1},
+		inputs:          inputs,
+		outputs:         outputs,
+		weightGradients: tsr.NewEmptyTensor2D(inputSize, outputSize),
+		biasGradients:   tsr.NewEmptyTensor1D(outputSize),
+		Weights:         weights,
+		Bias:            tsr.NewEmptyTensor1D(outputSize),
+		Activation:      activation,
 	}
 }
 
-// Copy creates a deep copy of the layer.
+// Copy creates a deep copy of the layer. A copy of a layer with tied weights is not tied, and starts
+// from the transpose of the weights the layer is tied to, since it shares no state with the layer.
+// An auto encoder ties the copies of its decoding layers to the copies of its encoding layers.
 func (layer *DenseLayer) Copy() Layer {
 	newLayer := NewDenseLayer(layer.InputShape().Cols, layer.OutputShape().Cols, layer.Activation)
-	newLayer.Weights.SetTensor(layer.Weights)
+	if layer.tied != nil {
+		tsr.MatrixTranspose(layer.tied.Weights, newLayer.Weights)
+	} else {
+		newLayer.Weights.SetTensor(layer.Weights)
+	}
 	newLayer.Bias.SetTensor(layer.Bias)
+	newLayer.Regularizer = layer.Regularizer
 	return newLayer
 }
 
@@ -63,8 +79,19 @@ func (layer *DenseLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error) {
 	if inputs.Frames != 1 {
 		return nil, fmt.Errorf("Input shape must have frame length of 1, is: %d", inputs.Frames)
 	}
-	layer.inputs.SetTensor(inputs)
-	_, err := tsr.MatrixMultiply(layer.inputs, layer.Weights, layer.outputs)
+	err := checkShape(inputs, layer.inputShape, "input")
+	if err != nil {
+		return nil, err
+	}
+	err = layer.syncTiedWeights()
+	if err != nil {
+		return nil, err
+	}
+	err = layer.inputs.SetTensor(inputs)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tsr.MatrixMultiply(layer.inputs, layer.Weights, layer.outputs)
 	if err != nil {
 		return nil, err
 	}
@@ -76,8 +103,30 @@ func (layer *DenseLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error) {
 	return layer.outputs, nil
 }
 
-// BackPropagate updates the weights and bias of the layer based on a set of deltas and a learning rate.
-func (layer *DenseLayer) BackPropagate(outputs *tsr.Tensor, learningRate float32, momentum float32) (*tsr.Tensor, error) {
+func (layer *DenseLayer) feedForwardBatch(inputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := layer.syncTiedWeights()
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := tsr.MatrixMultiply(inputs, layer.Weights, nil)
+	if err != nil {
+		return nil, err
+	}
+	outputs.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return current + layer.Bias.Get(0, 0, col)
+	})
+	// Each sample is activated on its own, since some activations depend on the whole sample.
+	samples, _ := outputs.Reshape(outputs.Rows, 1, outputs.Cols)
+	for i := 0; i < samples.Frames; i++ {
+		sample, _ := samples.SliceFrames(i, i+1)
+		layer.Activation.Function(sample)
+	}
+	return outputs, nil
+}
+
+// BackPropagate computes the gradients of the weights and bias of the layer from the gradient of
+// its outputs, and returns the gradient of its inputs.
+func (layer *DenseLayer) BackPropagate(outputs *tsr.Tensor) (*tsr.Tensor, error) {
 	if outputs.Frames != 1 {
 		return nil, fmt.Errorf("Input shape must have frame length of 1, is: %d", outputs.Frames)
 	}
@@ -86,56 +135,106 @@ func (layer *DenseLayer) BackPropagate(outputs *tsr.Tensor, learningRate float32
 	if err != nil {
 		return nil, err
 	}
-	gradient.Scale(learningRate)
 	transposedInputs, _ := tsr.MatrixTranspose(layer.inputs, nil)
-	weightChange, err := tsr.MatrixMultiply(transposedInputs, gradient, nil)
+	weightGradient, err := tsr.MatrixMultiply(transposedInputs, gradient, nil)
 	if err != nil {
 		return nil, err
 	}
-	err = layer.Weights.AddTensor(weightChange)
-	if err != nil {
-		return nil, err
+	if layer.tied != nil {
+		// The gradient of tied weights belongs to the layer they are tied to, so it is transposed
+		// back to its shape.
+		weightGradient, _ = tsr.MatrixTranspose(weightGradient, nil)
+		err = layer.tied.weightGradients.AddTensor(weightGradient)
+	} else {
+		err = layer.weightGradients.AddTensor(weightGradient)
 	}
-	layer.PrevUpdate.Scale(momentum)
-	err = layer.Weights.AddTensor(layer.PrevUpdate)
 	if err != nil {
 		return nil, err
 	}
-	err = layer.PrevUpdate.SetTensor(weightChange)
-	if err != nil {
-		return nil, err
-	}
-	err = layer.Bias.AddTensor(gradient)
+	err = layer.biasGradients.AddTensor(gradient)
 	if err != nil {
 		return nil, err
 	}
 	transposedWeights, _ := tsr.MatrixTranspose(layer.Weights, nil)
-	nextDeltas, err := tsr.MatrixMultiply(outputs, transposedWeights, nil)
+	nextDeltas, err := tsr.MatrixMultiply(gradient, transposedWeights, nil)
 	if err != nil {
 		return nil, err
 	}
 	return nextDeltas, nil
 }
 
-// DenseLayerData represents a serialized layer that can be saved to a file.
+// Parameters returns the weights and bias of the layer. A layer with tied weights only returns its
+// bias, since its weights are parameters of the layer they are tied to.
+func (layer *DenseLayer) Parameters() []*tsr.Tensor {
+	if layer.tied != nil {
+		return []*tsr.Tensor{layer.Bias}
+	}
+	return []*tsr.Tensor{layer.Weights, layer.Bias}
+}
+
+// Gradients returns the gradients of the weights and bias.
+func (layer *DenseLayer) Gradients() []*tsr.Tensor {
+	if layer.tied != nil {
+		return []*tsr.Tensor{layer.biasGradients}
+	}
+	return []*tsr.Tensor{layer.weightGradients, layer.biasGradients}
+}
+
+func (layer *DenseLayer) regularizedParameters() (Regularizer, []*tsr.Tensor) {
+	if layer.tied != nil {
+		return layer.Regularizer, []*tsr.Tensor{}
+	}
+	return layer.Regularizer, []*tsr.Tensor{layer.Weights}
+}
+
+// tieWeights makes the weights of the layer the transpose of the weights of another layer, which
+// has the inputs and outputs of the layer swapped, so they are learned only once.
+func (layer *DenseLayer) tieWeights(other *DenseLayer) error {
+	if other.InputShape().Cols != layer.OutputShape().Cols || other.OutputShape().Cols != layer.InputShape().Cols {
+		return fmt.Errorf(
+			"Tied layer must have swapped inputs and outputs: (%d, %d) != (%d, %d)",
+			other.InputShape().Cols, other.OutputShape().Cols, layer.OutputShape().Cols, layer.InputShape().Cols,
+		)
+	}
+	layer.tied = other
+	return layer.syncTiedWeights()
+}
+
+// syncTiedWeights updates the weights of a layer with tied weights to the transpose of the weights
+// they are tied to, since those can change after each update.
+func (layer *DenseLayer) syncTiedWeights() error {
+	if layer.tied == nil {
+		return nil
+	}
+	_, err := tsr.MatrixTranspose(layer.tied.Weights, layer.Weights)
+	return err
+}
+
+// DenseLayerData represents a serialized layer that can be saved to a file. Tied weights are saved
+// as the weights of the layer, so a loaded layer is not tied. An auto encoder saves which of its
+// layers are tied and ties them again when it is loaded.
 type DenseLayerData struct {
-	Type       LayerType      `json:"type"`
-	InputSize  int            `json:"inputSize"`
-	OutputSize int            `json:"outputSize"`
-	Weights    [][]float32    `json:"weights"`
-	Bias       []float32      `json:"bias"`
-	Activation ActivationType `json:"activation"`
+	Type       LayerType          `json:"type"`
+	InputSize  int                `json:"inputSize"`
+	OutputSize int                `json:"outputSize"`
+	Weights    [][]float32        `json:"weights"`
+	Bias       []float32          `json:"bias"`
+	Activation ActivationFunction `json:"activation"`
 }
 
 // MarshalJSON converts the layer to JSON.
 func (layer *DenseLayer) MarshalJSON() ([]byte, error) {
+	err := layer.syncTiedWeights()
+	if err != nil {
+		return nil, err
+	}
 	data := DenseLayerData{
 		Type:       LayerTypeDense,
 		InputSize:  layer.InputShape().Cols,
 		OutputSize: layer.OutputShape().Cols,
 		Weights:    layer.Weights.GetFrame(0),
 		Bias:       layer.Bias.GetFrame(0)[0],
-		Activation: layer.Activation.Type,
+		Activation: layer.Activation,
 	}
 	return json.Marshal(data)
 }
@@ -147,11 +246,34 @@ func (layer *DenseLayer) UnmarshalJSON(b []byte) error {
 	if err != nil {
 		return err
 	}
+	err = checkLoadedShape(LayerShape{1, data.InputSize, 1}, LayerShape{1, data.OutputSize, 1})
+	if err != nil {
+		return err
+	}
+	err = checkLoadedMatrix(data.Weights, "weights")
+	if err != nil {
+		return err
+	}
+	if len(data.Weights) != data.InputSize || len(data.Weights[0]) != data.OutputSize {
+		return fmt.Errorf(
+			"Weights of layer must be %d by %d, are: %d by %d",
+			data.InputSize, data.OutputSize, len(data.Weights), len(data.Weights[0]),
+		)
+	}
+	if len(data.Bias) != data.OutputSize {
+		return fmt.Errorf("Number of biases of layer must be %d, is: %d", data.OutputSize, len(data.Bias))
+	}
+	err = checkLoadedActivation(data.Activation)
+	if err != nil {
+		return err
+	}
 	layer.inputs = tsr.NewEmptyTensor1D(data.InputSize)
 	layer.outputs = tsr.NewEmptyTensor1D(data.OutputSize)
 	layer.Weights = tsr.NewValueTensor2D(data.Weights)
 	layer.Bias = tsr.NewValueTensor1D(data.Bias)
-	layer.Activation = activationFunctionOfType(data.Activation)
+	layer.weightGradients = tsr.NewEmptyTensor2D(data.InputSize, data.OutputSize)
+	layer.biasGradients = tsr.NewEmptyTensor1D(data.OutputSize)
+	layer.Activation = data.Activation
 	layer.inputShape = LayerShape{1, data.InputSize, 1}
 	layer.outputShape = LayerShape{1, data.OutputSize, 1}
 	return nil
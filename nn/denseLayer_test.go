@@ -8,12 +8,17 @@ import (
 
 func TestDenseLayer(t *testing.T) {
 	layer := NewDenseLayer(3, 2, ActivationRELU)
+	layer.Weights.SetTensor(tsr.NewValueTensor2D([][]float32{
+		{0.1, 0.2},
+		{0.3, -0.1},
+		{-0.2, 0.4},
+	}))
+	layer.Bias.SetTensor(tsr.NewValueTensor1D([]float32{0.1, -0.1}))
 
 	originalOutputs := layer.outputs.Copy()
 	originalWeights := layer.Weights.Copy()
 
 	inputs := tsr.NewValueTensor1D([]float32{3, 4, 5})
-	targets := tsr.NewValueTensor1D([]float32{1, 2})
 
 	outputs, err := layer.FeedForward(inputs)
 	if err != nil {
@@ -27,14 +32,50 @@ func TestDenseLayer(t *testing.T) {
 		t.Errorf("Matrix after feed forward should have changed from:\n%swhen result is:\n%s", originalOutputs.String(), outputs.String())
 	}
 
-	targets.SubtractTensor(outputs)
-
-	_, err = layer.BackPropagate(targets, 0.5, 0.0)
+	deltas, err := layer.BackPropagate(tsr.NewValueTensor1D([]float32{1, -2}))
 	if err != nil {
 		t.Fatalf("Error in BackPropagate: %s", err.Error())
 	}
 
+	solutionDeltas := tsr.NewValueTensor1D([]float32{-0.3, 0.5, -1.0})
+	if !deltas.Equals(solutionDeltas) {
+		t.Errorf("Deltas after back propagate should be:\n%swhen result is:\n%s", solutionDeltas.String(), deltas.String())
+	}
+
+	solutionGradients := tsr.NewValueTensor2D([][]float32{
+		{3, -6},
+		{4, -8},
+		{5, -10},
+	})
+	if !layer.weightGradients.Equals(solutionGradients) {
+		t.Errorf("Weight gradients after back propagate should be:\n%swhen result is:\n%s", solutionGradients.String(), layer.weightGradients.String())
+	}
+	if !layer.Weights.Equals(originalWeights) {
+		t.Errorf("Weights should not change until the gradients are applied")
+	}
+
+	err = NewSGDOptimizer(0.5, 0).Update(layer.Weights, layer.weightGradients, 1)
+	if err != nil {
+		t.Fatalf("Error in Update: %s", err.Error())
+	}
 	if layer.Weights.Equals(originalWeights) {
-		t.Errorf("Weights after back propagate should have changed from:\n%swhen result is:\n%s", originalWeights, layer.Weights.String())
+		t.Errorf("Weights after update should have changed from:\n%swhen result is:\n%s", originalWeights, layer.Weights.String())
+	}
+}
+
+func TestDenseLayerInvalidInputs(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+	_, err := neuralNetwork.Predict([][][]float32{{{1, 0}}})
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	_, err = neuralNetwork.Predict([][][]float32{{{1, 0, 1}}})
+	if err == nil {
+		t.Errorf("Input with more columns than the layer did not trigger error")
+	}
+	_, err = NewDenseLayer(2, 1, ActivationSigmoid).FeedForward(tsr.NewEmptyTensor2D(2, 2))
+	if err == nil {
+		t.Errorf("Input with more rows than the layer did not trigger error")
 	}
 }
@@ -0,0 +1,530 @@
+package nn
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+
+	tsr "../tensor"
+)
+
+// ParameterServer synchronizes the parameters of neural networks trained by a number of workers,
+// such as processes on different machines that each train on a shard of the data. Workers can
+// synchronize in rounds, where each worker sends its parameters and receives the average of the
+// parameters of all workers once every worker has sent them. Workers can also train
+// asynchronously, pulling the shared parameters and pushing their changes to them without waiting
+// for each other. A push is rejected if the parameters have been updated more than the maximum
+// staleness number of times since the worker pulled them, which keeps slow workers from undoing
+// newer progress. A round fails if a worker waits longer than the round timeout for the others,
+// such as when another worker has stopped, or waits as long as it takes with a timeout of 0.
+type ParameterServer struct {
+	workers      int
+	mutex        sync.Mutex
+	rounds       map[int]*averagingRound
+	parameters   [][]float32
+	version      int
+	MaxStaleness int
+	RoundTimeout time.Duration
+}
+
+type averagingRound struct {
+	sum    [][]float32
+	count  int
+	err    error
+	failed bool
+	done   chan struct{}
+}
+
+// AverageArgs holds the parameters a worker sends to a parameter server in a round.
+type AverageArgs struct {
+	Round      int
+	Parameters [][]float32
+}
+
+// AverageReply holds the average parameters a parameter server sends back to a worker.
+type AverageReply struct {
+	Parameters [][]float32
+}
+
+// PullArgs holds the parameters of a worker, which a parameter server uses as the shared parameters
+// if it does not have any yet.
+type PullArgs struct {
+	Parameters [][]float32
+}
+
+// PullReply holds the shared parameters of a parameter server and the number of times they have
+// been updated.
+type PullReply struct {
+	Parameters [][]float32
+	Version    int
+}
+
+// PushArgs holds the changes a worker made to the shared parameters since it pulled a version.
+type PushArgs struct {
+	Version int
+	Changes [][]float32
+}
+
+// PushReply holds whether the changes of a worker were applied, along with the latest shared
+// parameters.
+type PushReply struct {
+	Accepted   bool
+	Parameters [][]float32
+	Version    int
+}
+
+// ParameterTransport carries the messages between a worker and a parameter server, such as over a
+// network connection or within the same process.
+type ParameterTransport interface {
+	Average(args AverageArgs) (AverageReply, error)
+	Pull(args PullArgs) (PullReply, error)
+	Push(args PushArgs) (PushReply, error)
+	Close() error
+}
+
+// NewParameterServer creates a new parameter server for a number of workers. The maximum staleness
+// starts as the number of workers, so each worker can fall behind by one push of every other
+// worker. There is no round timeout, so a round waits for every worker.
+func NewParameterServer(workers int) *ParameterServer {
+	return &ParameterServer{
+		workers:      workers,
+		rounds:       map[int]*averagingRound{},
+		MaxStaleness: workers,
+	}
+}
+
+// Serve accepts connections from workers on a listener until the listener is closed, and then
+// returns without an error.
+func (server *ParameterServer) Serve(listener net.Listener) error {
+	rpcServer := rpc.NewServer()
+	err := rpcServer.RegisterName("ParameterServer", &parameterService{server: server})
+	if err != nil {
+		return err
+	}
+	for {
+		connection, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go rpcServer.ServeConn(connection)
+	}
+}
+
+// ListenAndServe listens for workers on a TCP address, such as ":9000", and serves them.
+func (server *ParameterServer) ListenAndServe(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return server.Serve(listener)
+}
+
+// average adds the parameters of a worker to a round and waits for the average of every worker, or
+// until the round times out. A round that timed out stays failed for the workers that arrive late.
+func (server *ParameterServer) average(round int, parameters [][]float32) ([][]float32, error) {
+	server.mutex.Lock()
+	current, ok := server.rounds[round]
+	if !ok {
+		current = &averagingRound{done: make(chan struct{})}
+		server.rounds[round] = current
+	}
+	if current.failed {
+		server.mutex.Unlock()
+		return nil, current.err
+	}
+	if current.sum == nil {
+		current.sum = make([][]float32, len(parameters))
+		for i, parameter := range parameters {
+			current.sum[i] = make([]float32, len(parameter))
+		}
+	}
+	err := addParameters(current.sum, parameters)
+	if err != nil && current.err == nil {
+		current.err = err
+	}
+	current.count++
+	if current.count == server.workers {
+		for _, parameter := range current.sum {
+			for i := range parameter {
+				parameter[i] /= float32(server.workers)
+			}
+		}
+		delete(server.rounds, round)
+		close(current.done)
+	}
+	timeout := server.RoundTimeout
+	server.mutex.Unlock()
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-current.done:
+		case <-timer.C:
+			server.mutex.Lock()
+			if !current.failed && current.count < server.workers {
+				current.err = fmt.Errorf("Round %d timed out with %d of %d workers", round, current.count, server.workers)
+				current.failed = true
+				close(current.done)
+			}
+			server.mutex.Unlock()
+		}
+	} else {
+		<-current.done
+	}
+	if current.err != nil {
+		return nil, current.err
+	}
+	return current.sum, nil
+}
+
+// pull returns the shared parameters, starting them from the given parameters if there are none.
+func (server *ParameterServer) pull(parameters [][]float32) PullReply {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if server.parameters == nil {
+		server.parameters = copyParameters(parameters)
+	}
+	return PullReply{Parameters: copyParameters(server.parameters), Version: server.version}
+}
+
+// push adds the changes of a worker to the shared parameters, unless they are too stale.
+func (server *ParameterServer) push(version int, changes [][]float32) (PushReply, error) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if server.parameters == nil {
+		return PushReply{}, fmt.Errorf("Parameters must be pulled before pushing changes")
+	}
+	reply := PushReply{}
+	if server.version-version <= server.MaxStaleness {
+		err := addParameters(server.parameters, changes)
+		if err != nil {
+			return PushReply{}, err
+		}
+		server.version++
+		reply.Accepted = true
+	}
+	reply.Parameters = copyParameters(server.parameters)
+	reply.Version = server.version
+	return reply, nil
+}
+
+func copyParameters(parameters [][]float32) [][]float32 {
+	copied := make([][]float32, len(parameters))
+	for i, parameter := range parameters {
+		copied[i] = make([]float32, len(parameter))
+		copy(copied[i], parameter)
+	}
+	return copied
+}
+
+func addParameters(sum [][]float32, parameters [][]float32) error {
+	if len(sum) != len(parameters) {
+		return fmt.Errorf("Number of parameters of workers does not match: %d != %d", len(parameters), len(sum))
+	}
+	for i, parameter := range parameters {
+		if len(parameter) != len(sum[i]) {
+			return fmt.Errorf("Size of parameter %d of workers does not match: %d != %d", i, len(parameter), len(sum[i]))
+		}
+		for j, value := range parameter {
+			sum[i][j] += value
+		}
+	}
+	return nil
+}
+
+// parameterService holds the methods of a parameter server that workers call remotely.
+type parameterService struct {
+	server *ParameterServer
+}
+
+// Average adds the parameters of a worker to a round and replies with the average.
+func (service *parameterService) Average(args AverageArgs, reply *AverageReply) error {
+	parameters, err := service.server.average(args.Round, args.Parameters)
+	if err != nil {
+		return err
+	}
+	reply.Parameters = parameters
+	return nil
+}
+
+// Pull replies with the shared parameters.
+func (service *parameterService) Pull(args PullArgs, reply *PullReply) error {
+	*reply = service.server.pull(args.Parameters)
+	return nil
+}
+
+// Push adds the changes of a worker to the shared parameters.
+func (service *parameterService) Push(args PushArgs, reply *PushReply) error {
+	pushReply, err := service.server.push(args.Version, args.Changes)
+	if err != nil {
+		return err
+	}
+	*reply = pushReply
+	return nil
+}
+
+// RPCTransport is a transport that calls a parameter server over a TCP connection.
+type RPCTransport struct {
+	client *rpc.Client
+}
+
+// NewRPCTransport creates a transport connected to a parameter server at a TCP address.
+func NewRPCTransport(address string) (*RPCTransport, error) {
+	client, err := rpc.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &RPCTransport{client: client}, nil
+}
+
+// Average sends the parameters of a worker for a round and waits for the average.
+func (transport *RPCTransport) Average(args AverageArgs) (AverageReply, error) {
+	reply := AverageReply{}
+	err := transport.client.Call("ParameterServer.Average", args, &reply)
+	return reply, err
+}
+
+// Pull requests the shared parameters.
+func (transport *RPCTransport) Pull(args PullArgs) (PullReply, error) {
+	reply := PullReply{}
+	err := transport.client.Call("ParameterServer.Pull", args, &reply)
+	return reply, err
+}
+
+// Push sends the changes of a worker to the shared parameters.
+func (transport *RPCTransport) Push(args PushArgs) (PushReply, error) {
+	reply := PushReply{}
+	err := transport.client.Call("ParameterServer.Push", args, &reply)
+	return reply, err
+}
+
+// Close closes the connection to the parameter server.
+func (transport *RPCTransport) Close() error {
+	return transport.client.Close()
+}
+
+// LocalTransport is a transport that calls a parameter server in the same process, such as for
+// workers that train in separate goroutines.
+type LocalTransport struct {
+	server *ParameterServer
+}
+
+// NewLocalTransport creates a transport that calls a parameter server directly.
+func NewLocalTransport(server *ParameterServer) *LocalTransport {
+	return &LocalTransport{server: server}
+}
+
+// Average sends the parameters of a worker for a round and waits for the average.
+func (transport *LocalTransport) Average(args AverageArgs) (AverageReply, error) {
+	parameters, err := transport.server.average(args.Round, copyParameters(args.Parameters))
+	if err != nil {
+		return AverageReply{}, err
+	}
+	return AverageReply{Parameters: copyParameters(parameters)}, nil
+}
+
+// Pull requests the shared parameters.
+func (transport *LocalTransport) Pull(args PullArgs) (PullReply, error) {
+	return transport.server.pull(args.Parameters), nil
+}
+
+// Push sends the changes of a worker to the shared parameters.
+func (transport *LocalTransport) Push(args PushArgs) (PushReply, error) {
+	return transport.server.push(args.Version, args.Changes)
+}
+
+// Close does nothing, since there is no connection to close.
+func (transport *LocalTransport) Close() error {
+	return nil
+}
+
+// DistributedWorker trains a neural network on a shard of the data and synchronizes its parameters
+// with the other workers through a parameter server.
+type DistributedWorker struct {
+	neuralNetwork *NeuralNetwork
+	transport     ParameterTransport
+	round         int
+	version       int
+	pulled        [][]float32
+}
+
+// NewDistributedWorker creates a worker for a neural network that connects to a parameter server
+// at a TCP address.
+func NewDistributedWorker(neuralNetwork *NeuralNetwork, address string) (*DistributedWorker, error) {
+	transport, err := NewRPCTransport(address)
+	if err != nil {
+		return nil, err
+	}
+	return NewDistributedWorkerWithTransport(neuralNetwork, transport), nil
+}
+
+// NewDistributedWorkerWithTransport creates a worker for a neural network that reaches a parameter
+// server through a transport.
+func NewDistributedWorkerWithTransport(neuralNetwork *NeuralNetwork, transport ParameterTransport) *DistributedWorker {
+	return &DistributedWorker{
+		neuralNetwork: neuralNetwork,
+		transport:     transport,
+	}
+}
+
+// Synchronize replaces the parameters of the neural network with the average of the parameters of
+// every worker. It waits until every worker has called it for the same round.
+func (worker *DistributedWorker) Synchronize() error {
+	args := AverageArgs{Round: worker.round, Parameters: flattenParameters(worker.neuralNetwork.Parameters())}
+	reply, err := worker.transport.Average(args)
+	if err != nil {
+		return err
+	}
+	worker.round++
+	return setParameters(worker.neuralNetwork.Parameters(), reply.Parameters)
+}
+
+// Pull replaces the parameters of the neural network with the shared parameters of the server.
+func (worker *DistributedWorker) Pull() error {
+	reply, err := worker.transport.Pull(PullArgs{Parameters: flattenParameters(worker.neuralNetwork.Parameters())})
+	if err != nil {
+		return err
+	}
+	return worker.adopt(reply.Parameters, reply.Version)
+}
+
+// Push sends the changes made to the parameters since they were last pulled to the server, and
+// then replaces the parameters with the latest shared parameters. It returns whether the changes
+// were applied, which they are not if they are too stale.
+func (worker *DistributedWorker) Push() (bool, error) {
+	if worker.pulled == nil {
+		return false, fmt.Errorf("Parameters must be pulled before pushing changes")
+	}
+	changes := flattenParameters(worker.neuralNetwork.Parameters())
+	for i, parameter := range changes {
+		for j := range parameter {
+			parameter[j] -= worker.pulled[i][j]
+		}
+	}
+	reply, err := worker.transport.Push(PushArgs{Version: worker.version, Changes: changes})
+	if err != nil {
+		return false, err
+	}
+	return reply.Accepted, worker.adopt(reply.Parameters, reply.Version)
+}
+
+func (worker *DistributedWorker) adopt(parameters [][]float32, version int) error {
+	err := setParameters(worker.neuralNetwork.Parameters(), parameters)
+	if err != nil {
+		return err
+	}
+	worker.pulled = parameters
+	worker.version = version
+	return nil
+}
+
+// Train trains the neural network on the shard of the worker in batches for a number of epochs. The
+// workers synchronize before training, so they all start from the same parameters, and again after
+// every epoch, so every worker must train for the same number of epochs. The callbacks of the
+// neural network are notified as in TrainBatch, after the parameters of an epoch are synchronized.
+// A callback that stops training early on one worker leaves the others waiting for it at the next
+// round, so it must stop every worker at the same epoch.
+func (worker *DistributedWorker) Train(inputs [][][][]float32, targets [][][][]float32, batchSize int, epochs int, optimizer Optimizer) error {
+	err := worker.Synchronize()
+	if err != nil {
+		return err
+	}
+	callbacks := append([]Callback{&synchronizeCallback{worker: worker}}, worker.neuralNetwork.callbacks...)
+	schedule := ConstantSchedule(optimizer.LearningRate())
+	return worker.neuralNetwork.trainBatches(inputs, targets, batchSize, epochs, SequentialSampler{}, schedule, optimizer, callbacks, nil)
+}
+
+// TrainAsync trains the neural network on the shard of the worker in batches for a number of
+// epochs without waiting for the other workers. It pulls the shared parameters before training and
+// pushes its changes after every given number of batches, so workers can train for different
+// numbers of epochs and at different speeds. The callbacks of the neural network are not notified.
+func (worker *DistributedWorker) TrainAsync(inputs [][][][]float32, targets [][][][]float32, batchSize int, epochs int, pushEvery int, optimizer Optimizer) error {
+	if len(inputs) != len(targets) {
+		return fmt.Errorf("Number of inputs and targets must match: %d != %d", len(inputs), len(targets))
+	}
+	if batchSize < 1 || pushEvery < 1 {
+		return fmt.Errorf("Batch size and batches between pushes must be at least 1, are: %d, %d", batchSize, pushEvery)
+	}
+	err := worker.Pull()
+	if err != nil {
+		return err
+	}
+	for epoch := 0; epoch < epochs; epoch++ {
+		for start := 0; start < len(inputs); start += batchSize * pushEvery {
+			end := start + batchSize*pushEvery
+			if end > len(inputs) {
+				end = len(inputs)
+			}
+			schedule := ConstantSchedule(optimizer.LearningRate())
+			err = worker.neuralNetwork.trainBatches(inputs[start:end], targets[start:end], batchSize, 1, SequentialSampler{}, schedule, optimizer, nil, nil)
+			if err != nil {
+				return err
+			}
+			_, err = worker.Push()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes the transport of the worker.
+func (worker *DistributedWorker) Close() error {
+	return worker.transport.Close()
+}
+
+// synchronizeCallback synchronizes the parameters of a worker at the end of each epoch, before the
+// other callbacks.
+type synchronizeCallback struct {
+	worker *DistributedWorker
+}
+
+func (callback *synchronizeCallback) OnTrainBegin(neuralNetwork *NeuralNetwork) error {
+	return nil
+}
+
+func (callback *synchronizeCallback) OnEpochEnd(neuralNetwork *NeuralNetwork, epoch int, metrics map[string]float32) error {
+	return callback.worker.Synchronize()
+}
+
+func (callback *synchronizeCallback) OnTrainEnd(neuralNetwork *NeuralNetwork) error {
+	return nil
+}
+
+func flattenParameters(parameters []*tsr.Tensor) [][]float32 {
+	flattened := make([][]float32, len(parameters))
+	for i, parameter := range parameters {
+		values := make([]float32, 0, parameter.Frames*parameter.Rows*parameter.Cols)
+		parameter.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			values = append(values, current)
+			return current
+		})
+		flattened[i] = values
+	}
+	return flattened
+}
+
+func setParameters(parameters []*tsr.Tensor, values [][]float32) error {
+	if len(parameters) != len(values) {
+		return fmt.Errorf("Number of parameters does not match: %d != %d", len(values), len(parameters))
+	}
+	for i, parameter := range parameters {
+		size := parameter.Frames * parameter.Rows * parameter.Cols
+		if len(values[i]) != size {
+			return fmt.Errorf("Size of parameter %d does not match: %d != %d", i, len(values[i]), size)
+		}
+		index := 0
+		parameter.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			index++
+			return values[i][index-1]
+		})
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package nn
+
+import (
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDistributedTraining(t *testing.T) {
+	rand.Seed(1)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error in Listen: %s", err.Error())
+	}
+	server := NewParameterServer(2)
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	// Each worker learns y = x1 from its own shard of the data.
+	shards := [][][][][]float32{
+		{{{{0, 0}}}, {{{1, 1}}}},
+		{{{{1, 0}}}, {{{0, 1}}}},
+	}
+	targets := [][][][][]float32{
+		{{{{0}}}, {{{1}}}},
+		{{{{1}}}, {{{0}}}},
+	}
+	networks := make([]*NeuralNetwork, 2)
+	errors := make(chan error, 2)
+	callbacks := make([]*recordingCallback, 2)
+	for i := range networks {
+		networks[i] = NewNeuralNetwork()
+		networks[i].Add(NewDenseLayer(2, 1, ActivationSigmoid))
+		callbacks[i] = &recordingCallback{stopAt: -1}
+		networks[i].AddCallback(callbacks[i])
+		worker, err := NewDistributedWorker(networks[i], listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Error in NewDistributedWorker: %s", err.Error())
+		}
+		defer worker.Close()
+		go func(i int) {
+			errors <- worker.Train(shards[i], targets[i], 2, 200, NewSGDOptimizer(1, 0))
+		}(i)
+	}
+	for range networks {
+		err := <-errors
+		if err != nil {
+			t.Fatalf("Error in Train: %s", err.Error())
+		}
+	}
+
+	weights := networks[0].LayerAt(0).(*DenseLayer).Weights
+	otherWeights := networks[1].LayerAt(0).(*DenseLayer).Weights
+	if !weights.Equals(otherWeights) {
+		t.Errorf("Workers should end with the same weights:\n%sand:\n%s", weights.String(), otherWeights.String())
+	}
+	for _, input := range [][]float32{{0, 0}, {1, 1}, {1, 0}, {0, 1}} {
+		result, err := networks[0].Predict([][][]float32{{input}})
+		if err != nil {
+			t.Fatalf("Error in Predict: %s", err.Error())
+		}
+		if (input[0] == 1) != (result[0][0][0] > 0.5) {
+			t.Errorf("Incorrect prediction for %v: %.3f", input, result[0][0][0])
+		}
+	}
+
+	// Each worker notifies its callbacks once at the start and end of training.
+	for i, callback := range callbacks {
+		events := callback.events
+		if len(events) != 202 || events[0] != "begin" || events[1] != "epoch 0" || events[201] != "end" {
+			t.Errorf("Callback of worker %d should be notified at the start, 200 epochs and the end, is: %d events", i, len(events))
+		}
+	}
+
+	listener.Close()
+	err = <-served
+	if err != nil {
+		t.Errorf("Serve should return without error after the listener is closed: %s", err.Error())
+	}
+}
+
+func TestDistributedRoundTimeout(t *testing.T) {
+	server := NewParameterServer(2)
+	server.RoundTimeout = 10 * time.Millisecond
+	workers := make([]*DistributedWorker, 2)
+	for i := range workers {
+		neuralNetwork := NewNeuralNetwork()
+		neuralNetwork.Add(NewDenseLayer(1, 1, ActivationSigmoid))
+		workers[i] = NewDistributedWorkerWithTransport(neuralNetwork, NewLocalTransport(server))
+	}
+
+	// The second worker arrives after the round has timed out, and fails without waiting.
+	err := workers[0].Synchronize()
+	if err == nil {
+		t.Errorf("Round with a missing worker did not trigger error")
+	}
+	server.RoundTimeout = 0
+	err = workers[1].Synchronize()
+	if err == nil {
+		t.Errorf("Round that timed out did not trigger error for a late worker")
+	}
+}
+
+func TestDistributedMismatchedWorkers(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error in Listen: %s", err.Error())
+	}
+	defer listener.Close()
+	server := NewParameterServer(2)
+	go server.Serve(listener)
+
+	errors := make(chan error, 2)
+	for _, size := range []int{2, 3} {
+		neuralNetwork := NewNeuralNetwork()
+		neuralNetwork.Add(NewDenseLayer(size, 1, ActivationSigmoid))
+		worker, err := NewDistributedWorker(neuralNetwork, listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Error in NewDistributedWorker: %s", err.Error())
+		}
+		defer worker.Close()
+		go func() {
+			errors <- worker.Synchronize()
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		if <-errors == nil {
+			t.Errorf("Workers with different parameters did not trigger error")
+		}
+	}
+}
+
+func TestDistributedAsyncTraining(t *testing.T) {
+	rand.Seed(1)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error in Listen: %s", err.Error())
+	}
+	defer listener.Close()
+	server := NewParameterServer(2)
+	go server.Serve(listener)
+
+	shards := [][][][][]float32{
+		{{{{0, 0}}}, {{{1, 1}}}},
+		{{{{1, 0}}}, {{{0, 1}}}},
+	}
+	targets := [][][][][]float32{
+		{{{{0}}}, {{{1}}}},
+		{{{{1}}}, {{{0}}}},
+	}
+	networks := make([]*NeuralNetwork, 2)
+	errors := make(chan error, 2)
+	for i := range networks {
+		networks[i] = NewNeuralNetwork()
+		networks[i].Add(NewDenseLayer(2, 1, ActivationSigmoid))
+		worker, err := NewDistributedWorker(networks[i], listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Error in NewDistributedWorker: %s", err.Error())
+		}
+		defer worker.Close()
+		// The second worker trains for fewer epochs, as if it were slower.
+		go func(i int) {
+			errors <- worker.TrainAsync(shards[i], targets[i], 2, 300-100*i, 1, NewSGDOptimizer(1, 0))
+		}(i)
+	}
+	for range networks {
+		err := <-errors
+		if err != nil {
+			t.Fatalf("Error in TrainAsync: %s", err.Error())
+		}
+	}
+
+	final := NewNeuralNetwork()
+	final.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+	worker := NewDistributedWorkerWithTransport(final, NewLocalTransport(server))
+	err = worker.Pull()
+	if err != nil {
+		t.Fatalf("Error in Pull: %s", err.Error())
+	}
+	for _, input := range [][]float32{{0, 0}, {1, 1}, {1, 0}, {0, 1}} {
+		result, err := final.Predict([][][]float32{{input}})
+		if err != nil {
+			t.Fatalf("Error in Predict: %s", err.Error())
+		}
+		if (input[0] == 1) != (result[0][0][0] > 0.5) {
+			t.Errorf("Incorrect prediction for %v: %.3f", input, result[0][0][0])
+		}
+	}
+}
+
+func TestDistributedStaleness(t *testing.T) {
+	server := NewParameterServer(2)
+	server.MaxStaleness = 1
+	workers := make([]*DistributedWorker, 3)
+	for i := range workers {
+		neuralNetwork := NewNeuralNetwork()
+		neuralNetwork.Add(NewDenseLayer(1, 1, ActivationSigmoid))
+		workers[i] = NewDistributedWorkerWithTransport(neuralNetwork, NewLocalTransport(server))
+		err := workers[i].Pull()
+		if err != nil {
+			t.Fatalf("Error in Pull: %s", err.Error())
+		}
+	}
+	for i, worker := range workers {
+		worker.neuralNetwork.LayerAt(0).(*DenseLayer).Weights.Set(0, 0, 0, float32(i+10))
+		accepted, err := worker.Push()
+		if err != nil {
+			t.Fatalf("Error in Push: %s", err.Error())
+		}
+		// The third worker pulled two versions ago, which is more than the maximum staleness.
+		if accepted != (i < 2) {
+			t.Errorf("Push of worker %d should be accepted: %t, is: %t", i, i < 2, accepted)
+		}
+	}
+	if server.version != 2 {
+		t.Errorf("Server version should be: 2, is: %d", server.version)
+	}
+	weight := workers[2].neuralNetwork.LayerAt(0).(*DenseLayer).Weights.Get(0, 0, 0)
+	expected := server.parameters[0][0]
+	if weight != expected {
+		t.Errorf("Rejected worker should have latest weight: %.3f, has: %.3f", expected, weight)
+	}
+}
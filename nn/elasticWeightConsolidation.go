@@ -0,0 +1,110 @@
+package nn
+
+import (
+	"fmt"
+
+	tsr "../tensor"
+)
+
+// ElasticWeightConsolidation is a regularizer for learning tasks one after another without
+// forgetting the earlier ones. After training on a task it records how important each parameter
+// was to it, and then penalizes moving important parameters away from their values.
+type ElasticWeightConsolidation struct {
+	Lambda  float32
+	anchors map[*tsr.Tensor]*tsr.Tensor
+	fisher  map[*tsr.Tensor]*tsr.Tensor
+}
+
+// NewElasticWeightConsolidation creates a new instance of an elastic weight consolidation
+// regularizer, where lambda sets how strongly earlier tasks are protected.
+func NewElasticWeightConsolidation(lambda float32) *ElasticWeightConsolidation {
+	return &ElasticWeightConsolidation{
+		Lambda:  lambda,
+		anchors: map[*tsr.Tensor]*tsr.Tensor{},
+		fisher:  map[*tsr.Tensor]*tsr.Tensor{},
+	}
+}
+
+// Consolidate records the current parameters of a neural network and their importance to a task.
+// The importance is the diagonal of the Fisher information, estimated from the squared gradients of
+// each output with respect to the parameters, averaged over samples of the task. Consolidating
+// after another task adds to the importance and moves the recorded parameters to their new values.
+func (ewc *ElasticWeightConsolidation) Consolidate(neuralNetwork *NeuralNetwork, inputs [][][][]float32) error {
+	if len(inputs) == 0 {
+		return fmt.Errorf("Consolidate requires at least 1 sample")
+	}
+	parameters := neuralNetwork.Parameters()
+	gradients := neuralNetwork.Gradients()
+	for j, parameter := range parameters {
+		stateFor(ewc.fisher, parameter)
+		gradients[j].Scale(0)
+	}
+	for _, input := range inputs {
+		outputs, err := neuralNetwork.feedForward(input)
+		if err != nil {
+			return err
+		}
+		deltas := tsr.NewEmptyTensor3D(outputs.Frames, outputs.Rows, outputs.Cols)
+		for index := 0; index < lossSize(outputs); index++ {
+			frame, row, col := index/(outputs.Rows*outputs.Cols), (index/outputs.Cols)%outputs.Rows, index%outputs.Cols
+			deltas.Scale(0)
+			deltas.Set(frame, row, col, 1)
+			err = neuralNetwork.backPropagateDeltas(deltas)
+			if err != nil {
+				return err
+			}
+			for j, parameter := range parameters {
+				fisher := ewc.fisher[parameter]
+				gradient := gradients[j]
+				fisher.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+					value := gradient.Get(frame, row, col)
+					return current + value*value/float32(len(inputs))
+				})
+				gradient.Scale(0)
+			}
+		}
+	}
+	for _, parameter := range parameters {
+		ewc.anchors[parameter] = parameter.Copy()
+	}
+	return nil
+}
+
+// Penalty computes half of lambda times the squared distance of a parameter from its recorded
+// value, weighted by the importance of each value.
+func (ewc *ElasticWeightConsolidation) Penalty(parameter *tsr.Tensor) float32 {
+	anchor, ok := ewc.anchors[parameter]
+	if !ok {
+		return 0
+	}
+	fisher := ewc.fisher[parameter]
+	sum := float32(0.0)
+	for frame := 0; frame < parameter.Frames; frame++ {
+		for row := 0; row < parameter.Rows; row++ {
+			for col := 0; col < parameter.Cols; col++ {
+				difference := parameter.Get(frame, row, col) - anchor.Get(frame, row, col)
+				sum += fisher.Get(frame, row, col) * difference * difference
+			}
+		}
+	}
+	return ewc.Lambda / 2 * sum
+}
+
+// AddGradient adds the gradient of the penalty to the gradient of a parameter. Parameters that were
+// never consolidated are not penalized.
+func (ewc *ElasticWeightConsolidation) AddGradient(parameter *tsr.Tensor, gradient *tsr.Tensor) error {
+	anchor, ok := ewc.anchors[parameter]
+	if !ok {
+		return nil
+	}
+	err := checkGradient(parameter, gradient)
+	if err != nil {
+		return err
+	}
+	fisher := ewc.fisher[parameter]
+	gradient.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		difference := parameter.Get(frame, row, col) - anchor.Get(frame, row, col)
+		return current + ewc.Lambda*fisher.Get(frame, row, col)*difference
+	})
+	return nil
+}
@@ -0,0 +1,64 @@
+package nn
+
+import (
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestElasticWeightConsolidation(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+
+	taskInputs := [][][][]float32{{{{1, 0}}}, {{{0, 1}}}}
+	taskATargets := [][][][]float32{{{{0.9}}}, {{{0.1}}}}
+	taskBTargets := [][][][]float32{{{{0.1}}}, {{{0.9}}}}
+
+	err := neuralNetwork.TrainBatchSchedule(taskInputs, taskATargets, 1, 500, ConstantSchedule(1), NewSGDOptimizer(1, 0))
+	if err != nil {
+		t.Fatalf("Error in TrainBatchSchedule: %s", err.Error())
+	}
+
+	ewc := NewElasticWeightConsolidation(1000)
+	err = ewc.Consolidate(neuralNetwork, taskInputs)
+	if err != nil {
+		t.Fatalf("Error in Consolidate: %s", err.Error())
+	}
+
+	weights := neuralNetwork.LayerAt(0).(*DenseLayer).Weights
+	anchor := weights.Copy()
+	if ewc.Penalty(weights) != 0 {
+		t.Errorf("Penalty at consolidated parameters should be 0, is: %.5f", ewc.Penalty(weights))
+	}
+	if ewc.Penalty(tsr.NewEmptyTensor1D(1)) != 0 {
+		t.Errorf("Penalty of unknown parameter should be 0")
+	}
+
+	unprotected := neuralNetwork.Copy()
+	neuralNetwork.AddRegularizer(ewc)
+	for _, network := range []*NeuralNetwork{neuralNetwork, unprotected} {
+		err = network.TrainBatchSchedule(taskInputs, taskBTargets, 1, 500, ConstantSchedule(1), NewSGDOptimizer(1, 0))
+		if err != nil {
+			t.Fatalf("Error in TrainBatchSchedule: %s", err.Error())
+		}
+	}
+
+	if ewc.Penalty(weights) <= 0 {
+		t.Errorf("Penalty after moving parameters should be positive, is: %.5f", ewc.Penalty(weights))
+	}
+	distance := func(tensor *tsr.Tensor) float32 {
+		difference := tensor.Copy()
+		difference.SubtractTensor(anchor)
+		return squaredNorm(difference)
+	}
+	protectedDistance := distance(weights)
+	unprotectedDistance := distance(unprotected.LayerAt(0).(*DenseLayer).Weights)
+	if protectedDistance >= unprotectedDistance {
+		t.Errorf("Consolidated weights should move less: %.4f >= %.4f", protectedDistance, unprotectedDistance)
+	}
+
+	err = ewc.Consolidate(neuralNetwork, [][][][]float32{})
+	if err == nil {
+		t.Errorf("Consolidate without samples did not trigger error")
+	}
+}
@@ -0,0 +1,189 @@
+package nn
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	tsr "../tensor"
+)
+
+// EmbeddingLayer maps integer indices, such as the tokens of a text or the categories of a feature,
+// to learned vectors. The input data is a single row of indices, and each index becomes a row of
+// the output data, so the output is a sequence that a recurrent layer can read. Only the rows of
+// the embeddings that were looked up are given to the optimizer.
+type EmbeddingLayer struct {
+	inputShape            LayerShape
+	outputShape           LayerShape
+	indices               []int
+	outputs               *tsr.Tensor
+	embeddingGradients    *tsr.Tensor
+	lookedUp              map[int]bool
+	rowSource             *tsr.Tensor
+	embeddingRows         []*tsr.Tensor
+	embeddingGradientRows []*tsr.Tensor
+	Embeddings            *tsr.Tensor
+}
+
+// NewEmbeddingLayer creates a new instance of an embedding layer for sequences of a length, with a
+// vector of the embedding size for each index of the vocabulary.
+func NewEmbeddingLayer(sequenceLength int, vocabularySize int, embeddingSize int) *EmbeddingLayer {
+	embeddings := tsr.NewEmptyTensor2D(vocabularySize, embeddingSize)
+	embeddings.SetRandom(-1.0, 1.0)
+	return newEmbeddingLayer(sequenceLength, embeddings)
+}
+
+func newEmbeddingLayer(sequenceLength int, embeddings *tsr.Tensor) *EmbeddingLayer {
+	return &EmbeddingLayer{
+		inputShape:         LayerShape{1, sequenceLength, 1},
+		outputShape:        LayerShape{sequenceLength, embeddings.Cols, 1},
+		indices:            make([]int, sequenceLength),
+		outputs:            tsr.NewEmptyTensor2D(sequenceLength, embeddings.Cols),
+		embeddingGradients: tsr.NewEmptyTensor2D(embeddings.Rows, embeddings.Cols),
+		lookedUp:           map[int]bool{},
+		Embeddings:         embeddings,
+	}
+}
+
+// Copy creates a deep copy of the layer.
+func (layer *EmbeddingLayer) Copy() Layer {
+	return newEmbeddingLayer(layer.inputShape.Cols, layer.Embeddings.Copy())
+}
+
+// InputShape returns the rows, columns and frames of the inputs to the layer.
+func (layer *EmbeddingLayer) InputShape() LayerShape {
+	return layer.inputShape
+}
+
+// OutputShape returns the rows, columns and frames of outputs from the layer.
+func (layer *EmbeddingLayer) OutputShape() LayerShape {
+	return layer.outputShape
+}
+
+// FeedForward looks up the embedding of each index of the inputs.
+func (layer *EmbeddingLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error) {
+	if inputs.Frames != 1 || inputs.Rows != 1 || inputs.Cols != layer.inputShape.Cols {
+		return nil, fmt.Errorf(
+			"Invalid input dimensions: (%d, %d, %d) != (1, 1, %d)",
+			inputs.Frames, inputs.Rows, inputs.Cols, layer.inputShape.Cols,
+		)
+	}
+	for col := 0; col < inputs.Cols; col++ {
+		value := inputs.Get(0, 0, col)
+		index := int(value)
+		if float32(index) != value || index < 0 || index >= layer.Embeddings.Rows {
+			return nil, fmt.Errorf("Invalid index at column %d: %f", col, value)
+		}
+		layer.indices[col] = index
+		for i := 0; i < layer.Embeddings.Cols; i++ {
+			layer.outputs.Set(0, col, i, layer.Embeddings.Get(0, index, i))
+		}
+	}
+	return layer.outputs, nil
+}
+
+// BackPropagate adds the deltas of each row of the outputs to the gradient of the embedding that
+// was looked up for it. The indices have no gradient, so the returned deltas are zero.
+func (layer *EmbeddingLayer) BackPropagate(outputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := checkShape(outputs, layer.outputShape, "delta")
+	if err != nil {
+		return nil, err
+	}
+	for row, index := range layer.indices {
+		for col := 0; col < outputs.Cols; col++ {
+			gradient := layer.embeddingGradients.Get(0, index, col)
+			layer.embeddingGradients.Set(0, index, col, gradient+outputs.Get(0, row, col))
+		}
+		layer.lookedUp[index] = true
+	}
+	return tsr.NewEmptyTensor2D(1, layer.inputShape.Cols), nil
+}
+
+// Parameters returns the embeddings of the layer.
+func (layer *EmbeddingLayer) Parameters() []*tsr.Tensor {
+	return []*tsr.Tensor{layer.Embeddings}
+}
+
+// Gradients returns the gradients of the embeddings.
+func (layer *EmbeddingLayer) Gradients() []*tsr.Tensor {
+	return []*tsr.Tensor{layer.embeddingGradients}
+}
+
+// sparseParameters returns views of the rows of the embeddings that were looked up since it was
+// last called, along with views of their gradients. The views of each row are kept, so an
+// optimizer keeps its state for the row between updates.
+func (layer *EmbeddingLayer) sparseParameters() ([]*tsr.Tensor, []*tsr.Tensor) {
+	layer.updateRowViews()
+	indices := []int{}
+	for index := range layer.lookedUp {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	parameters := make([]*tsr.Tensor, len(indices))
+	gradients := make([]*tsr.Tensor, len(indices))
+	for i, index := range indices {
+		parameters[i] = layer.embeddingRows[index]
+		gradients[i] = layer.embeddingGradientRows[index]
+	}
+	layer.lookedUp = map[int]bool{}
+	return parameters, gradients
+}
+
+// parameterParts returns the views of every row of the embeddings.
+func (layer *EmbeddingLayer) parameterParts() []*tsr.Tensor {
+	layer.updateRowViews()
+	return layer.embeddingRows
+}
+
+func (layer *EmbeddingLayer) updateRowViews() {
+	if layer.rowSource != layer.Embeddings {
+		layer.embeddingRows = rowViews(layer.Embeddings)
+		layer.embeddingGradientRows = rowViews(layer.embeddingGradients)
+		layer.rowSource = layer.Embeddings
+	}
+}
+
+func rowViews(matrix *tsr.Tensor) []*tsr.Tensor {
+	rows, _ := matrix.Reshape(matrix.Rows, 1, matrix.Cols)
+	views := make([]*tsr.Tensor, matrix.Rows)
+	for row := range views {
+		views[row], _ = rows.SliceFrames(row, row+1)
+	}
+	return views
+}
+
+// EmbeddingLayerData represents a serialized layer that can be saved to a file.
+type EmbeddingLayerData struct {
+	Type           LayerType   `json:"type"`
+	SequenceLength int         `json:"sequenceLength"`
+	Embeddings     [][]float32 `json:"embeddings"`
+}
+
+// MarshalJSON converts the layer to JSON.
+func (layer *EmbeddingLayer) MarshalJSON() ([]byte, error) {
+	data := EmbeddingLayerData{
+		Type:           LayerTypeEmbedding,
+		SequenceLength: layer.inputShape.Cols,
+		Embeddings:     layer.Embeddings.GetFrame(0),
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new layer from JSON.
+func (layer *EmbeddingLayer) UnmarshalJSON(b []byte) error {
+	data := EmbeddingLayerData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	err = checkLoadedMatrix(data.Embeddings, "embeddings")
+	if err != nil {
+		return err
+	}
+	err = checkLoadedShape(LayerShape{data.SequenceLength, len(data.Embeddings[0]), 1})
+	if err != nil {
+		return err
+	}
+	*layer = *newEmbeddingLayer(data.SequenceLength, tsr.NewValueTensor2D(data.Embeddings))
+	return nil
+}
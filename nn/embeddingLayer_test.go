@@ -0,0 +1,135 @@
+package nn
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestEmbeddingLayerFeedForward(t *testing.T) {
+	embeddings := tsr.NewValueTensor2D([][]float32{
+		{0.1, 0.2},
+		{0.3, 0.4},
+		{0.5, 0.6},
+	})
+	layer := newEmbeddingLayer(3, embeddings)
+	result, err := layer.FeedForward(tsr.NewValueTensor1D([]float32{2, 0, 2}))
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+	solution := tsr.NewValueTensor2D([][]float32{
+		{0.5, 0.6},
+		{0.1, 0.2},
+		{0.5, 0.6},
+	})
+	if !result.Equals(solution) {
+		t.Errorf("Embedding layer output should be:\n%swhen result is:\n%s", solution.String(), result.String())
+	}
+
+	for _, indices := range [][]float32{{0, 1, 3}, {0, -1, 1}, {0, 1.5, 1}} {
+		_, err := layer.FeedForward(tsr.NewValueTensor1D(indices))
+		if err == nil {
+			t.Errorf("Invalid indices %v did not trigger error", indices)
+		}
+	}
+}
+
+func TestEmbeddingLayerBackPropagate(t *testing.T) {
+	embeddings := tsr.NewEmptyTensor2D(4, 2)
+	layer := newEmbeddingLayer(3, embeddings)
+	_, err := layer.FeedForward(tsr.NewValueTensor1D([]float32{1, 3, 1}))
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+	_, err = layer.BackPropagate(tsr.NewValueTensor2D([][]float32{
+		{1, 2},
+		{3, 4},
+		{5, 6},
+	}))
+	if err != nil {
+		t.Fatalf("Error in BackPropagate: %s", err.Error())
+	}
+	solution := tsr.NewValueTensor2D([][]float32{
+		{0, 0},
+		{6, 8},
+		{0, 0},
+		{3, 4},
+	})
+	if !layer.embeddingGradients.Equals(solution) {
+		t.Errorf("Embedding gradients should be:\n%swhen result is:\n%s", solution.String(), layer.embeddingGradients.String())
+	}
+
+	parameters, gradients := layer.sparseParameters()
+	if len(parameters) != 2 || len(gradients) != 2 {
+		t.Fatalf("Only the 2 looked up rows should be trainable, are: %d", len(parameters))
+	}
+	if gradients[1].Get(0, 0, 1) != 4 {
+		t.Errorf("Gradient of row 3 should be: 4, is: %.1f", gradients[1].Get(0, 0, 1))
+	}
+	parameters[1].Set(0, 0, 0, 7)
+	if embeddings.Get(0, 3, 0) != 7 {
+		t.Errorf("Row views should share values with the embeddings")
+	}
+	parameters, _ = layer.sparseParameters()
+	if len(parameters) != 0 {
+		t.Errorf("Looked up rows should be cleared after they are returned")
+	}
+}
+
+func TestEmbeddingLayerSparseUpdate(t *testing.T) {
+	rand.Seed(1)
+	layer := NewEmbeddingLayer(2, 5, 3)
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(
+		layer,
+		NewFlattenLayer(2, 3, 1),
+		NewDenseLayer(6, 1, ActivationSigmoid),
+	)
+	optimizer := NewSGDOptimizer(0.5, 0.9)
+	err := neuralNetwork.Train([][][]float32{{{0, 1}}}, [][][]float32{{{1}}}, optimizer)
+	if err != nil {
+		t.Fatalf("Error in Train: %s", err.Error())
+	}
+	before := layer.Embeddings.Copy()
+
+	// With momentum, the rows of the first step would keep moving if every row were updated.
+	err = neuralNetwork.Train([][][]float32{{{2, 3}}}, [][][]float32{{{0}}}, optimizer)
+	if err != nil {
+		t.Fatalf("Error in Train: %s", err.Error())
+	}
+	for row := 0; row < 5; row++ {
+		changed := false
+		for col := 0; col < 3; col++ {
+			if layer.Embeddings.Get(0, row, col) != before.Get(0, row, col) {
+				changed = true
+			}
+		}
+		if changed != (row == 2 || row == 3) {
+			t.Errorf("Row %d should be updated: %t, is: %t", row, row == 2 || row == 3, changed)
+		}
+	}
+}
+
+func TestEmbeddingLayerJSON(t *testing.T) {
+	layer := NewEmbeddingLayer(4, 10, 3)
+	data, err := json.Marshal(layer)
+	if err != nil {
+		t.Fatalf("Error in MarshalJSON: %s", err.Error())
+	}
+	loaded, err := unmarshalLayer(data)
+	if err != nil {
+		t.Fatalf("Error in UnmarshalJSON: %s", err.Error())
+	}
+	loadedLayer, ok := loaded.(*EmbeddingLayer)
+	if !ok {
+		t.Fatalf("Loaded layer should be an embedding layer")
+	}
+	if loadedLayer.InputShape() != layer.InputShape() || loadedLayer.OutputShape() != layer.OutputShape() {
+		t.Errorf("Loaded layer shapes do not match original")
+	}
+	if !loadedLayer.Embeddings.Equals(layer.Embeddings) {
+		t.Errorf("Loaded embeddings do not match original")
+	}
+}
@@ -0,0 +1,135 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	tsr "../tensor"
+)
+
+// Metric measures how well the predictions of a neural network match their targets, with a
+// prediction and target tensor for each sample.
+type Metric struct {
+	Name     string
+	Function func(predictions []*tsr.Tensor, targets []*tsr.Tensor) (float32, error)
+}
+
+// MetricAccuracy is the fraction of samples that are predicted correctly. Predictions with a single
+// value are correct when they round to the target, while other predictions are correct when their
+// largest value is at the same place as the largest target.
+var MetricAccuracy = Metric{
+	Name: "accuracy",
+	Function: func(predictions []*tsr.Tensor, targets []*tsr.Tensor) (float32, error) {
+		return averageOverSamples(predictions, targets, func(predicted []float32, expected []float32) float32 {
+			if len(predicted) == 1 {
+				if (predicted[0] >= 0.5) == (expected[0] >= 0.5) {
+					return 1
+				}
+			} else if argmax(predicted) == argmax(expected) {
+				return 1
+			}
+			return 0
+		})
+	},
+}
+
+// MetricMeanAbsoluteError is the absolute difference between the predictions and targets, averaged
+// over the values of each sample and then over the samples.
+var MetricMeanAbsoluteError = Metric{
+	Name: "meanAbsoluteError",
+	Function: func(predictions []*tsr.Tensor, targets []*tsr.Tensor) (float32, error) {
+		return averageOverSamples(predictions, targets, func(predicted []float32, expected []float32) float32 {
+			sum := 0.0
+			for i := range predicted {
+				sum += math.Abs(float64(predicted[i] - expected[i]))
+			}
+			return float32(sum / float64(len(predicted)))
+		})
+	},
+}
+
+// Evaluate measures the neural network on samples it may not have been trained on, returning the
+// loss averaged over the samples along with the value of each metric by its name. Timesteps masked
+// by a masking layer do not count toward the loss or the metrics, and samples with every timestep
+// masked are left out of the metrics.
+func (neuralNetwork *NeuralNetwork) Evaluate(inputs [][][][]float32, targets [][][][]float32, loss LossFunction, metrics ...Metric) (float32, map[string]float32, error) {
+	if len(inputs) != len(targets) {
+		return 0, nil, fmt.Errorf("Number of inputs and targets must match: %d != %d", len(inputs), len(targets))
+	}
+	predictions := make([]*tsr.Tensor, 0, len(inputs))
+	targetTensors := make([]*tsr.Tensor, 0, len(inputs))
+	totalLoss := float32(0.0)
+	for i, input := range inputs {
+		outputs, err := neuralNetwork.feedForward(input)
+		if err != nil {
+			return 0, nil, err
+		}
+		targetTensor := tsr.NewValueTensor3D(targets[i])
+		sampleLoss, err := maskedLoss(loss, outputs, targetTensor, neuralNetwork.outputMask)
+		if err != nil {
+			return 0, nil, err
+		}
+		totalLoss += sampleLoss
+		prediction := outputs.Copy()
+		if neuralNetwork.outputMask != nil {
+			timesteps := unmaskedTimesteps(neuralNetwork.outputMask, outputs.Rows)
+			if len(timesteps) == 0 {
+				continue
+			}
+			prediction = selectTimesteps(outputs, timesteps)
+			targetTensor = selectTimesteps(targetTensor, timesteps)
+		}
+		predictions = append(predictions, prediction)
+		targetTensors = append(targetTensors, targetTensor)
+	}
+	if len(inputs) > 0 {
+		totalLoss /= float32(len(inputs))
+	}
+	values := map[string]float32{}
+	for _, metric := range metrics {
+		value, err := metric.Function(predictions, targetTensors)
+		if err != nil {
+			return 0, nil, err
+		}
+		values[metric.Name] = value
+	}
+	return totalLoss, values, nil
+}
+
+func averageOverSamples(predictions []*tsr.Tensor, targets []*tsr.Tensor, score func([]float32, []float32) float32) (float32, error) {
+	if len(predictions) != len(targets) {
+		return 0, fmt.Errorf("Number of predictions and targets must match: %d != %d", len(predictions), len(targets))
+	}
+	if len(predictions) == 0 {
+		return 0, nil
+	}
+	total := float32(0.0)
+	for i, prediction := range predictions {
+		predicted := tensorValues(prediction)
+		expected := tensorValues(targets[i])
+		if len(predicted) != len(expected) {
+			return 0, fmt.Errorf("Number of outputs and targets must match: %d != %d", len(predicted), len(expected))
+		}
+		total += score(predicted, expected)
+	}
+	return total / float32(len(predictions)), nil
+}
+
+func tensorValues(tensor *tsr.Tensor) []float32 {
+	values := make([]float32, 0, tensor.Frames*tensor.Rows*tensor.Cols)
+	tensor.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		values = append(values, current)
+		return current
+	})
+	return values
+}
+
+func argmax(values []float32) int {
+	best := 0
+	for i, value := range values {
+		if value > values[best] {
+			best = i
+		}
+	}
+	return best
+}
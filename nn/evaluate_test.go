@@ -0,0 +1,39 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestEvaluate(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	layer := NewDenseLayer(2, 2, ActivationLinear)
+	layer.Weights.SetTensor(tsr.NewValueTensor2D([][]float32{{1, 0}, {0, 1}}))
+	layer.Bias.SetTensor(tsr.NewValueTensor1D([]float32{0, 0}))
+	neuralNetwork.Add(layer)
+	inputs := [][][][]float32{{{{1, 0}}}, {{{0, 1}}}, {{{2, 3}}}}
+	targets := [][][][]float32{{{{1, 0}}}, {{{0, 1}}}, {{{1, 0}}}}
+
+	loss, metrics, err := neuralNetwork.Evaluate(inputs, targets, LossMSE, MetricAccuracy, MetricMeanAbsoluteError)
+	if err != nil {
+		t.Fatalf("Error in Evaluate: %s", err.Error())
+	}
+	// Only the last sample is wrong, with errors of 1 and 3.
+	if math.Abs(float64(loss)-10.0/6) > 1e-5 {
+		t.Errorf("Loss should be %f, is: %f", 10.0/6, loss)
+	}
+	if fmt.Sprintf("%.3f", metrics["accuracy"]) != "0.667" {
+		t.Errorf("Accuracy should be 0.667, is: %f", metrics["accuracy"])
+	}
+	if math.Abs(float64(metrics["meanAbsoluteError"])-4.0/6) > 1e-5 {
+		t.Errorf("Mean absolute error should be %f, is: %f", 4.0/6, metrics["meanAbsoluteError"])
+	}
+
+	_, _, err = neuralNetwork.Evaluate(inputs, targets[:2], LossMSE)
+	if err == nil {
+		t.Errorf("Evaluating mismatched inputs and targets should have an error")
+	}
+}
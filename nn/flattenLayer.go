@@ -45,7 +45,10 @@ func (layer *FlattenLayer) OutputShape() LayerShape {
 // FeedForward flattens the data from its input shape to a shape of 1 row and 1 frame.
 func (layer *FlattenLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error) {
 	flattenedIndex := 0
-	layer.inputs.SetTensor(inputs)
+	err := layer.inputs.SetTensor(inputs)
+	if err != nil {
+		return nil, err
+	}
 	for frame := 0; frame < inputs.Frames; frame++ {
 		for row := 0; row < inputs.Rows; row++ {
 			for col := 0; col < inputs.Cols; col++ {
@@ -57,9 +60,24 @@ func (layer *FlattenLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error)
 	return layer.outputs, nil
 }
 
-// BackPropagate unflattens the data to its original shape.
-func (layer *FlattenLayer) BackPropagate(outputs *tsr.Tensor, learningRate float32, momentum float32) (*tsr.Tensor, error) {
-	return layer.inputs, nil
+// BackPropagate unflattens the deltas to the original shape of the inputs.
+func (layer *FlattenLayer) BackPropagate(outputs *tsr.Tensor) (*tsr.Tensor, error) {
+	nextDeltas := tsr.NewEmptyTensor3D(layer.inputShape.Frames, layer.inputShape.Rows, layer.inputShape.Cols)
+	err := reshapeInto(outputs, nextDeltas)
+	if err != nil {
+		return nil, err
+	}
+	return nextDeltas, nil
+}
+
+// Parameters returns an empty list, since the layer has no parameters.
+func (layer *FlattenLayer) Parameters() []*tsr.Tensor {
+	return []*tsr.Tensor{}
+}
+
+// Gradients returns an empty list, since the layer has no parameters.
+func (layer *FlattenLayer) Gradients() []*tsr.Tensor {
+	return []*tsr.Tensor{}
 }
 
 // FlattenLayerData represents a serialized layer that can be saved to a file.
@@ -88,6 +106,10 @@ func (layer *FlattenLayer) UnmarshalJSON(b []byte) error {
 	if err != nil {
 		return err
 	}
+	err = checkLoadedShape(LayerShape{data.InputRows, data.InputCols, data.InputFrames})
+	if err != nil {
+		return err
+	}
 	layer.inputs = tsr.NewEmptyTensor3D(data.InputFrames, data.InputRows, data.InputCols)
 	outputSize := data.InputRows * data.InputCols * data.InputFrames
 	layer.outputs = tsr.NewEmptyTensor1D(outputSize)
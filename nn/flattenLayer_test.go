@@ -40,12 +40,25 @@ func TestFlattenLayer(t *testing.T) {
 		t.Errorf("Matrix after feed forward should be:\n%swhen result is:\n%s", solution.String(), flattened.String())
 	}
 
-	unflattened, err := layer.BackPropagate(flattened, 0.0, 0.0)
+	deltas := solution.Copy()
+	deltas.Scale(-0.5)
+	deltaSolution := inputs.Copy()
+	deltaSolution.Scale(-0.5)
+
+	unflattened, err := layer.BackPropagate(deltas)
 	if err != nil {
 		t.Fatalf("Error in BackPropagate: %s", err.Error())
 	}
+	if unflattened.Frames != inputs.Frames {
+		t.Fatalf("Unflattened deltas have incorrect frame length: %d != %d", unflattened.Frames, inputs.Frames)
+	}
+
+	if !unflattened.Equals(deltaSolution) {
+		t.Errorf("Matrix after back propagate should be:\n%swhen result is:\n%s", deltaSolution.String(), unflattened.String())
+	}
 
-	if !unflattened.Equals(inputs) {
-		t.Errorf("Matrix after back propagate should be:\n%swhen result is:\n%s", inputs.String(), unflattened.String())
+	_, err = layer.BackPropagate(tsr.NewEmptyTensor1D(4))
+	if err == nil {
+		t.Errorf("Deltas with incorrect size did not trigger error")
 	}
 }
@@ -0,0 +1,150 @@
+package nn
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func fuzzNeuralNetwork() *NeuralNetwork {
+	convolutionLayer, _ := NewConvolutionLayer(4, 4, 1, []*tsr.Tensor{FilterVerticalEdges}, ActivationRELU)
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(
+		convolutionLayer,
+		NewPoolingLayer(4, 4, 1, 2, PoolingMax),
+		NewFlattenLayer(2, 2, 1),
+		NewDenseLayer(4, 3, ActivationSigmoid),
+		NewSoftmaxLayer(3),
+	)
+	return neuralNetwork
+}
+
+// FuzzNeuralNetworkReadFrom checks that malformed saved neural networks, in JSON or the binary
+// format, produce errors rather than panics, both while loading and while predicting.
+func FuzzNeuralNetworkReadFrom(f *testing.F) {
+	neuralNetwork := fuzzNeuralNetwork()
+	saved, _ := json.Marshal(neuralNetwork)
+	f.Add(saved)
+	layers, _ := json.Marshal(neuralNetwork.layers)
+	f.Add([]byte(`{"layers":` + string(layers) + `}`))
+	binary := bytes.Buffer{}
+	neuralNetwork.writeBinary(&binary)
+	f.Add(binary.Bytes())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		loaded := NewNeuralNetwork()
+		_, err := loaded.ReadFrom(bytes.NewReader(data))
+		if err != nil || loaded.LayerCount() == 0 {
+			return
+		}
+		shape := loaded.LayerAt(0).InputShape()
+		if shape.Rows*shape.Cols*shape.Frames > 1<<16 {
+			return
+		}
+		inputs := tsr.NewEmptyTensor3D(shape.Frames, shape.Rows, shape.Cols)
+		loaded.Predict(inputs.GetAll())
+	})
+}
+
+// FuzzReadONNX checks that malformed ONNX models produce errors rather than panics, both while
+// loading and while predicting.
+func FuzzReadONNX(f *testing.F) {
+	f.Add(onnxDenseModel())
+	f.Add(onnxConvolutionModel())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		loaded := NewNeuralNetwork()
+		err := loaded.readONNX(bytes.NewReader(data))
+		if err != nil || loaded.LayerCount() == 0 {
+			return
+		}
+		shape := loaded.LayerAt(0).InputShape()
+		if shape.Rows*shape.Cols*shape.Frames > 1<<16 {
+			return
+		}
+		inputs := tsr.NewEmptyTensor3D(shape.Frames, shape.Rows, shape.Cols)
+		loaded.Predict(inputs.GetAll())
+	})
+}
+
+// FuzzUnmarshalProto checks that malformed neural networks in the protocol buffer format produce
+// errors rather than panics, both while loading and while predicting.
+func FuzzUnmarshalProto(f *testing.F) {
+	saved, _ := fuzzNeuralNetwork().MarshalProto()
+	f.Add(saved)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		loaded := NewNeuralNetwork()
+		err := loaded.UnmarshalProto(data)
+		if err != nil || loaded.LayerCount() == 0 {
+			return
+		}
+		shape := loaded.LayerAt(0).InputShape()
+		if shape.Rows*shape.Cols*shape.Frames > 1<<16 {
+			return
+		}
+		inputs := tsr.NewEmptyTensor3D(shape.Frames, shape.Rows, shape.Cols)
+		loaded.Predict(inputs.GetAll())
+	})
+}
+
+// FuzzLayerFeedForward checks that inputs of any shape fed to a layer produce errors rather than
+// panics.
+func FuzzLayerFeedForward(f *testing.F) {
+	f.Add(uint8(1), uint8(4), uint8(4))
+	f.Add(uint8(2), uint8(1), uint8(3))
+	f.Add(uint8(0), uint8(0), uint8(0))
+
+	f.Fuzz(func(t *testing.T, frames uint8, rows uint8, cols uint8) {
+		inputs := tsr.NewEmptyTensor3D(int(frames%8), int(rows%8), int(cols%8))
+		for _, layer := range fuzzLayers() {
+			layer.FeedForward(inputs)
+			layer.BackPropagate(inputs)
+		}
+	})
+}
+
+func fuzzLayers() []Layer {
+	layers := []Layer{}
+	neuralNetwork := fuzzNeuralNetwork()
+	for i := 0; i < neuralNetwork.LayerCount(); i++ {
+		layers = append(layers, neuralNetwork.LayerAt(i))
+	}
+	reshapeLayer, _ := NewReshapeLayer(LayerShape{2, 2, 1}, LayerShape{1, 4, 1})
+	timeDistributedLayer, _ := NewTimeDistributedLayer(3, NewDenseLayer(2, 2, ActivationTanh))
+	bidirectionalLayer, _ := NewBidirectionalLayer(NewRecurrentLayer(3, 2, 2, ActivationTanh, true))
+	return append(
+		layers,
+		reshapeLayer,
+		timeDistributedLayer,
+		bidirectionalLayer,
+		NewMaskingLayer(3, 2, 0),
+		NewEmbeddingLayer(3, 5, 2),
+		NewRecurrentLayer(3, 2, 2, ActivationTanh, false),
+	)
+}
+
+func TestUnmarshalMalformedLayers(t *testing.T) {
+	layers := []string{
+		`{"type":"dense","inputSize":2,"outputSize":1,"weights":[[1],[2],[3]],"bias":[0],"activation":"sigmoid"}`,
+		`{"type":"dense","inputSize":2,"outputSize":1,"weights":[[1],[2]],"bias":[0,1],"activation":"sigmoid"}`,
+		`{"type":"dense","inputSize":2,"outputSize":1,"weights":[[1],[2]],"bias":[0]}`,
+		`{"type":"dense","inputSize":2,"outputSize":1,"weights":[[1],[2]],"bias":[0],"activation":""}`,
+		`{"type":"dense","inputSize":-2,"outputSize":1,"weights":[],"bias":[0],"activation":"sigmoid"}`,
+		`{"type":"convolution","inputRows":4,"inputCols":4,"inputFrames":1,"filters":[[[1,2],[3]]],"activation":"relu"}`,
+		`{"type":"convolution","inputRows":4,"inputCols":4,"inputFrames":1,"filters":[[[0,0]]],"activation":"relu"}`,
+		`{"type":"pooling","inputRows":4,"inputCols":4,"inputFrames":1,"poolSize":0}`,
+		`{"type":"flatten","inputRows":100000,"inputCols":100000,"inputFrames":100000}`,
+		`{"type":"softmax","size":0}`,
+		`{"type":"embedding","sequenceLength":3,"embeddings":[]}`,
+		`{"type":"recurrent","timesteps":3,"inputWeights":[[1,2]],"recurrentWeights":[[1]],"bias":[0,0],"activation":"tanh"}`,
+	}
+	for _, layer := range layers {
+		_, err := unmarshalLayer([]byte(layer))
+		if err == nil {
+			t.Errorf("Malformed layer did not trigger error: %s", layer)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	tsr "../tensor"
+)
+
+// GradientCheck is the result of checking the gradients of the parameters of a layer, with the
+// largest relative error between a back propagated gradient and its numerical estimate.
+type GradientCheck struct {
+	Layer            int
+	Type             LayerType
+	MaxRelativeError float32
+}
+
+// CheckGradients compares the gradients that a neural network back propagates for the average loss
+// over samples to numerical gradients, found by moving each parameter up and down by epsilon and
+// measuring the change in the loss. It returns a check for each layer with parameters, where a
+// relative error much above the epsilon points to a mistake in the back propagation of the layer.
+// Gradients smaller than the epsilon are compared by their absolute error instead, and regularizers
+// are left out. The checks run on a copy, so the neural network is not changed.
+func CheckGradients(neuralNetwork *NeuralNetwork, inputs [][][][]float32, targets [][][][]float32, epsilon float32) ([]GradientCheck, error) {
+	if len(inputs) != len(targets) {
+		return nil, fmt.Errorf("Number of inputs and targets must match: %d != %d", len(inputs), len(targets))
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("Must have at least 1 sample")
+	}
+	if epsilon <= 0 {
+		return nil, fmt.Errorf("Epsilon must be positive, is: %f", epsilon)
+	}
+	checked := neuralNetwork.Copy()
+	for _, gradient := range checked.Gradients() {
+		gradient.Scale(0)
+	}
+	for i := range inputs {
+		_, err := checked.backPropagate(inputs[i], targets[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	checks := []GradientCheck{}
+	for i, layer := range checked.layers {
+		parameters := layer.Parameters()
+		if len(parameters) == 0 {
+			continue
+		}
+		gradients := layer.Gradients()
+		maxError := 0.0
+		for j, parameter := range parameters {
+			for frame := 0; frame < parameter.Frames; frame++ {
+				for row := 0; row < parameter.Rows; row++ {
+					for col := 0; col < parameter.Cols; col++ {
+						value := parameter.Get(frame, row, col)
+						parameter.Set(frame, row, col, value+epsilon)
+						lossAbove, err := checked.averageLoss(inputs, targets)
+						if err != nil {
+							return nil, err
+						}
+						parameter.Set(frame, row, col, value-epsilon)
+						lossBelow, err := checked.averageLoss(inputs, targets)
+						if err != nil {
+							return nil, err
+						}
+						parameter.Set(frame, row, col, value)
+
+						numerical := (lossAbove - lossBelow) / (2 * float64(epsilon))
+						analytical := float64(gradients[j].Get(frame, row, col)) / float64(len(inputs))
+						scale := math.Max(math.Max(math.Abs(numerical), math.Abs(analytical)), float64(epsilon))
+						maxError = math.Max(maxError, math.Abs(numerical-analytical)/scale)
+					}
+				}
+			}
+		}
+		checks = append(checks, GradientCheck{Layer: i, Type: typeOfLayer(layer), MaxRelativeError: float32(maxError)})
+	}
+	return checks, nil
+}
+
+// averageLoss feeds samples through the neural network and returns their average loss.
+func (neuralNetwork *NeuralNetwork) averageLoss(inputs [][][][]float32, targets [][][][]float32) (float64, error) {
+	total := 0.0
+	for i := range inputs {
+		outputs, err := neuralNetwork.feedForward(inputs[i])
+		if err != nil {
+			return 0, err
+		}
+		loss, err := maskedLoss(neuralNetwork.loss, outputs, tsr.NewValueTensor3D(targets[i]), neuralNetwork.outputMask)
+		if err != nil {
+			return 0, err
+		}
+		total += float64(loss)
+	}
+	return total / float64(len(inputs)), nil
+}
@@ -0,0 +1,71 @@
+package nn
+
+import (
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestCheckGradients(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	convolutionLayer, _ := NewRandomConvolutionLayer(3, 3, 1, 2, 3, ActivationTanh)
+	neuralNetwork.Add(
+		convolutionLayer,
+		NewFlattenLayer(3, 3, 2),
+		NewDenseLayer(18, 4, ActivationSigmoid),
+		NewDenseLayer(4, 3, ActivationLinear),
+		NewSoftmaxLayer(3),
+	)
+	neuralNetwork.SetLoss(LossCrossEntropy)
+	inputs := [][][][]float32{
+		{{{0.1, 0.5, -0.2}, {0.3, -0.4, 0.8}, {0.0, 0.2, -0.6}}},
+		{{{-0.3, 0.1, 0.4}, {0.7, 0.2, -0.1}, {0.5, -0.5, 0.3}}},
+	}
+	targets := [][][][]float32{{{{1, 0, 0}}}, {{{0, 0, 1}}}}
+
+	checks, err := CheckGradients(neuralNetwork, inputs, targets, 1e-2)
+	if err != nil {
+		t.Fatalf("Error in CheckGradients: %s", err.Error())
+	}
+	if len(checks) != 3 {
+		t.Fatalf("Should have checks for 3 layers, has: %d", len(checks))
+	}
+	if checks[0].Layer != 0 || checks[0].Type != LayerTypeConvolution || checks[2].Layer != 3 {
+		t.Errorf("Checks should be for layers 0, 2 and 3, are: %v", checks)
+	}
+	for _, check := range checks {
+		if check.MaxRelativeError > 0.05 {
+			t.Errorf("Relative error of layer %d should be small, is: %f", check.Layer, check.MaxRelativeError)
+		}
+	}
+	if neuralNetwork.Gradients()[0].Sum() != 0 {
+		t.Errorf("Checking gradients should not change the neural network")
+	}
+}
+
+func TestCheckGradientsWrongDerivative(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 2, ActivationSigmoid))
+	wrongLoss := LossMSE
+	wrongLoss.Derivative = func(outputs *tsr.Tensor, targets *tsr.Tensor) (*tsr.Tensor, error) {
+		return lossGradient(outputs, targets, func(output float32, target float32) float32 {
+			return 2 * (output - target)
+		})
+	}
+	neuralNetwork.SetLoss(wrongLoss)
+	inputs := [][][][]float32{{{{0.5, -0.5}}}}
+	targets := [][][][]float32{{{{1, 0}}}}
+
+	checks, err := CheckGradients(neuralNetwork, inputs, targets, 1e-2)
+	if err != nil {
+		t.Fatalf("Error in CheckGradients: %s", err.Error())
+	}
+	if checks[0].MaxRelativeError < 0.4 {
+		t.Errorf("Relative error of a wrong derivative should be large, is: %f", checks[0].MaxRelativeError)
+	}
+
+	_, err = CheckGradients(neuralNetwork, inputs, targets, 0)
+	if err == nil {
+		t.Errorf("Checking gradients with an epsilon of 0 should have an error")
+	}
+}
@@ -0,0 +1,60 @@
+package nn
+
+import (
+	"math"
+
+	tsr "../tensor"
+)
+
+// gradientUpdate is a parameter with its gradient, waiting for the optimizer to update it.
+type gradientUpdate struct {
+	parameter         *tsr.Tensor
+	gradient          *tsr.Tensor
+	learningRateScale float32
+}
+
+// SetClipNorm sets the largest global norm of the gradients of a training step, which is the square
+// root of the sum of every squared gradient value. Larger gradients are scaled down to the norm
+// before the parameters are updated, keeping their direction, which stops deep or recurrent
+// networks from taking steps so large they diverge. A norm of 0 turns clipping off.
+func (neuralNetwork *NeuralNetwork) SetClipNorm(maxNorm float32) {
+	neuralNetwork.clipNorm = maxNorm
+}
+
+// SetClipValue sets the largest absolute value of each gradient value of a training step, which
+// larger values are clipped to before the parameters are updated. A value of 0 turns clipping off.
+func (neuralNetwork *NeuralNetwork) SetClipValue(maxValue float32) {
+	neuralNetwork.clipValue = maxValue
+}
+
+// clipGradients clips each gradient value and then the global norm of the gradients. Layers frozen
+// with a learning rate scale of 0 are left out of the norm, since they are not updated.
+func (neuralNetwork *NeuralNetwork) clipGradients(updates []gradientUpdate) {
+	if neuralNetwork.clipValue > 0 {
+		maxValue := neuralNetwork.clipValue
+		for _, update := range updates {
+			update.gradient.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+				if current > maxValue {
+					return maxValue
+				} else if current < -maxValue {
+					return -maxValue
+				}
+				return current
+			})
+		}
+	}
+	if neuralNetwork.clipNorm > 0 {
+		sum := 0.0
+		for _, update := range updates {
+			if update.learningRateScale > 0 {
+				sum += float64(squaredNorm(update.gradient))
+			}
+		}
+		norm := float32(math.Sqrt(sum))
+		if norm > neuralNetwork.clipNorm {
+			for _, update := range updates {
+				update.gradient.Scale(neuralNetwork.clipNorm / norm)
+			}
+		}
+	}
+}
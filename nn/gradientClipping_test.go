@@ -0,0 +1,57 @@
+package nn
+
+import (
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func clippingNetwork() (*NeuralNetwork, *DenseLayer) {
+	layer := NewDenseLayer(2, 1, ActivationLinear)
+	layer.Weights.SetTensor(tsr.NewValueTensor2D([][]float32{{0}, {0}}))
+	layer.Bias.SetTensor(tsr.NewValueTensor1D([]float32{0}))
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(layer)
+	return neuralNetwork, layer
+}
+
+func TestClipNorm(t *testing.T) {
+	// The output is 0 and the target 10, so the gradients of the weights are -60 and -80 and the bias
+	// is -20, with a global norm of sqrt(10400).
+	neuralNetwork, layer := clippingNetwork()
+	neuralNetwork.SetClipNorm(1)
+	err := neuralNetwork.Train([][][]float32{{{3, 4}}}, [][][]float32{{{10}}}, NewSGDOptimizer(1, 0))
+	if err != nil {
+		t.Fatalf("Error in Train: %s", err.Error())
+	}
+	norm := math.Sqrt(10400)
+	solution := []float64{60 / norm, 80 / norm, 20 / norm}
+	values := []float32{layer.Weights.Get(0, 0, 0), layer.Weights.Get(0, 1, 0), layer.Bias.Get(0, 0, 0)}
+	for i, value := range values {
+		if math.Abs(float64(value)-solution[i]) > 1e-5 {
+			t.Errorf("Updates should be clipped to a norm of 1 as %v, are: %v", solution, values)
+			break
+		}
+	}
+}
+
+func TestClipValue(t *testing.T) {
+	neuralNetwork, layer := clippingNetwork()
+	neuralNetwork.SetClipValue(30)
+	err := neuralNetwork.Train([][][]float32{{{3, 0.5}}}, [][][]float32{{{10}}}, NewSGDOptimizer(1, 0))
+	if err != nil {
+		t.Fatalf("Error in Train: %s", err.Error())
+	}
+	values := []float32{layer.Weights.Get(0, 0, 0), layer.Weights.Get(0, 1, 0), layer.Bias.Get(0, 0, 0)}
+	solution := []float32{30, 10, 20}
+	for i, value := range values {
+		if math.Abs(float64(value-solution[i])) > 1e-5 {
+			t.Errorf("Updates should be clipped to %v, are: %v", solution, values)
+			break
+		}
+	}
+	if neuralNetwork.Copy().clipValue != 30 {
+		t.Errorf("Copied neural network should keep the clip value")
+	}
+}
@@ -0,0 +1,126 @@
+package nn
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+
+	tsr "../tensor"
+)
+
+// ImageClassifier is a convolutional neural network that sorts images into classes.
+type ImageClassifier struct {
+	Width         int
+	Height        int
+	Channels      int
+	Labels        []string
+	NeuralNetwork *NeuralNetwork
+}
+
+// BuildImageClassifier creates an image classifier for images of a width and height with 1 (gray),
+// 3 (RGB) or 4 (RGBA) channels. The neural network has a convolution layer with 8 random 3x3
+// filters, max pooling, a hidden dense layer and a softmax layer with an output for each class,
+// which works for small images such as icons or handwritten digits. The labels of the classes
+// start as their numbers.
+func BuildImageClassifier(width int, height int, channels int, numClasses int) *ImageClassifier {
+	convolutionLayer, _ := NewRandomConvolutionLayer(height, width, channels, 8, 3, ActivationRELU)
+	poolingLayer := NewPoolingLayer(height, width, convolutionLayer.OutputShape().Frames, 2, PoolingMax)
+	pooledShape := poolingLayer.OutputShape()
+	flattenLayer := NewFlattenLayer(pooledShape.Rows, pooledShape.Cols, pooledShape.Frames)
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(
+		convolutionLayer,
+		poolingLayer,
+		flattenLayer,
+		NewDenseLayer(flattenLayer.OutputShape().Cols, 32, ActivationRELU),
+		NewDenseLayer(32, numClasses, ActivationLinear),
+		NewSoftmaxLayer(numClasses),
+	)
+	neuralNetwork.SetLoss(LossCrossEntropy)
+	labels := make([]string, numClasses)
+	for i := range labels {
+		labels[i] = strconv.Itoa(i)
+	}
+	return &ImageClassifier{
+		Width:         width,
+		Height:        height,
+		Channels:      channels,
+		Labels:        labels,
+		NeuralNetwork: neuralNetwork,
+	}
+}
+
+// Train trains the classifier on images and the number of the class of each image, in batches for
+// a number of epochs.
+func (classifier *ImageClassifier) Train(images []image.Image, classes []int, batchSize int, epochs int, optimizer Optimizer) error {
+	if len(images) != len(classes) {
+		return fmt.Errorf("Number of images and classes must match: %d != %d", len(images), len(classes))
+	}
+	inputs := make([][][][]float32, len(images))
+	targets := make([][][][]float32, len(images))
+	for i, img := range images {
+		if classes[i] < 0 || classes[i] >= len(classifier.Labels) {
+			return fmt.Errorf("Invalid class of image %d: %d", i, classes[i])
+		}
+		input, err := classifier.imageTensor(img)
+		if err != nil {
+			return err
+		}
+		inputs[i] = input.GetAll()
+		target := make([]float32, len(classifier.Labels))
+		target[classes[i]] = 1
+		targets[i] = [][][]float32{{target}}
+	}
+	for epoch := 0; epoch < epochs; epoch++ {
+		err := classifier.NeuralNetwork.TrainBatch(inputs, targets, batchSize, optimizer)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClassifyImage returns the probability of each class for an image, from the most to the least
+// likely class.
+func (classifier *ImageClassifier) ClassifyImage(img image.Image) ([]ClassProbability, error) {
+	input, err := classifier.imageTensor(img)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := classifier.NeuralNetwork.Predict(input.GetAll())
+	if err != nil {
+		return nil, err
+	}
+	return classProbabilities(outputs[0][0], classifier.Labels)
+}
+
+// imageTensor scales an image to the size of the classifier with the nearest pixels, and converts
+// it to a tensor with a frame for each channel and values between 0 and 1.
+func (classifier *ImageClassifier) imageTensor(img image.Image) (*tsr.Tensor, error) {
+	if classifier.Channels != 1 && classifier.Channels != 3 && classifier.Channels != 4 {
+		return nil, fmt.Errorf("Number of channels must be 1, 3 or 4, is: %d", classifier.Channels)
+	}
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil, fmt.Errorf("Image must not be empty")
+	}
+	tensor := tsr.NewEmptyTensor3D(classifier.Channels, classifier.Height, classifier.Width)
+	for row := 0; row < classifier.Height; row++ {
+		y := bounds.Min.Y + row*bounds.Dy()/classifier.Height
+		for col := 0; col < classifier.Width; col++ {
+			x := bounds.Min.X + col*bounds.Dx()/classifier.Width
+			pixel := img.At(x, y)
+			if classifier.Channels == 1 {
+				gray := color.Gray16Model.Convert(pixel).(color.Gray16)
+				tensor.Set(0, row, col, float32(gray.Y)/0xffff)
+				continue
+			}
+			r, g, b, a := pixel.RGBA()
+			for channel, value := range []uint32{r, g, b, a}[:classifier.Channels] {
+				tensor.Set(channel, row, col, float32(value)/0xffff)
+			}
+		}
+	}
+	return tensor, nil
+}
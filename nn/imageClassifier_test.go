@@ -0,0 +1,87 @@
+package nn
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func barImage(vertical bool, position int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, 16, 16))
+	for i := 0; i < 16; i++ {
+		for j := 0; j < 2; j++ {
+			if vertical {
+				img.SetGray(2*position+j, i, color.Gray{Y: 255})
+			} else {
+				img.SetGray(i, 2*position+j, color.Gray{Y: 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestImageClassifierClassifyImage(t *testing.T) {
+	rand.Seed(1)
+	classifier := BuildImageClassifier(8, 8, 3, 4)
+	classifier.Labels = []string{"a", "b", "c", "d"}
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	img.Set(3, 4, color.RGBA{R: 255, A: 255})
+
+	probabilities, err := classifier.ClassifyImage(img)
+	if err != nil {
+		t.Fatalf("Error in ClassifyImage: %s", err.Error())
+	}
+	if len(probabilities) != 4 {
+		t.Fatalf("Number of probabilities should be: 4, is: %d", len(probabilities))
+	}
+	sum := float32(0)
+	for i, probability := range probabilities {
+		sum += probability.Probability
+		if i > 0 && probability.Probability > probabilities[i-1].Probability {
+			t.Errorf("Probabilities should be sorted from most to least likely")
+		}
+	}
+	if sum < 0.999 || sum > 1.001 {
+		t.Errorf("Probabilities should add up to 1, are: %.4f", sum)
+	}
+
+	classifier.Channels = 2
+	_, err = classifier.ClassifyImage(img)
+	if err == nil {
+		t.Errorf("Invalid number of channels did not trigger error")
+	}
+}
+
+func TestImageClassifierTrain(t *testing.T) {
+	rand.Seed(1)
+	classifier := BuildImageClassifier(8, 8, 1, 2)
+	classifier.Labels = []string{"vertical", "horizontal"}
+
+	// The images are twice the size of the classifier, so they are scaled down.
+	images := []image.Image{}
+	classes := []int{}
+	for position := 0; position < 8; position++ {
+		images = append(images, barImage(true, position), barImage(false, position))
+		classes = append(classes, 0, 1)
+	}
+	err := classifier.Train(images, classes, 4, 30, NewAdamOptimizer(0.01))
+	if err != nil {
+		t.Fatalf("Error in Train: %s", err.Error())
+	}
+
+	for i, img := range images {
+		probabilities, err := classifier.ClassifyImage(img)
+		if err != nil {
+			t.Fatalf("Error in ClassifyImage: %s", err.Error())
+		}
+		if probabilities[0].Label != classifier.Labels[classes[i]] {
+			t.Errorf("Image %d should be classified as %s, is: %s", i, classifier.Labels[classes[i]], probabilities[0].Label)
+		}
+	}
+
+	err = classifier.Train(images, []int{0}, 4, 1, NewAdamOptimizer(0.01))
+	if err == nil {
+		t.Errorf("Mismatched images and classes did not trigger error")
+	}
+}
@@ -0,0 +1,83 @@
+package nn
+
+import (
+	"math"
+
+	tsr "../tensor"
+)
+
+// Initializer sets the starting values of the weights of a layer, from the number of inputs and
+// outputs each weight connects, known as the fan in and fan out.
+type Initializer struct {
+	Type       InitializerType
+	Initialize func(weights *tsr.Tensor, fanIn int, fanOut int)
+}
+
+// InitializerType is the identifying type of the initializer.
+type InitializerType string
+
+const (
+	// InitializerTypeUniform is the type for an initializer with values in a fixed range.
+	InitializerTypeUniform = InitializerType("uniform")
+
+	// InitializerTypeXavier is the type for a Xavier or Glorot initializer.
+	InitializerTypeXavier = InitializerType("xavier")
+
+	// InitializerTypeHe is the type for a He initializer.
+	InitializerTypeHe = InitializerType("he")
+
+	// InitializerTypeLeCun is the type for a LeCun initializer.
+	InitializerTypeLeCun = InitializerType("lecun")
+)
+
+// InitXavier sets weights to random values scaled to both the fan in and fan out, which keeps the
+// variance of values the same forwards and backwards through layers with sigmoid or tanh
+// activations.
+var InitXavier = Initializer{
+	Type: InitializerTypeXavier,
+	Initialize: func(weights *tsr.Tensor, fanIn int, fanOut int) {
+		limit := float32(math.Sqrt(6 / float64(fanIn+fanOut)))
+		weights.SetRandom(-limit, limit)
+	},
+}
+
+// InitHe sets weights to random values scaled to the fan in, which makes up for rectified linear
+// units setting half of their inputs to 0.
+var InitHe = Initializer{
+	Type: InitializerTypeHe,
+	Initialize: func(weights *tsr.Tensor, fanIn int, fanOut int) {
+		limit := float32(math.Sqrt(6 / float64(fanIn)))
+		weights.SetRandom(-limit, limit)
+	},
+}
+
+// InitLeCun sets weights to random values scaled to the fan in, with half the variance of InitHe.
+var InitLeCun = Initializer{
+	Type: InitializerTypeLeCun,
+	Initialize: func(weights *tsr.Tensor, fanIn int, fanOut int) {
+		limit := float32(math.Sqrt(3 / float64(fanIn)))
+		weights.SetRandom(-limit, limit)
+	},
+}
+
+// InitUniform creates an initializer that sets weights to random values between min and max,
+// regardless of the size of the layer.
+func InitUniform(min float32, max float32) Initializer {
+	return Initializer{
+		Type: InitializerTypeUniform,
+		Initialize: func(weights *tsr.Tensor, fanIn int, fanOut int) {
+			weights.SetRandom(min, max)
+		},
+	}
+}
+
+// defaultInitializer returns the initializer that suits an activation function, which is InitHe for
+// rectified linear units and InitXavier otherwise.
+func defaultInitializer(activation ActivationFunction) Initializer {
+	switch activation.Type {
+	case ActivationTypeRELU, ActivationTypeLeakyRELU:
+		return InitHe
+	default:
+		return InitXavier
+	}
+}
@@ -0,0 +1,59 @@
+package nn
+
+import (
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestInitializerLimits(t *testing.T) {
+	cases := []struct {
+		initializer Initializer
+		limit       float32
+	}{
+		{InitXavier, float32(math.Sqrt(6.0 / 150.0))},
+		{InitHe, float32(math.Sqrt(6.0 / 100.0))},
+		{InitLeCun, float32(math.Sqrt(3.0 / 100.0))},
+		{InitUniform(-0.1, 0.1), 0.1},
+	}
+	for _, c := range cases {
+		weights := tsr.NewEmptyTensor2D(100, 50)
+		c.initializer.Initialize(weights, 100, 50)
+		largest := float32(0.0)
+		weights.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			if float32(math.Abs(float64(current))) > largest {
+				largest = float32(math.Abs(float64(current)))
+			}
+			return current
+		})
+		if largest > c.limit || largest < c.limit/2 {
+			t.Errorf("Largest weight of %s initializer should be close to but not above %f, is: %f", c.initializer.Type, c.limit, largest)
+		}
+	}
+}
+
+func TestDenseLayerWithInitializer(t *testing.T) {
+	layer := NewDenseLayerWithInitializer(4, 3, ActivationSigmoid, InitUniform(0.5, 0.6))
+	for _, row := range layer.Weights.GetFrame(0) {
+		for _, value := range row {
+			if value < 0.5 || value > 0.6 {
+				t.Fatalf("Weights should all be between 0.5 and 0.6, are:\n%s", layer.Weights.String())
+			}
+		}
+	}
+	if !layer.Bias.Equals(tsr.NewEmptyTensor1D(3)) {
+		t.Errorf("Bias should start at 0, is:\n%s", layer.Bias.String())
+	}
+	if defaultInitializer(ActivationRELU).Type != InitializerTypeHe || defaultInitializer(ActivationTanh).Type != InitializerTypeXavier {
+		t.Errorf("Default initializer should be He for RELU and Xavier for tanh")
+	}
+
+	convolutionLayer, err := NewConvolutionLayerWithInitializer(4, 4, 1, 2, 3, ActivationRELU, InitUniform(0.25, 0.3))
+	if err != nil {
+		t.Fatalf("Error in NewConvolutionLayerWithInitializer: %s", err.Error())
+	}
+	if value := convolutionLayer.Filters[1].Get(0, 2, 2); value < 0.25 || value > 0.3 {
+		t.Errorf("Filters should be set by the initializer, are:\n%s", convolutionLayer.Filters[1].String())
+	}
+}
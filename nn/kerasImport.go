@@ -0,0 +1,357 @@
+package nn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	tsr "../tensor"
+)
+
+// LoadFromFileKeras adds the layers of a Keras Sequential model to the neural network, from the
+// JSON architecture of the model, as saved by model.to_json(), and a JSON file of its weights,
+// as saved by json.dump([w.tolist() for w in model.get_weights()], file). Dense, Conv2D,
+// MaxPooling2D, Flatten, Activation and Dropout layers with channels last data are supported,
+// and Dropout is left out since it does nothing during predictions. Since the convolution layer
+// filters each input channel on its own, a Conv2D must have a single input channel, strides of 1
+// and same padding. Inputs with channels are given to the neural network with a frame for each
+// channel.
+func (neuralNetwork *NeuralNetwork) LoadFromFileKeras(architectureFileName string, weightsFileName string) error {
+	architecture, err := ioutil.ReadFile(architectureFileName)
+	if err != nil {
+		return err
+	}
+	weights, err := ioutil.ReadFile(weightsFileName)
+	if err != nil {
+		return err
+	}
+	layers, err := kerasLayers(architecture, weights)
+	if err != nil {
+		return err
+	}
+	return neuralNetwork.Add(layers...)
+}
+
+type kerasLayer struct {
+	ClassName string           `json:"class_name"`
+	Config    kerasLayerConfig `json:"config"`
+}
+
+type kerasLayerConfig struct {
+	BatchInputShape []*int `json:"batch_input_shape"`
+	BatchShape      []*int `json:"batch_shape"`
+	Units           int    `json:"units"`
+	Filters         int    `json:"filters"`
+	KernelSize      []int  `json:"kernel_size"`
+	Strides         []int  `json:"strides"`
+	DilationRate    []int  `json:"dilation_rate"`
+	PoolSize        []int  `json:"pool_size"`
+	Padding         string `json:"padding"`
+	DataFormat      string `json:"data_format"`
+	Activation      string `json:"activation"`
+	UseBias         *bool  `json:"use_bias"`
+}
+
+// kerasArray is a nested array of weights with its shape and its values in row major order.
+type kerasArray struct {
+	shape  []int
+	values []float32
+}
+
+func kerasLayers(architecture []byte, weightsData []byte) ([]Layer, error) {
+	model := struct {
+		ClassName string          `json:"class_name"`
+		Config    json.RawMessage `json:"config"`
+	}{}
+	err := json.Unmarshal(architecture, &model)
+	if err != nil {
+		return nil, err
+	}
+	if model.ClassName != "Sequential" {
+		return nil, fmt.Errorf("Only Sequential Keras models are supported, model is: %s", model.ClassName)
+	}
+	// Older versions of Keras save the layers as the config itself.
+	config := struct {
+		Layers []kerasLayer `json:"layers"`
+	}{}
+	err = json.Unmarshal(model.Config, &config)
+	if err != nil {
+		err = json.Unmarshal(model.Config, &config.Layers)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(config.Layers) == 0 {
+		return nil, fmt.Errorf("Keras model does not have any layers")
+	}
+
+	rawWeights := []interface{}{}
+	err = json.Unmarshal(weightsData, &rawWeights)
+	if err != nil {
+		return nil, err
+	}
+	weights := make([]kerasArray, len(rawWeights))
+	for i, raw := range rawWeights {
+		weights[i], err = newKerasArray(raw)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Keras weights %d: %s", i, err.Error())
+		}
+	}
+
+	builder := &kerasBuilder{weights: weights}
+	for i, layer := range config.Layers {
+		err = builder.add(layer)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Keras layer %d (%s): %s", i, layer.ClassName, err.Error())
+		}
+	}
+	if builder.next != len(weights) {
+		return nil, fmt.Errorf("Number of weights does not match layers: %d != %d", len(weights), builder.next)
+	}
+	return builder.layers, nil
+}
+
+// kerasBuilder creates layers one Keras layer at a time, following the shape of the data and
+// taking the weights of each layer in order.
+type kerasBuilder struct {
+	layers        []Layer
+	shape         *LayerShape
+	weights       []kerasArray
+	next          int
+	flattenedFrom *LayerShape
+}
+
+func (builder *kerasBuilder) add(layer kerasLayer) error {
+	config := layer.Config
+	if config.DataFormat == "channels_first" {
+		return fmt.Errorf("Only channels last data is supported")
+	}
+	if builder.shape == nil {
+		inputShape := config.BatchInputShape
+		if inputShape == nil {
+			inputShape = config.BatchShape
+		}
+		shape, err := kerasInputShape(inputShape)
+		if err != nil {
+			return err
+		}
+		builder.shape = &shape
+	}
+	shape := *builder.shape
+	flattenedFrom := builder.flattenedFrom
+	builder.flattenedFrom = nil
+
+	var newLayer Layer
+	switch layer.ClassName {
+	case "InputLayer", "Dropout":
+		builder.flattenedFrom = flattenedFrom
+		return nil
+	case "Dense":
+		if shape.Rows != 1 || shape.Frames != 1 {
+			return fmt.Errorf("Inputs must be flat, are: (%d, %d, %d)", shape.Rows, shape.Cols, shape.Frames)
+		}
+		kernel, bias, err := builder.take(config, []int{shape.Cols, config.Units})
+		if err != nil {
+			return err
+		}
+		denseLayer := NewDenseLayer(shape.Cols, config.Units, ActivationLinear)
+		for row := 0; row < shape.Cols; row++ {
+			// Keras flattens channels last, while the flatten layer puts each frame after the other.
+			kerasRow := row
+			if flattenedFrom != nil {
+				area := flattenedFrom.Rows * flattenedFrom.Cols
+				kerasRow = (row%area)*flattenedFrom.Frames + row/area
+			}
+			for col := 0; col < config.Units; col++ {
+				denseLayer.Weights.Set(0, row, col, kernel.values[kerasRow*config.Units+col])
+			}
+		}
+		for col, value := range bias {
+			denseLayer.Bias.Set(0, 0, col, value)
+		}
+		newLayer = denseLayer
+	case "Conv2D":
+		if shape.Frames != 1 {
+			return fmt.Errorf("Only a single input channel is supported, has: %d", shape.Frames)
+		}
+		if len(config.KernelSize) != 2 || config.KernelSize[0] != config.KernelSize[1] || config.KernelSize[0]%2 == 0 {
+			return fmt.Errorf("Kernel must be square with an odd size, is: %v", config.KernelSize)
+		}
+		for _, value := range append(append([]int{}, config.Strides...), config.DilationRate...) {
+			if value != 1 {
+				return fmt.Errorf("Only strides and dilation rates of 1 are supported, are: %v, %v", config.Strides, config.DilationRate)
+			}
+		}
+		if config.Padding != "same" && config.KernelSize[0] != 1 {
+			return fmt.Errorf("Padding must be same, is: %s", config.Padding)
+		}
+		size := config.KernelSize[0]
+		kernel, bias, err := builder.take(config, []int{size, size, 1, config.Filters})
+		if err != nil {
+			return err
+		}
+		filters := make([]*tsr.Tensor, config.Filters)
+		for i := range filters {
+			filters[i] = tsr.NewEmptyTensor2D(size, size)
+			for row := 0; row < size; row++ {
+				for col := 0; col < size; col++ {
+					filters[i].Set(0, row, col, kernel.values[(row*size+col)*config.Filters+i])
+				}
+			}
+		}
+		convolutionLayer, err := NewConvolutionLayer(shape.Rows, shape.Cols, shape.Frames, filters, ActivationLinear)
+		if err != nil {
+			return err
+		}
+		for i, value := range bias {
+			convolutionLayer.Bias.Set(0, 0, i, value)
+		}
+		newLayer = convolutionLayer
+	case "MaxPooling2D":
+		poolSize := config.PoolSize
+		if len(poolSize) != 2 || poolSize[0] != poolSize[1] {
+			return fmt.Errorf("Pool must be square, is: %v", poolSize)
+		}
+		if config.Strides != nil && (len(config.Strides) != 2 || config.Strides[0] != poolSize[0] || config.Strides[1] != poolSize[0]) {
+			return fmt.Errorf("Strides must match the pool size %d, are: %v", poolSize[0], config.Strides)
+		}
+		if config.Padding == "same" {
+			return fmt.Errorf("Padding must be valid, is: %s", config.Padding)
+		}
+		newLayer = NewPoolingLayer(shape.Rows, shape.Cols, shape.Frames, poolSize[0], PoolingMax)
+	case "Flatten":
+		newLayer = NewFlattenLayer(shape.Rows, shape.Cols, shape.Frames)
+		if shape.Frames > 1 {
+			builder.flattenedFrom = &shape
+		}
+	case "Activation":
+		return builder.setActivation(config.Activation)
+	default:
+		return fmt.Errorf("Unsupported Keras layer")
+	}
+	builder.append(newLayer)
+	if layer.ClassName == "Dense" || layer.ClassName == "Conv2D" {
+		return builder.setActivation(config.Activation)
+	}
+	return nil
+}
+
+func (builder *kerasBuilder) append(layer Layer) {
+	builder.layers = append(builder.layers, layer)
+	shape := layer.OutputShape()
+	builder.shape = &shape
+}
+
+// take returns the next kernel of the weights, which must have a shape, and the next bias if the
+// layer uses one, or 0 for each output if it does not.
+func (builder *kerasBuilder) take(config kerasLayerConfig, shape []int) (kerasArray, []float32, error) {
+	outputs := shape[len(shape)-1]
+	if builder.next >= len(builder.weights) {
+		return kerasArray{}, nil, fmt.Errorf("Missing kernel weights")
+	}
+	kernel := builder.weights[builder.next]
+	builder.next++
+	if fmt.Sprint(kernel.shape) != fmt.Sprint(shape) {
+		return kerasArray{}, nil, fmt.Errorf("Kernel must have shape %v, has: %v", shape, kernel.shape)
+	}
+	if config.UseBias != nil && !*config.UseBias {
+		return kernel, make([]float32, outputs), nil
+	}
+	if builder.next >= len(builder.weights) {
+		return kerasArray{}, nil, fmt.Errorf("Missing bias weights")
+	}
+	bias := builder.weights[builder.next]
+	builder.next++
+	if len(bias.shape) != 1 || bias.shape[0] != outputs {
+		return kerasArray{}, nil, fmt.Errorf("Bias must have shape [%d], has: %v", outputs, bias.shape)
+	}
+	return kernel, bias.values, nil
+}
+
+// setActivation sets the activation of the last layer, which must be a dense or convolution layer
+// without an activation. A softmax becomes a SoftmaxLayer after the last layer.
+func (builder *kerasBuilder) setActivation(name string) error {
+	if name == "" || name == "linear" {
+		return nil
+	}
+	if name == "softmax" {
+		shape := *builder.shape
+		if shape.Rows != 1 || shape.Frames != 1 {
+			return fmt.Errorf("Softmax inputs must be flat, are: (%d, %d, %d)", shape.Rows, shape.Cols, shape.Frames)
+		}
+		builder.append(NewSoftmaxLayer(shape.Cols))
+		return nil
+	}
+	activations := map[string]ActivationFunction{
+		"relu":    ActivationRELU,
+		"sigmoid": ActivationSigmoid,
+		"tanh":    ActivationTanh,
+	}
+	activation, ok := activations[name]
+	if !ok {
+		return fmt.Errorf("Unsupported activation: %s", name)
+	}
+	if len(builder.layers) > 0 {
+		switch layer := builder.layers[len(builder.layers)-1].(type) {
+		case *DenseLayer:
+			if layer.Activation.Type == ActivationTypeLinear {
+				layer.Activation = activation
+				return nil
+			}
+		case *ConvolutionLayer:
+			if layer.Activation.Type == ActivationTypeLinear {
+				layer.Activation = activation
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("Activation must follow a Dense or Conv2D layer without an activation")
+}
+
+// kerasInputShape converts the batch input shape of a Keras model, which is either (batch,
+// features) or (batch, height, width, channels), to the shape of a layer.
+func kerasInputShape(batchShape []*int) (LayerShape, error) {
+	if len(batchShape) < 2 {
+		return LayerShape{}, fmt.Errorf("Keras model must have an input shape")
+	}
+	dims := []int{}
+	for _, dim := range batchShape[1:] {
+		if dim == nil || *dim < 1 {
+			return LayerShape{}, fmt.Errorf("Input shape must be fixed apart from the batch size")
+		}
+		dims = append(dims, *dim)
+	}
+	switch len(dims) {
+	case 1:
+		return LayerShape{1, dims[0], 1}, nil
+	case 3:
+		return LayerShape{dims[0], dims[1], dims[2]}, nil
+	default:
+		return LayerShape{}, fmt.Errorf("Input shape must be (batch, features) or (batch, height, width, channels), is: %d dimensions", len(batchShape))
+	}
+}
+
+// newKerasArray flattens a nested array of numbers decoded from JSON.
+func newKerasArray(value interface{}) (kerasArray, error) {
+	switch value := value.(type) {
+	case float64:
+		return kerasArray{shape: []int{}, values: []float32{float32(value)}}, nil
+	case []interface{}:
+		array := kerasArray{shape: []int{len(value)}, values: []float32{}}
+		for i, element := range value {
+			inner, err := newKerasArray(element)
+			if err != nil {
+				return kerasArray{}, err
+			}
+			if i == 0 {
+				array.shape = append(array.shape, inner.shape...)
+			} else if fmt.Sprint(inner.shape) != fmt.Sprint(array.shape[1:]) {
+				return kerasArray{}, fmt.Errorf("Nested arrays must have the same shape")
+			}
+			array.values = append(array.values, inner.values...)
+		}
+		return array, nil
+	default:
+		return kerasArray{}, fmt.Errorf("Weights must be numbers, are: %T", value)
+	}
+}
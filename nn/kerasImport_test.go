@@ -0,0 +1,129 @@
+package nn
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestNeuralNetworkLoadKerasDense(t *testing.T) {
+	architecture := `{
+		"class_name": "Sequential",
+		"config": {
+			"name": "sequential",
+			"layers": [
+				{"class_name": "InputLayer", "config": {"batch_input_shape": [null, 2], "name": "input"}},
+				{"class_name": "Dense", "config": {"units": 3, "activation": "relu", "use_bias": true}},
+				{"class_name": "Dropout", "config": {"rate": 0.5}},
+				{"class_name": "Dense", "config": {"units": 2, "activation": "softmax", "use_bias": false}}
+			]
+		},
+		"keras_version": "2.13.1"
+	}`
+	weights := `[[[1, 0, 1], [0, 1, -1]], [0, 1, -5], [[1, 0], [0, 1], [1, 1]]]`
+	ioutil.WriteFile("keras.json", []byte(architecture), 0644)
+	defer os.Remove("keras.json")
+	ioutil.WriteFile("keras_weights.json", []byte(weights), 0644)
+	defer os.Remove("keras_weights.json")
+
+	neuralNetwork := NewNeuralNetwork()
+	err := neuralNetwork.LoadFromFileKeras("keras.json", "keras_weights.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFileKeras: %s", err.Error())
+	}
+	if neuralNetwork.LayerCount() != 3 {
+		t.Fatalf("Neural network should have 3 layers, has: %d", neuralNetwork.LayerCount())
+	}
+
+	// The hidden values are relu(3, 3, -4) = (3, 3, 0), so the scores are (3, 3).
+	outputs, err := neuralNetwork.Predict([][][]float32{{{3, 2}}})
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	if !tsr.NewValueTensor3D(outputs).Equals(tsr.NewValueTensor1D([]float32{0.5, 0.5})) {
+		t.Errorf("Outputs should be: [0.5 0.5], are: %v", outputs[0][0])
+	}
+}
+
+func TestNeuralNetworkLoadKerasConvolution(t *testing.T) {
+	architecture := `{
+		"class_name": "Sequential",
+		"config": {
+			"layers": [
+				{"class_name": "Conv2D", "config": {
+					"batch_input_shape": [null, 4, 4, 1], "filters": 2, "kernel_size": [3, 3], "strides": [1, 1],
+					"padding": "same", "data_format": "channels_last", "dilation_rate": [1, 1], "activation": "linear"
+				}},
+				{"class_name": "Activation", "config": {"activation": "relu"}},
+				{"class_name": "MaxPooling2D", "config": {"pool_size": [2, 2], "strides": null, "padding": "valid"}},
+				{"class_name": "Flatten", "config": {}},
+				{"class_name": "Dense", "config": {"units": 1, "activation": "linear"}}
+			]
+		}
+	}`
+	// The kernel has the shape (3, 3, 1, 2), with an identity filter and a filter that sums its
+	// neighbors, and the dense kernel has the shape (8, 1).
+	kernel := `[[[[0, 1]], [[0, 1]], [[0, 1]]], [[[0, 1]], [[1, 1]], [[0, 1]]], [[[0, 1]], [[0, 1]], [[0, 1]]]]`
+	dense := `[[1], [2], [3], [4], [5], [6], [7], [8]]`
+	weights := `[` + kernel + `, [-0.5, 0.25], ` + dense + `, [1]]`
+	layers, err := kerasLayers([]byte(architecture), []byte(weights))
+	if err != nil {
+		t.Fatalf("Error in kerasLayers: %s", err.Error())
+	}
+	neuralNetwork := NewNeuralNetwork()
+	err = neuralNetwork.Add(layers...)
+	if err != nil {
+		t.Fatalf("Error in Add: %s", err.Error())
+	}
+
+	identity := tsr.NewValueTensor2D([][]float32{{0, 0, 0}, {0, 1, 0}, {0, 0, 0}})
+	sum := tsr.NewValueTensor2D([][]float32{{1, 1, 1}, {1, 1, 1}, {1, 1, 1}})
+	convolutionLayer, err := NewConvolutionLayer(4, 4, 1, []*tsr.Tensor{identity, sum}, ActivationRELU)
+	if err != nil {
+		t.Fatalf("Error in NewConvolutionLayer: %s", err.Error())
+	}
+	convolutionLayer.Bias = tsr.NewValueTensor1D([]float32{-0.5, 0.25})
+	features := NewNeuralNetwork()
+	features.Add(convolutionLayer, NewPoolingLayer(4, 4, 2, 2, PoolingMax))
+
+	inputs := [][][]float32{{{1, 0, 2, 0}, {0, 3, 0, 1}, {1, 1, 0, 0}, {0, 2, 1, 1}}}
+	outputs, err := neuralNetwork.Predict(inputs)
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	// Keras flattens the pooled features with the channels last, as (row, col, channel).
+	pooled, _ := features.Predict(inputs)
+	solution := float32(1)
+	index := 0
+	for row := 0; row < 2; row++ {
+		for col := 0; col < 2; col++ {
+			for channel := 0; channel < 2; channel++ {
+				index++
+				solution += pooled[channel][row][col] * float32(index)
+			}
+		}
+	}
+	if outputs[0][0][0] != solution {
+		t.Errorf("Output should be: %f, is: %f", solution, outputs[0][0][0])
+	}
+}
+
+func TestNeuralNetworkLoadKerasUnsupported(t *testing.T) {
+	dense := `{"class_name": "Dense", "config": {"batch_input_shape": [null, 2], "units": 1}}`
+	models := map[string][2]string{
+		"functional model":   {`{"class_name": "Functional", "config": {"layers": []}}`, `[]`},
+		"unsupported layer":  {`{"class_name": "Sequential", "config": {"layers": [{"class_name": "LSTM", "config": {"batch_input_shape": [null, 2]}}]}}`, `[]`},
+		"missing weights":    {`{"class_name": "Sequential", "config": {"layers": [` + dense + `]}}`, `[[[1], [2]]]`},
+		"extra weights":      {`{"class_name": "Sequential", "config": {"layers": [` + dense + `]}}`, `[[[1], [2]], [0], [0]]`},
+		"mismatched weights": {`{"class_name": "Sequential", "config": {"layers": [` + dense + `]}}`, `[[[1], [2], [3]], [0]]`},
+		"ragged weights":     {`{"class_name": "Sequential", "config": {"layers": [` + dense + `]}}`, `[[[1], [2, 3]], [0]]`},
+	}
+	for name, model := range models {
+		_, err := kerasLayers([]byte(model[0]), []byte(model[1]))
+		if err == nil {
+			t.Errorf("Loading a Keras model with %s did not trigger error", name)
+		}
+	}
+}
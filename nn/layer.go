@@ -1,18 +1,39 @@
 package nn
 
 import (
+	"encoding/json"
 	"fmt"
 
 	tsr "../tensor"
 )
 
-// Layer is a stage of data computation in a neural network.
+// Layer is a stage of data computation in a neural network. BackPropagate takes the gradient of the
+// loss with respect to the outputs of the last feed forward, adds to the gradients of any parameters
+// of the layer, and returns the gradient with respect to the inputs. Parameters returns the tensors
+// that an optimizer updates, which can be read and changed in place, and Gradients returns their
+// gradients in the same order. Layers without parameters return empty lists.
 type Layer interface {
 	Copy() Layer
 	InputShape() LayerShape
 	OutputShape() LayerShape
 	FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error)
-	BackPropagate(outputs *tsr.Tensor, learningRate float32, momentum float32) (*tsr.Tensor, error)
+	BackPropagate(outputs *tsr.Tensor) (*tsr.Tensor, error)
+	Parameters() []*tsr.Tensor
+	Gradients() []*tsr.Tensor
+}
+
+// sparseLayer is a trainable layer where only some parts of the parameters get gradients in each
+// step, such as the looked up rows of an embedding. Only those parts are given to the optimizer,
+// and parameterParts returns all of the parts the optimizer may have been given.
+type sparseLayer interface {
+	sparseParameters() ([]*tsr.Tensor, []*tsr.Tensor)
+	parameterParts() []*tsr.Tensor
+}
+
+// batchLayer is a layer that can feed forward a batch of samples at once, where each sample is a
+// single row and the samples are stacked along the rows of a single frame.
+type batchLayer interface {
+	feedForwardBatch(inputs *tsr.Tensor) (*tsr.Tensor, error)
 }
 
 // LayerType represents the type of layer.
@@ -30,13 +51,88 @@ const (
 
 	// LayerTypeFlatten flattens the frames and rows of the data to 1.
 	LayerTypeFlatten = LayerType("flatten")
+
+	// LayerTypeTimeDistributed applies an inner layer to every timestep of a sequence.
+	LayerTypeTimeDistributed = LayerType("timeDistributed")
+
+	// LayerTypeMasking masks the padded timesteps of a sequence.
+	LayerTypeMasking = LayerType("masking")
+
+	// LayerTypeBidirectional runs a sequence layer over the data in both directions.
+	LayerTypeBidirectional = LayerType("bidirectional")
+
+	// LayerTypeReshape changes the rows, columns and frames of the data.
+	LayerTypeReshape = LayerType("reshape")
+
+	// LayerTypeRecurrent keeps a hidden state over the timesteps of a sequence.
+	LayerTypeRecurrent = LayerType("recurrent")
+
+	// LayerTypeEmbedding maps indices to learned vectors.
+	LayerTypeEmbedding = LayerType("embedding")
+
+	// LayerTypeSoftmax turns scores into a probability distribution.
+	LayerTypeSoftmax = LayerType("softmax")
 )
 
 // LayerShape is the rows, columns and frames of the data used in the layer.
 type LayerShape struct {
-	Rows   int
-	Cols   int
-	Frames int
+	Rows   int `json:"rows"`
+	Cols   int `json:"cols"`
+	Frames int `json:"frames"`
+}
+
+// checkShape makes sure a tensor given to a layer, such as its inputs or the deltas of its outputs,
+// has the shape the layer expects, so malformed tensors cause an error rather than indexing out of
+// range.
+func checkShape(tensor *tsr.Tensor, shape LayerShape, name string) error {
+	if tensor.Frames != shape.Frames || tensor.Rows != shape.Rows || tensor.Cols != shape.Cols {
+		return fmt.Errorf(
+			"Invalid %s dimensions: (%d, %d, %d) != (%d, %d, %d)",
+			name, tensor.Frames, tensor.Rows, tensor.Cols, shape.Frames, shape.Rows, shape.Cols,
+		)
+	}
+	return nil
+}
+
+// maxLoadedSize is the largest number of values in a tensor of a layer read from a saved neural
+// network, so malformed sizes cause an error rather than running out of memory.
+const maxLoadedSize = 1 << 26
+
+// checkLoadedShape makes sure each dimension of the shapes read from a saved layer is at least 1,
+// and that tensors of the shapes are not too large.
+func checkLoadedShape(shapes ...LayerShape) error {
+	for _, shape := range shapes {
+		if shape.Rows < 1 || shape.Cols < 1 || shape.Frames < 1 {
+			return fmt.Errorf("Dimensions of layer must be at least 1, are: %s", shapeString(shape))
+		}
+		if shape.Rows > maxLoadedSize/shape.Cols/shape.Frames {
+			return fmt.Errorf("Dimensions of layer are too large: %s", shapeString(shape))
+		}
+	}
+	return nil
+}
+
+// checkLoadedMatrix makes sure values read from a saved layer have at least one row and column, and
+// that every row has the same number of columns.
+func checkLoadedMatrix(values [][]float32, name string) error {
+	if len(values) == 0 || len(values[0]) == 0 {
+		return fmt.Errorf("The %s of layer must not be empty", name)
+	}
+	for _, row := range values {
+		if len(row) != len(values[0]) {
+			return fmt.Errorf("Rows of the %s of layer must have the same length: %d != %d", name, len(row), len(values[0]))
+		}
+	}
+	return nil
+}
+
+// checkLoadedActivation makes sure a saved layer has an activation, since a missing one is left
+// without functions.
+func checkLoadedActivation(activation ActivationFunction) error {
+	if activation.Function == nil || activation.Derivative == nil {
+		return fmt.Errorf("Layer must have an activation")
+	}
+	return nil
 }
 
 func layerForType(layerType LayerType) (Layer, error) {
@@ -49,7 +145,69 @@ func layerForType(layerType LayerType) (Layer, error) {
 		return &PoolingLayer{}, nil
 	case LayerTypeFlatten:
 		return &FlattenLayer{}, nil
+	case LayerTypeTimeDistributed:
+		return &TimeDistributedLayer{}, nil
+	case LayerTypeMasking:
+		return &MaskingLayer{}, nil
+	case LayerTypeBidirectional:
+		return &BidirectionalLayer{}, nil
+	case LayerTypeReshape:
+		return &ReshapeLayer{}, nil
+	case LayerTypeRecurrent:
+		return &RecurrentLayer{}, nil
+	case LayerTypeEmbedding:
+		return &EmbeddingLayer{}, nil
+	case LayerTypeSoftmax:
+		return &SoftmaxLayer{}, nil
 	default:
 		return nil, fmt.Errorf("Invalid layer type: %s", layerType)
 	}
 }
+
+func typeOfLayer(layer Layer) LayerType {
+	switch layer.(type) {
+	case *DenseLayer:
+		return LayerTypeDense
+	case *ConvolutionLayer:
+		return LayerTypeConvolution
+	case *PoolingLayer:
+		return LayerTypePooling
+	case *FlattenLayer:
+		return LayerTypeFlatten
+	case *TimeDistributedLayer:
+		return LayerTypeTimeDistributed
+	case *MaskingLayer:
+		return LayerTypeMasking
+	case *BidirectionalLayer:
+		return LayerTypeBidirectional
+	case *ReshapeLayer:
+		return LayerTypeReshape
+	case *RecurrentLayer:
+		return LayerTypeRecurrent
+	case *EmbeddingLayer:
+		return LayerTypeEmbedding
+	case *SoftmaxLayer:
+		return LayerTypeSoftmax
+	default:
+		return LayerType("unknown")
+	}
+}
+
+func unmarshalLayer(b []byte) (Layer, error) {
+	layerData := struct {
+		Type LayerType `json:"type"`
+	}{}
+	err := json.Unmarshal(b, &layerData)
+	if err != nil {
+		return nil, err
+	}
+	layer, err := layerForType(layerData.Type)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, layer)
+	if err != nil {
+		return nil, err
+	}
+	return layer, nil
+}
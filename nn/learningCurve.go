@@ -0,0 +1,91 @@
+package nn
+
+import (
+	"fmt"
+
+	tsr "../tensor"
+)
+
+// LearningCurvePoint is the error of a neural network trained on a fraction of the training data.
+type LearningCurvePoint struct {
+	Fraction        float32
+	TrainingSize    int
+	TrainingError   float32
+	ValidationError float32
+}
+
+// LearningCurve trains a new neural network on each of the given fractions of the training data and
+// measures its mean squared error on the data it was trained on and on the validation data. A large
+// gap between the errors means the network overfits, while high errors for both mean it underfits.
+// Each fraction uses the first samples of the training data, so the data should be shuffled first.
+// Every network is trained with a new optimizer.
+func LearningCurve(
+	newNeuralNetwork func() *NeuralNetwork,
+	inputs [][][][]float32,
+	targets [][][][]float32,
+	validationInputs [][][][]float32,
+	validationTargets [][][][]float32,
+	fractions []float32,
+	epochs int,
+	newOptimizer func() Optimizer,
+) ([]LearningCurvePoint, error) {
+	if len(inputs) != len(targets) || len(validationInputs) != len(validationTargets) {
+		return nil, fmt.Errorf("Number of inputs and targets must match")
+	}
+	points := []LearningCurvePoint{}
+	for _, fraction := range fractions {
+		if fraction <= 0 || fraction > 1 {
+			return nil, fmt.Errorf("Fraction must be between 0 and 1, is: %f", fraction)
+		}
+		size := int(fraction * float32(len(inputs)))
+		if size < 1 {
+			size = 1
+		}
+		neuralNetwork := newNeuralNetwork()
+		optimizer := newOptimizer()
+		for epoch := 0; epoch < epochs; epoch++ {
+			for i := 0; i < size; i++ {
+				err := neuralNetwork.Train(inputs[i], targets[i], optimizer)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		trainingError, err := neuralNetwork.meanSquaredError(inputs[:size], targets[:size])
+		if err != nil {
+			return nil, err
+		}
+		validationError, err := neuralNetwork.meanSquaredError(validationInputs, validationTargets)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, LearningCurvePoint{
+			Fraction:        fraction,
+			TrainingSize:    size,
+			TrainingError:   trainingError,
+			ValidationError: validationError,
+		})
+	}
+	return points, nil
+}
+
+func (neuralNetwork *NeuralNetwork) meanSquaredError(inputs [][][][]float32, targets [][][][]float32) (float32, error) {
+	if len(inputs) == 0 {
+		return 0, nil
+	}
+	total := float32(0.0)
+	for i, input := range inputs {
+		outputs, err := neuralNetwork.feedForward(input)
+		if err != nil {
+			return 0, err
+		}
+		errors := tsr.NewValueTensor3D(targets[i])
+		err = errors.SubtractTensor(outputs)
+		if err != nil {
+			return 0, err
+		}
+		errors.ScaleTensor(errors.Copy())
+		total += errors.Sum() / float32(errors.Frames*errors.Rows*errors.Cols)
+	}
+	return total / float32(len(inputs)), nil
+}
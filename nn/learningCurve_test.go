@@ -0,0 +1,44 @@
+package nn
+
+import "testing"
+
+func TestLearningCurve(t *testing.T) {
+	inputs := [][][][]float32{}
+	targets := [][][][]float32{}
+	for i := 0; i < 20; i++ {
+		x := float32(i) / 20
+		inputs = append(inputs, [][][]float32{{{x}}})
+		targets = append(targets, [][][]float32{{{x / 2}}})
+	}
+	newNeuralNetwork := func() *NeuralNetwork {
+		neuralNetwork := NewNeuralNetwork()
+		neuralNetwork.Add(NewDenseLayer(1, 1, ActivationSigmoid))
+		return neuralNetwork
+	}
+	newOptimizer := func() Optimizer {
+		return NewSGDOptimizer(0.5, 0)
+	}
+
+	points, err := LearningCurve(newNeuralNetwork, inputs[:16], targets[:16], inputs[16:], targets[16:], []float32{0.25, 0.5, 1}, 20, newOptimizer)
+	if err != nil {
+		t.Fatalf("Error in LearningCurve: %s", err.Error())
+	}
+	if len(points) != 3 {
+		t.Fatalf("Learning curve should have 3 points, has: %d", len(points))
+	}
+
+	sizes := []int{4, 8, 16}
+	for i, point := range points {
+		if point.TrainingSize != sizes[i] {
+			t.Errorf("Incorrect training size for fraction %.2f: %d != %d", point.Fraction, point.TrainingSize, sizes[i])
+		}
+		if point.TrainingError < 0 || point.ValidationError < 0 {
+			t.Errorf("Errors must not be negative: %+v", point)
+		}
+	}
+
+	_, err = LearningCurve(newNeuralNetwork, inputs, targets, inputs, targets, []float32{1.5}, 1, newOptimizer)
+	if err == nil {
+		t.Errorf("Did not trigger error on invalid fraction")
+	}
+}
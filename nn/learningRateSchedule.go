@@ -0,0 +1,64 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+)
+
+// LearningRateSchedule computes the learning rate to use at a training step, starting from step 0.
+type LearningRateSchedule func(step int) float32
+
+// ConstantSchedule creates a schedule that uses the same learning rate at every step.
+func ConstantSchedule(learningRate float32) LearningRateSchedule {
+	return func(step int) float32 {
+		return learningRate
+	}
+}
+
+// WarmupSchedule creates a schedule that increases the learning rate linearly from 0 over a number
+// of warmup steps, and then follows the given schedule.
+func WarmupSchedule(warmupSteps int, schedule LearningRateSchedule) LearningRateSchedule {
+	return func(step int) float32 {
+		if step < warmupSteps {
+			return schedule(warmupSteps) * float32(step+1) / float32(warmupSteps+1)
+		}
+		return schedule(step)
+	}
+}
+
+// OneCycleSchedule creates a schedule that increases the learning rate from a 25th of the maximum
+// to the maximum over the first 30% of the steps, and then anneals it along a cosine curve to a
+// 10000th of its starting value by the last step.
+func OneCycleSchedule(maxLearningRate float32, totalSteps int) LearningRateSchedule {
+	initial := float64(maxLearningRate) / 25
+	final := initial / 10000
+	peakStep := int(0.3 * float64(totalSteps))
+	return func(step int) float32 {
+		if step >= totalSteps-1 {
+			return float32(final)
+		}
+		if step < peakStep {
+			return float32(cosineAnneal(initial, float64(maxLearningRate), float64(step)/float64(peakStep)))
+		}
+		progress := float64(step-peakStep) / float64(totalSteps-1-peakStep)
+		return float32(cosineAnneal(float64(maxLearningRate), final, progress))
+	}
+}
+
+// TriangularSchedule creates a cyclical schedule that moves the learning rate linearly between a
+// base and a maximum value and back again, with each half of the cycle lasting a number of steps.
+// The step size must be positive.
+func TriangularSchedule(baseLearningRate float32, maxLearningRate float32, stepSize int) (LearningRateSchedule, error) {
+	if stepSize <= 0 {
+		return nil, fmt.Errorf("Step size must be positive, is: %d", stepSize)
+	}
+	return func(step int) float32 {
+		cycle := math.Floor(1 + float64(step)/float64(2*stepSize))
+		x := math.Abs(float64(step)/float64(stepSize) - 2*cycle + 1)
+		return baseLearningRate + (maxLearningRate-baseLearningRate)*float32(math.Max(0, 1-x))
+	}, nil
+}
+
+func cosineAnneal(start float64, end float64, progress float64) float64 {
+	return end + (start-end)*(1+math.Cos(math.Pi*progress))/2
+}
@@ -0,0 +1,89 @@
+package nn
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWarmupSchedule(t *testing.T) {
+	schedule := WarmupSchedule(3, ConstantSchedule(0.4))
+
+	solution := []float32{0.1, 0.2, 0.3, 0.4, 0.4}
+	for step, learningRate := range solution {
+		if math.Abs(float64(schedule(step)-learningRate)) > 1e-6 {
+			t.Errorf("Learning rate at step %d should be %.3f, is: %.3f", step, learningRate, schedule(step))
+		}
+	}
+}
+
+func TestOneCycleSchedule(t *testing.T) {
+	schedule := OneCycleSchedule(1.0, 11)
+
+	if math.Abs(float64(schedule(0))-0.04) > 1e-6 {
+		t.Errorf("Learning rate at first step should be 0.04, is: %.5f", schedule(0))
+	}
+	if schedule(3) != 1.0 {
+		t.Errorf("Learning rate at peak step should be 1.0, is: %.5f", schedule(3))
+	}
+	if math.Abs(float64(schedule(10))-0.000004) > 1e-9 {
+		t.Errorf("Learning rate at last step should be 0.000004, is: %.7f", schedule(10))
+	}
+	for step := 1; step <= 3; step++ {
+		if schedule(step) <= schedule(step-1) {
+			t.Errorf("Learning rate should increase before the peak at step %d", step)
+		}
+	}
+	for step := 4; step <= 10; step++ {
+		if schedule(step) >= schedule(step-1) {
+			t.Errorf("Learning rate should decrease after the peak at step %d", step)
+		}
+	}
+}
+
+func TestTriangularSchedule(t *testing.T) {
+	schedule, err := TriangularSchedule(0.1, 0.5, 4)
+	if err != nil {
+		t.Fatalf("Error in TriangularSchedule: %s", err.Error())
+	}
+
+	solution := []float32{0.1, 0.2, 0.3, 0.4, 0.5, 0.4, 0.3, 0.2, 0.1, 0.2}
+	for step, learningRate := range solution {
+		if math.Abs(float64(schedule(step)-learningRate)) > 1e-6 {
+			t.Errorf("Learning rate at step %d should be %.3f, is: %.3f", step, learningRate, schedule(step))
+		}
+	}
+
+	for _, stepSize := range []int{0, -2} {
+		_, err = TriangularSchedule(0.1, 0.5, stepSize)
+		if err == nil {
+			t.Errorf("Step size of %d did not trigger error", stepSize)
+		}
+	}
+}
+
+func TestNeuralNetworkTrainBatchSchedule(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+
+	inputs := [][][][]float32{{{{0, 1}}}, {{{1, 0}}}, {{{1, 1}}}}
+	targets := [][][][]float32{{{{1}}}, {{{1}}}, {{{0}}}}
+
+	steps := []int{}
+	schedule := func(step int) float32 {
+		steps = append(steps, step)
+		return 0.1
+	}
+	err := neuralNetwork.TrainBatchSchedule(inputs, targets, 2, 3, schedule, NewSGDOptimizer(0.1, 0))
+	if err != nil {
+		t.Fatalf("Error in TrainBatchSchedule: %s", err.Error())
+	}
+
+	if len(steps) != 6 {
+		t.Fatalf("Schedule should be used for 6 batches, was used for: %d", len(steps))
+	}
+	for i, step := range steps {
+		if step != i {
+			t.Errorf("Batch %d should use schedule step %d, used: %d", i, i, step)
+		}
+	}
+}
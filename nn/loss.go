@@ -0,0 +1,295 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	tsr "../tensor"
+)
+
+// LossFunction represents a function measuring the error between the outputs of a neural network
+// and their targets. The derivative is the gradient of the loss with respect to the outputs.
+type LossFunction struct {
+	Type       LossType
+	Function   func(outputs *tsr.Tensor, targets *tsr.Tensor) (float32, error)
+	Derivative func(outputs *tsr.Tensor, targets *tsr.Tensor) (*tsr.Tensor, error)
+}
+
+// LossType is the identifying type of the loss function.
+type LossType string
+
+const (
+	// LossTypeMSE is the type for a mean squared error loss function.
+	LossTypeMSE = LossType("mse")
+
+	// LossTypeCrossEntropy is the type for a categorical cross entropy loss function.
+	LossTypeCrossEntropy = LossType("crossEntropy")
+
+	// LossTypeBinaryCrossEntropy is the type for a binary cross entropy loss function.
+	LossTypeBinaryCrossEntropy = LossType("binaryCrossEntropy")
+
+	// LossTypeHuber is the type for a Huber loss function.
+	LossTypeHuber = LossType("huber")
+
+	// LossTypeFocal is the type for a focal loss function.
+	LossTypeFocal = LossType("focal")
+)
+
+// lossEpsilon keeps probabilities away from 0 and 1 in logarithms and divisions.
+const lossEpsilon = 1e-7
+
+// LossMSE is the mean squared error loss function.
+var LossMSE = LossFunction{
+	Type: LossTypeMSE,
+	Function: func(outputs *tsr.Tensor, targets *tsr.Tensor) (float32, error) {
+		sum := float32(0.0)
+		err := forEachLossValue(outputs, targets, func(output float32, target float32) {
+			sum += (output - target) * (output - target)
+		})
+		if err != nil {
+			return 0, err
+		}
+		return sum / float32(lossSize(outputs)), nil
+	},
+	Derivative: func(outputs *tsr.Tensor, targets *tsr.Tensor) (*tsr.Tensor, error) {
+		size := float32(lossSize(outputs))
+		return lossGradient(outputs, targets, func(output float32, target float32) float32 {
+			return 2 * (output - target) / size
+		})
+	},
+}
+
+// LossCrossEntropy is the categorical cross entropy loss function, for outputs that are a
+// probability distribution over classes, such as from a softmax activation.
+var LossCrossEntropy = LossFunction{
+	Type: LossTypeCrossEntropy,
+	Function: func(outputs *tsr.Tensor, targets *tsr.Tensor) (float32, error) {
+		sum := 0.0
+		err := forEachLossValue(outputs, targets, func(output float32, target float32) {
+			sum -= float64(target) * math.Log(float64(clipLossProbability(output)))
+		})
+		if err != nil {
+			return 0, err
+		}
+		return float32(sum), nil
+	},
+	Derivative: func(outputs *tsr.Tensor, targets *tsr.Tensor) (*tsr.Tensor, error) {
+		return lossGradient(outputs, targets, func(output float32, target float32) float32 {
+			return -target / clipLossProbability(output)
+		})
+	},
+}
+
+// LossBinaryCrossEntropy is the binary cross entropy loss function, for outputs that are each an
+// independent probability, such as from a sigmoid activation.
+var LossBinaryCrossEntropy = LossFunction{
+	Type: LossTypeBinaryCrossEntropy,
+	Function: func(outputs *tsr.Tensor, targets *tsr.Tensor) (float32, error) {
+		sum := 0.0
+		err := forEachLossValue(outputs, targets, func(output float32, target float32) {
+			probability := float64(clipLossProbability(output))
+			sum -= float64(target)*math.Log(probability) + float64(1-target)*math.Log(1-probability)
+		})
+		if err != nil {
+			return 0, err
+		}
+		return float32(sum / float64(lossSize(outputs))), nil
+	},
+	Derivative: func(outputs *tsr.Tensor, targets *tsr.Tensor) (*tsr.Tensor, error) {
+		size := float32(lossSize(outputs))
+		return lossGradient(outputs, targets, func(output float32, target float32) float32 {
+			probability := clipLossProbability(output)
+			return (probability - target) / (probability * (1 - probability)) / size
+		})
+	},
+}
+
+// LossHuber is the Huber loss function with a delta of 1.
+var LossHuber = NewHuberLoss(1.0)
+
+// NewHuberLoss creates a Huber loss function, which is squared for errors up to a delta and linear
+// beyond it, making it less sensitive to outliers than the mean squared error.
+func NewHuberLoss(delta float32) LossFunction {
+	return LossFunction{
+		Type: LossTypeHuber,
+		Function: func(outputs *tsr.Tensor, targets *tsr.Tensor) (float32, error) {
+			sum := float32(0.0)
+			err := forEachLossValue(outputs, targets, func(output float32, target float32) {
+				difference := float32(math.Abs(float64(output - target)))
+				if difference <= delta {
+					sum += 0.5 * difference * difference
+				} else {
+					sum += delta * (difference - 0.5*delta)
+				}
+			})
+			if err != nil {
+				return 0, err
+			}
+			return sum / float32(lossSize(outputs)), nil
+		},
+		Derivative: func(outputs *tsr.Tensor, targets *tsr.Tensor) (*tsr.Tensor, error) {
+			size := float32(lossSize(outputs))
+			return lossGradient(outputs, targets, func(output float32, target float32) float32 {
+				difference := output - target
+				if difference > delta {
+					difference = delta
+				} else if difference < -delta {
+					difference = -delta
+				}
+				return difference / size
+			})
+		},
+	}
+}
+
+// NewFocalLoss creates a binary focal loss function for outputs that are each an independent
+// probability, which scales the binary cross entropy of each value down by its probability of
+// being right raised to a power of gamma. Confident, correct outputs add little to the loss, so
+// training focuses on the hard samples of a rare class. Alpha weights the loss of positive targets,
+// and the loss of negative targets by 1 minus alpha. A gamma of 0 and alpha of 0.5 give half the
+// binary cross entropy.
+func NewFocalLoss(gamma float32, alpha float32) LossFunction {
+	return LossFunction{
+		Type: LossTypeFocal,
+		Function: func(outputs *tsr.Tensor, targets *tsr.Tensor) (float32, error) {
+			sum := 0.0
+			err := forEachLossValue(outputs, targets, func(output float32, target float32) {
+				p := float64(clipLossProbability(output))
+				positive := -float64(alpha) * math.Pow(1-p, float64(gamma)) * math.Log(p)
+				negative := -float64(1-alpha) * math.Pow(p, float64(gamma)) * math.Log(1-p)
+				sum += float64(target)*positive + float64(1-target)*negative
+			})
+			if err != nil {
+				return 0, err
+			}
+			return float32(sum / float64(lossSize(outputs))), nil
+		},
+		Derivative: func(outputs *tsr.Tensor, targets *tsr.Tensor) (*tsr.Tensor, error) {
+			size := float64(lossSize(outputs))
+			g := float64(gamma)
+			return lossGradient(outputs, targets, func(output float32, target float32) float32 {
+				p := float64(clipLossProbability(output))
+				positive := float64(alpha) * (g*math.Pow(1-p, g-1)*math.Log(p) - math.Pow(1-p, g)/p)
+				negative := -float64(1-alpha) * (g*math.Pow(p, g-1)*math.Log(1-p) - math.Pow(p, g)/(1-p))
+				return float32((float64(target)*positive + float64(1-target)*negative) / size)
+			})
+		},
+	}
+}
+
+func forEachLossValue(outputs *tsr.Tensor, targets *tsr.Tensor, function func(float32, float32)) error {
+	err := checkLossShapes(outputs, targets)
+	if err != nil {
+		return err
+	}
+	for frame := 0; frame < outputs.Frames; frame++ {
+		for row := 0; row < outputs.Rows; row++ {
+			for col := 0; col < outputs.Cols; col++ {
+				function(outputs.Get(frame, row, col), targets.Get(frame, row, col))
+			}
+		}
+	}
+	return nil
+}
+
+func lossGradient(outputs *tsr.Tensor, targets *tsr.Tensor, derivative func(float32, float32) float32) (*tsr.Tensor, error) {
+	err := checkLossShapes(outputs, targets)
+	if err != nil {
+		return nil, err
+	}
+	gradient := tsr.NewEmptyTensor3D(outputs.Frames, outputs.Rows, outputs.Cols)
+	gradient.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return derivative(outputs.Get(frame, row, col), targets.Get(frame, row, col))
+	})
+	return gradient, nil
+}
+
+// maskedLoss computes a loss over the timesteps of the outputs that are not masked, so padded
+// timesteps do not count toward the loss. A loss without a mask covers all of the outputs, and a
+// loss with every timestep masked is 0.
+func maskedLoss(loss LossFunction, outputs *tsr.Tensor, targets *tsr.Tensor, mask []bool) (float32, error) {
+	if mask == nil {
+		return loss.Function(outputs, targets)
+	}
+	err := checkLossShapes(outputs, targets)
+	if err != nil {
+		return 0, err
+	}
+	timesteps := unmaskedTimesteps(mask, outputs.Rows)
+	if len(timesteps) == 0 {
+		return 0, nil
+	}
+	return loss.Function(selectTimesteps(outputs, timesteps), selectTimesteps(targets, timesteps))
+}
+
+// maskedLossDerivative computes the gradient of a masked loss, which is 0 for masked timesteps.
+func maskedLossDerivative(loss LossFunction, outputs *tsr.Tensor, targets *tsr.Tensor, mask []bool) (*tsr.Tensor, error) {
+	if mask == nil {
+		return loss.Derivative(outputs, targets)
+	}
+	err := checkLossShapes(outputs, targets)
+	if err != nil {
+		return nil, err
+	}
+	gradient := tsr.NewEmptyTensor3D(outputs.Frames, outputs.Rows, outputs.Cols)
+	timesteps := unmaskedTimesteps(mask, outputs.Rows)
+	if len(timesteps) == 0 {
+		return gradient, nil
+	}
+	selected, err := loss.Derivative(selectTimesteps(outputs, timesteps), selectTimesteps(targets, timesteps))
+	if err != nil {
+		return nil, err
+	}
+	for frame := 0; frame < outputs.Frames; frame++ {
+		for i, timestep := range timesteps {
+			for col := 0; col < outputs.Cols; col++ {
+				gradient.Set(frame, timestep, col, selected.Get(frame, i, col))
+			}
+		}
+	}
+	return gradient, nil
+}
+
+// unmaskedTimesteps returns the rows of a sequence that are not masked. A mask that does not have a
+// value for each row does not apply to the sequence.
+func unmaskedTimesteps(mask []bool, rows int) []int {
+	timesteps := make([]int, 0, rows)
+	for row := 0; row < rows; row++ {
+		if len(mask) != rows || !mask[row] {
+			timesteps = append(timesteps, row)
+		}
+	}
+	return timesteps
+}
+
+func selectTimesteps(sequence *tsr.Tensor, timesteps []int) *tsr.Tensor {
+	selected := tsr.NewEmptyTensor3D(sequence.Frames, len(timesteps), sequence.Cols)
+	selected.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return sequence.Get(frame, timesteps[row], col)
+	})
+	return selected
+}
+
+func checkLossShapes(outputs *tsr.Tensor, targets *tsr.Tensor) error {
+	if outputs.Frames != targets.Frames || outputs.Rows != targets.Rows || outputs.Cols != targets.Cols {
+		return fmt.Errorf(
+			"Dimensions of outputs and targets must match: (%d, %d, %d) != (%d, %d, %d)",
+			outputs.Frames, outputs.Rows, outputs.Cols, targets.Frames, targets.Rows, targets.Cols,
+		)
+	}
+	return nil
+}
+
+func lossSize(tensor *tsr.Tensor) int {
+	return tensor.Frames * tensor.Rows * tensor.Cols
+}
+
+func clipLossProbability(probability float32) float32 {
+	if probability < lossEpsilon {
+		return lossEpsilon
+	}
+	if probability > 1-lossEpsilon {
+		return 1 - lossEpsilon
+	}
+	return probability
+}
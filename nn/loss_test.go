@@ -0,0 +1,90 @@
+package nn
+
+import (
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestLossFunctions(t *testing.T) {
+	outputs := tsr.NewValueTensor1D([]float32{0.2, 0.7, 0.1})
+	targets := tsr.NewValueTensor1D([]float32{0, 1, 0})
+
+	lossTests := []struct {
+		loss     LossFunction
+		solution float64
+	}{
+		{LossMSE, (0.04 + 0.09 + 0.01) / 3},
+		{LossCrossEntropy, -math.Log(0.7)},
+		{LossBinaryCrossEntropy, -(math.Log(0.8) + math.Log(0.7) + math.Log(0.9)) / 3},
+		{NewHuberLoss(0.15), (0.15*(0.2-0.075) + 0.15*(0.3-0.075) + 0.5*0.01) / 3},
+		{NewFocalLoss(2, 0.25), -(0.75*0.04*math.Log(0.8) + 0.25*0.09*math.Log(0.7) + 0.75*0.01*math.Log(0.9)) / 3},
+		{NewFocalLoss(0, 0.5), -(math.Log(0.8) + math.Log(0.7) + math.Log(0.9)) / 6},
+	}
+
+	for _, test := range lossTests {
+		loss, err := test.loss.Function(outputs, targets)
+		if err != nil {
+			t.Fatalf("Error in %s loss: %s", test.loss.Type, err.Error())
+		}
+		if math.Abs(float64(loss)-test.solution) > 1e-5 {
+			t.Errorf("Loss for %s should be %.5f, is: %.5f", test.loss.Type, test.solution, loss)
+		}
+
+		// The derivative should match the change in loss from a small change in each output.
+		gradient, err := test.loss.Derivative(outputs, targets)
+		if err != nil {
+			t.Fatalf("Error in %s derivative: %s", test.loss.Type, err.Error())
+		}
+		for col := 0; col < outputs.Cols; col++ {
+			step := float32(1e-3)
+			above := outputs.Copy()
+			above.Set(0, 0, col, outputs.Get(0, 0, col)+step)
+			below := outputs.Copy()
+			below.Set(0, 0, col, outputs.Get(0, 0, col)-step)
+			lossAbove, _ := test.loss.Function(above, targets)
+			lossBelow, _ := test.loss.Function(below, targets)
+			numerical := (lossAbove - lossBelow) / (2 * step)
+			if math.Abs(float64(numerical-gradient.Get(0, 0, col))) > 1e-2 {
+				t.Errorf("Gradient %d for %s should be %.4f, is: %.4f", col, test.loss.Type, numerical, gradient.Get(0, 0, col))
+			}
+		}
+	}
+
+	_, err := LossMSE.Function(outputs, tsr.NewValueTensor1D([]float32{0, 1}))
+	if err == nil {
+		t.Errorf("Loss with mismatched dimensions did not trigger error")
+	}
+}
+
+func TestNeuralNetworkSetLoss(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+	neuralNetwork.SetLoss(LossBinaryCrossEntropy)
+	if neuralNetwork.Loss().Type != LossTypeBinaryCrossEntropy {
+		t.Errorf("Loss type should be %s, is: %s", LossTypeBinaryCrossEntropy, neuralNetwork.Loss().Type)
+	}
+
+	inputs := [][][][]float32{{{{0, 0}}}, {{{0, 1}}}, {{{1, 0}}}, {{{1, 1}}}}
+	targets := [][][][]float32{{{{0}}}, {{{0}}}, {{{0}}}, {{{1}}}}
+	optimizer := NewSGDOptimizer(0.5, 0)
+	for epoch := 0; epoch < 2000; epoch++ {
+		for i := range inputs {
+			err := neuralNetwork.Train(inputs[i], targets[i], optimizer)
+			if err != nil {
+				t.Fatalf("Error in Train: %s", err.Error())
+			}
+		}
+	}
+
+	for i := range inputs {
+		prediction, err := neuralNetwork.Predict(inputs[i])
+		if err != nil {
+			t.Fatalf("Error in Predict: %s", err.Error())
+		}
+		if math.Abs(float64(prediction[0][0][0]-targets[i][0][0][0])) > 0.1 {
+			t.Errorf("Incorrect prediction for %v: %.3f", inputs[i][0][0], prediction[0][0][0])
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package nn
+
+import (
+	"encoding/json"
+
+	tsr "../tensor"
+)
+
+// MaskingLayer is a layer that masks the padded timesteps of a sequence. Each row of the input
+// data is a timestep, and a timestep is masked when all of its values equal the mask value.
+type MaskingLayer struct {
+	inputShape  LayerShape
+	outputShape LayerShape
+	outputs     *tsr.Tensor
+	mask        []bool
+	MaskValue   float32
+}
+
+// MaskableLayer is a sequence layer that can ignore the masked timesteps of its inputs. The neural
+// network passes the mask from the last masking layer to every following maskable layer.
+type MaskableLayer interface {
+	SetMask(mask []bool)
+}
+
+// sequenceLayer is a layer that may produce a row for each timestep of its inputs. When it does, the
+// mask of its inputs also applies to its outputs, so padded timesteps are left out of the loss.
+type sequenceLayer interface {
+	returnsSequences() bool
+}
+
+// NewMaskingLayer creates a new instance of a masking layer.
+func NewMaskingLayer(timesteps int, features int, maskValue float32) *MaskingLayer {
+	return &MaskingLayer{
+		inputShape:  LayerShape{timesteps, features, 1},
+		outputShape: LayerShape{timesteps, features, 1},
+		outputs:     tsr.NewEmptyTensor2D(timesteps, features),
+		mask:        make([]bool, timesteps),
+		MaskValue:   maskValue,
+	}
+}
+
+// Copy creates a deep copy of the layer.
+func (layer *MaskingLayer) Copy() Layer {
+	return NewMaskingLayer(layer.InputShape().Rows, layer.InputShape().Cols, layer.MaskValue)
+}
+
+// InputShape returns the rows, columns and frames of the inputs to the layer.
+func (layer *MaskingLayer) InputShape() LayerShape {
+	return layer.inputShape
+}
+
+// OutputShape returns the rows, columns and frames of outputs from the layer.
+func (layer *MaskingLayer) OutputShape() LayerShape {
+	return layer.outputShape
+}
+
+// Mask returns which timesteps of the last inputs were masked.
+func (layer *MaskingLayer) Mask() []bool {
+	return layer.mask
+}
+
+// FeedForward finds the masked timesteps of the inputs and sets their values to zero.
+func (layer *MaskingLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := layer.outputs.SetTensor(inputs)
+	if err != nil {
+		return nil, err
+	}
+	for timestep := 0; timestep < inputs.Rows; timestep++ {
+		layer.mask[timestep] = true
+		for col := 0; col < inputs.Cols; col++ {
+			if inputs.Get(0, timestep, col) != layer.MaskValue {
+				layer.mask[timestep] = false
+				break
+			}
+		}
+	}
+	layer.outputs.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		if layer.mask[row] {
+			return 0
+		}
+		return current
+	})
+	return layer.outputs, nil
+}
+
+// BackPropagate sets the deltas of masked timesteps to zero so they do not affect earlier layers.
+func (layer *MaskingLayer) BackPropagate(outputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := checkShape(outputs, layer.OutputShape(), "delta")
+	if err != nil {
+		return nil, err
+	}
+	nextDeltas := outputs.Copy()
+	nextDeltas.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		if layer.mask[row] {
+			return 0
+		}
+		return current
+	})
+	return nextDeltas, nil
+}
+
+func (layer *MaskingLayer) returnsSequences() bool {
+	return true
+}
+
+// Parameters returns an empty list, since the layer has no parameters.
+func (layer *MaskingLayer) Parameters() []*tsr.Tensor {
+	return []*tsr.Tensor{}
+}
+
+// Gradients returns an empty list, since the layer has no parameters.
+func (layer *MaskingLayer) Gradients() []*tsr.Tensor {
+	return []*tsr.Tensor{}
+}
+
+// MaskingLayerData represents a serialized layer that can be saved to a file.
+type MaskingLayerData struct {
+	Type      LayerType `json:"type"`
+	Timesteps int       `json:"timesteps"`
+	Features  int       `json:"features"`
+	MaskValue float32   `json:"maskValue"`
+}
+
+// MarshalJSON converts the layer to JSON.
+func (layer *MaskingLayer) MarshalJSON() ([]byte, error) {
+	data := MaskingLayerData{
+		Type:      LayerTypeMasking,
+		Timesteps: layer.InputShape().Rows,
+		Features:  layer.InputShape().Cols,
+		MaskValue: layer.MaskValue,
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new layer from JSON.
+func (layer *MaskingLayer) UnmarshalJSON(b []byte) error {
+	data := MaskingLayerData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	err = checkLoadedShape(LayerShape{data.Timesteps, data.Features, 1})
+	if err != nil {
+		return err
+	}
+	*layer = *NewMaskingLayer(data.Timesteps, data.Features, data.MaskValue)
+	return nil
+}
@@ -0,0 +1,142 @@
+package nn
+
+import (
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestMaskingLayer(t *testing.T) {
+	layer := NewMaskingLayer(4, 2, 0)
+
+	inputs := tsr.NewValueTensor2D([][]float32{
+		{1, 2},
+		{0, 3},
+		{0, 0},
+		{0, 0},
+	})
+
+	outputs, err := layer.FeedForward(inputs)
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+	if !outputs.Equals(inputs) {
+		t.Errorf("Matrix after feed forward should be:\n%swhen result is:\n%s", inputs.String(), outputs.String())
+	}
+
+	solutionMask := []bool{false, false, true, true}
+	for timestep, masked := range layer.Mask() {
+		if masked != solutionMask[timestep] {
+			t.Errorf("Incorrect mask for timestep %d: %t != %t", timestep, masked, solutionMask[timestep])
+		}
+	}
+
+	deltas, err := layer.BackPropagate(tsr.NewValueTensor2D([][]float32{
+		{1, 1},
+		{1, 1},
+		{1, 1},
+		{1, 1},
+	}))
+	if err != nil {
+		t.Fatalf("Error in BackPropagate: %s", err.Error())
+	}
+
+	solution := tsr.NewValueTensor2D([][]float32{
+		{1, 1},
+		{1, 1},
+		{0, 0},
+		{0, 0},
+	})
+	if !deltas.Equals(solution) {
+		t.Errorf("Matrix after back propagate should be:\n%swhen result is:\n%s", solution.String(), deltas.String())
+	}
+}
+
+func TestMaskingLayerPropagation(t *testing.T) {
+	masking := NewMaskingLayer(3, 2, -1)
+	timeDistributed, _ := NewTimeDistributedLayer(3, NewDenseLayer(2, 2, ActivationSigmoid))
+
+	neuralNetwork := NewNeuralNetwork()
+	err := neuralNetwork.Add(masking, timeDistributed)
+	if err != nil {
+		t.Fatalf("Error in Add: %s", err.Error())
+	}
+
+	prediction, err := neuralNetwork.Predict([][][]float32{{
+		{0.5, 0.5},
+		{-1, -1},
+		{0.2, 0.8},
+	}})
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+
+	for col := 0; col < 2; col++ {
+		if prediction[0][1][col] != 0 {
+			t.Errorf("Masked timestep should produce zero output, is: %.3f", prediction[0][1][col])
+		}
+		if prediction[0][0][col] == 0 {
+			t.Errorf("Unmasked timestep should produce sigmoid output, is: %.3f", prediction[0][0][col])
+		}
+	}
+}
+
+func TestMaskingLayerLoss(t *testing.T) {
+	masking := NewMaskingLayer(3, 2, -1)
+	timeDistributed, _ := NewTimeDistributedLayer(3, NewDenseLayer(2, 2, ActivationSigmoid))
+	neuralNetwork := NewNeuralNetwork()
+	err := neuralNetwork.Add(masking, timeDistributed)
+	if err != nil {
+		t.Fatalf("Error in Add: %s", err.Error())
+	}
+
+	inputs := [][][]float32{{{0.5, 0.5}, {-1, -1}, {0.2, 0.8}}}
+	targets := [][][]float32{{{1, 0}, {100, 100}, {0, 1}}}
+	prediction, _ := neuralNetwork.Predict(inputs)
+
+	// Only the first and last timesteps count toward the loss and metrics.
+	unmaskedPrediction := tsr.NewValueTensor2D([][]float32{prediction[0][0], prediction[0][2]})
+	unmaskedTargets := tsr.NewValueTensor2D([][]float32{targets[0][0], targets[0][2]})
+	solution, _ := LossMSE.Function(unmaskedPrediction, unmaskedTargets)
+	loss, metrics, err := neuralNetwork.Evaluate([][][][]float32{inputs}, [][][][]float32{targets}, LossMSE, MetricMeanAbsoluteError)
+	if err != nil {
+		t.Fatalf("Error in Evaluate: %s", err.Error())
+	}
+	if math.Abs(float64(loss-solution)) > 1e-5 {
+		t.Errorf("Loss should be %.5f, is: %.5f", solution, loss)
+	}
+	errorSolution, _ := MetricMeanAbsoluteError.Function([]*tsr.Tensor{unmaskedPrediction}, []*tsr.Tensor{unmaskedTargets})
+	if math.Abs(float64(metrics["meanAbsoluteError"]-errorSolution)) > 1e-5 {
+		t.Errorf("Mean absolute error should be %.5f, is: %.5f", errorSolution, metrics["meanAbsoluteError"])
+	}
+
+	// The target of a masked timestep does not change training.
+	first := neuralNetwork.Copy()
+	first.Train(inputs, targets, NewSGDOptimizer(0.5, 0))
+	second := neuralNetwork.Copy()
+	second.Train(inputs, [][][]float32{{{1, 0}, {0, 0}, {0, 1}}}, NewSGDOptimizer(0.5, 0))
+	firstPrediction, _ := first.Predict(inputs)
+	secondPrediction, _ := second.Predict(inputs)
+	if !tsr.NewValueTensor3D(firstPrediction).Equals(tsr.NewValueTensor3D(secondPrediction)) {
+		t.Errorf("Training should not depend on the targets of masked timesteps")
+	}
+
+	// A recurrent layer that returns only its last state does not produce a sequence, so the mask
+	// does not apply to its outputs.
+	recurrent := NewRecurrentLayer(3, 2, 2, ActivationTanh, false)
+	sequenceNetwork := NewNeuralNetwork()
+	err = sequenceNetwork.Add(NewMaskingLayer(3, 2, -1), recurrent)
+	if err != nil {
+		t.Fatalf("Error in Add: %s", err.Error())
+	}
+	prediction, _ = sequenceNetwork.Predict(inputs)
+	solution, _ = LossMSE.Function(tsr.NewValueTensor3D(prediction), tsr.NewValueTensor1D([]float32{1, 0}))
+	loss, _, err = sequenceNetwork.Evaluate([][][][]float32{inputs}, [][][][]float32{{{{1, 0}}}}, LossMSE)
+	if err != nil {
+		t.Fatalf("Error in Evaluate: %s", err.Error())
+	}
+	if math.Abs(float64(loss-solution)) > 1e-5 {
+		t.Errorf("Loss of the last state should be %.5f, is: %.5f", solution, loss)
+	}
+}
@@ -0,0 +1,125 @@
+package nn
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"os"
+	"time"
+)
+
+// Metadata describes a trained neural network, such as where it came from and what its inputs and
+// outputs mean. It is saved along with the layers of the neural network.
+type Metadata struct {
+	Name         string            `json:"name,omitempty"`
+	Version      string            `json:"version,omitempty"`
+	TrainedAt    time.Time         `json:"trainedAt"`
+	DatasetHash  string            `json:"datasetHash,omitempty"`
+	InputSchema  Schema            `json:"inputSchema"`
+	OutputSchema Schema            `json:"outputSchema"`
+	ClassLabels  []string          `json:"classLabels,omitempty"`
+	Properties   map[string]string `json:"properties,omitempty"`
+}
+
+// Schema describes the shape of the inputs or outputs of a neural network, with optional names for
+// the columns, such as the names of features, and optional ranges of the values of each column.
+type Schema struct {
+	Rows    int       `json:"rows"`
+	Cols    int       `json:"cols"`
+	Frames  int       `json:"frames"`
+	Names   []string  `json:"names,omitempty"`
+	Minimum []float32 `json:"minimum,omitempty"`
+	Maximum []float32 `json:"maximum,omitempty"`
+}
+
+// NewMetadata creates metadata for a neural network with a name and version. The schemas start
+// from the shapes of the first and last layers, and the training date is the current time.
+func NewMetadata(neuralNetwork *NeuralNetwork, name string, version string) *Metadata {
+	metadata := &Metadata{
+		Name:      name,
+		Version:   version,
+		TrainedAt: time.Now().UTC(),
+	}
+	if len(neuralNetwork.layers) > 0 {
+		inputShape := neuralNetwork.layers[0].InputShape()
+		outputShape := neuralNetwork.layers[len(neuralNetwork.layers)-1].OutputShape()
+		metadata.InputSchema = Schema{Rows: inputShape.Rows, Cols: inputShape.Cols, Frames: inputShape.Frames}
+		metadata.OutputSchema = Schema{Rows: outputShape.Rows, Cols: outputShape.Cols, Frames: outputShape.Frames}
+	}
+	return metadata
+}
+
+// Copy creates a deep copy of the metadata.
+func (metadata *Metadata) Copy() *Metadata {
+	newMetadata := *metadata
+	newMetadata.InputSchema = metadata.InputSchema.copy()
+	newMetadata.OutputSchema = metadata.OutputSchema.copy()
+	newMetadata.ClassLabels = append([]string(nil), metadata.ClassLabels...)
+	if metadata.Properties != nil {
+		newMetadata.Properties = map[string]string{}
+		for key, value := range metadata.Properties {
+			newMetadata.Properties[key] = value
+		}
+	}
+	return &newMetadata
+}
+
+func (schema Schema) copy() Schema {
+	schema.Names = append([]string(nil), schema.Names...)
+	schema.Minimum = append([]float32(nil), schema.Minimum...)
+	schema.Maximum = append([]float32(nil), schema.Maximum...)
+	return schema
+}
+
+// DatasetHash computes a SHA-256 hash of the inputs and targets of a data set, which identifies
+// the exact data a neural network was trained on.
+func DatasetHash(inputs [][][][]float32, targets [][][][]float32) string {
+	hash := sha256.New()
+	buffer := make([]byte, 4)
+	for _, samples := range [][][][][]float32{inputs, targets} {
+		for _, sample := range samples {
+			for _, frame := range sample {
+				for _, row := range frame {
+					for _, value := range row {
+						binary.LittleEndian.PutUint32(buffer, math.Float32bits(value))
+						hash.Write(buffer)
+					}
+				}
+			}
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// LoadMetadataFromFile loads only the metadata of a neural network saved to a file, without
+// creating its layers. It returns nil if the neural network was saved without metadata.
+func LoadMetadataFromFile(fileName string) (*Metadata, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	neuralNetworkData := struct {
+		Metadata *Metadata `json:"metadata"`
+	}{}
+	reader := bufio.NewReader(file)
+	if isBinary(reader) {
+		config, _, err := readBinaryConfig(reader)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(config, &neuralNetworkData)
+		if err != nil {
+			return nil, err
+		}
+		return neuralNetworkData.Metadata, nil
+	}
+	err = json.NewDecoder(reader).Decode(&neuralNetworkData)
+	if err != nil {
+		return nil, err
+	}
+	return neuralNetworkData.Metadata, nil
+}
@@ -0,0 +1,85 @@
+package nn
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMetadataSaveLoad(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(3, 4, ActivationSigmoid), NewDenseLayer(4, 2, ActivationSigmoid))
+	inputs := [][][][]float32{{{{1, 2, 3}}}, {{{4, 5, 6}}}}
+	targets := [][][][]float32{{{{1, 0}}}, {{{0, 1}}}}
+	metadata := NewMetadata(neuralNetwork, "classifier", "1.2.0")
+	metadata.DatasetHash = DatasetHash(inputs, targets)
+	metadata.InputSchema.Names = []string{"a", "b", "c"}
+	metadata.ClassLabels = []string{"yes", "no"}
+	neuralNetwork.SetMetadata(metadata)
+
+	if metadata.InputSchema.Cols != 3 || metadata.OutputSchema.Cols != 2 {
+		t.Errorf("Schemas should have 3 input and 2 output columns, have: %d, %d", metadata.InputSchema.Cols, metadata.OutputSchema.Cols)
+	}
+
+	err := neuralNetwork.SaveToFile("metadata.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+	defer os.Remove("metadata.json")
+
+	loaded := NewNeuralNetwork()
+	err = loaded.LoadFromFile("metadata.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+	loadedMetadata := loaded.Metadata()
+	if loadedMetadata == nil {
+		t.Fatalf("Loaded neural network should have metadata")
+	}
+	if loadedMetadata.Name != "classifier" || loadedMetadata.Version != "1.2.0" {
+		t.Errorf("Loaded name and version should be: classifier 1.2.0, are: %s %s", loadedMetadata.Name, loadedMetadata.Version)
+	}
+	if !loadedMetadata.TrainedAt.Equal(metadata.TrainedAt) {
+		t.Errorf("Loaded training date should be: %s, is: %s", metadata.TrainedAt, loadedMetadata.TrainedAt)
+	}
+	if loadedMetadata.DatasetHash != metadata.DatasetHash || loadedMetadata.ClassLabels[1] != "no" || loadedMetadata.InputSchema.Names[2] != "c" {
+		t.Errorf("Loaded metadata does not match original")
+	}
+
+	onlyMetadata, err := LoadMetadataFromFile("metadata.json")
+	if err != nil {
+		t.Fatalf("Error in LoadMetadataFromFile: %s", err.Error())
+	}
+	if onlyMetadata.Name != "classifier" {
+		t.Errorf("Metadata loaded on its own should have name: classifier, has: %s", onlyMetadata.Name)
+	}
+}
+
+func TestMetadataCopy(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+	metadata := NewMetadata(neuralNetwork, "model", "1")
+	metadata.ClassLabels = []string{"a"}
+	metadata.Properties = map[string]string{"owner": "team"}
+	neuralNetwork.SetMetadata(metadata)
+
+	copied := neuralNetwork.Copy().Metadata()
+	copied.ClassLabels[0] = "b"
+	copied.Properties["owner"] = "other"
+	copied.TrainedAt = time.Time{}
+	if metadata.ClassLabels[0] != "a" || metadata.Properties["owner"] != "team" || metadata.TrainedAt.IsZero() {
+		t.Errorf("Changing copied metadata should not change the original")
+	}
+}
+
+func TestDatasetHash(t *testing.T) {
+	inputs := [][][][]float32{{{{1, 2}}}}
+	targets := [][][][]float32{{{{1}}}}
+	hash := DatasetHash(inputs, targets)
+	if len(hash) != 64 || hash != DatasetHash(inputs, targets) {
+		t.Errorf("Dataset hash should be the same 64 hex digits for the same data, is: %s", hash)
+	}
+	if hash == DatasetHash([][][][]float32{{{{1, 2.5}}}}, targets) {
+		t.Errorf("Dataset hash should change when the data changes")
+	}
+}
@@ -0,0 +1,127 @@
+package nn
+
+import (
+	"math"
+
+	tsr "../tensor"
+)
+
+// ContrastiveLoss computes the contrastive loss of a pair of embeddings. Similar pairs are pulled
+// together, and dissimilar pairs are pushed apart until their distance reaches the margin. It
+// returns the loss and its gradients with respect to each embedding.
+func ContrastiveLoss(embedding1 *tsr.Tensor, embedding2 *tsr.Tensor, similar bool, margin float32) (float32, []*tsr.Tensor, error) {
+	difference := embedding1.Copy()
+	err := difference.SubtractTensor(embedding2)
+	if err != nil {
+		return 0, nil, err
+	}
+	distance := float32(math.Sqrt(float64(squaredNorm(difference))))
+	gradient := difference.Copy()
+	var loss float32
+	if similar {
+		loss = 0.5 * distance * distance
+	} else if distance < margin {
+		loss = 0.5 * (margin - distance) * (margin - distance)
+		if distance > 0 {
+			gradient.Scale(-(margin - distance) / distance)
+		} else {
+			gradient.Scale(0)
+		}
+	} else {
+		gradient.Scale(0)
+	}
+	negativeGradient := gradient.Copy()
+	negativeGradient.Scale(-1)
+	return loss, []*tsr.Tensor{gradient, negativeGradient}, nil
+}
+
+// TripletLoss computes the triplet margin loss of an anchor, a positive and a negative embedding,
+// using squared euclidean distances. The loss is zero once the negative is further from the anchor
+// than the positive by at least the margin. It returns the loss and its gradients with respect to
+// the anchor, positive and negative embeddings.
+func TripletLoss(anchor *tsr.Tensor, positive *tsr.Tensor, negative *tsr.Tensor, margin float32) (float32, []*tsr.Tensor, error) {
+	positiveDifference := anchor.Copy()
+	err := positiveDifference.SubtractTensor(positive)
+	if err != nil {
+		return 0, nil, err
+	}
+	negativeDifference := anchor.Copy()
+	err = negativeDifference.SubtractTensor(negative)
+	if err != nil {
+		return 0, nil, err
+	}
+	loss := squaredNorm(positiveDifference) - squaredNorm(negativeDifference) + margin
+	anchorGradient := tsr.NewEmptyTensor3D(anchor.Frames, anchor.Rows, anchor.Cols)
+	positiveGradient := tsr.NewEmptyTensor3D(anchor.Frames, anchor.Rows, anchor.Cols)
+	negativeGradient := tsr.NewEmptyTensor3D(anchor.Frames, anchor.Rows, anchor.Cols)
+	if loss <= 0 {
+		return 0, []*tsr.Tensor{anchorGradient, positiveGradient, negativeGradient}, nil
+	}
+	anchorGradient.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return 2 * (negative.Get(frame, row, col) - positive.Get(frame, row, col))
+	})
+	positiveGradient.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return -2 * positiveDifference.Get(frame, row, col)
+	})
+	negativeGradient.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return 2 * negativeDifference.Get(frame, row, col)
+	})
+	return loss, []*tsr.Tensor{anchorGradient, positiveGradient, negativeGradient}, nil
+}
+
+// HardestNegative finds the index of the candidate embedding closest to the anchor, which is the
+// negative producing the largest triplet loss. It returns -1 if there are no candidates.
+func HardestNegative(anchor *tsr.Tensor, candidates []*tsr.Tensor) int {
+	hardest := -1
+	hardestDistance := float32(math.MaxFloat32)
+	for i, candidate := range candidates {
+		distance := squaredDistance(anchor, candidate)
+		if distance < hardestDistance {
+			hardest = i
+			hardestDistance = distance
+		}
+	}
+	return hardest
+}
+
+// SemiHardNegative finds the index of the closest candidate embedding that is further from the
+// anchor than the positive but still within the margin. If there is no such candidate it falls
+// back to the hardest negative. It returns -1 if there are no candidates.
+func SemiHardNegative(anchor *tsr.Tensor, positive *tsr.Tensor, candidates []*tsr.Tensor, margin float32) int {
+	positiveDistance := squaredDistance(anchor, positive)
+	semiHard := -1
+	semiHardDistance := float32(math.MaxFloat32)
+	for i, candidate := range candidates {
+		distance := squaredDistance(anchor, candidate)
+		if distance > positiveDistance && distance < positiveDistance+margin && distance < semiHardDistance {
+			semiHard = i
+			semiHardDistance = distance
+		}
+	}
+	if semiHard < 0 {
+		return HardestNegative(anchor, candidates)
+	}
+	return semiHard
+}
+
+func squaredNorm(tensor *tsr.Tensor) float32 {
+	sum := float32(0.0)
+	for frame := 0; frame < tensor.Frames; frame++ {
+		for row := 0; row < tensor.Rows; row++ {
+			for col := 0; col < tensor.Cols; col++ {
+				value := tensor.Get(frame, row, col)
+				sum += value * value
+			}
+		}
+	}
+	return sum
+}
+
+func squaredDistance(tensor1 *tsr.Tensor, tensor2 *tsr.Tensor) float32 {
+	difference := tensor1.Copy()
+	err := difference.SubtractTensor(tensor2)
+	if err != nil {
+		return float32(math.MaxFloat32)
+	}
+	return squaredNorm(difference)
+}
@@ -0,0 +1,92 @@
+package nn
+
+import (
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestContrastiveLoss(t *testing.T) {
+	embedding1 := tsr.NewValueTensor1D([]float32{0, 0})
+	embedding2 := tsr.NewValueTensor1D([]float32{3, 4})
+
+	loss, gradients, err := ContrastiveLoss(embedding1, embedding2, true, 1)
+	if err != nil {
+		t.Fatalf("Error in ContrastiveLoss: %s", err.Error())
+	}
+	if loss != 12.5 {
+		t.Errorf("Loss of similar pair should be 12.5, is: %.3f", loss)
+	}
+	solution := tsr.NewValueTensor1D([]float32{-3, -4})
+	if !gradients[0].Equals(solution) {
+		t.Errorf("Gradient of similar pair should be:\n%swhen result is:\n%s", solution.String(), gradients[0].String())
+	}
+
+	loss, _, _ = ContrastiveLoss(embedding1, embedding2, false, 1)
+	if loss != 0 {
+		t.Errorf("Loss of dissimilar pair beyond the margin should be 0, is: %.3f", loss)
+	}
+
+	loss, gradients, _ = ContrastiveLoss(embedding1, embedding2, false, 10)
+	if loss != 12.5 {
+		t.Errorf("Loss of dissimilar pair within the margin should be 12.5, is: %.3f", loss)
+	}
+	solution = tsr.NewValueTensor1D([]float32{3, 4})
+	if !gradients[0].Equals(solution) {
+		t.Errorf("Gradient of dissimilar pair should be:\n%swhen result is:\n%s", solution.String(), gradients[0].String())
+	}
+}
+
+func TestTripletLoss(t *testing.T) {
+	anchor := tsr.NewValueTensor1D([]float32{0, 0})
+	positive := tsr.NewValueTensor1D([]float32{1, 0})
+	negative := tsr.NewValueTensor1D([]float32{0, 1})
+
+	loss, gradients, err := TripletLoss(anchor, positive, negative, 0.5)
+	if err != nil {
+		t.Fatalf("Error in TripletLoss: %s", err.Error())
+	}
+	if loss != 0.5 {
+		t.Errorf("Loss should be 0.5, is: %.3f", loss)
+	}
+	solutions := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{-2, 2}),
+		tsr.NewValueTensor1D([]float32{2, 0}),
+		tsr.NewValueTensor1D([]float32{0, -2}),
+	}
+	for i, solution := range solutions {
+		if !gradients[i].Equals(solution) {
+			t.Errorf("Gradient %d should be:\n%swhen result is:\n%s", i, solution.String(), gradients[i].String())
+		}
+	}
+
+	farNegative := tsr.NewValueTensor1D([]float32{0, 2})
+	loss, _, _ = TripletLoss(anchor, positive, farNegative, 0.5)
+	if loss != 0 {
+		t.Errorf("Loss with distant negative should be 0, is: %.3f", loss)
+	}
+}
+
+func TestNegativeMining(t *testing.T) {
+	anchor := tsr.NewValueTensor1D([]float32{0, 0})
+	positive := tsr.NewValueTensor1D([]float32{1, 0})
+	candidates := []*tsr.Tensor{
+		tsr.NewValueTensor1D([]float32{3, 0}),
+		tsr.NewValueTensor1D([]float32{0.5, 0}),
+		tsr.NewValueTensor1D([]float32{0, 1.2}),
+	}
+
+	hardest := HardestNegative(anchor, candidates)
+	if hardest != 1 {
+		t.Errorf("Hardest negative should be 1, is: %d", hardest)
+	}
+
+	semiHard := SemiHardNegative(anchor, positive, candidates, 1)
+	if semiHard != 2 {
+		t.Errorf("Semi-hard negative should be 2, is: %d", semiHard)
+	}
+
+	if HardestNegative(anchor, []*tsr.Tensor{}) != -1 {
+		t.Errorf("Hardest negative without candidates should be -1")
+	}
+}
@@ -0,0 +1,104 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	tsr "../tensor"
+)
+
+// LossTypeWeightedBinaryCrossEntropy is the type for a binary cross entropy loss function with a
+// weight for the positive targets of each class.
+const LossTypeWeightedBinaryCrossEntropy = LossType("weightedBinaryCrossEntropy")
+
+// NewWeightedBinaryCrossEntropy creates a binary cross entropy loss function for multi-label
+// outputs, where each column is the probability of an independent class. The loss of a positive
+// target of each class is scaled by the weight of the class, so rare classes can be given a weight
+// above 1, such as their number of negative samples divided by their number of positive samples.
+func NewWeightedBinaryCrossEntropy(positiveWeights []float32) LossFunction {
+	weightAt := func(col int) float64 {
+		return float64(positiveWeights[col%len(positiveWeights)])
+	}
+	return LossFunction{
+		Type: LossTypeWeightedBinaryCrossEntropy,
+		Function: func(outputs *tsr.Tensor, targets *tsr.Tensor) (float32, error) {
+			err := checkLossShapes(outputs, targets)
+			if err != nil {
+				return 0, err
+			}
+			sum := 0.0
+			outputs.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+				probability := float64(clipLossProbability(current))
+				target := float64(targets.Get(frame, row, col))
+				sum -= weightAt(col)*target*math.Log(probability) + (1-target)*math.Log(1-probability)
+				return current
+			})
+			return float32(sum / float64(lossSize(outputs))), nil
+		},
+		Derivative: func(outputs *tsr.Tensor, targets *tsr.Tensor) (*tsr.Tensor, error) {
+			err := checkLossShapes(outputs, targets)
+			if err != nil {
+				return nil, err
+			}
+			size := float64(lossSize(outputs))
+			gradient := tsr.NewEmptyTensor3D(outputs.Frames, outputs.Rows, outputs.Cols)
+			gradient.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+				probability := float64(clipLossProbability(outputs.Get(frame, row, col)))
+				target := float64(targets.Get(frame, row, col))
+				return float32((-weightAt(col)*target/probability + (1-target)/(1-probability)) / size)
+			})
+			return gradient, nil
+		},
+	}
+}
+
+// AddMultiLabelHead adds a dense layer with a sigmoid output for each class to the end of a neural
+// network, so any number of classes can apply to a sample, and sets its loss to a binary cross
+// entropy for each class. The last layer of the neural network must have a single row of outputs.
+// Positive weights for each class give a NewWeightedBinaryCrossEntropy loss, or nil weights give
+// LossBinaryCrossEntropy.
+func AddMultiLabelHead(neuralNetwork *NeuralNetwork, classes int, positiveWeights []float32) error {
+	if neuralNetwork.LayerCount() == 0 {
+		return fmt.Errorf("Neural network must have a layer to add a multi-label head to")
+	}
+	if positiveWeights != nil && len(positiveWeights) != classes {
+		return fmt.Errorf("Number of positive weights must match classes: %d != %d", len(positiveWeights), classes)
+	}
+	outputShape := neuralNetwork.LayerAt(neuralNetwork.LayerCount() - 1).OutputShape()
+	if outputShape.Rows != 1 || outputShape.Frames != 1 {
+		return fmt.Errorf("Outputs of last layer must be a single row, are: (%d, %d, %d)", outputShape.Rows, outputShape.Cols, outputShape.Frames)
+	}
+	err := neuralNetwork.Add(NewDenseLayer(outputShape.Cols, classes, ActivationSigmoid))
+	if err != nil {
+		return err
+	}
+	if positiveWeights != nil {
+		neuralNetwork.SetLoss(NewWeightedBinaryCrossEntropy(positiveWeights))
+	} else {
+		neuralNetwork.SetLoss(LossBinaryCrossEntropy)
+	}
+	return nil
+}
+
+// PredictMultiLabel returns every class whose predicted probability for the inputs is at least the
+// threshold of the class. A single threshold applies to every class.
+func (neuralNetwork *NeuralNetwork) PredictMultiLabel(inputs [][][]float32, thresholds []float32) ([]int, error) {
+	outputs, err := neuralNetwork.Predict(inputs)
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs) != 1 || len(outputs[0]) != 1 {
+		return nil, fmt.Errorf("Outputs must be a single row of class probabilities")
+	}
+	probabilities := outputs[0][0]
+	if len(thresholds) != 1 && len(thresholds) != len(probabilities) {
+		return nil, fmt.Errorf("Number of thresholds must be 1 or match classes: %d != %d", len(thresholds), len(probabilities))
+	}
+	classes := []int{}
+	for class, probability := range probabilities {
+		if probability >= thresholds[class%len(thresholds)] {
+			classes = append(classes, class)
+		}
+	}
+	return classes, nil
+}
@@ -0,0 +1,70 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestWeightedBinaryCrossEntropy(t *testing.T) {
+	loss := NewWeightedBinaryCrossEntropy([]float32{3, 1})
+	outputs := tsr.NewValueTensor1D([]float32{0.6, 0.2})
+	targets := tsr.NewValueTensor1D([]float32{1, 0})
+	value, err := loss.Function(outputs, targets)
+	if err != nil {
+		t.Fatalf("Error in weighted binary cross entropy: %s", err.Error())
+	}
+	solution := -(3*math.Log(0.6) + math.Log(0.8)) / 2
+	if math.Abs(float64(value)-solution) > 1e-5 {
+		t.Errorf("Loss should be %f, is: %f", solution, value)
+	}
+
+	gradient, err := loss.Derivative(outputs, targets)
+	if err != nil {
+		t.Fatalf("Error in weighted binary cross entropy derivative: %s", err.Error())
+	}
+	if math.Abs(float64(gradient.Get(0, 0, 0))-(-3/0.6/2)) > 1e-4 || math.Abs(float64(gradient.Get(0, 0, 1))-(1/0.8/2)) > 1e-4 {
+		t.Errorf("Gradient should be [%f, %f], is:\n%s", -3/0.6/2, 1/0.8/2, gradient.String())
+	}
+}
+
+func TestMultiLabelHead(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 8, ActivationTanh))
+	err := AddMultiLabelHead(neuralNetwork, 2, nil)
+	if err != nil {
+		t.Fatalf("Error in AddMultiLabelHead: %s", err.Error())
+	}
+	if neuralNetwork.Loss().Type != LossTypeBinaryCrossEntropy {
+		t.Errorf("Multi-label head should have a binary cross entropy loss, has: %s", neuralNetwork.Loss().Type)
+	}
+
+	// The first class is whether the first input is on, and the second whether the second is on.
+	inputs := [][][][]float32{{{{0, 0}}}, {{{0, 1}}}, {{{1, 0}}}, {{{1, 1}}}}
+	targets := [][][][]float32{{{{0, 0}}}, {{{0, 1}}}, {{{1, 0}}}, {{{1, 1}}}}
+	err = neuralNetwork.TrainBatchSchedule(inputs, targets, 4, 500, ConstantSchedule(0.05), NewAdamOptimizer(0.05))
+	if err != nil {
+		t.Fatalf("Error in TrainBatchSchedule: %s", err.Error())
+	}
+	for i, input := range inputs {
+		classes, err := neuralNetwork.PredictMultiLabel(input, []float32{0.5})
+		if err != nil {
+			t.Fatalf("Error in PredictMultiLabel: %s", err.Error())
+		}
+		solution := [][]int{{}, {1}, {0}, {0, 1}}[i]
+		if fmt.Sprint(classes) != fmt.Sprint(solution) {
+			t.Errorf("Classes of %v should be %v, are: %v", input, solution, classes)
+		}
+	}
+
+	_, err = neuralNetwork.PredictMultiLabel(inputs[0], []float32{0.5, 0.5, 0.5})
+	if err == nil {
+		t.Errorf("Wrong number of thresholds should have an error")
+	}
+	err = AddMultiLabelHead(neuralNetwork, 3, []float32{1, 2})
+	if err == nil {
+		t.Errorf("Wrong number of positive weights should have an error")
+	}
+}
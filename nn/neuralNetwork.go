@@ -1,8 +1,11 @@
 package nn
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math/rand"
 	"os"
 
 	tsr "../tensor"
@@ -10,20 +13,41 @@ import (
 
 // NeuralNetwork is a basic neural network that can handle multiple layer types.
 type NeuralNetwork struct {
-	layers []Layer
+	layers             []Layer
+	learningRateScales []float32
+	loss               LossFunction
+	regularizers       []Regularizer
+	callbacks          []Callback
+	autoAdapters       bool
+	metadata           *Metadata
+	validateInputs     bool
+	clipNorm           float32
+	clipValue          float32
+	outputMask         []bool
 }
 
 // NewNeuralNetwork Creates a new instance of a NeuralNetwork.
 func NewNeuralNetwork() *NeuralNetwork {
-	return &NeuralNetwork{layers: []Layer{}}
+	return &NeuralNetwork{layers: []Layer{}, learningRateScales: []float32{}, loss: LossMSE}
 }
 
 // Copy creates a deep copy of the neural network.
 func (neuralNetwork *NeuralNetwork) Copy() *NeuralNetwork {
 	newNeuralNetwork := NewNeuralNetwork()
+	newNeuralNetwork.loss = neuralNetwork.loss
+	newNeuralNetwork.regularizers = append(newNeuralNetwork.regularizers, neuralNetwork.regularizers...)
+	newNeuralNetwork.callbacks = append(newNeuralNetwork.callbacks, neuralNetwork.callbacks...)
+	newNeuralNetwork.autoAdapters = neuralNetwork.autoAdapters
+	newNeuralNetwork.validateInputs = neuralNetwork.validateInputs
+	newNeuralNetwork.clipNorm = neuralNetwork.clipNorm
+	newNeuralNetwork.clipValue = neuralNetwork.clipValue
+	if neuralNetwork.metadata != nil {
+		newNeuralNetwork.metadata = neuralNetwork.metadata.Copy()
+	}
 	for _, layer := range neuralNetwork.layers {
 		newNeuralNetwork.Add(layer.Copy())
 	}
+	copy(newNeuralNetwork.learningRateScales, neuralNetwork.learningRateScales)
 	return newNeuralNetwork
 }
 
@@ -40,11 +64,78 @@ func (neuralNetwork *NeuralNetwork) LayerAt(index int) Layer {
 	return neuralNetwork.layers[index]
 }
 
+// Loss returns the loss function the neural network is trained to minimize.
+func (neuralNetwork *NeuralNetwork) Loss() LossFunction {
+	return neuralNetwork.loss
+}
+
+// SetLoss sets the loss function the neural network is trained to minimize. The default is the
+// mean squared error.
+func (neuralNetwork *NeuralNetwork) SetLoss(loss LossFunction) {
+	neuralNetwork.loss = loss
+}
+
+// Metadata returns the metadata that describes the neural network, or nil if it has none.
+func (neuralNetwork *NeuralNetwork) Metadata() *Metadata {
+	return neuralNetwork.metadata
+}
+
+// SetMetadata sets the metadata that describes the neural network, which is saved along with it.
+func (neuralNetwork *NeuralNetwork) SetMetadata(metadata *Metadata) {
+	neuralNetwork.metadata = metadata
+}
+
+// AddRegularizer adds a penalty on the parameters to the loss the neural network is trained on.
+func (neuralNetwork *NeuralNetwork) AddRegularizer(regularizer Regularizer) {
+	neuralNetwork.regularizers = append(neuralNetwork.regularizers, regularizer)
+}
+
+// AddCallback adds a callback that is notified of the progress of training in epochs.
+func (neuralNetwork *NeuralNetwork) AddCallback(callback Callback) {
+	neuralNetwork.callbacks = append(neuralNetwork.callbacks, callback)
+}
+
+// LearningRateScale gets the multiplier applied to the learning rate of a layer at a certain index.
+func (neuralNetwork *NeuralNetwork) LearningRateScale(index int) float32 {
+	if index < 0 || index >= len(neuralNetwork.learningRateScales) {
+		return 0
+	}
+	return neuralNetwork.learningRateScales[index]
+}
+
+// SetLearningRateScale sets a multiplier applied to the learning rate of a layer at a certain
+// index while training, such as a smaller scale for pretrained layers when fine tuning. A scale of
+// 0 freezes the layer. Every layer starts with a scale of 1.
+func (neuralNetwork *NeuralNetwork) SetLearningRateScale(index int, scale float32) error {
+	if index < 0 || index >= len(neuralNetwork.layers) {
+		return fmt.Errorf("Layer index out of range: %d", index)
+	}
+	if scale < 0 {
+		return fmt.Errorf("Learning rate scale must not be negative, is: %f", scale)
+	}
+	neuralNetwork.learningRateScales[index] = scale
+	return nil
+}
+
+// SetAutoAdapters sets whether Add inserts a flatten or reshape layer between two layers whose
+// shapes hold the same number of values in a different layout.
+func (neuralNetwork *NeuralNetwork) SetAutoAdapters(autoAdapters bool) {
+	neuralNetwork.autoAdapters = autoAdapters
+}
+
 // Add adds a number of new layers to the neural network.
 func (neuralNetwork *NeuralNetwork) Add(layers ...Layer) error {
 	for _, layer := range layers {
 		if len(neuralNetwork.layers) > 0 {
 			lastLayer := neuralNetwork.layers[len(neuralNetwork.layers)-1]
+			if lastLayer.OutputShape() != layer.InputShape() && neuralNetwork.autoAdapters {
+				adapter, err := adapterLayer(lastLayer.OutputShape(), layer.InputShape())
+				if err == nil {
+					neuralNetwork.layers = append(neuralNetwork.layers, adapter)
+					neuralNetwork.learningRateScales = append(neuralNetwork.learningRateScales, 1)
+					lastLayer = adapter
+				}
+			}
 			if lastLayer.OutputShape() != layer.InputShape() {
 				return fmt.Errorf(
 					"Output shape of last layer does not match input shape of new layer: (%d, %d, %d) != (%d, %d, %d)",
@@ -54,12 +145,24 @@ func (neuralNetwork *NeuralNetwork) Add(layers ...Layer) error {
 			}
 		}
 		neuralNetwork.layers = append(neuralNetwork.layers, layer)
+		neuralNetwork.learningRateScales = append(neuralNetwork.learningRateScales, 1)
 	}
 	return nil
 }
 
+func adapterLayer(outputShape LayerShape, inputShape LayerShape) (Layer, error) {
+	if inputShape == (LayerShape{1, shapeSize(outputShape), 1}) {
+		return NewFlattenLayer(outputShape.Rows, outputShape.Cols, outputShape.Frames), nil
+	}
+	return NewReshapeLayer(outputShape, inputShape)
+}
+
 // Predict generates a prediction for a certain set of inputs.
 func (neuralNetwork *NeuralNetwork) Predict(inputs [][][]float32) ([][][]float32, error) {
+	err := neuralNetwork.validate(inputs)
+	if err != nil {
+		return nil, err
+	}
 	outputs, err := neuralNetwork.feedForward(inputs)
 	if err != nil {
 		return nil, err
@@ -67,90 +170,508 @@ func (neuralNetwork *NeuralNetwork) Predict(inputs [][][]float32) ([][][]float32
 	return outputs.Copy().GetAll(), nil
 }
 
+// PredictBatch generates predictions for a batch of inputs. Consecutive layers that take a single
+// row, such as dense layers, process the whole batch with one matrix multiplication, which is much
+// faster than predicting each sample on its own.
+func (neuralNetwork *NeuralNetwork) PredictBatch(inputs []*tsr.Tensor) ([]*tsr.Tensor, error) {
+	if len(inputs) == 0 || len(neuralNetwork.layers) == 0 {
+		return []*tsr.Tensor{}, nil
+	}
+	inputShape := neuralNetwork.layers[0].InputShape()
+	for _, input := range inputs {
+		if input.Frames != inputShape.Frames || input.Rows != inputShape.Rows || input.Cols != inputShape.Cols {
+			return nil, fmt.Errorf(
+				"Input shape must match input shape of first layer: (%d, %d, %d) != (%d, %d, %d)",
+				input.Rows, input.Cols, input.Frames, inputShape.Rows, inputShape.Cols, inputShape.Frames,
+			)
+		}
+		if neuralNetwork.validateInputs {
+			err := neuralNetwork.validate(input.GetAll())
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, layer := range neuralNetwork.layers {
+		_, maskable := layer.(MaskableLayer)
+		_, masking := layer.(*MaskingLayer)
+		if maskable || masking {
+			// Masks are found for each sample, so the samples go through the layers one by one.
+			return neuralNetwork.predictEach(inputs)
+		}
+	}
+	samples := inputs
+	var batch *tsr.Tensor
+	var err error
+	for _, layer := range neuralNetwork.layers {
+		batchable, ok := layer.(batchLayer)
+		if ok && layer.InputShape().Rows == 1 && layer.InputShape().Frames == 1 {
+			if batch == nil {
+				batch = stackBatch(samples)
+			}
+			batch, err = batchable.feedForwardBatch(batch)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if batch != nil {
+			samples = unstackBatch(batch)
+			batch = nil
+		}
+		outputs := make([]*tsr.Tensor, len(samples))
+		for i, sample := range samples {
+			output, err := layer.FeedForward(sample)
+			if err != nil {
+				return nil, err
+			}
+			outputs[i] = output.Copy()
+		}
+		samples = outputs
+	}
+	if batch != nil {
+		samples = unstackBatch(batch)
+	}
+	return samples, nil
+}
+
+func (neuralNetwork *NeuralNetwork) predictEach(inputs []*tsr.Tensor) ([]*tsr.Tensor, error) {
+	outputs := make([]*tsr.Tensor, len(inputs))
+	for i, input := range inputs {
+		output, err := neuralNetwork.feedForward(input.GetAll())
+		if err != nil {
+			return nil, err
+		}
+		outputs[i] = output.Copy()
+	}
+	return outputs, nil
+}
+
+func stackBatch(samples []*tsr.Tensor) *tsr.Tensor {
+	batch := tsr.NewEmptyTensor2D(len(samples), samples[0].Cols)
+	batch.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return samples[row].Get(0, 0, col)
+	})
+	return batch
+}
+
+func unstackBatch(batch *tsr.Tensor) []*tsr.Tensor {
+	samples := make([]*tsr.Tensor, batch.Rows)
+	for row := range samples {
+		samples[row] = tsr.NewEmptyTensor1D(batch.Cols)
+		samples[row].ApplyFunction(func(current float32, frame int, r int, col int) float32 {
+			return batch.Get(0, row, col)
+		})
+	}
+	return samples
+}
+
 // Train takes a set of inputs and their respective targets, and adjusts the layers to produce the
-// given outputs through supervised learning.
-func (neuralNetwork *NeuralNetwork) Train(inputs [][][]float32, targets [][][]float32, learningRate float32, momentum float32) error {
-	outputs, err := neuralNetwork.feedForward(inputs)
+// given outputs through supervised learning, using an optimizer to update the parameters.
+func (neuralNetwork *NeuralNetwork) Train(inputs [][][]float32, targets [][][]float32, optimizer Optimizer) error {
+	_, err := neuralNetwork.backPropagate(inputs, targets)
 	if err != nil {
 		return err
 	}
-	deltas := tsr.NewValueTensor3D(targets)
-	err = deltas.SubtractTensor(outputs)
-	if err != nil {
-		return err
+	return neuralNetwork.applyGradients(optimizer, 1)
+}
+
+// TrainBatch trains the neural network on a set of samples in batches of a given size. The
+// gradients of every sample in a batch are averaged and given to the optimizer once at the end of
+// the batch.
+func (neuralNetwork *NeuralNetwork) TrainBatch(inputs [][][][]float32, targets [][][][]float32, batchSize int, optimizer Optimizer) error {
+	return neuralNetwork.TrainBatchSchedule(inputs, targets, batchSize, 1, ConstantSchedule(optimizer.LearningRate()), optimizer)
+}
+
+// PartialFit updates the neural network from a batch of new samples, such as the latest samples of
+// a stream, with a single optimizer step. Reusing the optimizer between calls keeps its state, so
+// training continues from where the last call left off. The callbacks of the neural network are
+// not notified, since each call is a step of one ongoing training rather than a training of its own.
+func (neuralNetwork *NeuralNetwork) PartialFit(inputs [][][][]float32, targets [][][][]float32, optimizer Optimizer) error {
+	if len(inputs) == 0 {
+		return nil
+	}
+	schedule := ConstantSchedule(optimizer.LearningRate())
+	return neuralNetwork.trainBatches(inputs, targets, len(inputs), 1, SequentialSampler{}, schedule, optimizer, nil, nil)
+}
+
+// TrainBatchSchedule trains the neural network on a set of samples in batches like TrainBatch for a
+// number of epochs, setting the learning rate of the optimizer from a schedule before each batch.
+// The steps of the schedule count the batches across all epochs.
+func (neuralNetwork *NeuralNetwork) TrainBatchSchedule(inputs [][][][]float32, targets [][][][]float32, batchSize int, epochs int, schedule LearningRateSchedule, optimizer Optimizer) error {
+	return neuralNetwork.TrainBatchSampler(inputs, targets, batchSize, epochs, SequentialSampler{}, schedule, optimizer)
+}
+
+// TrainBatchSampler trains the neural network in batches like TrainBatchSchedule, using a sampler to
+// choose which samples make up the batches of each epoch. The callbacks of the neural network are
+// notified at the start and end of training and at the end of every epoch.
+func (neuralNetwork *NeuralNetwork) TrainBatchSampler(inputs [][][][]float32, targets [][][][]float32, batchSize int, epochs int, sampler Sampler, schedule LearningRateSchedule, optimizer Optimizer) error {
+	return neuralNetwork.trainBatches(inputs, targets, batchSize, epochs, sampler, schedule, optimizer, neuralNetwork.callbacks, nil)
+}
+
+// trainBatches runs the training loop from the epoch and step of a training state, which is kept up
+// to date so it can be saved at the end of an epoch. Without a state, training starts from the
+// first epoch.
+func (neuralNetwork *NeuralNetwork) trainBatches(inputs [][][][]float32, targets [][][][]float32, batchSize int, epochs int, sampler Sampler, schedule LearningRateSchedule, optimizer Optimizer, callbacks []Callback, state *trainingState) error {
+	if len(inputs) != len(targets) {
+		return fmt.Errorf("Number of inputs and targets must match: %d != %d", len(inputs), len(targets))
+	}
+	if batchSize < 1 {
+		return fmt.Errorf("Batch size must be at least 1, is: %d", batchSize)
+	}
+	for _, callback := range callbacks {
+		err := callback.OnTrainBegin(neuralNetwork)
+		if err != nil {
+			return err
+		}
+	}
+	if state == nil {
+		state = &trainingState{}
+	}
+	for ; state.epoch < epochs; state.epoch++ {
+		if state.seed != 0 {
+			state.random = rand.New(rand.NewSource(state.seed + int64(state.epoch)))
+		}
+		indices, err := sampleIndices(sampler, len(inputs), state.random)
+		if err != nil {
+			return err
+		}
+		totalLoss := float32(0.0)
+		for start := 0; start < len(indices); start += batchSize {
+			end := start + batchSize
+			if end > len(indices) {
+				end = len(indices)
+			}
+			for _, index := range indices[start:end] {
+				loss, err := neuralNetwork.backPropagate(inputs[index], targets[index])
+				if err != nil {
+					return err
+				}
+				totalLoss += loss
+			}
+			optimizer.SetLearningRate(schedule(state.step))
+			err := neuralNetwork.applyGradients(optimizer, end-start)
+			if err != nil {
+				return err
+			}
+			state.step++
+		}
+		metrics := map[string]float32{"learningRate": optimizer.LearningRate()}
+		if len(indices) > 0 {
+			metrics["loss"] = totalLoss / float32(len(indices))
+		}
+		stop := false
+		for _, callback := range callbacks {
+			err := callback.OnEpochEnd(neuralNetwork, state.epoch, metrics)
+			if err == ErrStopTraining {
+				stop = true
+			} else if err != nil {
+				return err
+			}
+		}
+		if stop {
+			break
+		}
+	}
+	for _, callback := range callbacks {
+		err := callback.OnTrainEnd(neuralNetwork)
+		if err != nil {
+			return err
+		}
 	}
-	return neuralNetwork.backPropagate(deltas, learningRate, momentum)
+	return nil
 }
 
+// feedForward feeds inputs through the layers. The mask of a masking layer is passed to the
+// maskable layers after it, and it is kept as the output mask while the layers after it produce
+// sequences, so the padded timesteps of the outputs can be left out of the loss.
 func (neuralNetwork *NeuralNetwork) feedForward(inputs [][][]float32) (*tsr.Tensor, error) {
+	err := checkValues(inputs, "inputs")
+	if err != nil {
+		return nil, err
+	}
 	nextInputs := tsr.NewValueTensor3D(inputs)
-	var err error
-	for _, layer := range neuralNetwork.layers {
+	var mask []bool
+	neuralNetwork.outputMask = nil
+	for i, layer := range neuralNetwork.layers {
+		if maskableLayer, ok := layer.(MaskableLayer); ok {
+			maskableLayer.SetMask(mask)
+		}
 		nextInputs, err = layer.FeedForward(nextInputs)
 		if err != nil {
 			return nil, err
 		}
+		if tsr.DebugInvariants {
+			err = checkShape(nextInputs, layer.OutputShape(), "output")
+			if err != nil {
+				return nil, fmt.Errorf("Invalid layer %d: %s", i, err.Error())
+			}
+		}
+		if maskingLayer, ok := layer.(*MaskingLayer); ok {
+			mask = maskingLayer.Mask()
+			neuralNetwork.outputMask = mask
+		} else if sequence, ok := layer.(sequenceLayer); !ok || !sequence.returnsSequences() {
+			neuralNetwork.outputMask = nil
+		}
 	}
 	return nextInputs, nil
 }
 
-func (neuralNetwork *NeuralNetwork) backPropagate(deltas *tsr.Tensor, learningRate float32, momentum float32) error {
+// checkValues makes sure the values of a sample, such as its inputs or targets, are not empty and
+// have the same number of rows in each frame and columns in each row, so they fit in a tensor.
+func checkValues(values [][][]float32, name string) error {
+	if len(values) == 0 || len(values[0]) == 0 || len(values[0][0]) == 0 {
+		return fmt.Errorf("Values of %s must not be empty", name)
+	}
+	rows, cols := len(values[0]), len(values[0][0])
+	for frame := range values {
+		if len(values[frame]) != rows {
+			return fmt.Errorf("Rows of frame %d of %s must be %d, are: %d", frame, name, rows, len(values[frame]))
+		}
+		for row := range values[frame] {
+			if len(values[frame][row]) != cols {
+				return fmt.Errorf("Columns of row %d of frame %d of %s must be %d, are: %d", row, frame, name, cols, len(values[frame][row]))
+			}
+		}
+	}
+	return nil
+}
+
+// backPropagate feeds a sample through the neural network and adds the gradients of its loss to
+// the gradients of the layers. It returns the loss of the sample.
+func (neuralNetwork *NeuralNetwork) backPropagate(inputs [][][]float32, targets [][][]float32) (float32, error) {
+	outputs, err := neuralNetwork.feedForward(inputs)
+	if err != nil {
+		return 0, err
+	}
+	err = checkValues(targets, "targets")
+	if err != nil {
+		return 0, err
+	}
+	targetTensor := tsr.NewValueTensor3D(targets)
+	loss, err := maskedLoss(neuralNetwork.loss, outputs, targetTensor, neuralNetwork.outputMask)
+	if err != nil {
+		return 0, err
+	}
+	lastIndex := len(neuralNetwork.layers) - 1
+	if lastIndex < 0 {
+		return loss, nil
+	}
+	if softmax, ok := neuralNetwork.layers[lastIndex].(*SoftmaxLayer); ok && neuralNetwork.loss.Type == LossTypeCrossEntropy {
+		deltas, err := softmax.crossEntropyDeltas(targetTensor)
+		if err != nil {
+			return 0, err
+		}
+		return loss, neuralNetwork.backPropagateDeltasFrom(deltas, lastIndex-1)
+	}
+	deltas, err := maskedLossDerivative(neuralNetwork.loss, outputs, targetTensor, neuralNetwork.outputMask)
+	if err != nil {
+		return 0, err
+	}
+	return loss, neuralNetwork.backPropagateDeltas(deltas)
+}
+
+// backPropagateDeltas back propagates the gradient of the outputs of the last feed forward through
+// the layers.
+func (neuralNetwork *NeuralNetwork) backPropagateDeltas(deltas *tsr.Tensor) error {
+	return neuralNetwork.backPropagateDeltasFrom(deltas, len(neuralNetwork.layers)-1)
+}
+
+// backPropagateDeltasFrom back propagates the gradient of the outputs of a layer through that layer
+// and the layers before it.
+func (neuralNetwork *NeuralNetwork) backPropagateDeltasFrom(deltas *tsr.Tensor, lastIndex int) error {
 	nextDeltas := deltas
 	var err error
-	for i := len(neuralNetwork.layers) - 1; i >= 0; i-- {
-		layer := neuralNetwork.layers[i]
-		nextDeltas, err = layer.BackPropagate(nextDeltas, learningRate, momentum)
+	for i := lastIndex; i >= 0; i-- {
+		nextDeltas, err = neuralNetwork.layers[i].BackPropagate(nextDeltas)
 		if err != nil {
 			return err
 		}
+		if tsr.DebugInvariants {
+			err = checkShape(nextDeltas, neuralNetwork.layers[i].InputShape(), "delta")
+			if err != nil {
+				return fmt.Errorf("Invalid layer %d: %s", i, err.Error())
+			}
+		}
 	}
 	return nil
 }
 
-// SaveToFile saves a neural network to a file.
-func (neuralNetwork *NeuralNetwork) SaveToFile(fileName string) error {
-	file, err := os.Create(fileName)
+// applyGradients updates the parameters of the layers with the average of the gradients added up
+// over a number of samples, and then clears the gradients. The gradients are regularized and
+// clipped before any parameter is updated.
+func (neuralNetwork *NeuralNetwork) applyGradients(optimizer Optimizer, samples int) error {
+	updates := []gradientUpdate{}
+	for i, layer := range neuralNetwork.layers {
+		parameters := layer.Parameters()
+		gradients := layer.Gradients()
+		if sparse, ok := layer.(sparseLayer); ok {
+			parameters, gradients = sparse.sparseParameters()
+		}
+		var layerRegularizer Regularizer
+		regularized := map[*tsr.Tensor]bool{}
+		if regularizedLayer, ok := layer.(regularizedLayer); ok {
+			var regularizedParameters []*tsr.Tensor
+			layerRegularizer, regularizedParameters = regularizedLayer.regularizedParameters()
+			for _, parameter := range regularizedParameters {
+				regularized[parameter] = true
+			}
+		}
+		for j, parameter := range parameters {
+			gradients[j].Scale(1 / float32(samples))
+			for _, regularizer := range neuralNetwork.regularizers {
+				err := regularizer.AddGradient(parameter, gradients[j])
+				if err != nil {
+					return err
+				}
+			}
+			if layerRegularizer != nil && regularized[parameter] {
+				err := layerRegularizer.AddGradient(parameter, gradients[j])
+				if err != nil {
+					return err
+				}
+			}
+			updates = append(updates, gradientUpdate{parameter, gradients[j], neuralNetwork.learningRateScales[i]})
+		}
+	}
+	neuralNetwork.clipGradients(updates)
+	for _, update := range updates {
+		if update.learningRateScale > 0 {
+			err := optimizer.Update(update.parameter, update.gradient, update.learningRateScale)
+			if err != nil {
+				return err
+			}
+		}
+		update.gradient.Scale(0)
+	}
+	return nil
+}
+
+// Parameters returns the parameters of every layer of the neural network, in order.
+func (neuralNetwork *NeuralNetwork) Parameters() []*tsr.Tensor {
+	parameters := []*tsr.Tensor{}
+	for _, layer := range neuralNetwork.layers {
+		parameters = append(parameters, layer.Parameters()...)
+	}
+	return parameters
+}
+
+// Gradients returns the gradients of the parameters of every layer.
+func (neuralNetwork *NeuralNetwork) Gradients() []*tsr.Tensor {
+	gradients := []*tsr.Tensor{}
+	for _, layer := range neuralNetwork.layers {
+		gradients = append(gradients, layer.Gradients()...)
+	}
+	return gradients
+}
+
+// MarshalJSON converts the layers and metadata of the neural network to JSON, along with the
+// version of the format, the input and output shapes and a checksum of the layers.
+func (neuralNetwork *NeuralNetwork) MarshalJSON() ([]byte, error) {
+	layers, err := json.Marshal(neuralNetwork.layers)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer file.Close()
-	neuralNetworkData := struct {
-		Layers []Layer `json:"layers"`
-	}{
-		Layers: neuralNetwork.layers,
+	checksum, err := layersChecksum(layers)
+	if err != nil {
+		return nil, err
+	}
+	neuralNetworkData := neuralNetworkData{
+		FormatVersion: FormatVersion,
+		Metadata:      neuralNetwork.metadata,
+		Checksum:      checksum,
+		Layers:        layers,
+	}
+	if len(neuralNetwork.layers) > 0 {
+		inputShape := neuralNetwork.layers[0].InputShape()
+		outputShape := neuralNetwork.layers[len(neuralNetwork.layers)-1].OutputShape()
+		neuralNetworkData.InputShape = &inputShape
+		neuralNetworkData.OutputShape = &outputShape
 	}
-	return json.NewEncoder(file).Encode(neuralNetworkData)
+	return json.Marshal(neuralNetworkData)
 }
 
-// LoadFromFile loads a neural network from a file.
-func (neuralNetwork *NeuralNetwork) LoadFromFile(fileName string) error {
-	file, err := os.Open(fileName)
+// UnmarshalJSON adds the layers saved in JSON to the neural network and sets its metadata. The
+// version, checksum and shapes are checked, except for neural networks saved before the format
+// had a version.
+func (neuralNetwork *NeuralNetwork) UnmarshalJSON(b []byte) error {
+	neuralNetworkData := neuralNetworkData{}
+	err := json.Unmarshal(b, &neuralNetworkData)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	neuralNetworkData := struct {
-		Layers []map[string]interface{} `json:"layers"`
-	}{}
-	err = json.NewDecoder(file).Decode(&neuralNetworkData)
+	err = neuralNetworkData.check()
 	if err != nil {
 		return err
 	}
-	for _, layerData := range neuralNetworkData.Layers {
-		layerType, _ := layerData["type"].(string)
-		layer, err := layerForType(LayerType(layerType))
+	layers := []json.RawMessage{}
+	if neuralNetworkData.Layers != nil {
+		err = json.Unmarshal(neuralNetworkData.Layers, &layers)
 		if err != nil {
 			return err
 		}
-		layerBytes, _ := json.Marshal(layerData)
-		err = json.Unmarshal(layerBytes, layer)
+	}
+	neuralNetwork.metadata = neuralNetworkData.Metadata
+	firstIndex := len(neuralNetwork.layers)
+	for i, layerData := range layers {
+		layer, err := unmarshalLayer(layerData)
 		if err != nil {
-			return err
+			return fmt.Errorf("Invalid layer %d: %s", i, err.Error())
 		}
 		err = neuralNetwork.Add(layer)
 		if err != nil {
-			return err
+			return fmt.Errorf("Invalid layer %d: %s", i, err.Error())
 		}
 	}
+	if len(layers) > 0 {
+		return neuralNetworkData.checkShapes(
+			neuralNetwork.layers[firstIndex].InputShape(),
+			neuralNetwork.layers[len(neuralNetwork.layers)-1].OutputShape(),
+		)
+	}
 	return nil
 }
+
+// WriteTo writes a neural network to a writer as JSON, such as to a network connection or through a
+// gzip writer. It returns the number of bytes written.
+func (neuralNetwork *NeuralNetwork) WriteTo(writer io.Writer) (int64, error) {
+	counter := &countingWriter{writer: writer}
+	err := json.NewEncoder(counter).Encode(neuralNetwork)
+	return counter.count, err
+}
+
+// ReadFrom reads a neural network from a reader, either in JSON or in the binary format. It returns
+// the number of bytes read, which can include bytes after the neural network that were buffered.
+func (neuralNetwork *NeuralNetwork) ReadFrom(reader io.Reader) (int64, error) {
+	counter := &countingReader{reader: reader}
+	bufferedReader := bufio.NewReader(counter)
+	var err error
+	if isBinary(bufferedReader) {
+		err = neuralNetwork.readBinary(bufferedReader)
+	} else {
+		err = json.NewDecoder(bufferedReader).Decode(neuralNetwork)
+	}
+	return counter.count, err
+}
+
+// SaveToFile saves a neural network to a file.
+func (neuralNetwork *NeuralNetwork) SaveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = neuralNetwork.WriteTo(file)
+	return err
+}
+
+// LoadFromFile loads a neural network from a file, either in JSON or in the binary format.
+func (neuralNetwork *NeuralNetwork) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = neuralNetwork.ReadFrom(file)
+	return err
+}
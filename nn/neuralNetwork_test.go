@@ -1,8 +1,12 @@
 package nn
 
 import (
+	"bytes"
+	"compress/gzip"
+	"math"
 	"math/rand"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -57,6 +61,38 @@ func TestNeuralNetworkAddGetLayers(t *testing.T) {
 	}
 }
 
+func TestNeuralNetworkParameters(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 2, ActivationLinear), NewSoftmaxLayer(2))
+
+	if len(neuralNetwork.LayerAt(1).Parameters()) != 0 || len(neuralNetwork.LayerAt(1).Gradients()) != 0 {
+		t.Errorf("Layer without parameters should have no parameters or gradients")
+	}
+	parameters := neuralNetwork.Parameters()
+	if len(parameters) != 2 || len(neuralNetwork.Gradients()) != 2 {
+		t.Fatalf("Neural network should have 2 parameters and gradients, has: %d", len(parameters))
+	}
+
+	// Changing the parameters in place should change the predictions.
+	parameters[0].SetTensor(tsr.NewValueTensor2D([][]float32{{0, 0}, {0, 0}}))
+	parameters[1].SetTensor(tsr.NewValueTensor1D([]float32{0, float32(math.Log(3))}))
+	result, err := neuralNetwork.Predict([][][]float32{{{1, 2}}})
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	if math.Abs(float64(result[0][0][1]-0.75)) > 1e-5 {
+		t.Errorf("Prediction should use the changed parameters: 0.75 != %.5f", result[0][0][1])
+	}
+
+	_, err = neuralNetwork.backPropagate([][][]float32{{{1, 2}}}, [][][]float32{{{1, 0}}})
+	if err != nil {
+		t.Fatalf("Error in backPropagate: %s", err.Error())
+	}
+	if neuralNetwork.Gradients()[1].Get(0, 0, 0) >= 0 {
+		t.Errorf("Gradient of the bias of the target class should be negative, is: %f", neuralNetwork.Gradients()[1].Get(0, 0, 0))
+	}
+}
+
 func TestNeuralNetworkXOR(t *testing.T) {
 	rand.Seed(time.Now().Unix())
 	trainingData := []TrainingData{
@@ -84,10 +120,11 @@ func TestNeuralNetworkXOR(t *testing.T) {
 		NewDenseLayer(2, 1, ActivationSigmoid),
 	)
 
+	optimizer := NewSGDOptimizer(0.15, 0.5)
 	for i := 0; i < 10000; i++ {
 		index := rand.Intn(len(trainingData))
 		data := trainingData[index]
-		err := neuralNetwork.Train(data.Inputs, data.Targets, 0.3, 0.5)
+		err := neuralNetwork.Train(data.Inputs, data.Targets, optimizer)
 		if err != nil {
 			t.Fatalf("Error in Train: %s", err.Error())
 		}
@@ -128,14 +165,17 @@ func TestNeuralNetworkXOR(t *testing.T) {
 
 func TestNeuralNetworkSaveLoad(t *testing.T) {
 	neuralNetwork := NewNeuralNetwork()
-	conv := NewConvolutionLayer(16, 16, 1, []*tsr.Tensor{FilterVerticalEdges, FilterHorizontalEdges}, ActivationRELU)
+	conv, err := NewConvolutionLayer(16, 16, 1, []*tsr.Tensor{FilterVerticalEdges, FilterHorizontalEdges}, ActivationRELU)
+	if err != nil {
+		t.Fatalf("Error in NewConvolutionLayer: %s", err.Error())
+	}
 	pool := NewPoolingLayer(conv.OutputShape().Rows, conv.OutputShape().Cols, conv.OutputShape().Frames, 2, PoolingMax)
 	flat := NewFlattenLayer(pool.OutputShape().Rows, pool.OutputShape().Cols, pool.OutputShape().Frames)
 	dense1 := NewDenseLayer(flat.OutputShape().Cols, 16, ActivationRELU)
 	dense2 := NewDenseLayer(dense1.OutputShape().Cols, 8, ActivationSoftmax)
 	neuralNetwork.Add(conv, pool, flat, dense1, dense2)
 
-	err := neuralNetwork.SaveToFile("neuralNetwork.json")
+	err = neuralNetwork.SaveToFile("neuralNetwork.json")
 	if err != nil {
 		t.Fatalf("Error in SaveNeuralNetwork: %s", err.Error())
 	}
@@ -155,3 +195,241 @@ func TestNeuralNetworkSaveLoad(t *testing.T) {
 		t.Fatalf("Error removing test file: %s", err.Error())
 	}
 }
+
+func TestNeuralNetworkWriteToReadFrom(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(3, 4, ActivationSigmoid), NewDenseLayer(4, 2, ActivationSigmoid))
+
+	// Write the neural network through a gzip writer, as if compressing it for storage.
+	buffer := bytes.Buffer{}
+	compressor := gzip.NewWriter(&buffer)
+	written, err := neuralNetwork.WriteTo(compressor)
+	if err != nil {
+		t.Fatalf("Error in WriteTo: %s", err.Error())
+	}
+	compressor.Close()
+	if written == 0 {
+		t.Errorf("Number of bytes written should not be 0")
+	}
+
+	decompressor, err := gzip.NewReader(&buffer)
+	if err != nil {
+		t.Fatalf("Error creating gzip reader: %s", err.Error())
+	}
+	loaded := NewNeuralNetwork()
+	read, err := loaded.ReadFrom(decompressor)
+	if err != nil {
+		t.Fatalf("Error in ReadFrom: %s", err.Error())
+	}
+	if read != written {
+		t.Errorf("Number of bytes read should match bytes written: %d != %d", read, written)
+	}
+	inputs := [][][]float32{{{1, 2, 3}}}
+	expected, _ := neuralNetwork.Predict(inputs)
+	result, _ := loaded.Predict(inputs)
+	if result[0][0][0] != expected[0][0][0] || result[0][0][1] != expected[0][0][1] {
+		t.Errorf("Prediction of read neural network should match: %v != %v", result, expected)
+	}
+
+	_, err = NewNeuralNetwork().ReadFrom(strings.NewReader("not a neural network"))
+	if err == nil {
+		t.Errorf("Reading invalid data should have an error")
+	}
+}
+
+func TestNeuralNetworkTrainBatch(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 2, ActivationSigmoid))
+
+	inputs := [][][][]float32{{{{0, 1}}}, {{{1, 0.5}}}}
+	targets := [][][][]float32{{{{1, 0}}}, {{{0, 1}}}}
+
+	// A batch makes the same changes as the average of training on each sample on its own.
+	first := neuralNetwork.Copy()
+	first.Train(inputs[0], targets[0], NewSGDOptimizer(0.5, 0))
+	second := neuralNetwork.Copy()
+	second.Train(inputs[1], targets[1], NewSGDOptimizer(0.5, 0))
+
+	err := neuralNetwork.TrainBatch(inputs, targets, 2, NewSGDOptimizer(0.5, 0))
+	if err != nil {
+		t.Fatalf("Error in TrainBatch: %s", err.Error())
+	}
+
+	layer := neuralNetwork.LayerAt(0).(*DenseLayer)
+	firstLayer := first.LayerAt(0).(*DenseLayer)
+	secondLayer := second.LayerAt(0).(*DenseLayer)
+	for row := 0; row < 2; row++ {
+		for col := 0; col < 2; col++ {
+			solution := (firstLayer.Weights.Get(0, row, col) + secondLayer.Weights.Get(0, row, col)) / 2
+			if math.Abs(float64(layer.Weights.Get(0, row, col)-solution)) > 1e-5 {
+				t.Errorf("Batch weight (%d, %d) should be %.5f, is: %.5f", row, col, solution, layer.Weights.Get(0, row, col))
+			}
+		}
+	}
+	for col := 0; col < 2; col++ {
+		solution := (firstLayer.Bias.Get(0, 0, col) + secondLayer.Bias.Get(0, 0, col)) / 2
+		if math.Abs(float64(layer.Bias.Get(0, 0, col)-solution)) > 1e-5 {
+			t.Errorf("Batch bias %d should be %.5f, is: %.5f", col, solution, layer.Bias.Get(0, 0, col))
+		}
+	}
+
+	err = neuralNetwork.TrainBatch(inputs, targets[:1], 2, NewSGDOptimizer(0.5, 0))
+	if err == nil {
+		t.Errorf("Mismatched inputs and targets did not trigger error")
+	}
+
+	// A neural network without layers has nothing to train, but training it does not fail.
+	err = NewNeuralNetwork().TrainBatch(inputs, inputs, 2, NewSGDOptimizer(0.5, 0))
+	if err != nil {
+		t.Errorf("Error in TrainBatch without layers: %s", err.Error())
+	}
+}
+
+func TestNeuralNetworkLearningRateScale(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(
+		NewDenseLayer(2, 3, ActivationSigmoid),
+		NewDenseLayer(3, 1, ActivationSigmoid),
+	)
+
+	err := neuralNetwork.SetLearningRateScale(0, 0)
+	if err != nil {
+		t.Fatalf("Error in SetLearningRateScale: %s", err.Error())
+	}
+	if neuralNetwork.LearningRateScale(0) != 0 || neuralNetwork.LearningRateScale(1) != 1 {
+		t.Errorf("Incorrect learning rate scales: %.1f, %.1f", neuralNetwork.LearningRateScale(0), neuralNetwork.LearningRateScale(1))
+	}
+	if neuralNetwork.Copy().LearningRateScale(0) != 0 {
+		t.Errorf("Copy should keep the learning rate scales")
+	}
+
+	frozenWeights := neuralNetwork.LayerAt(0).(*DenseLayer).Weights.Copy()
+	trainedWeights := neuralNetwork.LayerAt(1).(*DenseLayer).Weights.Copy()
+	optimizer := NewSGDOptimizer(0.5, 0.5)
+	neuralNetwork.Train([][][]float32{{{1, 0}}}, [][][]float32{{{1}}}, optimizer)
+	neuralNetwork.TrainBatch([][][][]float32{{{{0, 1}}}}, [][][][]float32{{{{0}}}}, 1, optimizer)
+
+	if !neuralNetwork.LayerAt(0).(*DenseLayer).Weights.Equals(frozenWeights) {
+		t.Errorf("Layer with learning rate scale of 0 should not change")
+	}
+	if neuralNetwork.LayerAt(1).(*DenseLayer).Weights.Equals(trainedWeights) {
+		t.Errorf("Layer with learning rate scale of 1 should change")
+	}
+
+	err = neuralNetwork.SetLearningRateScale(2, 1)
+	if err == nil {
+		t.Errorf("Learning rate scale for missing layer did not trigger error")
+	}
+}
+
+func TestNeuralNetworkPartialFit(t *testing.T) {
+	rand.Seed(1)
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+
+	callback := &recordingCallback{stopAt: -1}
+	neuralNetwork.AddCallback(callback)
+
+	// Stream samples of the function y = x1 in small batches.
+	optimizer := NewAdamOptimizer(0.05)
+	for i := 0; i < 300; i++ {
+		inputs := [][][][]float32{}
+		targets := [][][][]float32{}
+		for j := 0; j < 4; j++ {
+			x1, x2 := float32(rand.Intn(2)), float32(rand.Intn(2))
+			inputs = append(inputs, [][][]float32{{{x1, x2}}})
+			targets = append(targets, [][][]float32{{{x1}}})
+		}
+		err := neuralNetwork.PartialFit(inputs, targets, optimizer)
+		if err != nil {
+			t.Fatalf("Error in PartialFit: %s", err.Error())
+		}
+	}
+
+	result, err := neuralNetwork.Predict([][][]float32{{{1, 0}}})
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	if result[0][0][0] < 0.9 {
+		t.Errorf("Incorrect prediction for [1, 0]: %.3f", result[0][0][0])
+	}
+	result, err = neuralNetwork.Predict([][][]float32{{{0, 1}}})
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	if result[0][0][0] > 0.1 {
+		t.Errorf("Incorrect prediction for [0, 1]: %.3f", result[0][0][0])
+	}
+
+	if len(callback.events) != 0 {
+		t.Errorf("PartialFit should not notify callbacks, notified: %v", callback.events)
+	}
+
+	err = neuralNetwork.PartialFit(nil, nil, optimizer)
+	if err != nil {
+		t.Errorf("Empty batch should not trigger error: %s", err.Error())
+	}
+}
+
+func TestNeuralNetworkPredictBatch(t *testing.T) {
+	rand.Seed(1)
+	neuralNetwork := NewNeuralNetwork()
+	conv, err := NewConvolutionLayer(4, 4, 1, []*tsr.Tensor{FilterVerticalEdges, FilterHorizontalEdges}, ActivationRELU)
+	if err != nil {
+		t.Fatalf("Error in NewConvolutionLayer: %s", err.Error())
+	}
+	pool := NewPoolingLayer(4, 4, 2, 2, PoolingMax)
+	flat := NewFlattenLayer(2, 2, 2)
+	neuralNetwork.Add(
+		conv, pool, flat,
+		NewDenseLayer(8, 6, ActivationTanh),
+		NewDenseLayer(6, 3, ActivationSoftmax),
+	)
+
+	inputs := make([]*tsr.Tensor, 5)
+	for i := range inputs {
+		inputs[i] = tsr.NewEmptyTensor2D(4, 4)
+		inputs[i].SetRandom(0, 1)
+	}
+
+	results, err := neuralNetwork.PredictBatch(inputs)
+	if err != nil {
+		t.Fatalf("Error in PredictBatch: %s", err.Error())
+	}
+	if len(results) != len(inputs) {
+		t.Fatalf("Number of predictions should be %d, is: %d", len(inputs), len(results))
+	}
+	for i, input := range inputs {
+		prediction, err := neuralNetwork.Predict(input.GetAll())
+		if err != nil {
+			t.Fatalf("Error in Predict: %s", err.Error())
+		}
+		solution := tsr.NewValueTensor3D(prediction)
+		for col := 0; col < solution.Cols; col++ {
+			if math.Abs(float64(results[i].Get(0, 0, col)-solution.Get(0, 0, col))) > 1e-6 {
+				t.Errorf("Batch prediction %d should be:\n%swhen result is:\n%s", i, solution.String(), results[i].String())
+				break
+			}
+		}
+	}
+
+	_, err = neuralNetwork.PredictBatch([]*tsr.Tensor{tsr.NewEmptyTensor2D(3, 4)})
+	if err == nil {
+		t.Errorf("Input with incorrect shape did not trigger error")
+	}
+}
+
+func TestNeuralNetworkMalformedValues(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+	for _, inputs := range [][][][]float32{nil, {{}}, {{{}}}, {{{1, 0}, {1}}}, {{{1, 0}}, {}}} {
+		_, err := neuralNetwork.Predict(inputs)
+		if err == nil {
+			t.Errorf("Malformed inputs %v did not trigger error", inputs)
+		}
+	}
+	err := neuralNetwork.Train([][][]float32{{{1, 0}}}, [][][]float32{{{1}, {}}}, NewSGDOptimizer(0.1, 0))
+	if err == nil {
+		t.Errorf("Malformed targets did not trigger error")
+	}
+}
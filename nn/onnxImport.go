@@ -0,0 +1,644 @@
+package nn
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+
+	tsr "../tensor"
+)
+
+// LoadFromFileONNX adds the layers of a model saved in the ONNX format to the neural network, so a
+// model trained with another library can be used for predictions. The graph must be a chain of
+// Gemm, Conv, MaxPool, Relu, Sigmoid, Softmax and Flatten operators, with weights stored as float
+// initializers. Relu and Sigmoid become the activation of the Gemm or Conv before them. Since the
+// convolution layer filters each input channel on its own, a Conv must have a single input channel,
+// a stride of 1 and padding that keeps the size of the inputs.
+func (neuralNetwork *NeuralNetwork) LoadFromFileONNX(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return neuralNetwork.readONNX(file)
+}
+
+func (neuralNetwork *NeuralNetwork) readONNX(reader io.Reader) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	graph, err := parseONNXModel(data)
+	if err != nil {
+		return err
+	}
+	layers, err := graph.layers()
+	if err != nil {
+		return err
+	}
+	return neuralNetwork.Add(layers...)
+}
+
+// protoField is a field of a message in the protocol buffer format that ONNX is saved in. Varint,
+// fixed 32 bit and fixed 64 bit values are kept in value, and length delimited values in bytes.
+type protoField struct {
+	number    int
+	value     uint64
+	bytes     []byte
+	delimited bool
+}
+
+func parseProto(data []byte) ([]protoField, error) {
+	fields := []protoField{}
+	for offset := 0; offset < len(data); {
+		key, n := binary.Uvarint(data[offset:])
+		if n <= 0 {
+			return nil, fmt.Errorf("Invalid protocol buffer key at byte %d", offset)
+		}
+		offset += n
+		field := protoField{number: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			field.value, n = binary.Uvarint(data[offset:])
+			if n <= 0 {
+				return nil, fmt.Errorf("Invalid protocol buffer varint at byte %d", offset)
+			}
+			offset += n
+		case 1:
+			if offset+8 > len(data) {
+				return nil, fmt.Errorf("Protocol buffer ends in the middle of a field")
+			}
+			field.value = binary.LittleEndian.Uint64(data[offset:])
+			offset += 8
+		case 2:
+			length, n := binary.Uvarint(data[offset:])
+			// The length is compared before converting it, since a large length would overflow.
+			if n <= 0 || length > uint64(len(data)-offset-n) {
+				return nil, fmt.Errorf("Invalid protocol buffer length at byte %d", offset)
+			}
+			offset += n
+			field.bytes = data[offset : offset+int(length)]
+			field.delimited = true
+			offset += int(length)
+		case 5:
+			if offset+4 > len(data) {
+				return nil, fmt.Errorf("Protocol buffer ends in the middle of a field")
+			}
+			field.value = uint64(binary.LittleEndian.Uint32(data[offset:]))
+			offset += 4
+		default:
+			return nil, fmt.Errorf("Unsupported protocol buffer wire type: %d", key&7)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// protoInts reads a repeated integer field, which may be packed into a single length delimited
+// value.
+func protoInts(field protoField) ([]int64, error) {
+	if !field.delimited {
+		return []int64{int64(field.value)}, nil
+	}
+	values := []int64{}
+	for offset := 0; offset < len(field.bytes); {
+		value, n := binary.Uvarint(field.bytes[offset:])
+		if n <= 0 {
+			return nil, fmt.Errorf("Invalid packed protocol buffer integers")
+		}
+		values = append(values, int64(value))
+		offset += n
+	}
+	return values, nil
+}
+
+// protoFloats reads a repeated float field, which may be packed into a single length delimited
+// value.
+func protoFloats(field protoField) ([]float32, error) {
+	if !field.delimited {
+		return []float32{math.Float32frombits(uint32(field.value))}, nil
+	}
+	if len(field.bytes)%4 != 0 {
+		return nil, fmt.Errorf("Invalid packed protocol buffer floats")
+	}
+	values := make([]float32, len(field.bytes)/4)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(field.bytes[i*4:]))
+	}
+	return values, nil
+}
+
+type onnxTensor struct {
+	dims   []int
+	values []float32
+}
+
+type onnxNode struct {
+	opType  string
+	inputs  []string
+	outputs []string
+	ints    map[string][]int64
+	floats  map[string]float32
+	strings map[string]string
+}
+
+type onnxGraph struct {
+	nodes        []onnxNode
+	initializers map[string]onnxTensor
+	inputName    string
+	inputDims    []int
+}
+
+func parseONNXModel(data []byte) (*onnxGraph, error) {
+	// The graph is field 7 of a ModelProto.
+	graph, err := protoMessage(data, 7)
+	if err != nil {
+		return nil, err
+	}
+	if graph == nil {
+		return nil, fmt.Errorf("ONNX model does not have a graph")
+	}
+	return parseONNXGraph(graph)
+}
+
+func parseONNXGraph(data []byte) (*onnxGraph, error) {
+	fields, err := parseProto(data)
+	if err != nil {
+		return nil, err
+	}
+	graph := &onnxGraph{initializers: map[string]onnxTensor{}}
+	inputs := []protoField{}
+	for _, field := range fields {
+		switch field.number {
+		case 1:
+			node, err := parseONNXNode(field.bytes)
+			if err != nil {
+				return nil, err
+			}
+			graph.nodes = append(graph.nodes, node)
+		case 5:
+			name, tensor, err := parseONNXTensor(field.bytes)
+			if err != nil {
+				return nil, err
+			}
+			graph.initializers[name] = tensor
+		case 11:
+			inputs = append(inputs, field)
+		}
+	}
+	// Older exporters list the initializers as inputs too, so the input of the data is the first
+	// one without an initializer.
+	for _, input := range inputs {
+		name, dims, err := parseONNXValueInfo(input.bytes)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := graph.initializers[name]; !ok {
+			graph.inputName = name
+			graph.inputDims = dims
+			return graph, nil
+		}
+	}
+	return nil, fmt.Errorf("ONNX graph does not have an input")
+}
+
+func parseONNXNode(data []byte) (onnxNode, error) {
+	node := onnxNode{ints: map[string][]int64{}, floats: map[string]float32{}, strings: map[string]string{}}
+	fields, err := parseProto(data)
+	if err != nil {
+		return node, err
+	}
+	for _, field := range fields {
+		switch field.number {
+		case 1:
+			node.inputs = append(node.inputs, string(field.bytes))
+		case 2:
+			node.outputs = append(node.outputs, string(field.bytes))
+		case 4:
+			node.opType = string(field.bytes)
+		case 5:
+			err = node.parseAttribute(field.bytes)
+			if err != nil {
+				return node, err
+			}
+		}
+	}
+	return node, nil
+}
+
+func (node *onnxNode) parseAttribute(data []byte) error {
+	fields, err := parseProto(data)
+	if err != nil {
+		return err
+	}
+	name := ""
+	for _, field := range fields {
+		if field.number == 1 {
+			name = string(field.bytes)
+		}
+	}
+	for _, field := range fields {
+		switch field.number {
+		case 2:
+			node.floats[name] = math.Float32frombits(uint32(field.value))
+		case 3, 8:
+			values, err := protoInts(field)
+			if err != nil {
+				return err
+			}
+			node.ints[name] = append(node.ints[name], values...)
+		case 4:
+			node.strings[name] = string(field.bytes)
+		}
+	}
+	return nil
+}
+
+// intAttribute returns the first value of an integer attribute, or a default value if the node
+// does not have the attribute.
+func (node *onnxNode) intAttribute(name string, defaultValue int64) int64 {
+	if values, ok := node.ints[name]; ok && len(values) > 0 {
+		return values[0]
+	}
+	return defaultValue
+}
+
+func (node *onnxNode) floatAttribute(name string, defaultValue float32) float32 {
+	if value, ok := node.floats[name]; ok {
+		return value
+	}
+	return defaultValue
+}
+
+func parseONNXTensor(data []byte) (string, onnxTensor, error) {
+	fields, err := parseProto(data)
+	if err != nil {
+		return "", onnxTensor{}, err
+	}
+	name := ""
+	tensor := onnxTensor{}
+	dataType := int64(1)
+	var raw []byte
+	for _, field := range fields {
+		switch field.number {
+		case 1:
+			dims, err := protoInts(field)
+			if err != nil {
+				return "", tensor, err
+			}
+			for _, dim := range dims {
+				if dim < 1 {
+					return "", tensor, fmt.Errorf("Dimensions of ONNX tensor must be positive, are: %v", dims)
+				}
+				tensor.dims = append(tensor.dims, int(dim))
+			}
+		case 2:
+			dataType = int64(field.value)
+		case 4:
+			values, err := protoFloats(field)
+			if err != nil {
+				return "", tensor, err
+			}
+			tensor.values = append(tensor.values, values...)
+		case 8:
+			name = string(field.bytes)
+		case 9:
+			raw = field.bytes
+		}
+	}
+	// Only 32 bit floats, which are data type 1, can be loaded into layers.
+	if dataType != 1 {
+		return "", tensor, fmt.Errorf("Unsupported data type of ONNX tensor %s: %d", name, dataType)
+	}
+	if raw != nil {
+		tensor.values, err = protoFloats(protoField{bytes: raw, delimited: true})
+		if err != nil {
+			return "", tensor, err
+		}
+	}
+	// Each dimension is checked against the values left before multiplying, so the size of a
+	// large shape cannot overflow.
+	size := 1
+	for _, dim := range tensor.dims {
+		if dim > len(tensor.values)/size {
+			return "", tensor, fmt.Errorf("Number of values of ONNX tensor %s does not match its shape: %d, %v", name, len(tensor.values), tensor.dims)
+		}
+		size *= dim
+	}
+	if size != len(tensor.values) {
+		return "", tensor, fmt.Errorf("Number of values of ONNX tensor %s does not match its shape: %d != %d", name, len(tensor.values), size)
+	}
+	return name, tensor, nil
+}
+
+// parseONNXValueInfo returns the name and dimensions of a value, where dimensions without a fixed
+// size, such as the batch size, are -1.
+func parseONNXValueInfo(data []byte) (string, []int, error) {
+	fields, err := parseProto(data)
+	if err != nil {
+		return "", nil, err
+	}
+	name := ""
+	dims := []int{}
+	for _, field := range fields {
+		switch field.number {
+		case 1:
+			name = string(field.bytes)
+		case 2:
+			// The shape is in type.tensor_type.shape.
+			tensorType, err := protoMessage(field.bytes, 1)
+			if err != nil {
+				return "", nil, err
+			}
+			shape, err := protoMessage(tensorType, 2)
+			if err != nil {
+				return "", nil, err
+			}
+			dimFields, err := parseProto(shape)
+			if err != nil {
+				return "", nil, err
+			}
+			for _, dimField := range dimFields {
+				if dimField.number != 1 {
+					continue
+				}
+				valueFields, err := parseProto(dimField.bytes)
+				if err != nil {
+					return "", nil, err
+				}
+				dim := -1
+				for _, valueField := range valueFields {
+					if valueField.number == 1 && !valueField.delimited {
+						dim = int(valueField.value)
+					}
+				}
+				dims = append(dims, dim)
+			}
+		}
+	}
+	return name, dims, nil
+}
+
+// protoMessage returns the first nested message with a field number, or nothing if there is none.
+func protoMessage(data []byte, number int) ([]byte, error) {
+	fields, err := parseProto(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		if field.number == number && field.delimited {
+			return field.bytes, nil
+		}
+	}
+	return nil, nil
+}
+
+// layers creates a layer for each operator of the graph, following the shape of the data from the
+// input of the graph.
+func (graph *onnxGraph) layers() ([]Layer, error) {
+	var shape LayerShape
+	dims := graph.inputDims
+	switch {
+	case len(dims) == 2 && dims[1] > 0:
+		shape = LayerShape{1, dims[1], 1}
+	case len(dims) == 4 && dims[1] > 0 && dims[2] > 0 && dims[3] > 0:
+		shape = LayerShape{dims[2], dims[3], dims[1]}
+	default:
+		return nil, fmt.Errorf("ONNX input must have a fixed shape of (batch, features) or (batch, channels, height, width), is: %v", dims)
+	}
+	err := checkLoadedShape(shape)
+	if err != nil {
+		return nil, err
+	}
+	layers := []Layer{}
+	current := graph.inputName
+	for i, node := range graph.nodes {
+		if len(node.inputs) == 0 || node.inputs[0] != current || len(node.outputs) == 0 {
+			return nil, fmt.Errorf("ONNX graph must be a chain of operators, node %d (%s) does not follow the node before it", i, node.opType)
+		}
+		var layer Layer
+		var err error
+		switch node.opType {
+		case "Gemm":
+			layer, err = graph.denseLayer(node, shape)
+		case "Conv":
+			layer, err = graph.convolutionLayer(node, shape)
+		case "MaxPool":
+			layer, err = poolingLayer(node, shape)
+		case "Flatten":
+			if node.intAttribute("axis", 1) != 1 {
+				return nil, fmt.Errorf("Flatten must keep only the batch axis, axis is: %d", node.intAttribute("axis", 1))
+			}
+			layer = NewFlattenLayer(shape.Rows, shape.Cols, shape.Frames)
+		case "Softmax":
+			if shape.Rows != 1 || shape.Frames != 1 {
+				return nil, fmt.Errorf("Softmax inputs must be flat, are: (%d, %d, %d)", shape.Rows, shape.Cols, shape.Frames)
+			}
+			layer = NewSoftmaxLayer(shape.Cols)
+		case "Relu":
+			err = setONNXActivation(layers, ActivationRELU)
+		case "Sigmoid":
+			err = setONNXActivation(layers, ActivationSigmoid)
+		default:
+			return nil, fmt.Errorf("Unsupported ONNX operator: %s", node.opType)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ONNX node %d (%s): %s", i, node.opType, err.Error())
+		}
+		if layer != nil {
+			layers = append(layers, layer)
+			shape = layer.OutputShape()
+		}
+		current = node.outputs[0]
+	}
+	return layers, nil
+}
+
+// weights returns an initializer of the graph, which must have a number of dimensions.
+func (graph *onnxGraph) weights(node onnxNode, index int, numDims int) (onnxTensor, error) {
+	if index >= len(node.inputs) || node.inputs[index] == "" {
+		return onnxTensor{}, fmt.Errorf("Missing input %d", index)
+	}
+	tensor, ok := graph.initializers[node.inputs[index]]
+	if !ok {
+		return onnxTensor{}, fmt.Errorf("Input %d must be an initializer: %s", index, node.inputs[index])
+	}
+	if len(tensor.dims) != numDims {
+		return onnxTensor{}, fmt.Errorf("Input %d must have %d dimensions, has: %d", index, numDims, len(tensor.dims))
+	}
+	return tensor, nil
+}
+
+// bias returns the values of an optional bias input of a node, or 0 for each output if the node
+// does not have one.
+func (graph *onnxGraph) bias(node onnxNode, index int, size int) ([]float32, error) {
+	if index >= len(node.inputs) || node.inputs[index] == "" {
+		return make([]float32, size), nil
+	}
+	tensor, ok := graph.initializers[node.inputs[index]]
+	if !ok {
+		return nil, fmt.Errorf("Input %d must be an initializer: %s", index, node.inputs[index])
+	}
+	if len(tensor.values) != size {
+		return nil, fmt.Errorf("Bias must have a value for each of %d outputs, has: %d", size, len(tensor.values))
+	}
+	return tensor.values, nil
+}
+
+// denseLayer creates a dense layer from a Gemm operator, which computes alpha * A * B + beta * C,
+// where B is stored as (outputs, inputs) when transB is set, as it is by PyTorch.
+func (graph *onnxGraph) denseLayer(node onnxNode, shape LayerShape) (Layer, error) {
+	if node.intAttribute("transA", 0) != 0 {
+		return nil, fmt.Errorf("Transposed inputs are not supported")
+	}
+	if shape.Rows != 1 || shape.Frames != 1 {
+		return nil, fmt.Errorf("Inputs must be flat, are: (%d, %d, %d)", shape.Rows, shape.Cols, shape.Frames)
+	}
+	weights, err := graph.weights(node, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+	transposed := node.intAttribute("transB", 0) != 0
+	inputSize, outputSize := weights.dims[0], weights.dims[1]
+	if transposed {
+		inputSize, outputSize = outputSize, inputSize
+	}
+	if inputSize != shape.Cols {
+		return nil, fmt.Errorf("Weights must have %d inputs, have: %d", shape.Cols, inputSize)
+	}
+	bias, err := graph.bias(node, 2, outputSize)
+	if err != nil {
+		return nil, err
+	}
+	alpha := node.floatAttribute("alpha", 1)
+	beta := node.floatAttribute("beta", 1)
+	layer := NewDenseLayer(inputSize, outputSize, ActivationLinear)
+	for row := 0; row < inputSize; row++ {
+		for col := 0; col < outputSize; col++ {
+			index := row*outputSize + col
+			if transposed {
+				index = col*inputSize + row
+			}
+			layer.Weights.Set(0, row, col, alpha*weights.values[index])
+		}
+	}
+	for col, value := range bias {
+		layer.Bias.Set(0, 0, col, beta*value)
+	}
+	return layer, nil
+}
+
+// convolutionLayer creates a convolution layer from a Conv operator with weights stored as
+// (filters, channels, height, width).
+func (graph *onnxGraph) convolutionLayer(node onnxNode, shape LayerShape) (Layer, error) {
+	weights, err := graph.weights(node, 1, 4)
+	if err != nil {
+		return nil, err
+	}
+	numFilters, channels, kernelRows, kernelCols := weights.dims[0], weights.dims[1], weights.dims[2], weights.dims[3]
+	if channels != 1 || shape.Frames != 1 || node.intAttribute("group", 1) != 1 {
+		return nil, fmt.Errorf("Only a single input channel is supported, has: %d", shape.Frames)
+	}
+	if kernelRows != kernelCols || kernelRows%2 == 0 {
+		return nil, fmt.Errorf("Kernel must be square with an odd size, is: (%d, %d)", kernelRows, kernelCols)
+	}
+	for _, name := range []string{"strides", "dilations"} {
+		for _, value := range node.ints[name] {
+			if value != 1 {
+				return nil, fmt.Errorf("Only %s of 1 are supported, are: %v", name, node.ints[name])
+			}
+		}
+	}
+	autoPad := node.strings["auto_pad"]
+	if autoPad != "SAME_UPPER" && autoPad != "SAME_LOWER" {
+		pads := node.ints["pads"]
+		if len(pads) == 0 {
+			pads = []int64{0, 0, 0, 0}
+		}
+		for _, pad := range pads {
+			if pad != int64(kernelRows/2) {
+				return nil, fmt.Errorf("Padding must keep the size of the inputs, is: %v", pads)
+			}
+		}
+	}
+	bias, err := graph.bias(node, 2, numFilters)
+	if err != nil {
+		return nil, err
+	}
+	filters := make([]*tsr.Tensor, numFilters)
+	size := kernelRows * kernelCols
+	for i := range filters {
+		filters[i] = tsr.NewEmptyTensor2D(kernelRows, kernelCols)
+		for row := 0; row < kernelRows; row++ {
+			for col := 0; col < kernelCols; col++ {
+				filters[i].Set(0, row, col, weights.values[i*size+row*kernelCols+col])
+			}
+		}
+	}
+	layer, err := NewConvolutionLayer(shape.Rows, shape.Cols, shape.Frames, filters, ActivationLinear)
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range bias {
+		layer.Bias.Set(0, 0, i, value)
+	}
+	return layer, nil
+}
+
+// poolingLayer creates a max pooling layer from a MaxPool operator with a square kernel that moves
+// by its own size without padding.
+func poolingLayer(node onnxNode, shape LayerShape) (Layer, error) {
+	kernel := node.ints["kernel_shape"]
+	if len(kernel) != 2 || kernel[0] != kernel[1] {
+		return nil, fmt.Errorf("Kernel must be square, is: %v", kernel)
+	}
+	if kernel[0] < 1 || kernel[0] > int64(shape.Rows) || kernel[0] > int64(shape.Cols) {
+		return nil, fmt.Errorf("Kernel must be between 1 and the size of the inputs, is: %v", kernel)
+	}
+	strides := node.ints["strides"]
+	if len(strides) != 2 || strides[0] != kernel[0] || strides[1] != kernel[0] {
+		return nil, fmt.Errorf("Strides must match the kernel size %d, are: %v", kernel[0], strides)
+	}
+	for _, pad := range node.ints["pads"] {
+		if pad != 0 {
+			return nil, fmt.Errorf("Padding is not supported, is: %v", node.ints["pads"])
+		}
+	}
+	if node.intAttribute("ceil_mode", 0) != 0 {
+		return nil, fmt.Errorf("Ceil mode is not supported")
+	}
+	return NewPoolingLayer(shape.Rows, shape.Cols, shape.Frames, int(kernel[0]), PoolingMax), nil
+}
+
+// setONNXActivation sets the activation of the last dense or convolution layer. Max pooling and
+// flattening give the same results before or after a Relu or Sigmoid, since both only move values
+// around or keep the largest value, so the activation may come after them.
+func setONNXActivation(layers []Layer, activation ActivationFunction) error {
+	for i := len(layers) - 1; i >= 0; i-- {
+		switch layer := layers[i].(type) {
+		case *DenseLayer:
+			if layer.Activation.Type != ActivationTypeLinear {
+				return fmt.Errorf("Layer already has an activation: %s", layer.Activation.Type)
+			}
+			layer.Activation = activation
+			return nil
+		case *ConvolutionLayer:
+			if layer.Activation.Type != ActivationTypeLinear {
+				return fmt.Errorf("Layer already has an activation: %s", layer.Activation.Type)
+			}
+			layer.Activation = activation
+			return nil
+		case *PoolingLayer:
+			if layer.Pooling.Method != PoolingMethodMax {
+				return fmt.Errorf("Activation must follow a Gemm or Conv operator")
+			}
+		case *FlattenLayer:
+		default:
+			return fmt.Errorf("Activation must follow a Gemm or Conv operator")
+		}
+	}
+	return fmt.Errorf("Activation must follow a Gemm or Conv operator")
+}
@@ -0,0 +1,217 @@
+package nn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func onnxTensorProto(name string, dims []int64, values []float32) []byte {
+	raw := make([]byte, len(values)*4)
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(raw[i*4:], math.Float32bits(value))
+	}
+	message := []byte{}
+	for _, dim := range dims {
+		message = append(message, protoVarint(1, dim)...)
+	}
+	return protoConcat(message, protoVarint(2, 1), protoBytes(8, []byte(name)), protoBytes(9, raw))
+}
+
+func onnxInts(name string, values ...int64) []byte {
+	attribute := protoBytes(1, []byte(name))
+	for _, value := range values {
+		attribute = append(attribute, protoVarint(8, value)...)
+	}
+	return attribute
+}
+
+func onnxFloat(name string, value float32) []byte {
+	bits := make([]byte, 4)
+	binary.LittleEndian.PutUint32(bits, math.Float32bits(value))
+	return protoConcat(protoBytes(1, []byte(name)), protoKey(2, 5), bits)
+}
+
+func onnxNodeProto(opType string, input string, output string, weights []string, attributes ...[]byte) []byte {
+	node := protoBytes(1, []byte(input))
+	for _, name := range weights {
+		node = append(node, protoBytes(1, []byte(name))...)
+	}
+	node = append(node, protoBytes(2, []byte(output))...)
+	node = append(node, protoBytes(4, []byte(opType))...)
+	for _, attribute := range attributes {
+		node = append(node, protoBytes(5, attribute)...)
+	}
+	return node
+}
+
+func onnxModelProto(inputDims []int64, nodes [][]byte, initializers [][]byte) []byte {
+	shape := []byte{}
+	for i, dim := range inputDims {
+		if i == 0 {
+			shape = append(shape, protoBytes(1, protoBytes(2, []byte("batch")))...)
+			continue
+		}
+		shape = append(shape, protoBytes(1, protoVarint(1, dim))...)
+	}
+	tensorType := protoConcat(protoVarint(1, 1), protoBytes(2, shape))
+	input := protoConcat(protoBytes(1, []byte("input")), protoBytes(2, protoBytes(1, tensorType)))
+	graph := []byte{}
+	for _, node := range nodes {
+		graph = append(graph, protoBytes(1, node)...)
+	}
+	for _, initializer := range initializers {
+		graph = append(graph, protoBytes(5, initializer)...)
+	}
+	graph = append(graph, protoBytes(11, input)...)
+	return protoConcat(protoVarint(1, 7), protoBytes(7, graph))
+}
+
+func onnxDenseModel() []byte {
+	return onnxModelProto(
+		[]int64{-1, 2},
+		[][]byte{
+			onnxNodeProto("Gemm", "input", "h", []string{"w1", "b1"}, protoConcat(onnxInts("transB"), protoVarint(3, 1))),
+			onnxNodeProto("Relu", "h", "r", nil),
+			onnxNodeProto("Gemm", "r", "s", []string{"w2"}, onnxFloat("alpha", 2)),
+			onnxNodeProto("Softmax", "s", "output", nil),
+		},
+		[][]byte{
+			// PyTorch stores the weights of a linear layer as (outputs, inputs).
+			onnxTensorProto("w1", []int64{3, 2}, []float32{1, 0, 0, 1, 1, -1}),
+			onnxTensorProto("b1", []int64{3}, []float32{0, 1, -5}),
+			onnxTensorProto("w2", []int64{3, 2}, []float32{1, 0, 0, 1, 1, 1}),
+		},
+	)
+}
+
+func onnxConvolutionModel() []byte {
+	filters := []float32{
+		0, 0, 0, 0, 1, 0, 0, 0, 0,
+		1, 1, 1, 1, 1, 1, 1, 1, 1,
+	}
+	dense := make([]float32, 16)
+	for i := range dense {
+		dense[i] = float32(i%3) - 1
+	}
+	return onnxModelProto(
+		[]int64{1, 1, 4, 4},
+		[][]byte{
+			onnxNodeProto("Conv", "input", "c", []string{"w", "b"}, onnxInts("pads", 1, 1, 1, 1), onnxInts("kernel_shape", 3, 3)),
+			onnxNodeProto("MaxPool", "c", "p", nil, onnxInts("kernel_shape", 2, 2), onnxInts("strides", 2, 2)),
+			onnxNodeProto("Relu", "p", "r", nil),
+			onnxNodeProto("Flatten", "r", "f", nil),
+			onnxNodeProto("Gemm", "f", "output", []string{"d"}),
+		},
+		[][]byte{
+			onnxTensorProto("w", []int64{2, 1, 3, 3}, filters),
+			onnxTensorProto("b", []int64{2}, []float32{-0.5, 0.25}),
+			onnxTensorProto("d", []int64{8, 2}, dense),
+		},
+	)
+}
+
+func TestNeuralNetworkLoadONNXDense(t *testing.T) {
+	err := ioutil.WriteFile("model.onnx", onnxDenseModel(), 0644)
+	if err != nil {
+		t.Fatalf("Error writing test file: %s", err.Error())
+	}
+	defer os.Remove("model.onnx")
+
+	neuralNetwork := NewNeuralNetwork()
+	err = neuralNetwork.LoadFromFileONNX("model.onnx")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFileONNX: %s", err.Error())
+	}
+	if neuralNetwork.LayerCount() != 3 {
+		t.Fatalf("Neural network should have 3 layers, has: %d", neuralNetwork.LayerCount())
+	}
+	if neuralNetwork.LayerAt(0).(*DenseLayer).Activation.Type != ActivationTypeRELU {
+		t.Errorf("Relu should become the activation of the first dense layer")
+	}
+
+	// The hidden values are relu(3, 3, -4) = (3, 3, 0), so the scores are 2 * (3, 3).
+	outputs, err := neuralNetwork.Predict([][][]float32{{{3, 2}}})
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	if !tsr.NewValueTensor3D(outputs).Equals(tsr.NewValueTensor1D([]float32{0.5, 0.5})) {
+		t.Errorf("Outputs should be: [0.5 0.5], are: %v", outputs[0][0])
+	}
+}
+
+func TestNeuralNetworkLoadONNXConvolution(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	err := neuralNetwork.readONNX(bytes.NewReader(onnxConvolutionModel()))
+	if err != nil {
+		t.Fatalf("Error in readONNX: %s", err.Error())
+	}
+
+	identity := tsr.NewValueTensor2D([][]float32{{0, 0, 0}, {0, 1, 0}, {0, 0, 0}})
+	sum := tsr.NewValueTensor2D([][]float32{{1, 1, 1}, {1, 1, 1}, {1, 1, 1}})
+	convolutionLayer, err := NewConvolutionLayer(4, 4, 1, []*tsr.Tensor{identity, sum}, ActivationRELU)
+	if err != nil {
+		t.Fatalf("Error in NewConvolutionLayer: %s", err.Error())
+	}
+	convolutionLayer.Bias = tsr.NewValueTensor1D([]float32{-0.5, 0.25})
+	denseLayer := NewDenseLayer(8, 2, ActivationLinear)
+	denseLayer.Weights = tsr.NewValueTensor2D([][]float32{{-1, 0}, {1, -1}, {0, 1}, {-1, 0}, {1, -1}, {0, 1}, {-1, 0}, {1, -1}})
+	denseLayer.Bias = tsr.NewEmptyTensor1D(2)
+	expected := NewNeuralNetwork()
+	expected.Add(convolutionLayer, NewPoolingLayer(4, 4, 2, 2, PoolingMax), NewFlattenLayer(2, 2, 2), denseLayer)
+
+	inputs := [][][]float32{{{1, 0, 2, 0}, {0, 3, 0, 1}, {1, 1, 0, 0}, {0, 2, 1, 1}}}
+	outputs, err := neuralNetwork.Predict(inputs)
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	solution, _ := expected.Predict(inputs)
+	if !tsr.NewValueTensor3D(outputs).Equals(tsr.NewValueTensor3D(solution)) {
+		t.Errorf("Outputs should be: %v, are: %v", solution[0][0], outputs[0][0])
+	}
+}
+
+func TestNeuralNetworkLoadONNXUnsupported(t *testing.T) {
+	weights := [][]byte{onnxTensorProto("w", []int64{2, 2}, []float32{1, 0, 0, 1})}
+	models := map[string][]byte{
+		"unsupported operator": onnxModelProto([]int64{-1, 2}, [][]byte{onnxNodeProto("Tanh", "input", "output", nil)}, nil),
+		"branching graph": onnxModelProto(
+			[]int64{-1, 2},
+			[][]byte{
+				onnxNodeProto("Gemm", "input", "a", []string{"w"}),
+				onnxNodeProto("Gemm", "input", "b", []string{"w"}),
+			},
+			weights,
+		),
+		"activation without layer": onnxModelProto([]int64{-1, 2}, [][]byte{onnxNodeProto("Relu", "input", "output", nil)}, nil),
+		"mismatched weights":       onnxModelProto([]int64{-1, 3}, [][]byte{onnxNodeProto("Gemm", "input", "output", []string{"w"})}, weights),
+		"missing graph":            protoVarint(1, 7),
+		"overflowing length":       {0x3a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, 0x00, 0x00},
+		"negative dimension": onnxModelProto(
+			[]int64{-1, 2},
+			[][]byte{onnxNodeProto("Gemm", "input", "output", []string{"w"})},
+			[][]byte{onnxTensorProto("w", []int64{-2, -2}, []float32{1, 0, 0, 1})},
+		),
+		"overflowing shape": onnxModelProto(
+			[]int64{-1, 2},
+			[][]byte{onnxNodeProto("Gemm", "input", "output", []string{"w"})},
+			[][]byte{onnxTensorProto("w", []int64{1 << 32, 1 << 32}, nil)},
+		),
+		"empty pooling": onnxModelProto(
+			[]int64{1, 1, 4, 4},
+			[][]byte{onnxNodeProto("MaxPool", "input", "output", nil, onnxInts("kernel_shape", 0, 0), onnxInts("strides", 0, 0))},
+			nil,
+		),
+	}
+	for name, model := range models {
+		err := NewNeuralNetwork().readONNX(bytes.NewReader(model))
+		if err == nil {
+			t.Errorf("Loading an ONNX model with %s did not trigger error", name)
+		}
+	}
+}
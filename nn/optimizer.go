@@ -0,0 +1,279 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	tsr "../tensor"
+)
+
+// Optimizer updates the parameters of a neural network from their gradients. Optimizers keep their
+// own state for each parameter they update, so a single optimizer should be used for each network.
+type Optimizer interface {
+	LearningRate() float32
+	SetLearningRate(learningRate float32)
+	Update(parameter *tsr.Tensor, gradient *tsr.Tensor, learningRateScale float32) error
+}
+
+// statefulOptimizer is an optimizer whose state for each parameter can be saved to a checkpoint and
+// restored. The states are returned by name, and are the maps the optimizer updates.
+type statefulOptimizer interface {
+	tensorStates() map[string]map[*tsr.Tensor]*tsr.Tensor
+	stepStates() map[string]map[*tsr.Tensor]int
+}
+
+// SGDOptimizer is a stochastic gradient descent optimizer with momentum.
+type SGDOptimizer struct {
+	learningRate float32
+	Momentum     float32
+	velocities   map[*tsr.Tensor]*tsr.Tensor
+}
+
+// NewSGDOptimizer creates a new instance of a stochastic gradient descent optimizer.
+func NewSGDOptimizer(learningRate float32, momentum float32) *SGDOptimizer {
+	return &SGDOptimizer{
+		learningRate: learningRate,
+		Momentum:     momentum,
+		velocities:   map[*tsr.Tensor]*tsr.Tensor{},
+	}
+}
+
+// LearningRate returns the learning rate of the optimizer.
+func (optimizer *SGDOptimizer) LearningRate() float32 {
+	return optimizer.learningRate
+}
+
+// SetLearningRate sets the learning rate of the optimizer.
+func (optimizer *SGDOptimizer) SetLearningRate(learningRate float32) {
+	optimizer.learningRate = learningRate
+}
+
+// Update moves the parameter against its gradient, adding on the previous update scaled by the
+// momentum.
+func (optimizer *SGDOptimizer) Update(parameter *tsr.Tensor, gradient *tsr.Tensor, learningRateScale float32) error {
+	err := checkGradient(parameter, gradient)
+	if err != nil {
+		return err
+	}
+	velocity := stateFor(optimizer.velocities, parameter)
+	learningRate := optimizer.learningRate * learningRateScale
+	velocity.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return optimizer.Momentum*current - learningRate*gradient.Get(frame, row, col)
+	})
+	return parameter.AddTensor(velocity)
+}
+
+func (optimizer *SGDOptimizer) tensorStates() map[string]map[*tsr.Tensor]*tsr.Tensor {
+	return map[string]map[*tsr.Tensor]*tsr.Tensor{
+		"velocities": optimizer.velocities,
+	}
+}
+
+func (optimizer *SGDOptimizer) stepStates() map[string]map[*tsr.Tensor]int {
+	return map[string]map[*tsr.Tensor]int{}
+}
+
+// AdamOptimizer is an optimizer that adapts the step size of each value from running averages of
+// its gradients and squared gradients.
+type AdamOptimizer struct {
+	learningRate float32
+	Beta1        float32
+	Beta2        float32
+	Epsilon      float32
+	moments      map[*tsr.Tensor]*tsr.Tensor
+	velocities   map[*tsr.Tensor]*tsr.Tensor
+	steps        map[*tsr.Tensor]int
+}
+
+// NewAdamOptimizer creates a new instance of an Adam optimizer with the usual decay rates.
+func NewAdamOptimizer(learningRate float32) *AdamOptimizer {
+	return &AdamOptimizer{
+		learningRate: learningRate,
+		Beta1:        0.9,
+		Beta2:        0.999,
+		Epsilon:      1e-8,
+		moments:      map[*tsr.Tensor]*tsr.Tensor{},
+		velocities:   map[*tsr.Tensor]*tsr.Tensor{},
+		steps:        map[*tsr.Tensor]int{},
+	}
+}
+
+// LearningRate returns the learning rate of the optimizer.
+func (optimizer *AdamOptimizer) LearningRate() float32 {
+	return optimizer.learningRate
+}
+
+// SetLearningRate sets the learning rate of the optimizer.
+func (optimizer *AdamOptimizer) SetLearningRate(learningRate float32) {
+	optimizer.learningRate = learningRate
+}
+
+// Update moves the parameter by the bias corrected average gradient, divided by the root of the
+// bias corrected average squared gradient.
+func (optimizer *AdamOptimizer) Update(parameter *tsr.Tensor, gradient *tsr.Tensor, learningRateScale float32) error {
+	err := checkGradient(parameter, gradient)
+	if err != nil {
+		return err
+	}
+	moment := stateFor(optimizer.moments, parameter)
+	velocity := stateFor(optimizer.velocities, parameter)
+	optimizer.steps[parameter]++
+	step := float64(optimizer.steps[parameter])
+	momentCorrection := float32(1 - math.Pow(float64(optimizer.Beta1), step))
+	velocityCorrection := float32(1 - math.Pow(float64(optimizer.Beta2), step))
+	learningRate := optimizer.learningRate * learningRateScale
+	parameter.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		value := gradient.Get(frame, row, col)
+		m := optimizer.Beta1*moment.Get(frame, row, col) + (1-optimizer.Beta1)*value
+		v := optimizer.Beta2*velocity.Get(frame, row, col) + (1-optimizer.Beta2)*value*value
+		moment.Set(frame, row, col, m)
+		velocity.Set(frame, row, col, v)
+		correctedVelocity := float64(v / velocityCorrection)
+		return current - learningRate*(m/momentCorrection)/(float32(math.Sqrt(correctedVelocity))+optimizer.Epsilon)
+	})
+	return nil
+}
+
+func (optimizer *AdamOptimizer) tensorStates() map[string]map[*tsr.Tensor]*tsr.Tensor {
+	return map[string]map[*tsr.Tensor]*tsr.Tensor{
+		"moments":    optimizer.moments,
+		"velocities": optimizer.velocities,
+	}
+}
+
+func (optimizer *AdamOptimizer) stepStates() map[string]map[*tsr.Tensor]int {
+	return map[string]map[*tsr.Tensor]int{
+		"steps": optimizer.steps,
+	}
+}
+
+// RMSPropOptimizer is an optimizer that divides the step size of each value by the root of a
+// running average of its squared gradients.
+type RMSPropOptimizer struct {
+	learningRate float32
+	Rho          float32
+	Epsilon      float32
+	velocities   map[*tsr.Tensor]*tsr.Tensor
+}
+
+// NewRMSPropOptimizer creates a new instance of an RMSProp optimizer with the usual decay rate.
+func NewRMSPropOptimizer(learningRate float32) *RMSPropOptimizer {
+	return &RMSPropOptimizer{
+		learningRate: learningRate,
+		Rho:          0.9,
+		Epsilon:      1e-7,
+		velocities:   map[*tsr.Tensor]*tsr.Tensor{},
+	}
+}
+
+// LearningRate returns the learning rate of the optimizer.
+func (optimizer *RMSPropOptimizer) LearningRate() float32 {
+	return optimizer.learningRate
+}
+
+// SetLearningRate sets the learning rate of the optimizer.
+func (optimizer *RMSPropOptimizer) SetLearningRate(learningRate float32) {
+	optimizer.learningRate = learningRate
+}
+
+// Update moves the parameter against its gradient, divided by the root of the average squared
+// gradient.
+func (optimizer *RMSPropOptimizer) Update(parameter *tsr.Tensor, gradient *tsr.Tensor, learningRateScale float32) error {
+	err := checkGradient(parameter, gradient)
+	if err != nil {
+		return err
+	}
+	velocity := stateFor(optimizer.velocities, parameter)
+	learningRate := optimizer.learningRate * learningRateScale
+	parameter.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		value := gradient.Get(frame, row, col)
+		v := optimizer.Rho*velocity.Get(frame, row, col) + (1-optimizer.Rho)*value*value
+		velocity.Set(frame, row, col, v)
+		return current - learningRate*value/(float32(math.Sqrt(float64(v)))+optimizer.Epsilon)
+	})
+	return nil
+}
+
+func (optimizer *RMSPropOptimizer) tensorStates() map[string]map[*tsr.Tensor]*tsr.Tensor {
+	return map[string]map[*tsr.Tensor]*tsr.Tensor{
+		"velocities": optimizer.velocities,
+	}
+}
+
+func (optimizer *RMSPropOptimizer) stepStates() map[string]map[*tsr.Tensor]int {
+	return map[string]map[*tsr.Tensor]int{}
+}
+
+// AdaGradOptimizer is an optimizer that divides the step size of each value by the root of the
+// sum of all its squared gradients, so values that change often take smaller steps.
+type AdaGradOptimizer struct {
+	learningRate float32
+	Epsilon      float32
+	sums         map[*tsr.Tensor]*tsr.Tensor
+}
+
+// NewAdaGradOptimizer creates a new instance of an AdaGrad optimizer.
+func NewAdaGradOptimizer(learningRate float32) *AdaGradOptimizer {
+	return &AdaGradOptimizer{
+		learningRate: learningRate,
+		Epsilon:      1e-7,
+		sums:         map[*tsr.Tensor]*tsr.Tensor{},
+	}
+}
+
+// LearningRate returns the learning rate of the optimizer.
+func (optimizer *AdaGradOptimizer) LearningRate() float32 {
+	return optimizer.learningRate
+}
+
+// SetLearningRate sets the learning rate of the optimizer.
+func (optimizer *AdaGradOptimizer) SetLearningRate(learningRate float32) {
+	optimizer.learningRate = learningRate
+}
+
+// Update moves the parameter against its gradient, divided by the root of the sum of squared
+// gradients.
+func (optimizer *AdaGradOptimizer) Update(parameter *tsr.Tensor, gradient *tsr.Tensor, learningRateScale float32) error {
+	err := checkGradient(parameter, gradient)
+	if err != nil {
+		return err
+	}
+	sum := stateFor(optimizer.sums, parameter)
+	learningRate := optimizer.learningRate * learningRateScale
+	parameter.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		value := gradient.Get(frame, row, col)
+		s := sum.Get(frame, row, col) + value*value
+		sum.Set(frame, row, col, s)
+		return current - learningRate*value/(float32(math.Sqrt(float64(s)))+optimizer.Epsilon)
+	})
+	return nil
+}
+
+func (optimizer *AdaGradOptimizer) tensorStates() map[string]map[*tsr.Tensor]*tsr.Tensor {
+	return map[string]map[*tsr.Tensor]*tsr.Tensor{
+		"sums": optimizer.sums,
+	}
+}
+
+func (optimizer *AdaGradOptimizer) stepStates() map[string]map[*tsr.Tensor]int {
+	return map[string]map[*tsr.Tensor]int{}
+}
+
+func checkGradient(parameter *tsr.Tensor, gradient *tsr.Tensor) error {
+	if parameter.Frames != gradient.Frames || parameter.Rows != gradient.Rows || parameter.Cols != gradient.Cols {
+		return fmt.Errorf(
+			"Dimensions of parameter and gradient must match: (%d, %d, %d) != (%d, %d, %d)",
+			parameter.Frames, parameter.Rows, parameter.Cols, gradient.Frames, gradient.Rows, gradient.Cols,
+		)
+	}
+	return nil
+}
+
+func stateFor(states map[*tsr.Tensor]*tsr.Tensor, parameter *tsr.Tensor) *tsr.Tensor {
+	state, ok := states[parameter]
+	if !ok {
+		state = tsr.NewEmptyTensor3D(parameter.Frames, parameter.Rows, parameter.Cols)
+		states[parameter] = state
+	}
+	return state
+}
@@ -0,0 +1,238 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	tsr "../tensor"
+)
+
+// GradientNoiseOptimizer is an optimizer that adds Gaussian noise to the gradients before a base
+// optimizer uses them, which can help small and noisy datasets. The variance of the noise is Eta
+// divided by (1 + t) raised to Gamma, where t is the number of updates of the parameter, so the
+// noise fades as training goes on.
+type GradientNoiseOptimizer struct {
+	Base  Optimizer
+	Eta   float32
+	Gamma float32
+	steps map[*tsr.Tensor]int
+}
+
+// NewGradientNoiseOptimizer creates an optimizer that adds noise with an initial variance to the
+// gradients of a base optimizer, with the usual decay rate of 0.55.
+func NewGradientNoiseOptimizer(base Optimizer, eta float32) (*GradientNoiseOptimizer, error) {
+	if !(eta >= 0) || math.IsInf(float64(eta), 1) {
+		return nil, fmt.Errorf("Variance of gradient noise must be at least 0, is: %g", eta)
+	}
+	return &GradientNoiseOptimizer{
+		Base:  base,
+		Eta:   eta,
+		Gamma: 0.55,
+		steps: map[*tsr.Tensor]int{},
+	}, nil
+}
+
+// LearningRate returns the learning rate of the base optimizer.
+func (optimizer *GradientNoiseOptimizer) LearningRate() float32 {
+	return optimizer.Base.LearningRate()
+}
+
+// SetLearningRate sets the learning rate of the base optimizer.
+func (optimizer *GradientNoiseOptimizer) SetLearningRate(learningRate float32) {
+	optimizer.Base.SetLearningRate(learningRate)
+}
+
+// Update adds noise to a copy of the gradient and updates the parameter with the base optimizer.
+func (optimizer *GradientNoiseOptimizer) Update(parameter *tsr.Tensor, gradient *tsr.Tensor, learningRateScale float32) error {
+	err := checkGradient(parameter, gradient)
+	if err != nil {
+		return err
+	}
+	optimizer.steps[parameter]++
+	variance := float64(optimizer.Eta) / math.Pow(1+float64(optimizer.steps[parameter]), float64(optimizer.Gamma))
+	standardDeviation := math.Sqrt(variance)
+	noisy := gradient.Copy()
+	noisy.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return current + float32(rand.NormFloat64()*standardDeviation)
+	})
+	return optimizer.Base.Update(parameter, noisy, learningRateScale)
+}
+
+func (optimizer *GradientNoiseOptimizer) tensorStates() map[string]map[*tsr.Tensor]*tsr.Tensor {
+	states := map[string]map[*tsr.Tensor]*tsr.Tensor{}
+	return withBaseTensorStates(optimizer.Base, states)
+}
+
+func (optimizer *GradientNoiseOptimizer) stepStates() map[string]map[*tsr.Tensor]int {
+	states := map[string]map[*tsr.Tensor]int{"steps": optimizer.steps}
+	return withBaseStepStates(optimizer.Base, states)
+}
+
+// LookaheadOptimizer is an optimizer that lets a base optimizer take a number of fast steps, and
+// then moves a set of slow weights a fraction of the way toward the fast weights and starts the
+// fast weights again from there. This makes training less sensitive to the settings of the base
+// optimizer.
+type LookaheadOptimizer struct {
+	Base        Optimizer
+	SyncPeriod  int
+	Alpha       float32
+	slowWeights map[*tsr.Tensor]*tsr.Tensor
+	steps       map[*tsr.Tensor]int
+}
+
+// NewLookaheadOptimizer creates an optimizer that syncs the slow weights after a number of steps of
+// the base optimizer, moving them by a fraction between 0 and 1 toward the fast weights.
+func NewLookaheadOptimizer(base Optimizer, syncPeriod int, alpha float32) (*LookaheadOptimizer, error) {
+	if syncPeriod < 1 {
+		return nil, fmt.Errorf("Sync period of lookahead must be at least 1, is: %d", syncPeriod)
+	}
+	if !(alpha > 0 && alpha <= 1) {
+		return nil, fmt.Errorf("Step size of lookahead must be between 0 and 1, is: %g", alpha)
+	}
+	return &LookaheadOptimizer{
+		Base:        base,
+		SyncPeriod:  syncPeriod,
+		Alpha:       alpha,
+		slowWeights: map[*tsr.Tensor]*tsr.Tensor{},
+		steps:       map[*tsr.Tensor]int{},
+	}, nil
+}
+
+// LearningRate returns the learning rate of the base optimizer.
+func (optimizer *LookaheadOptimizer) LearningRate() float32 {
+	return optimizer.Base.LearningRate()
+}
+
+// SetLearningRate sets the learning rate of the base optimizer.
+func (optimizer *LookaheadOptimizer) SetLearningRate(learningRate float32) {
+	optimizer.Base.SetLearningRate(learningRate)
+}
+
+// Update takes a fast step with the base optimizer, and syncs the slow and fast weights of the
+// parameter after every SyncPeriod steps.
+func (optimizer *LookaheadOptimizer) Update(parameter *tsr.Tensor, gradient *tsr.Tensor, learningRateScale float32) error {
+	err := checkGradient(parameter, gradient)
+	if err != nil {
+		return err
+	}
+	slow, ok := optimizer.slowWeights[parameter]
+	if !ok {
+		slow = parameter.Copy()
+		optimizer.slowWeights[parameter] = slow
+	}
+	err = optimizer.Base.Update(parameter, gradient, learningRateScale)
+	if err != nil {
+		return err
+	}
+	optimizer.steps[parameter]++
+	if optimizer.steps[parameter]%optimizer.SyncPeriod != 0 {
+		return nil
+	}
+	slow.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return current + optimizer.Alpha*(parameter.Get(frame, row, col)-current)
+	})
+	return parameter.SetTensor(slow)
+}
+
+func (optimizer *LookaheadOptimizer) tensorStates() map[string]map[*tsr.Tensor]*tsr.Tensor {
+	states := map[string]map[*tsr.Tensor]*tsr.Tensor{"slowWeights": optimizer.slowWeights}
+	return withBaseTensorStates(optimizer.Base, states)
+}
+
+func (optimizer *LookaheadOptimizer) stepStates() map[string]map[*tsr.Tensor]int {
+	states := map[string]map[*tsr.Tensor]int{"steps": optimizer.steps}
+	return withBaseStepStates(optimizer.Base, states)
+}
+
+// EMAOptimizer is an optimizer that updates parameters with a base optimizer, and keeps an
+// exponential moving average of each parameter after its updates. The averages often predict
+// better than the last parameters, and can be swapped in to evaluate or save a neural network.
+type EMAOptimizer struct {
+	Base     Optimizer
+	Decay    float32
+	averages map[*tsr.Tensor]*tsr.Tensor
+}
+
+// NewEMAOptimizer creates an optimizer that averages the parameters updated by a base optimizer,
+// keeping a fraction between 0 and 1 of the average after each update.
+func NewEMAOptimizer(base Optimizer, decay float32) (*EMAOptimizer, error) {
+	if !(decay >= 0 && decay < 1) {
+		return nil, fmt.Errorf("Decay of moving average must be at least 0 and below 1, is: %g", decay)
+	}
+	return &EMAOptimizer{
+		Base:     base,
+		Decay:    decay,
+		averages: map[*tsr.Tensor]*tsr.Tensor{},
+	}, nil
+}
+
+// LearningRate returns the learning rate of the base optimizer.
+func (optimizer *EMAOptimizer) LearningRate() float32 {
+	return optimizer.Base.LearningRate()
+}
+
+// SetLearningRate sets the learning rate of the base optimizer.
+func (optimizer *EMAOptimizer) SetLearningRate(learningRate float32) {
+	optimizer.Base.SetLearningRate(learningRate)
+}
+
+// Update updates the parameter with the base optimizer, and then moves its average toward it. The
+// average starts from the parameter after its first update.
+func (optimizer *EMAOptimizer) Update(parameter *tsr.Tensor, gradient *tsr.Tensor, learningRateScale float32) error {
+	err := optimizer.Base.Update(parameter, gradient, learningRateScale)
+	if err != nil {
+		return err
+	}
+	average, ok := optimizer.averages[parameter]
+	if !ok {
+		optimizer.averages[parameter] = parameter.Copy()
+		return nil
+	}
+	average.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return optimizer.Decay*current + (1-optimizer.Decay)*parameter.Get(frame, row, col)
+	})
+	return nil
+}
+
+func (optimizer *EMAOptimizer) tensorStates() map[string]map[*tsr.Tensor]*tsr.Tensor {
+	states := map[string]map[*tsr.Tensor]*tsr.Tensor{"averages": optimizer.averages}
+	return withBaseTensorStates(optimizer.Base, states)
+}
+
+func (optimizer *EMAOptimizer) stepStates() map[string]map[*tsr.Tensor]int {
+	states := map[string]map[*tsr.Tensor]int{}
+	return withBaseStepStates(optimizer.Base, states)
+}
+
+// SwapAverages swaps the values of every parameter the optimizer has updated with their averages.
+// Swap them in to evaluate or save the averaged parameters, and swap them back before training on.
+func (optimizer *EMAOptimizer) SwapAverages() {
+	for parameter, average := range optimizer.averages {
+		values := parameter.Copy()
+		parameter.SetTensor(average)
+		average.SetTensor(values)
+	}
+}
+
+// withBaseTensorStates adds the states of a base optimizer to the states of a wrapper, with names
+// that start with "base.".
+func withBaseTensorStates(base Optimizer, states map[string]map[*tsr.Tensor]*tsr.Tensor) map[string]map[*tsr.Tensor]*tsr.Tensor {
+	if stateful, ok := base.(statefulOptimizer); ok {
+		for name, state := range stateful.tensorStates() {
+			states["base."+name] = state
+		}
+	}
+	return states
+}
+
+// withBaseStepStates adds the step counts of a base optimizer to the step counts of a wrapper, with
+// names that start with "base.".
+func withBaseStepStates(base Optimizer, states map[string]map[*tsr.Tensor]int) map[string]map[*tsr.Tensor]int {
+	if stateful, ok := base.(statefulOptimizer); ok {
+		for name, state := range stateful.stepStates() {
+			states["base."+name] = state
+		}
+	}
+	return states
+}
@@ -0,0 +1,113 @@
+package nn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	tsr "../tensor"
+)
+
+// minimizeSquare minimizes (x - 3)^2 starting from x = 0, and returns x after the first step and
+// after the last step.
+func minimizeSquare(t *testing.T, optimizer Optimizer, steps int) (float32, float32) {
+	parameter := tsr.NewValueTensor1D([]float32{0})
+	gradient := tsr.NewEmptyTensor1D(1)
+	var firstStep float32
+	for step := 0; step < steps; step++ {
+		gradient.Set(0, 0, 0, 2*(parameter.Get(0, 0, 0)-3))
+		err := optimizer.Update(parameter, gradient, 1)
+		if err != nil {
+			t.Fatalf("Error in Update: %s", err.Error())
+		}
+		if step == 0 {
+			firstStep = parameter.Get(0, 0, 0)
+		}
+	}
+	return firstStep, parameter.Get(0, 0, 0)
+}
+
+func TestGradientNoiseOptimizer(t *testing.T) {
+	rand.Seed(1)
+	quiet, _ := NewGradientNoiseOptimizer(NewSGDOptimizer(0.1, 0), 0)
+	firstStep, _ := minimizeSquare(t, quiet, 1)
+	if math.Abs(float64(firstStep)-0.6) > 1e-5 {
+		t.Errorf("First step without noise should be 0.6, is: %.4f", firstStep)
+	}
+
+	noisy, _ := NewGradientNoiseOptimizer(NewSGDOptimizer(0.1, 0), 1)
+	firstStep, last := minimizeSquare(t, noisy, 1000)
+	if math.Abs(float64(firstStep)-0.6) < 1e-5 {
+		t.Errorf("First step with noise should not be exactly 0.6")
+	}
+	if math.Abs(float64(last)-3) > 0.1 {
+		t.Errorf("Optimizer with fading noise should converge to 3, is: %.4f", last)
+	}
+	if noisy.LearningRate() != 0.1 {
+		t.Errorf("Learning rate should be the learning rate of the base optimizer, is: %f", noisy.LearningRate())
+	}
+
+	_, err := NewGradientNoiseOptimizer(NewSGDOptimizer(0.1, 0), -1)
+	if err == nil {
+		t.Errorf("Negative noise variance did not trigger error")
+	}
+}
+
+func TestLookaheadOptimizer(t *testing.T) {
+	// The fast step goes from 0 to 0.6, and the slow weights move halfway there.
+	optimizer, err := NewLookaheadOptimizer(NewSGDOptimizer(0.1, 0), 1, 0.5)
+	if err != nil {
+		t.Fatalf("Error in NewLookaheadOptimizer: %s", err.Error())
+	}
+	firstStep, last := minimizeSquare(t, optimizer, 200)
+	if math.Abs(float64(firstStep)-0.3) > 1e-5 {
+		t.Errorf("First step should be 0.3, is: %.4f", firstStep)
+	}
+	if math.Abs(float64(last)-3) > 0.01 {
+		t.Errorf("Lookahead should converge to 3, is: %.4f", last)
+	}
+
+	// Between syncs the base optimizer takes its steps as usual.
+	optimizer, _ = NewLookaheadOptimizer(NewSGDOptimizer(0.1, 0), 5, 0.5)
+	firstStep, _ = minimizeSquare(t, optimizer, 1)
+	if math.Abs(float64(firstStep)-0.6) > 1e-5 {
+		t.Errorf("First step before a sync should be 0.6, is: %.4f", firstStep)
+	}
+
+	_, err = NewLookaheadOptimizer(NewSGDOptimizer(0.1, 0), 0, 0.5)
+	if err == nil {
+		t.Errorf("Sync period of 0 did not trigger error")
+	}
+	_, err = NewLookaheadOptimizer(NewSGDOptimizer(0.1, 0), 5, 1.5)
+	if err == nil {
+		t.Errorf("Step size above 1 did not trigger error")
+	}
+}
+
+func TestEMAOptimizer(t *testing.T) {
+	optimizer, err := NewEMAOptimizer(NewSGDOptimizer(0.1, 0), 0.5)
+	if err != nil {
+		t.Fatalf("Error in NewEMAOptimizer: %s", err.Error())
+	}
+	parameter := tsr.NewValueTensor1D([]float32{0})
+	// The parameter steps to 1 and then 3, so the average is 1 and then 0.5 * 1 + 0.5 * 3 = 2.
+	for _, gradient := range []float32{-10, -20} {
+		err = optimizer.Update(parameter, tsr.NewValueTensor1D([]float32{gradient}), 1)
+		if err != nil {
+			t.Fatalf("Error in Update: %s", err.Error())
+		}
+	}
+	optimizer.SwapAverages()
+	if math.Abs(float64(parameter.Get(0, 0, 0))-2) > 1e-5 {
+		t.Errorf("Swapped in average should be 2, is: %.4f", parameter.Get(0, 0, 0))
+	}
+	optimizer.SwapAverages()
+	if math.Abs(float64(parameter.Get(0, 0, 0))-3) > 1e-5 {
+		t.Errorf("Swapped back parameter should be 3, is: %.4f", parameter.Get(0, 0, 0))
+	}
+
+	_, err = NewEMAOptimizer(NewSGDOptimizer(0.1, 0), 1)
+	if err == nil {
+		t.Errorf("Decay of 1 did not trigger error")
+	}
+}
@@ -0,0 +1,62 @@
+package nn
+
+import (
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestOptimizers(t *testing.T) {
+	optimizerTests := []struct {
+		name      string
+		optimizer Optimizer
+		firstStep float64
+	}{
+		{"SGD", NewSGDOptimizer(0.1, 0.5), 0.6},
+		{"Adam", NewAdamOptimizer(0.1), 0.1},
+		{"RMSProp", NewRMSPropOptimizer(0.1), 0.1 / math.Sqrt(0.1)},
+		{"AdaGrad", NewAdaGradOptimizer(0.5), 0.5},
+	}
+
+	for _, test := range optimizerTests {
+		// Minimize (x - 3)^2 starting from x = 0.
+		parameter := tsr.NewValueTensor1D([]float32{0})
+		gradient := tsr.NewEmptyTensor1D(1)
+		for step := 0; step < 1000; step++ {
+			gradient.Set(0, 0, 0, 2*(parameter.Get(0, 0, 0)-3))
+			err := test.optimizer.Update(parameter, gradient, 1)
+			if err != nil {
+				t.Fatalf("Error in %s Update: %s", test.name, err.Error())
+			}
+			if step == 0 && math.Abs(float64(parameter.Get(0, 0, 0))-test.firstStep) > 1e-4 {
+				t.Errorf("First step of %s should be %.4f, is: %.4f", test.name, test.firstStep, parameter.Get(0, 0, 0))
+			}
+		}
+		if math.Abs(float64(parameter.Get(0, 0, 0))-3) > 0.05 {
+			t.Errorf("%s should converge to 3, is: %.4f", test.name, parameter.Get(0, 0, 0))
+		}
+
+		err := test.optimizer.Update(parameter, tsr.NewEmptyTensor1D(2), 1)
+		if err == nil {
+			t.Errorf("%s update with invalid gradient did not trigger error", test.name)
+		}
+	}
+}
+
+func TestOptimizerLearningRate(t *testing.T) {
+	optimizer := NewAdamOptimizer(0.01)
+	optimizer.SetLearningRate(0.5)
+	if optimizer.LearningRate() != 0.5 {
+		t.Errorf("Learning rate should be 0.5, is: %.3f", optimizer.LearningRate())
+	}
+
+	parameter := tsr.NewValueTensor1D([]float32{1})
+	err := NewSGDOptimizer(1, 0).Update(parameter, tsr.NewValueTensor1D([]float32{1}), 0.25)
+	if err != nil {
+		t.Fatalf("Error in Update: %s", err.Error())
+	}
+	if parameter.Get(0, 0, 0) != 0.75 {
+		t.Errorf("Scaled update should move parameter to 0.75, is: %.3f", parameter.Get(0, 0, 0))
+	}
+}
@@ -2,6 +2,7 @@ package nn
 
 import (
 	"encoding/json"
+	"fmt"
 
 	tsr "../tensor"
 )
@@ -49,7 +50,10 @@ func (layer *PoolingLayer) OutputShape() LayerShape {
 
 // FeedForward reduces the input data by the pool size.
 func (layer *PoolingLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error) {
-	layer.inputs.SetTensor(inputs)
+	err := layer.inputs.SetTensor(inputs)
+	if err != nil {
+		return nil, err
+	}
 	for frame := 0; frame < layer.outputs.Frames; frame++ {
 		for row := 0; row < layer.outputs.Rows; row++ {
 			poolRow := row * layer.PoolSize
@@ -63,9 +67,38 @@ func (layer *PoolingLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error)
 	return layer.outputs, nil
 }
 
-// BackPropagate does not operate on the data in a pooling layer.
-func (layer *PoolingLayer) BackPropagate(outputs *tsr.Tensor, learningRate float32, momentum float32) (*tsr.Tensor, error) {
-	return layer.inputs, nil
+// BackPropagate passes the gradient of each pooled value back to its pool. Max pooling gives the
+// whole gradient to the largest input of the pool, and average pooling shares it evenly. Inputs
+// left out of every pool get a gradient of 0.
+func (layer *PoolingLayer) BackPropagate(outputs *tsr.Tensor) (*tsr.Tensor, error) {
+	if outputs.Frames != layer.outputs.Frames || outputs.Rows != layer.outputs.Rows || outputs.Cols != layer.outputs.Cols {
+		return nil, fmt.Errorf(
+			"Invalid gradient dimensions: (%d, %d, %d) != (%d, %d, %d)",
+			outputs.Frames, outputs.Rows, outputs.Cols, layer.outputs.Frames, layer.outputs.Rows, layer.outputs.Cols,
+		)
+	}
+	nextDeltas := tsr.NewEmptyTensor3D(layer.inputs.Frames, layer.inputs.Rows, layer.inputs.Cols)
+	for frame := 0; frame < outputs.Frames; frame++ {
+		for row := 0; row < outputs.Rows; row++ {
+			poolRow := row * layer.PoolSize
+			for col := 0; col < outputs.Cols; col++ {
+				poolCol := col * layer.PoolSize
+				delta := outputs.Get(frame, row, col)
+				layer.Pooling.RouteGradient(layer.inputs, nextDeltas, delta, frame, poolRow, poolCol, layer.PoolSize)
+			}
+		}
+	}
+	return nextDeltas, nil
+}
+
+// Parameters returns an empty list, since the layer has no parameters.
+func (layer *PoolingLayer) Parameters() []*tsr.Tensor {
+	return []*tsr.Tensor{}
+}
+
+// Gradients returns an empty list, since the layer has no parameters.
+func (layer *PoolingLayer) Gradients() []*tsr.Tensor {
+	return []*tsr.Tensor{}
 }
 
 // PoolingLayerData represents a serialized layer that can be saved to a file.
@@ -98,9 +131,19 @@ func (layer *PoolingLayer) UnmarshalJSON(b []byte) error {
 	if err != nil {
 		return err
 	}
-	layer.inputs = tsr.NewEmptyTensor3D(data.InputFrames, data.InputRows, data.InputCols)
+	if data.PoolSize < 1 {
+		return fmt.Errorf("Pool size must be at least 1, is: %d", data.PoolSize)
+	}
 	outputRows := data.InputRows / data.PoolSize
 	outputCols := data.InputCols / data.PoolSize
+	err = checkLoadedShape(
+		LayerShape{data.InputRows, data.InputCols, data.InputFrames},
+		LayerShape{outputRows, outputCols, data.InputFrames},
+	)
+	if err != nil {
+		return err
+	}
+	layer.inputs = tsr.NewEmptyTensor3D(data.InputFrames, data.InputRows, data.InputCols)
 	layer.outputs = tsr.NewEmptyTensor3D(data.InputFrames, outputRows, outputCols)
 	layer.PoolSize = data.PoolSize
 	layer.Pooling = poolingFunctionOfMethod(data.Pooling)
@@ -33,7 +33,18 @@ func TestPoolingLayer(t *testing.T) {
 		t.Errorf("Matrix after feed forward should be:\n%swhen result is:\n%s", solution.String(), pooled.String())
 	}
 
-	unpooled, err := layer.BackPropagate(pooled, 0.0, 0.0)
+	deltas := tsr.NewValueTensor2D([][]float32{
+		{1, 2},
+		{3, 4},
+	})
+	deltaSolution := tsr.NewValueTensor2D([][]float32{
+		{1, 0, 0, 0},
+		{0, 0, 2, 0},
+		{0, 3, 0, 0},
+		{0, 0, 0, 4},
+	})
+
+	unpooled, err := layer.BackPropagate(deltas)
 	if err != nil {
 		t.Fatalf("Error in BackPropagate: %s", err.Error())
 	}
@@ -41,7 +52,55 @@ func TestPoolingLayer(t *testing.T) {
 		t.Fatalf("Unpooled outputs have incorrect frame length: %d != %d", unpooled.Frames, inputs.Frames)
 	}
 
-	if !unpooled.Equals(inputs) {
-		t.Errorf("Matrix after back propagate should be:\n%swhen result is:\n%s", inputs.String(), unpooled.String())
+	if !unpooled.Equals(deltaSolution) {
+		t.Errorf("Matrix after back propagate should be:\n%swhen result is:\n%s", deltaSolution.String(), unpooled.String())
+	}
+
+	_, err = layer.BackPropagate(inputs)
+	if err == nil {
+		t.Errorf("Gradient with incorrect shape did not trigger error")
+	}
+}
+
+func TestPoolingLayerAverage(t *testing.T) {
+	layer := NewPoolingLayer(4, 4, 1, 2, PoolingAvg)
+
+	inputs := tsr.NewValueTensor2D([][]float32{
+		{4, 2, 6, 5},
+		{1, 3, 8, 7},
+		{6, 9, 3, 4},
+		{8, 7, 2, 5},
+	})
+
+	solution := tsr.NewValueTensor2D([][]float32{
+		{2.5, 6.5},
+		{7.5, 3.5},
+	})
+
+	pooled, err := layer.FeedForward(inputs)
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+	if !pooled.Equals(solution) {
+		t.Errorf("Matrix after feed forward should be:\n%swhen result is:\n%s", solution.String(), pooled.String())
+	}
+
+	deltas := tsr.NewValueTensor2D([][]float32{
+		{4, 8},
+		{12, 16},
+	})
+	deltaSolution := tsr.NewValueTensor2D([][]float32{
+		{1, 1, 2, 2},
+		{1, 1, 2, 2},
+		{3, 3, 4, 4},
+		{3, 3, 4, 4},
+	})
+
+	unpooled, err := layer.BackPropagate(deltas)
+	if err != nil {
+		t.Fatalf("Error in BackPropagate: %s", err.Error())
+	}
+	if !unpooled.Equals(deltaSolution) {
+		t.Errorf("Matrix after back propagate should be:\n%swhen result is:\n%s", deltaSolution.String(), unpooled.String())
 	}
 }
@@ -2,10 +2,12 @@ package nn
 
 import tsr "../tensor"
 
-// PoolingFunction represents a function used to find a pooled value.
+// PoolingFunction represents a function used to find a pooled value, and to pass the gradient of
+// a pooled value back to the values in its pool.
 type PoolingFunction struct {
 	Method          PoolingMethod
 	FindPooledValue func(*tsr.Tensor, int, int, int, int) float32
+	RouteGradient   func(*tsr.Tensor, *tsr.Tensor, float32, int, int, int, int)
 }
 
 // PoolingMethod is the identifying type of the pooling function.
@@ -37,6 +39,17 @@ var PoolingMax = PoolingFunction{
 		}
 		return max
 	},
+	RouteGradient: func(matrix *tsr.Tensor, gradient *tsr.Tensor, delta float32, frame int, row int, col int, poolSize int) {
+		maxRow, maxCol := row, col
+		for or := 0; or < poolSize; or++ {
+			for oc := 0; oc < poolSize; oc++ {
+				if matrix.Get(frame, row+or, col+oc) > matrix.Get(frame, maxRow, maxCol) {
+					maxRow, maxCol = row+or, col+oc
+				}
+			}
+		}
+		gradient.Set(frame, maxRow, maxCol, gradient.Get(frame, maxRow, maxCol)+delta)
+	},
 }
 
 // PoolingAvg finds the average value of the pool.
@@ -51,6 +64,14 @@ var PoolingAvg = PoolingFunction{
 		}
 		return total / float32(poolSize*poolSize)
 	},
+	RouteGradient: func(matrix *tsr.Tensor, gradient *tsr.Tensor, delta float32, frame int, row int, col int, poolSize int) {
+		share := delta / float32(poolSize*poolSize)
+		for or := 0; or < poolSize; or++ {
+			for oc := 0; oc < poolSize; oc++ {
+				gradient.Set(frame, row+or, col+oc, gradient.Get(frame, row+or, col+oc)+share)
+			}
+		}
+	},
 }
 
 func poolingFunctionOfMethod(poolingMethod PoolingMethod) PoolingFunction {
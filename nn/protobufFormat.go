@@ -0,0 +1,328 @@
+package nn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+
+	tsr "../tensor"
+)
+
+// The protocol buffer format saves a neural network as a Model message of this schema, so it can be
+// read by any language with a protocol buffer library and sent over services that use them:
+//
+//	message Tensor {
+//	  int32 frames = 1;
+//	  int32 rows = 2;
+//	  int32 cols = 3;
+//	  repeated float values = 4 [packed = true];
+//	}
+//
+//	message LayerShape {
+//	  int32 rows = 1;
+//	  int32 cols = 2;
+//	  int32 frames = 3;
+//	}
+//
+//	message LayerConfig {
+//	  string type = 1;
+//	  LayerShape input_shape = 2;
+//	  LayerShape output_shape = 3;
+//	  bytes options = 4;
+//	  repeated Tensor parameters = 5;
+//	}
+//
+//	message Model {
+//	  int32 format_version = 1;
+//	  bytes metadata = 2;
+//	  repeated LayerConfig layers = 3;
+//	}
+//
+// The options of a layer are the JSON of the layer with its parameters set to 0, and the metadata is
+// the JSON of the metadata of the neural network, if it has any.
+
+// SaveToFileProto saves a neural network to a file as a Model message in the protocol buffer format.
+func (neuralNetwork *NeuralNetwork) SaveToFileProto(fileName string) error {
+	data, err := neuralNetwork.MarshalProto()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, data, 0644)
+}
+
+// LoadFromFileProto adds the layers of a neural network saved in the protocol buffer format to the
+// neural network and sets its metadata.
+func (neuralNetwork *NeuralNetwork) LoadFromFileProto(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	return neuralNetwork.UnmarshalProto(data)
+}
+
+// MarshalProto converts the layers and metadata of the neural network to a Model message in the
+// protocol buffer format.
+func (neuralNetwork *NeuralNetwork) MarshalProto() ([]byte, error) {
+	model := protoVarint(1, FormatVersion)
+	if neuralNetwork.metadata != nil {
+		metadata, err := json.Marshal(neuralNetwork.metadata)
+		if err != nil {
+			return nil, err
+		}
+		model = append(model, protoBytes(2, metadata)...)
+	}
+	for _, layer := range neuralNetwork.layers {
+		config, err := marshalLayerProto(layer)
+		if err != nil {
+			return nil, err
+		}
+		model = append(model, protoBytes(3, config)...)
+	}
+	return model, nil
+}
+
+// UnmarshalProto adds the layers of a Model message in the protocol buffer format to the neural
+// network and sets its metadata. The version of the format and the shapes and parameters of each
+// layer are checked.
+func (neuralNetwork *NeuralNetwork) UnmarshalProto(data []byte) error {
+	fields, err := parseProto(data)
+	if err != nil {
+		return err
+	}
+	layers := []Layer{}
+	var metadata *Metadata
+	for _, field := range fields {
+		switch {
+		case field.number == 1 && !field.delimited:
+			if int64(field.value) > FormatVersion {
+				return fmt.Errorf(
+					"Neural network was saved with format version %d, which is newer than the supported version %d",
+					int64(field.value), FormatVersion,
+				)
+			}
+		case field.number == 2 && field.delimited:
+			metadata = &Metadata{}
+			err = json.Unmarshal(field.bytes, metadata)
+			if err != nil {
+				return err
+			}
+		case field.number == 3 && field.delimited:
+			layer, err := unmarshalLayerProto(field.bytes)
+			if err != nil {
+				return fmt.Errorf("Invalid layer %d: %s", len(layers), err.Error())
+			}
+			layers = append(layers, layer)
+		}
+	}
+	neuralNetwork.metadata = metadata
+	for i, layer := range layers {
+		err = neuralNetwork.Add(layer)
+		if err != nil {
+			return fmt.Errorf("Invalid layer %d: %s", i, err.Error())
+		}
+	}
+	return nil
+}
+
+func marshalLayerProto(layer Layer) ([]byte, error) {
+	// The parameters are written as tensors, so they are left out of the JSON of the layer.
+	skeleton := layer.Copy()
+	for _, parameter := range skeleton.Parameters() {
+		parameter.Scale(0)
+	}
+	options, err := json.Marshal(skeleton)
+	if err != nil {
+		return nil, err
+	}
+	config := protoConcat(
+		protoBytes(1, []byte(typeOfLayer(layer))),
+		protoBytes(2, marshalShapeProto(layer.InputShape())),
+		protoBytes(3, marshalShapeProto(layer.OutputShape())),
+		protoBytes(4, options),
+	)
+	for _, parameter := range layer.Parameters() {
+		config = append(config, protoBytes(5, marshalTensorProto(parameter))...)
+	}
+	return config, nil
+}
+
+func unmarshalLayerProto(data []byte) (Layer, error) {
+	fields, err := parseProto(data)
+	if err != nil {
+		return nil, err
+	}
+	var layerType LayerType
+	var inputShape, outputShape *LayerShape
+	var options []byte
+	parameters := []*tsr.Tensor{}
+	for _, field := range fields {
+		if !field.delimited {
+			continue
+		}
+		switch field.number {
+		case 1:
+			layerType = LayerType(field.bytes)
+		case 2, 3:
+			shape, err := unmarshalShapeProto(field.bytes)
+			if err != nil {
+				return nil, err
+			}
+			if field.number == 2 {
+				inputShape = &shape
+			} else {
+				outputShape = &shape
+			}
+		case 4:
+			options = field.bytes
+		case 5:
+			parameter, err := unmarshalTensorProto(field.bytes)
+			if err != nil {
+				return nil, err
+			}
+			parameters = append(parameters, parameter)
+		}
+	}
+	if options == nil {
+		return nil, fmt.Errorf("Layer does not have options")
+	}
+	layer, err := unmarshalLayer(options)
+	if err != nil {
+		return nil, err
+	}
+	if typeOfLayer(layer) != layerType {
+		return nil, fmt.Errorf("Type of layer does not match its options: %s != %s", layerType, typeOfLayer(layer))
+	}
+	if inputShape != nil && *inputShape != layer.InputShape() {
+		return nil, fmt.Errorf("Input shape of layer does not match its options: %s != %s", shapeString(*inputShape), shapeString(layer.InputShape()))
+	}
+	if outputShape != nil && *outputShape != layer.OutputShape() {
+		return nil, fmt.Errorf("Output shape of layer does not match its options: %s != %s", shapeString(*outputShape), shapeString(layer.OutputShape()))
+	}
+	layerParameters := layer.Parameters()
+	if len(parameters) != len(layerParameters) {
+		return nil, fmt.Errorf("Number of parameters does not match layer: %d != %d", len(parameters), len(layerParameters))
+	}
+	for i, parameter := range layerParameters {
+		err = parameter.SetTensor(parameters[i])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid parameter %d: %s", i, err.Error())
+		}
+	}
+	return layer, nil
+}
+
+func marshalShapeProto(shape LayerShape) []byte {
+	return protoConcat(
+		protoVarint(1, int64(shape.Rows)),
+		protoVarint(2, int64(shape.Cols)),
+		protoVarint(3, int64(shape.Frames)),
+	)
+}
+
+func unmarshalShapeProto(data []byte) (LayerShape, error) {
+	fields, err := parseProto(data)
+	if err != nil {
+		return LayerShape{}, err
+	}
+	shape := LayerShape{}
+	for _, field := range fields {
+		if field.delimited {
+			continue
+		}
+		switch field.number {
+		case 1:
+			shape.Rows = int(int32(field.value))
+		case 2:
+			shape.Cols = int(int32(field.value))
+		case 3:
+			shape.Frames = int(int32(field.value))
+		}
+	}
+	return shape, nil
+}
+
+func marshalTensorProto(tensor *tsr.Tensor) []byte {
+	return protoConcat(
+		protoVarint(1, int64(tensor.Frames)),
+		protoVarint(2, int64(tensor.Rows)),
+		protoVarint(3, int64(tensor.Cols)),
+		protoPackedFloats(4, tensorValues(tensor)),
+	)
+}
+
+func unmarshalTensorProto(data []byte) (*tsr.Tensor, error) {
+	fields, err := parseProto(data)
+	if err != nil {
+		return nil, err
+	}
+	dims := make([]int64, 3)
+	values := []float32{}
+	for _, field := range fields {
+		switch {
+		case field.number >= 1 && field.number <= 3 && !field.delimited:
+			dims[field.number-1] = int64(int32(field.value))
+		case field.number == 4:
+			floats, err := protoFloats(field)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, floats...)
+		}
+	}
+	frames, rows, cols := dims[0], dims[1], dims[2]
+	// The dimensions are compared to the number of values before multiplying, so large dimensions
+	// cannot overflow.
+	if frames < 1 || rows < 1 || cols < 1 || cols > int64(len(values)) || rows > int64(len(values))/cols ||
+		frames*rows*cols != int64(len(values)) {
+		return nil, fmt.Errorf("Number of values of tensor does not match its shape: %d, (%d, %d, %d)", len(values), frames, rows, cols)
+	}
+	tensor := tsr.NewEmptyTensor3D(int(frames), int(rows), int(cols))
+	index := 0
+	for frame := 0; frame < tensor.Frames; frame++ {
+		for row := 0; row < tensor.Rows; row++ {
+			for col := 0; col < tensor.Cols; col++ {
+				tensor.Set(frame, row, col, values[index])
+				index++
+			}
+		}
+	}
+	return tensor, nil
+}
+
+func uvarint(value uint64) []byte {
+	buffer := make([]byte, binary.MaxVarintLen64)
+	return buffer[:binary.PutUvarint(buffer, value)]
+}
+
+func protoKey(number int, wireType int) []byte {
+	return uvarint(uint64(number<<3 | wireType))
+}
+
+func protoVarint(number int, value int64) []byte {
+	return protoConcat(protoKey(number, 0), uvarint(uint64(value)))
+}
+
+func protoBytes(number int, value []byte) []byte {
+	return protoConcat(protoKey(number, 2), uvarint(uint64(len(value))), value)
+}
+
+func protoPackedFloats(number int, values []float32) []byte {
+	packed := make([]byte, len(values)*4)
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(packed[i*4:], math.Float32bits(value))
+	}
+	return protoBytes(number, packed)
+}
+
+func protoConcat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
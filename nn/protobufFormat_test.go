@@ -0,0 +1,141 @@
+package nn
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestNeuralNetworkSaveLoadProto(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	convolutionLayer, _ := NewRandomConvolutionLayer(4, 4, 1, 2, 3, ActivationRELU)
+	neuralNetwork.Add(
+		convolutionLayer,
+		NewFlattenLayer(4, 4, 2),
+		NewDenseLayer(32, 8, ActivationSigmoid),
+		NewDenseLayer(8, 3, ActivationLinear),
+		NewSoftmaxLayer(3),
+	)
+	neuralNetwork.SetMetadata(NewMetadata(neuralNetwork, "proto", "1.0.0"))
+
+	fileName := filepath.Join(t.TempDir(), "nn.pb")
+	err := neuralNetwork.SaveToFileProto(fileName)
+	if err != nil {
+		t.Fatalf("Error in SaveToFileProto: %s", err.Error())
+	}
+	loaded := NewNeuralNetwork()
+	err = loaded.LoadFromFileProto(fileName)
+	if err != nil {
+		t.Fatalf("Error in LoadFromFileProto: %s", err.Error())
+	}
+
+	inputs := [][][]float32{{{1, 0, 1, 0}, {0, 1, 0, 1}, {1, 1, 0, 0}, {0, 0, 1, 1}}}
+	expected, _ := neuralNetwork.Predict(inputs)
+	result, err := loaded.Predict(inputs)
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	for i := range expected[0][0] {
+		if result[0][0][i] != expected[0][0][i] {
+			t.Errorf("Prediction of loaded network should match: %v != %v", result, expected)
+			break
+		}
+	}
+	if loaded.Metadata() == nil || loaded.Metadata().Name != "proto" {
+		t.Errorf("Loaded network should have its metadata")
+	}
+
+	for _, layer := range fuzzLayers() {
+		for _, parameter := range layer.Parameters() {
+			parameter.SetRandom(-1, 1)
+		}
+		data, err := marshalLayerProto(layer)
+		if err != nil {
+			t.Fatalf("Error in marshalLayerProto: %s", err.Error())
+		}
+		loadedLayer, err := unmarshalLayerProto(data)
+		if err != nil {
+			t.Fatalf("Error in unmarshalLayerProto of %s layer: %s", typeOfLayer(layer), err.Error())
+		}
+		for i, parameter := range layer.Parameters() {
+			if !loadedLayer.Parameters()[i].Equals(parameter) {
+				t.Errorf("Parameter %d of loaded %s layer should match", i, typeOfLayer(layer))
+			}
+		}
+	}
+}
+
+func TestTensorProto(t *testing.T) {
+	// The message as encoded by any protocol buffer library: the dimensions as varints, followed
+	// by the packed little endian values.
+	solution := []byte{
+		0x08, 0x01, 0x10, 0x01, 0x18, 0x02,
+		0x22, 0x08, 0x00, 0x00, 0x80, 0x3f, 0x00, 0x00, 0x00, 0x40,
+	}
+	tensor := tsr.NewValueTensor1D([]float32{1, 2})
+	data := marshalTensorProto(tensor)
+	if !bytes.Equal(data, solution) {
+		t.Errorf("Tensor message should be %x, is: %x", solution, data)
+	}
+
+	loaded, err := unmarshalTensorProto(data)
+	if err != nil {
+		t.Fatalf("Error in unmarshalTensorProto: %s", err.Error())
+	}
+	if !loaded.Equals(tensor) {
+		t.Errorf("Loaded tensor should be:\n%swhen result is:\n%s", tensor.String(), loaded.String())
+	}
+
+	invalid := [][]byte{
+		protoConcat(protoVarint(1, 1), protoVarint(2, 2), protoVarint(3, 2), protoPackedFloats(4, []float32{1, 2})),
+		protoConcat(protoVarint(1, 0), protoVarint(2, 1), protoVarint(3, 1), protoPackedFloats(4, []float32{})),
+		protoConcat(protoVarint(1, 1<<20), protoVarint(2, 1<<20), protoVarint(3, 1<<20), protoPackedFloats(4, []float32{1})),
+		data[:len(data)-1],
+	}
+	for _, message := range invalid {
+		_, err = unmarshalTensorProto(message)
+		if err == nil {
+			t.Errorf("Invalid tensor message %x did not trigger error", message)
+		}
+	}
+}
+
+func TestNeuralNetworkUnmarshalProtoInvalid(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 3, ActivationSigmoid))
+	layer := neuralNetwork.LayerAt(0)
+	options, _ := json.Marshal(layer)
+	shape := marshalShapeProto(layer.InputShape())
+	weights := marshalTensorProto(layer.Parameters()[0])
+	bias := marshalTensorProto(layer.Parameters()[1])
+
+	models := map[string][]byte{
+		"newer version": protoVarint(1, FormatVersion+1),
+		"wrong type": protoBytes(3, protoConcat(
+			protoBytes(1, []byte(LayerTypeConvolution)), protoBytes(4, options), protoBytes(5, weights), protoBytes(5, bias),
+		)),
+		"wrong input shape": protoBytes(3, protoConcat(
+			protoBytes(1, []byte(LayerTypeDense)), protoBytes(2, marshalShapeProto(LayerShape{1, 4, 1})),
+			protoBytes(4, options), protoBytes(5, weights), protoBytes(5, bias),
+		)),
+		"missing parameter": protoBytes(3, protoConcat(
+			protoBytes(1, []byte(LayerTypeDense)), protoBytes(2, shape), protoBytes(4, options), protoBytes(5, weights),
+		)),
+		"wrong parameter shape": protoBytes(3, protoConcat(
+			protoBytes(1, []byte(LayerTypeDense)), protoBytes(4, options), protoBytes(5, bias), protoBytes(5, weights),
+		)),
+		"missing options": protoBytes(3, protoConcat(
+			protoBytes(1, []byte(LayerTypeDense)), protoBytes(5, weights), protoBytes(5, bias),
+		)),
+	}
+	for name, model := range models {
+		loaded := NewNeuralNetwork()
+		err := loaded.UnmarshalProto(model)
+		if err == nil {
+			t.Errorf("Model with %s did not trigger error", name)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package nn
+
+import (
+	"fmt"
+
+	tsr "../tensor"
+)
+
+// LossTypePinball is the type for a pinball or quantile loss function.
+const LossTypePinball = LossType("pinball")
+
+// NewPinballLoss creates a pinball loss function for quantile regression, which trains each output
+// to predict a quantile of its target instead of the mean. Outputs below the target are penalized
+// by the quantile and outputs above it by 1 minus the quantile, so a quantile of 0.9 predicts a
+// value that 90% of targets are below. With several quantiles, the columns of the outputs use each
+// quantile in turn, such as the outputs of a head added by AddQuantileHead.
+func NewPinballLoss(quantiles ...float32) (LossFunction, error) {
+	if len(quantiles) == 0 {
+		return LossFunction{}, fmt.Errorf("Must have at least 1 quantile")
+	}
+	for _, quantile := range quantiles {
+		if quantile <= 0 || quantile >= 1 {
+			return LossFunction{}, fmt.Errorf("Quantile must be between 0 and 1, is: %f", quantile)
+		}
+	}
+	quantileAt := func(col int) float32 {
+		return quantiles[col%len(quantiles)]
+	}
+	return LossFunction{
+		Type: LossTypePinball,
+		Function: func(outputs *tsr.Tensor, targets *tsr.Tensor) (float32, error) {
+			err := checkLossShapes(outputs, targets)
+			if err != nil {
+				return 0, err
+			}
+			sum := float32(0.0)
+			outputs.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+				difference := targets.Get(frame, row, col) - current
+				if difference >= 0 {
+					sum += quantileAt(col) * difference
+				} else {
+					sum += (quantileAt(col) - 1) * difference
+				}
+				return current
+			})
+			return sum / float32(lossSize(outputs)), nil
+		},
+		Derivative: func(outputs *tsr.Tensor, targets *tsr.Tensor) (*tsr.Tensor, error) {
+			err := checkLossShapes(outputs, targets)
+			if err != nil {
+				return nil, err
+			}
+			size := float32(lossSize(outputs))
+			gradient := tsr.NewEmptyTensor3D(outputs.Frames, outputs.Rows, outputs.Cols)
+			gradient.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+				if targets.Get(frame, row, col) > outputs.Get(frame, row, col) {
+					return -quantileAt(col) / size
+				}
+				return (1 - quantileAt(col)) / size
+			})
+			return gradient, nil
+		},
+	}, nil
+}
+
+// AddQuantileHead adds a linear dense layer with an output for each quantile to the end of a neural
+// network, and sets its loss to a pinball loss for the quantiles. The last layer of the neural
+// network must have a single row of outputs. Quantiles such as 0.05, 0.5 and 0.95 give the median
+// along with a 90% prediction interval. The targets need a copy of their value for each quantile,
+// which QuantileTargets makes.
+func AddQuantileHead(neuralNetwork *NeuralNetwork, quantiles []float32) error {
+	if neuralNetwork.LayerCount() == 0 {
+		return fmt.Errorf("Neural network must have a layer to add a quantile head to")
+	}
+	loss, err := NewPinballLoss(quantiles...)
+	if err != nil {
+		return err
+	}
+	outputShape := neuralNetwork.LayerAt(neuralNetwork.LayerCount() - 1).OutputShape()
+	if outputShape.Rows != 1 || outputShape.Frames != 1 {
+		return fmt.Errorf("Outputs of last layer must be a single row, are: (%d, %d, %d)", outputShape.Rows, outputShape.Cols, outputShape.Frames)
+	}
+	err = neuralNetwork.Add(NewDenseLayer(outputShape.Cols, len(quantiles), ActivationLinear))
+	if err != nil {
+		return err
+	}
+	neuralNetwork.SetLoss(loss)
+	return nil
+}
+
+// QuantileTargets repeats the single target value of each sample once for each of a number of
+// quantiles, to train a neural network with a quantile head.
+func QuantileTargets(targets [][][][]float32, quantiles int) ([][][][]float32, error) {
+	quantileTargets := make([][][][]float32, len(targets))
+	for i, target := range targets {
+		if len(target) != 1 || len(target[0]) != 1 || len(target[0][0]) != 1 {
+			return nil, fmt.Errorf("Target %d must be a single value", i)
+		}
+		values := make([]float32, quantiles)
+		for j := range values {
+			values[j] = target[0][0][0]
+		}
+		quantileTargets[i] = [][][]float32{{values}}
+	}
+	return quantileTargets, nil
+}
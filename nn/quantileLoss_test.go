@@ -0,0 +1,80 @@
+package nn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestPinballLoss(t *testing.T) {
+	loss, err := NewPinballLoss(0.1, 0.9)
+	if err != nil {
+		t.Fatalf("Error in NewPinballLoss: %s", err.Error())
+	}
+	outputs := tsr.NewValueTensor1D([]float32{1, 1})
+	targets := tsr.NewValueTensor1D([]float32{3, 3})
+	value, err := loss.Function(outputs, targets)
+	if err != nil {
+		t.Fatalf("Error in pinball loss: %s", err.Error())
+	}
+	// Both outputs are 2 below the target, penalized by their quantiles of 0.1 and 0.9.
+	if math.Abs(float64(value)-(0.2+1.8)/2) > 1e-5 {
+		t.Errorf("Pinball loss should be 1, is: %f", value)
+	}
+	gradient, err := loss.Derivative(outputs, tsr.NewValueTensor1D([]float32{0, 3}))
+	if err != nil {
+		t.Fatalf("Error in pinball derivative: %s", err.Error())
+	}
+	if math.Abs(float64(gradient.Get(0, 0, 0)-0.45)) > 1e-5 || math.Abs(float64(gradient.Get(0, 0, 1)+0.45)) > 1e-5 {
+		t.Errorf("Pinball gradient should be [0.45, -0.45], is:\n%s", gradient.String())
+	}
+
+	_, err = NewPinballLoss(1.5)
+	if err == nil {
+		t.Errorf("Quantile above 1 should have an error")
+	}
+}
+
+func TestQuantileHead(t *testing.T) {
+	rand.Seed(1)
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(1, 8, ActivationTanh))
+	err := AddQuantileHead(neuralNetwork, []float32{0.1, 0.5, 0.9})
+	if err != nil {
+		t.Fatalf("Error in AddQuantileHead: %s", err.Error())
+	}
+	if neuralNetwork.LayerAt(1).OutputShape().Cols != 3 || neuralNetwork.Loss().Type != LossTypePinball {
+		t.Fatalf("Quantile head should have 3 outputs and a pinball loss")
+	}
+
+	inputs := make([][][][]float32, 500)
+	targets := make([][][][]float32, 500)
+	for i := range inputs {
+		x := rand.Float32()
+		inputs[i] = [][][]float32{{{x}}}
+		targets[i] = [][][]float32{{{x + rand.Float32()}}}
+	}
+	quantileTargets, err := QuantileTargets(targets, 3)
+	if err != nil {
+		t.Fatalf("Error in QuantileTargets: %s", err.Error())
+	}
+	err = neuralNetwork.TrainBatchSchedule(inputs, quantileTargets, 10, 60, ConstantSchedule(0.01), NewAdamOptimizer(0.01))
+	if err != nil {
+		t.Fatalf("Error in TrainBatchSchedule: %s", err.Error())
+	}
+
+	// The noise is uniform between 0 and 1, so the quantiles are near x + 0.1, x + 0.5 and x + 0.9.
+	outputs, err := neuralNetwork.Predict([][][]float32{{{0.5}}})
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	solution := []float32{0.6, 1.0, 1.4}
+	for i, output := range outputs[0][0] {
+		if math.Abs(float64(output-solution[i])) > 0.15 {
+			t.Errorf("Quantiles should be close to %v, are: %v", solution, outputs[0][0])
+			break
+		}
+	}
+}
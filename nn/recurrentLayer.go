@@ -0,0 +1,333 @@
+package nn
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	tsr "../tensor"
+)
+
+// RecurrentLayer is a simple recurrent layer that keeps a hidden state over the timesteps of a
+// sequence. Each row of the input data is a timestep, and the state after each timestep is the
+// activation of the inputs and the previous state. The layer outputs the state after every
+// timestep if it returns sequences, or else only the state after the last timestep.
+type RecurrentLayer struct {
+	inputShape               LayerShape
+	outputShape              LayerShape
+	inputs                   *tsr.Tensor
+	states                   *tsr.Tensor
+	outputs                  *tsr.Tensor
+	initialState             *tsr.Tensor
+	initialStateDeltas       *tsr.Tensor
+	state                    *tsr.Tensor
+	mask                     []bool
+	inputWeightGradients     *tsr.Tensor
+	recurrentWeightGradients *tsr.Tensor
+	biasGradients            *tsr.Tensor
+	InputWeights             *tsr.Tensor
+	RecurrentWeights         *tsr.Tensor
+	Bias                     *tsr.Tensor
+	Activation               ActivationFunction
+	ReturnSequences          bool
+	Stateful                 bool
+}
+
+// NewRecurrentLayer creates a new instance of a simple recurrent layer with a number of units in
+// its hidden state.
+func NewRecurrentLayer(timesteps int, inputSize int, units int, activation ActivationFunction, returnSequences bool) *RecurrentLayer {
+	inputWeights := tsr.NewEmptyTensor2D(inputSize, units)
+	inputWeights.SetRandom(-1.0, 1.0)
+	// Smaller recurrent weights keep the state from growing or vanishing over long sequences.
+	limit := float32(1 / math.Sqrt(float64(units)))
+	recurrentWeights := tsr.NewEmptyTensor2D(units, units)
+	recurrentWeights.SetRandom(-limit, limit)
+	bias := tsr.NewEmptyTensor1D(units)
+	bias.SetRandom(-1.0, 1.0)
+	return newRecurrentLayer(timesteps, inputWeights, recurrentWeights, bias, activation, returnSequences)
+}
+
+func newRecurrentLayer(timesteps int, inputWeights *tsr.Tensor, recurrentWeights *tsr.Tensor, bias *tsr.Tensor, activation ActivationFunction, returnSequences bool) *RecurrentLayer {
+	inputSize := inputWeights.Rows
+	units := inputWeights.Cols
+	outputRows := 1
+	if returnSequences {
+		outputRows = timesteps
+	}
+	return &RecurrentLayer{
+		inputShape:               LayerShape{timesteps, inputSize, 1},
+		outputShape:              LayerShape{outputRows, units, 1},
+		inputs:                   tsr.NewEmptyTensor2D(timesteps, inputSize),
+		states:                   tsr.NewEmptyTensor2D(timesteps, units),
+		outputs:                  tsr.NewEmptyTensor2D(outputRows, units),
+		initialState:             tsr.NewEmptyTensor1D(units),
+		initialStateDeltas:       tsr.NewEmptyTensor1D(units),
+		state:                    tsr.NewEmptyTensor1D(units),
+		inputWeightGradients:     tsr.NewEmptyTensor2D(inputSize, units),
+		recurrentWeightGradients: tsr.NewEmptyTensor2D(units, units),
+		biasGradients:            tsr.NewEmptyTensor1D(units),
+		InputWeights:             inputWeights,
+		RecurrentWeights:         recurrentWeights,
+		Bias:                     bias,
+		Activation:               activation,
+		ReturnSequences:          returnSequences,
+	}
+}
+
+// Copy creates a deep copy of the layer. The copy starts with a reset state.
+func (layer *RecurrentLayer) Copy() Layer {
+	newLayer := newRecurrentLayer(
+		layer.inputShape.Rows,
+		layer.InputWeights.Copy(),
+		layer.RecurrentWeights.Copy(),
+		layer.Bias.Copy(),
+		layer.Activation,
+		layer.ReturnSequences,
+	)
+	newLayer.Stateful = layer.Stateful
+	return newLayer
+}
+
+// InputShape returns the rows, columns and frames of the inputs to the layer.
+func (layer *RecurrentLayer) InputShape() LayerShape {
+	return layer.inputShape
+}
+
+// OutputShape returns the rows, columns and frames of outputs from the layer.
+func (layer *RecurrentLayer) OutputShape() LayerShape {
+	return layer.outputShape
+}
+
+// SetMask sets the timesteps to skip in the following feed forward and back propagation. Masked
+// timesteps keep the state of the previous timestep and produce outputs of zero.
+func (layer *RecurrentLayer) SetMask(mask []bool) {
+	if len(mask) != layer.inputShape.Rows {
+		layer.mask = nil
+		return
+	}
+	layer.mask = mask
+}
+
+// ResetState sets the hidden state that a stateful layer carries between sequences back to zero.
+func (layer *RecurrentLayer) ResetState() {
+	layer.state.Scale(0)
+}
+
+// FeedForward runs the hidden state over each timestep of the inputs. A stateful layer starts from
+// the last state of the previous sequence, while other layers start from a state of zero.
+func (layer *RecurrentLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error) {
+	if layer.Stateful {
+		layer.initialState.SetTensor(layer.state)
+	} else {
+		layer.initialState.Scale(0)
+	}
+	return layer.feedForwardFromInitialState(inputs)
+}
+
+// feedForwardFrom runs the hidden state over each timestep of the inputs starting from a given
+// state, such as the last state of an encoder.
+func (layer *RecurrentLayer) feedForwardFrom(inputs *tsr.Tensor, state *tsr.Tensor) (*tsr.Tensor, error) {
+	err := layer.initialState.SetTensor(state)
+	if err != nil {
+		return nil, err
+	}
+	return layer.feedForwardFromInitialState(inputs)
+}
+
+func (layer *RecurrentLayer) feedForwardFromInitialState(inputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := layer.inputs.SetTensor(inputs)
+	if err != nil {
+		return nil, err
+	}
+	previousState := layer.initialState
+	for timestep := 0; timestep < layer.inputShape.Rows; timestep++ {
+		state := previousState.Copy()
+		if !layer.isMasked(timestep) {
+			state, err = tsr.MatrixMultiply(timestepOf(inputs, timestep), layer.InputWeights, nil)
+			if err != nil {
+				return nil, err
+			}
+			recurrent, err := tsr.MatrixMultiply(previousState, layer.RecurrentWeights, nil)
+			if err != nil {
+				return nil, err
+			}
+			state.AddTensor(recurrent)
+			state.AddTensor(layer.Bias)
+			layer.Activation.Function(state)
+		}
+		setTimestep(layer.states, timestep, state)
+		if layer.ReturnSequences {
+			if layer.isMasked(timestep) {
+				setTimestep(layer.outputs, timestep, tsr.NewEmptyTensor1D(state.Cols))
+			} else {
+				setTimestep(layer.outputs, timestep, state)
+			}
+		}
+		previousState = state
+	}
+	if !layer.ReturnSequences {
+		setTimestep(layer.outputs, 0, previousState)
+	}
+	if layer.Stateful {
+		layer.state.SetTensor(previousState)
+	}
+	return layer.outputs, nil
+}
+
+// BackPropagate back propagates the deltas through time, from the last timestep to the first. The
+// gradients of the weights add up over all timesteps. The state before the first timestep is not
+// learned, but its gradient is kept so it can be passed back to an encoder that produced it.
+func (layer *RecurrentLayer) BackPropagate(outputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := checkShape(outputs, layer.outputShape, "delta")
+	if err != nil {
+		return nil, err
+	}
+	nextDeltas := tsr.NewEmptyTensor2D(layer.inputShape.Rows, layer.inputShape.Cols)
+	stateDeltas := tsr.NewEmptyTensor1D(layer.outputShape.Cols)
+	transposedInputWeights, _ := tsr.MatrixTranspose(layer.InputWeights, nil)
+	transposedRecurrentWeights, _ := tsr.MatrixTranspose(layer.RecurrentWeights, nil)
+	lastTimestep := layer.inputShape.Rows - 1
+	for timestep := lastTimestep; timestep >= 0; timestep-- {
+		if layer.ReturnSequences && !layer.isMasked(timestep) {
+			stateDeltas.AddTensor(timestepOf(outputs, timestep))
+		} else if !layer.ReturnSequences && timestep == lastTimestep {
+			stateDeltas.AddTensor(timestepOf(outputs, 0))
+		}
+		if layer.isMasked(timestep) {
+			// The state passes through masked timesteps unchanged, and so does its gradient.
+			continue
+		}
+		previousState := layer.initialState
+		if timestep > 0 {
+			previousState = timestepOf(layer.states, timestep-1)
+		}
+		gradient := layer.Activation.Derivative(timestepOf(layer.states, timestep))
+		gradient.ScaleTensor(stateDeltas)
+		err := addOuterProduct(layer.inputWeightGradients, timestepOf(layer.inputs, timestep), gradient)
+		if err != nil {
+			return nil, err
+		}
+		err = addOuterProduct(layer.recurrentWeightGradients, previousState, gradient)
+		if err != nil {
+			return nil, err
+		}
+		layer.biasGradients.AddTensor(gradient)
+		inputDeltas, err := tsr.MatrixMultiply(gradient, transposedInputWeights, nil)
+		if err != nil {
+			return nil, err
+		}
+		setTimestep(nextDeltas, timestep, inputDeltas)
+		stateDeltas, err = tsr.MatrixMultiply(gradient, transposedRecurrentWeights, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	layer.initialStateDeltas.SetTensor(stateDeltas)
+	return nextDeltas, nil
+}
+
+// Parameters returns the input weights, recurrent weights and bias of the layer.
+func (layer *RecurrentLayer) Parameters() []*tsr.Tensor {
+	return []*tsr.Tensor{layer.InputWeights, layer.RecurrentWeights, layer.Bias}
+}
+
+// Gradients returns the gradients of the input weights, recurrent weights and bias.
+func (layer *RecurrentLayer) Gradients() []*tsr.Tensor {
+	return []*tsr.Tensor{layer.inputWeightGradients, layer.recurrentWeightGradients, layer.biasGradients}
+}
+
+func (layer *RecurrentLayer) isMasked(timestep int) bool {
+	return layer.mask != nil && layer.mask[timestep]
+}
+
+func (layer *RecurrentLayer) returnsSequences() bool {
+	return layer.ReturnSequences
+}
+
+func setTimestep(sequence *tsr.Tensor, timestep int, values *tsr.Tensor) {
+	for col := 0; col < sequence.Cols; col++ {
+		sequence.Set(0, timestep, col, values.Get(0, 0, col))
+	}
+}
+
+func addOuterProduct(target *tsr.Tensor, rows *tsr.Tensor, cols *tsr.Tensor) error {
+	transposedRows, _ := tsr.MatrixTranspose(rows, nil)
+	product, err := tsr.MatrixMultiply(transposedRows, cols, nil)
+	if err != nil {
+		return err
+	}
+	return target.AddTensor(product)
+}
+
+// RecurrentLayerData represents a serialized layer that can be saved to a file.
+type RecurrentLayerData struct {
+	Type             LayerType          `json:"type"`
+	Timesteps        int                `json:"timesteps"`
+	InputWeights     [][]float32        `json:"inputWeights"`
+	RecurrentWeights [][]float32        `json:"recurrentWeights"`
+	Bias             []float32          `json:"bias"`
+	Activation       ActivationFunction `json:"activation"`
+	ReturnSequences  bool               `json:"returnSequences"`
+	Stateful         bool               `json:"stateful"`
+}
+
+// MarshalJSON converts the layer to JSON.
+func (layer *RecurrentLayer) MarshalJSON() ([]byte, error) {
+	data := RecurrentLayerData{
+		Type:             LayerTypeRecurrent,
+		Timesteps:        layer.inputShape.Rows,
+		InputWeights:     layer.InputWeights.GetFrame(0),
+		RecurrentWeights: layer.RecurrentWeights.GetFrame(0),
+		Bias:             layer.Bias.GetFrame(0)[0],
+		Activation:       layer.Activation,
+		ReturnSequences:  layer.ReturnSequences,
+		Stateful:         layer.Stateful,
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new layer from JSON.
+func (layer *RecurrentLayer) UnmarshalJSON(b []byte) error {
+	data := RecurrentLayerData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	err = checkLoadedMatrix(data.InputWeights, "input weights")
+	if err != nil {
+		return err
+	}
+	err = checkLoadedMatrix(data.RecurrentWeights, "recurrent weights")
+	if err != nil {
+		return err
+	}
+	units := len(data.InputWeights[0])
+	if len(data.RecurrentWeights) != units || len(data.RecurrentWeights[0]) != units {
+		return fmt.Errorf(
+			"Recurrent weights of layer must be %d by %d, are: %d by %d",
+			units, units, len(data.RecurrentWeights), len(data.RecurrentWeights[0]),
+		)
+	}
+	if len(data.Bias) != units {
+		return fmt.Errorf("Number of biases of layer must be %d, is: %d", units, len(data.Bias))
+	}
+	err = checkLoadedShape(LayerShape{data.Timesteps, len(data.InputWeights), 1}, LayerShape{data.Timesteps, units, 1})
+	if err != nil {
+		return err
+	}
+	err = checkLoadedActivation(data.Activation)
+	if err != nil {
+		return err
+	}
+	newLayer := newRecurrentLayer(
+		data.Timesteps,
+		tsr.NewValueTensor2D(data.InputWeights),
+		tsr.NewValueTensor2D(data.RecurrentWeights),
+		tsr.NewValueTensor1D(data.Bias),
+		data.Activation,
+		data.ReturnSequences,
+	)
+	newLayer.Stateful = data.Stateful
+	*layer = *newLayer
+	return nil
+}
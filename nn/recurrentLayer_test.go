@@ -0,0 +1,169 @@
+package nn
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestRecurrentLayerBackPropagate(t *testing.T) {
+	rand.Seed(1)
+	layer := NewRecurrentLayer(4, 3, 2, ActivationTanh, true)
+	layer.SetMask([]bool{false, false, true, false})
+
+	inputs := tsr.NewEmptyTensor2D(4, 3)
+	inputs.SetRandom(-1, 1)
+	deltas := tsr.NewEmptyTensor2D(4, 2)
+	deltas.SetRandom(-1, 1)
+
+	outputs, err := layer.FeedForward(inputs)
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+	for col := 0; col < 2; col++ {
+		if outputs.Get(0, 2, col) != 0 {
+			t.Errorf("Output of masked timestep should be 0, is: %.4f", outputs.Get(0, 2, col))
+		}
+	}
+	inputDeltas, err := layer.BackPropagate(deltas)
+	if err != nil {
+		t.Fatalf("Error in BackPropagate: %s", err.Error())
+	}
+
+	weightedOutputs := func() float64 {
+		outputs, _ := layer.FeedForward(inputs)
+		sum := 0.0
+		outputs.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			sum += float64(current * deltas.Get(frame, row, col))
+			return current
+		})
+		return sum
+	}
+	numericalGradient := func(tensor *tsr.Tensor, frame int, row int, col int) float32 {
+		epsilon := float32(0.01)
+		value := tensor.Get(frame, row, col)
+		tensor.Set(frame, row, col, value+epsilon)
+		plus := weightedOutputs()
+		tensor.Set(frame, row, col, value-epsilon)
+		minus := weightedOutputs()
+		tensor.Set(frame, row, col, value)
+		return float32((plus - minus) / float64(2*epsilon))
+	}
+
+	inputDeltas.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		solution := numericalGradient(inputs, frame, row, col)
+		if math.Abs(float64(current-solution)) > 2e-3 {
+			t.Errorf("Input delta (%d, %d) should be %.4f, is: %.4f", row, col, solution, current)
+		}
+		return current
+	})
+	names := []string{"input weight", "recurrent weight", "bias"}
+	for i, parameter := range layer.Parameters() {
+		layer.Gradients()[i].ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			solution := numericalGradient(parameter, frame, row, col)
+			if math.Abs(float64(current-solution)) > 2e-3 {
+				t.Errorf("Gradient of %s (%d, %d) should be %.4f, is: %.4f", names[i], row, col, solution, current)
+			}
+			return current
+		})
+	}
+
+	_, err = layer.BackPropagate(tsr.NewEmptyTensor2D(1, 2))
+	if err == nil {
+		t.Errorf("Deltas with incorrect shape did not trigger error")
+	}
+}
+
+func TestRecurrentLayerState(t *testing.T) {
+	rand.Seed(1)
+	layer := NewRecurrentLayer(2, 1, 3, ActivationTanh, false)
+	inputs := tsr.NewValueTensor2D([][]float32{{1}, {-1}})
+
+	first, _ := layer.FeedForward(inputs)
+	first = first.Copy()
+	second, _ := layer.FeedForward(inputs)
+	if !first.Equals(second) {
+		t.Errorf("Layer that is not stateful should give the same outputs for the same sequence")
+	}
+
+	layer.Stateful = true
+	first, _ = layer.FeedForward(inputs)
+	first = first.Copy()
+	second, _ = layer.FeedForward(inputs)
+	if first.Equals(second) {
+		t.Errorf("Stateful layer should carry its state to the next sequence")
+	}
+	layer.ResetState()
+	reset, _ := layer.FeedForward(inputs)
+	if !reset.Equals(first) {
+		t.Errorf("Stateful layer after reset should be:\n%swhen result is:\n%s", first.String(), reset.String())
+	}
+}
+
+func TestRecurrentLayerJSON(t *testing.T) {
+	layer := NewRecurrentLayer(5, 2, 3, ActivationTanh, true)
+	layer.Stateful = true
+
+	data, err := json.Marshal(layer)
+	if err != nil {
+		t.Fatalf("Error in MarshalJSON: %s", err.Error())
+	}
+	loaded := &RecurrentLayer{}
+	err = json.Unmarshal(data, loaded)
+	if err != nil {
+		t.Fatalf("Error in UnmarshalJSON: %s", err.Error())
+	}
+	if loaded.InputShape() != layer.InputShape() || loaded.OutputShape() != layer.OutputShape() || !loaded.Stateful {
+		t.Errorf("Loaded layer shapes do not match original")
+	}
+	if !loaded.RecurrentWeights.Equals(layer.RecurrentWeights) || !loaded.Bias.Equals(layer.Bias) {
+		t.Errorf("Loaded layer weights do not match original")
+	}
+}
+
+func TestRecurrentLayerTrain(t *testing.T) {
+	rand.Seed(1)
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(
+		NewRecurrentLayer(4, 1, 8, ActivationTanh, false),
+		NewDenseLayer(8, 1, ActivationSigmoid),
+	)
+
+	// The target is whether the first value of the sequence was positive, which must be carried in
+	// the state over every timestep.
+	inputs := [][][][]float32{}
+	targets := [][][][]float32{}
+	for i := 0; i < 64; i++ {
+		sequence := make([][]float32, 4)
+		for timestep := range sequence {
+			sequence[timestep] = []float32{rand.Float32()*2 - 1}
+		}
+		target := float32(0)
+		if sequence[0][0] > 0 {
+			target = 1
+		}
+		inputs = append(inputs, [][][]float32{sequence})
+		targets = append(targets, [][][]float32{{{target}}})
+	}
+	err := neuralNetwork.TrainBatchSchedule(inputs, targets, 8, 150, ConstantSchedule(0.01), NewAdamOptimizer(0.01))
+	if err != nil {
+		t.Fatalf("Error in TrainBatchSchedule: %s", err.Error())
+	}
+
+	correct := 0
+	for i, input := range inputs {
+		result, err := neuralNetwork.Predict(input)
+		if err != nil {
+			t.Fatalf("Error in Predict: %s", err.Error())
+		}
+		if (result[0][0][0] > 0.5) == (targets[i][0][0][0] == 1) {
+			correct++
+		}
+	}
+	if correct < 58 {
+		t.Errorf("Recurrent network should classify at least 58 of 64 sequences, is: %d", correct)
+	}
+}
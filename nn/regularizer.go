@@ -0,0 +1,76 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	tsr "../tensor"
+)
+
+// Regularizer adds a penalty on the parameters of a neural network to the loss it is trained on.
+// AddGradient adds the gradient of the penalty to the gradient of a parameter before the optimizer
+// updates it.
+type Regularizer interface {
+	Penalty(parameter *tsr.Tensor) float32
+	AddGradient(parameter *tsr.Tensor, gradient *tsr.Tensor) error
+}
+
+// regularizedLayer is a trainable layer with its own regularizer for some of its parameters, such as
+// the weights but not the bias of a dense layer.
+type regularizedLayer interface {
+	regularizedParameters() (Regularizer, []*tsr.Tensor)
+}
+
+// L1L2Regularizer is a weight decay penalty, with L1 times the sum of the absolute values of a
+// parameter, which pushes values to exactly 0, plus L2 times the sum of their squares, which
+// keeps values small.
+type L1L2Regularizer struct {
+	L1 float32
+	L2 float32
+}
+
+// NewL1Regularizer creates a regularizer with only an L1 penalty.
+func NewL1Regularizer(l1 float32) *L1L2Regularizer {
+	return &L1L2Regularizer{L1: l1}
+}
+
+// NewL2Regularizer creates a regularizer with only an L2 penalty.
+func NewL2Regularizer(l2 float32) *L1L2Regularizer {
+	return &L1L2Regularizer{L2: l2}
+}
+
+// NewL1L2Regularizer creates a regularizer with both an L1 and an L2 penalty.
+func NewL1L2Regularizer(l1 float32, l2 float32) *L1L2Regularizer {
+	return &L1L2Regularizer{L1: l1, L2: l2}
+}
+
+// Penalty computes the L1 and L2 penalties of a parameter.
+func (regularizer *L1L2Regularizer) Penalty(parameter *tsr.Tensor) float32 {
+	penalty := float32(0.0)
+	parameter.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		penalty += regularizer.L1*float32(math.Abs(float64(current))) + regularizer.L2*current*current
+		return current
+	})
+	return penalty
+}
+
+// AddGradient adds L1 times the sign of each value plus twice L2 times each value to the gradient.
+func (regularizer *L1L2Regularizer) AddGradient(parameter *tsr.Tensor, gradient *tsr.Tensor) error {
+	if parameter.Frames != gradient.Frames || parameter.Rows != gradient.Rows || parameter.Cols != gradient.Cols {
+		return fmt.Errorf(
+			"Dimensions of parameter and gradient must match: (%d, %d, %d) != (%d, %d, %d)",
+			parameter.Frames, parameter.Rows, parameter.Cols, gradient.Frames, gradient.Rows, gradient.Cols,
+		)
+	}
+	gradient.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		value := parameter.Get(frame, row, col)
+		sign := float32(0.0)
+		if value > 0 {
+			sign = 1
+		} else if value < 0 {
+			sign = -1
+		}
+		return current + regularizer.L1*sign + 2*regularizer.L2*value
+	})
+	return nil
+}
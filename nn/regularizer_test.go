@@ -0,0 +1,59 @@
+package nn
+
+import (
+	"math"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestL1L2Regularizer(t *testing.T) {
+	regularizer := NewL1L2Regularizer(0.1, 0.5)
+	parameter := tsr.NewValueTensor1D([]float32{2, -1, 0})
+	penalty := regularizer.Penalty(parameter)
+	if math.Abs(float64(penalty)-(0.1*3+0.5*5)) > 1e-6 {
+		t.Errorf("Penalty should be %f, is: %f", 0.1*3+0.5*5, penalty)
+	}
+
+	gradient := tsr.NewValueTensor1D([]float32{1, 1, 1})
+	err := regularizer.AddGradient(parameter, gradient)
+	if err != nil {
+		t.Fatalf("Error in AddGradient: %s", err.Error())
+	}
+	solution := []float32{1 + 0.1 + 2, 1 - 0.1 - 1, 1}
+	for i, value := range solution {
+		if math.Abs(float64(gradient.Get(0, 0, i)-value)) > 1e-6 {
+			t.Errorf("Gradient should be %v, is:\n%s", solution, gradient.String())
+			break
+		}
+	}
+
+	err = regularizer.AddGradient(parameter, tsr.NewEmptyTensor1D(2))
+	if err == nil {
+		t.Errorf("Mismatched parameter and gradient should have an error")
+	}
+}
+
+func TestLayerRegularizer(t *testing.T) {
+	layer := NewDenseLayer(2, 1, ActivationLinear)
+	layer.Weights.SetTensor(tsr.NewValueTensor2D([][]float32{{1}, {-2}}))
+	layer.Bias.SetTensor(tsr.NewValueTensor1D([]float32{3}))
+	layer.Regularizer = NewL2Regularizer(0.25)
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(layer)
+
+	// With inputs of 0 and a target equal to the output, only the weight decay changes the weights.
+	err := neuralNetwork.Train([][][]float32{{{0, 0}}}, [][][]float32{{{3}}}, NewSGDOptimizer(1, 0))
+	if err != nil {
+		t.Fatalf("Error in Train: %s", err.Error())
+	}
+	if layer.Weights.Get(0, 0, 0) != 0.5 || layer.Weights.Get(0, 1, 0) != -1 {
+		t.Errorf("Weights should decay to [0.5, -1], are:\n%s", layer.Weights.String())
+	}
+	if layer.Bias.Get(0, 0, 0) != 3 {
+		t.Errorf("Bias should not be regularized, is: %f", layer.Bias.Get(0, 0, 0))
+	}
+	if layer.Copy().(*DenseLayer).Regularizer != layer.Regularizer {
+		t.Errorf("Copied layer should keep the regularizer")
+	}
+}
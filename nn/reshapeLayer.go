@@ -0,0 +1,145 @@
+package nn
+
+import (
+	"encoding/json"
+	"fmt"
+
+	tsr "../tensor"
+)
+
+// ReshapeLayer is a layer that changes the rows, columns and frames of data without changing the
+// order of its values.
+type ReshapeLayer struct {
+	inputShape  LayerShape
+	outputShape LayerShape
+	outputs     *tsr.Tensor
+}
+
+// NewReshapeLayer creates a new instance of a reshaping layer.
+func NewReshapeLayer(inputShape LayerShape, outputShape LayerShape) (*ReshapeLayer, error) {
+	if shapeSize(inputShape) != shapeSize(outputShape) {
+		return nil, fmt.Errorf(
+			"Input and output shapes must have the same size: (%d, %d, %d) != (%d, %d, %d)",
+			inputShape.Rows, inputShape.Cols, inputShape.Frames,
+			outputShape.Rows, outputShape.Cols, outputShape.Frames,
+		)
+	}
+	return &ReshapeLayer{
+		inputShape:  inputShape,
+		outputShape: outputShape,
+		outputs:     tsr.NewEmptyTensor3D(outputShape.Frames, outputShape.Rows, outputShape.Cols),
+	}, nil
+}
+
+// Copy creates a deep copy of the layer.
+func (layer *ReshapeLayer) Copy() Layer {
+	newLayer, _ := NewReshapeLayer(layer.InputShape(), layer.OutputShape())
+	return newLayer
+}
+
+// InputShape returns the rows, columns and frames of the inputs to the layer.
+func (layer *ReshapeLayer) InputShape() LayerShape {
+	return layer.inputShape
+}
+
+// OutputShape returns the rows, columns and frames of outputs from the layer.
+func (layer *ReshapeLayer) OutputShape() LayerShape {
+	return layer.outputShape
+}
+
+// FeedForward reshapes the data from its input shape to its output shape.
+func (layer *ReshapeLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := reshapeInto(inputs, layer.outputs)
+	if err != nil {
+		return nil, err
+	}
+	return layer.outputs, nil
+}
+
+// BackPropagate reshapes the deltas back to the input shape.
+func (layer *ReshapeLayer) BackPropagate(outputs *tsr.Tensor) (*tsr.Tensor, error) {
+	nextDeltas := tsr.NewEmptyTensor3D(layer.inputShape.Frames, layer.inputShape.Rows, layer.inputShape.Cols)
+	err := reshapeInto(outputs, nextDeltas)
+	if err != nil {
+		return nil, err
+	}
+	return nextDeltas, nil
+}
+
+func reshapeInto(source *tsr.Tensor, target *tsr.Tensor) error {
+	if source.Frames*source.Rows*source.Cols != target.Frames*target.Rows*target.Cols {
+		return fmt.Errorf(
+			"Cannot reshape data: (%d, %d, %d) -> (%d, %d, %d)",
+			source.Frames, source.Rows, source.Cols, target.Frames, target.Rows, target.Cols,
+		)
+	}
+	target.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		index := (frame*target.Rows+row)*target.Cols + col
+		return source.Get(index/(source.Rows*source.Cols), (index/source.Cols)%source.Rows, index%source.Cols)
+	})
+	return nil
+}
+
+func shapeSize(shape LayerShape) int {
+	return shape.Rows * shape.Cols * shape.Frames
+}
+
+// Parameters returns an empty list, since the layer has no parameters.
+func (layer *ReshapeLayer) Parameters() []*tsr.Tensor {
+	return []*tsr.Tensor{}
+}
+
+// Gradients returns an empty list, since the layer has no parameters.
+func (layer *ReshapeLayer) Gradients() []*tsr.Tensor {
+	return []*tsr.Tensor{}
+}
+
+// ReshapeLayerData represents a serialized layer that can be saved to a file.
+type ReshapeLayerData struct {
+	Type         LayerType `json:"type"`
+	InputRows    int       `json:"inputRows"`
+	InputCols    int       `json:"inputCols"`
+	InputFrames  int       `json:"inputFrames"`
+	OutputRows   int       `json:"outputRows"`
+	OutputCols   int       `json:"outputCols"`
+	OutputFrames int       `json:"outputFrames"`
+}
+
+// MarshalJSON converts the layer to JSON.
+func (layer *ReshapeLayer) MarshalJSON() ([]byte, error) {
+	data := ReshapeLayerData{
+		Type:         LayerTypeReshape,
+		InputRows:    layer.InputShape().Rows,
+		InputCols:    layer.InputShape().Cols,
+		InputFrames:  layer.InputShape().Frames,
+		OutputRows:   layer.OutputShape().Rows,
+		OutputCols:   layer.OutputShape().Cols,
+		OutputFrames: layer.OutputShape().Frames,
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new layer from JSON.
+func (layer *ReshapeLayer) UnmarshalJSON(b []byte) error {
+	data := ReshapeLayerData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	err = checkLoadedShape(
+		LayerShape{data.InputRows, data.InputCols, data.InputFrames},
+		LayerShape{data.OutputRows, data.OutputCols, data.OutputFrames},
+	)
+	if err != nil {
+		return err
+	}
+	newLayer, err := NewReshapeLayer(
+		LayerShape{data.InputRows, data.InputCols, data.InputFrames},
+		LayerShape{data.OutputRows, data.OutputCols, data.OutputFrames},
+	)
+	if err != nil {
+		return err
+	}
+	*layer = *newLayer
+	return nil
+}
@@ -0,0 +1,92 @@
+package nn
+
+import (
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestReshapeLayer(t *testing.T) {
+	layer, err := NewReshapeLayer(LayerShape{2, 3, 2}, LayerShape{3, 4, 1})
+	if err != nil {
+		t.Fatalf("Error in NewReshapeLayer: %s", err.Error())
+	}
+
+	inputs := tsr.NewValueTensor3D([][][]float32{
+		{
+			{1, 2, 3},
+			{4, 5, 6},
+		},
+		{
+			{7, 8, 9},
+			{10, 11, 12},
+		},
+	})
+
+	solution := tsr.NewValueTensor2D([][]float32{
+		{1, 2, 3, 4},
+		{5, 6, 7, 8},
+		{9, 10, 11, 12},
+	})
+
+	reshaped, err := layer.FeedForward(inputs)
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+	if !reshaped.Equals(solution) {
+		t.Errorf("Matrix after feed forward should be:\n%swhen result is:\n%s", solution.String(), reshaped.String())
+	}
+
+	restored, err := layer.BackPropagate(reshaped)
+	if err != nil {
+		t.Fatalf("Error in BackPropagate: %s", err.Error())
+	}
+	if restored.Frames != inputs.Frames || !restored.Equals(inputs) {
+		t.Errorf("Matrix after back propagate should be:\n%swhen result is:\n%s", inputs.String(), restored.String())
+	}
+
+	_, err = NewReshapeLayer(LayerShape{2, 3, 2}, LayerShape{2, 2, 2})
+	if err == nil {
+		t.Errorf("Did not trigger error on shapes of different sizes")
+	}
+}
+
+func TestNeuralNetworkAutoAdapters(t *testing.T) {
+	pool := NewPoolingLayer(4, 4, 2, 2, PoolingMax)
+	dense := NewDenseLayer(8, 2, ActivationSigmoid)
+	conv, err := NewConvolutionLayer(2, 4, 1, []*tsr.Tensor{FilterVerticalEdges}, ActivationRELU)
+	if err != nil {
+		t.Fatalf("Error in NewConvolutionLayer: %s", err.Error())
+	}
+
+	neuralNetwork := NewNeuralNetwork()
+	err = neuralNetwork.Add(pool, dense)
+	if err == nil {
+		t.Errorf("Did not trigger error on incorrect layer shape without adapters")
+	}
+
+	neuralNetwork = NewNeuralNetwork()
+	neuralNetwork.SetAutoAdapters(true)
+	err = neuralNetwork.Add(pool, dense)
+	if err != nil {
+		t.Fatalf("Error in Add: %s", err.Error())
+	}
+	if _, ok := neuralNetwork.LayerAt(1).(*FlattenLayer); !ok {
+		t.Errorf("Flatten layer should have been inserted before the dense layer")
+	}
+
+	neuralNetwork = NewNeuralNetwork()
+	neuralNetwork.SetAutoAdapters(true)
+	err = neuralNetwork.Add(NewDenseLayer(4, 8, ActivationSigmoid), conv)
+	if err != nil {
+		t.Fatalf("Error in Add: %s", err.Error())
+	}
+	if _, ok := neuralNetwork.LayerAt(1).(*ReshapeLayer); !ok {
+		t.Errorf("Reshape layer should have been inserted before the convolution layer")
+	}
+
+	err = neuralNetwork.Add(NewDenseLayer(3, 1, ActivationSigmoid))
+	if err == nil {
+		t.Errorf("Did not trigger error on layer shape with a different size")
+	}
+}
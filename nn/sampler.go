@@ -0,0 +1,152 @@
+package nn
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Sampler chooses which samples of a data set are trained on in an epoch and in what order.
+type Sampler interface {
+	Indices(size int) ([]int, error)
+}
+
+// randomSourceSampler is a sampler that can draw its indices from a given random source instead of
+// the global one, so a trainer with a seed shuffles the same way without changing the random
+// numbers seen by the rest of the process.
+type randomSourceSampler interface {
+	indicesFrom(size int, random *rand.Rand) ([]int, error)
+}
+
+// sampleIndices returns the indices of an epoch from a sampler, drawn from a random source if
+// there is one and the sampler can use it.
+func sampleIndices(sampler Sampler, size int, random *rand.Rand) ([]int, error) {
+	if sourceSampler, ok := sampler.(randomSourceSampler); ok && random != nil {
+		return sourceSampler.indicesFrom(size, random)
+	}
+	return sampler.Indices(size)
+}
+
+// SequentialSampler trains on every sample once in the order of the data set.
+type SequentialSampler struct{}
+
+// Indices returns the indices of every sample in order.
+func (sampler SequentialSampler) Indices(size int) ([]int, error) {
+	indices := make([]int, size)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices, nil
+}
+
+// RandomSampler trains on every sample once in a random order.
+type RandomSampler struct{}
+
+// Indices returns the indices of every sample in a random order.
+func (sampler RandomSampler) Indices(size int) ([]int, error) {
+	return rand.Perm(size), nil
+}
+
+func (sampler RandomSampler) indicesFrom(size int, random *rand.Rand) ([]int, error) {
+	return random.Perm(size), nil
+}
+
+// WeightedSampler draws samples with replacement, where each sample is chosen in proportion to its
+// weight. The weights do not need to sum to 1.
+type WeightedSampler struct {
+	Weights []float32
+	Samples int
+}
+
+// NewWeightedSampler creates a sampler that draws a number of samples in each epoch from the given
+// weights. If the number of samples is 0, it draws as many samples as the data set has.
+func NewWeightedSampler(weights []float32, samples int) *WeightedSampler {
+	return &WeightedSampler{
+		Weights: weights,
+		Samples: samples,
+	}
+}
+
+// Indices returns the indices of the drawn samples.
+func (sampler *WeightedSampler) Indices(size int) ([]int, error) {
+	return sampler.draw(size, rand.Float32)
+}
+
+func (sampler *WeightedSampler) indicesFrom(size int, random *rand.Rand) ([]int, error) {
+	return sampler.draw(size, random.Float32)
+}
+
+// draw draws the indices of samples with a function returning random numbers from 0 to 1.
+func (sampler *WeightedSampler) draw(size int, random func() float32) ([]int, error) {
+	if len(sampler.Weights) != size {
+		return nil, fmt.Errorf("Number of weights and samples must match: %d != %d", len(sampler.Weights), size)
+	}
+	cumulative := make([]float32, size)
+	sum := float32(0.0)
+	for i, weight := range sampler.Weights {
+		if weight < 0 {
+			return nil, fmt.Errorf("Weights must not be negative, has: %f", weight)
+		}
+		sum += weight
+		cumulative[i] = sum
+	}
+	if sum <= 0 {
+		return nil, fmt.Errorf("Weights must have a positive sum, is: %f", sum)
+	}
+	samples := sampler.Samples
+	if samples <= 0 {
+		samples = size
+	}
+	indices := make([]int, samples)
+	for i := range indices {
+		threshold := random() * sum
+		index := sort.Search(size, func(j int) bool {
+			return cumulative[j] > threshold
+		})
+		// Rounding can leave the threshold at the end of the cumulative sums.
+		for index >= size || sampler.Weights[index] == 0 {
+			index--
+		}
+		indices[i] = index
+	}
+	return indices, nil
+}
+
+// NewClassBalancedSampler creates a weighted sampler that draws each class equally often, by
+// weighting each sample by the inverse of the number of samples with its label.
+func NewClassBalancedSampler(labels []int, samples int) *WeightedSampler {
+	counts := map[int]int{}
+	for _, label := range labels {
+		counts[label]++
+	}
+	weights := make([]float32, len(labels))
+	for i, label := range labels {
+		weights[i] = 1 / float32(counts[label])
+	}
+	return NewWeightedSampler(weights, samples)
+}
+
+// CurriculumSampler trains on every sample once, from the easiest to the hardest, where the
+// difficulty of each sample is given by a score and lower scores are easier.
+type CurriculumSampler struct {
+	Scores []float32
+}
+
+// NewCurriculumSampler creates a sampler that orders samples by the given difficulty scores.
+func NewCurriculumSampler(scores []float32) *CurriculumSampler {
+	return &CurriculumSampler{
+		Scores: scores,
+	}
+}
+
+// Indices returns the indices of every sample in order of increasing score.
+func (sampler *CurriculumSampler) Indices(size int) ([]int, error) {
+	if len(sampler.Scores) != size {
+		return nil, fmt.Errorf("Number of scores and samples must match: %d != %d", len(sampler.Scores), size)
+	}
+	indices, _ := SequentialSampler{}.Indices(size)
+	sort.SliceStable(indices, func(i int, j int) bool {
+		return sampler.Scores[indices[i]] < sampler.Scores[indices[j]]
+	})
+	return indices, nil
+}
@@ -0,0 +1,127 @@
+package nn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSequentialRandomSampler(t *testing.T) {
+	indices, _ := SequentialSampler{}.Indices(4)
+	for i, index := range indices {
+		if index != i {
+			t.Errorf("Sequential index %d should be %d, is: %d", i, i, index)
+		}
+	}
+
+	rand.Seed(1)
+	indices, _ = RandomSampler{}.Indices(5)
+	seen := map[int]bool{}
+	for _, index := range indices {
+		seen[index] = true
+	}
+	if len(indices) != 5 || len(seen) != 5 {
+		t.Errorf("Random sampler should return every index once, is: %v", indices)
+	}
+}
+
+func TestWeightedSampler(t *testing.T) {
+	rand.Seed(1)
+	sampler := NewWeightedSampler([]float32{1, 0, 3}, 4000)
+	indices, err := sampler.Indices(3)
+	if err != nil {
+		t.Fatalf("Error in Indices: %s", err.Error())
+	}
+	if len(indices) != 4000 {
+		t.Fatalf("Weighted sampler should draw 4000 samples, is: %d", len(indices))
+	}
+	counts := make([]int, 3)
+	for _, index := range indices {
+		counts[index]++
+	}
+	if counts[1] != 0 {
+		t.Errorf("Sample with weight 0 should not be drawn, is: %d", counts[1])
+	}
+	if counts[0] < 900 || counts[0] > 1100 {
+		t.Errorf("Sample with a quarter of the weight should be drawn about 1000 times, is: %d", counts[0])
+	}
+
+	indices, _ = NewWeightedSampler([]float32{1, 1}, 0).Indices(2)
+	if len(indices) != 2 {
+		t.Errorf("Weighted sampler without a number of samples should draw 2, is: %d", len(indices))
+	}
+
+	_, err = sampler.Indices(4)
+	if err == nil {
+		t.Errorf("Mismatched weights and samples did not trigger error")
+	}
+	_, err = NewWeightedSampler([]float32{0, 0}, 1).Indices(2)
+	if err == nil {
+		t.Errorf("Weights without a positive sum did not trigger error")
+	}
+}
+
+func TestClassBalancedSampler(t *testing.T) {
+	rand.Seed(1)
+	labels := []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
+	indices, err := NewClassBalancedSampler(labels, 4000).Indices(len(labels))
+	if err != nil {
+		t.Fatalf("Error in Indices: %s", err.Error())
+	}
+	rare := 0
+	for _, index := range indices {
+		if labels[index] == 1 {
+			rare++
+		}
+	}
+	if rare < 1800 || rare > 2200 {
+		t.Errorf("Rare class should be drawn about 2000 times, is: %d", rare)
+	}
+}
+
+func TestCurriculumSampler(t *testing.T) {
+	sampler := NewCurriculumSampler([]float32{0.5, 0.1, 0.9, 0.1})
+	indices, err := sampler.Indices(4)
+	if err != nil {
+		t.Fatalf("Error in Indices: %s", err.Error())
+	}
+	solution := []int{1, 3, 0, 2}
+	for i, index := range indices {
+		if index != solution[i] {
+			t.Errorf("Curriculum order should be %v, is: %v", solution, indices)
+			break
+		}
+	}
+
+	_, err = sampler.Indices(3)
+	if err == nil {
+		t.Errorf("Mismatched scores and samples did not trigger error")
+	}
+}
+
+func TestNeuralNetworkTrainBatchSampler(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+
+	inputs := [][][][]float32{{{{0, 1}}}, {{{1, 0}}}}
+	targets := [][][][]float32{{{{1}}}, {{{0}}}}
+
+	// A sampler that only draws the first sample trains like the first sample on its own.
+	single := neuralNetwork.Copy()
+	single.TrainBatch(inputs[:1], targets[:1], 1, NewSGDOptimizer(0.5, 0))
+
+	sampler := NewWeightedSampler([]float32{1, 0}, 1)
+	err := neuralNetwork.TrainBatchSampler(inputs, targets, 1, 1, sampler, ConstantSchedule(0.5), NewSGDOptimizer(0.5, 0))
+	if err != nil {
+		t.Fatalf("Error in TrainBatchSampler: %s", err.Error())
+	}
+	weights := neuralNetwork.LayerAt(0).(*DenseLayer).Weights
+	solution := single.LayerAt(0).(*DenseLayer).Weights
+	if !weights.Equals(solution) {
+		t.Errorf("Weights after sampled training should be:\n%swhen result is:\n%s", solution.String(), weights.String())
+	}
+
+	err = neuralNetwork.TrainBatchSampler(inputs, targets, 1, 1, NewCurriculumSampler([]float32{1}), ConstantSchedule(0.5), NewSGDOptimizer(0.5, 0))
+	if err == nil {
+		t.Errorf("Sampler error did not trigger error")
+	}
+}
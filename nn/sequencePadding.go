@@ -0,0 +1,110 @@
+package nn
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SequenceBucket is a group of sequences of similar length padded to the longest of them, with the
+// index of each sequence in the original data and a mask of its padded timesteps.
+type SequenceBucket struct {
+	Length  int
+	Indices []int
+	Inputs  [][][][]float32
+	Masks   [][]bool
+}
+
+// PadSequences pads sequences with a row of the pad value for each missing timestep to a length,
+// or cuts off the timesteps of longer sequences, so they can be the inputs of a sequence layer. Each
+// sequence is a row of features for each timestep, and the padded sequences are inputs with a
+// single frame. It also returns a mask for each sequence, where the padded timesteps are true.
+// Padding with the mask value of a MaskingLayer lets recurrent layers skip the padding.
+func PadSequences(sequences [][][]float32, length int, padValue float32) ([][][][]float32, [][]bool, error) {
+	features, err := sequenceFeatures(sequences)
+	if err != nil {
+		return nil, nil, err
+	}
+	inputs := make([][][][]float32, len(sequences))
+	masks := make([][]bool, len(sequences))
+	for i, sequence := range sequences {
+		rows := make([][]float32, length)
+		masks[i] = make([]bool, length)
+		for timestep := range rows {
+			if timestep < len(sequence) {
+				rows[timestep] = append([]float32(nil), sequence[timestep]...)
+				continue
+			}
+			rows[timestep] = make([]float32, features)
+			for col := range rows[timestep] {
+				rows[timestep][col] = padValue
+			}
+			masks[i][timestep] = true
+		}
+		inputs[i] = [][][]float32{rows}
+	}
+	return inputs, masks, nil
+}
+
+// BucketSequences sorts sequences by length and groups them into buckets of up to a size, padding
+// each bucket only to the length of its longest sequence. Training on batches of similar length
+// wastes less time on padding than padding every sequence to the longest one. Sequences of the
+// same length keep their order.
+func BucketSequences(sequences [][][]float32, bucketSize int, padValue float32) ([]SequenceBucket, error) {
+	if bucketSize < 1 {
+		return nil, fmt.Errorf("Bucket size must be at least 1, is: %d", bucketSize)
+	}
+	_, err := sequenceFeatures(sequences)
+	if err != nil {
+		return nil, err
+	}
+	indices := make([]int, len(sequences))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i int, j int) bool {
+		return len(sequences[indices[i]]) < len(sequences[indices[j]])
+	})
+	buckets := []SequenceBucket{}
+	for start := 0; start < len(indices); start += bucketSize {
+		end := start + bucketSize
+		if end > len(indices) {
+			end = len(indices)
+		}
+		bucketIndices := indices[start:end]
+		length := len(sequences[bucketIndices[len(bucketIndices)-1]])
+		bucketSequences := make([][][]float32, len(bucketIndices))
+		for i, index := range bucketIndices {
+			bucketSequences[i] = sequences[index]
+		}
+		inputs, masks, err := PadSequences(bucketSequences, length, padValue)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, SequenceBucket{
+			Length:  length,
+			Indices: append([]int(nil), bucketIndices...),
+			Inputs:  inputs,
+			Masks:   masks,
+		})
+	}
+	return buckets, nil
+}
+
+// sequenceFeatures returns the number of features in every timestep of the sequences, which must be
+// the same.
+func sequenceFeatures(sequences [][][]float32) (int, error) {
+	features := -1
+	for i, sequence := range sequences {
+		for _, timestep := range sequence {
+			if features < 0 {
+				features = len(timestep)
+			} else if len(timestep) != features {
+				return 0, fmt.Errorf("Timesteps of sequence %d must have %d features, have: %d", i, features, len(timestep))
+			}
+		}
+	}
+	if features < 0 {
+		features = 0
+	}
+	return features, nil
+}
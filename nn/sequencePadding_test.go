@@ -0,0 +1,56 @@
+package nn
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPadSequences(t *testing.T) {
+	sequences := [][][]float32{
+		{{1, 2}},
+		{{3, 4}, {5, 6}, {7, 8}},
+	}
+	inputs, masks, err := PadSequences(sequences, 2, -1)
+	if err != nil {
+		t.Fatalf("Error in PadSequences: %s", err.Error())
+	}
+	solution := "[[[[1 2] [-1 -1]]] [[[3 4] [5 6]]]]"
+	if fmt.Sprint(inputs) != solution {
+		t.Errorf("Padded sequences should be %s, are: %v", solution, inputs)
+	}
+	if fmt.Sprint(masks) != "[[false true] [false false]]" {
+		t.Errorf("Masks should be [[false true] [false false]], are: %v", masks)
+	}
+
+	_, _, err = PadSequences([][][]float32{{{1, 2}}, {{1}}}, 2, 0)
+	if err == nil {
+		t.Errorf("Sequences with different numbers of features should have an error")
+	}
+}
+
+func TestBucketSequences(t *testing.T) {
+	sequences := [][][]float32{
+		{{1}, {1}, {1}, {1}},
+		{{2}},
+		{{3}, {3}, {3}},
+		{{4}, {4}},
+		{{5}},
+	}
+	buckets, err := BucketSequences(sequences, 2, 0)
+	if err != nil {
+		t.Fatalf("Error in BucketSequences: %s", err.Error())
+	}
+	if len(buckets) != 3 {
+		t.Fatalf("Should have 3 buckets, has: %d", len(buckets))
+	}
+	lengths := []int{buckets[0].Length, buckets[1].Length, buckets[2].Length}
+	if fmt.Sprint(lengths) != "[1 3 4]" {
+		t.Errorf("Bucket lengths should be [1 3 4], are: %v", lengths)
+	}
+	if fmt.Sprint(buckets[0].Indices, buckets[1].Indices, buckets[2].Indices) != "[1 4] [3 2] [0]" {
+		t.Errorf("Bucket indices should be [1 4] [3 2] [0], are: %v %v %v", buckets[0].Indices, buckets[1].Indices, buckets[2].Indices)
+	}
+	if fmt.Sprint(buckets[1].Inputs[0], buckets[1].Masks[0]) != "[[[4] [4] [0]]] [false false true]" {
+		t.Errorf("Shorter sequence of bucket should be padded, is: %v %v", buckets[1].Inputs[0], buckets[1].Masks[0])
+	}
+}
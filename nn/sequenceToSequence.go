@@ -0,0 +1,245 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	tsr "../tensor"
+)
+
+// SequenceToSequence is an encoder and decoder pair for tasks that turn one sequence of tokens into
+// another, such as translation or summarization. The encoder embeds the source tokens and reads
+// them with a recurrent layer, and its last state is the first state of the decoder. The decoder
+// embeds the target tokens read so far and scores every token of the target vocabulary as the next
+// one. Sources shorter than the source length are padded and masked, and targets can have up to
+// the target length of tokens before the end token.
+type SequenceToSequence struct {
+	SourceLength    int
+	TargetLength    int
+	StartToken      int
+	EndToken        int
+	sourceEmbedding *EmbeddingLayer
+	encoder         *RecurrentLayer
+	targetEmbedding *EmbeddingLayer
+	decoder         *RecurrentLayer
+	output          *TimeDistributedLayer
+}
+
+// NewSequenceToSequence creates a new instance of a sequence to sequence model. The start and end
+// tokens are tokens of the target vocabulary that begin the input of the decoder and end its
+// output.
+func NewSequenceToSequence(sourceLength int, targetLength int, sourceVocabulary int, targetVocabulary int, embeddingSize int, units int, startToken int, endToken int) (*SequenceToSequence, error) {
+	if sourceLength < 1 || targetLength < 1 {
+		return nil, fmt.Errorf("Source and target lengths must be at least 1, are: %d, %d", sourceLength, targetLength)
+	}
+	if sourceVocabulary < 1 || targetVocabulary < 2 {
+		return nil, fmt.Errorf("Vocabularies must have at least 1 source and 2 target tokens, have: %d, %d", sourceVocabulary, targetVocabulary)
+	}
+	if startToken < 0 || startToken >= targetVocabulary || endToken < 0 || endToken >= targetVocabulary {
+		return nil, fmt.Errorf("Start and end tokens must be in the target vocabulary, are: %d, %d", startToken, endToken)
+	}
+	decoderSteps := targetLength + 1
+	output, err := NewTimeDistributedLayer(decoderSteps, NewDenseLayer(units, targetVocabulary, ActivationLinear))
+	if err != nil {
+		return nil, err
+	}
+	return &SequenceToSequence{
+		SourceLength:    sourceLength,
+		TargetLength:    targetLength,
+		StartToken:      startToken,
+		EndToken:        endToken,
+		sourceEmbedding: NewEmbeddingLayer(sourceLength, sourceVocabulary, embeddingSize),
+		encoder:         NewRecurrentLayer(sourceLength, embeddingSize, units, ActivationTanh, false),
+		targetEmbedding: NewEmbeddingLayer(decoderSteps, targetVocabulary, embeddingSize),
+		decoder:         NewRecurrentLayer(decoderSteps, embeddingSize, units, ActivationTanh, true),
+		output:          output,
+	}, nil
+}
+
+// Train trains the model on pairs of source and target sequences with teacher forcing, so the
+// decoder reads the expected tokens rather than its own predictions. The parameters are updated
+// once with the average gradient of the pairs, and the average cross entropy of the predicted
+// tokens is returned.
+func (model *SequenceToSequence) Train(sources [][]int, targets [][]int, optimizer Optimizer) (float32, error) {
+	if len(sources) != len(targets) {
+		return 0, fmt.Errorf("Number of sources and targets must match: %d != %d", len(sources), len(targets))
+	}
+	if len(sources) == 0 {
+		return 0, nil
+	}
+	var totalLoss float32
+	for i := range sources {
+		loss, err := model.backPropagate(sources[i], targets[i])
+		if err != nil {
+			return 0, err
+		}
+		totalLoss += loss
+	}
+	for _, layer := range model.layers() {
+		parameters := layer.Parameters()
+		gradients := layer.Gradients()
+		if sparse, ok := layer.(sparseLayer); ok {
+			parameters, gradients = sparse.sparseParameters()
+		}
+		for i, parameter := range parameters {
+			gradients[i].Scale(1 / float32(len(sources)))
+			err := optimizer.Update(parameter, gradients[i], 1)
+			if err != nil {
+				return 0, err
+			}
+			gradients[i].Scale(0)
+		}
+	}
+	return totalLoss / float32(len(sources)), nil
+}
+
+// Decode translates a source sequence by choosing the most likely token at every step, and returns
+// the tokens without the end token.
+func (model *SequenceToSequence) Decode(source []int) ([]int, error) {
+	hypotheses, err := model.BeamDecode(source, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	return hypotheses[0].Tokens, nil
+}
+
+// BeamDecode translates a source sequence with a beam search over the predictions of the decoder,
+// and returns the hypotheses from best to worst score.
+func (model *SequenceToSequence) BeamDecode(source []int, beamWidth int, lengthPenalty float32) ([]BeamHypothesis, error) {
+	state, err := model.encode(source)
+	if err != nil {
+		return nil, err
+	}
+	step := func(sequence []int) ([]float32, error) {
+		scores, err := model.decode(state, sequence)
+		if err != nil {
+			return nil, err
+		}
+		return logSoftmax(scores.GetFrame(0)[len(sequence)-1]), nil
+	}
+	return BeamSearch(step, model.StartToken, model.EndToken, beamWidth, model.TargetLength+1, lengthPenalty)
+}
+
+// backPropagate adds the gradients of the loss of a pair of sequences to the gradients of the
+// layers, and returns the loss. The gradient of the first state of the decoder is passed back
+// through the encoder.
+func (model *SequenceToSequence) backPropagate(source []int, target []int) (float32, error) {
+	if len(target) > model.TargetLength {
+		return 0, fmt.Errorf("Target must have at most %d tokens, has: %d", model.TargetLength, len(target))
+	}
+	state, err := model.encode(source)
+	if err != nil {
+		return 0, err
+	}
+	scores, err := model.decode(state, append([]int{model.StartToken}, target...))
+	if err != nil {
+		return 0, err
+	}
+	expected := append(append([]int{}, target...), model.EndToken)
+	deltas := tsr.NewEmptyTensor2D(scores.Rows, scores.Cols)
+	var loss float32
+	for step, token := range expected {
+		logProbabilities := logSoftmax(scores.GetFrame(0)[step])
+		loss -= logProbabilities[token]
+		for col, logProbability := range logProbabilities {
+			delta := float32(math.Exp(float64(logProbability)))
+			if col == token {
+				delta--
+			}
+			deltas.Set(0, step, col, delta/float32(len(expected)))
+		}
+	}
+	deltas, err = model.output.BackPropagate(deltas)
+	if err != nil {
+		return 0, err
+	}
+	deltas, err = model.decoder.BackPropagate(deltas)
+	if err != nil {
+		return 0, err
+	}
+	_, err = model.targetEmbedding.BackPropagate(deltas)
+	if err != nil {
+		return 0, err
+	}
+	deltas, err = model.encoder.BackPropagate(model.decoder.initialStateDeltas)
+	if err != nil {
+		return 0, err
+	}
+	_, err = model.sourceEmbedding.BackPropagate(deltas)
+	if err != nil {
+		return 0, err
+	}
+	return loss / float32(len(expected)), nil
+}
+
+// encode feeds a source sequence through the encoder, and returns a copy of its last state.
+// Padded timesteps are masked, so the state is the state after the last token of the source.
+func (model *SequenceToSequence) encode(source []int) (*tsr.Tensor, error) {
+	if len(source) < 1 || len(source) > model.SourceLength {
+		return nil, fmt.Errorf("Source must have between 1 and %d tokens, has: %d", model.SourceLength, len(source))
+	}
+	indices := make([]float32, model.SourceLength)
+	mask := make([]bool, model.SourceLength)
+	for i := range indices {
+		if i < len(source) {
+			indices[i] = float32(source[i])
+		} else {
+			mask[i] = true
+		}
+	}
+	embedded, err := model.sourceEmbedding.FeedForward(tsr.NewValueTensor2D([][]float32{indices}))
+	if err != nil {
+		return nil, err
+	}
+	model.encoder.SetMask(mask)
+	state, err := model.encoder.FeedForward(embedded)
+	if err != nil {
+		return nil, err
+	}
+	return state.Copy(), nil
+}
+
+// decode feeds a sequence of target tokens through the decoder starting from an encoded state, and
+// returns the scores of the next token after each of them. The sequence is padded with the end
+// token, and the scores after the padding are not used.
+func (model *SequenceToSequence) decode(state *tsr.Tensor, tokens []int) (*tsr.Tensor, error) {
+	indices := make([]float32, model.TargetLength+1)
+	for i := range indices {
+		indices[i] = float32(model.EndToken)
+		if i < len(tokens) {
+			indices[i] = float32(tokens[i])
+		}
+	}
+	embedded, err := model.targetEmbedding.FeedForward(tsr.NewValueTensor2D([][]float32{indices}))
+	if err != nil {
+		return nil, err
+	}
+	states, err := model.decoder.feedForwardFrom(embedded, state)
+	if err != nil {
+		return nil, err
+	}
+	return model.output.FeedForward(states)
+}
+
+func (model *SequenceToSequence) layers() []Layer {
+	return []Layer{model.sourceEmbedding, model.encoder, model.targetEmbedding, model.decoder, model.output}
+}
+
+func logSoftmax(scores []float32) []float32 {
+	largest := scores[0]
+	for _, score := range scores {
+		if score > largest {
+			largest = score
+		}
+	}
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp(float64(score - largest))
+	}
+	logSum := largest + float32(math.Log(sum))
+	logProbabilities := make([]float32, len(scores))
+	for i, score := range scores {
+		logProbabilities[i] = score - logSum
+	}
+	return logProbabilities
+}
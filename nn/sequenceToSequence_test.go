@@ -0,0 +1,150 @@
+package nn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSequenceToSequenceReverse(t *testing.T) {
+	rand.Seed(1)
+	// Tokens 0 and 1 start and end the targets, and tokens 2 to 4 are the words of both sequences.
+	model, err := NewSequenceToSequence(3, 3, 5, 5, 6, 16, 0, 1)
+	if err != nil {
+		t.Fatalf("Error in NewSequenceToSequence: %s", err.Error())
+	}
+	sources := [][]int{}
+	targets := [][]int{}
+	for _, source := range [][]int{{2}, {3}, {4}, {2, 3}, {3, 4}, {4, 2}, {2, 4, 3}, {3, 2, 4}, {4, 3, 2}} {
+		target := make([]int, len(source))
+		for i, token := range source {
+			target[len(source)-1-i] = token
+		}
+		sources = append(sources, source)
+		targets = append(targets, target)
+	}
+
+	optimizer := NewAdamOptimizer(0.02)
+	var loss float32
+	for epoch := 0; epoch < 300; epoch++ {
+		loss, err = model.Train(sources, targets, optimizer)
+		if err != nil {
+			t.Fatalf("Error in Train: %s", err.Error())
+		}
+	}
+	if loss > 0.1 {
+		t.Errorf("Loss after training should be below 0.1, is: %.4f", loss)
+	}
+	for i, source := range sources {
+		decoded, err := model.Decode(source)
+		if err != nil {
+			t.Fatalf("Error in Decode: %s", err.Error())
+		}
+		if !equalTokens(decoded, targets[i]) {
+			t.Errorf("Decoded sequence of %v should be: %v, is: %v", source, targets[i], decoded)
+		}
+	}
+
+	hypotheses, err := model.BeamDecode([]int{2, 3}, 3, 1)
+	if err != nil {
+		t.Fatalf("Error in BeamDecode: %s", err.Error())
+	}
+	if len(hypotheses) != 3 || !equalTokens(hypotheses[0].Tokens, []int{3, 2}) {
+		t.Errorf("Best of 3 hypotheses should be: [3 2], are: %v", hypotheses)
+	}
+}
+
+func TestSequenceToSequenceGradients(t *testing.T) {
+	rand.Seed(1)
+	model, err := NewSequenceToSequence(3, 2, 4, 4, 3, 4, 0, 1)
+	if err != nil {
+		t.Fatalf("Error in NewSequenceToSequence: %s", err.Error())
+	}
+	source := []int{3, 2}
+	target := []int{2, 3}
+	_, err = model.backPropagate(source, target)
+	if err != nil {
+		t.Fatalf("Error in backPropagate: %s", err.Error())
+	}
+
+	// The gradients of the encoder come only from the first state of the decoder, so they check
+	// that the gradient of that state is passed back.
+	loss := func() float64 {
+		state, _ := model.encode(source)
+		scores, _ := model.decode(state, append([]int{model.StartToken}, target...))
+		sum := 0.0
+		for step, token := range append(append([]int{}, target...), model.EndToken) {
+			sum -= float64(logSoftmax(scores.GetFrame(0)[step])[token])
+		}
+		return sum / float64(len(target)+1)
+	}
+	for i, parameter := range model.encoder.Parameters() {
+		gradient := model.encoder.Gradients()[i]
+		for row := 0; row < parameter.Rows; row++ {
+			for col := 0; col < parameter.Cols; col++ {
+				value := parameter.Get(0, row, col)
+				parameter.Set(0, row, col, value+0.005)
+				plus := loss()
+				parameter.Set(0, row, col, value-0.005)
+				minus := loss()
+				parameter.Set(0, row, col, value)
+				numerical := (plus - minus) / 0.01
+				if math.Abs(numerical-float64(gradient.Get(0, row, col))) > 1e-3 {
+					t.Errorf(
+						"Gradient of encoder parameter %d at (%d, %d) should be: %.5f, is: %.5f",
+						i, row, col, numerical, gradient.Get(0, row, col),
+					)
+				}
+			}
+		}
+	}
+}
+
+func TestSequenceToSequenceInvalid(t *testing.T) {
+	_, err := NewSequenceToSequence(0, 3, 5, 5, 4, 4, 0, 1)
+	if err == nil {
+		t.Errorf("Source length of 0 did not trigger error")
+	}
+	_, err = NewSequenceToSequence(3, 3, 5, 5, 4, 4, 0, 5)
+	if err == nil {
+		t.Errorf("End token outside of the target vocabulary did not trigger error")
+	}
+
+	model, err := NewSequenceToSequence(3, 2, 5, 5, 4, 4, 0, 1)
+	if err != nil {
+		t.Fatalf("Error in NewSequenceToSequence: %s", err.Error())
+	}
+	optimizer := NewSGDOptimizer(0.1, 0)
+	_, err = model.Train([][]int{{2, 3, 4, 2}}, [][]int{{2}}, optimizer)
+	if err == nil {
+		t.Errorf("Source longer than the source length did not trigger error")
+	}
+	_, err = model.Train([][]int{{2}}, [][]int{{2, 3, 4}}, optimizer)
+	if err == nil {
+		t.Errorf("Target longer than the target length did not trigger error")
+	}
+	_, err = model.Train([][]int{{2}}, [][]int{{7}}, optimizer)
+	if err == nil {
+		t.Errorf("Target outside of the vocabulary did not trigger error")
+	}
+	_, err = model.Train([][]int{{2}}, nil, optimizer)
+	if err == nil {
+		t.Errorf("Mismatched sources and targets did not trigger error")
+	}
+	_, err = model.Decode(nil)
+	if err == nil {
+		t.Errorf("Empty source did not trigger error")
+	}
+}
+
+func equalTokens(first []int, second []int) bool {
+	if len(first) != len(second) {
+		return false
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			return false
+		}
+	}
+	return true
+}
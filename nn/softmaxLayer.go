@@ -0,0 +1,124 @@
+package nn
+
+import (
+	"encoding/json"
+
+	tsr "../tensor"
+)
+
+// SoftmaxLayer turns the scores of the inputs into a probability distribution. Unlike a dense
+// layer with a softmax activation, it back propagates the full gradient of the softmax, and when
+// it is the last layer of a neural network with a cross entropy loss, the gradient of its inputs is
+// computed directly from the probabilities and targets, which avoids dividing by small
+// probabilities.
+type SoftmaxLayer struct {
+	inputShape  LayerShape
+	outputShape LayerShape
+	outputs     *tsr.Tensor
+}
+
+// NewSoftmaxLayer creates a new instance of a softmax layer for a number of classes.
+func NewSoftmaxLayer(size int) *SoftmaxLayer {
+	return &SoftmaxLayer{
+		inputShape:  LayerShape{1, size, 1},
+		outputShape: LayerShape{1, size, 1},
+		outputs:     tsr.NewEmptyTensor1D(size),
+	}
+}
+
+// Copy creates a deep copy of the layer.
+func (layer *SoftmaxLayer) Copy() Layer {
+	return NewSoftmaxLayer(layer.inputShape.Cols)
+}
+
+// InputShape returns the rows, columns and frames of the inputs to the layer.
+func (layer *SoftmaxLayer) InputShape() LayerShape {
+	return layer.inputShape
+}
+
+// OutputShape returns the rows, columns and frames of outputs from the layer.
+func (layer *SoftmaxLayer) OutputShape() LayerShape {
+	return layer.outputShape
+}
+
+// FeedForward computes the probability of each class from the scores of the inputs.
+func (layer *SoftmaxLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := layer.outputs.SetTensor(inputs)
+	if err != nil {
+		return nil, err
+	}
+	ActivationSoftmax.Function(layer.outputs)
+	return layer.outputs, nil
+}
+
+// BackPropagate multiplies the deltas by the Jacobian of the softmax, which for each input is its
+// probability times the difference between its delta and the delta averaged over the probabilities.
+func (layer *SoftmaxLayer) BackPropagate(outputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := checkShape(outputs, layer.outputShape, "delta")
+	if err != nil {
+		return nil, err
+	}
+	average := float32(0.0)
+	outputs.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		average += current * layer.outputs.Get(frame, row, col)
+		return current
+	})
+	nextDeltas := layer.outputs.Copy()
+	nextDeltas.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return current * (outputs.Get(frame, row, col) - average)
+	})
+	return nextDeltas, nil
+}
+
+// crossEntropyDeltas returns the gradient of the cross entropy loss with respect to the inputs of
+// the layer, which is the probabilities scaled by the sum of the targets minus the targets, or the
+// probabilities minus the targets when the targets add up to 1.
+func (layer *SoftmaxLayer) crossEntropyDeltas(targets *tsr.Tensor) (*tsr.Tensor, error) {
+	deltas := layer.outputs.Copy()
+	deltas.Scale(targets.Sum())
+	err := deltas.SubtractTensor(targets)
+	if err != nil {
+		return nil, err
+	}
+	return deltas, nil
+}
+
+// Parameters returns an empty list, since the layer has no parameters.
+func (layer *SoftmaxLayer) Parameters() []*tsr.Tensor {
+	return []*tsr.Tensor{}
+}
+
+// Gradients returns an empty list, since the layer has no parameters.
+func (layer *SoftmaxLayer) Gradients() []*tsr.Tensor {
+	return []*tsr.Tensor{}
+}
+
+// SoftmaxLayerData represents a serialized layer that can be saved to a file.
+type SoftmaxLayerData struct {
+	Type LayerType `json:"type"`
+	Size int       `json:"size"`
+}
+
+// MarshalJSON converts the layer to JSON.
+func (layer *SoftmaxLayer) MarshalJSON() ([]byte, error) {
+	data := SoftmaxLayerData{
+		Type: LayerTypeSoftmax,
+		Size: layer.inputShape.Cols,
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new layer from JSON.
+func (layer *SoftmaxLayer) UnmarshalJSON(b []byte) error {
+	data := SoftmaxLayerData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	err = checkLoadedShape(LayerShape{1, data.Size, 1})
+	if err != nil {
+		return err
+	}
+	*layer = *NewSoftmaxLayer(data.Size)
+	return nil
+}
@@ -0,0 +1,110 @@
+package nn
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestSoftmaxLayerFeedForward(t *testing.T) {
+	layer := NewSoftmaxLayer(3)
+
+	// Large scores would overflow without subtracting the largest score first.
+	result, err := layer.FeedForward(tsr.NewValueTensor1D([]float32{1000, 1000, 1000 + float32(math.Log(2))}))
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+	solution := []float32{0.25, 0.25, 0.5}
+	for col, value := range solution {
+		if math.Abs(float64(result.Get(0, 0, col)-value)) > 1e-5 {
+			t.Errorf("Softmax layer output %d should be: %.4f, is: %.4f", col, value, result.Get(0, 0, col))
+		}
+	}
+}
+
+func TestSoftmaxLayerBackPropagate(t *testing.T) {
+	rand.Seed(1)
+	layer := NewSoftmaxLayer(4)
+	inputs := tsr.NewEmptyTensor1D(4)
+	inputs.SetRandom(-2, 2)
+	deltas := tsr.NewEmptyTensor1D(4)
+	deltas.SetRandom(-1, 1)
+
+	_, err := layer.FeedForward(inputs)
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+	inputDeltas, err := layer.BackPropagate(deltas)
+	if err != nil {
+		t.Fatalf("Error in BackPropagate: %s", err.Error())
+	}
+
+	weightedOutputs := func() float64 {
+		outputs, _ := layer.FeedForward(inputs)
+		sum := 0.0
+		outputs.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			sum += float64(current * deltas.Get(frame, row, col))
+			return current
+		})
+		return sum
+	}
+	inputDeltas.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		epsilon := float32(0.01)
+		value := inputs.Get(frame, row, col)
+		inputs.Set(frame, row, col, value+epsilon)
+		plus := weightedOutputs()
+		inputs.Set(frame, row, col, value-epsilon)
+		minus := weightedOutputs()
+		inputs.Set(frame, row, col, value)
+		solution := float32((plus - minus) / float64(2*epsilon))
+		if math.Abs(float64(current-solution)) > 1e-3 {
+			t.Errorf("Input delta %d should be %.4f, is: %.4f", col, solution, current)
+		}
+		return current
+	})
+}
+
+func TestSoftmaxLayerCrossEntropy(t *testing.T) {
+	rand.Seed(1)
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 3, ActivationLinear), NewSoftmaxLayer(3))
+	neuralNetwork.SetLoss(LossCrossEntropy)
+	dense := neuralNetwork.LayerAt(0).(*DenseLayer)
+
+	inputs := [][][]float32{{{0.5, -1}}}
+	targets := [][][]float32{{{0, 1, 0}}}
+	outputs, err := neuralNetwork.Predict(inputs)
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	_, err = neuralNetwork.backPropagate(inputs, targets)
+	if err != nil {
+		t.Fatalf("Error in backPropagate: %s", err.Error())
+	}
+
+	// The gradient of the scores is the probabilities minus the targets.
+	for col := 0; col < 3; col++ {
+		solution := outputs[0][0][col] - targets[0][0][col]
+		if math.Abs(float64(dense.biasGradients.Get(0, 0, col)-solution)) > 1e-5 {
+			t.Errorf("Bias gradient %d should be: %.4f, is: %.4f", col, solution, dense.biasGradients.Get(0, 0, col))
+		}
+	}
+}
+
+func TestSoftmaxLayerJSON(t *testing.T) {
+	layer := NewSoftmaxLayer(5)
+	data, err := json.Marshal(layer)
+	if err != nil {
+		t.Fatalf("Error in MarshalJSON: %s", err.Error())
+	}
+	loaded, err := unmarshalLayer(data)
+	if err != nil {
+		t.Fatalf("Error in UnmarshalJSON: %s", err.Error())
+	}
+	if loaded.InputShape() != layer.InputShape() || loaded.OutputShape() != layer.OutputShape() {
+		t.Errorf("Loaded layer shapes do not match original")
+	}
+}
@@ -0,0 +1,41 @@
+package nn
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+)
+
+// Summary describes the layers of the neural network in a table, with the type, input shape,
+// output shape and number of parameters of each layer, followed by the total number of parameters
+// and the number that are trained. Parameters of layers frozen with a learning rate scale of 0 are
+// not trained. Shapes are written as (rows, columns, frames).
+func (neuralNetwork *NeuralNetwork) Summary() string {
+	buffer := bytes.Buffer{}
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Layer\tType\tInput Shape\tOutput Shape\tParameters")
+	total := 0
+	trainable := 0
+	for i, layer := range neuralNetwork.layers {
+		count := 0
+		for _, parameter := range layer.Parameters() {
+			count += parameter.Frames * parameter.Rows * parameter.Cols
+		}
+		total += count
+		if neuralNetwork.learningRateScales[i] > 0 {
+			trainable += count
+		}
+		fmt.Fprintf(
+			writer, "%d\t%s\t%s\t%s\t%d\n",
+			i, typeOfLayer(layer), shapeString(layer.InputShape()), shapeString(layer.OutputShape()), count,
+		)
+	}
+	writer.Flush()
+	fmt.Fprintf(&buffer, "Total parameters: %d\n", total)
+	fmt.Fprintf(&buffer, "Trainable parameters: %d\n", trainable)
+	return buffer.String()
+}
+
+func shapeString(shape LayerShape) string {
+	return fmt.Sprintf("(%d, %d, %d)", shape.Rows, shape.Cols, shape.Frames)
+}
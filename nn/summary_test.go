@@ -0,0 +1,38 @@
+package nn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	convolutionLayer, _ := NewRandomConvolutionLayer(4, 4, 1, 2, 3, ActivationRELU)
+	neuralNetwork.Add(
+		convolutionLayer,
+		NewPoolingLayer(4, 4, 2, 2, PoolingMax),
+		NewFlattenLayer(2, 2, 2),
+		NewDenseLayer(8, 3, ActivationSigmoid),
+	)
+	neuralNetwork.SetLearningRateScale(0, 0)
+
+	summary := neuralNetwork.Summary()
+	lines := strings.Split(strings.TrimSpace(summary), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("Summary should have 7 lines, has: %d\n%s", len(lines), summary)
+	}
+	expected := [][]string{
+		{"0", "convolution", "(4, 4, 1)", "(4, 4, 2)", "20"},
+		{"3", "dense", "(1, 8, 1)", "(1, 3, 1)", "27"},
+	}
+	for i, line := range []string{lines[1], lines[4]} {
+		for _, part := range expected[i] {
+			if !strings.Contains(line, part) {
+				t.Errorf("Summary line should contain %s, is: %s", part, line)
+			}
+		}
+	}
+	if lines[5] != "Total parameters: 47" || lines[6] != "Trainable parameters: 27" {
+		t.Errorf("Summary should have 47 parameters with 27 trainable, has:\n%s", summary)
+	}
+}
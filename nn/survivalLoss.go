@@ -0,0 +1,95 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	tsr "../tensor"
+)
+
+// CoxLoss computes the negative partial log-likelihood of the Cox proportional hazards model for
+// the risk scores of a batch of samples, with the time of each sample and whether its event was
+// observed or the sample was censored at that time. Each observed event compares the risk of its
+// sample to the risks of every sample still at risk at its time, and the loss is averaged over the
+// events. It returns the loss and its gradient with respect to each risk score.
+func CoxLoss(risks []float32, times []float32, events []bool) (float32, []float32, error) {
+	if len(risks) != len(times) || len(risks) != len(events) {
+		return 0, nil, fmt.Errorf("Number of risks, times and events must match: %d, %d, %d", len(risks), len(times), len(events))
+	}
+	// Subtracting the largest risk keeps the exponents from overflowing, and cancels out of the loss.
+	max := math.Inf(-1)
+	for _, risk := range risks {
+		max = math.Max(max, float64(risk))
+	}
+	hazards := make([]float64, len(risks))
+	for i, risk := range risks {
+		hazards[i] = math.Exp(float64(risk) - max)
+	}
+	observed := 0
+	loss := 0.0
+	gradients := make([]float32, len(risks))
+	for i := range risks {
+		if !events[i] {
+			continue
+		}
+		observed++
+		atRisk := 0.0
+		for j := range risks {
+			if times[j] >= times[i] {
+				atRisk += hazards[j]
+			}
+		}
+		loss -= float64(risks[i]) - max - math.Log(atRisk)
+		gradients[i]--
+		for j := range risks {
+			if times[j] >= times[i] {
+				gradients[j] += float32(hazards[j] / atRisk)
+			}
+		}
+	}
+	if observed == 0 {
+		return 0, nil, fmt.Errorf("Must have at least 1 observed event")
+	}
+	for i := range gradients {
+		gradients[i] /= float32(observed)
+	}
+	return float32(loss / float64(observed)), gradients, nil
+}
+
+// TrainSurvival trains a neural network with a single output, the risk score of a sample, on a batch
+// of samples with the time of each sample and whether its event was observed, using the CoxLoss
+// of the batch. The whole batch is needed to compare risks, so it makes a single optimizer step
+// and returns the loss.
+func (neuralNetwork *NeuralNetwork) TrainSurvival(inputs [][][][]float32, times []float32, events []bool, optimizer Optimizer) (float32, error) {
+	if len(inputs) != len(times) {
+		return 0, fmt.Errorf("Number of inputs and times must match: %d != %d", len(inputs), len(times))
+	}
+	risks := make([]float32, len(inputs))
+	for i, input := range inputs {
+		outputs, err := neuralNetwork.feedForward(input)
+		if err != nil {
+			return 0, err
+		}
+		if outputs.Frames*outputs.Rows*outputs.Cols != 1 {
+			return 0, fmt.Errorf("Neural network must have a single output for the risk score")
+		}
+		risks[i] = outputs.Get(0, 0, 0)
+	}
+	loss, gradients, err := CoxLoss(risks, times, events)
+	if err != nil {
+		return 0, err
+	}
+	// The layers keep the values of the last feed forward, so each sample is fed forward again
+	// before back propagating its gradient.
+	for i, input := range inputs {
+		_, err := neuralNetwork.feedForward(input)
+		if err != nil {
+			return 0, err
+		}
+		err = neuralNetwork.backPropagateDeltas(tsr.NewValueTensor1D([]float32{gradients[i]}))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return loss, neuralNetwork.applyGradients(optimizer, 1)
+}
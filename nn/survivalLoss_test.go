@@ -0,0 +1,76 @@
+package nn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCoxLoss(t *testing.T) {
+	risks := []float32{0.5, -0.2, 1.0, 0.3}
+	times := []float32{2, 5, 1, 3}
+	events := []bool{true, false, true, true}
+
+	loss, gradients, err := CoxLoss(risks, times, events)
+	if err != nil {
+		t.Fatalf("Error in CoxLoss: %s", err.Error())
+	}
+
+	// The loss of each event is the log of the sum of the hazards at risk minus its own risk.
+	exp := func(value float32) float64 { return math.Exp(float64(value)) }
+	solution := (math.Log(exp(1.0)+exp(0.5)+exp(-0.2)+exp(0.3)) - 1.0 +
+		math.Log(exp(0.5)+exp(-0.2)+exp(0.3)) - 0.5 +
+		math.Log(exp(-0.2)+exp(0.3)) - 0.3) / 3
+	if math.Abs(float64(loss)-solution) > 1e-5 {
+		t.Errorf("Cox loss should be %f, is: %f", solution, loss)
+	}
+
+	// The gradient should match the change in loss from a small change in each risk.
+	for i := range risks {
+		step := float32(1e-3)
+		above := append([]float32(nil), risks...)
+		above[i] += step
+		below := append([]float32(nil), risks...)
+		below[i] -= step
+		lossAbove, _, _ := CoxLoss(above, times, events)
+		lossBelow, _, _ := CoxLoss(below, times, events)
+		numerical := (lossAbove - lossBelow) / (2 * step)
+		if math.Abs(float64(numerical-gradients[i])) > 1e-3 {
+			t.Errorf("Gradient of risk %d should be %f, is: %f", i, numerical, gradients[i])
+		}
+	}
+
+	_, _, err = CoxLoss(risks, times, []bool{false, false, false, false})
+	if err == nil {
+		t.Errorf("Batch without events should have an error")
+	}
+}
+
+func TestTrainSurvival(t *testing.T) {
+	rand.Seed(1)
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationLinear))
+
+	// The hazard grows with the first feature and does not depend on the second.
+	inputs := make([][][][]float32, 64)
+	times := make([]float32, 64)
+	events := make([]bool, 64)
+	for i := range inputs {
+		x1 := rand.Float32()*2 - 1
+		x2 := rand.Float32()*2 - 1
+		inputs[i] = [][][]float32{{{x1, x2}}}
+		times[i] = float32(rand.ExpFloat64() / math.Exp(2*float64(x1)))
+		events[i] = rand.Float32() < 0.8
+	}
+	optimizer := NewAdamOptimizer(0.05)
+	for epoch := 0; epoch < 100; epoch++ {
+		_, err := neuralNetwork.TrainSurvival(inputs, times, events, optimizer)
+		if err != nil {
+			t.Fatalf("Error in TrainSurvival: %s", err.Error())
+		}
+	}
+	weights := neuralNetwork.LayerAt(0).(*DenseLayer).Weights
+	if weights.Get(0, 0, 0) < 1 || math.Abs(float64(weights.Get(0, 1, 0))) > 0.5 {
+		t.Errorf("Weights should be near 2 for the first feature and 0 for the second, are:\n%s", weights.String())
+	}
+}
@@ -0,0 +1,201 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// MakeBlobs creates a dataset of samples drawn from normal distributions with a standard deviation
+// around a number of random centers between -10 and 10, with a number of features. The targets are
+// one-hot vectors of the center of each sample. The samples are spread evenly over the centers and
+// shuffled, and the same seed always makes the same dataset.
+func MakeBlobs(samples int, centers int, features int, spread float32, seed int64) (*Dataset, error) {
+	if samples < 1 || centers < 1 || features < 1 {
+		return nil, fmt.Errorf("Number of samples, centers and features must be at least 1, are: %d, %d, %d", samples, centers, features)
+	}
+	random := rand.New(rand.NewSource(seed))
+	positions := make([][]float32, centers)
+	for i := range positions {
+		positions[i] = make([]float32, features)
+		for j := range positions[i] {
+			positions[i][j] = random.Float32()*20 - 10
+		}
+	}
+	inputs := make([][]float32, samples)
+	classes := make([]int, samples)
+	for i := range inputs {
+		classes[i] = i % centers
+		inputs[i] = make([]float32, features)
+		for j := range inputs[i] {
+			inputs[i][j] = positions[classes[i]][j] + float32(random.NormFloat64())*spread
+		}
+	}
+	return syntheticDataset(inputs, oneHotTargets(classes, centers), random), nil
+}
+
+// MakeMoons creates a dataset of two interleaving half circles in two features, with normally
+// distributed noise of a standard deviation added to each feature. The target of each sample is 0
+// for the upper half circle and 1 for the lower.
+func MakeMoons(samples int, noise float32, seed int64) (*Dataset, error) {
+	if samples < 2 {
+		return nil, fmt.Errorf("Number of samples must be at least 2, is: %d", samples)
+	}
+	random := rand.New(rand.NewSource(seed))
+	inputs := make([][]float32, samples)
+	targets := make([][]float32, samples)
+	upper := (samples + 1) / 2
+	for i := range inputs {
+		if i < upper {
+			angle := math.Pi * spacing(i, upper)
+			inputs[i] = []float32{float32(math.Cos(angle)), float32(math.Sin(angle))}
+			targets[i] = []float32{0}
+		} else {
+			angle := math.Pi * spacing(i-upper, samples-upper)
+			inputs[i] = []float32{float32(1 - math.Cos(angle)), float32(0.5 - math.Sin(angle))}
+			targets[i] = []float32{1}
+		}
+		addNoise(inputs[i], noise, random)
+	}
+	return syntheticDataset(inputs, targets, random), nil
+}
+
+// MakeCircles creates a dataset of a large circle of radius 1 around a smaller circle, whose
+// radius is a factor between 0 and 1 of the larger one, in two features, with normally distributed
+// noise of a standard deviation added to each feature. The target of each sample is 0 for the outer
+// circle and 1 for the inner.
+func MakeCircles(samples int, factor float32, noise float32, seed int64) (*Dataset, error) {
+	if samples < 2 {
+		return nil, fmt.Errorf("Number of samples must be at least 2, is: %d", samples)
+	}
+	if factor <= 0 || factor >= 1 {
+		return nil, fmt.Errorf("Factor must be between 0 and 1, is: %f", factor)
+	}
+	random := rand.New(rand.NewSource(seed))
+	inputs := make([][]float32, samples)
+	targets := make([][]float32, samples)
+	outer := (samples + 1) / 2
+	for i := range inputs {
+		radius := float32(1.0)
+		angle := 2 * math.Pi * float64(i) / float64(outer)
+		targets[i] = []float32{0}
+		if i >= outer {
+			radius = factor
+			angle = 2 * math.Pi * float64(i-outer) / float64(samples-outer)
+			targets[i] = []float32{1}
+		}
+		inputs[i] = []float32{radius * float32(math.Cos(angle)), radius * float32(math.Sin(angle))}
+		addNoise(inputs[i], noise, random)
+	}
+	return syntheticDataset(inputs, targets, random), nil
+}
+
+// MakeRegression creates a dataset of random features from a standard normal distribution, with
+// targets that are a linear combination of the features plus normally distributed noise of a
+// standard deviation. It also returns the random coefficients of the linear combination, so a
+// model can be checked against them.
+func MakeRegression(samples int, features int, noise float32, seed int64) (*Dataset, []float32, error) {
+	if samples < 1 || features < 1 {
+		return nil, nil, fmt.Errorf("Number of samples and features must be at least 1, are: %d, %d", samples, features)
+	}
+	random := rand.New(rand.NewSource(seed))
+	coefficients := make([]float32, features)
+	for i := range coefficients {
+		coefficients[i] = random.Float32()*2 - 1
+	}
+	inputs := make([][]float32, samples)
+	targets := make([][]float32, samples)
+	for i := range inputs {
+		inputs[i] = make([]float32, features)
+		target := float32(random.NormFloat64()) * noise
+		for j := range inputs[i] {
+			inputs[i][j] = float32(random.NormFloat64())
+			target += coefficients[j] * inputs[i][j]
+		}
+		targets[i] = []float32{target}
+	}
+	return syntheticDataset(inputs, targets, random), coefficients, nil
+}
+
+// MakeXOR creates a dataset of points around the four corners of the unit square, with normally
+// distributed noise of a standard deviation added to each feature. The target of each sample is the
+// exclusive or of its corner, which is 1 when exactly one of its features is 1.
+func MakeXOR(samples int, noise float32, seed int64) (*Dataset, error) {
+	if samples < 1 {
+		return nil, fmt.Errorf("Number of samples must be at least 1, is: %d", samples)
+	}
+	random := rand.New(rand.NewSource(seed))
+	inputs := make([][]float32, samples)
+	targets := make([][]float32, samples)
+	for i := range inputs {
+		a, b := i%2, (i/2)%2
+		inputs[i] = []float32{float32(a), float32(b)}
+		targets[i] = []float32{float32(a ^ b)}
+		addNoise(inputs[i], noise, random)
+	}
+	return syntheticDataset(inputs, targets, random), nil
+}
+
+// MakeSpirals creates a dataset of a number of spiral arms that wind around the origin in two
+// features, with normally distributed noise of a standard deviation added to each feature. The
+// targets are one-hot vectors of the arm of each sample.
+func MakeSpirals(samples int, arms int, noise float32, seed int64) (*Dataset, error) {
+	if samples < 1 || arms < 1 {
+		return nil, fmt.Errorf("Number of samples and arms must be at least 1, are: %d, %d", samples, arms)
+	}
+	random := rand.New(rand.NewSource(seed))
+	inputs := make([][]float32, samples)
+	classes := make([]int, samples)
+	perArm := (samples + arms - 1) / arms
+	for i := range inputs {
+		classes[i] = i % arms
+		// Each arm goes from the origin to a radius of 1 over one and a half turns.
+		progress := spacing(i/arms, perArm)
+		angle := 3*math.Pi*progress + 2*math.Pi*float64(classes[i])/float64(arms)
+		inputs[i] = []float32{float32(progress * math.Cos(angle)), float32(progress * math.Sin(angle))}
+		addNoise(inputs[i], noise, random)
+	}
+	return syntheticDataset(inputs, oneHotTargets(classes, arms), random), nil
+}
+
+// spacing returns the fraction of the way the index is through a number of evenly spaced points
+// from 0 to 1.
+func spacing(index int, count int) float64 {
+	if count < 2 {
+		return 0
+	}
+	return float64(index) / float64(count-1)
+}
+
+func addNoise(values []float32, noise float32, random *rand.Rand) {
+	for i := range values {
+		values[i] += float32(random.NormFloat64()) * noise
+	}
+}
+
+func oneHotTargets(classes []int, numClasses int) [][]float32 {
+	targets := make([][]float32, len(classes))
+	for i, class := range classes {
+		targets[i] = make([]float32, numClasses)
+		targets[i][class] = 1
+	}
+	return targets
+}
+
+// syntheticDataset shuffles the rows of inputs and targets and makes a dataset with a single row
+// for each sample.
+func syntheticDataset(inputs [][]float32, targets [][]float32, random *rand.Rand) *Dataset {
+	random.Shuffle(len(inputs), func(i, j int) {
+		inputs[i], inputs[j] = inputs[j], inputs[i]
+		targets[i], targets[j] = targets[j], targets[i]
+	})
+	dataset := &Dataset{
+		Inputs:  make([][][][]float32, len(inputs)),
+		Targets: make([][][][]float32, len(targets)),
+	}
+	for i := range inputs {
+		dataset.Inputs[i] = [][][]float32{{inputs[i]}}
+		dataset.Targets[i] = [][][]float32{{targets[i]}}
+	}
+	return dataset
+}
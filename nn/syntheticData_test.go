@@ -0,0 +1,120 @@
+package nn
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMakeBlobs(t *testing.T) {
+	dataset, err := MakeBlobs(30, 3, 4, 0.1, 1)
+	if err != nil {
+		t.Fatalf("Error in MakeBlobs: %s", err.Error())
+	}
+	if dataset.Len() != 30 || len(dataset.Inputs[0][0][0]) != 4 || len(dataset.Targets[0][0][0]) != 3 {
+		t.Fatalf("Dataset should have 30 samples of 4 features and 3 targets")
+	}
+	counts := make([]int, 3)
+	for i := range dataset.Targets {
+		counts[argmax(dataset.Targets[i][0][0])]++
+	}
+	if !reflect.DeepEqual(counts, []int{10, 10, 10}) {
+		t.Errorf("Samples should be spread evenly over the centers, are: %v", counts)
+	}
+
+	same, _ := MakeBlobs(30, 3, 4, 0.1, 1)
+	if !reflect.DeepEqual(dataset, same) {
+		t.Errorf("Datasets with the same seed should be the same")
+	}
+	different, _ := MakeBlobs(30, 3, 4, 0.1, 2)
+	if reflect.DeepEqual(dataset, different) {
+		t.Errorf("Datasets with different seeds should be different")
+	}
+
+	_, err = MakeBlobs(30, 0, 4, 0.1, 1)
+	if err == nil {
+		t.Errorf("Did not trigger error on 0 centers")
+	}
+}
+
+func TestMakeMoonsAndCircles(t *testing.T) {
+	moons, err := MakeMoons(20, 0, 1)
+	if err != nil {
+		t.Fatalf("Error in MakeMoons: %s", err.Error())
+	}
+	for i := range moons.Inputs {
+		x, y := float64(moons.Inputs[i][0][0][0]), float64(moons.Inputs[i][0][0][1])
+		if moons.Targets[i][0][0][0] == 1 {
+			x, y = 1-x, 0.5-y
+		}
+		if math.Abs(math.Hypot(x, y)-1) > 1e-5 || y < -1e-5 {
+			t.Fatalf("Sample without noise should be on its half circle: %v", moons.Inputs[i][0][0])
+		}
+	}
+
+	circles, err := MakeCircles(20, 0.5, 0, 1)
+	if err != nil {
+		t.Fatalf("Error in MakeCircles: %s", err.Error())
+	}
+	for i := range circles.Inputs {
+		radius := math.Hypot(float64(circles.Inputs[i][0][0][0]), float64(circles.Inputs[i][0][0][1]))
+		expected := 1.0
+		if circles.Targets[i][0][0][0] == 1 {
+			expected = 0.5
+		}
+		if math.Abs(radius-expected) > 1e-5 {
+			t.Fatalf("Sample without noise should have radius %f, has: %f", expected, radius)
+		}
+	}
+
+	_, err = MakeCircles(20, 1.5, 0, 1)
+	if err == nil {
+		t.Errorf("Did not trigger error on factor greater than 1")
+	}
+}
+
+func TestMakeRegression(t *testing.T) {
+	dataset, coefficients, err := MakeRegression(10, 3, 0, 1)
+	if err != nil {
+		t.Fatalf("Error in MakeRegression: %s", err.Error())
+	}
+	if len(coefficients) != 3 {
+		t.Fatalf("There should be 3 coefficients, are: %d", len(coefficients))
+	}
+	for i := range dataset.Inputs {
+		expected := float32(0)
+		for j, value := range dataset.Inputs[i][0][0] {
+			expected += coefficients[j] * value
+		}
+		if math.Abs(float64(dataset.Targets[i][0][0][0]-expected)) > 1e-5 {
+			t.Errorf("Target without noise should be %f, is: %f", expected, dataset.Targets[i][0][0][0])
+		}
+	}
+}
+
+func TestMakeXORAndSpirals(t *testing.T) {
+	xor, err := MakeXOR(8, 0, 1)
+	if err != nil {
+		t.Fatalf("Error in MakeXOR: %s", err.Error())
+	}
+	for i := range xor.Inputs {
+		a, b := xor.Inputs[i][0][0][0], xor.Inputs[i][0][0][1]
+		if (a != b) != (xor.Targets[i][0][0][0] == 1) {
+			t.Errorf("Target of %v should be its exclusive or, is: %f", xor.Inputs[i][0][0], xor.Targets[i][0][0][0])
+		}
+	}
+
+	spirals, err := MakeSpirals(30, 3, 0.01, 1)
+	if err != nil {
+		t.Fatalf("Error in MakeSpirals: %s", err.Error())
+	}
+	if spirals.Len() != 30 || len(spirals.Targets[0][0][0]) != 3 {
+		t.Fatalf("Dataset should have 30 samples with 3 targets")
+	}
+	for i := range spirals.Inputs {
+		radius := math.Hypot(float64(spirals.Inputs[i][0][0][0]), float64(spirals.Inputs[i][0][0][1]))
+		if radius > 1.1 {
+			t.Errorf("Samples should be within the unit circle, radius is: %f", radius)
+		}
+	}
+}
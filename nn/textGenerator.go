@@ -0,0 +1,159 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// TextGenerator is a character level language model that learns to predict the next character of
+// a text from the characters before it, and writes new text by sampling one character at a time.
+type TextGenerator struct {
+	SequenceLength int
+	Characters     []rune
+	Temperature    float32
+	TopK           int
+	NeuralNetwork  *NeuralNetwork
+	indices        map[rune]int
+}
+
+// BuildTextGenerator creates a text generator for the characters of a corpus, which predicts each
+// character from a number of characters before it. The neural network has an embedding layer,
+// a recurrent layer with a number of units, a dense layer with a score for each character and a
+// softmax layer. Generating starts with a temperature of 1 and samples from every character.
+func BuildTextGenerator(corpus string, sequenceLength int, embeddingSize int, units int) (*TextGenerator, error) {
+	indices := map[rune]int{}
+	for _, character := range corpus {
+		indices[character] = 0
+	}
+	if len(indices) < 2 {
+		return nil, fmt.Errorf("Corpus must have at least 2 different characters, has: %d", len(indices))
+	}
+	characters := make([]rune, 0, len(indices))
+	for character := range indices {
+		characters = append(characters, character)
+	}
+	sort.Slice(characters, func(i int, j int) bool {
+		return characters[i] < characters[j]
+	})
+	for i, character := range characters {
+		indices[character] = i
+	}
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(
+		NewEmbeddingLayer(sequenceLength, len(characters), embeddingSize),
+		NewRecurrentLayer(sequenceLength, embeddingSize, units, ActivationTanh, false),
+		NewDenseLayer(units, len(characters), ActivationLinear),
+		NewSoftmaxLayer(len(characters)),
+	)
+	neuralNetwork.SetLoss(LossCrossEntropy)
+	return &TextGenerator{
+		SequenceLength: sequenceLength,
+		Characters:     characters,
+		Temperature:    1,
+		NeuralNetwork:  neuralNetwork,
+		indices:        indices,
+	}, nil
+}
+
+// Train trains the generator to predict each character of a text from the characters before it,
+// in batches for a number of epochs.
+func (generator *TextGenerator) Train(text string, batchSize int, epochs int, optimizer Optimizer) error {
+	indices, err := generator.encode(text)
+	if err != nil {
+		return err
+	}
+	if len(indices) <= generator.SequenceLength {
+		return fmt.Errorf("Text must be longer than the sequence length: %d <= %d", len(indices), generator.SequenceLength)
+	}
+	inputs := make([][][][]float32, 0, len(indices)-generator.SequenceLength)
+	targets := make([][][][]float32, 0, len(indices)-generator.SequenceLength)
+	for i := generator.SequenceLength; i < len(indices); i++ {
+		inputs = append(inputs, [][][]float32{{indices[i-generator.SequenceLength : i]}})
+		target := make([]float32, len(generator.Characters))
+		target[int(indices[i])] = 1
+		targets = append(targets, [][][]float32{{target}})
+	}
+	for epoch := 0; epoch < epochs; epoch++ {
+		err := generator.NeuralNetwork.TrainBatch(inputs, targets, batchSize, optimizer)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Generate continues a prefix with a number of characters and returns the prefix with the new
+// characters. Each character is sampled from the predicted probabilities after dividing their log
+// by the temperature and keeping only the TopK most likely characters, or all of them if TopK is 0.
+// A prefix shorter than the sequence length is padded on the left with the first character.
+func (generator *TextGenerator) Generate(prefix string, length int) (string, error) {
+	if generator.Temperature <= 0 {
+		return "", fmt.Errorf("Temperature must be positive, is: %f", generator.Temperature)
+	}
+	indices, err := generator.encode(prefix)
+	if err != nil {
+		return "", err
+	}
+	for len(indices) < generator.SequenceLength {
+		indices = append([]float32{0}, indices...)
+	}
+	generated := []rune(prefix)
+	for i := 0; i < length; i++ {
+		context := indices[len(indices)-generator.SequenceLength:]
+		outputs, err := generator.NeuralNetwork.Predict([][][]float32{{context}})
+		if err != nil {
+			return "", err
+		}
+		index := sampleIndex(outputs[0][0], generator.Temperature, generator.TopK)
+		indices = append(indices, float32(index))
+		generated = append(generated, generator.Characters[index])
+	}
+	return string(generated), nil
+}
+
+// encode converts the characters of a text to their indices.
+func (generator *TextGenerator) encode(text string) ([]float32, error) {
+	indices := []float32{}
+	for _, character := range text {
+		index, ok := generator.indices[character]
+		if !ok {
+			return nil, fmt.Errorf("Character is not in the vocabulary: %q", character)
+		}
+		indices = append(indices, float32(index))
+	}
+	return indices, nil
+}
+
+// sampleIndex samples an index from probabilities raised to the power of 1 / temperature, keeping
+// only the top k probabilities when k is positive.
+func sampleIndex(probabilities []float32, temperature float32, topK int) int {
+	order := make([]int, len(probabilities))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i int, j int) bool {
+		return probabilities[order[i]] > probabilities[order[j]]
+	})
+	if topK > 0 && topK < len(order) {
+		order = order[:topK]
+	}
+	weights := make([]float64, len(order))
+	sum := 0.0
+	for i, index := range order {
+		weights[i] = math.Pow(float64(probabilities[index]), 1/float64(temperature))
+		sum += weights[i]
+	}
+	if sum == 0 {
+		return order[0]
+	}
+	threshold := rand.Float64() * sum
+	for i, weight := range weights {
+		threshold -= weight
+		if threshold < 0 {
+			return order[i]
+		}
+	}
+	return order[len(order)-1]
+}
@@ -0,0 +1,69 @@
+package nn
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestTextGeneratorGenerate(t *testing.T) {
+	rand.Seed(1)
+	generator, err := BuildTextGenerator(strings.Repeat("abcd", 30), 3, 4, 12)
+	if err != nil {
+		t.Fatalf("Error in BuildTextGenerator: %s", err.Error())
+	}
+	if string(generator.Characters) != "abcd" {
+		t.Errorf("Characters should be abcd, are: %s", string(generator.Characters))
+	}
+	err = generator.Train(strings.Repeat("abcd", 30), 4, 40, NewAdamOptimizer(0.02))
+	if err != nil {
+		t.Fatalf("Error in Train: %s", err.Error())
+	}
+
+	generator.TopK = 1
+	text, err := generator.Generate("abc", 8)
+	if err != nil {
+		t.Fatalf("Error in Generate: %s", err.Error())
+	}
+	if text != "abcdabcdabc" {
+		t.Errorf("Generated text should continue the pattern: abcdabcdabc, is: %s", text)
+	}
+
+	generator.TopK = 0
+	generator.Temperature = 2
+	text, err = generator.Generate("b", 20)
+	if err != nil {
+		t.Fatalf("Error in Generate: %s", err.Error())
+	}
+	if len(text) != 21 || !strings.HasPrefix(text, "b") {
+		t.Errorf("Generated text should have the prefix and 20 characters, is: %s", text)
+	}
+
+	_, err = generator.Generate("xyz", 1)
+	if err == nil {
+		t.Errorf("Prefix with unknown characters did not trigger error")
+	}
+	_, err = BuildTextGenerator("aaaa", 3, 4, 12)
+	if err == nil {
+		t.Errorf("Corpus with a single character did not trigger error")
+	}
+}
+
+func TestSampleIndex(t *testing.T) {
+	probabilities := []float32{0.1, 0.6, 0.3}
+	for i := 0; i < 20; i++ {
+		if sampleIndex(probabilities, 1, 1) != 1 {
+			t.Fatalf("Sampling the top 1 index should always give the most likely index")
+		}
+		if sampleIndex(probabilities, 1, 2) == 0 {
+			t.Fatalf("Sampling the top 2 indices should never give the least likely index")
+		}
+	}
+	counts := make([]int, 3)
+	for i := 0; i < 1000; i++ {
+		counts[sampleIndex(probabilities, 0.1, 0)]++
+	}
+	if counts[1] < 950 {
+		t.Errorf("Low temperature should almost always give the most likely index, gave it %d times", counts[1])
+	}
+}
@@ -0,0 +1,372 @@
+package nn
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	tsr "../tensor"
+)
+
+// SaveToFileTFLite saves the neural network as a TensorFlow Lite model, so it can run on mobile
+// devices with the TensorFlow Lite runtime. Dense, softmax and flatten layers can be exported, where
+// dense layers must have a linear, rectified linear, sigmoid, tanh or softmax activation. The model
+// takes a batch of a single sample of float32 values, with the shape (1, features) when the first
+// layer is dense or softmax and (1, frames, rows, cols) when it is a flatten layer.
+func (neuralNetwork *NeuralNetwork) SaveToFileTFLite(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return neuralNetwork.writeTFLite(file)
+}
+
+// The TensorFlow Lite schema version, and the numbers of the operators, options and tensor types
+// used from the schema.
+const (
+	tfliteSchemaVersion = 3
+
+	tfliteOperatorFullyConnected = 9
+	tfliteOperatorLogistic       = 14
+	tfliteOperatorReshape        = 22
+	tfliteOperatorSoftmax        = 25
+
+	tfliteOptionsFullyConnected = 8
+	tfliteOptionsSoftmax        = 9
+	tfliteOptionsReshape        = 17
+
+	tfliteActivationNone = 0
+	tfliteActivationRELU = 1
+	tfliteActivationTanh = 4
+
+	tfliteTypeFloat32 = 0
+	tfliteTypeInt32   = 2
+)
+
+// tfliteModel collects the tensors, buffers, operators and operator codes of a TensorFlow Lite
+// model while the layers are converted.
+type tfliteModel struct {
+	tensors   []interface{}
+	buffers   []interface{}
+	operators []interface{}
+	codes     []int32
+}
+
+func (neuralNetwork *NeuralNetwork) writeTFLite(writer io.Writer) error {
+	if len(neuralNetwork.layers) == 0 {
+		return fmt.Errorf("Neural network must have a layer to export")
+	}
+	// Buffer 0 is an empty buffer for tensors without constant data.
+	model := &tfliteModel{buffers: []interface{}{flatTable{}}}
+	inputShape := neuralNetwork.layers[0].InputShape()
+	dims := []int32{1, int32(inputShape.Cols)}
+	if _, ok := neuralNetwork.layers[0].(*FlattenLayer); ok {
+		dims = []int32{1, int32(inputShape.Frames), int32(inputShape.Rows), int32(inputShape.Cols)}
+	}
+	input := model.addTensor("input", dims, tfliteTypeFloat32, nil)
+	current := input
+	for i, layer := range neuralNetwork.layers {
+		var err error
+		current, err = model.addLayer(i, layer, current)
+		if err != nil {
+			return err
+		}
+	}
+	subgraph := flatTable{
+		model.tensors,
+		[]int32{int32(input)},
+		[]int32{int32(current)},
+		model.operators,
+		"main",
+	}
+	codes := make([]interface{}, len(model.codes))
+	for i, code := range model.codes {
+		codes[i] = flatTable{int8(code), nil, int32(1), code}
+	}
+	root := flatTable{
+		uint32(tfliteSchemaVersion),
+		codes,
+		[]interface{}{subgraph},
+		"Exported by ml-go",
+		model.buffers,
+	}
+	_, err := writer.Write(buildFlatBuffer(root, "TFL3"))
+	return err
+}
+
+// addLayer adds the tensors and operators of a layer that take the tensor at an index as inputs,
+// and returns the index of the tensor of its outputs.
+func (model *tfliteModel) addLayer(index int, layer Layer, input int) (int, error) {
+	name := fmt.Sprintf("layer_%d", index)
+	size := int32(layer.OutputShape().Cols)
+	switch layer := layer.(type) {
+	case *DenseLayer:
+		err := layer.syncTiedWeights()
+		if err != nil {
+			return 0, err
+		}
+		activation := int8(tfliteActivationNone)
+		switch layer.Activation.Type {
+		case ActivationTypeLinear, ActivationTypeSigmoid, ActivationTypeSoftmax:
+		case ActivationTypeRELU:
+			activation = tfliteActivationRELU
+		case ActivationTypeTanh:
+			activation = tfliteActivationTanh
+		default:
+			return 0, fmt.Errorf("Activation of layer %d cannot be exported to TensorFlow Lite: %s", index, layer.Activation.Type)
+		}
+		// TensorFlow Lite keeps the weights of a fully connected layer as (outputs, inputs).
+		weights := tsr.NewEmptyTensor2D(layer.Weights.Cols, layer.Weights.Rows)
+		tsr.MatrixTranspose(layer.Weights, weights)
+		weightsTensor := model.addTensor(name+"/weights", []int32{size, int32(layer.Weights.Rows)}, tfliteTypeFloat32, floatBytes(tensorValues(weights)))
+		biasTensor := model.addTensor(name+"/bias", []int32{size}, tfliteTypeFloat32, floatBytes(tensorValues(layer.Bias)))
+		output := model.addTensor(name, []int32{1, size}, tfliteTypeFloat32, nil)
+		options := flatTable{activation}
+		model.addOperator(tfliteOperatorFullyConnected, []int{input, weightsTensor, biasTensor}, output, tfliteOptionsFullyConnected, options)
+		switch layer.Activation.Type {
+		case ActivationTypeSigmoid:
+			return model.addActivation(name+"/sigmoid", tfliteOperatorLogistic, output, size), nil
+		case ActivationTypeSoftmax:
+			return model.addActivation(name+"/softmax", tfliteOperatorSoftmax, output, size), nil
+		}
+		return output, nil
+	case *SoftmaxLayer:
+		return model.addActivation(name, tfliteOperatorSoftmax, input, size), nil
+	case *FlattenLayer:
+		shape := model.addTensor(name+"/shape", []int32{2}, tfliteTypeInt32, intBytes([]int32{1, size}))
+		output := model.addTensor(name, []int32{1, size}, tfliteTypeFloat32, nil)
+		options := flatTable{[]int32{1, size}}
+		model.addOperator(tfliteOperatorReshape, []int{input, shape}, output, tfliteOptionsReshape, options)
+		return output, nil
+	default:
+		return 0, fmt.Errorf("Layer %d of type %s cannot be exported to TensorFlow Lite", index, typeOfLayer(layer))
+	}
+}
+
+// addActivation adds an operator that applies an activation to a tensor of a size, and returns the
+// index of the tensor of its outputs.
+func (model *tfliteModel) addActivation(name string, operator int32, input int, size int32) int {
+	output := model.addTensor(name, []int32{1, size}, tfliteTypeFloat32, nil)
+	if operator == tfliteOperatorSoftmax {
+		model.addOperator(operator, []int{input}, output, tfliteOptionsSoftmax, flatTable{float32(1)})
+	} else {
+		model.addOperator(operator, []int{input}, output, 0, nil)
+	}
+	return output
+}
+
+// addTensor adds a tensor with constant data, or without data if it is nil, and returns its index.
+func (model *tfliteModel) addTensor(name string, shape []int32, tensorType int8, data []byte) int {
+	buffer := uint32(0)
+	if data != nil {
+		buffer = uint32(len(model.buffers))
+		model.buffers = append(model.buffers, flatTable{flatBytes(data)})
+	}
+	model.tensors = append(model.tensors, flatTable{shape, tensorType, buffer, name})
+	return len(model.tensors) - 1
+}
+
+func (model *tfliteModel) addOperator(operator int32, inputs []int, output int, optionsType uint8, options flatTable) {
+	codeIndex := len(model.codes)
+	for i, code := range model.codes {
+		if code == operator {
+			codeIndex = i
+		}
+	}
+	if codeIndex == len(model.codes) {
+		model.codes = append(model.codes, operator)
+	}
+	inputIndices := make([]int32, len(inputs))
+	for i, input := range inputs {
+		inputIndices[i] = int32(input)
+	}
+	table := flatTable{uint32(codeIndex), inputIndices, []int32{int32(output)}}
+	if options != nil {
+		table = append(table, optionsType, options)
+	}
+	model.operators = append(model.operators, table)
+}
+
+func floatBytes(values []float32) []byte {
+	data := make([]byte, len(values)*4)
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(value))
+	}
+	return data
+}
+
+func intBytes(values []int32) []byte {
+	data := make([]byte, len(values)*4)
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(data[i*4:], uint32(value))
+	}
+	return data
+}
+
+// flatTable is a table of a FlatBuffer with the value of each field at the index of its id, or nil
+// for fields left at their default. Values are int8, uint8, int32, uint32 or float32 scalars,
+// strings, []int32 vectors, flatBytes vectors, nested tables and []interface{} vectors of tables.
+type flatTable []interface{}
+
+// flatBytes is a vector of bytes of a FlatBuffer, aligned to 16 bytes so it can hold the data of a
+// tensor of any type.
+type flatBytes []byte
+
+// buildFlatBuffer lays out a FlatBuffer with a root table and a file identifier. Unlike the
+// FlatBuffers library, which builds from the end, each object is written before the objects it
+// refers to, since references must point forward, and the references are filled in once the
+// objects are written.
+func buildFlatBuffer(root flatTable, identifier string) []byte {
+	builder := &flatBuilder{buffer: make([]byte, 4)}
+	builder.buffer = append(builder.buffer, identifier...)
+	position := builder.writeTable(root)
+	binary.LittleEndian.PutUint32(builder.buffer, uint32(position))
+	return builder.buffer
+}
+
+type flatBuilder struct {
+	buffer []byte
+}
+
+// flatReference is a reference from a position in the buffer to an object that is not written yet.
+type flatReference struct {
+	position int
+	object   interface{}
+}
+
+func (builder *flatBuilder) align(alignment int) {
+	for len(builder.buffer)%alignment != 0 {
+		builder.buffer = append(builder.buffer, 0)
+	}
+}
+
+func (builder *flatBuilder) uint16(value uint16) {
+	bytes := make([]byte, 2)
+	binary.LittleEndian.PutUint16(bytes, value)
+	builder.buffer = append(builder.buffer, bytes...)
+}
+
+func (builder *flatBuilder) uint32(value uint32) {
+	bytes := make([]byte, 4)
+	binary.LittleEndian.PutUint32(bytes, value)
+	builder.buffer = append(builder.buffer, bytes...)
+}
+
+// writeObject writes a table, vector or string, and returns its position.
+func (builder *flatBuilder) writeObject(object interface{}) int {
+	switch object := object.(type) {
+	case flatTable:
+		return builder.writeTable(object)
+	case string:
+		builder.align(4)
+		position := len(builder.buffer)
+		builder.uint32(uint32(len(object)))
+		builder.buffer = append(builder.buffer, object...)
+		builder.buffer = append(builder.buffer, 0)
+		return position
+	case []int32:
+		builder.align(4)
+		position := len(builder.buffer)
+		builder.uint32(uint32(len(object)))
+		for _, value := range object {
+			builder.uint32(uint32(value))
+		}
+		return position
+	case flatBytes:
+		// The length comes right before the data, so it is placed 4 bytes before the alignment.
+		for (len(builder.buffer)+4)%16 != 0 {
+			builder.buffer = append(builder.buffer, 0)
+		}
+		position := len(builder.buffer)
+		builder.uint32(uint32(len(object)))
+		builder.buffer = append(builder.buffer, object...)
+		return position
+	case []interface{}:
+		builder.align(4)
+		position := len(builder.buffer)
+		builder.uint32(uint32(len(object)))
+		references := make([]flatReference, len(object))
+		for i, element := range object {
+			references[i] = flatReference{len(builder.buffer), element}
+			builder.uint32(0)
+		}
+		builder.resolve(references)
+		return position
+	default:
+		panic(fmt.Sprintf("Unsupported FlatBuffer object: %T", object))
+	}
+}
+
+// writeTable writes the vtable of a table followed by the table, and then the objects the table
+// refers to. Fields are ordered by size, so every field is aligned.
+func (builder *flatBuilder) writeTable(table flatTable) int {
+	fieldSize := func(value interface{}) int {
+		switch value.(type) {
+		case int8, uint8:
+			return 1
+		default:
+			return 4
+		}
+	}
+	ids := []int{}
+	for id, value := range table {
+		if value != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.SliceStable(ids, func(i int, j int) bool {
+		return fieldSize(table[ids[i]]) > fieldSize(table[ids[j]])
+	})
+	offsets := make([]int, len(table))
+	size := 4
+	for _, id := range ids {
+		offsets[id] = size
+		size += fieldSize(table[id])
+	}
+
+	builder.align(2)
+	vtablePosition := len(builder.buffer)
+	vtable := []uint16{uint16(4 + 2*len(table)), uint16(size)}
+	for _, offset := range offsets {
+		vtable = append(vtable, uint16(offset))
+	}
+	for _, value := range vtable {
+		builder.uint16(value)
+	}
+	builder.align(4)
+	position := len(builder.buffer)
+	builder.uint32(uint32(position - vtablePosition))
+	references := []flatReference{}
+	for _, id := range ids {
+		switch value := table[id].(type) {
+		case int8:
+			builder.buffer = append(builder.buffer, byte(value))
+		case uint8:
+			builder.buffer = append(builder.buffer, value)
+		case int32:
+			builder.uint32(uint32(value))
+		case uint32:
+			builder.uint32(value)
+		case float32:
+			builder.uint32(math.Float32bits(value))
+		default:
+			references = append(references, flatReference{len(builder.buffer), value})
+			builder.uint32(0)
+		}
+	}
+	builder.resolve(references)
+	return position
+}
+
+// resolve writes the objects of references and fills in the offsets to them, which are relative to
+// the position of each reference.
+func (builder *flatBuilder) resolve(references []flatReference) {
+	for _, reference := range references {
+		position := builder.writeObject(reference.object)
+		binary.LittleEndian.PutUint32(builder.buffer[reference.position:], uint32(position-reference.position))
+	}
+}
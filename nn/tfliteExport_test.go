@@ -0,0 +1,217 @@
+package nn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+
+	tsr "../tensor"
+)
+
+// flatReader reads the tables, vectors and scalars of a FlatBuffer, to check exported models
+// independently of the builder.
+type flatReader []byte
+
+func (data flatReader) uint32(position int) int {
+	return int(binary.LittleEndian.Uint32(data[position:]))
+}
+
+// field returns the position of a field of the table at a position, or 0 if it is not set.
+func (data flatReader) field(table int, id int) int {
+	vtable := table - int(int32(data.uint32(table)))
+	if 4+2*id >= int(binary.LittleEndian.Uint16(data[vtable:])) {
+		return 0
+	}
+	offset := int(binary.LittleEndian.Uint16(data[vtable+4+2*id:]))
+	if offset == 0 {
+		return 0
+	}
+	return table + offset
+}
+
+// reference follows the offset stored at a position to the object it refers to.
+func (data flatReader) reference(position int) int {
+	return position + data.uint32(position)
+}
+
+// vector returns the position of the first element and the length of the vector referred to by a
+// field of a table.
+func (data flatReader) vector(table int, id int) (int, int) {
+	field := data.field(table, id)
+	if field == 0 {
+		return 0, 0
+	}
+	vector := data.reference(field)
+	return vector + 4, data.uint32(vector)
+}
+
+func (data flatReader) ints(table int, id int) []int {
+	start, length := data.vector(table, id)
+	values := make([]int, length)
+	for i := range values {
+		values[i] = int(int32(data.uint32(start + 4*i)))
+	}
+	return values
+}
+
+func (data flatReader) tables(table int, id int) []int {
+	start, length := data.vector(table, id)
+	tables := make([]int, length)
+	for i := range tables {
+		tables[i] = data.reference(start + 4*i)
+	}
+	return tables
+}
+
+// runTFLite runs an exported model on inputs with the operators it may contain, and returns the
+// outputs.
+func runTFLite(t *testing.T, model []byte, inputs []float32) []float32 {
+	data := flatReader(model)
+	if string(data[4:8]) != "TFL3" {
+		t.Fatalf("Model should have the TFL3 identifier, has: %q", data[4:8])
+	}
+	root := data.reference(0)
+	if version := data.uint32(data.field(root, 0)); version != tfliteSchemaVersion {
+		t.Fatalf("Model should have schema version %d, has: %d", tfliteSchemaVersion, version)
+	}
+	codes := []int{}
+	for _, code := range data.tables(root, 1) {
+		codes = append(codes, int(int32(data.uint32(data.field(code, 3)))))
+	}
+	buffers := [][]float32{}
+	for _, buffer := range data.tables(root, 4) {
+		start, length := data.vector(buffer, 0)
+		if start%16 != 0 {
+			t.Errorf("Buffer data at %d should be aligned to 16 bytes", start)
+		}
+		values := make([]float32, length/4)
+		for i := range values {
+			values[i] = math.Float32frombits(uint32(data.uint32(start + 4*i)))
+		}
+		buffers = append(buffers, values)
+	}
+	subgraphs := data.tables(root, 2)
+	if len(subgraphs) != 1 {
+		t.Fatalf("Model should have 1 subgraph, has: %d", len(subgraphs))
+	}
+	values := map[int][]float32{}
+	for i, tensor := range data.tables(subgraphs[0], 0) {
+		if field := data.field(tensor, 2); field != 0 && data.uint32(field) != 0 {
+			values[i] = buffers[data.uint32(field)]
+		}
+	}
+	values[data.ints(subgraphs[0], 1)[0]] = inputs
+	for _, operator := range data.tables(subgraphs[0], 3) {
+		code := codes[data.uint32(data.field(operator, 0))]
+		operands := data.ints(operator, 1)
+		input := values[operands[0]]
+		var output []float32
+		switch code {
+		case tfliteOperatorFullyConnected:
+			weights, bias := values[operands[1]], values[operands[2]]
+			options := data.reference(data.field(operator, 4))
+			output = make([]float32, len(bias))
+			for o := range output {
+				sum := bias[o]
+				for i, value := range input {
+					sum += value * weights[o*len(input)+i]
+				}
+				switch activation := int(data[data.field(options, 0)]); activation {
+				case tfliteActivationRELU:
+					sum = float32(math.Max(0, float64(sum)))
+				case tfliteActivationTanh:
+					sum = float32(math.Tanh(float64(sum)))
+				}
+				output[o] = sum
+			}
+		case tfliteOperatorLogistic:
+			for _, value := range input {
+				output = append(output, float32(1/(1+math.Exp(-float64(value)))))
+			}
+		case tfliteOperatorSoftmax:
+			sum := 0.0
+			for _, value := range input {
+				sum += math.Exp(float64(value))
+			}
+			for _, value := range input {
+				output = append(output, float32(math.Exp(float64(value))/sum))
+			}
+		case tfliteOperatorReshape:
+			output = input
+		default:
+			t.Fatalf("Model has an unexpected operator: %d", code)
+		}
+		values[data.ints(operator, 2)[0]] = output
+	}
+	return values[data.ints(subgraphs[0], 2)[0]]
+}
+
+func TestNeuralNetworkSaveTFLite(t *testing.T) {
+	withFlatten := NewNeuralNetwork()
+	withFlatten.Add(
+		NewFlattenLayer(2, 3, 2),
+		NewDenseLayer(12, 8, ActivationRELU),
+		NewDenseLayer(8, 4, ActivationTanh),
+		NewDenseLayer(4, 3, ActivationLinear),
+		NewSoftmaxLayer(3),
+	)
+	withActivations := NewNeuralNetwork()
+	withActivations.Add(
+		NewDenseLayer(5, 4, ActivationSigmoid),
+		NewDenseLayer(4, 3, ActivationSoftmax),
+	)
+
+	for _, neuralNetwork := range []*NeuralNetwork{withFlatten, withActivations} {
+		for _, parameter := range neuralNetwork.Parameters() {
+			parameter.SetRandom(-1, 1)
+		}
+		shape := neuralNetwork.LayerAt(0).InputShape()
+		inputs := tsr.NewEmptyTensor3D(shape.Frames, shape.Rows, shape.Cols)
+		inputs.SetRandom(-1, 1)
+		expected, err := neuralNetwork.Predict(inputs.GetAll())
+		if err != nil {
+			t.Fatalf("Error in Predict: %s", err.Error())
+		}
+
+		fileName := filepath.Join(t.TempDir(), "model.tflite")
+		err = neuralNetwork.SaveToFileTFLite(fileName)
+		if err != nil {
+			t.Fatalf("Error in SaveToFileTFLite: %s", err.Error())
+		}
+		model, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			t.Fatalf("Error in ReadFile: %s", err.Error())
+		}
+		result := runTFLite(t, model, tensorValues(inputs))
+		if len(result) != len(expected[0][0]) {
+			t.Fatalf("Model should have %d outputs, has: %d", len(expected[0][0]), len(result))
+		}
+		for i, value := range result {
+			if math.Abs(float64(value-expected[0][0][i])) > 1e-5 {
+				t.Errorf("Outputs of exported model should match predictions: %v != %v", result, expected[0][0])
+				break
+			}
+		}
+	}
+}
+
+func TestNeuralNetworkSaveTFLiteUnsupported(t *testing.T) {
+	convolutionLayer, _ := NewRandomConvolutionLayer(4, 4, 1, 2, 3, ActivationRELU)
+	leakyRELU, _ := NewLeakyRELUActivation(0.1)
+	unsupported := [][]Layer{
+		{},
+		{convolutionLayer, NewFlattenLayer(4, 4, 2)},
+		{NewDenseLayer(3, 2, leakyRELU)},
+	}
+	for _, layers := range unsupported {
+		neuralNetwork := NewNeuralNetwork()
+		neuralNetwork.Add(layers...)
+		err := neuralNetwork.writeTFLite(&bytes.Buffer{})
+		if err == nil {
+			t.Errorf("Exporting %d layers that are not supported did not trigger error", len(layers))
+		}
+	}
+}
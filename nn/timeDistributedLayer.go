@@ -0,0 +1,179 @@
+package nn
+
+import (
+	"encoding/json"
+	"fmt"
+
+	tsr "../tensor"
+)
+
+// TimeDistributedLayer is a layer that applies an inner layer to every timestep of a sequence.
+// Each row of the input data is a timestep, and the inner layer must take and produce a single row.
+type TimeDistributedLayer struct {
+	inputShape  LayerShape
+	outputShape LayerShape
+	inputs      *tsr.Tensor
+	outputs     *tsr.Tensor
+	mask        []bool
+	Layer       Layer
+}
+
+// NewTimeDistributedLayer creates a new instance of a time distributed layer.
+func NewTimeDistributedLayer(timesteps int, layer Layer) (*TimeDistributedLayer, error) {
+	innerInputShape := layer.InputShape()
+	innerOutputShape := layer.OutputShape()
+	if innerInputShape.Rows != 1 || innerInputShape.Frames != 1 || innerOutputShape.Rows != 1 || innerOutputShape.Frames != 1 {
+		return nil, fmt.Errorf(
+			"Inner layer must take and produce a single row and frame: (%d, %d, %d) -> (%d, %d, %d)",
+			innerInputShape.Rows, innerInputShape.Cols, innerInputShape.Frames,
+			innerOutputShape.Rows, innerOutputShape.Cols, innerOutputShape.Frames,
+		)
+	}
+	return &TimeDistributedLayer{
+		inputShape:  LayerShape{timesteps, innerInputShape.Cols, 1},
+		outputShape: LayerShape{timesteps, innerOutputShape.Cols, 1},
+		inputs:      tsr.NewEmptyTensor2D(timesteps, innerInputShape.Cols),
+		outputs:     tsr.NewEmptyTensor2D(timesteps, innerOutputShape.Cols),
+		Layer:       layer,
+	}, nil
+}
+
+// Copy creates a deep copy of the layer.
+func (layer *TimeDistributedLayer) Copy() Layer {
+	newLayer, _ := NewTimeDistributedLayer(layer.InputShape().Rows, layer.Layer.Copy())
+	return newLayer
+}
+
+// InputShape returns the rows, columns and frames of the inputs to the layer.
+func (layer *TimeDistributedLayer) InputShape() LayerShape {
+	return layer.inputShape
+}
+
+// OutputShape returns the rows, columns and frames of outputs from the layer.
+func (layer *TimeDistributedLayer) OutputShape() LayerShape {
+	return layer.outputShape
+}
+
+// SetMask sets the timesteps to skip in the following feed forward and back propagation. Masked
+// timesteps produce outputs and deltas of zero.
+func (layer *TimeDistributedLayer) SetMask(mask []bool) {
+	if len(mask) != layer.inputShape.Rows {
+		layer.mask = nil
+		return
+	}
+	layer.mask = mask
+}
+
+// FeedForward applies the inner layer to each timestep of the inputs.
+func (layer *TimeDistributedLayer) FeedForward(inputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := layer.inputs.SetTensor(inputs)
+	if err != nil {
+		return nil, err
+	}
+	for timestep := 0; timestep < layer.inputShape.Rows; timestep++ {
+		if layer.isMasked(timestep) {
+			for col := 0; col < layer.outputShape.Cols; col++ {
+				layer.outputs.Set(0, timestep, col, 0)
+			}
+			continue
+		}
+		stepOutputs, err := layer.Layer.FeedForward(timestepOf(inputs, timestep))
+		if err != nil {
+			return nil, err
+		}
+		for col := 0; col < stepOutputs.Cols; col++ {
+			layer.outputs.Set(0, timestep, col, stepOutputs.Get(0, 0, col))
+		}
+	}
+	return layer.outputs, nil
+}
+
+// BackPropagate back propagates the deltas of each timestep through the inner layer, starting
+// from the last timestep. The gradients of the inner layer add up over all timesteps.
+func (layer *TimeDistributedLayer) BackPropagate(outputs *tsr.Tensor) (*tsr.Tensor, error) {
+	err := checkShape(outputs, layer.OutputShape(), "delta")
+	if err != nil {
+		return nil, err
+	}
+	nextDeltas := tsr.NewEmptyTensor2D(layer.inputShape.Rows, layer.inputShape.Cols)
+	for timestep := layer.inputShape.Rows - 1; timestep >= 0; timestep-- {
+		if layer.isMasked(timestep) {
+			continue
+		}
+		// The inner layer only remembers its last inputs, so the timestep is fed forward again.
+		_, err := layer.Layer.FeedForward(timestepOf(layer.inputs, timestep))
+		if err != nil {
+			return nil, err
+		}
+		stepDeltas, err := layer.Layer.BackPropagate(timestepOf(outputs, timestep))
+		if err != nil {
+			return nil, err
+		}
+		for col := 0; col < stepDeltas.Cols; col++ {
+			nextDeltas.Set(0, timestep, col, stepDeltas.Get(0, 0, col))
+		}
+	}
+	return nextDeltas, nil
+}
+
+// Parameters returns the parameters of the wrapped layer.
+func (layer *TimeDistributedLayer) Parameters() []*tsr.Tensor {
+	return layer.Layer.Parameters()
+}
+
+// Gradients returns the gradients of the parameters of the wrapped layer.
+func (layer *TimeDistributedLayer) Gradients() []*tsr.Tensor {
+	return layer.Layer.Gradients()
+}
+
+func (layer *TimeDistributedLayer) isMasked(timestep int) bool {
+	return layer.mask != nil && layer.mask[timestep]
+}
+
+func (layer *TimeDistributedLayer) returnsSequences() bool {
+	return true
+}
+
+func timestepOf(sequence *tsr.Tensor, timestep int) *tsr.Tensor {
+	return tsr.NewValueTensor1D(sequence.GetFrame(0)[timestep])
+}
+
+// TimeDistributedLayerData represents a serialized layer that can be saved to a file.
+type TimeDistributedLayerData struct {
+	Type      LayerType       `json:"type"`
+	Timesteps int             `json:"timesteps"`
+	Layer     json.RawMessage `json:"layer"`
+}
+
+// MarshalJSON converts the layer to JSON.
+func (layer *TimeDistributedLayer) MarshalJSON() ([]byte, error) {
+	innerData, err := json.Marshal(layer.Layer)
+	if err != nil {
+		return nil, err
+	}
+	data := TimeDistributedLayerData{
+		Type:      LayerTypeTimeDistributed,
+		Timesteps: layer.InputShape().Rows,
+		Layer:     innerData,
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new layer from JSON.
+func (layer *TimeDistributedLayer) UnmarshalJSON(b []byte) error {
+	data := TimeDistributedLayerData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	innerLayer, err := unmarshalLayer(data.Layer)
+	if err != nil {
+		return err
+	}
+	newLayer, err := NewTimeDistributedLayer(data.Timesteps, innerLayer)
+	if err != nil {
+		return err
+	}
+	*layer = *newLayer
+	return nil
+}
@@ -0,0 +1,90 @@
+package nn
+
+import (
+	"encoding/json"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestTimeDistributedLayer(t *testing.T) {
+	dense := NewDenseLayer(3, 2, ActivationSigmoid)
+	layer, err := NewTimeDistributedLayer(4, dense)
+	if err != nil {
+		t.Fatalf("Error in NewTimeDistributedLayer: %s", err.Error())
+	}
+
+	inputs := tsr.NewValueTensor2D([][]float32{
+		{1, 0, 0},
+		{0, 1, 0},
+		{0, 0, 1},
+		{1, 0, 0},
+	})
+
+	outputs, err := layer.FeedForward(inputs)
+	if err != nil {
+		t.Fatalf("Error in FeedForward: %s", err.Error())
+	}
+	if outputs.Rows != 4 || outputs.Cols != 2 {
+		t.Fatalf("Outputs have incorrect shape: (%d, %d) != (%d, %d)", outputs.Rows, outputs.Cols, 4, 2)
+	}
+
+	for timestep := 0; timestep < inputs.Rows; timestep++ {
+		stepOutputs, _ := dense.FeedForward(tsr.NewValueTensor1D(inputs.GetFrame(0)[timestep]))
+		for col := 0; col < stepOutputs.Cols; col++ {
+			if outputs.Get(0, timestep, col) != stepOutputs.Get(0, 0, col) {
+				t.Errorf("Timestep %d does not match inner layer output: %.3f != %.3f", timestep, outputs.Get(0, timestep, col), stepOutputs.Get(0, 0, col))
+			}
+		}
+	}
+	if outputs.Get(0, 0, 0) != outputs.Get(0, 3, 0) {
+		t.Errorf("Equal timesteps should produce equal outputs: %.3f != %.3f", outputs.Get(0, 0, 0), outputs.Get(0, 3, 0))
+	}
+
+	deltas, err := layer.BackPropagate(tsr.NewValueTensor2D([][]float32{
+		{1, -1},
+		{0.5, 0.5},
+		{-1, 1},
+		{0, 0},
+	}))
+	if err != nil {
+		t.Fatalf("Error in BackPropagate: %s", err.Error())
+	}
+	if deltas.Rows != 4 || deltas.Cols != 3 {
+		t.Fatalf("Deltas have incorrect shape: (%d, %d) != (%d, %d)", deltas.Rows, deltas.Cols, 4, 3)
+	}
+	if dense.weightGradients.Equals(tsr.NewEmptyTensor2D(3, 2)) {
+		t.Errorf("Inner weight gradients after back propagate should not be zero")
+	}
+
+	_, err = NewTimeDistributedLayer(4, NewFlattenLayer(2, 2, 1))
+	if err == nil {
+		t.Errorf("Did not trigger error on inner layer with multiple rows")
+	}
+}
+
+func TestTimeDistributedLayerSaveLoad(t *testing.T) {
+	layer, _ := NewTimeDistributedLayer(5, NewDenseLayer(3, 2, ActivationTanh))
+
+	data, err := json.Marshal(layer)
+	if err != nil {
+		t.Fatalf("Error in MarshalJSON: %s", err.Error())
+	}
+
+	loadedLayer := &TimeDistributedLayer{}
+	err = json.Unmarshal(data, loadedLayer)
+	if err != nil {
+		t.Fatalf("Error in UnmarshalJSON: %s", err.Error())
+	}
+
+	if loadedLayer.InputShape() != layer.InputShape() || loadedLayer.OutputShape() != layer.OutputShape() {
+		t.Errorf("Loaded layer shape does not match original")
+	}
+	loadedDense, ok := loadedLayer.Layer.(*DenseLayer)
+	if !ok {
+		t.Fatalf("Loaded inner layer should be a dense layer")
+	}
+	if !loadedDense.Weights.Equals(layer.Layer.(*DenseLayer).Weights) {
+		t.Errorf("Loaded inner weights do not match original")
+	}
+}
@@ -0,0 +1,166 @@
+package nn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dataset is a set of samples to train or evaluate a neural network on, with the targets of each
+// input at the same index.
+type Dataset struct {
+	Inputs  [][][][]float32
+	Targets [][][][]float32
+}
+
+// NewDataset creates a dataset from inputs and their targets, which must have the same length.
+func NewDataset(inputs [][][][]float32, targets [][][][]float32) (*Dataset, error) {
+	if len(inputs) != len(targets) {
+		return nil, fmt.Errorf("Number of inputs and targets must match: %d != %d", len(inputs), len(targets))
+	}
+	return &Dataset{Inputs: inputs, Targets: targets}, nil
+}
+
+// Len returns the number of samples in the dataset.
+func (dataset *Dataset) Len() int {
+	return len(dataset.Inputs)
+}
+
+// Split divides the dataset in two, with a fraction of the samples from the start of the dataset in
+// the first part and the rest in the second. The samples are not shuffled, so shuffle them first if
+// they are in an order.
+func (dataset *Dataset) Split(fraction float32) (*Dataset, *Dataset, error) {
+	if fraction < 0 || fraction > 1 {
+		return nil, nil, fmt.Errorf("Fraction must be between 0 and 1, is: %f", fraction)
+	}
+	size := int(fraction * float32(dataset.Len()))
+	first := &Dataset{Inputs: dataset.Inputs[:size], Targets: dataset.Targets[:size]}
+	second := &Dataset{Inputs: dataset.Inputs[size:], Targets: dataset.Targets[size:]}
+	return first, second, nil
+}
+
+// Trainer runs the training loop of a neural network, which shuffles the samples of a dataset each
+// epoch, trains on them in batches and reports the loss and accuracy of each epoch to its
+// callbacks. The sampler and learning rate schedule can be changed before fitting, and default to
+// a RandomSampler and the learning rate of the optimizer. With validation data, or a validation
+// split that holds out a fraction of the samples at the end of the dataset instead, the loss and
+// accuracy on that data are reported as well. Any other metrics are computed on the same data
+// each epoch. With a checkpoint path, the progress of fitting is saved at the end of each epoch so
+// it can be resumed. With a seed other than 0, the samples of each epoch are drawn from a random
+// source of the trainer started from the seed plus the epoch, so a resumed training draws the same
+// samples as an uninterrupted one. The global random source is left alone, so custom samplers and
+// layers that use random numbers while training, such as dropout, still draw from it.
+type Trainer struct {
+	NeuralNetwork   *NeuralNetwork
+	Optimizer       Optimizer
+	Sampler         Sampler
+	Schedule        LearningRateSchedule
+	ValidationData  *Dataset
+	ValidationSplit float32
+	Metrics         []Metric
+	CheckpointPath  string
+	Seed            int64
+	callbacks       []Callback
+	resumed         *trainingState
+}
+
+// NewTrainer creates a trainer for a neural network that minimizes a loss with an optimizer.
+func NewTrainer(neuralNetwork *NeuralNetwork, optimizer Optimizer, loss LossFunction) *Trainer {
+	neuralNetwork.SetLoss(loss)
+	return &Trainer{
+		NeuralNetwork: neuralNetwork,
+		Optimizer:     optimizer,
+		Sampler:       RandomSampler{},
+		Schedule:      ConstantSchedule(optimizer.LearningRate()),
+	}
+}
+
+// AddCallback adds a callback that is notified of the progress of fitting, after any callbacks of
+// the neural network.
+func (trainer *Trainer) AddCallback(callback Callback) {
+	trainer.callbacks = append(trainer.callbacks, callback)
+}
+
+// Fit trains the neural network on a dataset for a number of epochs in batches of a size, and
+// returns the metrics of each epoch. Along with the "loss" and "learningRate" of the epoch, the
+// metrics include the "accuracy" of the neural network on the dataset at the end of the epoch, and
+// the "validationLoss" and "validationAccuracy" if the trainer has validation data. Each metric of
+// the trainer is included by its name, and by its name after "validation" for the validation data,
+// such as "meanAbsoluteError" and "validationMeanAbsoluteError". Training ends early if a callback
+// returns ErrStopTraining. After ResumeFrom, fitting starts from the epoch after the checkpoint
+// and only the metrics of the remaining epochs are returned.
+func (trainer *Trainer) Fit(dataset *Dataset, epochs int, batchSize int) ([]map[string]float32, error) {
+	validation := trainer.ValidationData
+	if validation == nil && trainer.ValidationSplit > 0 {
+		var err error
+		dataset, validation, err = dataset.Split(1 - trainer.ValidationSplit)
+		if err != nil {
+			return nil, err
+		}
+	}
+	history := &historyCallback{
+		dataset:    dataset,
+		validation: validation,
+		metrics:    append([]Metric{MetricAccuracy}, trainer.Metrics...),
+	}
+	callbacks := []Callback{history}
+	callbacks = append(callbacks, trainer.NeuralNetwork.callbacks...)
+	callbacks = append(callbacks, trainer.callbacks...)
+	state := trainer.resumed
+	trainer.resumed = nil
+	if state == nil {
+		state = &trainingState{seed: trainer.Seed}
+	}
+	if trainer.CheckpointPath != "" {
+		callbacks = append(callbacks, &checkpointCallback{trainer: trainer, fileName: trainer.CheckpointPath, state: state})
+	}
+	err := trainer.NeuralNetwork.trainBatches(
+		dataset.Inputs, dataset.Targets, batchSize, epochs, trainer.Sampler, trainer.Schedule, trainer.Optimizer, callbacks, state,
+	)
+	return history.history, err
+}
+
+// historyCallback adds the accuracy, the metrics of the trainer and the validation metrics to the
+// metrics of each epoch, before the other callbacks see them, and keeps the metrics.
+type historyCallback struct {
+	dataset    *Dataset
+	validation *Dataset
+	metrics    []Metric
+	history    []map[string]float32
+}
+
+func (callback *historyCallback) OnTrainBegin(neuralNetwork *NeuralNetwork) error {
+	return nil
+}
+
+func (callback *historyCallback) OnEpochEnd(neuralNetwork *NeuralNetwork, epoch int, metrics map[string]float32) error {
+	_, values, err := neuralNetwork.Evaluate(callback.dataset.Inputs, callback.dataset.Targets, neuralNetwork.loss, callback.metrics...)
+	if err != nil {
+		return err
+	}
+	for name, value := range values {
+		metrics[name] = value
+	}
+	if callback.validation != nil {
+		loss, values, err := neuralNetwork.Evaluate(callback.validation.Inputs, callback.validation.Targets, neuralNetwork.loss, callback.metrics...)
+		if err != nil {
+			return err
+		}
+		metrics["validationLoss"] = loss
+		for name, value := range values {
+			metrics[validationName(name)] = value
+		}
+	}
+	callback.history = append(callback.history, metrics)
+	return nil
+}
+
+func (callback *historyCallback) OnTrainEnd(neuralNetwork *NeuralNetwork) error {
+	return nil
+}
+
+func validationName(name string) string {
+	if name == "" {
+		return "validation"
+	}
+	return "validation" + strings.ToUpper(name[:1]) + name[1:]
+}
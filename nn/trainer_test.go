@@ -0,0 +1,105 @@
+package nn
+
+import (
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestTrainerFit(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+	dataset, err := NewDataset(
+		[][][][]float32{{{{0, 0}}}, {{{0, 1}}}, {{{1, 0}}}, {{{1, 1}}}},
+		[][][][]float32{{{{0}}}, {{{1}}}, {{{1}}}, {{{1}}}},
+	)
+	if err != nil {
+		t.Fatalf("Error in NewDataset: %s", err.Error())
+	}
+	trainer := NewTrainer(neuralNetwork, NewSGDOptimizer(0.5, 0.5), LossBinaryCrossEntropy)
+	callback := &recordingCallback{stopAt: -1}
+	trainer.AddCallback(callback)
+
+	history, err := trainer.Fit(dataset, 200, 2)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+	if len(history) != 200 || len(callback.events) != 202 {
+		t.Fatalf("Fit should report 200 epochs, reported: %d, %d", len(history), len(callback.events)-2)
+	}
+	last := history[len(history)-1]
+	if last["accuracy"] != 1 {
+		t.Errorf("Accuracy should be 1 after fitting OR, is: %f", last["accuracy"])
+	}
+	if last["loss"] >= history[0]["loss"] {
+		t.Errorf("Loss should decrease while fitting: %f >= %f", last["loss"], history[0]["loss"])
+	}
+
+	_, err = NewDataset([][][][]float32{{{{0, 0}}}}, [][][][]float32{})
+	if err == nil {
+		t.Errorf("Dataset with mismatched inputs and targets should have an error")
+	}
+}
+
+func TestTrainerValidationSplit(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+	dataset, _ := NewDataset(
+		[][][][]float32{{{{0, 0}}}, {{{0, 1}}}, {{{1, 0}}}, {{{1, 1}}}, {{{0, 0}}}},
+		[][][][]float32{{{{0}}}, {{{1}}}, {{{1}}}, {{{1}}}, {{{0}}}},
+	)
+	training, validation, err := dataset.Split(0.6)
+	if err != nil {
+		t.Fatalf("Error in Split: %s", err.Error())
+	}
+	if training.Len() != 3 || validation.Len() != 2 {
+		t.Errorf("Split should have 3 and 2 samples, has: %d and %d", training.Len(), validation.Len())
+	}
+
+	trainer := NewTrainer(neuralNetwork, NewSGDOptimizer(0.1, 0), LossMSE)
+	trainer.ValidationSplit = 0.4
+	history, err := trainer.Fit(dataset, 2, 2)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+	if _, ok := history[1]["validationAccuracy"]; !ok {
+		t.Errorf("Metrics should include the validation accuracy, are: %v", history[1])
+	}
+}
+
+func TestTrainerMetrics(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+	dataset, _ := NewDataset(
+		[][][][]float32{{{{0, 0}}}, {{{0, 1}}}, {{{1, 0}}}, {{{1, 1}}}, {{{0, 0}}}},
+		[][][][]float32{{{{0}}}, {{{1}}}, {{{1}}}, {{{1}}}, {{{0}}}},
+	)
+	samples := Metric{
+		Name: "samples",
+		Function: func(predictions []*tsr.Tensor, targets []*tsr.Tensor) (float32, error) {
+			return float32(len(predictions)), nil
+		},
+	}
+	trainer := NewTrainer(neuralNetwork, NewSGDOptimizer(0.1, 0), LossMSE)
+	trainer.ValidationSplit = 0.4
+	trainer.Metrics = []Metric{MetricMeanAbsoluteError, samples}
+	history, err := trainer.Fit(dataset, 2, 2)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+	for _, metrics := range history {
+		if metrics["samples"] != 3 || metrics["validationSamples"] != 2 {
+			t.Errorf("Metrics should be computed on 3 training and 2 validation samples, are: %v", metrics)
+		}
+		meanAbsoluteError, ok := metrics["meanAbsoluteError"]
+		if !ok || meanAbsoluteError <= 0 || meanAbsoluteError >= 1 {
+			t.Errorf("Metrics should include the mean absolute error between 0 and 1, are: %v", metrics)
+		}
+		if _, ok := metrics["validationMeanAbsoluteError"]; !ok {
+			t.Errorf("Metrics should include the validation mean absolute error, are: %v", metrics)
+		}
+		if _, ok := metrics["accuracy"]; !ok {
+			t.Errorf("Metrics should still include the accuracy, are: %v", metrics)
+		}
+	}
+}
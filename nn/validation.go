@@ -0,0 +1,102 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ValidationIssue is a problem found when validating inputs against a schema. The frame, row and
+// column are those of the invalid value, or -1 for problems with the shape of the inputs.
+type ValidationIssue struct {
+	Frame  int
+	Row    int
+	Col    int
+	Reason string
+}
+
+// ValidationError is returned for inputs that do not match a schema, with every issue found.
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+// Error describes the first few issues of the inputs.
+func (err *ValidationError) Error() string {
+	reasons := []string{}
+	for i, issue := range err.Issues {
+		if i == 3 {
+			reasons = append(reasons, fmt.Sprintf("and %d more", len(err.Issues)-i))
+			break
+		}
+		if issue.Frame < 0 {
+			reasons = append(reasons, issue.Reason)
+		} else {
+			reasons = append(reasons, fmt.Sprintf("%s at (%d, %d, %d)", issue.Reason, issue.Frame, issue.Row, issue.Col))
+		}
+	}
+	return fmt.Sprintf("Invalid inputs: %s", strings.Join(reasons, ", "))
+}
+
+// Validate checks that inputs have the shape of the schema, contain no NaN or infinite values and
+// are within the minimum and maximum of their column, if the schema has them. It returns a
+// ValidationError with every issue found, or nil if the inputs are valid.
+func (schema Schema) Validate(inputs [][][]float32) error {
+	if schema.Minimum != nil && len(schema.Minimum) != schema.Cols {
+		return fmt.Errorf("Number of minimums must match columns of schema: %d != %d", len(schema.Minimum), schema.Cols)
+	}
+	if schema.Maximum != nil && len(schema.Maximum) != schema.Cols {
+		return fmt.Errorf("Number of maximums must match columns of schema: %d != %d", len(schema.Maximum), schema.Cols)
+	}
+	issues := []ValidationIssue{}
+	shapeIssue := func(reason string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{Frame: -1, Row: -1, Col: -1, Reason: fmt.Sprintf(reason, args...)})
+	}
+	if len(inputs) != schema.Frames {
+		shapeIssue("Frames must be %d, are: %d", schema.Frames, len(inputs))
+	}
+	for frame, rows := range inputs {
+		if len(rows) != schema.Rows {
+			shapeIssue("Rows of frame %d must be %d, are: %d", frame, schema.Rows, len(rows))
+		}
+		for row, cols := range rows {
+			if len(cols) != schema.Cols {
+				shapeIssue("Columns of row %d of frame %d must be %d, are: %d", row, frame, schema.Cols, len(cols))
+				continue
+			}
+			for col, value := range cols {
+				issue := ValidationIssue{Frame: frame, Row: row, Col: col}
+				if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+					issue.Reason = fmt.Sprintf("Value must be finite, is: %f", value)
+				} else if schema.Minimum != nil && value < schema.Minimum[col] {
+					issue.Reason = fmt.Sprintf("Value must be at least %f, is: %f", schema.Minimum[col], value)
+				} else if schema.Maximum != nil && value > schema.Maximum[col] {
+					issue.Reason = fmt.Sprintf("Value must be at most %f, is: %f", schema.Maximum[col], value)
+				} else {
+					continue
+				}
+				issues = append(issues, issue)
+			}
+		}
+	}
+	if len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// SetInputValidation sets whether predictions validate their inputs against the input schema in
+// the metadata of the neural network first, which protects against malformed inputs such as those
+// sent to a served model.
+func (neuralNetwork *NeuralNetwork) SetInputValidation(enabled bool) {
+	neuralNetwork.validateInputs = enabled
+}
+
+func (neuralNetwork *NeuralNetwork) validate(inputs [][][]float32) error {
+	if !neuralNetwork.validateInputs {
+		return nil
+	}
+	if neuralNetwork.metadata == nil {
+		return fmt.Errorf("Neural network must have metadata with an input schema to validate inputs")
+	}
+	return neuralNetwork.metadata.InputSchema.Validate(inputs)
+}
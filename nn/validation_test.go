@@ -0,0 +1,72 @@
+package nn
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	schema := Schema{Rows: 1, Cols: 3, Frames: 1, Minimum: []float32{0, 0, -1}, Maximum: []float32{1, 10, 1}}
+	err := schema.Validate([][][]float32{{{0.5, 5, 0}}})
+	if err != nil {
+		t.Errorf("Valid inputs should not have an error, have: %s", err.Error())
+	}
+
+	nan := float32(math.NaN())
+	err = schema.Validate([][][]float32{{{nan, 11, -0.5}}})
+	validationError, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("Invalid inputs should have a validation error, have: %v", err)
+	}
+	if len(validationError.Issues) != 2 {
+		t.Fatalf("Validation error should have 2 issues, has: %d", len(validationError.Issues))
+	}
+	if validationError.Issues[0].Col != 0 || validationError.Issues[1].Col != 1 {
+		t.Errorf("Issues should be at columns 0 and 1, are at: %d, %d", validationError.Issues[0].Col, validationError.Issues[1].Col)
+	}
+
+	err = schema.Validate([][][]float32{{{0, 0}, {0, 0, 0}}})
+	validationError, ok = err.(*ValidationError)
+	if !ok || len(validationError.Issues) != 2 || validationError.Issues[0].Frame != -1 {
+		t.Errorf("Inputs with the wrong shape should have 2 shape issues, have: %v", err)
+	}
+
+	invalidSchema := Schema{Rows: 1, Cols: 2, Frames: 1, Minimum: []float32{0}}
+	err = invalidSchema.Validate([][][]float32{{{0, 0}}})
+	if _, ok := err.(*ValidationError); err == nil || ok {
+		t.Errorf("Schema with the wrong number of minimums should have an error")
+	}
+}
+
+func TestPredictInputValidation(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(2, 1, ActivationSigmoid))
+	neuralNetwork.SetInputValidation(true)
+	_, err := neuralNetwork.Predict([][][]float32{{{0, 1}}})
+	if err == nil {
+		t.Errorf("Validating inputs without metadata should have an error")
+	}
+
+	metadata := NewMetadata(neuralNetwork, "model", "1")
+	metadata.InputSchema.Minimum = []float32{0, 0}
+	metadata.InputSchema.Maximum = []float32{1, 1}
+	neuralNetwork.SetMetadata(metadata)
+	_, err = neuralNetwork.Predict([][][]float32{{{0, 1}}})
+	if err != nil {
+		t.Errorf("Predicting valid inputs should not have an error, has: %s", err.Error())
+	}
+	_, err = neuralNetwork.Predict([][][]float32{{{0, 2}}})
+	if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("Predicting inputs out of range should have a validation error, has: %v", err)
+	}
+	_, err = neuralNetwork.Predict([][][]float32{{{0, 1, 0}}})
+	if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("Predicting inputs of the wrong shape should have a validation error, has: %v", err)
+	}
+
+	neuralNetwork.SetInputValidation(false)
+	_, err = neuralNetwork.Predict([][][]float32{{{0, 2}}})
+	if err != nil {
+		t.Errorf("Predicting without validation should not have an error, has: %s", err.Error())
+	}
+}
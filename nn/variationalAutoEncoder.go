@@ -0,0 +1,363 @@
+package nn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+
+	tsr "../tensor"
+)
+
+// VariationalAutoEncoder is an auto encoder that encodes inputs as a normal distribution over a
+// latent space, rather than a single point, so new outputs can be generated by decoding points of
+// the latent space. Its coding layers are followed by a mean layer and a log variance layer, which
+// are linear, and the decoding layers mirror the coding layers from the latent space back to the
+// inputs.
+type VariationalAutoEncoder struct {
+	inputSize        int
+	latentSize       int
+	klWeight         float32
+	encodingLayers   []*DenseLayer
+	meanLayer        *DenseLayer
+	logVarianceLayer *DenseLayer
+	decodingLayers   []*DenseLayer
+}
+
+// NewVariationalAutoEncoder creates a new instance of a variational auto encoder with a number of
+// inputs and a number of latent features. It needs at least one coding layer before it can be used.
+func NewVariationalAutoEncoder(inputSize int, latentSize int) *VariationalAutoEncoder {
+	return &VariationalAutoEncoder{
+		inputSize:      inputSize,
+		latentSize:     latentSize,
+		klWeight:       1,
+		encodingLayers: []*DenseLayer{},
+		decodingLayers: []*DenseLayer{},
+	}
+}
+
+// Copy creates a deep copy of the variational auto encoder.
+func (autoEncoder *VariationalAutoEncoder) Copy() *VariationalAutoEncoder {
+	newAutoEncoder := NewVariationalAutoEncoder(autoEncoder.inputSize, autoEncoder.latentSize)
+	newAutoEncoder.klWeight = autoEncoder.klWeight
+	for _, layer := range autoEncoder.encodingLayers {
+		newAutoEncoder.encodingLayers = append(newAutoEncoder.encodingLayers, layer.Copy().(*DenseLayer))
+	}
+	for _, layer := range autoEncoder.decodingLayers {
+		newAutoEncoder.decodingLayers = append(newAutoEncoder.decodingLayers, layer.Copy().(*DenseLayer))
+	}
+	if autoEncoder.meanLayer != nil {
+		newAutoEncoder.meanLayer = autoEncoder.meanLayer.Copy().(*DenseLayer)
+		newAutoEncoder.logVarianceLayer = autoEncoder.logVarianceLayer.Copy().(*DenseLayer)
+	}
+	return newAutoEncoder
+}
+
+// WithKLWeight sets the weight of the KL divergence in the loss, which is 1 by default. A weight
+// above 1 makes the latent features closer to independent, at the cost of the reconstructions.
+func (autoEncoder *VariationalAutoEncoder) WithKLWeight(weight float32) *VariationalAutoEncoder {
+	autoEncoder.klWeight = weight
+	return autoEncoder
+}
+
+// LayerCount returns the number of layers in the variational auto encoder, including the mean and
+// log variance layers.
+func (autoEncoder *VariationalAutoEncoder) LayerCount() int {
+	count := len(autoEncoder.encodingLayers) + len(autoEncoder.decodingLayers)
+	if autoEncoder.meanLayer != nil {
+		count += 2
+	}
+	return count
+}
+
+// AddCodingLayer adds an intermediate layer of features to the variational auto encoder, between
+// the previous coding layer and the latent space. The mean, log variance and first decoding layers
+// are replaced to fit the new layer.
+func (autoEncoder *VariationalAutoEncoder) AddCodingLayer(coded int, activation ActivationFunction) error {
+	if coded < 1 {
+		return fmt.Errorf("Size of coding layer must be at least 1, is: %d", coded)
+	}
+	var inputSize int
+	if len(autoEncoder.encodingLayers) > 0 {
+		inputSize = autoEncoder.encodingLayers[len(autoEncoder.encodingLayers)-1].OutputShape().Cols
+	} else {
+		inputSize = autoEncoder.inputSize
+	}
+	autoEncoder.encodingLayers = append(autoEncoder.encodingLayers, NewDenseLayer(inputSize, coded, activation))
+	// The first decoding layer comes from the latent space, so it is replaced by one to the new
+	// layer, followed by the mirror of the new layer.
+	decodingLayers := []*DenseLayer{
+		NewDenseLayer(autoEncoder.latentSize, coded, activation),
+		NewDenseLayer(coded, inputSize, activation),
+	}
+	if len(autoEncoder.decodingLayers) > 0 {
+		decodingLayers = append(decodingLayers, autoEncoder.decodingLayers[1:]...)
+	}
+	autoEncoder.decodingLayers = decodingLayers
+	autoEncoder.meanLayer = NewDenseLayer(coded, autoEncoder.latentSize, ActivationLinear)
+	autoEncoder.logVarianceLayer = NewDenseLayer(coded, autoEncoder.latentSize, ActivationLinear)
+	return nil
+}
+
+// Encode computes the mean and log variance of the distribution of the latent features for a
+// certain set of inputs.
+func (autoEncoder *VariationalAutoEncoder) Encode(inputs []float32) ([]float32, []float32, error) {
+	mean, logVariance, err := autoEncoder.encode(tsr.NewValueTensor1D(inputs))
+	if err != nil {
+		return nil, nil, err
+	}
+	return mean.GetFrame(0)[0], logVariance.GetFrame(0)[0], nil
+}
+
+// Decode decodes a point of the latent space to a set of outputs.
+func (autoEncoder *VariationalAutoEncoder) Decode(latent []float32) ([]float32, error) {
+	if len(autoEncoder.decodingLayers) == 0 {
+		return nil, fmt.Errorf("Variational auto encoder must have at least 1 coding layer")
+	}
+	if len(latent) != autoEncoder.latentSize {
+		return nil, fmt.Errorf("Number of latent features must be %d, is: %d", autoEncoder.latentSize, len(latent))
+	}
+	decoded, err := autoEncoder.decode(tsr.NewValueTensor1D(latent))
+	if err != nil {
+		return nil, err
+	}
+	return decoded.Copy().GetFrame(0)[0], nil
+}
+
+// Sample generates a new set of outputs by decoding a random point of the latent space, drawn from
+// the standard normal distribution that the encodings are trained towards.
+func (autoEncoder *VariationalAutoEncoder) Sample() ([]float32, error) {
+	latent := make([]float32, autoEncoder.latentSize)
+	for i := range latent {
+		latent[i] = float32(rand.NormFloat64())
+	}
+	return autoEncoder.Decode(latent)
+}
+
+// Loss computes the loss of a certain set of inputs, which is the mean squared error of the
+// reconstruction from the mean of the encoding, plus the weighted KL divergence of the encoding
+// from the standard normal distribution, divided by the number of inputs.
+func (autoEncoder *VariationalAutoEncoder) Loss(inputs []float32) (float32, error) {
+	inputsTensor := tsr.NewValueTensor1D(inputs)
+	mean, logVariance, err := autoEncoder.encode(inputsTensor)
+	if err != nil {
+		return 0, err
+	}
+	outputs, err := autoEncoder.decode(mean)
+	if err != nil {
+		return 0, err
+	}
+	loss, err := LossMSE.Function(outputs, inputsTensor)
+	if err != nil {
+		return 0, err
+	}
+	divergence := 0.0
+	for i := 0; i < autoEncoder.latentSize; i++ {
+		mu := float64(mean.Get(0, 0, i))
+		variance := float64(logVariance.Get(0, 0, i))
+		divergence += -0.5 * (1 + variance - mu*mu - math.Exp(variance))
+	}
+	return loss + autoEncoder.klWeight*float32(divergence)/float32(autoEncoder.inputSize), nil
+}
+
+// Train takes a set of inputs and adjusts the layers to reproduce them through a point sampled from
+// their encoding, using an optimizer to update the parameters. The point is the mean plus the
+// standard deviation times standard normal noise, so the loss can be propagated back to the mean
+// and log variance.
+func (autoEncoder *VariationalAutoEncoder) Train(inputs []float32, optimizer Optimizer) error {
+	inputsTensor := tsr.NewValueTensor1D(inputs)
+	mean, logVariance, err := autoEncoder.encode(inputsTensor)
+	if err != nil {
+		return err
+	}
+	noise := tsr.NewEmptyTensor1D(autoEncoder.latentSize)
+	noise.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return float32(rand.NormFloat64())
+	})
+	latent := tsr.NewEmptyTensor1D(autoEncoder.latentSize)
+	latent.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		standardDeviation := math.Exp(0.5 * float64(logVariance.Get(0, 0, col)))
+		return mean.Get(0, 0, col) + float32(standardDeviation)*noise.Get(0, 0, col)
+	})
+	outputs, err := autoEncoder.decode(latent)
+	if err != nil {
+		return err
+	}
+	deltas, err := LossMSE.Derivative(outputs, inputsTensor)
+	if err != nil {
+		return err
+	}
+	for i := len(autoEncoder.decodingLayers) - 1; i >= 0; i-- {
+		deltas, err = autoEncoder.decodingLayers[i].BackPropagate(deltas)
+		if err != nil {
+			return err
+		}
+	}
+	// The gradient of the KL divergence is the mean for the mean, and half of the variance minus 1
+	// for the log variance.
+	scale := autoEncoder.klWeight / float32(autoEncoder.inputSize)
+	meanDeltas := deltas.Copy()
+	meanDeltas.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return current + scale*mean.Get(0, 0, col)
+	})
+	logVarianceDeltas := deltas.Copy()
+	logVarianceDeltas.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		variance := float32(math.Exp(float64(logVariance.Get(0, 0, col))))
+		standardDeviation := float32(math.Sqrt(float64(variance)))
+		return current*noise.Get(0, 0, col)*0.5*standardDeviation + scale*0.5*(variance-1)
+	})
+	deltas, err = autoEncoder.meanLayer.BackPropagate(meanDeltas)
+	if err != nil {
+		return err
+	}
+	logVarianceInputDeltas, err := autoEncoder.logVarianceLayer.BackPropagate(logVarianceDeltas)
+	if err != nil {
+		return err
+	}
+	err = deltas.AddTensor(logVarianceInputDeltas)
+	if err != nil {
+		return err
+	}
+	for i := len(autoEncoder.encodingLayers) - 1; i >= 0; i-- {
+		deltas, err = autoEncoder.encodingLayers[i].BackPropagate(deltas)
+		if err != nil {
+			return err
+		}
+	}
+	for _, layer := range autoEncoder.layers() {
+		for i, parameter := range layer.Parameters() {
+			err = optimizer.Update(parameter, layer.Gradients()[i], 1)
+			if err != nil {
+				return err
+			}
+			layer.Gradients()[i].Scale(0)
+		}
+	}
+	return nil
+}
+
+// encode feeds inputs through the coding layers, and returns copies of the mean and log variance
+// of the latent features.
+func (autoEncoder *VariationalAutoEncoder) encode(inputs *tsr.Tensor) (*tsr.Tensor, *tsr.Tensor, error) {
+	if len(autoEncoder.encodingLayers) == 0 {
+		return nil, nil, fmt.Errorf("Variational auto encoder must have at least 1 coding layer")
+	}
+	if inputs.Cols != autoEncoder.inputSize {
+		return nil, nil, fmt.Errorf("Number of inputs must be %d, is: %d", autoEncoder.inputSize, inputs.Cols)
+	}
+	nextInputs := inputs
+	var err error
+	for _, layer := range autoEncoder.encodingLayers {
+		nextInputs, err = layer.FeedForward(nextInputs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	mean, err := autoEncoder.meanLayer.FeedForward(nextInputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	logVariance, err := autoEncoder.logVarianceLayer.FeedForward(nextInputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mean.Copy(), logVariance.Copy(), nil
+}
+
+func (autoEncoder *VariationalAutoEncoder) decode(latent *tsr.Tensor) (*tsr.Tensor, error) {
+	nextInputs := latent
+	var err error
+	for _, layer := range autoEncoder.decodingLayers {
+		nextInputs, err = layer.FeedForward(nextInputs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nextInputs, nil
+}
+
+func (autoEncoder *VariationalAutoEncoder) layers() []*DenseLayer {
+	layers := []*DenseLayer{}
+	layers = append(layers, autoEncoder.encodingLayers...)
+	if autoEncoder.meanLayer != nil {
+		layers = append(layers, autoEncoder.meanLayer, autoEncoder.logVarianceLayer)
+	}
+	return append(layers, autoEncoder.decodingLayers...)
+}
+
+// VariationalAutoEncoderData represents a serialized variational auto encoder.
+type VariationalAutoEncoderData struct {
+	InputSize        int           `json:"inputSize"`
+	LatentSize       int           `json:"latentSize"`
+	KLWeight         float32       `json:"klWeight"`
+	EncodingLayers   []*DenseLayer `json:"encodingLayers"`
+	MeanLayer        *DenseLayer   `json:"meanLayer"`
+	LogVarianceLayer *DenseLayer   `json:"logVarianceLayer"`
+	DecodingLayers   []*DenseLayer `json:"decodingLayers"`
+}
+
+// WriteTo writes a variational auto encoder to a writer as JSON. It returns the number of bytes
+// written.
+func (autoEncoder *VariationalAutoEncoder) WriteTo(writer io.Writer) (int64, error) {
+	data := VariationalAutoEncoderData{
+		InputSize:        autoEncoder.inputSize,
+		LatentSize:       autoEncoder.latentSize,
+		KLWeight:         autoEncoder.klWeight,
+		EncodingLayers:   autoEncoder.encodingLayers,
+		MeanLayer:        autoEncoder.meanLayer,
+		LogVarianceLayer: autoEncoder.logVarianceLayer,
+		DecodingLayers:   autoEncoder.decodingLayers,
+	}
+	counter := &countingWriter{writer: writer}
+	err := json.NewEncoder(counter).Encode(data)
+	return counter.count, err
+}
+
+// ReadFrom reads a variational auto encoder from JSON in a reader, replacing its layers. It returns
+// the number of bytes read, which can include bytes after the auto encoder that were buffered.
+func (autoEncoder *VariationalAutoEncoder) ReadFrom(reader io.Reader) (int64, error) {
+	data := VariationalAutoEncoderData{}
+	counter := &countingReader{reader: reader}
+	err := json.NewDecoder(counter).Decode(&data)
+	if err != nil {
+		return counter.count, err
+	}
+	if (data.MeanLayer == nil) != (len(data.EncodingLayers) == 0) || (data.MeanLayer == nil) != (data.LogVarianceLayer == nil) {
+		return counter.count, fmt.Errorf("Variational auto encoder must have mean and log variance layers after its coding layers")
+	}
+	if len(data.DecodingLayers) != len(data.EncodingLayers)+1 && len(data.EncodingLayers) > 0 {
+		return counter.count, fmt.Errorf("Number of decoding layers must be %d, is: %d", len(data.EncodingLayers)+1, len(data.DecodingLayers))
+	}
+	autoEncoder.inputSize = data.InputSize
+	autoEncoder.latentSize = data.LatentSize
+	autoEncoder.klWeight = data.KLWeight
+	autoEncoder.encodingLayers = data.EncodingLayers
+	autoEncoder.meanLayer = data.MeanLayer
+	autoEncoder.logVarianceLayer = data.LogVarianceLayer
+	autoEncoder.decodingLayers = data.DecodingLayers
+	return counter.count, nil
+}
+
+// SaveToFile saves a variational auto encoder to a file.
+func (autoEncoder *VariationalAutoEncoder) SaveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = autoEncoder.WriteTo(file)
+	return err
+}
+
+// LoadFromFile loads a variational auto encoder from a file.
+func (autoEncoder *VariationalAutoEncoder) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = autoEncoder.ReadFrom(file)
+	return err
+}
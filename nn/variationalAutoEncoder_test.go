@@ -0,0 +1,106 @@
+package nn
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestVariationalAutoEncoderTrain(t *testing.T) {
+	rand.Seed(1)
+	inputs := [][]float32{
+		{0.0, 0.0, 1.0, 1.0},
+		{0.0, 1.0, 1.0, 0.0},
+		{1.0, 0.0, 0.0, 1.0},
+	}
+	autoEncoder := NewVariationalAutoEncoder(4, 2).WithKLWeight(0.1)
+	_, err := autoEncoder.Sample()
+	if err == nil {
+		t.Errorf("Sampling without a coding layer did not trigger error")
+	}
+	autoEncoder.AddCodingLayer(8, ActivationSigmoid)
+	if autoEncoder.LayerCount() != 5 {
+		t.Errorf("Layer count should be 5, is: %d", autoEncoder.LayerCount())
+	}
+	totalLoss := func() float32 {
+		sum := float32(0.0)
+		for _, input := range inputs {
+			loss, err := autoEncoder.Loss(input)
+			if err != nil {
+				t.Fatalf("Error in Loss: %s", err.Error())
+			}
+			sum += loss
+		}
+		return sum
+	}
+
+	initialLoss := totalLoss()
+	optimizer := NewAdamOptimizer(0.01)
+	for i := 0; i < 3000; i++ {
+		err := autoEncoder.Train(inputs[rand.Intn(len(inputs))], optimizer)
+		if err != nil {
+			t.Fatalf("Error in Train: %s", err.Error())
+		}
+	}
+	finalLoss := totalLoss()
+	if finalLoss >= initialLoss/2 {
+		t.Errorf("Loss should at least halve while training: %f >= %f", finalLoss, initialLoss/2)
+	}
+
+	mean, logVariance, err := autoEncoder.Encode(inputs[0])
+	if err != nil {
+		t.Fatalf("Error in Encode: %s", err.Error())
+	}
+	if len(mean) != 2 || len(logVariance) != 2 {
+		t.Fatalf("Encoding should have 2 latent features, has: %d, %d", len(mean), len(logVariance))
+	}
+	decoded, err := autoEncoder.Decode(mean)
+	if err != nil {
+		t.Fatalf("Error in Decode: %s", err.Error())
+	}
+	for i := range decoded {
+		if decoded[i] < inputs[0][i]-0.3 || decoded[i] > inputs[0][i]+0.3 {
+			t.Errorf("Incorrect decode of mean at index %d: %.3f", i, decoded[i])
+		}
+	}
+	sample, err := autoEncoder.Sample()
+	if err != nil {
+		t.Fatalf("Error in Sample: %s", err.Error())
+	}
+	if len(sample) != 4 {
+		t.Errorf("Sample should have 4 outputs, has: %d", len(sample))
+	}
+	_, err = autoEncoder.Decode([]float32{0})
+	if err == nil {
+		t.Errorf("Decoding wrong number of latent features did not trigger error")
+	}
+}
+
+func TestVariationalAutoEncoderWriteToReadFrom(t *testing.T) {
+	autoEncoder := NewVariationalAutoEncoder(4, 2).WithKLWeight(0.5)
+	autoEncoder.AddCodingLayer(3, ActivationSigmoid)
+	autoEncoder.AddCodingLayer(3, ActivationTanh)
+
+	buffer := bytes.Buffer{}
+	_, err := autoEncoder.WriteTo(&buffer)
+	if err != nil {
+		t.Fatalf("Error in WriteTo: %s", err.Error())
+	}
+	loaded := NewVariationalAutoEncoder(1, 1)
+	_, err = loaded.ReadFrom(&buffer)
+	if err != nil {
+		t.Fatalf("Error in ReadFrom: %s", err.Error())
+	}
+	if loaded.LayerCount() != autoEncoder.LayerCount() || loaded.klWeight != 0.5 {
+		t.Errorf("Read variational auto encoder does not match original")
+	}
+	inputs := []float32{0.1, 0.2, 0.3, 0.4}
+	expected, _ := autoEncoder.Loss(inputs)
+	result, err := loaded.Loss(inputs)
+	if err != nil {
+		t.Fatalf("Error in Loss: %s", err.Error())
+	}
+	if result != expected {
+		t.Errorf("Loss of read variational auto encoder should match: %f != %f", result, expected)
+	}
+}
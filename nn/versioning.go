@@ -0,0 +1,67 @@
+package nn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// FormatVersion is the version of the format of saved neural networks. Neural networks saved
+// before the format had a version are read as version 0.
+const FormatVersion = 1
+
+// neuralNetworkData represents a serialized neural network that can be saved to a file.
+type neuralNetworkData struct {
+	FormatVersion int             `json:"formatVersion"`
+	Metadata      *Metadata       `json:"metadata,omitempty"`
+	InputShape    *LayerShape     `json:"inputShape,omitempty"`
+	OutputShape   *LayerShape     `json:"outputShape,omitempty"`
+	Checksum      string          `json:"checksum,omitempty"`
+	Layers        json.RawMessage `json:"layers"`
+}
+
+// check makes sure a saved neural network is not from a newer version of the format and that its
+// layers match their checksum.
+func (data neuralNetworkData) check() error {
+	if data.FormatVersion > FormatVersion {
+		return fmt.Errorf(
+			"Neural network was saved with format version %d, which is newer than the supported version %d",
+			data.FormatVersion, FormatVersion,
+		)
+	}
+	if data.FormatVersion == 0 || data.Layers == nil {
+		return nil
+	}
+	checksum, err := layersChecksum(data.Layers)
+	if err != nil {
+		return err
+	}
+	if checksum != data.Checksum {
+		return fmt.Errorf("Checksum of layers does not match, the neural network may be corrupted or edited: %s != %s", checksum, data.Checksum)
+	}
+	return nil
+}
+
+// checkShapes makes sure the shapes of the loaded layers match the saved input and output shapes.
+func (data neuralNetworkData) checkShapes(inputShape LayerShape, outputShape LayerShape) error {
+	if data.InputShape != nil && *data.InputShape != inputShape {
+		return fmt.Errorf("Input shape of layers does not match saved input shape: %s != %s", shapeString(inputShape), shapeString(*data.InputShape))
+	}
+	if data.OutputShape != nil && *data.OutputShape != outputShape {
+		return fmt.Errorf("Output shape of layers does not match saved output shape: %s != %s", shapeString(outputShape), shapeString(*data.OutputShape))
+	}
+	return nil
+}
+
+// layersChecksum computes the SHA-256 hash of the JSON of layers, ignoring whitespace.
+func layersChecksum(layers []byte) (string, error) {
+	compacted := bytes.Buffer{}
+	err := json.Compact(&compacted, layers)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(compacted.Bytes())
+	return hex.EncodeToString(hash[:]), nil
+}
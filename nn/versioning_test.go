@@ -0,0 +1,81 @@
+package nn
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNeuralNetworkVersioning(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(3, 4, ActivationSigmoid), NewDenseLayer(4, 2, ActivationSigmoid))
+	b, err := json.Marshal(neuralNetwork)
+	if err != nil {
+		t.Fatalf("Error in MarshalJSON: %s", err.Error())
+	}
+	saved := map[string]json.RawMessage{}
+	json.Unmarshal(b, &saved)
+	if string(saved["formatVersion"]) != "1" || string(saved["inputShape"]) != `{"rows":1,"cols":3,"frames":1}` || len(saved["checksum"]) != 66 {
+		t.Errorf("Saved neural network should have a version, shapes and checksum: %s", string(b))
+	}
+
+	changes := []struct {
+		name  string
+		key   string
+		value string
+		err   string
+	}{
+		{"newer version", "formatVersion", "99", "newer than the supported version"},
+		{"edited layers", "layers", `[{"type":"softmax","size":2}]`, "Checksum of layers does not match"},
+		{"wrong input shape", "inputShape", `{"rows":1,"cols":5,"frames":1}`, "Input shape of layers does not match"},
+		{"wrong output shape", "outputShape", `{"rows":1,"cols":3,"frames":1}`, "Output shape of layers does not match"},
+	}
+	for _, change := range changes {
+		changed := map[string]json.RawMessage{}
+		for key, value := range saved {
+			changed[key] = value
+		}
+		changed[change.key] = json.RawMessage(change.value)
+		b, _ := json.Marshal(changed)
+		err := json.Unmarshal(b, NewNeuralNetwork())
+		if err == nil || !strings.Contains(err.Error(), change.err) {
+			t.Errorf("Loading a neural network with %s should have an error with: %s, has: %v", change.name, change.err, err)
+		}
+	}
+
+	// Neural networks saved before the format had a version are loaded without checks.
+	legacy := NewNeuralNetwork()
+	err = json.Unmarshal([]byte(`{"layers":[{"type":"softmax","size":2}]}`), legacy)
+	if err != nil {
+		t.Fatalf("Error loading neural network without version: %s", err.Error())
+	}
+	if legacy.LayerCount() != 1 {
+		t.Errorf("Neural network without version should have 1 layer, has: %d", legacy.LayerCount())
+	}
+
+	err = json.Unmarshal([]byte(`{"layers":[{"type":"softmax","size":2},{"type":"unknown"}]}`), NewNeuralNetwork())
+	if err == nil || !strings.Contains(err.Error(), "Invalid layer 1") {
+		t.Errorf("Loading an unknown layer should have an error with its index, has: %v", err)
+	}
+}
+
+func TestNeuralNetworkBinaryChecksum(t *testing.T) {
+	neuralNetwork := NewNeuralNetwork()
+	neuralNetwork.Add(NewDenseLayer(8, 8, ActivationSigmoid))
+	err := neuralNetwork.SaveToFileBinary("checksum.bin")
+	if err != nil {
+		t.Fatalf("Error in SaveToFileBinary: %s", err.Error())
+	}
+	defer os.Remove("checksum.bin")
+
+	// Change a byte of the last weight, before the checksum at the end of the file.
+	b, _ := ioutil.ReadFile("checksum.bin")
+	b[len(b)-6] ^= 0xff
+	ioutil.WriteFile("checksum.bin", b, 0644)
+	err = NewNeuralNetwork().LoadFromFile("checksum.bin")
+	if err == nil || !strings.Contains(err.Error(), "Checksum of parameters does not match") {
+		t.Errorf("Loading changed parameters should have a checksum error, has: %v", err)
+	}
+}
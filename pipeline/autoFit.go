@@ -0,0 +1,233 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"../nn"
+	"../preprocess"
+	tsr "../tensor"
+)
+
+// TaskType is the kind of problem that AutoFit infers from the targets.
+type TaskType string
+
+const (
+	// TaskTypeBinaryClassification is the type for targets of a single column of 0 and 1.
+	TaskTypeBinaryClassification = TaskType("binaryClassification")
+
+	// TaskTypeClassification is the type for targets with a column for each class, where each row
+	// has a 1 for its class and 0 for the others, or for targets of a single column of class labels
+	// from 0 to less than autoFitMaxClasses.
+	TaskTypeClassification = TaskType("classification")
+
+	// TaskTypeMultiLabel is the type for targets with a column for each class, where each row has
+	// a 1 for every class that applies to it and 0 for the others.
+	TaskTypeMultiLabel = TaskType("multiLabel")
+
+	// TaskTypeRegression is the type for any other targets.
+	TaskTypeRegression = TaskType("regression")
+)
+
+// autoFitMaxClasses is the number of classes up to which a single column of whole numbers is
+// treated as class labels rather than as a regression target.
+const autoFitMaxClasses = 20
+
+// autoFitArchitectures are the sizes of the hidden layers of the neural networks that AutoFit tries,
+// from a linear or logistic model to two hidden layers.
+var autoFitArchitectures = [][]int{{}, {16}, {32, 16}}
+
+// AutoFitCandidate is the evaluation of one of the neural networks tried by AutoFit, on the samples
+// it held out from training.
+type AutoFitCandidate struct {
+	HiddenUnits    []int
+	ValidationLoss float32
+	Metrics        map[string]float32
+}
+
+// AutoFitReport is the inferred task of the targets and the evaluation of each candidate tried by
+// AutoFit, with the index of the best candidate.
+type AutoFitReport struct {
+	Task       TaskType
+	Candidates []AutoFitCandidate
+	Best       int
+}
+
+// AutoFit builds a quick model for tabular data. It infers the task from the targets, holds out a
+// random 20% of the samples, and trains pipelines of a robust scaler and neural networks of a few
+// sizes on the rest for a number of epochs. It returns the pipeline with the lowest loss on the
+// held out samples, along with a report of every candidate. Classification candidates report their
+// accuracy, multi-label candidates the fraction of labels they predict correctly, and regression
+// candidates their mean absolute error. For a single column of class labels, the pipeline predicts
+// the probability of each label from 0 to the largest label.
+func AutoFit(inputs *tsr.Tensor, targets *tsr.Tensor, epochs int) (*Pipeline, *AutoFitReport, error) {
+	if inputs.Rows != targets.Rows {
+		return nil, nil, fmt.Errorf("Number of inputs and targets must match: %d != %d", inputs.Rows, targets.Rows)
+	}
+	if inputs.Rows < 5 {
+		return nil, nil, fmt.Errorf("AutoFit needs at least 5 samples to hold out some of them, has: %d", inputs.Rows)
+	}
+	task := inferTask(targets)
+	if task == TaskTypeClassification && targets.Cols == 1 {
+		targets = oneHotLabels(targets)
+	}
+	trainingInputs, trainingTargets, validationInputs, validationTargets := splitRows(inputs, targets, 0.8)
+	report := &AutoFitReport{Task: task}
+	var best *Pipeline
+	for _, hiddenUnits := range autoFitArchitectures {
+		neuralNetwork, loss, metric, err := autoFitNeuralNetwork(task, inputs.Cols, targets.Cols, hiddenUnits)
+		if err != nil {
+			return nil, nil, err
+		}
+		neuralNetwork.SetLoss(loss)
+		candidate := NewPipeline(neuralNetwork, preprocess.NewRobustScaler())
+		err = candidate.Fit(trainingInputs, trainingTargets, 16, epochs, nn.NewAdamOptimizer(0.01))
+		if err != nil {
+			return nil, nil, err
+		}
+		transformed, err := candidate.Transform(validationInputs)
+		if err != nil {
+			return nil, nil, err
+		}
+		validationLoss, metrics, err := neuralNetwork.Evaluate(samplesOf(transformed), samplesOf(validationTargets), loss, metric)
+		if err != nil {
+			return nil, nil, err
+		}
+		report.Candidates = append(report.Candidates, AutoFitCandidate{
+			HiddenUnits:    hiddenUnits,
+			ValidationLoss: validationLoss,
+			Metrics:        metrics,
+		})
+		if best == nil || validationLoss < report.Candidates[report.Best].ValidationLoss {
+			best = candidate
+			report.Best = len(report.Candidates) - 1
+		}
+	}
+	return best, report, nil
+}
+
+// inferTask infers the task from the values of the targets. A single column is a binary
+// classification if it only has 0 and 1, and a classification if it only has whole numbers from 0
+// to less than autoFitMaxClasses. Several columns of only 0 and 1 are a classification if each row
+// has a single 1, and a multi-label classification otherwise. Any other targets are a regression.
+func inferTask(targets *tsr.Tensor) TaskType {
+	binary := true
+	labels := true
+	oneHot := true
+	for _, row := range targets.GetFrame(0) {
+		ones := 0
+		for _, value := range row {
+			if value != 0 && value != 1 {
+				binary = false
+			}
+			if value < 0 || value >= autoFitMaxClasses || value != float32(math.Floor(float64(value))) {
+				labels = false
+			}
+			if value == 1 {
+				ones++
+			}
+		}
+		oneHot = oneHot && ones == 1
+	}
+	switch {
+	case targets.Cols == 1 && binary:
+		return TaskTypeBinaryClassification
+	case targets.Cols == 1 && labels:
+		return TaskTypeClassification
+	case targets.Cols > 1 && binary && oneHot:
+		return TaskTypeClassification
+	case targets.Cols > 1 && binary:
+		return TaskTypeMultiLabel
+	default:
+		return TaskTypeRegression
+	}
+}
+
+// oneHotLabels converts a single column of class labels to a column for each label from 0 to the
+// largest label, with a 1 in the column of the label of each row.
+func oneHotLabels(targets *tsr.Tensor) *tsr.Tensor {
+	classes := 0
+	for _, row := range targets.GetFrame(0) {
+		if int(row[0])+1 > classes {
+			classes = int(row[0]) + 1
+		}
+	}
+	oneHot := tsr.NewEmptyTensor2D(targets.Rows, classes)
+	for i, row := range targets.GetFrame(0) {
+		oneHot.Set(0, i, int(row[0]), 1)
+	}
+	return oneHot
+}
+
+// autoFitNeuralNetwork builds a neural network with hidden layers of a number of units and an output
+// layer that suits the task, along with the loss and metric for the task.
+func autoFitNeuralNetwork(task TaskType, inputSize int, outputSize int, hiddenUnits []int) (*nn.NeuralNetwork, nn.LossFunction, nn.Metric, error) {
+	neuralNetwork := nn.NewNeuralNetwork()
+	size := inputSize
+	for _, units := range hiddenUnits {
+		err := neuralNetwork.Add(nn.NewDenseLayer(size, units, nn.ActivationRELU))
+		if err != nil {
+			return nil, nn.LossFunction{}, nn.Metric{}, err
+		}
+		size = units
+	}
+	var err error
+	switch task {
+	case TaskTypeBinaryClassification:
+		err = neuralNetwork.Add(nn.NewDenseLayer(size, outputSize, nn.ActivationSigmoid))
+		return neuralNetwork, nn.LossBinaryCrossEntropy, nn.MetricAccuracy, err
+	case TaskTypeClassification:
+		err = neuralNetwork.Add(nn.NewDenseLayer(size, outputSize, nn.ActivationLinear), nn.NewSoftmaxLayer(outputSize))
+		return neuralNetwork, nn.LossCrossEntropy, nn.MetricAccuracy, err
+	case TaskTypeMultiLabel:
+		err = neuralNetwork.Add(nn.NewDenseLayer(size, outputSize, nn.ActivationSigmoid))
+		return neuralNetwork, nn.LossBinaryCrossEntropy, metricLabelAccuracy, err
+	default:
+		err = neuralNetwork.Add(nn.NewDenseLayer(size, outputSize, nn.ActivationLinear))
+		return neuralNetwork, nn.LossMSE, nn.MetricMeanAbsoluteError, err
+	}
+}
+
+// metricLabelAccuracy is the fraction of the labels of multi-label targets that are predicted
+// correctly, where a label is predicted when its probability is at least 0.5.
+var metricLabelAccuracy = nn.Metric{
+	Name: "labelAccuracy",
+	Function: func(predictions []*tsr.Tensor, targets []*tsr.Tensor) (float32, error) {
+		correct := 0
+		total := 0
+		for i, prediction := range predictions {
+			if targets[i].Cols != prediction.Cols {
+				return 0, fmt.Errorf("Number of labels of predictions and targets must match: %d != %d", prediction.Cols, targets[i].Cols)
+			}
+			for col := 0; col < prediction.Cols; col++ {
+				if (prediction.Get(0, 0, col) >= 0.5) == (targets[i].Get(0, 0, col) >= 0.5) {
+					correct++
+				}
+				total++
+			}
+		}
+		if total == 0 {
+			return 0, nil
+		}
+		return float32(correct) / float32(total), nil
+	},
+}
+
+// splitRows shuffles the rows of the inputs and targets the same way, and splits them into a
+// fraction of the rows and the rest.
+func splitRows(inputs *tsr.Tensor, targets *tsr.Tensor, fraction float32) (*tsr.Tensor, *tsr.Tensor, *tsr.Tensor, *tsr.Tensor) {
+	inputRows := inputs.GetFrame(0)
+	targetRows := targets.GetFrame(0)
+	order := rand.Perm(inputs.Rows)
+	size := int(fraction * float32(inputs.Rows))
+	rowsAt := func(rows [][]float32, indices []int) *tsr.Tensor {
+		selected := make([][]float32, len(indices))
+		for i, index := range indices {
+			selected[i] = rows[index]
+		}
+		return tsr.NewValueTensor2D(selected)
+	}
+	return rowsAt(inputRows, order[:size]), rowsAt(targetRows, order[:size]),
+		rowsAt(inputRows, order[size:]), rowsAt(targetRows, order[size:])
+}
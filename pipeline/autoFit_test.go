@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"math/rand"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestAutoFitClassification(t *testing.T) {
+	rand.Seed(1)
+	// The class depends on which side of a circle the point is, with the first feature on a large
+	// scale.
+	inputs := make([][]float32, 200)
+	targets := make([][]float32, 200)
+	for i := range inputs {
+		x, y := rand.Float32()*2-1, rand.Float32()*2-1
+		inputs[i] = []float32{1000 * x, y}
+		targets[i] = []float32{1, 0}
+		if x*x+y*y < 0.5 {
+			targets[i] = []float32{0, 1}
+		}
+	}
+	pipeline, report, err := AutoFit(tsr.NewValueTensor2D(inputs), tsr.NewValueTensor2D(targets), 60)
+	if err != nil {
+		t.Fatalf("Error in AutoFit: %s", err.Error())
+	}
+	if report.Task != TaskTypeClassification {
+		t.Errorf("Task should be classification, is: %s", report.Task)
+	}
+	if len(report.Candidates) != len(autoFitArchitectures) {
+		t.Fatalf("Report should have %d candidates, has: %d", len(autoFitArchitectures), len(report.Candidates))
+	}
+	for _, candidate := range report.Candidates {
+		if candidate.ValidationLoss < report.Candidates[report.Best].ValidationLoss {
+			t.Errorf("Best candidate should have the lowest validation loss: %v", report.Candidates)
+		}
+	}
+	// A circle cannot be separated by a logistic model, so a network with hidden layers wins.
+	if len(report.Candidates[report.Best].HiddenUnits) == 0 {
+		t.Errorf("Best candidate should have hidden layers: %v", report.Candidates)
+	}
+	if report.Candidates[report.Best].Metrics["accuracy"] < 0.85 {
+		t.Errorf("Best candidate should have an accuracy of at least 0.85, is: %v", report.Candidates[report.Best].Metrics)
+	}
+	predictions, err := pipeline.Predict(tsr.NewValueTensor2D([][]float32{{0, 0}, {900, 0.9}}))
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	if predictions.Get(0, 0, 1) < 0.5 || predictions.Get(0, 1, 0) < 0.5 {
+		t.Errorf("Points inside and outside the circle should be classified correctly: %v", predictions.GetFrame(0))
+	}
+}
+
+func TestAutoFitTasks(t *testing.T) {
+	rand.Seed(1)
+	inputs := make([][]float32, 40)
+	binaryTargets := make([][]float32, 40)
+	regressionTargets := make([][]float32, 40)
+	for i := range inputs {
+		x := rand.Float32()
+		inputs[i] = []float32{x}
+		binaryTargets[i] = []float32{0}
+		if x > 0.5 {
+			binaryTargets[i][0] = 1
+		}
+		regressionTargets[i] = []float32{3*x + 1}
+	}
+	_, report, err := AutoFit(tsr.NewValueTensor2D(inputs), tsr.NewValueTensor2D(binaryTargets), 5)
+	if err != nil {
+		t.Fatalf("Error in AutoFit: %s", err.Error())
+	}
+	if report.Task != TaskTypeBinaryClassification {
+		t.Errorf("Task should be binary classification, is: %s", report.Task)
+	}
+	_, report, err = AutoFit(tsr.NewValueTensor2D(inputs), tsr.NewValueTensor2D(regressionTargets), 5)
+	if err != nil {
+		t.Fatalf("Error in AutoFit: %s", err.Error())
+	}
+	if report.Task != TaskTypeRegression {
+		t.Errorf("Task should be regression, is: %s", report.Task)
+	}
+	if _, ok := report.Candidates[0].Metrics["meanAbsoluteError"]; !ok {
+		t.Errorf("Regression candidates should report the mean absolute error: %v", report.Candidates[0].Metrics)
+	}
+
+	_, _, err = AutoFit(tsr.NewValueTensor2D(inputs), tsr.NewValueTensor2D(regressionTargets[:10]), 5)
+	if err == nil {
+		t.Errorf("Mismatched inputs and targets did not trigger error")
+	}
+	_, _, err = AutoFit(tsr.NewValueTensor2D(inputs[:4]), tsr.NewValueTensor2D(regressionTargets[:4]), 5)
+	if err == nil {
+		t.Errorf("Too few samples did not trigger error")
+	}
+}
+
+func TestAutoFitLabels(t *testing.T) {
+	rand.Seed(1)
+	inputs := make([][]float32, 60)
+	labelTargets := make([][]float32, 60)
+	multiLabelTargets := make([][]float32, 60)
+	for i := range inputs {
+		x, y := rand.Float32(), rand.Float32()
+		inputs[i] = []float32{x, y}
+		labelTargets[i] = []float32{float32(int(x * 3))}
+		multiLabelTargets[i] = []float32{0, 0, 0}
+		if x > 0.5 {
+			multiLabelTargets[i][0] = 1
+		}
+		if y > 0.5 {
+			multiLabelTargets[i][1] = 1
+		}
+	}
+	pipeline, report, err := AutoFit(tsr.NewValueTensor2D(inputs), tsr.NewValueTensor2D(labelTargets), 5)
+	if err != nil {
+		t.Fatalf("Error in AutoFit: %s", err.Error())
+	}
+	if report.Task != TaskTypeClassification {
+		t.Errorf("Task should be classification, is: %s", report.Task)
+	}
+	if _, ok := report.Candidates[0].Metrics["accuracy"]; !ok {
+		t.Errorf("Classification candidates should report the accuracy: %v", report.Candidates[0].Metrics)
+	}
+	predictions, err := pipeline.Predict(tsr.NewValueTensor2D(inputs[:1]))
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	if predictions.Cols != 3 {
+		t.Errorf("Predictions should have a probability for each of 3 labels, have: %d", predictions.Cols)
+	}
+
+	_, report, err = AutoFit(tsr.NewValueTensor2D(inputs), tsr.NewValueTensor2D(multiLabelTargets), 5)
+	if err != nil {
+		t.Fatalf("Error in AutoFit: %s", err.Error())
+	}
+	if report.Task != TaskTypeMultiLabel {
+		t.Errorf("Task should be multi-label, is: %s", report.Task)
+	}
+	if _, ok := report.Candidates[0].Metrics["labelAccuracy"]; !ok {
+		t.Errorf("Multi-label candidates should report the label accuracy: %v", report.Candidates[0].Metrics)
+	}
+}
+
+func TestInferTask(t *testing.T) {
+	tests := []struct {
+		targets [][]float32
+		task    TaskType
+	}{
+		{[][]float32{{0}, {1}, {1}}, TaskTypeBinaryClassification},
+		{[][]float32{{0}, {2}, {1}}, TaskTypeClassification},
+		{[][]float32{{0, 1}, {1, 0}}, TaskTypeClassification},
+		{[][]float32{{0, 1}, {1, 1}, {0, 0}}, TaskTypeMultiLabel},
+		{[][]float32{{0}, {2.5}, {1}}, TaskTypeRegression},
+		{[][]float32{{-1}, {2}, {1}}, TaskTypeRegression},
+		{[][]float32{{0}, {40}, {1}}, TaskTypeRegression},
+		{[][]float32{{0, 2}, {1, 0}}, TaskTypeRegression},
+	}
+	for _, test := range tests {
+		task := inferTask(tsr.NewValueTensor2D(test.targets))
+		if task != test.task {
+			t.Errorf("Task of %v should be %s, is: %s", test.targets, test.task, task)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"../nn"
+	"../preprocess"
+	tsr "../tensor"
+)
+
+// Transform is a preprocessing step that is fit to training data and then applied the same way to
+// any data, where each row of the data is a sample and each column is a feature.
+type Transform interface {
+	Fit(data *tsr.Tensor) error
+	Transform(data *tsr.Tensor) (*tsr.Tensor, error)
+}
+
+// TargetTransform is a transform that is fit to the targets as well as the data, such as a feature
+// selector that keeps the features most related to the classes.
+type TargetTransform interface {
+	Transform
+	FitTargets(data *tsr.Tensor, targets *tsr.Tensor) error
+}
+
+// TransformType is the identifying type of a transform in a saved pipeline.
+type TransformType string
+
+const (
+	// TransformTypeRobustScaler is the type for a robust scaler.
+	TransformTypeRobustScaler = TransformType("robustScaler")
+
+	// TransformTypeWhitening is the type for a whitening transform.
+	TransformTypeWhitening = TransformType("whitening")
+
+	// TransformTypeRandomProjection is the type for a random projection.
+	TransformTypeRandomProjection = TransformType("randomProjection")
+
+	// TransformTypeFeatureSelector is the type for a feature selector.
+	TransformTypeFeatureSelector = TransformType("featureSelector")
+
+	// TransformTypeOrdinalEncoder is the type for an ordinal encoder.
+	TransformTypeOrdinalEncoder = TransformType("ordinalEncoder")
+
+	// TransformTypeTargetEncoder is the type for a target encoder.
+	TransformTypeTargetEncoder = TransformType("targetEncoder")
+
+	// TransformTypeOutlierDetector is the type for an outlier detector.
+	TransformTypeOutlierDetector = TransformType("outlierDetector")
+)
+
+// Pipeline chains preprocessing transforms with a neural network. The transforms are fit to the
+// training data and saved along with the neural network, so data is always preprocessed the same
+// way for predictions as it was for training.
+type Pipeline struct {
+	Transforms    []Transform
+	NeuralNetwork *nn.NeuralNetwork
+}
+
+// NewPipeline creates a new pipeline that applies transforms in order before a neural network.
+func NewPipeline(neuralNetwork *nn.NeuralNetwork, transforms ...Transform) *Pipeline {
+	return &Pipeline{
+		Transforms:    transforms,
+		NeuralNetwork: neuralNetwork,
+	}
+}
+
+// Fit fits each transform to the inputs as transformed by the transforms before it, along with the
+// targets for a TargetTransform, and then trains the neural network on the transformed inputs in
+// batches for a number of epochs. Each row of the inputs and targets is a sample.
+func (pipeline *Pipeline) Fit(inputs *tsr.Tensor, targets *tsr.Tensor, batchSize int, epochs int, optimizer nn.Optimizer) error {
+	if inputs.Rows != targets.Rows {
+		return fmt.Errorf("Number of inputs and targets must match: %d != %d", inputs.Rows, targets.Rows)
+	}
+	transformed := inputs
+	for _, transform := range pipeline.Transforms {
+		var err error
+		if targetTransform, ok := transform.(TargetTransform); ok {
+			err = targetTransform.FitTargets(transformed, targets)
+		} else {
+			err = transform.Fit(transformed)
+		}
+		if err != nil {
+			return err
+		}
+		transformed, err = transform.Transform(transformed)
+		if err != nil {
+			return err
+		}
+	}
+	return pipeline.NeuralNetwork.TrainBatchSchedule(
+		samplesOf(transformed),
+		samplesOf(targets),
+		batchSize,
+		epochs,
+		nn.ConstantSchedule(optimizer.LearningRate()),
+		optimizer,
+	)
+}
+
+// Transform applies the fitted transforms to the inputs.
+func (pipeline *Pipeline) Transform(inputs *tsr.Tensor) (*tsr.Tensor, error) {
+	transformed := inputs
+	for _, transform := range pipeline.Transforms {
+		var err error
+		transformed, err = transform.Transform(transformed)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return transformed, nil
+}
+
+// Predict transforms the inputs and makes a prediction for each row, returning the predictions as
+// the rows of a tensor.
+func (pipeline *Pipeline) Predict(inputs *tsr.Tensor) (*tsr.Tensor, error) {
+	transformed, err := pipeline.Transform(inputs)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]*tsr.Tensor, transformed.Rows)
+	for i, row := range transformed.GetFrame(0) {
+		samples[i] = tsr.NewValueTensor1D(row)
+	}
+	outputs, err := pipeline.NeuralNetwork.PredictBatch(samples)
+	if err != nil {
+		return nil, err
+	}
+	predictions := make([][]float32, len(outputs))
+	for i, output := range outputs {
+		predictions[i] = output.GetFrame(0)[0]
+	}
+	return tsr.NewValueTensor2D(predictions), nil
+}
+
+func samplesOf(data *tsr.Tensor) [][][][]float32 {
+	samples := make([][][][]float32, data.Rows)
+	for i, row := range data.GetFrame(0) {
+		samples[i] = [][][]float32{{row}}
+	}
+	return samples
+}
+
+// TransformData represents a serialized transform in a pipeline.
+type TransformData struct {
+	Type      TransformType   `json:"type"`
+	Transform json.RawMessage `json:"transform"`
+}
+
+// PipelineData represents a serialized pipeline that can be saved to a file.
+type PipelineData struct {
+	Transforms    []TransformData   `json:"transforms"`
+	NeuralNetwork *nn.NeuralNetwork `json:"neuralNetwork"`
+}
+
+// MarshalJSON converts the pipeline to JSON.
+func (pipeline *Pipeline) MarshalJSON() ([]byte, error) {
+	data := PipelineData{
+		Transforms:    make([]TransformData, len(pipeline.Transforms)),
+		NeuralNetwork: pipeline.NeuralNetwork,
+	}
+	for i, transform := range pipeline.Transforms {
+		transformType, err := typeOfTransform(transform)
+		if err != nil {
+			return nil, err
+		}
+		transformData, err := json.Marshal(transform)
+		if err != nil {
+			return nil, err
+		}
+		data.Transforms[i] = TransformData{Type: transformType, Transform: transformData}
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new pipeline from JSON.
+func (pipeline *Pipeline) UnmarshalJSON(b []byte) error {
+	data := PipelineData{NeuralNetwork: nn.NewNeuralNetwork()}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	transforms := make([]Transform, len(data.Transforms))
+	for i, transformData := range data.Transforms {
+		transforms[i], err = transformOfType(transformData.Type)
+		if err != nil {
+			return err
+		}
+		err = json.Unmarshal(transformData.Transform, transforms[i])
+		if err != nil {
+			return err
+		}
+	}
+	pipeline.Transforms = transforms
+	pipeline.NeuralNetwork = data.NeuralNetwork
+	return nil
+}
+
+// SaveToFile saves a pipeline to a file.
+func (pipeline *Pipeline) SaveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(pipeline)
+}
+
+// LoadFromFile loads a pipeline from a file.
+func (pipeline *Pipeline) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewDecoder(file).Decode(pipeline)
+}
+
+func typeOfTransform(transform Transform) (TransformType, error) {
+	switch transform.(type) {
+	case *preprocess.RobustScaler:
+		return TransformTypeRobustScaler, nil
+	case *preprocess.Whitening:
+		return TransformTypeWhitening, nil
+	case *preprocess.RandomProjection:
+		return TransformTypeRandomProjection, nil
+	case *preprocess.FeatureSelector:
+		return TransformTypeFeatureSelector, nil
+	case *preprocess.OrdinalEncoder:
+		return TransformTypeOrdinalEncoder, nil
+	case *preprocess.TargetEncoder:
+		return TransformTypeTargetEncoder, nil
+	case *preprocess.OutlierDetector:
+		return TransformTypeOutlierDetector, nil
+	default:
+		return "", fmt.Errorf("Transform cannot be saved: %T", transform)
+	}
+}
+
+func transformOfType(transformType TransformType) (Transform, error) {
+	switch transformType {
+	case TransformTypeRobustScaler:
+		return preprocess.NewRobustScaler(), nil
+	case TransformTypeWhitening:
+		return &preprocess.Whitening{}, nil
+	case TransformTypeRandomProjection:
+		return &preprocess.RandomProjection{}, nil
+	case TransformTypeFeatureSelector:
+		return &preprocess.FeatureSelector{}, nil
+	case TransformTypeOrdinalEncoder:
+		return &preprocess.OrdinalEncoder{}, nil
+	case TransformTypeTargetEncoder:
+		return &preprocess.TargetEncoder{}, nil
+	case TransformTypeOutlierDetector:
+		return &preprocess.OutlierDetector{}, nil
+	default:
+		return nil, fmt.Errorf("Invalid transform type: %s", transformType)
+	}
+}
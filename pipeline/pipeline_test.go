@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"math/rand"
+	"os"
+	"testing"
+
+	"../nn"
+	"../preprocess"
+	tsr "../tensor"
+)
+
+type identityTransform struct{}
+
+func (transform identityTransform) Fit(data *tsr.Tensor) error {
+	return nil
+}
+
+func (transform identityTransform) Transform(data *tsr.Tensor) (*tsr.Tensor, error) {
+	return data, nil
+}
+
+func TestPipelineFitPredict(t *testing.T) {
+	rand.Seed(1)
+	neuralNetwork := nn.NewNeuralNetwork()
+	neuralNetwork.Add(nn.NewDenseLayer(2, 1, nn.ActivationSigmoid))
+	neuralNetwork.SetLoss(nn.LossBinaryCrossEntropy)
+	pipeline := NewPipeline(neuralNetwork, preprocess.NewRobustScaler())
+
+	// The first feature is on a large scale, which the network cannot learn from without scaling.
+	inputs := make([][]float32, 64)
+	targets := make([][]float32, 64)
+	for i := range inputs {
+		inputs[i] = []float32{1000 + rand.Float32()*1000, rand.Float32()}
+		targets[i] = []float32{0}
+		if inputs[i][0] > 1500 {
+			targets[i][0] = 1
+		}
+	}
+	err := pipeline.Fit(tsr.NewValueTensor2D(inputs), tsr.NewValueTensor2D(targets), 8, 100, nn.NewAdamOptimizer(0.05))
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+	predictions, err := pipeline.Predict(tsr.NewValueTensor2D(inputs))
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	if predictions.Rows != 64 || predictions.Cols != 1 {
+		t.Fatalf("Predictions should have shape (64, 1), is: (%d, %d)", predictions.Rows, predictions.Cols)
+	}
+	correct := 0
+	for i := range inputs {
+		if (predictions.Get(0, i, 0) > 0.5) == (targets[i][0] == 1) {
+			correct++
+		}
+	}
+	if correct < 60 {
+		t.Errorf("Pipeline should classify at least 60 of 64 samples, is: %d", correct)
+	}
+
+	err = pipeline.Fit(tsr.NewValueTensor2D(inputs), tsr.NewValueTensor2D(targets[:10]), 8, 1, nn.NewAdamOptimizer(0.05))
+	if err == nil {
+		t.Errorf("Mismatched inputs and targets did not trigger error")
+	}
+}
+
+func TestPipelineSaveLoad(t *testing.T) {
+	rand.Seed(1)
+	neuralNetwork := nn.NewNeuralNetwork()
+	neuralNetwork.Add(nn.NewDenseLayer(2, 2, nn.ActivationTanh))
+	pipeline := NewPipeline(
+		neuralNetwork,
+		preprocess.NewRobustScaler(),
+		preprocess.NewWhitening(preprocess.WhiteningTypeZCA, 1e-5),
+		preprocess.NewRandomProjection(preprocess.ProjectionTypeGaussian, 2),
+	)
+	data := tsr.NewEmptyTensor2D(20, 3)
+	data.SetRandom(-5, 5)
+	targets := tsr.NewEmptyTensor2D(20, 2)
+	err := pipeline.Fit(data, targets, 4, 1, nn.NewSGDOptimizer(0.1, 0))
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+	predictions, err := pipeline.Predict(data)
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+
+	err = pipeline.SaveToFile("pipeline.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+	defer os.Remove("pipeline.json")
+	loaded := &Pipeline{}
+	err = loaded.LoadFromFile("pipeline.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+	if len(loaded.Transforms) != 3 {
+		t.Fatalf("Loaded pipeline should have 3 transforms, has: %d", len(loaded.Transforms))
+	}
+	loadedPredictions, err := loaded.Predict(data)
+	if err != nil {
+		t.Fatalf("Error in Predict: %s", err.Error())
+	}
+	if !loadedPredictions.Equals(predictions) {
+		t.Errorf("Loaded pipeline predictions should be:\n%swhen result is:\n%s", predictions.String(), loadedPredictions.String())
+	}
+
+	pipeline.Transforms = append(pipeline.Transforms, identityTransform{})
+	err = pipeline.SaveToFile("pipeline.json")
+	if err == nil {
+		t.Errorf("Saving a transform of an unknown type did not trigger error")
+	}
+}
+
+func TestPipelineFitTargets(t *testing.T) {
+	neuralNetwork := nn.NewNeuralNetwork()
+	neuralNetwork.Add(nn.NewDenseLayer(1, 1, nn.ActivationSigmoid))
+	selector := preprocess.NewMutualInformationSelector(1)
+	pipeline := NewPipeline(neuralNetwork, selector)
+
+	inputs := tsr.NewValueTensor2D([][]float32{{5, 0}, {5, 1}, {3, 0}, {3, 1}})
+	targets := tsr.NewValueTensor2D([][]float32{{0}, {1}, {0}, {1}})
+	err := pipeline.Fit(inputs, targets, 2, 1, nn.NewSGDOptimizer(0.1, 0))
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+	if len(selector.Columns) != 1 || selector.Columns[0] != 1 {
+		t.Errorf("Feature selector should be fit to the targets and keep column 1, keeps: %v", selector.Columns)
+	}
+}
@@ -0,0 +1,298 @@
+package preprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	tsr "../tensor"
+)
+
+// OrdinalEncoder is a transform that replaces the categories of some columns of data with their
+// number in the sorted order of the categories seen while fitting, so categories coded with any
+// values become 0, 1, 2 and so on. Categories that were not seen while fitting become -1. Each row
+// of the data is a sample and each column is a feature, and other columns are left unchanged.
+type OrdinalEncoder struct {
+	Columns    []int
+	Features   int
+	Categories [][]float32
+}
+
+// NewOrdinalEncoder creates a new instance of an ordinal encoder for a number of columns.
+func NewOrdinalEncoder(columns ...int) *OrdinalEncoder {
+	return &OrdinalEncoder{Columns: columns}
+}
+
+// Fit finds the sorted categories of each encoded column.
+func (encoder *OrdinalEncoder) Fit(data *tsr.Tensor) error {
+	categories, err := categoriesOf(data, encoder.Columns)
+	if err != nil {
+		return err
+	}
+	encoder.Features = data.Cols
+	encoder.Categories = categories
+	return nil
+}
+
+// Transform replaces the categories of the encoded columns with their numbers.
+func (encoder *OrdinalEncoder) Transform(data *tsr.Tensor) (*tsr.Tensor, error) {
+	if encoder.Categories == nil {
+		return nil, fmt.Errorf("Ordinal encoder must be fit before transforming data")
+	}
+	if data.Cols != encoder.Features {
+		return nil, fmt.Errorf("Number of features must match fitted data: %d != %d", data.Cols, encoder.Features)
+	}
+	return encodeColumns(data, encoder.Columns, encoder.Categories, func(i int, index int) float32 {
+		return float32(index)
+	}, -1), nil
+}
+
+// TargetEncoder is a transform that replaces the categories of some columns of data with the mean
+// of the targets of the samples in each category. The mean of a category is smoothed towards the
+// mean of all targets, with the smoothing acting as a number of extra samples at the overall mean,
+// so rare categories do not get extreme values. Categories that were not seen while fitting become
+// the overall mean. Each row of the data is a sample and each column is a feature, and other
+// columns are left unchanged.
+type TargetEncoder struct {
+	Columns    []int
+	Smoothing  float32
+	Features   int
+	Mean       float32
+	Categories [][]float32
+	Encodings  [][]float32
+}
+
+// NewTargetEncoder creates a new instance of a target encoder for a number of columns, with an
+// amount of smoothing.
+func NewTargetEncoder(smoothing float32, columns ...int) *TargetEncoder {
+	return &TargetEncoder{
+		Columns:   columns,
+		Smoothing: smoothing,
+	}
+}
+
+// Fit returns an error, since a target encoder must be fit with the targets.
+func (encoder *TargetEncoder) Fit(data *tsr.Tensor) error {
+	return fmt.Errorf("Target encoder must be fit with targets")
+}
+
+// FitTargets computes the smoothed mean of the targets for each category of the encoded columns.
+// The targets have a row for each sample with a single column.
+func (encoder *TargetEncoder) FitTargets(data *tsr.Tensor, targets *tsr.Tensor) error {
+	if targets.Rows != data.Rows {
+		return fmt.Errorf("Number of samples and targets must match: %d != %d", data.Rows, targets.Rows)
+	}
+	if targets.Cols != 1 {
+		return fmt.Errorf("Targets must have a single column, have: %d", targets.Cols)
+	}
+	if encoder.Smoothing < 0 {
+		return fmt.Errorf("Smoothing must not be negative, is: %g", encoder.Smoothing)
+	}
+	categories, err := categoriesOf(data, encoder.Columns)
+	if err != nil {
+		return err
+	}
+	mean := tsr.Mean(targets)
+	smoothing := float64(encoder.Smoothing)
+	encodings := make([][]float32, len(encoder.Columns))
+	for i, col := range encoder.Columns {
+		sums := make([]float64, len(categories[i]))
+		counts := make([]float64, len(categories[i]))
+		for row := 0; row < data.Rows; row++ {
+			index := categoryIndex(categories[i], data.Get(0, row, col))
+			sums[index] += float64(targets.Get(0, row, 0))
+			counts[index]++
+		}
+		encodings[i] = make([]float32, len(categories[i]))
+		for index := range encodings[i] {
+			encodings[i][index] = float32((sums[index] + smoothing*float64(mean)) / (counts[index] + smoothing))
+		}
+	}
+	encoder.Features = data.Cols
+	encoder.Mean = mean
+	encoder.Categories = categories
+	encoder.Encodings = encodings
+	return nil
+}
+
+// Transform replaces the categories of the encoded columns with their smoothed target means.
+func (encoder *TargetEncoder) Transform(data *tsr.Tensor) (*tsr.Tensor, error) {
+	if encoder.Categories == nil {
+		return nil, fmt.Errorf("Target encoder must be fit before transforming data")
+	}
+	if data.Cols != encoder.Features {
+		return nil, fmt.Errorf("Number of features must match fitted data: %d != %d", data.Cols, encoder.Features)
+	}
+	return encodeColumns(data, encoder.Columns, encoder.Categories, func(i int, index int) float32 {
+		return encoder.Encodings[i][index]
+	}, encoder.Mean), nil
+}
+
+// categoriesOf returns the sorted distinct values of each of the columns of the data.
+func categoriesOf(data *tsr.Tensor, columns []int) ([][]float32, error) {
+	categories := make([][]float32, len(columns))
+	for i, col := range columns {
+		if col < 0 || col >= data.Cols {
+			return nil, fmt.Errorf("Invalid column for %d features: %d", data.Cols, col)
+		}
+		seen := map[float32]bool{}
+		categories[i] = []float32{}
+		for row := 0; row < data.Rows; row++ {
+			value := data.Get(0, row, col)
+			if !seen[value] {
+				seen[value] = true
+				categories[i] = append(categories[i], value)
+			}
+		}
+		sort.Slice(categories[i], func(a, b int) bool {
+			return categories[i][a] < categories[i][b]
+		})
+	}
+	return categories, nil
+}
+
+// categoryIndex returns the index of a value in sorted categories, or -1 if it is not one of them.
+func categoryIndex(categories []float32, value float32) int {
+	index := sort.Search(len(categories), func(i int) bool {
+		return categories[i] >= value
+	})
+	if index < len(categories) && categories[index] == value {
+		return index
+	}
+	return -1
+}
+
+// encodeColumns replaces the values of the encoded columns of a copy of the data with the encoding
+// of their category, or an unknown value for categories that are not found.
+func encodeColumns(data *tsr.Tensor, columns []int, categories [][]float32, encoding func(int, int) float32, unknown float32) *tsr.Tensor {
+	encoded := map[int]int{}
+	for i, col := range columns {
+		encoded[col] = i
+	}
+	result := data.Copy()
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		i, ok := encoded[col]
+		if !ok {
+			return current
+		}
+		index := categoryIndex(categories[i], current)
+		if index < 0 {
+			return unknown
+		}
+		return encoding(i, index)
+	})
+	return result
+}
+
+// OrdinalEncoderData represents a serialized ordinal encoder that can be saved to a file.
+type OrdinalEncoderData struct {
+	Columns    []int       `json:"columns"`
+	Features   int         `json:"features"`
+	Categories [][]float32 `json:"categories"`
+}
+
+// MarshalJSON converts the transform to JSON.
+func (encoder *OrdinalEncoder) MarshalJSON() ([]byte, error) {
+	data := OrdinalEncoderData{
+		Columns:    encoder.Columns,
+		Features:   encoder.Features,
+		Categories: encoder.Categories,
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new transform from JSON.
+func (encoder *OrdinalEncoder) UnmarshalJSON(b []byte) error {
+	data := OrdinalEncoderData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	encoder.Columns = data.Columns
+	encoder.Features = data.Features
+	encoder.Categories = data.Categories
+	return nil
+}
+
+// SaveToFile saves an ordinal encoder to a file.
+func (encoder *OrdinalEncoder) SaveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(encoder)
+}
+
+// LoadFromFile loads an ordinal encoder from a file.
+func (encoder *OrdinalEncoder) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewDecoder(file).Decode(encoder)
+}
+
+// TargetEncoderData represents a serialized target encoder that can be saved to a file.
+type TargetEncoderData struct {
+	Columns    []int       `json:"columns"`
+	Smoothing  float32     `json:"smoothing"`
+	Features   int         `json:"features"`
+	Mean       float32     `json:"mean"`
+	Categories [][]float32 `json:"categories"`
+	Encodings  [][]float32 `json:"encodings"`
+}
+
+// MarshalJSON converts the transform to JSON.
+func (encoder *TargetEncoder) MarshalJSON() ([]byte, error) {
+	data := TargetEncoderData{
+		Columns:    encoder.Columns,
+		Smoothing:  encoder.Smoothing,
+		Features:   encoder.Features,
+		Mean:       encoder.Mean,
+		Categories: encoder.Categories,
+		Encodings:  encoder.Encodings,
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new transform from JSON.
+func (encoder *TargetEncoder) UnmarshalJSON(b []byte) error {
+	data := TargetEncoderData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	if len(data.Categories) != len(data.Encodings) {
+		return fmt.Errorf("Number of categories and encodings must match: %d != %d", len(data.Categories), len(data.Encodings))
+	}
+	encoder.Columns = data.Columns
+	encoder.Smoothing = data.Smoothing
+	encoder.Features = data.Features
+	encoder.Mean = data.Mean
+	encoder.Categories = data.Categories
+	encoder.Encodings = data.Encodings
+	return nil
+}
+
+// SaveToFile saves a target encoder to a file.
+func (encoder *TargetEncoder) SaveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(encoder)
+}
+
+// LoadFromFile loads a target encoder from a file.
+func (encoder *TargetEncoder) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewDecoder(file).Decode(encoder)
+}
@@ -0,0 +1,128 @@
+package preprocess
+
+import (
+	"os"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestOrdinalEncoder(t *testing.T) {
+	data := tsr.NewValueTensor2D([][]float32{
+		{30, 1.5},
+		{10, 2.5},
+		{20, 3.5},
+		{10, 4.5},
+	})
+
+	encoder := NewOrdinalEncoder(0)
+	err := encoder.Fit(data)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+
+	encoded, err := encoder.Transform(tsr.NewValueTensor2D([][]float32{
+		{10, 1.5},
+		{30, 2.5},
+		{20, 3.5},
+		{40, 4.5},
+	}))
+	if err != nil {
+		t.Fatalf("Error in Transform: %s", err.Error())
+	}
+	solution := tsr.NewValueTensor2D([][]float32{
+		{0, 1.5},
+		{2, 2.5},
+		{1, 3.5},
+		{-1, 4.5},
+	})
+	if !encoded.Equals(solution) {
+		t.Errorf("Encoded data should be:\n%swhen result is:\n%s", solution.String(), encoded.String())
+	}
+
+	err = NewOrdinalEncoder(2).Fit(data)
+	if err == nil {
+		t.Errorf("Did not trigger error on invalid column")
+	}
+}
+
+func TestTargetEncoder(t *testing.T) {
+	data := tsr.NewValueTensor2D([][]float32{
+		{0, 5},
+		{0, 6},
+		{0, 7},
+		{1, 8},
+	})
+	targets := tsr.NewValueTensor2D([][]float32{{1}, {1}, {0}, {0}})
+
+	encoder := NewTargetEncoder(2, 0)
+	err := encoder.Fit(data)
+	if err == nil {
+		t.Errorf("Fitting without targets did not trigger error")
+	}
+	err = encoder.FitTargets(data, targets)
+	if err != nil {
+		t.Fatalf("Error in FitTargets: %s", err.Error())
+	}
+
+	// Category 0 has targets 1, 1 and 0, and category 1 has target 0, each with 2 extra samples at
+	// the overall mean of 0.5.
+	encoded, err := encoder.Transform(tsr.NewValueTensor2D([][]float32{{0, 5}, {1, 6}, {2, 7}}))
+	if err != nil {
+		t.Fatalf("Error in Transform: %s", err.Error())
+	}
+	solution := tsr.NewValueTensor2D([][]float32{
+		{0.6, 5},
+		{1.0 / 3, 6},
+		{0.5, 7},
+	})
+	if !encoded.Equals(solution) {
+		t.Errorf("Encoded data should be:\n%swhen result is:\n%s", solution.String(), encoded.String())
+	}
+
+	err = encoder.FitTargets(data, tsr.NewValueTensor2D([][]float32{{1, 0}, {1, 0}, {0, 1}, {0, 1}}))
+	if err == nil {
+		t.Errorf("Did not trigger error on targets with more than 1 column")
+	}
+}
+
+func TestCategoricalEncoderSaveLoad(t *testing.T) {
+	data := tsr.NewValueTensor2D([][]float32{{3, 1}, {4, 2}, {3, 3}})
+	ordinal := NewOrdinalEncoder(0, 1)
+	ordinal.Fit(data)
+	target := NewTargetEncoder(1, 0)
+	target.FitTargets(data, tsr.NewValueTensor2D([][]float32{{2}, {4}, {6}}))
+
+	err := ordinal.SaveToFile("ordinalEncoder.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+	defer os.Remove("ordinalEncoder.json")
+	err = target.SaveToFile("targetEncoder.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+	defer os.Remove("targetEncoder.json")
+
+	loadedOrdinal := &OrdinalEncoder{}
+	err = loadedOrdinal.LoadFromFile("ordinalEncoder.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+	loadedTarget := &TargetEncoder{}
+	err = loadedTarget.LoadFromFile("targetEncoder.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+
+	expected, _ := ordinal.Transform(data)
+	result, err := loadedOrdinal.Transform(data)
+	if err != nil || !result.Equals(expected) {
+		t.Errorf("Loaded ordinal encoder does not match original")
+	}
+	expected, _ = target.Transform(data)
+	result, err = loadedTarget.Transform(data)
+	if err != nil || !result.Equals(expected) {
+		t.Errorf("Loaded target encoder does not match original")
+	}
+}
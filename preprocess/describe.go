@@ -0,0 +1,97 @@
+package preprocess
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"text/tabwriter"
+
+	tsr "../tensor"
+)
+
+// DatasetSummary describes each feature of a dataset, to check the data before training.
+type DatasetSummary struct {
+	Samples  int
+	Features []FeatureSummary
+}
+
+// FeatureSummary holds the statistics of a feature of a dataset. Missing values are NaN, and the
+// other statistics leave them out, so they are NaN for a feature without any values. The standard
+// deviation is of the population, and the cardinality is the number of distinct values.
+type FeatureSummary struct {
+	Mean              float32
+	StandardDeviation float32
+	Min               float32
+	Max               float32
+	MissingRate       float32
+	Cardinality       int
+}
+
+// Describe computes the statistics of each feature of data, where each row of the data is a sample
+// and each column is a feature.
+func Describe(data *tsr.Tensor) *DatasetSummary {
+	summary := &DatasetSummary{
+		Samples:  data.Rows,
+		Features: make([]FeatureSummary, data.Cols),
+	}
+	rows := data.GetFrame(0)
+	for col := range summary.Features {
+		values := map[float32]bool{}
+		sum := 0.0
+		min := math.Inf(1)
+		max := math.Inf(-1)
+		missing := 0
+		for _, row := range rows {
+			value := float64(row[col])
+			if math.IsNaN(value) {
+				missing++
+				continue
+			}
+			values[row[col]] = true
+			sum += value
+			min = math.Min(min, value)
+			max = math.Max(max, value)
+		}
+		count := len(rows) - missing
+		feature := FeatureSummary{Cardinality: len(values)}
+		if len(rows) > 0 {
+			feature.MissingRate = float32(missing) / float32(len(rows))
+		}
+		if count == 0 {
+			nan := float32(math.NaN())
+			feature.Mean, feature.StandardDeviation, feature.Min, feature.Max = nan, nan, nan, nan
+			summary.Features[col] = feature
+			continue
+		}
+		mean := sum / float64(count)
+		variance := 0.0
+		for _, row := range rows {
+			if !math.IsNaN(float64(row[col])) {
+				variance += (float64(row[col]) - mean) * (float64(row[col]) - mean)
+			}
+		}
+		feature.Mean = float32(mean)
+		feature.StandardDeviation = float32(math.Sqrt(variance / float64(count)))
+		feature.Min = float32(min)
+		feature.Max = float32(max)
+		summary.Features[col] = feature
+	}
+	return summary
+}
+
+// String describes the features in a table, with a row for each feature and the missing rate as a
+// percentage, followed by the number of samples.
+func (summary *DatasetSummary) String() string {
+	buffer := bytes.Buffer{}
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Feature\tMean\tStd\tMin\tMax\tMissing\tCardinality")
+	for i, feature := range summary.Features {
+		fmt.Fprintf(
+			writer, "%d\t%.4g\t%.4g\t%.4g\t%.4g\t%.1f%%\t%d\n",
+			i, feature.Mean, feature.StandardDeviation, feature.Min, feature.Max, feature.MissingRate*100, feature.Cardinality,
+		)
+	}
+	writer.Flush()
+	fmt.Fprintf(&buffer, "Samples: %d\n", summary.Samples)
+	return buffer.String()
+}
@@ -0,0 +1,47 @@
+package preprocess
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestDescribe(t *testing.T) {
+	nan := float32(math.NaN())
+	data := tsr.NewValueTensor2D([][]float32{
+		{1, 5, nan},
+		{2, 5, nan},
+		{3, nan, nan},
+		{2, 7, nan},
+	})
+
+	summary := Describe(data)
+	if summary.Samples != 4 || len(summary.Features) != 3 {
+		t.Fatalf("Summary should have 4 samples and 3 features, has: %d, %d", summary.Samples, len(summary.Features))
+	}
+	first := summary.Features[0]
+	if first.Mean != 2 || first.Min != 1 || first.Max != 3 || first.Cardinality != 3 || first.MissingRate != 0 {
+		t.Errorf("Statistics of first feature are incorrect: %+v", first)
+	}
+	if math.Abs(float64(first.StandardDeviation)-math.Sqrt(0.5)) > 1e-6 {
+		t.Errorf("Standard deviation of first feature should be: %f, is: %f", math.Sqrt(0.5), first.StandardDeviation)
+	}
+	second := summary.Features[1]
+	if second.Mean != 17.0/3 || second.Cardinality != 2 || second.MissingRate != 0.25 {
+		t.Errorf("Statistics of second feature should leave out the missing value: %+v", second)
+	}
+	third := summary.Features[2]
+	if third.MissingRate != 1 || third.Cardinality != 0 || !math.IsNaN(float64(third.Mean)) {
+		t.Errorf("Feature without values should have NaN statistics: %+v", third)
+	}
+
+	lines := strings.Split(strings.TrimSpace(summary.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Report should have 5 lines, has: %d\n%s", len(lines), summary.String())
+	}
+	if !strings.Contains(lines[2], "25.0%") || lines[4] != "Samples: 4" {
+		t.Errorf("Report should show the missing rate and number of samples, is:\n%s", summary.String())
+	}
+}
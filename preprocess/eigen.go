@@ -0,0 +1,61 @@
+package preprocess
+
+import "math"
+
+// symmetricEigen computes the eigenvalues and eigenvectors of a symmetric matrix with the Jacobi
+// eigenvalue algorithm. The eigenvectors are the columns of the returned matrix.
+func symmetricEigen(matrix [][]float64) ([]float64, [][]float64) {
+	size := len(matrix)
+	a := make([][]float64, size)
+	vectors := make([][]float64, size)
+	for i := range a {
+		a[i] = append([]float64{}, matrix[i]...)
+		vectors[i] = make([]float64, size)
+		vectors[i][i] = 1
+	}
+	for sweep := 0; sweep < 100; sweep++ {
+		offDiagonal := 0.0
+		for p := 0; p < size; p++ {
+			for q := p + 1; q < size; q++ {
+				offDiagonal += a[p][q] * a[p][q]
+			}
+		}
+		if offDiagonal < 1e-22 {
+			break
+		}
+		for p := 0; p < size; p++ {
+			for q := p + 1; q < size; q++ {
+				if math.Abs(a[p][q]) < 1e-300 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < size; k++ {
+					akp := a[k][p]
+					akq := a[k][q]
+					a[k][p] = c*akp - s*akq
+					a[k][q] = s*akp + c*akq
+				}
+				for k := 0; k < size; k++ {
+					apk := a[p][k]
+					aqk := a[q][k]
+					a[p][k] = c*apk - s*aqk
+					a[q][k] = s*apk + c*aqk
+				}
+				for k := 0; k < size; k++ {
+					vkp := vectors[k][p]
+					vkq := vectors[k][q]
+					vectors[k][p] = c*vkp - s*vkq
+					vectors[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+	values := make([]float64, size)
+	for i := range values {
+		values[i] = a[i][i]
+	}
+	return values, vectors
+}
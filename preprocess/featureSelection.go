@@ -0,0 +1,351 @@
+package preprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	tsr "../tensor"
+)
+
+// SelectionType is the identifying type of a feature selector.
+type SelectionType string
+
+const (
+	// SelectionTypeVariance keeps the features with a variance above a threshold, which removes
+	// features that are constant or nearly constant.
+	SelectionTypeVariance = SelectionType("variance")
+
+	// SelectionTypeMutualInformation keeps the features that share the most information with the
+	// classes, after sorting the values of each feature into bins of equal width.
+	SelectionTypeMutualInformation = SelectionType("mutualInformation")
+
+	// SelectionTypeChiSquared keeps the features with the largest chi-squared statistic between the
+	// feature and the classes, which works for features that are counts or frequencies.
+	SelectionTypeChiSquared = SelectionType("chiSquared")
+)
+
+// FeatureSelector is a transform that keeps a subset of the features of data. The columns that
+// are kept are chosen when the selector is fit and saved with it, so the same columns are kept for
+// predictions. Each row of the data is a sample and each column is a feature.
+type FeatureSelector struct {
+	Type      SelectionType
+	Threshold float32
+	Count     int
+	Bins      int
+	Features  int
+	Scores    []float32
+	Columns   []int
+}
+
+// NewVarianceSelector creates a new instance of a feature selector that keeps the features with a
+// variance above a threshold.
+func NewVarianceSelector(threshold float32) *FeatureSelector {
+	return &FeatureSelector{
+		Type:      SelectionTypeVariance,
+		Threshold: threshold,
+	}
+}
+
+// NewMutualInformationSelector creates a new instance of a feature selector that keeps a number of
+// features with the most mutual information with the classes, using 10 bins for each feature.
+func NewMutualInformationSelector(count int) *FeatureSelector {
+	return &FeatureSelector{
+		Type:  SelectionTypeMutualInformation,
+		Count: count,
+		Bins:  10,
+	}
+}
+
+// NewChiSquaredSelector creates a new instance of a feature selector that keeps a number of
+// features with the largest chi-squared statistic with the classes. The values of the features
+// must not be negative.
+func NewChiSquaredSelector(count int) *FeatureSelector {
+	return &FeatureSelector{
+		Type:  SelectionTypeChiSquared,
+		Count: count,
+	}
+}
+
+// Fit chooses the columns of the data to keep. Only a variance selector can be fit without the
+// targets.
+func (selector *FeatureSelector) Fit(data *tsr.Tensor) error {
+	if selector.Type != SelectionTypeVariance {
+		return fmt.Errorf("Feature selector of type %s must be fit with targets", selector.Type)
+	}
+	return selector.FitTargets(data, nil)
+}
+
+// FitTargets scores each feature of the data and chooses the columns to keep. The targets have a
+// row for each sample, with either a single column holding the number of the class or a column for
+// each class, in which case the largest column is the class.
+func (selector *FeatureSelector) FitTargets(data *tsr.Tensor, targets *tsr.Tensor) error {
+	rows := data.GetFrame(0)
+	if len(rows) == 0 {
+		return fmt.Errorf("Feature selector must be fit to at least 1 sample")
+	}
+	var scores []float32
+	switch selector.Type {
+	case SelectionTypeVariance:
+		scores = varianceScores(rows, data.Cols)
+	case SelectionTypeMutualInformation, SelectionTypeChiSquared:
+		if targets == nil {
+			return fmt.Errorf("Feature selector of type %s must be fit with targets", selector.Type)
+		}
+		if targets.Rows != data.Rows {
+			return fmt.Errorf("Number of samples and targets must match: %d != %d", data.Rows, targets.Rows)
+		}
+		if selector.Count < 1 {
+			return fmt.Errorf("Number of features to keep must be at least 1, is: %d", selector.Count)
+		}
+		classes, numClasses, err := classesOf(targets)
+		if err != nil {
+			return err
+		}
+		if selector.Type == SelectionTypeMutualInformation {
+			scores, err = mutualInformationScores(rows, data.Cols, classes, numClasses, selector.Bins)
+		} else {
+			scores, err = chiSquaredScores(rows, data.Cols, classes, numClasses)
+		}
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Invalid selection type: %s", selector.Type)
+	}
+
+	columns := []int{}
+	if selector.Type == SelectionTypeVariance {
+		for col, score := range scores {
+			if score > selector.Threshold {
+				columns = append(columns, col)
+			}
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("No features have a variance above the threshold: %g", selector.Threshold)
+		}
+	} else {
+		order := make([]int, len(scores))
+		for col := range order {
+			order[col] = col
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return scores[order[i]] > scores[order[j]]
+		})
+		if len(order) > selector.Count {
+			order = order[:selector.Count]
+		}
+		columns = append(columns, order...)
+		sort.Ints(columns)
+	}
+	selector.Features = data.Cols
+	selector.Scores = scores
+	selector.Columns = columns
+	return nil
+}
+
+// Transform keeps the selected columns of the data.
+func (selector *FeatureSelector) Transform(data *tsr.Tensor) (*tsr.Tensor, error) {
+	if selector.Columns == nil {
+		return nil, fmt.Errorf("Feature selector must be fit before transforming data")
+	}
+	if data.Cols != selector.Features {
+		return nil, fmt.Errorf("Number of features must match fitted data: %d != %d", data.Cols, selector.Features)
+	}
+	result := tsr.NewEmptyTensor2D(data.Rows, len(selector.Columns))
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return data.Get(0, row, selector.Columns[col])
+	})
+	return result, nil
+}
+
+func varianceScores(rows [][]float32, features int) []float32 {
+	scores := make([]float32, features)
+	for col := range scores {
+		mean := 0.0
+		for _, row := range rows {
+			mean += float64(row[col])
+		}
+		mean /= float64(len(rows))
+		variance := 0.0
+		for _, row := range rows {
+			difference := float64(row[col]) - mean
+			variance += difference * difference
+		}
+		scores[col] = float32(variance / float64(len(rows)))
+	}
+	return scores
+}
+
+// mutualInformationScores sorts the values of each feature into bins of equal width between its
+// smallest and largest values, and computes the mutual information in nats between the bins and
+// the classes.
+func mutualInformationScores(rows [][]float32, features int, classes []int, numClasses int, bins int) ([]float32, error) {
+	if bins < 2 {
+		return nil, fmt.Errorf("Number of bins must be at least 2, is: %d", bins)
+	}
+	samples := float64(len(rows))
+	classCounts := make([]float64, numClasses)
+	for _, class := range classes {
+		classCounts[class]++
+	}
+	scores := make([]float32, features)
+	for col := range scores {
+		min, max := rows[0][col], rows[0][col]
+		for _, row := range rows {
+			if row[col] < min {
+				min = row[col]
+			}
+			if row[col] > max {
+				max = row[col]
+			}
+		}
+		joint := make([][]float64, bins)
+		for bin := range joint {
+			joint[bin] = make([]float64, numClasses)
+		}
+		binCounts := make([]float64, bins)
+		for i, row := range rows {
+			bin := 0
+			if max > min {
+				bin = int(float64(row[col]-min) / float64(max-min) * float64(bins))
+				if bin >= bins {
+					bin = bins - 1
+				}
+			}
+			joint[bin][classes[i]]++
+			binCounts[bin]++
+		}
+		information := 0.0
+		for bin, counts := range joint {
+			for class, count := range counts {
+				if count == 0 {
+					continue
+				}
+				information += count / samples * math.Log(count*samples/(binCounts[bin]*classCounts[class]))
+			}
+		}
+		scores[col] = float32(information)
+	}
+	return scores, nil
+}
+
+// chiSquaredScores compares the sum of each feature over the samples of each class with the sum
+// expected if the feature did not depend on the class.
+func chiSquaredScores(rows [][]float32, features int, classes []int, numClasses int) ([]float32, error) {
+	samples := float64(len(rows))
+	classCounts := make([]float64, numClasses)
+	for _, class := range classes {
+		classCounts[class]++
+	}
+	scores := make([]float32, features)
+	for col := range scores {
+		observed := make([]float64, numClasses)
+		total := 0.0
+		for i, row := range rows {
+			if row[col] < 0 {
+				return nil, fmt.Errorf("Chi-squared features must not be negative, feature %d is: %g", col, row[col])
+			}
+			observed[classes[i]] += float64(row[col])
+			total += float64(row[col])
+		}
+		statistic := 0.0
+		for class, count := range observed {
+			expected := total * classCounts[class] / samples
+			if expected > 0 {
+				statistic += (count - expected) * (count - expected) / expected
+			}
+		}
+		scores[col] = float32(statistic)
+	}
+	return scores, nil
+}
+
+// classesOf returns the class of each row of the targets and the number of classes.
+func classesOf(targets *tsr.Tensor) ([]int, int, error) {
+	classes := make([]int, targets.Rows)
+	numClasses := targets.Cols
+	for i, row := range targets.GetFrame(0) {
+		if len(row) > 1 {
+			for col, value := range row {
+				if value > row[classes[i]] {
+					classes[i] = col
+				}
+			}
+			continue
+		}
+		class := int(math.Round(float64(row[0])))
+		if class < 0 {
+			return nil, 0, fmt.Errorf("Invalid class of target %d: %d", i, class)
+		}
+		classes[i] = class
+		if class+1 > numClasses {
+			numClasses = class + 1
+		}
+	}
+	return classes, numClasses, nil
+}
+
+// FeatureSelectorData represents a serialized feature selector that can be saved to a file.
+type FeatureSelectorData struct {
+	Type      SelectionType `json:"type"`
+	Threshold float32       `json:"threshold"`
+	Count     int           `json:"count"`
+	Bins      int           `json:"bins"`
+	Features  int           `json:"features"`
+	Scores    []float32     `json:"scores"`
+	Columns   []int         `json:"columns"`
+}
+
+// MarshalJSON converts the transform to JSON.
+func (selector *FeatureSelector) MarshalJSON() ([]byte, error) {
+	data := FeatureSelectorData{
+		Type:      selector.Type,
+		Threshold: selector.Threshold,
+		Count:     selector.Count,
+		Bins:      selector.Bins,
+		Features:  selector.Features,
+		Scores:    selector.Scores,
+		Columns:   selector.Columns,
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new transform from JSON.
+func (selector *FeatureSelector) UnmarshalJSON(b []byte) error {
+	data := FeatureSelectorData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	selector.Type = data.Type
+	selector.Threshold = data.Threshold
+	selector.Count = data.Count
+	selector.Bins = data.Bins
+	selector.Features = data.Features
+	selector.Scores = data.Scores
+	selector.Columns = data.Columns
+	return nil
+}
+
+// SaveToFile saves a feature selector to a file.
+func (selector *FeatureSelector) SaveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(selector)
+}
+
+// LoadFromFile loads a feature selector from a file.
+func (selector *FeatureSelector) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewDecoder(file).Decode(selector)
+}
@@ -0,0 +1,135 @@
+package preprocess
+
+import (
+	"os"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestVarianceSelector(t *testing.T) {
+	data := tsr.NewValueTensor2D([][]float32{
+		{1, 5, 0},
+		{2, 5, 0.1},
+		{3, 5, 0},
+		{4, 5, 0.1},
+	})
+
+	selector := NewVarianceSelector(0.01)
+	err := selector.Fit(data)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+	if len(selector.Columns) != 1 || selector.Columns[0] != 0 {
+		t.Errorf("Selected columns should be: [0], are: %v", selector.Columns)
+	}
+
+	selected, err := selector.Transform(data)
+	if err != nil {
+		t.Fatalf("Error in Transform: %s", err.Error())
+	}
+	solution := tsr.NewValueTensor2D([][]float32{{1}, {2}, {3}, {4}})
+	if !selected.Equals(solution) {
+		t.Errorf("Selected data should be:\n%swhen result is:\n%s", solution.String(), selected.String())
+	}
+
+	_, err = selector.Transform(tsr.NewValueTensor1D([]float32{1, 2}))
+	if err == nil {
+		t.Errorf("Did not trigger error on mismatched number of features")
+	}
+	err = NewVarianceSelector(10).Fit(data)
+	if err == nil {
+		t.Errorf("Did not trigger error when no features are above the threshold")
+	}
+}
+
+func TestMutualInformationSelector(t *testing.T) {
+	// The second feature matches the class, the first is spread evenly over the classes and the third
+	// is constant.
+	data := tsr.NewValueTensor2D([][]float32{
+		{0.1, 0, 1},
+		{0.9, 0, 1},
+		{0.1, 1, 1},
+		{0.9, 1, 1},
+		{0.5, 0, 1},
+		{0.5, 1, 1},
+	})
+	targets := tsr.NewValueTensor2D([][]float32{{0}, {0}, {1}, {1}, {0}, {1}})
+
+	selector := NewMutualInformationSelector(1)
+	err := selector.Fit(data)
+	if err == nil {
+		t.Errorf("Fitting without targets did not trigger error")
+	}
+	err = selector.FitTargets(data, targets)
+	if err != nil {
+		t.Fatalf("Error in FitTargets: %s", err.Error())
+	}
+	if len(selector.Columns) != 1 || selector.Columns[0] != 1 {
+		t.Errorf("Selected columns should be: [1], are: %v", selector.Columns)
+	}
+	if selector.Scores[2] != 0 {
+		t.Errorf("Constant feature should have no mutual information, has: %f", selector.Scores[2])
+	}
+
+	err = selector.FitTargets(data, tsr.NewValueTensor2D([][]float32{{0}, {1}, {1}}))
+	if err == nil {
+		t.Errorf("Did not trigger error on mismatched number of targets")
+	}
+}
+
+func TestChiSquaredSelector(t *testing.T) {
+	data := tsr.NewValueTensor2D([][]float32{
+		{3, 1, 2},
+		{4, 1, 0},
+		{0, 1, 0},
+		{1, 1, 0},
+	})
+	targets := tsr.NewValueTensor2D([][]float32{{1, 0}, {1, 0}, {0, 1}, {0, 1}})
+
+	selector := NewChiSquaredSelector(2)
+	err := selector.FitTargets(data, targets)
+	if err != nil {
+		t.Fatalf("Error in FitTargets: %s", err.Error())
+	}
+	if len(selector.Columns) != 2 || selector.Columns[0] != 0 || selector.Columns[1] != 2 {
+		t.Errorf("Selected columns should be: [0 2], are: %v", selector.Columns)
+	}
+	if selector.Scores[1] != 0 {
+		t.Errorf("Feature that does not depend on the class should have a statistic of 0, has: %f", selector.Scores[1])
+	}
+
+	data.Set(0, 0, 0, -1)
+	err = selector.FitTargets(data, targets)
+	if err == nil {
+		t.Errorf("Did not trigger error on negative feature")
+	}
+}
+
+func TestFeatureSelectorSaveLoad(t *testing.T) {
+	selector := NewChiSquaredSelector(1)
+	selector.FitTargets(
+		tsr.NewValueTensor2D([][]float32{{1, 0}, {0, 1}, {1, 1}}),
+		tsr.NewValueTensor2D([][]float32{{0}, {1}, {1}}),
+	)
+
+	err := selector.SaveToFile("featureSelector.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+
+	loadedSelector := &FeatureSelector{}
+	err = loadedSelector.LoadFromFile("featureSelector.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+
+	if loadedSelector.Type != selector.Type || loadedSelector.Features != 2 || len(loadedSelector.Columns) != 1 || loadedSelector.Columns[0] != selector.Columns[0] {
+		t.Errorf("Loaded feature selector does not match original")
+	}
+
+	err = os.Remove("featureSelector.json")
+	if err != nil {
+		t.Fatalf("Error removing test file: %s", err.Error())
+	}
+}
@@ -0,0 +1,239 @@
+package preprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	tsr "../tensor"
+)
+
+// OutlierMethod is the identifying type of the method used to find the bounds of each feature.
+type OutlierMethod string
+
+const (
+	// OutlierMethodZScore treats values more than a number of standard deviations from the mean of
+	// a feature as outliers.
+	OutlierMethodZScore = OutlierMethod("zScore")
+
+	// OutlierMethodIQR treats values more than a multiple of the interquartile range below the
+	// first quartile or above the third quartile of a feature as outliers, which is not affected
+	// by the outliers themselves.
+	OutlierMethodIQR = OutlierMethod("iqr")
+)
+
+// OutlierAction is the identifying type of what is done with outliers when transforming data.
+type OutlierAction string
+
+const (
+	// OutlierActionClip replaces outliers with the nearest bound of their feature.
+	OutlierActionClip = OutlierAction("clip")
+
+	// OutlierActionFlag keeps the values and adds a column for each feature after the data, which
+	// is 1 for outliers and 0 otherwise.
+	OutlierActionFlag = OutlierAction("flag")
+)
+
+// OutlierDetector is a transform that finds the bounds of each feature of data, and clips or flags
+// the values outside of them. It can be used before a scaler, so a few extreme values do not
+// change the scale of a feature. Each row of the data is a sample and each column is a feature.
+type OutlierDetector struct {
+	Method    OutlierMethod
+	Action    OutlierAction
+	Threshold float32
+	Lower     *tsr.Tensor
+	Upper     *tsr.Tensor
+}
+
+// NewZScoreOutlierDetector creates a new instance of an outlier detector for values more than a
+// number of standard deviations from the mean, such as 3.
+func NewZScoreOutlierDetector(threshold float32, action OutlierAction) *OutlierDetector {
+	return &OutlierDetector{
+		Method:    OutlierMethodZScore,
+		Action:    action,
+		Threshold: threshold,
+	}
+}
+
+// NewIQROutlierDetector creates a new instance of an outlier detector for values more than a
+// multiple of the interquartile range outside of the quartiles, such as 1.5.
+func NewIQROutlierDetector(multiplier float32, action OutlierAction) *OutlierDetector {
+	return &OutlierDetector{
+		Method:    OutlierMethodIQR,
+		Action:    action,
+		Threshold: multiplier,
+	}
+}
+
+// Fit computes the lower and upper bounds of each feature of the data.
+func (detector *OutlierDetector) Fit(data *tsr.Tensor) error {
+	if detector.Threshold < 0 {
+		return fmt.Errorf("Outlier threshold must not be negative, is: %g", detector.Threshold)
+	}
+	if data.Rows == 0 {
+		return fmt.Errorf("Outlier detector must be fit to at least 1 sample")
+	}
+	lower := tsr.NewEmptyTensor1D(data.Cols)
+	upper := tsr.NewEmptyTensor1D(data.Cols)
+	switch detector.Method {
+	case OutlierMethodZScore:
+		rows := data.GetFrame(0)
+		for col := 0; col < data.Cols; col++ {
+			mean := 0.0
+			for _, row := range rows {
+				mean += float64(row[col])
+			}
+			mean /= float64(len(rows))
+			variance := 0.0
+			for _, row := range rows {
+				variance += (float64(row[col]) - mean) * (float64(row[col]) - mean)
+			}
+			spread := float64(detector.Threshold) * math.Sqrt(variance/float64(len(rows)))
+			lower.Set(0, 0, col, float32(mean-spread))
+			upper.Set(0, 0, col, float32(mean+spread))
+		}
+	case OutlierMethodIQR:
+		firstQuartile, err := tsr.Quantile(data, 0.25, tsr.AxisRows)
+		if err != nil {
+			return err
+		}
+		thirdQuartile, err := tsr.Quantile(data, 0.75, tsr.AxisRows)
+		if err != nil {
+			return err
+		}
+		for col := 0; col < data.Cols; col++ {
+			first := firstQuartile.Get(0, 0, col)
+			third := thirdQuartile.Get(0, 0, col)
+			spread := detector.Threshold * (third - first)
+			lower.Set(0, 0, col, first-spread)
+			upper.Set(0, 0, col, third+spread)
+		}
+	default:
+		return fmt.Errorf("Invalid outlier method: %s", detector.Method)
+	}
+	detector.Lower = lower
+	detector.Upper = upper
+	return nil
+}
+
+// Outliers returns whether each value of the data is outside the bounds of its feature, with a
+// row for each sample.
+func (detector *OutlierDetector) Outliers(data *tsr.Tensor) ([][]bool, error) {
+	if detector.Lower == nil {
+		return nil, fmt.Errorf("Outlier detector must be fit before finding outliers")
+	}
+	if data.Cols != detector.Lower.Cols {
+		return nil, fmt.Errorf("Number of features must match fitted data: %d != %d", data.Cols, detector.Lower.Cols)
+	}
+	outliers := make([][]bool, data.Rows)
+	for i, row := range data.GetFrame(0) {
+		outliers[i] = make([]bool, len(row))
+		for col, value := range row {
+			outliers[i][col] = value < detector.Lower.Get(0, 0, col) || value > detector.Upper.Get(0, 0, col)
+		}
+	}
+	return outliers, nil
+}
+
+// Transform clips the outliers of the data to the bounds of their features, or adds a column of
+// flags for each feature, depending on the action of the detector.
+func (detector *OutlierDetector) Transform(data *tsr.Tensor) (*tsr.Tensor, error) {
+	outliers, err := detector.Outliers(data)
+	if err != nil {
+		return nil, err
+	}
+	switch detector.Action {
+	case OutlierActionClip:
+		result := data.Copy()
+		result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			if current < detector.Lower.Get(0, 0, col) {
+				return detector.Lower.Get(0, 0, col)
+			}
+			if current > detector.Upper.Get(0, 0, col) {
+				return detector.Upper.Get(0, 0, col)
+			}
+			return current
+		})
+		return result, nil
+	case OutlierActionFlag:
+		result := tsr.NewEmptyTensor2D(data.Rows, data.Cols*2)
+		result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			if col < data.Cols {
+				return data.Get(0, row, col)
+			}
+			if outliers[row][col-data.Cols] {
+				return 1
+			}
+			return 0
+		})
+		return result, nil
+	default:
+		return nil, fmt.Errorf("Invalid outlier action: %s", detector.Action)
+	}
+}
+
+// OutlierDetectorData represents a serialized outlier detector that can be saved to a file.
+type OutlierDetectorData struct {
+	Method    OutlierMethod `json:"method"`
+	Action    OutlierAction `json:"action"`
+	Threshold float32       `json:"threshold"`
+	Lower     []float32     `json:"lower"`
+	Upper     []float32     `json:"upper"`
+}
+
+// MarshalJSON converts the transform to JSON.
+func (detector *OutlierDetector) MarshalJSON() ([]byte, error) {
+	data := OutlierDetectorData{
+		Method:    detector.Method,
+		Action:    detector.Action,
+		Threshold: detector.Threshold,
+	}
+	if detector.Lower != nil {
+		data.Lower = detector.Lower.GetFrame(0)[0]
+		data.Upper = detector.Upper.GetFrame(0)[0]
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new transform from JSON.
+func (detector *OutlierDetector) UnmarshalJSON(b []byte) error {
+	data := OutlierDetectorData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	detector.Method = data.Method
+	detector.Action = data.Action
+	detector.Threshold = data.Threshold
+	detector.Lower = nil
+	detector.Upper = nil
+	if data.Lower != nil {
+		if len(data.Lower) != len(data.Upper) {
+			return fmt.Errorf("Number of lower and upper bounds must match: %d != %d", len(data.Lower), len(data.Upper))
+		}
+		detector.Lower = tsr.NewValueTensor1D(data.Lower)
+		detector.Upper = tsr.NewValueTensor1D(data.Upper)
+	}
+	return nil
+}
+
+// SaveToFile saves an outlier detector to a file.
+func (detector *OutlierDetector) SaveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(detector)
+}
+
+// LoadFromFile loads an outlier detector from a file.
+func (detector *OutlierDetector) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewDecoder(file).Decode(detector)
+}
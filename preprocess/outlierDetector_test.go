@@ -0,0 +1,98 @@
+package preprocess
+
+import (
+	"os"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestIQROutlierDetector(t *testing.T) {
+	data := tsr.NewValueTensor2D([][]float32{
+		{1, 10},
+		{2, 20},
+		{3, 30},
+		{4, 40},
+		{100, 50},
+	})
+
+	// The first feature has quartiles 2 and 4, so its bounds are -1 and 7.
+	detector := NewIQROutlierDetector(1.5, OutlierActionClip)
+	err := detector.Fit(data)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+	clipped, err := detector.Transform(tsr.NewValueTensor2D([][]float32{{100, 30}, {-5, 90}}))
+	if err != nil {
+		t.Fatalf("Error in Transform: %s", err.Error())
+	}
+	solution := tsr.NewValueTensor2D([][]float32{{7, 30}, {-1, 70}})
+	if !clipped.Equals(solution) {
+		t.Errorf("Clipped data should be:\n%swhen result is:\n%s", solution.String(), clipped.String())
+	}
+
+	detector.Action = OutlierActionFlag
+	flagged, err := detector.Transform(data)
+	if err != nil {
+		t.Fatalf("Error in Transform: %s", err.Error())
+	}
+	if flagged.Cols != 4 || flagged.Get(0, 4, 0) != 100 || flagged.Get(0, 4, 2) != 1 || flagged.Get(0, 4, 3) != 0 || flagged.Get(0, 0, 2) != 0 {
+		t.Errorf("Flagged data should keep the values and flag the outlier:\n%s", flagged.String())
+	}
+
+	_, err = detector.Transform(tsr.NewValueTensor1D([]float32{1, 2, 3}))
+	if err == nil {
+		t.Errorf("Did not trigger error on mismatched number of features")
+	}
+}
+
+func TestZScoreOutlierDetector(t *testing.T) {
+	data := tsr.NewValueTensor2D([][]float32{{2}, {4}, {4}, {4}, {5}, {5}, {7}, {9}})
+
+	// The mean is 5 and the standard deviation is 2, so the bounds are 1 and 9.
+	detector := NewZScoreOutlierDetector(2, OutlierActionFlag)
+	err := detector.Fit(data)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+	outliers, err := detector.Outliers(tsr.NewValueTensor2D([][]float32{{0}, {1}, {9}, {9.5}}))
+	if err != nil {
+		t.Fatalf("Error in Outliers: %s", err.Error())
+	}
+	expected := []bool{true, false, false, true}
+	for i, row := range outliers {
+		if row[0] != expected[i] {
+			t.Errorf("Outlier %d should be: %t, is: %t", i, expected[i], row[0])
+		}
+	}
+
+	err = NewZScoreOutlierDetector(-1, OutlierActionClip).Fit(data)
+	if err == nil {
+		t.Errorf("Negative threshold did not trigger error")
+	}
+}
+
+func TestOutlierDetectorSaveLoad(t *testing.T) {
+	detector := NewIQROutlierDetector(1.5, OutlierActionClip)
+	detector.Fit(tsr.NewValueTensor2D([][]float32{{1, 8}, {2, 4}, {3, 6}}))
+
+	err := detector.SaveToFile("outlierDetector.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+
+	loadedDetector := &OutlierDetector{}
+	err = loadedDetector.LoadFromFile("outlierDetector.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+
+	if loadedDetector.Method != detector.Method || loadedDetector.Action != detector.Action || !loadedDetector.Lower.Equals(detector.Lower) || !loadedDetector.Upper.Equals(detector.Upper) {
+		t.Errorf("Loaded outlier detector does not match original")
+	}
+
+	err = os.Remove("outlierDetector.json")
+	if err != nil {
+		t.Fatalf("Error removing test file: %s", err.Error())
+	}
+}
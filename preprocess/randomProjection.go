@@ -0,0 +1,181 @@
+package preprocess
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"os"
+
+	tsr "../tensor"
+)
+
+// ProjectionType is the identifying type of a random projection.
+type ProjectionType string
+
+const (
+	// ProjectionTypeGaussian projects data with values drawn from a normal distribution.
+	ProjectionTypeGaussian = ProjectionType("gaussian")
+
+	// ProjectionTypeSparse projects data with values that are mostly 0, which is faster to fit
+	// and apply to very wide data.
+	ProjectionTypeSparse = ProjectionType("sparse")
+
+	// ProjectionTypeHashing adds each feature to a single component chosen by hashing its index,
+	// with a random sign, which is the hashing trick.
+	ProjectionTypeHashing = ProjectionType("hashing")
+)
+
+// RandomProjection is a transform that reduces the number of features of data by multiplying it
+// by a random matrix. Unlike PCA, the matrix does not depend on the data, so it is cheap to fit to
+// data with many features, and it keeps the distances between samples close to the same. Each row
+// of the data is a sample and each column is a feature.
+type RandomProjection struct {
+	Type       ProjectionType
+	Components int
+	Density    float32
+	Seed       int64
+	Matrix     *tsr.Tensor
+}
+
+// NewRandomProjection creates a new instance of a random projection to a number of components.
+// The density of a sparse projection starts at 0, which uses 1 over the square root of the number
+// of features, and the seed starts at 0, so the same projection is made every time it is fit.
+func NewRandomProjection(projectionType ProjectionType, components int) *RandomProjection {
+	return &RandomProjection{
+		Type:       projectionType,
+		Components: components,
+	}
+}
+
+// MinimumComponents returns the number of components that a random projection of a number of
+// samples needs to keep the distances between samples within a fraction epsilon of their original
+// distances, with high probability, from the Johnson-Lindenstrauss lemma.
+func MinimumComponents(samples int, epsilon float32) int {
+	e := float64(epsilon)
+	return int(math.Ceil(4 * math.Log(float64(samples)) / (e*e/2 - e*e*e/3)))
+}
+
+// Fit creates a random projection matrix for the number of features of the data.
+func (projection *RandomProjection) Fit(data *tsr.Tensor) error {
+	if projection.Components < 1 {
+		return fmt.Errorf("Number of components must be at least 1, is: %d", projection.Components)
+	}
+	features := data.Cols
+	random := rand.New(rand.NewSource(projection.Seed))
+	matrix := tsr.NewEmptyTensor2D(features, projection.Components)
+	switch projection.Type {
+	case ProjectionTypeGaussian:
+		scale := 1 / math.Sqrt(float64(projection.Components))
+		matrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			return float32(random.NormFloat64() * scale)
+		})
+	case ProjectionTypeSparse:
+		density := float64(projection.Density)
+		if density == 0 {
+			density = 1 / math.Sqrt(float64(features))
+		}
+		if density < 0 || density > 1 {
+			return fmt.Errorf("Density must be between 0 and 1, is: %f", density)
+		}
+		scale := float32(math.Sqrt(1 / (density * float64(projection.Components))))
+		matrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+			value := random.Float64()
+			if value < density/2 {
+				return -scale
+			} else if value < density {
+				return scale
+			}
+			return 0
+		})
+	case ProjectionTypeHashing:
+		for feature := 0; feature < features; feature++ {
+			hash := fnv.New64a()
+			binary.Write(hash, binary.LittleEndian, []int64{projection.Seed, int64(feature)})
+			sum := hash.Sum64()
+			sign := float32(1.0)
+			if sum&1 == 1 {
+				sign = -1
+			}
+			matrix.Set(0, feature, int((sum>>1)%uint64(projection.Components)), sign)
+		}
+	default:
+		return fmt.Errorf("Invalid projection type: %s", projection.Type)
+	}
+	projection.Matrix = matrix
+	return nil
+}
+
+// Transform projects the data onto the components using the fitted matrix.
+func (projection *RandomProjection) Transform(data *tsr.Tensor) (*tsr.Tensor, error) {
+	if projection.Matrix == nil {
+		return nil, fmt.Errorf("Random projection must be fit before transforming data")
+	}
+	if data.Cols != projection.Matrix.Rows {
+		return nil, fmt.Errorf("Number of features must match fitted data: %d != %d", data.Cols, projection.Matrix.Rows)
+	}
+	return tsr.MatrixMultiply(data, projection.Matrix, nil)
+}
+
+// RandomProjectionData represents a serialized random projection that can be saved to a file.
+type RandomProjectionData struct {
+	Type       ProjectionType `json:"type"`
+	Components int            `json:"components"`
+	Density    float32        `json:"density"`
+	Seed       int64          `json:"seed"`
+	Matrix     [][]float32    `json:"matrix"`
+}
+
+// MarshalJSON converts the transform to JSON.
+func (projection *RandomProjection) MarshalJSON() ([]byte, error) {
+	data := RandomProjectionData{
+		Type:       projection.Type,
+		Components: projection.Components,
+		Density:    projection.Density,
+		Seed:       projection.Seed,
+	}
+	if projection.Matrix != nil {
+		data.Matrix = projection.Matrix.GetFrame(0)
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new transform from JSON.
+func (projection *RandomProjection) UnmarshalJSON(b []byte) error {
+	data := RandomProjectionData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	projection.Type = data.Type
+	projection.Components = data.Components
+	projection.Density = data.Density
+	projection.Seed = data.Seed
+	projection.Matrix = nil
+	if data.Matrix != nil {
+		projection.Matrix = tsr.NewValueTensor2D(data.Matrix)
+	}
+	return nil
+}
+
+// SaveToFile saves a random projection to a file.
+func (projection *RandomProjection) SaveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(projection)
+}
+
+// LoadFromFile loads a random projection from a file.
+func (projection *RandomProjection) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewDecoder(file).Decode(projection)
+}
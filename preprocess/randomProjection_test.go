@@ -0,0 +1,93 @@
+package preprocess
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func squaredDistance(data *tsr.Tensor, row1 int, row2 int) float64 {
+	sum := 0.0
+	for col := 0; col < data.Cols; col++ {
+		difference := float64(data.Get(0, row1, col) - data.Get(0, row2, col))
+		sum += difference * difference
+	}
+	return sum
+}
+
+func TestRandomProjection(t *testing.T) {
+	rand.Seed(1)
+	data := tsr.NewEmptyTensor2D(20, 2000)
+	data.SetRandom(-1, 1)
+
+	for _, projectionType := range []ProjectionType{ProjectionTypeGaussian, ProjectionTypeSparse, ProjectionTypeHashing} {
+		projection := NewRandomProjection(projectionType, 500)
+		err := projection.Fit(data)
+		if err != nil {
+			t.Fatalf("Error in Fit: %s", err.Error())
+		}
+		projected, err := projection.Transform(data)
+		if err != nil {
+			t.Fatalf("Error in Transform: %s", err.Error())
+		}
+		if projected.Rows != 20 || projected.Cols != 500 {
+			t.Fatalf("Projected data should have 20 rows and 500 columns, has: %d, %d", projected.Rows, projected.Cols)
+		}
+
+		// The distances between samples should stay about the same.
+		for row := 1; row < data.Rows; row++ {
+			ratio := squaredDistance(projected, 0, row) / squaredDistance(data, 0, row)
+			if math.Abs(ratio-1) > 0.3 {
+				t.Errorf("Ratio of squared distances of %s projection should be close to 1, is: %f", projectionType, ratio)
+			}
+		}
+
+		refit := NewRandomProjection(projectionType, 500)
+		refit.Fit(data)
+		if !refit.Matrix.Equals(projection.Matrix) {
+			t.Errorf("Fitting a %s projection with the same seed should make the same matrix", projectionType)
+		}
+	}
+
+	_, err := NewRandomProjection(ProjectionTypeGaussian, 10).Transform(data)
+	if err == nil {
+		t.Errorf("Did not trigger error on transform before fit")
+	}
+	err = NewRandomProjection(ProjectionType("unknown"), 10).Fit(data)
+	if err == nil {
+		t.Errorf("Did not trigger error on invalid projection type")
+	}
+}
+
+func TestMinimumComponents(t *testing.T) {
+	components := MinimumComponents(1000, 0.1)
+	if components != 5921 {
+		t.Errorf("Minimum components for 1000 samples and epsilon 0.1 should be 5921, is: %d", components)
+	}
+}
+
+func TestRandomProjectionSaveLoad(t *testing.T) {
+	data := tsr.NewEmptyTensor2D(5, 30)
+	data.SetRandom(-1, 1)
+	projection := NewRandomProjection(ProjectionTypeSparse, 8)
+	projection.Seed = 42
+	projection.Fit(data)
+
+	err := projection.SaveToFile("randomProjection.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+	defer os.Remove("randomProjection.json")
+
+	loadedProjection := &RandomProjection{}
+	err = loadedProjection.LoadFromFile("randomProjection.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+	if loadedProjection.Type != projection.Type || loadedProjection.Seed != 42 || !loadedProjection.Matrix.Equals(projection.Matrix) {
+		t.Errorf("Loaded random projection does not match original")
+	}
+}
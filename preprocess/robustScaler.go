@@ -0,0 +1,119 @@
+package preprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	tsr "../tensor"
+)
+
+// RobustScaler is a transform that centers each feature of data on its median and scales it by its
+// interquartile range, which is not affected by a small number of large outliers. Each row of the
+// data is a sample and each column is a feature.
+type RobustScaler struct {
+	Median *tsr.Tensor
+	Range  *tsr.Tensor
+}
+
+// NewRobustScaler creates a new instance of a robust scaler.
+func NewRobustScaler() *RobustScaler {
+	return &RobustScaler{}
+}
+
+// Fit computes the median and interquartile range of each feature of the data.
+func (scaler *RobustScaler) Fit(data *tsr.Tensor) error {
+	median, err := tsr.Quantile(data, 0.5, tsr.AxisRows)
+	if err != nil {
+		return err
+	}
+	lower, err := tsr.Quantile(data, 0.25, tsr.AxisRows)
+	if err != nil {
+		return err
+	}
+	upper, err := tsr.Quantile(data, 0.75, tsr.AxisRows)
+	if err != nil {
+		return err
+	}
+	err = upper.SubtractTensor(lower)
+	if err != nil {
+		return err
+	}
+	upper.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		// Features without spread are only centered.
+		if current == 0 {
+			return 1
+		}
+		return current
+	})
+	scaler.Median = median
+	scaler.Range = upper
+	return nil
+}
+
+// Transform scales the data using the fitted medians and interquartile ranges.
+func (scaler *RobustScaler) Transform(data *tsr.Tensor) (*tsr.Tensor, error) {
+	if scaler.Median == nil {
+		return nil, fmt.Errorf("Robust scaler must be fit before transforming data")
+	}
+	if data.Cols != scaler.Median.Cols {
+		return nil, fmt.Errorf("Number of features must match fitted data: %d != %d", data.Cols, scaler.Median.Cols)
+	}
+	result := data.Copy()
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return (current - scaler.Median.Get(0, 0, col)) / scaler.Range.Get(0, 0, col)
+	})
+	return result, nil
+}
+
+// RobustScalerData represents a serialized robust scaler that can be saved to a file.
+type RobustScalerData struct {
+	Median []float32 `json:"median"`
+	Range  []float32 `json:"range"`
+}
+
+// MarshalJSON converts the transform to JSON.
+func (scaler *RobustScaler) MarshalJSON() ([]byte, error) {
+	data := RobustScalerData{}
+	if scaler.Median != nil {
+		data.Median = scaler.Median.GetFrame(0)[0]
+		data.Range = scaler.Range.GetFrame(0)[0]
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new transform from JSON.
+func (scaler *RobustScaler) UnmarshalJSON(b []byte) error {
+	data := RobustScalerData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	scaler.Median = nil
+	scaler.Range = nil
+	if data.Median != nil {
+		scaler.Median = tsr.NewValueTensor1D(data.Median)
+		scaler.Range = tsr.NewValueTensor1D(data.Range)
+	}
+	return nil
+}
+
+// SaveToFile saves a robust scaler to a file.
+func (scaler *RobustScaler) SaveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(scaler)
+}
+
+// LoadFromFile loads a robust scaler from a file.
+func (scaler *RobustScaler) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewDecoder(file).Decode(scaler)
+}
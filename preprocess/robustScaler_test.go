@@ -0,0 +1,74 @@
+package preprocess
+
+import (
+	"os"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestRobustScaler(t *testing.T) {
+	data := tsr.NewValueTensor2D([][]float32{
+		{1, 5},
+		{2, 5},
+		{3, 5},
+		{4, 5},
+		{1000, 5},
+	})
+
+	scaler := NewRobustScaler()
+	err := scaler.Fit(data)
+	if err != nil {
+		t.Fatalf("Error in Fit: %s", err.Error())
+	}
+
+	scaled, err := scaler.Transform(data)
+	if err != nil {
+		t.Fatalf("Error in Transform: %s", err.Error())
+	}
+
+	solution := tsr.NewValueTensor2D([][]float32{
+		{-1, 0},
+		{-0.5, 0},
+		{0, 0},
+		{0.5, 0},
+		{498.5, 0},
+	})
+	if !scaled.Equals(solution) {
+		t.Errorf("Scaled data should be:\n%swhen result is:\n%s", solution.String(), scaled.String())
+	}
+
+	_, err = scaler.Transform(tsr.NewValueTensor1D([]float32{1, 2, 3}))
+	if err == nil {
+		t.Errorf("Did not trigger error on mismatched number of features")
+	}
+}
+
+func TestRobustScalerSaveLoad(t *testing.T) {
+	scaler := NewRobustScaler()
+	scaler.Fit(tsr.NewValueTensor2D([][]float32{
+		{1, 8},
+		{2, 4},
+		{3, 6},
+	}))
+
+	err := scaler.SaveToFile("robustScaler.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+
+	loadedScaler := NewRobustScaler()
+	err = loadedScaler.LoadFromFile("robustScaler.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+
+	if !loadedScaler.Median.Equals(scaler.Median) || !loadedScaler.Range.Equals(scaler.Range) {
+		t.Errorf("Loaded robust scaler does not match original")
+	}
+
+	err = os.Remove("robustScaler.json")
+	if err != nil {
+		t.Fatalf("Error removing test file: %s", err.Error())
+	}
+}
@@ -0,0 +1,156 @@
+package preprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	tsr "../tensor"
+)
+
+// WhiteningType is the identifying type of a whitening transform.
+type WhiteningType string
+
+const (
+	// WhiteningTypePCA rotates data onto its principal components before scaling them.
+	WhiteningTypePCA = WhiteningType("pca")
+
+	// WhiteningTypeZCA rotates whitened data back to its original axes, keeping it as close as
+	// possible to the original data.
+	WhiteningTypeZCA = WhiteningType("zca")
+)
+
+// Whitening is a transform that decorrelates the features of data and scales them to unit
+// variance. Each row of the data is a sample and each column is a feature.
+type Whitening struct {
+	Type    WhiteningType
+	Epsilon float32
+	Mean    *tsr.Tensor
+	Matrix  *tsr.Tensor
+}
+
+// NewWhitening creates a new instance of a whitening transform. Epsilon is added to the variance
+// of each component to avoid amplifying noise in components with almost no variance.
+func NewWhitening(whiteningType WhiteningType, epsilon float32) *Whitening {
+	return &Whitening{
+		Type:    whiteningType,
+		Epsilon: epsilon,
+	}
+}
+
+// Fit computes the mean and whitening matrix of the data.
+func (whitening *Whitening) Fit(data *tsr.Tensor) error {
+	if whitening.Type != WhiteningTypePCA && whitening.Type != WhiteningTypeZCA {
+		return fmt.Errorf("Invalid whitening type: %s", whitening.Type)
+	}
+	covariance, err := tsr.Covariance(data, nil)
+	if err != nil {
+		return err
+	}
+	features := data.Cols
+	mean := tsr.NewEmptyTensor1D(features)
+	mean.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		sum := float32(0.0)
+		for i := 0; i < data.Rows; i++ {
+			sum += data.Get(0, i, col)
+		}
+		return sum / float32(data.Rows)
+	})
+	covarianceValues := make([][]float64, features)
+	for row := range covarianceValues {
+		covarianceValues[row] = make([]float64, features)
+		for col := range covarianceValues[row] {
+			covarianceValues[row][col] = float64(covariance.Get(0, row, col))
+		}
+	}
+	eigenvalues, eigenvectors := symmetricEigen(covarianceValues)
+	matrix := tsr.NewEmptyTensor2D(features, features)
+	matrix.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		if whitening.Type == WhiteningTypePCA {
+			return float32(eigenvectors[row][col] / math.Sqrt(eigenvalues[col]+float64(whitening.Epsilon)))
+		}
+		sum := 0.0
+		for i := 0; i < features; i++ {
+			sum += eigenvectors[row][i] * eigenvectors[col][i] / math.Sqrt(eigenvalues[i]+float64(whitening.Epsilon))
+		}
+		return float32(sum)
+	})
+	whitening.Mean = mean
+	whitening.Matrix = matrix
+	return nil
+}
+
+// Transform whitens the data using the fitted mean and whitening matrix.
+func (whitening *Whitening) Transform(data *tsr.Tensor) (*tsr.Tensor, error) {
+	if whitening.Matrix == nil {
+		return nil, fmt.Errorf("Whitening must be fit before transforming data")
+	}
+	if data.Cols != whitening.Mean.Cols {
+		return nil, fmt.Errorf("Number of features must match fitted data: %d != %d", data.Cols, whitening.Mean.Cols)
+	}
+	centered := data.Copy()
+	centered.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return current - whitening.Mean.Get(0, 0, col)
+	})
+	return tsr.MatrixMultiply(centered, whitening.Matrix, nil)
+}
+
+// WhiteningData represents a serialized whitening transform that can be saved to a file.
+type WhiteningData struct {
+	Type    WhiteningType `json:"type"`
+	Epsilon float32       `json:"epsilon"`
+	Mean    []float32     `json:"mean"`
+	Matrix  [][]float32   `json:"matrix"`
+}
+
+// MarshalJSON converts the transform to JSON.
+func (whitening *Whitening) MarshalJSON() ([]byte, error) {
+	data := WhiteningData{
+		Type:    whitening.Type,
+		Epsilon: whitening.Epsilon,
+	}
+	if whitening.Matrix != nil {
+		data.Mean = whitening.Mean.GetFrame(0)[0]
+		data.Matrix = whitening.Matrix.GetFrame(0)
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON creates a new transform from JSON.
+func (whitening *Whitening) UnmarshalJSON(b []byte) error {
+	data := WhiteningData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	whitening.Type = data.Type
+	whitening.Epsilon = data.Epsilon
+	whitening.Mean = nil
+	whitening.Matrix = nil
+	if data.Matrix != nil {
+		whitening.Mean = tsr.NewValueTensor1D(data.Mean)
+		whitening.Matrix = tsr.NewValueTensor2D(data.Matrix)
+	}
+	return nil
+}
+
+// SaveToFile saves a whitening transform to a file.
+func (whitening *Whitening) SaveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(whitening)
+}
+
+// LoadFromFile loads a whitening transform from a file.
+func (whitening *Whitening) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewDecoder(file).Decode(whitening)
+}
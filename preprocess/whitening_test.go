@@ -0,0 +1,96 @@
+package preprocess
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	tsr "../tensor"
+)
+
+func correlatedData(samples int) *tsr.Tensor {
+	data := tsr.NewEmptyTensor2D(samples, 3)
+	for row := 0; row < samples; row++ {
+		x := rand.Float32()*4 - 2
+		y := rand.Float32()*2 - 1
+		data.Set(0, row, 0, x+5)
+		data.Set(0, row, 1, 2*x+y)
+		data.Set(0, row, 2, y-x)
+	}
+	return data
+}
+
+func isIdentity(tensor *tsr.Tensor, tolerance float64) bool {
+	for row := 0; row < tensor.Rows; row++ {
+		for col := 0; col < tensor.Cols; col++ {
+			expected := 0.0
+			if row == col {
+				expected = 1.0
+			}
+			if math.Abs(float64(tensor.Get(0, row, col))-expected) > tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestWhitening(t *testing.T) {
+	rand.Seed(time.Now().Unix())
+	data := correlatedData(200)
+	data.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		// Add a little noise so the features are not perfectly dependent.
+		return current + rand.Float32()*0.1
+	})
+
+	for _, whiteningType := range []WhiteningType{WhiteningTypePCA, WhiteningTypeZCA} {
+		whitening := NewWhitening(whiteningType, 0)
+		err := whitening.Fit(data)
+		if err != nil {
+			t.Fatalf("Error in Fit: %s", err.Error())
+		}
+
+		whitened, err := whitening.Transform(data)
+		if err != nil {
+			t.Fatalf("Error in Transform: %s", err.Error())
+		}
+
+		covariance, _ := tsr.Covariance(whitened, nil)
+		if !isIdentity(covariance, 1e-2) {
+			t.Errorf("Covariance of %s whitened data should be the identity, is:\n%s", whiteningType, covariance.String())
+		}
+	}
+
+	_, err := NewWhitening(WhiteningTypeZCA, 0).Transform(data)
+	if err == nil {
+		t.Errorf("Did not trigger error on transform before fit")
+	}
+}
+
+func TestWhiteningSaveLoad(t *testing.T) {
+	data := correlatedData(50)
+	whitening := NewWhitening(WhiteningTypeZCA, 1e-3)
+	whitening.Fit(data)
+
+	err := whitening.SaveToFile("whitening.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+
+	loadedWhitening := &Whitening{}
+	err = loadedWhitening.LoadFromFile("whitening.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+
+	if loadedWhitening.Type != whitening.Type || !loadedWhitening.Matrix.Equals(whitening.Matrix) || !loadedWhitening.Mean.Equals(whitening.Mean) {
+		t.Errorf("Loaded whitening does not match original")
+	}
+
+	err = os.Remove("whitening.json")
+	if err != nil {
+		t.Fatalf("Error removing test file: %s", err.Error())
+	}
+}
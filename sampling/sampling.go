@@ -0,0 +1,110 @@
+package sampling
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	tsr "../tensor"
+)
+
+// Categorical draws an index from a tensor of probabilities, where each index is chosen in
+// proportion to its value. Indices count across the frames, rows and columns of the tensor in
+// order, so for a single row the index is the column. The probabilities do not need to sum to 1.
+func Categorical(probabilities *tsr.Tensor) (int, error) {
+	values := flatten(probabilities)
+	sum := float32(0.0)
+	for _, value := range values {
+		if value < 0 {
+			return -1, fmt.Errorf("Probabilities must not be negative, has: %f", value)
+		}
+		sum += value
+	}
+	if sum <= 0 {
+		return -1, fmt.Errorf("Probabilities must have a positive sum, is: %f", sum)
+	}
+	threshold := rand.Float32() * sum
+	cumulative := float32(0.0)
+	for i, value := range values {
+		cumulative += value
+		if threshold < cumulative {
+			return i, nil
+		}
+	}
+	// Rounding can leave the threshold just above the last cumulative sum.
+	for i := len(values) - 1; i >= 0; i-- {
+		if values[i] > 0 {
+			return i, nil
+		}
+	}
+	return len(values) - 1, nil
+}
+
+// CategoricalLogits draws an index from a tensor of unnormalized log probabilities after scaling
+// them by a temperature.
+func CategoricalLogits(logits *tsr.Tensor, temperature float32) (int, error) {
+	probabilities, err := Temperature(logits, temperature)
+	if err != nil {
+		return -1, err
+	}
+	return Categorical(probabilities)
+}
+
+// Temperature divides a tensor of unnormalized log probabilities by a temperature and converts
+// them to probabilities. Temperatures below 1 make the distribution sharper, and temperatures
+// above 1 make it flatter.
+func Temperature(logits *tsr.Tensor, temperature float32) (*tsr.Tensor, error) {
+	if temperature <= 0 {
+		return nil, fmt.Errorf("Temperature must be positive, is: %f", temperature)
+	}
+	scaled := logits.Copy()
+	scaled.Scale(1 / temperature)
+	return softmax(scaled), nil
+}
+
+// GumbelSoftmax draws a relaxed one-hot sample from a tensor of unnormalized log probabilities by
+// adding Gumbel noise and applying a softmax with a temperature. As the temperature approaches 0
+// the sample approaches a one-hot categorical sample.
+func GumbelSoftmax(logits *tsr.Tensor, temperature float32) (*tsr.Tensor, error) {
+	if temperature <= 0 {
+		return nil, fmt.Errorf("Temperature must be positive, is: %f", temperature)
+	}
+	noisy := logits.Copy()
+	noisy.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return (current + gumbel()) / temperature
+	})
+	return softmax(noisy), nil
+}
+
+func gumbel() float32 {
+	uniform := rand.Float64()
+	for uniform == 0 {
+		uniform = rand.Float64()
+	}
+	return float32(-math.Log(-math.Log(uniform)))
+}
+
+func softmax(tensor *tsr.Tensor) *tsr.Tensor {
+	max := float32(-math.MaxFloat32)
+	for _, value := range flatten(tensor) {
+		if value > max {
+			max = value
+		}
+	}
+	result := tensor.Copy()
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return float32(math.Exp(float64(current - max)))
+	})
+	result.Scale(1 / result.Sum())
+	return result
+}
+
+func flatten(tensor *tsr.Tensor) []float32 {
+	values := make([]float32, 0, tensor.Frames*tensor.Rows*tensor.Cols)
+	for _, frame := range tensor.GetAll() {
+		for _, row := range frame {
+			values = append(values, row...)
+		}
+	}
+	return values
+}
@@ -0,0 +1,95 @@
+package sampling
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	tsr "../tensor"
+)
+
+func TestCategorical(t *testing.T) {
+	rand.Seed(1)
+
+	probabilities := tsr.NewValueTensor1D([]float32{0.1, 0, 0.6, 0.3})
+	counts := make([]int, 4)
+	samples := 10000
+	for i := 0; i < samples; i++ {
+		index, err := Categorical(probabilities)
+		if err != nil {
+			t.Fatalf("Error in Categorical: %s", err.Error())
+		}
+		counts[index]++
+	}
+
+	for i, count := range counts {
+		frequency := float32(count) / float32(samples)
+		if math.Abs(float64(frequency-probabilities.Get(0, 0, i))) > 0.02 {
+			t.Errorf("Frequency of index %d should be close to %.2f, is: %.3f", i, probabilities.Get(0, 0, i), frequency)
+		}
+	}
+
+	_, err := Categorical(tsr.NewValueTensor1D([]float32{0.5, -0.5}))
+	if err == nil {
+		t.Errorf("Categorical with negative probability did not trigger error")
+	}
+
+	_, err = Categorical(tsr.NewValueTensor1D([]float32{0, 0}))
+	if err == nil {
+		t.Errorf("Categorical with zero probabilities did not trigger error")
+	}
+}
+
+func TestTemperature(t *testing.T) {
+	logits := tsr.NewValueTensor1D([]float32{1, 2, 3})
+
+	probabilities, err := Temperature(logits, 1)
+	if err != nil {
+		t.Fatalf("Error in Temperature: %s", err.Error())
+	}
+	sum := math.Exp(1) + math.Exp(2) + math.Exp(3)
+	for col := 0; col < 3; col++ {
+		solution := math.Exp(float64(col+1)) / sum
+		if math.Abs(float64(probabilities.Get(0, 0, col))-solution) > 1e-5 {
+			t.Errorf("Probability %d should be %.4f, is: %.4f", col, solution, probabilities.Get(0, 0, col))
+		}
+	}
+
+	sharp, _ := Temperature(logits, 0.1)
+	flat, _ := Temperature(logits, 10)
+	if sharp.Get(0, 0, 2) <= probabilities.Get(0, 0, 2) || flat.Get(0, 0, 2) >= probabilities.Get(0, 0, 2) {
+		t.Errorf("Temperature should sharpen below 1 and flatten above 1")
+	}
+
+	_, err = Temperature(logits, 0)
+	if err == nil {
+		t.Errorf("Temperature of zero did not trigger error")
+	}
+}
+
+func TestGumbelSoftmax(t *testing.T) {
+	rand.Seed(1)
+
+	logits := tsr.NewValueTensor1D([]float32{0, 5, 0})
+	counts := make([]int, 3)
+	for i := 0; i < 1000; i++ {
+		sample, err := GumbelSoftmax(logits, 0.1)
+		if err != nil {
+			t.Fatalf("Error in GumbelSoftmax: %s", err.Error())
+		}
+		if math.Abs(float64(sample.Sum())-1) > 1e-5 {
+			t.Fatalf("Gumbel softmax sample should sum to 1, is: %.5f", sample.Sum())
+		}
+		largest := 0
+		for col := 1; col < 3; col++ {
+			if sample.Get(0, 0, col) > sample.Get(0, 0, largest) {
+				largest = col
+			}
+		}
+		counts[largest]++
+	}
+
+	if counts[1] < 950 {
+		t.Errorf("Most likely index should be sampled most often, counts: %v", counts)
+	}
+}
@@ -0,0 +1,72 @@
+package tensor
+
+import (
+	"fmt"
+)
+
+// Greater compares two tensors element by element, producing 1 where the first value is greater
+// and 0 elsewhere.
+func Greater(tensor1 *Tensor, tensor2 *Tensor, target *Tensor) (*Tensor, error) {
+	return compare(tensor1, tensor2, target, func(value1 float32, value2 float32) bool {
+		return value1 > value2
+	})
+}
+
+// Less compares two tensors element by element, producing 1 where the first value is less and 0
+// elsewhere.
+func Less(tensor1 *Tensor, tensor2 *Tensor, target *Tensor) (*Tensor, error) {
+	return compare(tensor1, tensor2, target, func(value1 float32, value2 float32) bool {
+		return value1 < value2
+	})
+}
+
+// EqualsElementwise compares two tensors element by element, producing 1 where the values are
+// equal and 0 elsewhere.
+func EqualsElementwise(tensor1 *Tensor, tensor2 *Tensor, target *Tensor) (*Tensor, error) {
+	return compare(tensor1, tensor2, target, func(value1 float32, value2 float32) bool {
+		return value1 == value2
+	})
+}
+
+// CountNonzero counts the values in the tensor that are not zero.
+func CountNonzero(tensor *Tensor) int {
+	count := 0
+	for frame := 0; frame < tensor.Frames; frame++ {
+		for row := 0; row < tensor.Rows; row++ {
+			for col := 0; col < tensor.Cols; col++ {
+				if tensor.values[tensor.index(frame, row, col)] != 0 {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+func compare(tensor1 *Tensor, tensor2 *Tensor, target *Tensor, condition func(float32, float32) bool) (*Tensor, error) {
+	if tensor1.Frames != tensor2.Frames || tensor1.Rows != tensor2.Rows || tensor1.Cols != tensor2.Cols {
+		return nil, fmt.Errorf(
+			"Dimensions must match: (%d, %d, %d) != (%d, %d, %d)",
+			tensor1.Frames, tensor1.Rows, tensor1.Cols, tensor2.Frames, tensor2.Rows, tensor2.Cols,
+		)
+	}
+	var result *Tensor
+	if target != nil {
+		if target.Frames != tensor1.Frames || target.Rows != tensor1.Rows || target.Cols != tensor1.Cols {
+			return nil, fmt.Errorf(
+				"Invalid target dimensions: (%d, %d, %d) != (%d, %d, %d)",
+				target.Frames, target.Rows, target.Cols, tensor1.Frames, tensor1.Rows, tensor1.Cols,
+			)
+		}
+		result = target
+	} else {
+		result = NewEmptyTensor3D(tensor1.Frames, tensor1.Rows, tensor1.Cols)
+	}
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		if condition(tensor1.Get(frame, row, col), tensor2.Get(frame, row, col)) {
+			return 1.0
+		}
+		return 0.0
+	})
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package tensor
+
+import (
+	"testing"
+)
+
+func TestComparison(t *testing.T) {
+	tensor1 := NewValueTensor2D([][]float32{
+		{1, 5, 3},
+		{-2, 0, 4},
+	})
+
+	tensor2 := NewValueTensor2D([][]float32{
+		{2, 5, 1},
+		{-2, 1, 3},
+	})
+
+	greater, err := Greater(tensor1, tensor2, nil)
+	if err != nil {
+		t.Fatalf("Error in Greater: %s", err.Error())
+	}
+	greaterSolution := NewValueTensor2D([][]float32{
+		{0, 0, 1},
+		{0, 0, 1},
+	})
+	if !greater.Equals(greaterSolution) {
+		t.Errorf("Greater mask should be:\n%swhen result is:\n%s", greaterSolution.String(), greater.String())
+	}
+
+	less, err := Less(tensor1, tensor2, nil)
+	if err != nil {
+		t.Fatalf("Error in Less: %s", err.Error())
+	}
+	lessSolution := NewValueTensor2D([][]float32{
+		{1, 0, 0},
+		{0, 1, 0},
+	})
+	if !less.Equals(lessSolution) {
+		t.Errorf("Less mask should be:\n%swhen result is:\n%s", lessSolution.String(), less.String())
+	}
+
+	equals, err := EqualsElementwise(tensor1, tensor2, nil)
+	if err != nil {
+		t.Fatalf("Error in EqualsElementwise: %s", err.Error())
+	}
+	equalsSolution := NewValueTensor2D([][]float32{
+		{0, 1, 0},
+		{1, 0, 0},
+	})
+	if !equals.Equals(equalsSolution) {
+		t.Errorf("Equals mask should be:\n%swhen result is:\n%s", equalsSolution.String(), equals.String())
+	}
+
+	_, err = Greater(tensor1, NewEmptyTensor2D(3, 2), nil)
+	if err == nil {
+		t.Errorf("Comparison with invalid dimensions did not trigger error")
+	}
+}
+
+func TestCountNonzero(t *testing.T) {
+	tensor := NewValueTensor3D([][][]float32{
+		{{1, 0}, {0, -3}},
+		{{0, 0}, {2, 0.5}},
+	})
+
+	count := CountNonzero(tensor)
+	if count != 4 {
+		t.Errorf("Nonzero count should be 4, is: %d", count)
+	}
+}
@@ -0,0 +1,138 @@
+package tensor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Einsum evaluates a tensor contraction written in Einstein summation notation, such as
+// "ij,jk->ik" for matrix multiplication or "bij,bkj->bik" for a batched product with a transpose.
+// Each subscript has up to three letters, which name the trailing axes of frames, rows and
+// columns, so "ij" expects a single frame and "j" a single row. Letters missing from the output
+// are summed over. If the output is left out, it is made of the letters that appear only once,
+// in alphabetical order.
+func Einsum(subscripts string, tensors ...*Tensor) (*Tensor, error) {
+	subscripts = strings.Replace(subscripts, " ", "", -1)
+	inputSubscripts := subscripts
+	outputSubscript := ""
+	explicitOutput := strings.Contains(subscripts, "->")
+	if explicitOutput {
+		parts := strings.Split(subscripts, "->")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid einsum subscripts: %s", subscripts)
+		}
+		inputSubscripts = parts[0]
+		outputSubscript = parts[1]
+	}
+	operands := strings.Split(inputSubscripts, ",")
+	if len(operands) != len(tensors) {
+		return nil, fmt.Errorf("Number of subscripts does not match number of tensors: %d != %d", len(operands), len(tensors))
+	}
+
+	sizes := map[rune]int{}
+	counts := map[rune]int{}
+	for i, operand := range operands {
+		if len(operand) > 3 {
+			return nil, fmt.Errorf("Subscript has more than 3 axes: %s", operand)
+		}
+		tensor := tensors[i]
+		shape := []int{tensor.Frames, tensor.Rows, tensor.Cols}
+		for axis := 0; axis < 3-len(operand); axis++ {
+			if shape[axis] != 1 {
+				return nil, fmt.Errorf(
+					"Subscript %s does not match tensor dimensions: (%d, %d, %d)",
+					operand, tensor.Frames, tensor.Rows, tensor.Cols,
+				)
+			}
+		}
+		for j, letter := range operand {
+			if letter < 'a' || letter > 'z' {
+				return nil, fmt.Errorf("Invalid subscript letter: %c", letter)
+			}
+			size := shape[3-len(operand)+j]
+			if existing, ok := sizes[letter]; ok && existing != size {
+				return nil, fmt.Errorf("Size of axis %c does not match: %d != %d", letter, existing, size)
+			}
+			sizes[letter] = size
+			counts[letter]++
+		}
+	}
+
+	if !explicitOutput {
+		for letter := 'a'; letter <= 'z'; letter++ {
+			if counts[letter] == 1 {
+				outputSubscript += string(letter)
+			}
+		}
+	}
+	if len(outputSubscript) > 3 {
+		return nil, fmt.Errorf("Output subscript has more than 3 axes: %s", outputSubscript)
+	}
+
+	// Letters that are not in the output are summed over.
+	letters := []rune{}
+	positions := map[rune]int{}
+	for _, letter := range outputSubscript {
+		if _, ok := sizes[letter]; !ok {
+			return nil, fmt.Errorf("Output subscript letter does not appear in the inputs: %c", letter)
+		}
+		if _, ok := positions[letter]; ok {
+			return nil, fmt.Errorf("Output subscript letter is repeated: %c", letter)
+		}
+		positions[letter] = len(letters)
+		letters = append(letters, letter)
+	}
+	for _, operand := range operands {
+		for _, letter := range operand {
+			if _, ok := positions[letter]; !ok {
+				positions[letter] = len(letters)
+				letters = append(letters, letter)
+			}
+		}
+	}
+
+	outputShape := []int{1, 1, 1}
+	for j, letter := range outputSubscript {
+		outputShape[3-len(outputSubscript)+j] = sizes[letter]
+	}
+	result := NewEmptyTensor3D(outputShape[0], outputShape[1], outputShape[2])
+	for _, letter := range letters {
+		if sizes[letter] == 0 {
+			return result, nil
+		}
+	}
+
+	indices := make([]int, len(letters))
+	for {
+		product := float32(1.0)
+		last := len(operands) - 1
+		for i := 0; i < last; i++ {
+			product *= tensors[i].Get(einsumLocation(operands[i], positions, indices))
+		}
+		frame, row, col := einsumLocation(outputSubscript, positions, indices)
+		index := result.index(frame, row, col)
+		result.values[index] = MultiplyAdd(result.values[index], product, tensors[last].Get(einsumLocation(operands[last], positions, indices)))
+
+		next := len(indices) - 1
+		for next >= 0 {
+			indices[next]++
+			if indices[next] < sizes[letters[next]] {
+				break
+			}
+			indices[next] = 0
+			next--
+		}
+		if next < 0 {
+			break
+		}
+	}
+	return result, nil
+}
+
+func einsumLocation(subscript string, positions map[rune]int, indices []int) (int, int, int) {
+	location := []int{0, 0, 0}
+	for j, letter := range subscript {
+		location[3-len(subscript)+j] = indices[positions[letter]]
+	}
+	return location[0], location[1], location[2]
+}
@@ -0,0 +1,123 @@
+package tensor
+
+import (
+	"testing"
+)
+
+func TestEinsumMatrixMultiply(t *testing.T) {
+	tensor1 := NewValueTensor2D([][]float32{
+		{1, 2, 3},
+		{4, 5, 6},
+	})
+
+	tensor2 := NewValueTensor2D([][]float32{
+		{1, 0},
+		{2, 1},
+		{0, 3},
+	})
+
+	result, err := Einsum("ij,jk->ik", tensor1, tensor2)
+	if err != nil {
+		t.Fatalf("Error in Einsum: %s", err.Error())
+	}
+
+	solution, _ := MatrixMultiply(tensor1, tensor2, nil)
+	if !result.Equals(solution) {
+		t.Errorf("Einsum matrix multiply should be:\n%swhen result is:\n%s", solution.String(), result.String())
+	}
+
+	implicit, err := Einsum("ij,jk", tensor1, tensor2)
+	if err != nil {
+		t.Fatalf("Error in Einsum: %s", err.Error())
+	}
+	if !implicit.Equals(solution) {
+		t.Errorf("Implicit einsum matrix multiply should be:\n%swhen result is:\n%s", solution.String(), implicit.String())
+	}
+}
+
+func TestEinsumBatched(t *testing.T) {
+	queries := NewValueTensor3D([][][]float32{
+		{{1, 0}, {0, 1}},
+		{{1, 2}, {3, 4}},
+	})
+
+	keys := NewValueTensor3D([][][]float32{
+		{{2, 3}, {4, 5}},
+		{{1, 1}, {0, 1}},
+	})
+
+	result, err := Einsum("bij,bkj->bik", queries, keys)
+	if err != nil {
+		t.Fatalf("Error in Einsum: %s", err.Error())
+	}
+
+	solution := NewValueTensor3D([][][]float32{
+		{{2, 4}, {3, 5}},
+		{{3, 2}, {7, 4}},
+	})
+	if !result.Equals(solution) || result.Frames != solution.Frames {
+		t.Errorf("Einsum batched product should be:\n%swhen result is:\n%s", solution.String(), result.String())
+	}
+}
+
+func TestEinsumReductions(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{
+		{1, 2},
+		{3, 4},
+	})
+
+	trace, err := Einsum("ii->", tensor)
+	if err != nil {
+		t.Fatalf("Error in Einsum: %s", err.Error())
+	}
+	if trace.Get(0, 0, 0) != 5 {
+		t.Errorf("Einsum trace should be 5, is: %.1f", trace.Get(0, 0, 0))
+	}
+
+	rowSums, err := Einsum("ij->i", tensor)
+	if err != nil {
+		t.Fatalf("Error in Einsum: %s", err.Error())
+	}
+	solution := NewValueTensor1D([]float32{3, 7})
+	if !rowSums.Equals(solution) {
+		t.Errorf("Einsum row sums should be:\n%swhen result is:\n%s", solution.String(), rowSums.String())
+	}
+
+	vector := NewValueTensor1D([]float32{1, 2})
+	outer, err := Einsum("i,j->ij", vector, vector)
+	if err != nil {
+		t.Fatalf("Error in Einsum: %s", err.Error())
+	}
+	outerSolution := NewValueTensor2D([][]float32{
+		{1, 2},
+		{2, 4},
+	})
+	if !outer.Equals(outerSolution) {
+		t.Errorf("Einsum outer product should be:\n%swhen result is:\n%s", outerSolution.String(), outer.String())
+	}
+}
+
+func TestEinsumInvalid(t *testing.T) {
+	tensor1 := NewEmptyTensor2D(2, 3)
+	tensor2 := NewEmptyTensor2D(2, 3)
+
+	_, err := Einsum("ij,jk->ik", tensor1, tensor2)
+	if err == nil {
+		t.Errorf("Einsum with mismatched axis sizes did not trigger error")
+	}
+
+	_, err = Einsum("ij->ik", tensor1)
+	if err == nil {
+		t.Errorf("Einsum with unknown output letter did not trigger error")
+	}
+
+	_, err = Einsum("ij,jk->ik", tensor1)
+	if err == nil {
+		t.Errorf("Einsum with missing tensor did not trigger error")
+	}
+
+	_, err = Einsum("j->j", tensor1)
+	if err == nil {
+		t.Errorf("Einsum with too few axes did not trigger error")
+	}
+}
@@ -0,0 +1,236 @@
+package tensor
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// FFT computes the discrete Fourier transform along an axis of a tensor of complex values, given as
+// a tensor of the real parts and a tensor of the imaginary parts, which can be nil for real values.
+// It returns the real and imaginary parts of the result. Lines with a power of 2 values use the
+// fast Fourier transform, and other lengths fall back to the direct transform, which is slower.
+func FFT(realPart *Tensor, imagPart *Tensor, axis Axis) (*Tensor, *Tensor, error) {
+	return transform(realPart, imagPart, axis, false)
+}
+
+// InverseFFT computes the inverse discrete Fourier transform along an axis of a tensor of complex
+// values, so that the inverse of the FFT of values gives back the values.
+func InverseFFT(realPart *Tensor, imagPart *Tensor, axis Axis) (*Tensor, *Tensor, error) {
+	return transform(realPart, imagPart, axis, true)
+}
+
+// FFT2D computes the 2D discrete Fourier transform of each frame of a tensor of complex values.
+func FFT2D(realPart *Tensor, imagPart *Tensor) (*Tensor, *Tensor, error) {
+	realPart, imagPart, err := FFT(realPart, imagPart, AxisRows)
+	if err != nil {
+		return nil, nil, err
+	}
+	return FFT(realPart, imagPart, AxisCols)
+}
+
+// InverseFFT2D computes the inverse 2D discrete Fourier transform of each frame of a tensor of
+// complex values.
+func InverseFFT2D(realPart *Tensor, imagPart *Tensor) (*Tensor, *Tensor, error) {
+	realPart, imagPart, err := InverseFFT(realPart, imagPart, AxisRows)
+	if err != nil {
+		return nil, nil, err
+	}
+	return InverseFFT(realPart, imagPart, AxisCols)
+}
+
+// HannWindow creates a Hann window of a size, which tapers a frame of a signal to 0 at both ends
+// to reduce spectral leakage.
+func HannWindow(size int) *Tensor {
+	return cosineWindow(size, 0.5, 0.5, 0)
+}
+
+// HammingWindow creates a Hamming window of a size, which tapers a frame of a signal without
+// reaching 0 at the ends.
+func HammingWindow(size int) *Tensor {
+	return cosineWindow(size, 0.54, 0.46, 0)
+}
+
+// BlackmanWindow creates a Blackman window of a size, which has lower side lobes than the Hann
+// window at the cost of a wider main lobe.
+func BlackmanWindow(size int) *Tensor {
+	return cosineWindow(size, 0.42, 0.5, 0.08)
+}
+
+// Spectrogram computes the magnitudes of the frequencies of a signal over time. The signal is the
+// columns of a single row, and it is split into frames of a size that start a hop size apart. Each
+// frame is multiplied by a window, which can be nil to leave it unchanged, and each row of the
+// result is the magnitude of the frequencies from 0 to half the sample rate in a frame.
+func Spectrogram(signal *Tensor, frameSize int, hopSize int, window *Tensor) (*Tensor, error) {
+	if signal.Frames != 1 || signal.Rows != 1 {
+		return nil, fmt.Errorf("Signal must be a single row, has: (%d, %d, %d)", signal.Frames, signal.Rows, signal.Cols)
+	}
+	if frameSize < 1 || hopSize < 1 {
+		return nil, fmt.Errorf("Frame size and hop size must be positive, are: %d, %d", frameSize, hopSize)
+	}
+	if frameSize > signal.Cols {
+		return nil, fmt.Errorf("Frame size must not be longer than the signal: %d > %d", frameSize, signal.Cols)
+	}
+	if window != nil && window.Frames*window.Rows*window.Cols != frameSize {
+		return nil, fmt.Errorf("Window size must match frame size: %d != %d", window.Frames*window.Rows*window.Cols, frameSize)
+	}
+	frameCount := 1 + (signal.Cols-frameSize)/hopSize
+	result := NewEmptyTensor2D(frameCount, frameSize/2+1)
+	values := make([]complex128, frameSize)
+	for frame := 0; frame < frameCount; frame++ {
+		for i := range values {
+			value := float64(signal.Get(0, 0, frame*hopSize+i))
+			if window != nil {
+				value *= float64(window.Get(0, 0, i))
+			}
+			values[i] = complex(value, 0)
+		}
+		fft(values, false)
+		for col := 0; col < result.Cols; col++ {
+			result.Set(0, frame, col, float32(cmplx.Abs(values[col])))
+		}
+	}
+	return result, nil
+}
+
+// FFTConvolve computes the full convolution of each row of a tensor with a kernel of a single row,
+// by multiplying their Fourier transforms. Each row of the result has the number of columns of the
+// tensor and the kernel minus 1. This is faster than direct convolution for long kernels.
+func FFTConvolve(tensor *Tensor, kernel *Tensor) (*Tensor, error) {
+	if kernel.Frames != 1 || kernel.Rows != 1 {
+		return nil, fmt.Errorf("Kernel must be a single row, has: (%d, %d, %d)", kernel.Frames, kernel.Rows, kernel.Cols)
+	}
+	if tensor.Cols == 0 || kernel.Cols == 0 {
+		return nil, fmt.Errorf("Tensor and kernel must have at least 1 column, have: %d, %d", tensor.Cols, kernel.Cols)
+	}
+	length := tensor.Cols + kernel.Cols - 1
+	size := 1
+	for size < length {
+		size *= 2
+	}
+	kernelValues := make([]complex128, size)
+	for i := 0; i < kernel.Cols; i++ {
+		kernelValues[i] = complex(float64(kernel.Get(0, 0, i)), 0)
+	}
+	fft(kernelValues, false)
+	result := NewEmptyTensor3D(tensor.Frames, tensor.Rows, length)
+	values := make([]complex128, size)
+	for frame := 0; frame < tensor.Frames; frame++ {
+		for row := 0; row < tensor.Rows; row++ {
+			for i := range values {
+				values[i] = 0
+				if i < tensor.Cols {
+					values[i] = complex(float64(tensor.Get(frame, row, i)), 0)
+				}
+			}
+			fft(values, false)
+			for i := range values {
+				values[i] *= kernelValues[i]
+			}
+			fft(values, true)
+			for col := 0; col < length; col++ {
+				result.Set(frame, row, col, float32(real(values[col])))
+			}
+		}
+	}
+	return result, nil
+}
+
+func transform(realPart *Tensor, imagPart *Tensor, axis Axis, inverse bool) (*Tensor, *Tensor, error) {
+	if imagPart == nil {
+		imagPart = NewEmptyTensor3D(realPart.Frames, realPart.Rows, realPart.Cols)
+	}
+	if realPart.Frames != imagPart.Frames || realPart.Rows != imagPart.Rows || realPart.Cols != imagPart.Cols {
+		return nil, nil, fmt.Errorf(
+			"Real and imaginary dimensions do not match: (%d, %d, %d) != (%d, %d, %d)",
+			realPart.Frames, realPart.Rows, realPart.Cols, imagPart.Frames, imagPart.Rows, imagPart.Cols,
+		)
+	}
+	resultReal := NewEmptyTensor3D(realPart.Frames, realPart.Rows, realPart.Cols)
+	resultImag := NewEmptyTensor3D(realPart.Frames, realPart.Rows, realPart.Cols)
+	err := forEachLine(realPart, axis, func(line func(int) (int, int, int), length int) {
+		values := make([]complex128, length)
+		for i := range values {
+			frame, row, col := line(i)
+			values[i] = complex(float64(realPart.Get(frame, row, col)), float64(imagPart.Get(frame, row, col)))
+		}
+		fft(values, inverse)
+		for i, value := range values {
+			frame, row, col := line(i)
+			resultReal.Set(frame, row, col, float32(real(value)))
+			resultImag.Set(frame, row, col, float32(imag(value)))
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resultReal, resultImag, nil
+}
+
+// fft transforms complex values in place, with the iterative radix 2 algorithm when the number of
+// values is a power of 2, or else the direct transform. The inverse is divided by the number of
+// values.
+func fft(values []complex128, inverse bool) {
+	n := len(values)
+	if n <= 1 {
+		return
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	if n&(n-1) != 0 {
+		result := make([]complex128, n)
+		for k := range result {
+			for t, value := range values {
+				result[k] += value * cmplx.Rect(1, sign*2*math.Pi*float64(k*t%n)/float64(n))
+			}
+		}
+		copy(values, result)
+	} else {
+		// Reorder the values by the bit reversal of their indices, then combine pairs of transforms
+		// of doubling size.
+		for i, j := 1, 0; i < n; i++ {
+			bit := n >> 1
+			for ; j&bit != 0; bit >>= 1 {
+				j ^= bit
+			}
+			j ^= bit
+			if i < j {
+				values[i], values[j] = values[j], values[i]
+			}
+		}
+		for size := 2; size <= n; size <<= 1 {
+			step := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+			for start := 0; start < n; start += size {
+				twiddle := complex(1, 0)
+				for k := 0; k < size/2; k++ {
+					even := values[start+k]
+					odd := values[start+k+size/2] * twiddle
+					values[start+k] = even + odd
+					values[start+k+size/2] = even - odd
+					twiddle *= step
+				}
+			}
+		}
+	}
+	if inverse {
+		for i := range values {
+			values[i] /= complex(float64(n), 0)
+		}
+	}
+}
+
+// cosineWindow creates a symmetric window from a sum of cosines with coefficients a0, a1 and a2.
+func cosineWindow(size int, a0 float64, a1 float64, a2 float64) *Tensor {
+	window := NewEmptyTensor1D(size)
+	if size == 1 {
+		window.Set(0, 0, 0, 1)
+		return window
+	}
+	for i := 0; i < size; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(size-1)
+		window.Set(0, 0, i, float32(a0-a1*math.Cos(angle)+a2*math.Cos(2*angle)))
+	}
+	return window
+}
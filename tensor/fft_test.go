@@ -0,0 +1,156 @@
+package tensor
+
+import (
+	"math"
+	"testing"
+)
+
+func closeTo(tensor *Tensor, solution *Tensor, tolerance float64) bool {
+	if tensor.Frames != solution.Frames || tensor.Rows != solution.Rows || tensor.Cols != solution.Cols {
+		return false
+	}
+	matches := true
+	tensor.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		if math.Abs(float64(current-solution.Get(frame, row, col))) > tolerance {
+			matches = false
+		}
+		return current
+	})
+	return matches
+}
+
+func TestTensorFFT(t *testing.T) {
+	for _, size := range []int{8, 6} {
+		// A cosine with 2 cycles over the signal has all its energy at frequencies 2 and size - 2.
+		signal := NewEmptyTensor2D(2, size)
+		expected := NewEmptyTensor2D(2, size)
+		for i := 0; i < size; i++ {
+			signal.Set(0, 0, i, float32(math.Cos(2*math.Pi*2*float64(i)/float64(size))))
+			signal.Set(0, 1, i, 1)
+		}
+		expected.Set(0, 0, 2, float32(size)/2)
+		expected.Set(0, 0, size-2, float32(size)/2)
+		expected.Set(0, 1, 0, float32(size))
+
+		realPart, imagPart, err := FFT(signal, nil, AxisCols)
+		if err != nil {
+			t.Fatalf("Error in FFT: %s", err.Error())
+		}
+		if !closeTo(realPart, expected, 1e-4) || !closeTo(imagPart, NewEmptyTensor2D(2, size), 1e-4) {
+			t.Errorf("FFT of size %d should be:\n%swhen result is:\n%s", size, expected.String(), realPart.String())
+		}
+
+		inverseReal, inverseImag, err := InverseFFT(realPart, imagPart, AxisCols)
+		if err != nil {
+			t.Fatalf("Error in InverseFFT: %s", err.Error())
+		}
+		if !closeTo(inverseReal, signal, 1e-5) || !closeTo(inverseImag, NewEmptyTensor2D(2, size), 1e-5) {
+			t.Errorf("Inverse FFT of size %d should give back the signal:\n%swhen result is:\n%s", size, signal.String(), inverseReal.String())
+		}
+	}
+
+	_, _, err := FFT(NewEmptyTensor1D(4), NewEmptyTensor1D(3), AxisCols)
+	if err == nil {
+		t.Errorf("Mismatched real and imaginary parts did not trigger error")
+	}
+}
+
+func TestTensorFFT2D(t *testing.T) {
+	image := NewValueTensor2D([][]float32{
+		{1, 2, 3, 4},
+		{0, 1, 0, 1},
+	})
+	realPart, imagPart, err := FFT2D(image, nil)
+	if err != nil {
+		t.Fatalf("Error in FFT2D: %s", err.Error())
+	}
+	if math.Abs(float64(realPart.Get(0, 0, 0)-image.Sum())) > 1e-5 {
+		t.Errorf("First value of the 2D FFT should be the sum %f, is: %f", image.Sum(), realPart.Get(0, 0, 0))
+	}
+	inverseReal, _, err := InverseFFT2D(realPart, imagPart)
+	if err != nil {
+		t.Fatalf("Error in InverseFFT2D: %s", err.Error())
+	}
+	if !closeTo(inverseReal, image, 1e-5) {
+		t.Errorf("Inverse 2D FFT should give back the image:\n%swhen result is:\n%s", image.String(), inverseReal.String())
+	}
+}
+
+func TestTensorWindows(t *testing.T) {
+	windows := []struct {
+		name   string
+		window *Tensor
+		ends   float32
+	}{
+		{"Hann", HannWindow(5), 0},
+		{"Hamming", HammingWindow(5), 0.08},
+		{"Blackman", BlackmanWindow(5), 0},
+	}
+	for _, test := range windows {
+		if math.Abs(float64(test.window.Get(0, 0, 0)-test.ends)) > 1e-5 || math.Abs(float64(test.window.Get(0, 0, 4)-test.ends)) > 1e-5 {
+			t.Errorf("%s window should be %.2f at the ends:\n%s", test.name, test.ends, test.window.String())
+		}
+		if math.Abs(float64(test.window.Get(0, 0, 2)-1)) > 1e-5 {
+			t.Errorf("%s window should be 1 in the middle:\n%s", test.name, test.window.String())
+		}
+	}
+}
+
+func TestTensorSpectrogram(t *testing.T) {
+	// The signal has a frequency of 4 cycles per 16 samples, then 2 cycles per 16 samples.
+	signal := NewEmptyTensor1D(64)
+	for i := 0; i < 64; i++ {
+		frequency := 4.0
+		if i >= 32 {
+			frequency = 2.0
+		}
+		signal.Set(0, 0, i, float32(math.Sin(2*math.Pi*frequency*float64(i)/16)))
+	}
+	spectrogram, err := Spectrogram(signal, 16, 16, HannWindow(16))
+	if err != nil {
+		t.Fatalf("Error in Spectrogram: %s", err.Error())
+	}
+	if spectrogram.Rows != 4 || spectrogram.Cols != 9 {
+		t.Fatalf("Spectrogram should have 4 rows and 9 columns, has: %d, %d", spectrogram.Rows, spectrogram.Cols)
+	}
+	for row, frequency := range []int{4, 4, 2, 2} {
+		loudest := 0
+		for col := 0; col < spectrogram.Cols; col++ {
+			if spectrogram.Get(0, row, col) > spectrogram.Get(0, row, loudest) {
+				loudest = col
+			}
+		}
+		if loudest != frequency {
+			t.Errorf("Loudest frequency of frame %d should be %d, is: %d", row, frequency, loudest)
+		}
+	}
+
+	_, err = Spectrogram(signal, 16, 8, HannWindow(8))
+	if err == nil {
+		t.Errorf("Window of the wrong size did not trigger error")
+	}
+}
+
+func TestTensorFFTConvolve(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{
+		{1, 2, 3},
+		{0, 1, 0},
+	})
+	kernel := NewValueTensor1D([]float32{1, 0, -1})
+	result, err := FFTConvolve(tensor, kernel)
+	if err != nil {
+		t.Fatalf("Error in FFTConvolve: %s", err.Error())
+	}
+	solution := NewValueTensor2D([][]float32{
+		{1, 2, 2, -2, -3},
+		{0, 1, 0, -1, 0},
+	})
+	if !closeTo(result, solution, 1e-5) {
+		t.Errorf("Convolution should be:\n%swhen result is:\n%s", solution.String(), result.String())
+	}
+
+	_, err = FFTConvolve(tensor, tensor)
+	if err == nil {
+		t.Errorf("Kernel with more than 1 row did not trigger error")
+	}
+}
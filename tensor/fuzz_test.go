@@ -0,0 +1,48 @@
+package tensor
+
+import (
+	"testing"
+)
+
+// FuzzTensorShapes checks that operations on tensors of mismatched or empty shapes, and with
+// out of range arguments, produce errors rather than panics.
+func FuzzTensorShapes(f *testing.F) {
+	f.Add(uint8(1), uint8(2), uint8(3), uint8(1), uint8(3), uint8(2), 0, 1)
+	f.Add(uint8(2), uint8(2), uint8(2), uint8(2), uint8(2), uint8(2), 1, 3)
+	f.Add(uint8(1), uint8(0), uint8(4), uint8(1), uint8(4), uint8(0), -1, 0)
+
+	f.Fuzz(func(t *testing.T, frames1 uint8, rows1 uint8, cols1 uint8, frames2 uint8, rows2 uint8, cols2 uint8, start int, end int) {
+		tensor1 := NewEmptyTensor3D(int(frames1%5), int(rows1%5), int(cols1%5))
+		tensor2 := NewEmptyTensor3D(int(frames2%5), int(rows2%5), int(cols2%5))
+		tensor1.SetRandom(-1, 1)
+		tensor2.SetRandom(-1, 1)
+
+		MatrixMultiply(tensor1, tensor2, nil)
+		MatrixMultiply(tensor1, tensor2, tensor1)
+		MatrixTranspose(tensor1, tensor2)
+		MatrixKronecker(tensor1, tensor2, nil)
+		Hadamard(tensor1, tensor2, nil)
+		Greater(tensor1, tensor2, nil)
+		tensor1.Copy().AddTensor(tensor2)
+		tensor1.Copy().SubtractTensor(tensor2)
+		tensor1.Copy().ScaleTensor(tensor2)
+		tensor1.Copy().SetTensor(tensor2)
+		tensor1.Reshape(tensor2.Frames, tensor2.Rows, tensor2.Cols)
+		tensor1.Reshape(start, end, 1)
+		tensor1.SliceFrames(start, end)
+		MatrixTrace(tensor1)
+		MatrixTril(tensor1, start, nil)
+		Covariance(tensor1, nil)
+		Quantile(tensor1, float32(start)/10, AxisRows)
+		Histogram(tensor1, end)
+		for _, axis := range []Axis{AxisRows, AxisCols} {
+			CumSum(tensor1, axis)
+			Diff(tensor1, axis)
+			Sort(tensor1, axis)
+			FFT(tensor1, tensor2, axis)
+		}
+		FFTConvolve(tensor1, tensor2)
+		Spectrogram(tensor1, start, end, nil)
+		Einsum("ij,jk->ik", tensor1, tensor2)
+	})
+}
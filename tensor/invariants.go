@@ -0,0 +1,49 @@
+package tensor
+
+import (
+	"fmt"
+	"log"
+)
+
+// Validate checks that the shape of a tensor is consistent with its values, so that every index of
+// the tensor is within the values it holds or shares.
+func (tensor *Tensor) Validate() error {
+	if tensor.Frames < 0 || tensor.Rows < 0 || tensor.Cols < 0 {
+		return fmt.Errorf("Dimensions of tensor must not be negative: (%d, %d, %d)", tensor.Frames, tensor.Rows, tensor.Cols)
+	}
+	if tensor.Frames == 0 || tensor.Rows == 0 || tensor.Cols == 0 {
+		return nil
+	}
+	if tensor.offset < 0 || tensor.strides[0] < 0 || tensor.strides[1] < 0 || tensor.strides[2] < 0 {
+		return fmt.Errorf("Offset and strides of tensor must not be negative: %d, %v", tensor.offset, tensor.strides)
+	}
+	last := tensor.index(tensor.Frames-1, tensor.Rows-1, tensor.Cols-1)
+	if last >= len(tensor.values) {
+		return fmt.Errorf("Last index of tensor is outside of its values: %d >= %d", last, len(tensor.values))
+	}
+	return nil
+}
+
+// checkInvariants validates the tensors made by an operation when built with the debug tag. An
+// invalid tensor is a bug in the operation rather than in its inputs, so it panics where the bug
+// happens rather than giving wrong values later.
+func checkInvariants(tensors ...*Tensor) {
+	if !DebugInvariants {
+		return
+	}
+	for _, tensor := range tensors {
+		err := tensor.Validate()
+		if err != nil {
+			log.Panicf("Invalid tensor after operation: %s", err.Error())
+		}
+	}
+}
+
+// sharesValues checks whether two tensors hold any of the same values, such as a tensor and a view
+// of it, where writing to one while reading from the other gives wrong results.
+func sharesValues(tensor1 *Tensor, tensor2 *Tensor) bool {
+	if len(tensor1.values) == 0 || len(tensor2.values) == 0 {
+		return false
+	}
+	return &tensor1.values[0] == &tensor2.values[0]
+}
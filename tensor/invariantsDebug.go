@@ -0,0 +1,7 @@
+//go:build debug
+
+package tensor
+
+// DebugInvariants is true when built with the debug tag, which checks the shapes of tensors after
+// each operation, at the cost of speed.
+const DebugInvariants = true
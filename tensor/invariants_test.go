@@ -0,0 +1,45 @@
+package tensor
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{{1, 2, 3}, {4, 5, 6}})
+	err := tensor.Validate()
+	if err != nil {
+		t.Errorf("Valid tensor should not have an error: %s", err.Error())
+	}
+	view, _ := tensor.SliceFrames(0, 1)
+	err = view.TransposeView().Validate()
+	if err != nil {
+		t.Errorf("Valid view should not have an error: %s", err.Error())
+	}
+
+	tensor.Rows = 3
+	err = tensor.Validate()
+	if err == nil {
+		t.Errorf("Tensor with more rows than values did not trigger error")
+	}
+	tensor.Rows = -2
+	err = tensor.Validate()
+	if err == nil {
+		t.Errorf("Tensor with negative rows did not trigger error")
+	}
+}
+
+func TestSharedTarget(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{{1, 2}, {3, 4}})
+	_, err := MatrixMultiply(tensor, tensor.Copy(), tensor)
+	if err == nil {
+		t.Errorf("Multiplying into one of the tensors did not trigger error")
+	}
+	_, err = MatrixTranspose(tensor, tensor.TransposeView())
+	if err == nil {
+		t.Errorf("Transposing into a view of the tensor did not trigger error")
+	}
+	_, err = tensor.Reshape(-1, 0, 1)
+	if err == nil {
+		t.Errorf("Reshaping to negative dimensions did not trigger error")
+	}
+}
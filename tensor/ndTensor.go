@@ -0,0 +1,294 @@
+package tensor
+
+import (
+	"fmt"
+	"log"
+)
+
+// NDTensor represents a set of values with any number of dimensions, such as a batch of images with
+// a shape of (batch, channels, height, width). The values are stored in a single slice in order of
+// the dimensions, with the last dimension changing fastest.
+type NDTensor struct {
+	shape   []int
+	strides []int
+	values  []float32
+}
+
+// NewEmptyNDTensor creates a new tensor of zeros with the given shape.
+func NewEmptyNDTensor(shape ...int) (*NDTensor, error) {
+	size, err := shapeSize(shape)
+	if err != nil {
+		return nil, err
+	}
+	return newNDTensor(shape, make([]float32, size)), nil
+}
+
+// NewValueNDTensor creates a new tensor with the given shape from values stored in order of the
+// dimensions.
+func NewValueNDTensor(values []float32, shape ...int) (*NDTensor, error) {
+	size, err := shapeSize(shape)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != size {
+		return nil, fmt.Errorf("Number of values does not match shape %v: %d != %d", shape, len(values), size)
+	}
+	tensorValues := make([]float32, size)
+	copy(tensorValues, values)
+	return newNDTensor(shape, tensorValues), nil
+}
+
+// NewNDTensorFromTensor creates a new tensor with a shape of (frames, rows, cols) from a 3D tensor.
+func NewNDTensorFromTensor(tensor *Tensor) *NDTensor {
+	copied := tensor.Copy()
+	return newNDTensor([]int{tensor.Frames, tensor.Rows, tensor.Cols}, copied.values)
+}
+
+func newNDTensor(shape []int, values []float32) *NDTensor {
+	tensorShape := make([]int, len(shape))
+	copy(tensorShape, shape)
+	strides := make([]int, len(shape))
+	stride := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		strides[i] = stride
+		stride *= shape[i]
+	}
+	return &NDTensor{shape: tensorShape, strides: strides, values: values}
+}
+
+func shapeSize(shape []int) (int, error) {
+	if len(shape) == 0 {
+		return 0, fmt.Errorf("Shape must have at least 1 dimension")
+	}
+	size := 1
+	for _, length := range shape {
+		if length < 1 {
+			return 0, fmt.Errorf("Dimensions of shape must be positive: %v", shape)
+		}
+		size *= length
+	}
+	return size, nil
+}
+
+// Shape returns the length of each dimension of the tensor.
+func (tensor *NDTensor) Shape() []int {
+	shape := make([]int, len(tensor.shape))
+	copy(shape, tensor.shape)
+	return shape
+}
+
+// Rank returns the number of dimensions of the tensor.
+func (tensor *NDTensor) Rank() int {
+	return len(tensor.shape)
+}
+
+// Size returns the number of values in the tensor.
+func (tensor *NDTensor) Size() int {
+	return len(tensor.values)
+}
+
+// Copy creates a deep copy of the tensor.
+func (tensor *NDTensor) Copy() *NDTensor {
+	values := make([]float32, len(tensor.values))
+	copy(values, tensor.values)
+	return newNDTensor(tensor.shape, values)
+}
+
+// Equals checks that two tensors have the same shape and values.
+func (tensor *NDTensor) Equals(other *NDTensor) bool {
+	if !tensor.sameShape(other) {
+		return false
+	}
+	for i, value := range tensor.values {
+		if value != other.values[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Get retrieves the value at the given index of each dimension.
+func (tensor *NDTensor) Get(indices ...int) float32 {
+	index, err := tensor.index(indices)
+	if err != nil {
+		log.Panic(err.Error())
+	}
+	return tensor.values[index]
+}
+
+// Set sets a new value at the given index of each dimension.
+func (tensor *NDTensor) Set(value float32, indices ...int) error {
+	index, err := tensor.index(indices)
+	if err != nil {
+		return err
+	}
+	tensor.values[index] = value
+	return nil
+}
+
+// Values retrieves a copy of all values of the tensor in order of the dimensions.
+func (tensor *NDTensor) Values() []float32 {
+	values := make([]float32, len(tensor.values))
+	copy(values, tensor.values)
+	return values
+}
+
+// Reshape creates a tensor with a new shape holding the same values in the same order, which shares
+// its values with the current tensor. One dimension may be -1, in which case its length is found
+// from the number of values.
+func (tensor *NDTensor) Reshape(shape ...int) (*NDTensor, error) {
+	newShape := make([]int, len(shape))
+	copy(newShape, shape)
+	inferred := -1
+	known := 1
+	for i, length := range newShape {
+		if length == -1 {
+			if inferred >= 0 {
+				return nil, fmt.Errorf("Only one dimension of shape can be -1: %v", shape)
+			}
+			inferred = i
+		} else {
+			known *= length
+		}
+	}
+	if inferred >= 0 && known > 0 && len(tensor.values)%known == 0 {
+		newShape[inferred] = len(tensor.values) / known
+	}
+	size, err := shapeSize(newShape)
+	if err != nil {
+		return nil, err
+	}
+	if size != len(tensor.values) {
+		return nil, fmt.Errorf("Cannot reshape tensor: %v -> %v", tensor.shape, shape)
+	}
+	return newNDTensor(newShape, tensor.values), nil
+}
+
+// ToTensor converts a tensor with at most 3 dimensions to a 3D tensor, where the dimensions fill
+// the columns, rows and frames from the last dimension backward.
+func (tensor *NDTensor) ToTensor() (*Tensor, error) {
+	if len(tensor.shape) > 3 {
+		return nil, fmt.Errorf("Tensor with %d dimensions cannot be converted to 3 dimensions", len(tensor.shape))
+	}
+	shape := []int{1, 1, 1}
+	copy(shape[3-len(tensor.shape):], tensor.shape)
+	values := make([]float32, len(tensor.values))
+	copy(values, tensor.values)
+	return newTensor(shape[0], shape[1], shape[2], values), nil
+}
+
+// Sum gets the sum of all values in the tensor.
+func (tensor *NDTensor) Sum() float32 {
+	sum := float32(0.0)
+	for _, value := range tensor.values {
+		sum += value
+	}
+	return sum
+}
+
+// ApplyFunction applies the input function to all the values of the tensor, along with the index
+// of each dimension of the value. The indices must not be changed by the function.
+func (tensor *NDTensor) ApplyFunction(function func(float32, []int) float32) {
+	indices := make([]int, len(tensor.shape))
+	for i := range tensor.values {
+		tensor.values[i] = function(tensor.values[i], indices)
+		for axis := len(indices) - 1; axis >= 0; axis-- {
+			indices[axis]++
+			if indices[axis] < tensor.shape[axis] {
+				break
+			}
+			indices[axis] = 0
+		}
+	}
+}
+
+// Add adds a scalar value to all the values of the tensor.
+func (tensor *NDTensor) Add(value float32) {
+	for i := range tensor.values {
+		tensor.values[i] += value
+	}
+}
+
+// AddTensor adds the values of the input tensor to the values of the current tensor.
+func (tensor *NDTensor) AddTensor(other *NDTensor) error {
+	return tensor.combine(other, func(current float32, value float32) float32 {
+		return current + value
+	})
+}
+
+// Subtract subtracts a scalar value from all the values of the tensor.
+func (tensor *NDTensor) Subtract(value float32) {
+	tensor.Add(-value)
+}
+
+// SubtractTensor subtracts the values of the input tensor from the values of the current tensor.
+func (tensor *NDTensor) SubtractTensor(other *NDTensor) error {
+	return tensor.combine(other, func(current float32, value float32) float32 {
+		return current - value
+	})
+}
+
+// Scale multiplies all the values of the current tensor by a scalar value.
+func (tensor *NDTensor) Scale(value float32) {
+	for i := range tensor.values {
+		tensor.values[i] *= value
+	}
+}
+
+// ScaleTensor multiplies all the values of the current tensor by the values of the input tensor.
+func (tensor *NDTensor) ScaleTensor(other *NDTensor) error {
+	return tensor.combine(other, func(current float32, value float32) float32 {
+		return current * value
+	})
+}
+
+// String creates a string representation of the tensor, with the shape followed by the values.
+func (tensor *NDTensor) String() string {
+	str := fmt.Sprintf("%v\n", tensor.shape)
+	last := tensor.shape[len(tensor.shape)-1]
+	for i, value := range tensor.values {
+		str += fmt.Sprintf("%.4f", value)
+		if (i+1)%last == 0 {
+			str += "\n"
+		} else {
+			str += " "
+		}
+	}
+	return str
+}
+
+func (tensor *NDTensor) combine(other *NDTensor, function func(float32, float32) float32) error {
+	if !tensor.sameShape(other) {
+		return fmt.Errorf("Shapes must match: %v != %v", tensor.shape, other.shape)
+	}
+	for i := range tensor.values {
+		tensor.values[i] = function(tensor.values[i], other.values[i])
+	}
+	return nil
+}
+
+func (tensor *NDTensor) sameShape(other *NDTensor) bool {
+	if len(tensor.shape) != len(other.shape) {
+		return false
+	}
+	for i, length := range tensor.shape {
+		if other.shape[i] != length {
+			return false
+		}
+	}
+	return true
+}
+
+func (tensor *NDTensor) index(indices []int) (int, error) {
+	if len(indices) != len(tensor.shape) {
+		return 0, fmt.Errorf("Number of indices does not match rank: %d != %d", len(indices), len(tensor.shape))
+	}
+	index := 0
+	for axis, i := range indices {
+		if i < 0 || i >= tensor.shape[axis] {
+			return 0, fmt.Errorf("Dimensions out of bounds: %v", indices)
+		}
+		index += i * tensor.strides[axis]
+	}
+	return index, nil
+}
@@ -0,0 +1,140 @@
+package tensor
+
+import "testing"
+
+func TestNDTensorGetSet(t *testing.T) {
+	tensor, err := NewEmptyNDTensor(2, 3, 4, 5)
+	if err != nil {
+		t.Fatalf("Error in NewEmptyNDTensor: %s", err.Error())
+	}
+	shape := tensor.Shape()
+	if tensor.Rank() != 4 || tensor.Size() != 120 || shape[0] != 2 || shape[3] != 5 {
+		t.Errorf("Incorrect shape: %v", shape)
+	}
+
+	err = tensor.Set(7, 1, 2, 3, 4)
+	if err != nil {
+		t.Fatalf("Error in Set: %s", err.Error())
+	}
+	if tensor.Get(1, 2, 3, 4) != 7 {
+		t.Errorf("Value should be 7, is: %.0f", tensor.Get(1, 2, 3, 4))
+	}
+	if tensor.Values()[119] != 7 {
+		t.Errorf("Last value should be 7, is: %.0f", tensor.Values()[119])
+	}
+
+	err = tensor.Set(1, 2, 0, 0, 0)
+	if err == nil {
+		t.Errorf("Setting value out of bounds did not trigger error")
+	}
+	err = tensor.Set(1, 0, 0)
+	if err == nil {
+		t.Errorf("Setting value with too few indices did not trigger error")
+	}
+	_, err = NewEmptyNDTensor(2, 0)
+	if err == nil {
+		t.Errorf("Shape with a length of 0 did not trigger error")
+	}
+	_, err = NewValueNDTensor([]float32{1, 2, 3}, 2, 2)
+	if err == nil {
+		t.Errorf("Values that do not match shape did not trigger error")
+	}
+}
+
+func TestNDTensorReshape(t *testing.T) {
+	tensor, _ := NewValueNDTensor([]float32{1, 2, 3, 4, 5, 6, 7, 8}, 2, 2, 2, 1)
+
+	reshaped, err := tensor.Reshape(2, -1)
+	if err != nil {
+		t.Fatalf("Error in Reshape: %s", err.Error())
+	}
+	if shape := reshaped.Shape(); len(shape) != 2 || shape[1] != 4 {
+		t.Errorf("Reshaped shape should be [2 4], is: %v", shape)
+	}
+	if reshaped.Get(1, 2) != 7 {
+		t.Errorf("Reshaped value should be 7, is: %.0f", reshaped.Get(1, 2))
+	}
+
+	// Reshaped tensors share their values.
+	reshaped.Set(0, 0, 0)
+	if tensor.Get(0, 0, 0, 0) != 0 {
+		t.Errorf("Reshaped tensor should share values with the original, is: %.0f", tensor.Get(0, 0, 0, 0))
+	}
+
+	_, err = tensor.Reshape(3, -1)
+	if err == nil {
+		t.Errorf("Reshape to a different size did not trigger error")
+	}
+	_, err = tensor.Reshape(-1, -1)
+	if err == nil {
+		t.Errorf("Reshape with two inferred dimensions did not trigger error")
+	}
+}
+
+func TestNDTensorElementwise(t *testing.T) {
+	tensor, _ := NewValueNDTensor([]float32{1, 2, 3, 4}, 1, 2, 1, 2)
+	other, _ := NewValueNDTensor([]float32{2, 2, 1, 0}, 1, 2, 1, 2)
+	solution, _ := NewValueNDTensor([]float32{8, 12, 7, -2}, 1, 2, 1, 2)
+
+	tensor.Add(1)
+	err := tensor.ScaleTensor(other)
+	if err != nil {
+		t.Fatalf("Error in ScaleTensor: %s", err.Error())
+	}
+	err = tensor.AddTensor(other)
+	if err != nil {
+		t.Fatalf("Error in AddTensor: %s", err.Error())
+	}
+	tensor.Subtract(1)
+	tensor.Scale(2)
+	err = tensor.SubtractTensor(other)
+	if err != nil {
+		t.Fatalf("Error in SubtractTensor: %s", err.Error())
+	}
+	if !tensor.Equals(solution) {
+		t.Errorf("Tensor after element-wise operations should be:\n%swhen result is:\n%s", solution.String(), tensor.String())
+	}
+	if tensor.Sum() != 25 {
+		t.Errorf("Sum should be 25, is: %.0f", tensor.Sum())
+	}
+
+	tensor.ApplyFunction(func(current float32, indices []int) float32 {
+		return float32(indices[1]*10 + indices[3])
+	})
+	if tensor.Get(0, 1, 0, 1) != 11 {
+		t.Errorf("Value from indices should be 11, is: %.0f", tensor.Get(0, 1, 0, 1))
+	}
+
+	mismatched, _ := NewEmptyNDTensor(2, 2)
+	err = tensor.AddTensor(mismatched)
+	if err == nil {
+		t.Errorf("Adding tensor with a different shape did not trigger error")
+	}
+}
+
+func TestNDTensorConversion(t *testing.T) {
+	tensor := NewValueTensor3D([][][]float32{
+		{{1, 2}, {3, 4}},
+		{{5, 6}, {7, 8}},
+	})
+
+	nd := NewNDTensorFromTensor(tensor)
+	if nd.Rank() != 3 || nd.Get(1, 0, 1) != 6 {
+		t.Errorf("Converted tensor should have rank 3 and value 6, has: %d, %.0f", nd.Rank(), nd.Get(1, 0, 1))
+	}
+
+	matrix, _ := nd.Reshape(4, 2)
+	converted, err := matrix.ToTensor()
+	if err != nil {
+		t.Fatalf("Error in ToTensor: %s", err.Error())
+	}
+	if converted.Frames != 1 || converted.Rows != 4 || converted.Get(0, 3, 0) != 7 {
+		t.Errorf("Converted matrix should have 1 frame, 4 rows and value 7:\n%s", converted.String())
+	}
+
+	batch, _ := nd.Reshape(1, 2, 2, 2)
+	_, err = batch.ToTensor()
+	if err == nil {
+		t.Errorf("Converting tensor with 4 dimensions did not trigger error")
+	}
+}
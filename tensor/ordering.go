@@ -0,0 +1,143 @@
+package tensor
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Axis is one of the dimensions of a tensor.
+type Axis int
+
+const (
+	// AxisFrames is the dimension of the frames of a tensor.
+	AxisFrames = Axis(iota)
+
+	// AxisRows is the dimension of the rows of a tensor.
+	AxisRows
+
+	// AxisCols is the dimension of the columns of a tensor.
+	AxisCols
+)
+
+// CumSum computes the cumulative sums of the values along an axis of a tensor.
+func CumSum(tensor *Tensor, axis Axis) (*Tensor, error) {
+	result := tensor.Copy()
+	err := forEachLine(result, axis, func(line func(int) (int, int, int), length int) {
+		sum := float32(0.0)
+		for i := 0; i < length; i++ {
+			frame, row, col := line(i)
+			sum += result.Get(frame, row, col)
+			result.Set(frame, row, col, sum)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Diff computes the differences between consecutive values along an axis of a tensor. The result
+// has one less value than the tensor along the axis.
+func Diff(tensor *Tensor, axis Axis) (*Tensor, error) {
+	frames, rows, cols := tensor.Frames, tensor.Rows, tensor.Cols
+	switch axis {
+	case AxisFrames:
+		frames--
+	case AxisRows:
+		rows--
+	case AxisCols:
+		cols--
+	default:
+		return nil, fmt.Errorf("Invalid axis: %d", axis)
+	}
+	if frames < 1 || rows < 1 || cols < 1 {
+		return nil, fmt.Errorf("Axis must have at least 2 values to compute differences")
+	}
+	result := NewEmptyTensor3D(frames, rows, cols)
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		nextFrame, nextRow, nextCol := frame, row, col
+		switch axis {
+		case AxisFrames:
+			nextFrame++
+		case AxisRows:
+			nextRow++
+		default:
+			nextCol++
+		}
+		return tensor.Get(nextFrame, nextRow, nextCol) - tensor.Get(frame, row, col)
+	})
+	return result, nil
+}
+
+// Sort sorts the values along an axis of a tensor in ascending order.
+func Sort(tensor *Tensor, axis Axis) (*Tensor, error) {
+	result := tensor.Copy()
+	err := forEachLine(result, axis, func(line func(int) (int, int, int), length int) {
+		values := make([]float32, length)
+		for i := range values {
+			values[i] = result.Get(line(i))
+		}
+		sort.Slice(values, func(i int, j int) bool {
+			return values[i] < values[j]
+		})
+		for i, value := range values {
+			frame, row, col := line(i)
+			result.Set(frame, row, col, value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Argsort finds the indices that would sort the values along an axis of a tensor in ascending
+// order. Equal values keep their original order.
+func Argsort(tensor *Tensor, axis Axis) (*Tensor, error) {
+	result := NewEmptyTensor3D(tensor.Frames, tensor.Rows, tensor.Cols)
+	err := forEachLine(result, axis, func(line func(int) (int, int, int), length int) {
+		indices := make([]int, length)
+		for i := range indices {
+			indices[i] = i
+		}
+		sort.SliceStable(indices, func(i int, j int) bool {
+			return tensor.Get(line(indices[i])) < tensor.Get(line(indices[j]))
+		})
+		for i, index := range indices {
+			frame, row, col := line(i)
+			result.Set(frame, row, col, float32(index))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// forEachLine calls the function for every line of values along an axis of a tensor, with a
+// function converting an index along the line to the frame, row and column of the value.
+func forEachLine(tensor *Tensor, axis Axis, function func(func(int) (int, int, int), int)) error {
+	switch axis {
+	case AxisFrames:
+		for row := 0; row < tensor.Rows; row++ {
+			for col := 0; col < tensor.Cols; col++ {
+				function(func(i int) (int, int, int) { return i, row, col }, tensor.Frames)
+			}
+		}
+	case AxisRows:
+		for frame := 0; frame < tensor.Frames; frame++ {
+			for col := 0; col < tensor.Cols; col++ {
+				function(func(i int) (int, int, int) { return frame, i, col }, tensor.Rows)
+			}
+		}
+	case AxisCols:
+		for frame := 0; frame < tensor.Frames; frame++ {
+			for row := 0; row < tensor.Rows; row++ {
+				function(func(i int) (int, int, int) { return frame, row, i }, tensor.Cols)
+			}
+		}
+	default:
+		return fmt.Errorf("Invalid axis: %d", axis)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package tensor
+
+import "testing"
+
+func TestTensorCumSum(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{
+		{1, 3, 2},
+		{-3, 2, -1},
+	})
+
+	result, err := CumSum(tensor, AxisCols)
+	if err != nil {
+		t.Fatalf("Error in CumSum: %s", err.Error())
+	}
+	solution := NewValueTensor2D([][]float32{
+		{1, 4, 6},
+		{-3, -1, -2},
+	})
+	if !result.Equals(solution) {
+		t.Errorf("Cumulative sum along columns should be:\n%swhen result is:\n%s", solution.String(), result.String())
+	}
+
+	result, _ = CumSum(tensor, AxisRows)
+	solution = NewValueTensor2D([][]float32{
+		{1, 3, 2},
+		{-2, 5, 1},
+	})
+	if !result.Equals(solution) {
+		t.Errorf("Cumulative sum along rows should be:\n%swhen result is:\n%s", solution.String(), result.String())
+	}
+
+	_, err = CumSum(tensor, Axis(3))
+	if err == nil {
+		t.Errorf("Invalid axis did not trigger error")
+	}
+}
+
+func TestTensorDiff(t *testing.T) {
+	tensor := NewValueTensor3D([][][]float32{
+		{
+			{1, 3, 2},
+		},
+		{
+			{2, 5, 0},
+		},
+	})
+
+	result, err := Diff(tensor, AxisCols)
+	if err != nil {
+		t.Fatalf("Error in Diff: %s", err.Error())
+	}
+	solution := NewValueTensor3D([][][]float32{
+		{
+			{2, -1},
+		},
+		{
+			{3, -5},
+		},
+	})
+	if result.Frames != 2 || !result.Equals(solution) {
+		t.Errorf("Differences along columns should be:\n%swhen result is:\n%s", solution.String(), result.String())
+	}
+
+	result, _ = Diff(tensor, AxisFrames)
+	solution = NewValueTensor1D([]float32{1, 2, -2})
+	if result.Frames != 1 || !result.Equals(solution) {
+		t.Errorf("Differences along frames should be:\n%swhen result is:\n%s", solution.String(), result.String())
+	}
+
+	_, err = Diff(tensor, AxisRows)
+	if err == nil {
+		t.Errorf("Differences along axis with a single value did not trigger error")
+	}
+}
+
+func TestTensorSort(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{
+		{1, 3, 2},
+		{-3, 2, -1},
+	})
+
+	result, err := Sort(tensor, AxisCols)
+	if err != nil {
+		t.Fatalf("Error in Sort: %s", err.Error())
+	}
+	solution := NewValueTensor2D([][]float32{
+		{1, 2, 3},
+		{-3, -1, 2},
+	})
+	if !result.Equals(solution) {
+		t.Errorf("Sorted tensor should be:\n%swhen result is:\n%s", solution.String(), result.String())
+	}
+
+	indices, err := Argsort(tensor, AxisRows)
+	if err != nil {
+		t.Fatalf("Error in Argsort: %s", err.Error())
+	}
+	solution = NewValueTensor2D([][]float32{
+		{1, 1, 1},
+		{0, 0, 0},
+	})
+	if !indices.Equals(solution) {
+		t.Errorf("Sorting indices should be:\n%swhen result is:\n%s", solution.String(), indices.String())
+	}
+
+	indices, _ = Argsort(NewValueTensor1D([]float32{2, 1, 2, 0}), AxisCols)
+	solution = NewValueTensor1D([]float32{3, 1, 0, 2})
+	if !indices.Equals(solution) {
+		t.Errorf("Sorting indices should be:\n%swhen result is:\n%s", solution.String(), indices.String())
+	}
+}
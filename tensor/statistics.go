@@ -0,0 +1,194 @@
+package tensor
+
+import (
+	"fmt"
+	"math"
+)
+
+// Covariance computes the sample covariance matrices of the columns across the frames of a tensor,
+// where each row is a sample and each column is a feature.
+func Covariance(tensor *Tensor, target *Tensor) (*Tensor, error) {
+	if tensor.Rows < 2 {
+		return nil, fmt.Errorf("Covariance requires at least 2 rows, has: %d", tensor.Rows)
+	}
+	var result *Tensor
+	if target != nil {
+		if target.Frames != tensor.Frames || target.Rows != tensor.Cols || target.Cols != tensor.Cols {
+			return nil, fmt.Errorf(
+				"Invalid target dimensions: (%d, %d, %d) != (%d, %d, %d)",
+				target.Frames, target.Rows, target.Cols, tensor.Frames, tensor.Cols, tensor.Cols,
+			)
+		}
+		result = target
+	} else {
+		result = NewEmptyTensor3D(tensor.Frames, tensor.Cols, tensor.Cols)
+	}
+	means := NewEmptyTensor3D(tensor.Frames, 1, tensor.Cols)
+	means.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		sum := float32(0.0)
+		for i := 0; i < tensor.Rows; i++ {
+			sum += tensor.Get(frame, i, col)
+		}
+		return sum / float32(tensor.Rows)
+	})
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		sum := float32(0.0)
+		for i := 0; i < tensor.Rows; i++ {
+			sum = MultiplyAdd(sum, tensor.Get(frame, i, row)-means.Get(frame, 0, row), tensor.Get(frame, i, col)-means.Get(frame, 0, col))
+		}
+		return sum / float32(tensor.Rows-1)
+	})
+	return result, nil
+}
+
+// Correlation computes the Pearson correlation matrices of the columns across the frames of a
+// tensor, where each row is a sample and each column is a feature. Columns without any variance
+// have a correlation of 0 with every column.
+func Correlation(tensor *Tensor, target *Tensor) (*Tensor, error) {
+	result, err := Covariance(tensor, target)
+	if err != nil {
+		return nil, err
+	}
+	deviations := NewEmptyTensor3D(result.Frames, 1, result.Cols)
+	deviations.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return float32(math.Sqrt(float64(result.Get(frame, col, col))))
+	})
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		deviation := deviations.Get(frame, 0, row) * deviations.Get(frame, 0, col)
+		if deviation == 0 {
+			return 0
+		}
+		return current / deviation
+	})
+	return result, nil
+}
+
+// Quantile computes the q-th quantile of the values along an axis of a tensor, interpolating
+// linearly between the two closest values. The result has a single value along the axis.
+func Quantile(tensor *Tensor, q float32, axis Axis) (*Tensor, error) {
+	if q < 0 || q > 1 {
+		return nil, fmt.Errorf("Quantile must be between 0 and 1, is: %f", q)
+	}
+	sorted, err := Sort(tensor, axis)
+	if err != nil {
+		return nil, err
+	}
+	frames, rows, cols := tensor.Frames, tensor.Rows, tensor.Cols
+	length := 0
+	switch axis {
+	case AxisFrames:
+		length, frames = frames, 1
+	case AxisRows:
+		length, rows = rows, 1
+	default:
+		length, cols = cols, 1
+	}
+	if length == 0 {
+		return nil, fmt.Errorf("Quantile must be of at least 1 value along the axis")
+	}
+	position := q * float32(length-1)
+	lower := int(position)
+	upper := lower
+	if upper < length-1 {
+		upper++
+	}
+	weight := position - float32(lower)
+	result := NewEmptyTensor3D(frames, rows, cols)
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		index := func(i int) (int, int, int) {
+			switch axis {
+			case AxisFrames:
+				return i, row, col
+			case AxisRows:
+				return frame, i, col
+			default:
+				return frame, row, i
+			}
+		}
+		lowerValue := sorted.Get(index(lower))
+		upperValue := sorted.Get(index(upper))
+		return lowerValue + (upperValue-lowerValue)*weight
+	})
+	return result, nil
+}
+
+// Mean computes the mean of all values in a tensor.
+func Mean(tensor *Tensor) float32 {
+	return tensor.Sum() / float32(tensor.Frames*tensor.Rows*tensor.Cols)
+}
+
+// Variance computes the population variance of all values in a tensor.
+func Variance(tensor *Tensor) float32 {
+	return centralMoment(tensor, 2)
+}
+
+// Skewness computes the population skewness of all values in a tensor, which is negative when the
+// values have a longer tail below the mean and positive when the tail is above. Values without any
+// variance have a skewness of 0.
+func Skewness(tensor *Tensor) float32 {
+	variance := centralMoment(tensor, 2)
+	if variance == 0 {
+		return 0
+	}
+	return float32(float64(centralMoment(tensor, 3)) / math.Pow(float64(variance), 1.5))
+}
+
+// Histogram counts the values of a tensor in a number of equally wide bins between its minimum and
+// maximum values. It returns the count of each bin and the edges of the bins, where the last bin
+// includes its upper edge. Values that are NaN or infinite are not counted.
+func Histogram(tensor *Tensor, bins int) ([]int, []float32, error) {
+	if bins < 1 {
+		return nil, nil, fmt.Errorf("Histogram requires at least 1 bin, has: %d", bins)
+	}
+	finite := func(value float32) bool {
+		return !math.IsNaN(float64(value)) && !math.IsInf(float64(value), 0)
+	}
+	min := math.MaxFloat64
+	max := -math.MaxFloat64
+	tensor.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		if finite(current) {
+			min = math.Min(min, float64(current))
+			max = math.Max(max, float64(current))
+		}
+		return current
+	})
+	if min > max {
+		min, max = 0, 0
+	}
+	if min == max {
+		min -= 0.5
+		max += 0.5
+	}
+	width := (max - min) / float64(bins)
+	edges := make([]float32, bins+1)
+	for i := 0; i < bins; i++ {
+		edges[i] = float32(min + float64(i)*width)
+	}
+	edges[bins] = float32(max)
+	counts := make([]int, bins)
+	tensor.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		if !finite(current) {
+			return current
+		}
+		bin := int((float64(current) - min) / width)
+		if bin >= bins {
+			bin = bins - 1
+		}
+		counts[bin]++
+		return current
+	})
+	return counts, edges, nil
+}
+
+func centralMoment(tensor *Tensor, order float64) float32 {
+	mean := float64(Mean(tensor))
+	sum := 0.0
+	for frame := 0; frame < tensor.Frames; frame++ {
+		for row := 0; row < tensor.Rows; row++ {
+			for col := 0; col < tensor.Cols; col++ {
+				sum += math.Pow(float64(tensor.values[tensor.index(frame, row, col)])-mean, order)
+			}
+		}
+	}
+	return float32(sum / float64(tensor.Frames*tensor.Rows*tensor.Cols))
+}
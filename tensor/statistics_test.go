@@ -0,0 +1,184 @@
+package tensor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTensorCovariance(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{
+		{1, 2, 5},
+		{2, 4, 5},
+		{3, 6, 5},
+	})
+
+	covariance, err := Covariance(tensor, nil)
+	if err != nil {
+		t.Fatalf("Error in Covariance: %s", err.Error())
+	}
+
+	solution := NewValueTensor2D([][]float32{
+		{1, 2, 0},
+		{2, 4, 0},
+		{0, 0, 0},
+	})
+	if !covariance.Equals(solution) {
+		t.Errorf("Covariance should be:\n%swhen result is:\n%s", solution.String(), covariance.String())
+	}
+
+	_, err = Covariance(NewValueTensor1D([]float32{1, 2}), nil)
+	if err == nil {
+		t.Errorf("Covariance of a single row did not trigger error")
+	}
+
+	_, err = Covariance(tensor, NewEmptyTensor2D(2, 3))
+	if err == nil {
+		t.Errorf("Covariance with invalid target did not trigger error")
+	}
+}
+
+func TestTensorCorrelation(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{
+		{1, 3, 5},
+		{2, 2, 5},
+		{3, 1, 5},
+		{4, 0, 5},
+	})
+
+	correlation, err := Correlation(tensor, nil)
+	if err != nil {
+		t.Fatalf("Error in Correlation: %s", err.Error())
+	}
+
+	solution := NewValueTensor2D([][]float32{
+		{1, -1, 0},
+		{-1, 1, 0},
+		{0, 0, 0},
+	})
+	for row := 0; row < solution.Rows; row++ {
+		for col := 0; col < solution.Cols; col++ {
+			if math.Abs(float64(correlation.Get(0, row, col)-solution.Get(0, row, col))) > 1e-5 {
+				t.Fatalf("Correlation should be:\n%swhen result is:\n%s", solution.String(), correlation.String())
+			}
+		}
+	}
+}
+
+func TestTensorQuantile(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{
+		{4, 1, 3, 2},
+		{10, 40, 20, 30},
+	})
+
+	median, err := Quantile(tensor, 0.5, AxisCols)
+	if err != nil {
+		t.Fatalf("Error in Quantile: %s", err.Error())
+	}
+	solution := NewValueTensor2D([][]float32{
+		{2.5},
+		{25},
+	})
+	if !median.Equals(solution) {
+		t.Errorf("Median along columns should be:\n%swhen result is:\n%s", solution.String(), median.String())
+	}
+
+	upper, _ := Quantile(tensor, 1, AxisRows)
+	solution = NewValueTensor1D([]float32{10, 40, 20, 30})
+	if !upper.Equals(solution) {
+		t.Errorf("Maximum along rows should be:\n%swhen result is:\n%s", solution.String(), upper.String())
+	}
+
+	quartile, _ := Quantile(tensor, 0.25, AxisCols)
+	if quartile.Get(0, 0, 0) != 1.75 {
+		t.Errorf("First quartile should be 1.75, is: %.3f", quartile.Get(0, 0, 0))
+	}
+
+	_, err = Quantile(tensor, 1.5, AxisCols)
+	if err == nil {
+		t.Errorf("Invalid quantile did not trigger error")
+	}
+}
+
+func TestTensorMoments(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{
+		{1, 2, 3},
+		{4, 5, 9},
+	})
+
+	mean := Mean(tensor)
+	if mean != 4 {
+		t.Errorf("Mean should be 4, is: %.3f", mean)
+	}
+
+	variance := Variance(tensor)
+	if math.Abs(float64(variance)-20.0/3.0) > 1e-5 {
+		t.Errorf("Variance should be %.3f, is: %.3f", 20.0/3.0, variance)
+	}
+
+	skewness := Skewness(tensor)
+	solution := 15.0 / math.Pow(20.0/3.0, 1.5)
+	if math.Abs(float64(skewness)-solution) > 1e-5 {
+		t.Errorf("Skewness should be %.3f, is: %.3f", solution, skewness)
+	}
+
+	constant := NewValueTensor1D([]float32{2, 2, 2})
+	if Variance(constant) != 0 || Skewness(constant) != 0 {
+		t.Errorf("Constant values should have no variance or skewness")
+	}
+}
+
+func TestTensorHistogram(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{
+		{0, 1, 2, 3},
+		{3.5, 4, 4, 1.5},
+	})
+
+	counts, edges, err := Histogram(tensor, 4)
+	if err != nil {
+		t.Fatalf("Error in Histogram: %s", err.Error())
+	}
+
+	solutionCounts := []int{1, 2, 1, 4}
+	for i, count := range counts {
+		if count != solutionCounts[i] {
+			t.Errorf("Incorrect count for bin %d: %d != %d", i, count, solutionCounts[i])
+		}
+	}
+	solutionEdges := []float32{0, 1, 2, 3, 4}
+	for i, edge := range edges {
+		if edge != solutionEdges[i] {
+			t.Errorf("Incorrect edge %d: %.3f != %.3f", i, edge, solutionEdges[i])
+		}
+	}
+
+	_, _, err = Histogram(tensor, 0)
+	if err == nil {
+		t.Errorf("Histogram without bins did not trigger error")
+	}
+
+	// NaN and infinite values are left out of the counts and the range of the bins.
+	nan := float32(math.NaN())
+	inf := float32(math.Inf(1))
+	nonFinite := NewValueTensor2D([][]float32{
+		{0, 1, 2, 3},
+		{3.5, 4, 4, 1.5},
+		{nan, inf, -inf, nan},
+	})
+	counts, edges, err = Histogram(nonFinite, 4)
+	if err != nil {
+		t.Fatalf("Error in Histogram: %s", err.Error())
+	}
+	for i, count := range counts {
+		if count != solutionCounts[i] || edges[i] != solutionEdges[i] {
+			t.Errorf("Incorrect count or edge for bin %d with non-finite values: %d, %.3f", i, count, edges[i])
+		}
+	}
+
+	counts, _, err = Histogram(NewValueTensor1D([]float32{nan, inf}), 2)
+	if err != nil {
+		t.Fatalf("Error in Histogram: %s", err.Error())
+	}
+	if counts[0] != 0 || counts[1] != 0 {
+		t.Errorf("Histogram of only non-finite values should be empty, is: %v", counts)
+	}
+}
@@ -0,0 +1,35 @@
+package tensor
+
+import "sync/atomic"
+
+// strictMath is 1 while strict math is enabled, and is read atomically so it can be changed while
+// other goroutines compute.
+var strictMath int32
+
+// SetStrictMath enables or disables strict math. Go may fuse a multiplication and an addition into
+// a single instruction on some architectures, which skips rounding the product and gives slightly
+// different results than on architectures without it. In strict math, products are always rounded
+// before they are added, so matrix products, convolutions and other sums of products give identical
+// results on every architecture, at some cost in speed. Sums are always added up in the same order,
+// including when matrix products are split across goroutines.
+func SetStrictMath(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&strictMath, 1)
+	} else {
+		atomic.StoreInt32(&strictMath, 0)
+	}
+}
+
+// StrictMath returns whether strict math is enabled.
+func StrictMath() bool {
+	return atomic.LoadInt32(&strictMath) == 1
+}
+
+// MultiplyAdd adds the product of two values to a sum. In strict math, the product is rounded to a
+// float32 before it is added, so the operations are never fused.
+func MultiplyAdd(sum float32, value1 float32, value2 float32) float32 {
+	if StrictMath() {
+		return sum + float32(value1*value2)
+	}
+	return sum + value1*value2
+}
@@ -0,0 +1,53 @@
+package tensor
+
+import "testing"
+
+func TestStrictMath(t *testing.T) {
+	defer SetStrictMath(false)
+	if StrictMath() {
+		t.Errorf("Strict math should be disabled by default")
+	}
+	SetStrictMath(true)
+	if !StrictMath() {
+		t.Errorf("Strict math should be enabled")
+	}
+
+	// Values that do not round exactly show whether the product was rounded before the sum.
+	a := float32(1.0000001)
+	b := float32(0.9999999)
+	c := float32(-1)
+	solution := c + float32(a*b)
+	if result := MultiplyAdd(c, a, b); result != solution {
+		t.Errorf("Strict multiply add should be %g, is: %g", solution, result)
+	}
+
+	tensor1 := NewEmptyTensor3D(2, 40, 30)
+	tensor1.SetRandom(-1, 1)
+	tensor2 := NewEmptyTensor3D(2, 30, 60)
+	tensor2.SetRandom(-1, 1)
+	result, err := MatrixMultiply(tensor1, tensor2, nil)
+	if err != nil {
+		t.Fatalf("Error in MatrixMultiply: %s", err.Error())
+	}
+	for frame := 0; frame < 2; frame++ {
+		for row := 0; row < 40; row++ {
+			for col := 0; col < 60; col++ {
+				sum := float32(0.0)
+				for i := 0; i < 30; i++ {
+					product := float32(tensor1.Get(frame, row, i) * tensor2.Get(frame, i, col))
+					sum += product
+				}
+				if result.Get(frame, row, col) != sum {
+					t.Fatalf("Strict product at (%d, %d, %d) should be %g, is: %g", frame, row, col, sum, result.Get(frame, row, col))
+				}
+			}
+		}
+	}
+	einsum, err := Einsum("fij,fjk->fik", tensor1, tensor2)
+	if err != nil {
+		t.Fatalf("Error in Einsum: %s", err.Error())
+	}
+	if !einsum.Equals(result) {
+		t.Errorf("Strict einsum should match the strict matrix product")
+	}
+}
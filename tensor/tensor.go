@@ -4,106 +4,95 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"runtime"
+	"sync"
 )
 
-// Tensor represents a multi-dimensional set of values.
+// Tensor represents a multi-dimensional set of values. The values are stored in a single slice,
+// and the strides give the distance in the slice between neighboring frames, rows and columns, so
+// views of a tensor can share its values without copying them.
 type Tensor struct {
-	Frames int
-	Rows   int
-	Cols   int
-	values [][][]float32
+	Frames  int
+	Rows    int
+	Cols    int
+	values  []float32
+	offset  int
+	strides [3]int
 }
 
 // NewEmptyTensor1D creates a new tensor with a single frame, row and given number of columns.
 func NewEmptyTensor1D(cols int) *Tensor {
-	values := make([]float32, cols)
-	for col := 0; col < cols; col++ {
-		values[col] = 0.0
-	}
-	return &Tensor{Frames: 1, Rows: 1, Cols: cols, values: [][][]float32{{values}}}
+	return NewEmptyTensor3D(1, 1, cols)
 }
 
 // NewEmptyTensor2D creates a new tensor with a single frame and given number of rows and columns.
 func NewEmptyTensor2D(rows int, cols int) *Tensor {
-	values := make([][]float32, rows)
-	for row := 0; row < rows; row++ {
-		values[row] = make([]float32, cols)
-		for col := 0; col < cols; col++ {
-			values[row][col] = 0.0
-		}
-	}
-	return &Tensor{Frames: 1, Rows: rows, Cols: cols, values: [][][]float32{values}}
+	return NewEmptyTensor3D(1, rows, cols)
 }
 
 // NewEmptyTensor3D creates a new tensor with the given number of rows, columns and frames.
 func NewEmptyTensor3D(frames int, rows int, cols int) *Tensor {
-	values := make([][][]float32, frames)
-	for frame := 0; frame < frames; frame++ {
-		values[frame] = make([][]float32, rows)
-		for row := 0; row < rows; row++ {
-			values[frame][row] = make([]float32, cols)
-			for col := 0; col < cols; col++ {
-				values[frame][row][col] = 0.0
-			}
-		}
-	}
-	return &Tensor{Frames: frames, Rows: rows, Cols: cols, values: values}
+	return newTensor(frames, rows, cols, make([]float32, frames*rows*cols))
 }
 
 // NewValueTensor1D creates a new tensor with the given 1D array of values.
 func NewValueTensor1D(values []float32) *Tensor {
 	if len(values) == 0 {
-		return &Tensor{Frames: 1, Rows: 1, Cols: 1, values: [][][]float32{{{0.0}}}}
-	}
-	cols := len(values)
-	tensorValues := make([]float32, cols)
-	for col := 0; col < cols; col++ {
-		tensorValues[col] = values[col]
+		return NewEmptyTensor3D(1, 1, 1)
 	}
-	return &Tensor{Frames: 1, Rows: 1, Cols: cols, values: [][][]float32{{tensorValues}}}
+	tensorValues := make([]float32, len(values))
+	copy(tensorValues, values)
+	return newTensor(1, 1, len(values), tensorValues)
 }
 
 // NewValueTensor2D creates a new tensor with the given 2D array of values.
 func NewValueTensor2D(values [][]float32) *Tensor {
 	if len(values) == 0 {
-		return &Tensor{Frames: 1, Rows: 1, Cols: 1, values: [][][]float32{{{0.0}}}}
-	}
-	rows := len(values)
-	cols := len(values[0])
-	tensorValues := make([][]float32, rows)
-	for row := 0; row < rows; row++ {
-		tensorValues[row] = make([]float32, cols)
-		for col := 0; col < cols; col++ {
-			tensorValues[row][col] = values[row][col]
-		}
+		return NewEmptyTensor3D(1, 1, 1)
 	}
-	return &Tensor{Frames: 1, Rows: rows, Cols: cols, values: [][][]float32{tensorValues}}
+	return NewValueTensor3D([][][]float32{values})
 }
 
 // NewValueTensor3D creates a new tensor with the given 3D array of values.
 func NewValueTensor3D(values [][][]float32) *Tensor {
 	if len(values) == 0 {
-		return &Tensor{Frames: 1, Rows: 1, Cols: 1, values: [][][]float32{{{0.0}}}}
+		return NewEmptyTensor3D(1, 1, 1)
 	}
 	frames := len(values)
 	rows := len(values[0])
 	cols := len(values[0][0])
-	tensorValues := make([][][]float32, frames)
+	tensor := NewEmptyTensor3D(frames, rows, cols)
 	for frame := 0; frame < frames; frame++ {
-		tensorValues[frame] = make([][]float32, rows)
 		for row := 0; row < rows; row++ {
-			tensorValues[frame][row] = make([]float32, cols)
-			for col := 0; col < cols; col++ {
-				tensorValues[frame][row][col] = values[frame][row][col]
-			}
+			copy(tensor.values[(frame*rows+row)*cols:(frame*rows+row+1)*cols], values[frame][row][:cols])
 		}
 	}
-	return &Tensor{Frames: frames, Rows: rows, Cols: cols, values: tensorValues}
+	return tensor
+}
+
+func newTensor(frames int, rows int, cols int, values []float32) *Tensor {
+	tensor := &Tensor{
+		Frames:  frames,
+		Rows:    rows,
+		Cols:    cols,
+		values:  values,
+		strides: [3]int{rows * cols, cols, 1},
+	}
+	checkInvariants(tensor)
+	return tensor
 }
 
 // Copy creates a deep copy of the tensor.
 func (tensor *Tensor) Copy() *Tensor {
-	return NewValueTensor3D(tensor.values)
+	result := NewEmptyTensor3D(tensor.Frames, tensor.Rows, tensor.Cols)
+	if tensor.IsContiguous() {
+		copy(result.values, tensor.values[tensor.offset:tensor.offset+len(result.values)])
+		return result
+	}
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return tensor.Get(frame, row, col)
+	})
+	return result
 }
 
 // Equals checks that all the values in two matrices are equivalent.
@@ -123,22 +112,96 @@ func (tensor *Tensor) Equals(other *Tensor) bool {
 	return true
 }
 
+// IsContiguous checks whether the values of the tensor are stored in order without any gaps, which
+// is true for every tensor that is not a transposed or sliced view.
+func (tensor *Tensor) IsContiguous() bool {
+	return tensor.strides == [3]int{tensor.Rows * tensor.Cols, tensor.Cols, 1}
+}
+
+// Reshape creates a tensor with a new shape holding the same values in the same order. The new
+// tensor shares its values with the current tensor when it is contiguous, and holds a copy when it
+// is not.
+func (tensor *Tensor) Reshape(frames int, rows int, cols int) (*Tensor, error) {
+	if frames < 0 || rows < 0 || cols < 0 || frames*rows*cols != tensor.Frames*tensor.Rows*tensor.Cols {
+		return nil, fmt.Errorf(
+			"Cannot reshape tensor: (%d, %d, %d) -> (%d, %d, %d)",
+			tensor.Frames, tensor.Rows, tensor.Cols, frames, rows, cols,
+		)
+	}
+	source := tensor
+	if !tensor.IsContiguous() {
+		source = tensor.Copy()
+	}
+	result := newTensor(frames, rows, cols, source.values)
+	result.offset = source.offset
+	checkInvariants(result)
+	return result, nil
+}
+
+// SliceFrames creates a view of the frames of the tensor from start up to but not including end,
+// which shares its values with the current tensor.
+func (tensor *Tensor) SliceFrames(start int, end int) (*Tensor, error) {
+	if start < 0 || end > tensor.Frames || start >= end {
+		return nil, fmt.Errorf("Invalid frame range: [%d, %d) of %d", start, end, tensor.Frames)
+	}
+	result := &Tensor{
+		Frames:  end - start,
+		Rows:    tensor.Rows,
+		Cols:    tensor.Cols,
+		values:  tensor.values,
+		offset:  tensor.offset + start*tensor.strides[0],
+		strides: tensor.strides,
+	}
+	checkInvariants(result)
+	return result, nil
+}
+
+// TransposeView creates a view of the tensor with the rows and columns of each frame swapped, which
+// shares its values with the current tensor.
+func (tensor *Tensor) TransposeView() *Tensor {
+	result := &Tensor{
+		Frames:  tensor.Frames,
+		Rows:    tensor.Cols,
+		Cols:    tensor.Rows,
+		values:  tensor.values,
+		offset:  tensor.offset,
+		strides: [3]int{tensor.strides[0], tensor.strides[2], tensor.strides[1]},
+	}
+	checkInvariants(result)
+	return result
+}
+
 // Get retrieves a value at a specific row and column.
 func (tensor *Tensor) Get(frame int, row int, col int) float32 {
 	if frame < 0 || frame >= tensor.Frames || row < 0 || row >= tensor.Rows || col < 0 || col >= tensor.Cols {
 		log.Panicf("Dimensions out of bounds: (%d, %d, %d)", frame, row, col)
 	}
-	return tensor.values[frame][row][col]
+	return tensor.values[tensor.index(frame, row, col)]
 }
 
-// GetFrame retrieves one 2D frame of values from the tensor.
+// GetFrame retrieves a copy of one 2D frame of values from the tensor.
 func (tensor *Tensor) GetFrame(frame int) [][]float32 {
-	return tensor.values[frame]
+	values := make([][]float32, tensor.Rows)
+	for row := 0; row < tensor.Rows; row++ {
+		values[row] = make([]float32, tensor.Cols)
+		for col := 0; col < tensor.Cols; col++ {
+			values[row][col] = tensor.values[tensor.index(frame, row, col)]
+		}
+	}
+	return values
 }
 
-// GetAll retrieves all values from the tensor.
+// GetAll retrieves a copy of all values from the tensor.
 func (tensor *Tensor) GetAll() [][][]float32 {
-	return tensor.values
+	values := make([][][]float32, tensor.Frames)
+	for frame := 0; frame < tensor.Frames; frame++ {
+		values[frame] = tensor.GetFrame(frame)
+	}
+	return values
+}
+
+func (tensor *Tensor) index(frame int, row int, col int) int {
+	return tensor.offset + frame*tensor.strides[0] + row*tensor.strides[1] + col*tensor.strides[2]
 }
 
 // Sum gets the sum of all values in the tensor.
@@ -147,7 +210,7 @@ func (tensor *Tensor) Sum() float32 {
 	for frame := 0; frame < tensor.Frames; frame++ {
 		for row := 0; row < tensor.Rows; row++ {
 			for col := 0; col < tensor.Cols; col++ {
-				sum += tensor.Get(frame, row, col)
+				sum += tensor.values[tensor.index(frame, row, col)]
 			}
 		}
 	}
@@ -159,7 +222,7 @@ func (tensor *Tensor) Set(frame int, row int, col int, value float32) error {
 	if frame < 0 || frame >= tensor.Frames || row < 0 || row >= tensor.Rows || col < 0 || col >= tensor.Cols {
 		return fmt.Errorf("Dimensions out of bounds: (%d, %d, %d)", frame, row, col)
 	}
-	tensor.values[frame][row][col] = value
+	tensor.values[tensor.index(frame, row, col)] = value
 	return nil
 }
 
@@ -256,11 +319,12 @@ func (tensor *Tensor) ApplyFunction(function func(float32, int, int, int) float3
 	for frame := 0; frame < tensor.Frames; frame++ {
 		for row := 0; row < tensor.Rows; row++ {
 			for col := 0; col < tensor.Cols; col++ {
-				newValue := function(tensor.Get(frame, row, col), frame, row, col)
-				tensor.Set(frame, row, col, newValue)
+				index := tensor.index(frame, row, col)
+				tensor.values[index] = function(tensor.values[index], frame, row, col)
 			}
 		}
 	}
+	checkInvariants(tensor)
 }
 
 // String creates a string representation of the tensor.
@@ -284,7 +348,13 @@ func (tensor *Tensor) String() string {
 	return str
 }
 
-// MatrixMultiply multiplies two matrices across the frames of two tensors.
+// parallelMultiplyThreshold is the number of multiplications in a matrix product above which the
+// rows are split across goroutines, since smaller products are faster on a single goroutine.
+const parallelMultiplyThreshold = 1 << 16
+
+// MatrixMultiply multiplies two matrices across the frames of two tensors. Large products are
+// computed in parallel on every CPU, which gives the same results since each value is summed by a
+// single goroutine.
 func MatrixMultiply(tensor1 *Tensor, tensor2 *Tensor, target *Tensor) (*Tensor, error) {
 	if tensor1.Frames != tensor2.Frames {
 		return nil, fmt.Errorf("Tensor frame lengths do not match: %d != %d", tensor1.Frames, tensor2.Frames)
@@ -300,21 +370,56 @@ func MatrixMultiply(tensor1 *Tensor, tensor2 *Tensor, target *Tensor) (*Tensor,
 				target.Frames, target.Rows, target.Cols, tensor1.Frames, tensor1.Rows, tensor2.Cols,
 			)
 		}
+		if sharesValues(target, tensor1) || sharesValues(target, tensor2) {
+			return nil, fmt.Errorf("Target must not share values with the tensors being multiplied")
+		}
 		result = target
 	} else {
 		result = NewEmptyTensor3D(tensor1.Frames, tensor1.Rows, tensor2.Cols)
 	}
-	for frame := 0; frame < tensor1.Frames; frame++ {
-		for row := 0; row < tensor1.Rows; row++ {
-			for col := 0; col < tensor2.Cols; col++ {
-				sum := float32(0.0)
-				for i := 0; i < tensor1.Cols; i++ {
-					sum += tensor1.Get(frame, row, i) * tensor2.Get(frame, i, col)
+	strict := StrictMath()
+	multiplyRow := func(frame int, row int) {
+		for col := 0; col < tensor2.Cols; col++ {
+			sum := float32(0.0)
+			index1 := tensor1.index(frame, row, 0)
+			index2 := tensor2.index(frame, 0, col)
+			for i := 0; i < tensor1.Cols; i++ {
+				if strict {
+					sum += float32(tensor1.values[index1] * tensor2.values[index2])
+				} else {
+					sum += tensor1.values[index1] * tensor2.values[index2]
 				}
-				result.Set(frame, row, col, sum)
+				index1 += tensor1.strides[2]
+				index2 += tensor2.strides[1]
 			}
+			result.values[result.index(frame, row, col)] = sum
+		}
+	}
+	rows := tensor1.Frames * tensor1.Rows
+	workers := runtime.NumCPU()
+	if workers > rows {
+		workers = rows
+	}
+	if workers < 2 || rows*tensor1.Cols*tensor2.Cols < parallelMultiplyThreshold {
+		for i := 0; i < rows; i++ {
+			multiplyRow(i/tensor1.Rows, i%tensor1.Rows)
 		}
+		checkInvariants(result)
+		return result, nil
+	}
+	// Each worker computes the result for an equal share of the rows across all frames.
+	var wait sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wait.Add(1)
+		go func(start int, end int) {
+			defer wait.Done()
+			for i := start; i < end; i++ {
+				multiplyRow(i/tensor1.Rows, i%tensor1.Rows)
+			}
+		}(worker*rows/workers, (worker+1)*rows/workers)
 	}
+	wait.Wait()
+	checkInvariants(result)
 	return result, nil
 }
 
@@ -328,6 +433,9 @@ func MatrixTranspose(tensor *Tensor, target *Tensor) (*Tensor, error) {
 				target.Frames, target.Rows, target.Cols, tensor.Frames, tensor.Cols, tensor.Rows,
 			)
 		}
+		if sharesValues(target, tensor) {
+			return nil, fmt.Errorf("Target must not share values with the tensor being transposed")
+		}
 		result = target
 	} else {
 		result = NewEmptyTensor3D(tensor.Frames, tensor.Cols, tensor.Rows)
@@ -337,3 +445,57 @@ func MatrixTranspose(tensor *Tensor, target *Tensor) (*Tensor, error) {
 	})
 	return result, nil
 }
+
+// Hadamard multiplies the values of two tensors element by element into a new tensor.
+func Hadamard(tensor1 *Tensor, tensor2 *Tensor, target *Tensor) (*Tensor, error) {
+	if tensor1.Frames != tensor2.Frames || tensor1.Rows != tensor2.Rows || tensor1.Cols != tensor2.Cols {
+		return nil, fmt.Errorf(
+			"Dimensions must match: (%d, %d, %d) != (%d, %d, %d)",
+			tensor1.Frames, tensor1.Rows, tensor1.Cols, tensor2.Frames, tensor2.Rows, tensor2.Cols,
+		)
+	}
+	var result *Tensor
+	if target != nil {
+		if target.Frames != tensor1.Frames || target.Rows != tensor1.Rows || target.Cols != tensor1.Cols {
+			return nil, fmt.Errorf(
+				"Invalid target dimensions: (%d, %d, %d) != (%d, %d, %d)",
+				target.Frames, target.Rows, target.Cols, tensor1.Frames, tensor1.Rows, tensor1.Cols,
+			)
+		}
+		result = target
+	} else {
+		result = NewEmptyTensor3D(tensor1.Frames, tensor1.Rows, tensor1.Cols)
+	}
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return tensor1.Get(frame, row, col) * tensor2.Get(frame, row, col)
+	})
+	return result, nil
+}
+
+// MatrixKronecker computes the Kronecker product of two matrices across the frames of two tensors,
+// where every value of the first matrix scales a copy of the second matrix.
+func MatrixKronecker(tensor1 *Tensor, tensor2 *Tensor, target *Tensor) (*Tensor, error) {
+	if tensor1.Frames != tensor2.Frames {
+		return nil, fmt.Errorf("Tensor frame lengths do not match: %d != %d", tensor1.Frames, tensor2.Frames)
+	}
+	rows := tensor1.Rows * tensor2.Rows
+	cols := tensor1.Cols * tensor2.Cols
+	var result *Tensor
+	if target != nil {
+		if target.Frames != tensor1.Frames || target.Rows != rows || target.Cols != cols {
+			return nil, fmt.Errorf(
+				"Invalid target dimensions: (%d, %d, %d) != (%d, %d, %d)",
+				target.Frames, target.Rows, target.Cols, tensor1.Frames, rows, cols,
+			)
+		}
+		result = target
+	} else {
+		result = NewEmptyTensor3D(tensor1.Frames, rows, cols)
+	}
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		value1 := tensor1.Get(frame, row/tensor2.Rows, col/tensor2.Cols)
+		value2 := tensor2.Get(frame, row%tensor2.Rows, col%tensor2.Cols)
+		return value1 * value2
+	})
+	return result, nil
+}
@@ -60,6 +60,86 @@ func TestTensorGetSet(t *testing.T) {
 	}
 }
 
+func TestTensorReshape(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{
+		{1, 2, 3},
+		{4, 5, 6},
+	})
+
+	solution := NewValueTensor2D([][]float32{
+		{1, 2},
+		{3, 4},
+		{5, 6},
+	})
+
+	reshaped, err := tensor.Reshape(1, 3, 2)
+	if err != nil {
+		t.Fatalf("Error in Reshape: %s", err.Error())
+	}
+	if !reshaped.Equals(solution) {
+		t.Errorf("Tensor after reshape should be:\n%swhen result is:\n%s", solution.String(), reshaped.String())
+	}
+
+	// A contiguous tensor shares its values with the reshaped tensor.
+	reshaped.Set(0, 2, 1, 7)
+	if tensor.Get(0, 1, 2) != 7 {
+		t.Errorf("Reshaped tensor should share values with the original, is: %.0f", tensor.Get(0, 1, 2))
+	}
+
+	_, err = tensor.Reshape(1, 4, 2)
+	if err == nil {
+		t.Errorf("Reshape to a different size did not trigger error")
+	}
+}
+
+func TestTensorViews(t *testing.T) {
+	tensor := NewValueTensor3D([][][]float32{
+		{{1, 2}, {3, 4}},
+		{{5, 6}, {7, 8}},
+		{{9, 10}, {11, 12}},
+	})
+
+	sliced, err := tensor.SliceFrames(1, 3)
+	if err != nil {
+		t.Fatalf("Error in SliceFrames: %s", err.Error())
+	}
+	solution := NewValueTensor3D([][][]float32{
+		{{5, 6}, {7, 8}},
+		{{9, 10}, {11, 12}},
+	})
+	if sliced.Frames != 2 || !sliced.Equals(solution) {
+		t.Errorf("Tensor after slicing frames should be:\n%swhen result is:\n%s", solution.String(), sliced.String())
+	}
+	sliced.Set(0, 0, 0, 0)
+	if tensor.Get(1, 0, 0) != 0 {
+		t.Errorf("Sliced tensor should share values with the original, is: %.0f", tensor.Get(1, 0, 0))
+	}
+
+	transposed := tensor.TransposeView()
+	if transposed.IsContiguous() {
+		t.Errorf("Transposed view should not be contiguous")
+	}
+	copied := transposed.Copy()
+	if !copied.IsContiguous() || !copied.Equals(transposed) {
+		t.Errorf("Copy of a transposed view should be contiguous and equal:\n%swhen result is:\n%s", transposed.String(), copied.String())
+	}
+	if transposed.Get(2, 0, 1) != 11 {
+		t.Errorf("Transposed value should be 11, is: %.0f", transposed.Get(2, 0, 1))
+	}
+	reshaped, err := transposed.Reshape(1, 1, 12)
+	if err != nil {
+		t.Fatalf("Error in Reshape: %s", err.Error())
+	}
+	if reshaped.Get(0, 0, 1) != 3 {
+		t.Errorf("Reshaped transposed value should be 3, is: %.0f", reshaped.Get(0, 0, 1))
+	}
+
+	_, err = tensor.SliceFrames(2, 4)
+	if err == nil {
+		t.Errorf("Slicing frames out of range did not trigger error")
+	}
+}
+
 func TestTensorAdd(t *testing.T) {
 	tensor := NewValueTensor2D([][]float32{
 		{1, 3, 2},
@@ -225,6 +305,42 @@ func TestTensorMultiply(t *testing.T) {
 	}
 }
 
+func TestTensorMultiplyParallel(t *testing.T) {
+	// The product is large enough to be split across goroutines.
+	tensor1 := NewEmptyTensor3D(2, 80, 90)
+	tensor1.SetRandom(-1, 1)
+	tensor2 := NewEmptyTensor3D(2, 90, 70)
+	tensor2.SetRandom(-1, 1)
+
+	result, err := MatrixMultiply(tensor1, tensor2, nil)
+	if err != nil {
+		t.Fatalf("Error in TensorMultiply: %s", err.Error())
+	}
+
+	for frame := 0; frame < 2; frame++ {
+		for row := 0; row < 80; row++ {
+			for col := 0; col < 70; col++ {
+				sum := float32(0.0)
+				for i := 0; i < 90; i++ {
+					sum += tensor1.Get(frame, row, i) * tensor2.Get(frame, i, col)
+				}
+				if result.Get(frame, row, col) != sum {
+					t.Fatalf("Parallel product at (%d, %d, %d) should be %f, is: %f", frame, row, col, sum, result.Get(frame, row, col))
+				}
+			}
+		}
+	}
+
+	transposed, err := MatrixMultiply(tensor2.TransposeView(), tensor1.TransposeView(), nil)
+	if err != nil {
+		t.Fatalf("Error in TensorMultiply: %s", err.Error())
+	}
+	solution, _ := MatrixTranspose(result, nil)
+	if !transposed.Equals(solution) {
+		t.Errorf("Product of transposed views should equal the transposed product")
+	}
+}
+
 func TestTensorTranspose(t *testing.T) {
 	tensor := NewValueTensor2D([][]float32{
 		{1, 3, 2},
@@ -245,3 +361,68 @@ func TestTensorTranspose(t *testing.T) {
 		t.Errorf("Tensor transpose result should be:\n%swhen result is:\n%s", solution.String(), result.String())
 	}
 }
+
+func TestTensorHadamard(t *testing.T) {
+	tensor1 := NewValueTensor2D([][]float32{
+		{1, 3, 2},
+		{-3, 2, -1},
+	})
+
+	tensor2 := NewValueTensor2D([][]float32{
+		{3, 2, 3},
+		{2, -1, 2},
+	})
+
+	result, err := Hadamard(tensor1, tensor2, nil)
+	if err != nil {
+		t.Fatalf("Error in Hadamard: %s", err.Error())
+	}
+
+	solution := NewValueTensor2D([][]float32{
+		{3, 6, 6},
+		{-6, -2, -2},
+	})
+	if !result.Equals(solution) {
+		t.Errorf("Hadamard product should be:\n%swhen result is:\n%s", solution.String(), result.String())
+	}
+	if tensor1.Get(0, 0, 1) != 3 {
+		t.Errorf("Hadamard product should not change the original tensor")
+	}
+
+	_, err = Hadamard(tensor1, NewEmptyTensor2D(2, 2), nil)
+	if err == nil {
+		t.Errorf("Hadamard product with invalid dimensions did not trigger error")
+	}
+}
+
+func TestTensorKronecker(t *testing.T) {
+	tensor1 := NewValueTensor2D([][]float32{
+		{1, 2},
+		{3, 4},
+	})
+
+	tensor2 := NewValueTensor2D([][]float32{
+		{0, 5},
+		{6, 7},
+	})
+
+	result, err := MatrixKronecker(tensor1, tensor2, nil)
+	if err != nil {
+		t.Fatalf("Error in MatrixKronecker: %s", err.Error())
+	}
+
+	solution := NewValueTensor2D([][]float32{
+		{0, 5, 0, 10},
+		{6, 7, 12, 14},
+		{0, 15, 0, 20},
+		{18, 21, 24, 28},
+	})
+	if !result.Equals(solution) {
+		t.Errorf("Kronecker product should be:\n%swhen result is:\n%s", solution.String(), result.String())
+	}
+
+	_, err = MatrixKronecker(tensor1, tensor2, NewEmptyTensor2D(2, 2))
+	if err == nil {
+		t.Errorf("Kronecker product with invalid target did not trigger error")
+	}
+}
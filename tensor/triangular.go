@@ -0,0 +1,83 @@
+package tensor
+
+import "fmt"
+
+// MatrixTrace computes the sum of the diagonal of the square matrices across the frames of a
+// tensor. The result has a single value for each frame.
+func MatrixTrace(tensor *Tensor) (*Tensor, error) {
+	if tensor.Rows != tensor.Cols {
+		return nil, fmt.Errorf("Trace requires square matrices: %d != %d", tensor.Rows, tensor.Cols)
+	}
+	result := NewEmptyTensor3D(tensor.Frames, 1, 1)
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		sum := float32(0.0)
+		for i := 0; i < tensor.Rows; i++ {
+			sum += tensor.Get(frame, i, i)
+		}
+		return sum
+	})
+	return result, nil
+}
+
+// MatrixDiag extracts the diagonal of the matrices across the frames of a tensor. The result has a
+// single row for each frame.
+func MatrixDiag(tensor *Tensor) *Tensor {
+	size := tensor.Rows
+	if tensor.Cols < size {
+		size = tensor.Cols
+	}
+	result := NewEmptyTensor3D(tensor.Frames, 1, size)
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		return tensor.Get(frame, col, col)
+	})
+	return result
+}
+
+// NewDiagonalTensor2D creates a new square tensor with the given values on its diagonal.
+func NewDiagonalTensor2D(values []float32) *Tensor {
+	result := NewEmptyTensor2D(len(values), len(values))
+	for i, value := range values {
+		result.Set(0, i, i, value)
+	}
+	return result
+}
+
+// MatrixTril keeps the lower triangle of the matrices across the frames of a tensor and sets the
+// other values to zero. The triangle includes the diagonal offset by k, where positive offsets are
+// above the main diagonal.
+func MatrixTril(tensor *Tensor, k int, target *Tensor) (*Tensor, error) {
+	return triangle(tensor, target, func(row int, col int) bool {
+		return col-row <= k
+	})
+}
+
+// MatrixTriu keeps the upper triangle of the matrices across the frames of a tensor and sets the
+// other values to zero. The triangle includes the diagonal offset by k, where positive offsets are
+// above the main diagonal.
+func MatrixTriu(tensor *Tensor, k int, target *Tensor) (*Tensor, error) {
+	return triangle(tensor, target, func(row int, col int) bool {
+		return col-row >= k
+	})
+}
+
+func triangle(tensor *Tensor, target *Tensor, keep func(int, int) bool) (*Tensor, error) {
+	var result *Tensor
+	if target != nil {
+		if target.Frames != tensor.Frames || target.Rows != tensor.Rows || target.Cols != tensor.Cols {
+			return nil, fmt.Errorf(
+				"Invalid target dimensions: (%d, %d, %d) != (%d, %d, %d)",
+				target.Frames, target.Rows, target.Cols, tensor.Frames, tensor.Rows, tensor.Cols,
+			)
+		}
+		result = target
+	} else {
+		result = NewEmptyTensor3D(tensor.Frames, tensor.Rows, tensor.Cols)
+	}
+	result.ApplyFunction(func(current float32, frame int, row int, col int) float32 {
+		if keep(row, col) {
+			return tensor.Get(frame, row, col)
+		}
+		return 0
+	})
+	return result, nil
+}
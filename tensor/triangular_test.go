@@ -0,0 +1,86 @@
+package tensor
+
+import "testing"
+
+func TestTensorTraceDiag(t *testing.T) {
+	tensor := NewValueTensor3D([][][]float32{
+		{
+			{1, 2},
+			{3, 4},
+		},
+		{
+			{-1, 0},
+			{5, 7},
+		},
+	})
+
+	trace, err := MatrixTrace(tensor)
+	if err != nil {
+		t.Fatalf("Error in MatrixTrace: %s", err.Error())
+	}
+	if trace.Frames != 2 || trace.Get(0, 0, 0) != 5 || trace.Get(1, 0, 0) != 6 {
+		t.Errorf("Traces should be 5 and 6, are:\n%s", trace.String())
+	}
+
+	_, err = MatrixTrace(NewEmptyTensor2D(2, 3))
+	if err == nil {
+		t.Errorf("Trace of non-square matrix did not trigger error")
+	}
+
+	diag := MatrixDiag(NewValueTensor2D([][]float32{
+		{1, 2, 3},
+		{4, 5, 6},
+	}))
+	solution := NewValueTensor1D([]float32{1, 5})
+	if !diag.Equals(solution) {
+		t.Errorf("Diagonal should be:\n%swhen result is:\n%s", solution.String(), diag.String())
+	}
+
+	diagonal := NewDiagonalTensor2D([]float32{2, 3})
+	solution = NewValueTensor2D([][]float32{
+		{2, 0},
+		{0, 3},
+	})
+	if !diagonal.Equals(solution) {
+		t.Errorf("Diagonal tensor should be:\n%swhen result is:\n%s", solution.String(), diagonal.String())
+	}
+}
+
+func TestTensorTriangles(t *testing.T) {
+	tensor := NewValueTensor2D([][]float32{
+		{1, 2, 3},
+		{4, 5, 6},
+		{7, 8, 9},
+	})
+
+	lower, err := MatrixTril(tensor, 0, nil)
+	if err != nil {
+		t.Fatalf("Error in MatrixTril: %s", err.Error())
+	}
+	solution := NewValueTensor2D([][]float32{
+		{1, 0, 0},
+		{4, 5, 0},
+		{7, 8, 9},
+	})
+	if !lower.Equals(solution) {
+		t.Errorf("Lower triangle should be:\n%swhen result is:\n%s", solution.String(), lower.String())
+	}
+
+	upper, err := MatrixTriu(tensor, 1, nil)
+	if err != nil {
+		t.Fatalf("Error in MatrixTriu: %s", err.Error())
+	}
+	solution = NewValueTensor2D([][]float32{
+		{0, 2, 3},
+		{0, 0, 6},
+		{0, 0, 0},
+	})
+	if !upper.Equals(solution) {
+		t.Errorf("Upper triangle should be:\n%swhen result is:\n%s", solution.String(), upper.String())
+	}
+
+	_, err = MatrixTril(tensor, 0, NewEmptyTensor2D(2, 2))
+	if err == nil {
+		t.Errorf("Invalid target dimensions did not trigger error")
+	}
+}
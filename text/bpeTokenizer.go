@@ -0,0 +1,242 @@
+package text
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// UnknownToken is the token for symbols that are not in the vocabulary of a tokenizer.
+const UnknownToken = "<unk>"
+
+// endOfWord marks the last symbol of a word, so tokens at the end of words are told apart from
+// the same letters inside words, and the spaces between words can be restored.
+const endOfWord = "</w>"
+
+// BPETokenizer splits text into subword tokens with byte pair encoding. Training starts from the
+// characters of the words in a corpus and repeatedly merges the most frequent pair of adjacent
+// symbols into a new token, so common words end up as single tokens while rare words are split
+// into smaller pieces instead of being unknown.
+type BPETokenizer struct {
+	Tokens []string
+	Merges [][2]string
+	ids    map[string]int
+	ranks  map[[2]string]int
+}
+
+// NewBPETokenizer creates a new instance of a byte pair encoding tokenizer with only the unknown
+// token in its vocabulary.
+func NewBPETokenizer() *BPETokenizer {
+	tokenizer := &BPETokenizer{}
+	tokenizer.setVocabulary([]string{UnknownToken}, [][2]string{})
+	return tokenizer
+}
+
+// Train learns a vocabulary of up to a number of tokens from the words of a corpus, starting with
+// the unknown token and every character, and then adding merged tokens until the vocabulary is
+// full or no pair of symbols is left to merge. Ties between pairs are broken alphabetically, so
+// the same corpus always gives the same vocabulary.
+func (tokenizer *BPETokenizer) Train(corpus []string, vocabularySize int) error {
+	wordCounts := map[string]int{}
+	for _, line := range corpus {
+		for _, word := range strings.Fields(line) {
+			wordCounts[word]++
+		}
+	}
+	if len(wordCounts) == 0 {
+		return fmt.Errorf("Corpus must have at least 1 word")
+	}
+	words := make([]string, 0, len(wordCounts))
+	for word := range wordCounts {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	symbols := make([][]string, len(words))
+	characters := map[string]bool{}
+	for i, word := range words {
+		symbols[i] = wordSymbols(word)
+		for _, symbol := range symbols[i] {
+			characters[symbol] = true
+		}
+	}
+	tokens := make([]string, 0, len(characters)+1)
+	for character := range characters {
+		tokens = append(tokens, character)
+	}
+	sort.Strings(tokens)
+	tokens = append([]string{UnknownToken}, tokens...)
+	if vocabularySize < len(tokens) {
+		return fmt.Errorf("Vocabulary size must be at least %d to hold every character, is: %d", len(tokens), vocabularySize)
+	}
+
+	merges := [][2]string{}
+	for len(tokens) < vocabularySize {
+		pairCounts := map[[2]string]int{}
+		for i, wordSymbols := range symbols {
+			for j := 0; j < len(wordSymbols)-1; j++ {
+				pairCounts[[2]string{wordSymbols[j], wordSymbols[j+1]}] += wordCounts[words[i]]
+			}
+		}
+		if len(pairCounts) == 0 {
+			break
+		}
+		best := [2]string{}
+		bestCount := 0
+		for pair, count := range pairCounts {
+			if count > bestCount || (count == bestCount && pair[0]+" "+pair[1] < best[0]+" "+best[1]) {
+				best = pair
+				bestCount = count
+			}
+		}
+		for i := range symbols {
+			symbols[i] = mergePair(symbols[i], best)
+		}
+		merges = append(merges, best)
+		tokens = append(tokens, best[0]+best[1])
+	}
+	tokenizer.setVocabulary(tokens, merges)
+	return nil
+}
+
+// VocabularySize returns the number of tokens in the vocabulary.
+func (tokenizer *BPETokenizer) VocabularySize() int {
+	return len(tokenizer.Tokens)
+}
+
+// Tokenize splits text into tokens by applying the learned merges to the characters of each word,
+// in the order they were learned. The last token of each word ends with "</w>".
+func (tokenizer *BPETokenizer) Tokenize(text string) []string {
+	tokens := []string{}
+	for _, word := range strings.Fields(text) {
+		symbols := wordSymbols(word)
+		for len(symbols) > 1 {
+			bestRank := -1
+			var best [2]string
+			for j := 0; j < len(symbols)-1; j++ {
+				pair := [2]string{symbols[j], symbols[j+1]}
+				if rank, ok := tokenizer.ranks[pair]; ok && (bestRank < 0 || rank < bestRank) {
+					bestRank = rank
+					best = pair
+				}
+			}
+			if bestRank < 0 {
+				break
+			}
+			symbols = mergePair(symbols, best)
+		}
+		tokens = append(tokens, symbols...)
+	}
+	return tokens
+}
+
+// Encode converts text to the ids of its tokens, where tokens that are not in the vocabulary get
+// the id of the unknown token.
+func (tokenizer *BPETokenizer) Encode(text string) []int {
+	tokens := tokenizer.Tokenize(text)
+	ids := make([]int, len(tokens))
+	for i, token := range tokens {
+		if id, ok := tokenizer.ids[token]; ok {
+			ids[i] = id
+		} else {
+			ids[i] = tokenizer.ids[UnknownToken]
+		}
+	}
+	return ids
+}
+
+// Decode converts ids of tokens back to text, with a space after the end of each word.
+func (tokenizer *BPETokenizer) Decode(ids []int) (string, error) {
+	var builder strings.Builder
+	for _, id := range ids {
+		if id < 0 || id >= len(tokenizer.Tokens) {
+			return "", fmt.Errorf("Invalid token id: %d", id)
+		}
+		builder.WriteString(strings.Replace(tokenizer.Tokens[id], endOfWord, " ", 1))
+	}
+	return strings.TrimSuffix(builder.String(), " "), nil
+}
+
+func (tokenizer *BPETokenizer) setVocabulary(tokens []string, merges [][2]string) {
+	tokenizer.Tokens = tokens
+	tokenizer.Merges = merges
+	tokenizer.ids = make(map[string]int, len(tokens))
+	for i, token := range tokens {
+		tokenizer.ids[token] = i
+	}
+	tokenizer.ranks = make(map[[2]string]int, len(merges))
+	for i, merge := range merges {
+		tokenizer.ranks[merge] = i
+	}
+}
+
+// wordSymbols splits a word into its characters, marking the last one as the end of the word.
+func wordSymbols(word string) []string {
+	symbols := strings.Split(word, "")
+	symbols[len(symbols)-1] += endOfWord
+	return symbols
+}
+
+// mergePair joins each occurrence of a pair of adjacent symbols into a single symbol.
+func mergePair(symbols []string, pair [2]string) []string {
+	merged := make([]string, 0, len(symbols))
+	for i := 0; i < len(symbols); i++ {
+		if i < len(symbols)-1 && symbols[i] == pair[0] && symbols[i+1] == pair[1] {
+			merged = append(merged, pair[0]+pair[1])
+			i++
+			continue
+		}
+		merged = append(merged, symbols[i])
+	}
+	return merged
+}
+
+// BPETokenizerData represents a serialized tokenizer that can be saved to a file.
+type BPETokenizerData struct {
+	Tokens []string    `json:"tokens"`
+	Merges [][2]string `json:"merges"`
+}
+
+// MarshalJSON converts the tokenizer to JSON.
+func (tokenizer *BPETokenizer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(BPETokenizerData{Tokens: tokenizer.Tokens, Merges: tokenizer.Merges})
+}
+
+// UnmarshalJSON creates a new tokenizer from JSON.
+func (tokenizer *BPETokenizer) UnmarshalJSON(b []byte) error {
+	data := BPETokenizerData{}
+	err := json.Unmarshal(b, &data)
+	if err != nil {
+		return err
+	}
+	if len(data.Tokens) == 0 || data.Tokens[0] != UnknownToken {
+		return fmt.Errorf("First token of the vocabulary must be %s", UnknownToken)
+	}
+	if data.Merges == nil {
+		data.Merges = [][2]string{}
+	}
+	tokenizer.setVocabulary(data.Tokens, data.Merges)
+	return nil
+}
+
+// SaveToFile saves a tokenizer to a file.
+func (tokenizer *BPETokenizer) SaveToFile(fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(tokenizer)
+}
+
+// LoadFromFile loads a tokenizer from a file.
+func (tokenizer *BPETokenizer) LoadFromFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewDecoder(file).Decode(tokenizer)
+}
@@ -0,0 +1,80 @@
+package text
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestBPETokenizer(t *testing.T) {
+	corpus := []string{
+		"low low low low low",
+		"lower lower",
+		"newest newest newest newest newest newest",
+		"widest widest widest",
+	}
+	tokenizer := NewBPETokenizer()
+	err := tokenizer.Train(corpus, 20)
+	if err != nil {
+		t.Fatalf("Error in Train: %s", err.Error())
+	}
+	if tokenizer.VocabularySize() != 20 {
+		t.Errorf("Vocabulary size should be 20, is: %d", tokenizer.VocabularySize())
+	}
+	if tokenizer.Merges[0] != [2]string{"e", "s"} {
+		t.Errorf("First merge should be the most frequent pair [e s], is: %v", tokenizer.Merges[0])
+	}
+
+	tokens := tokenizer.Tokenize("newest lowest")
+	if len(tokens) >= len("newestlowest") {
+		t.Errorf("Tokens should join characters into subwords, are: %v", tokens)
+	}
+	if tokens[0] != "newest</w>" {
+		t.Errorf("Frequent word should be a single token, is: %v", tokens)
+	}
+
+	ids := tokenizer.Encode("newest lowest")
+	decoded, err := tokenizer.Decode(ids)
+	if err != nil {
+		t.Fatalf("Error in Decode: %s", err.Error())
+	}
+	if decoded != "newest lowest" {
+		t.Errorf("Decoded text should be: newest lowest, is: %s", decoded)
+	}
+
+	unknown := tokenizer.Encode("xyz")
+	if unknown[0] != 0 {
+		t.Errorf("Unknown character should have id 0, has: %d", unknown[0])
+	}
+	_, err = tokenizer.Decode([]int{100})
+	if err == nil {
+		t.Errorf("Decoding an invalid id should have an error")
+	}
+	err = NewBPETokenizer().Train(corpus, 5)
+	if err == nil {
+		t.Errorf("Vocabulary size smaller than the characters should have an error")
+	}
+}
+
+func TestBPETokenizerSaveLoad(t *testing.T) {
+	tokenizer := NewBPETokenizer()
+	err := tokenizer.Train([]string{"the cat sat on the mat", "the hat"}, 25)
+	if err != nil {
+		t.Fatalf("Error in Train: %s", err.Error())
+	}
+	err = tokenizer.SaveToFile("tokenizer.json")
+	if err != nil {
+		t.Fatalf("Error in SaveToFile: %s", err.Error())
+	}
+	defer os.Remove("tokenizer.json")
+
+	loaded := NewBPETokenizer()
+	err = loaded.LoadFromFile("tokenizer.json")
+	if err != nil {
+		t.Fatalf("Error in LoadFromFile: %s", err.Error())
+	}
+	text := "the cat sat on that mat"
+	if !reflect.DeepEqual(loaded.Encode(text), tokenizer.Encode(text)) {
+		t.Errorf("Loaded tokenizer should encode like the original: %v != %v", loaded.Encode(text), tokenizer.Encode(text))
+	}
+}
@@ -0,0 +1,164 @@
+package text
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// StartToken pads the start of each sentence of an n-gram model, so the first words have a
+	// context.
+	StartToken = "<s>"
+
+	// EndToken ends each sentence of an n-gram model, so the model also predicts where sentences end.
+	EndToken = "</s>"
+)
+
+// NGramModel is a language model that predicts each word from the words before it, using counts of
+// sequences of up to a number of words with interpolated Kneser-Ney smoothing. Each count is
+// reduced by a discount that is given to shorter contexts, and shorter contexts count the number of
+// different words that come before a word rather than how often it appears, so a word that is only
+// common after one other word is not expected everywhere.
+type NGramModel struct {
+	Order    int
+	Discount float32
+	counts   []map[string]int
+	totals   []map[string]int
+	types    []map[string]int
+	words    map[string]bool
+}
+
+// NewNGramModel creates a new instance of an n-gram model that predicts words from up to order - 1
+// words before them, with a discount of 0.75.
+func NewNGramModel(order int) (*NGramModel, error) {
+	if order < 1 {
+		return nil, fmt.Errorf("Order must be at least 1, is: %d", order)
+	}
+	return &NGramModel{Order: order, Discount: 0.75, words: map[string]bool{}}, nil
+}
+
+// Train counts the sequences of words in sentences, where each sentence is a list of words. Training
+// again replaces the counts.
+func (model *NGramModel) Train(sentences [][]string) error {
+	if model.Discount <= 0 || model.Discount >= 1 {
+		return fmt.Errorf("Discount must be between 0 and 1, is: %f", model.Discount)
+	}
+	raw := make([]map[string]int, model.Order+1)
+	for order := range raw {
+		raw[order] = map[string]int{}
+	}
+	model.words = map[string]bool{EndToken: true}
+	for _, sentence := range sentences {
+		padded := model.pad(sentence)
+		for i := model.Order - 1; i < len(padded); i++ {
+			model.words[padded[i]] = true
+			for order := 1; order <= model.Order; order++ {
+				raw[order][ngramKey(padded[i-order+1:i+1])]++
+			}
+		}
+	}
+	if len(raw[1]) == 0 {
+		return fmt.Errorf("Must have at least 1 sentence")
+	}
+
+	// Shorter sequences count the different words that come before them in the longer sequences.
+	model.counts = make([]map[string]int, model.Order+1)
+	model.counts[model.Order] = raw[model.Order]
+	for order := 1; order < model.Order; order++ {
+		model.counts[order] = map[string]int{}
+		for key := range raw[order+1] {
+			model.counts[order][key[strings.Index(key, "\x00")+1:]]++
+		}
+	}
+	model.totals = make([]map[string]int, model.Order+1)
+	model.types = make([]map[string]int, model.Order+1)
+	for order := 1; order <= model.Order; order++ {
+		model.totals[order] = map[string]int{}
+		model.types[order] = map[string]int{}
+		for key, count := range model.counts[order] {
+			context := ""
+			if index := strings.LastIndex(key, "\x00"); index >= 0 {
+				context = key[:index]
+			}
+			model.totals[order][context] += count
+			model.types[order][context]++
+		}
+	}
+	return nil
+}
+
+// VocabularySize returns the number of words the model can predict, which includes the end of a
+// sentence and the unknown token for words that were not in training.
+func (model *NGramModel) VocabularySize() int {
+	return len(model.words) + 1
+}
+
+// Probability returns the probability of a word following the words of a context. Only the last
+// order - 1 words of the context are used, and a shorter context is treated as the start of a
+// sentence.
+func (model *NGramModel) Probability(context []string, word string) (float32, error) {
+	if model.counts == nil {
+		return 0, fmt.Errorf("N-gram model must be trained before computing probabilities")
+	}
+	padded := model.pad(context)
+	padded = padded[len(padded)-model.Order : len(padded)-1]
+	return float32(model.probability(padded, word)), nil
+}
+
+// Perplexity returns how surprised the model is by sentences, which is the exponent of the average
+// negative log probability of each word and the end of each sentence. Lower is better, and a
+// model that guesses uniformly has a perplexity equal to the vocabulary size.
+func (model *NGramModel) Perplexity(sentences [][]string) (float32, error) {
+	if model.counts == nil {
+		return 0, fmt.Errorf("N-gram model must be trained before computing perplexity")
+	}
+	logSum := 0.0
+	count := 0
+	for _, sentence := range sentences {
+		padded := model.pad(sentence)
+		for i := model.Order - 1; i < len(padded); i++ {
+			logSum -= math.Log(model.probability(padded[i-model.Order+1:i], padded[i]))
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("Must have at least 1 sentence")
+	}
+	return float32(math.Exp(logSum / float64(count))), nil
+}
+
+// probability interpolates the discounted count of a word after a context with its probability after
+// the context without its first word, down to the uniform probability of every word.
+func (model *NGramModel) probability(context []string, word string) float64 {
+	if !model.words[word] {
+		word = UnknownToken
+	}
+	order := len(context) + 1
+	lower := 1 / float64(model.VocabularySize())
+	if order > 1 {
+		lower = model.probability(context[1:], word)
+	}
+	contextKey := ngramKey(context)
+	total := float64(model.totals[order][contextKey])
+	if total == 0 {
+		return lower
+	}
+	discount := float64(model.Discount)
+	count := float64(model.counts[order][ngramKey(append(append([]string{}, context...), word))])
+	return math.Max(count-discount, 0)/total + discount*float64(model.types[order][contextKey])/total*lower
+}
+
+// pad adds start tokens before a sentence and an end token after it.
+func (model *NGramModel) pad(sentence []string) []string {
+	padded := make([]string, 0, len(sentence)+model.Order)
+	for i := 0; i < model.Order-1; i++ {
+		padded = append(padded, StartToken)
+	}
+	padded = append(padded, sentence...)
+	return append(padded, EndToken)
+}
+
+func ngramKey(words []string) string {
+	return strings.Join(words, "\x00")
+}
@@ -0,0 +1,71 @@
+package text
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNGramModel(t *testing.T) {
+	sentences := [][]string{}
+	for _, line := range []string{
+		"the cat sat on the mat",
+		"the dog sat on the rug",
+		"the cat ate the fish",
+		"a dog ate a bone",
+	} {
+		sentences = append(sentences, strings.Fields(line))
+	}
+	model, err := NewNGramModel(3)
+	if err != nil {
+		t.Fatalf("Error in NewNGramModel: %s", err.Error())
+	}
+	err = model.Train(sentences)
+	if err != nil {
+		t.Fatalf("Error in Train: %s", err.Error())
+	}
+
+	// The probabilities of every word after a context should add up to 1.
+	words := []string{UnknownToken}
+	for word := range model.words {
+		words = append(words, word)
+	}
+	for _, context := range [][]string{{}, {"the"}, {"sat", "on"}, {"unseen", "words"}} {
+		sum := float32(0.0)
+		for _, word := range words {
+			probability, err := model.Probability(context, word)
+			if err != nil {
+				t.Fatalf("Error in Probability: %s", err.Error())
+			}
+			sum += probability
+		}
+		if math.Abs(float64(sum-1)) > 1e-4 {
+			t.Errorf("Probabilities after %v should add up to 1, add up to: %f", context, sum)
+		}
+	}
+
+	seen, _ := model.Probability([]string{"sat", "on"}, "the")
+	unseen, _ := model.Probability([]string{"sat", "on"}, "bone")
+	if seen <= unseen {
+		t.Errorf("Seen word should be more likely than unseen word: %f <= %f", seen, unseen)
+	}
+
+	likely, err := model.Perplexity([][]string{strings.Fields("the cat sat on the rug")})
+	if err != nil {
+		t.Fatalf("Error in Perplexity: %s", err.Error())
+	}
+	unlikely, _ := model.Perplexity([][]string{strings.Fields("rug the on cat sat the")})
+	if likely >= unlikely || likely >= float32(model.VocabularySize()) {
+		t.Errorf("Perplexity of a likely sentence should be lower: %f >= %f", likely, unlikely)
+	}
+
+	_, err = NewNGramModel(0)
+	if err == nil {
+		t.Errorf("Order of 0 should have an error")
+	}
+	untrained, _ := NewNGramModel(2)
+	_, err = untrained.Perplexity(sentences)
+	if err == nil {
+		t.Errorf("Perplexity of an untrained model should have an error")
+	}
+}
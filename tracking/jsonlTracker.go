@@ -0,0 +1,114 @@
+package tracking
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// RecordType is the identifying type of a record of a training run.
+type RecordType string
+
+const (
+	// RecordTypeParams is a record of the parameters of a run.
+	RecordTypeParams = RecordType("params")
+
+	// RecordTypeMetrics is a record of the metrics of a run at a step.
+	RecordTypeMetrics = RecordType("metrics")
+
+	// RecordTypeArtifact is a record of a file produced by a run.
+	RecordTypeArtifact = RecordType("artifact")
+)
+
+// Record is a single line of a JSONL tracking file.
+type Record struct {
+	RunID    string                 `json:"runId"`
+	Type     RecordType             `json:"type"`
+	Time     int64                  `json:"time"`
+	Step     int                    `json:"step"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+	Metrics  map[string]float32     `json:"metrics,omitempty"`
+	Artifact string                 `json:"artifact,omitempty"`
+	Path     string                 `json:"path,omitempty"`
+}
+
+// JSONLTracker is a tracker that appends each record of a run as a line of JSON to a local file,
+// so a single file can hold many runs.
+type JSONLTracker struct {
+	runID   string
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// NewJSONLTracker creates a tracker for a run that appends to the file at the given path, creating
+// it if it does not exist. If the run ID is empty, a random one is created.
+func NewJSONLTracker(path string, runID string) (*JSONLTracker, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if runID == "" {
+		runID = NewRunID()
+	}
+	return &JSONLTracker{
+		runID:   runID,
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}, nil
+}
+
+// RunID returns the identifier of the run.
+func (tracker *JSONLTracker) RunID() string {
+	return tracker.runID
+}
+
+// LogParams records the parameters of the run.
+func (tracker *JSONLTracker) LogParams(params map[string]interface{}) error {
+	return tracker.write(Record{Type: RecordTypeParams, Params: params})
+}
+
+// LogMetrics records the metrics of the run at a step.
+func (tracker *JSONLTracker) LogMetrics(step int, metrics map[string]float32) error {
+	return tracker.write(Record{Type: RecordTypeMetrics, Step: step, Metrics: metrics})
+}
+
+// LogArtifact records a file produced by the run.
+func (tracker *JSONLTracker) LogArtifact(name string, path string) error {
+	return tracker.write(Record{Type: RecordTypeArtifact, Artifact: name, Path: path})
+}
+
+// Close closes the file of the tracker.
+func (tracker *JSONLTracker) Close() error {
+	return tracker.file.Close()
+}
+
+func (tracker *JSONLTracker) write(record Record) error {
+	record.RunID = tracker.runID
+	record.Time = time.Now().UnixNano() / int64(time.Millisecond)
+	return tracker.encoder.Encode(record)
+}
+
+// ReadJSONL reads all the records of a JSONL tracking file.
+func ReadJSONL(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	records := []Record{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		record := Record{}
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,55 @@
+package tracking
+
+import (
+	"os"
+	"testing"
+)
+
+func TestJSONLTracker(t *testing.T) {
+	path := "tracking.jsonl"
+	tracker, err := NewJSONLTracker(path, "run1")
+	if err != nil {
+		t.Fatalf("Error in NewJSONLTracker: %s", err.Error())
+	}
+	tracker.LogParams(map[string]interface{}{"learningRate": 0.1})
+	tracker.LogMetrics(0, map[string]float32{"loss": 0.5})
+	tracker.LogMetrics(1, map[string]float32{"loss": 0.25})
+	tracker.LogArtifact("model", "model.json")
+	tracker.Close()
+
+	// A second run appends to the same file.
+	tracker, err = NewJSONLTracker(path, "")
+	if err != nil {
+		t.Fatalf("Error in NewJSONLTracker: %s", err.Error())
+	}
+	if tracker.RunID() == "" || tracker.RunID() == "run1" {
+		t.Errorf("Tracker without run ID should create a new one, is: %s", tracker.RunID())
+	}
+	tracker.LogMetrics(0, map[string]float32{"loss": 1})
+	tracker.Close()
+
+	records, err := ReadJSONL(path)
+	if err != nil {
+		t.Fatalf("Error in ReadJSONL: %s", err.Error())
+	}
+	if len(records) != 5 {
+		t.Fatalf("Number of records should be 5, is: %d", len(records))
+	}
+	if records[0].Type != RecordTypeParams || records[0].Params["learningRate"] != 0.1 {
+		t.Errorf("First record should hold the params, is: %+v", records[0])
+	}
+	if records[2].Type != RecordTypeMetrics || records[2].Step != 1 || records[2].Metrics["loss"] != 0.25 {
+		t.Errorf("Third record should hold the metrics of step 1, is: %+v", records[2])
+	}
+	if records[3].Type != RecordTypeArtifact || records[3].Artifact != "model" || records[3].Path != "model.json" {
+		t.Errorf("Fourth record should hold the artifact, is: %+v", records[3])
+	}
+	if records[3].RunID != "run1" || records[4].RunID != tracker.RunID() {
+		t.Errorf("Records have incorrect run IDs: %s, %s", records[3].RunID, records[4].RunID)
+	}
+
+	err = os.Remove(path)
+	if err != nil {
+		t.Fatalf("Error removing test file: %s", err.Error())
+	}
+}
@@ -0,0 +1,170 @@
+package tracking
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// TensorBoardWriter is a tracker that writes metrics as scalar summaries to a TensorBoard event
+// file, so a run can be viewed by pointing TensorBoard at its directory. Each metric is written with
+// its name as the tag. Parameters and artifacts are not supported by scalar summaries and are
+// ignored.
+type TensorBoardWriter struct {
+	runID  string
+	file   *os.File
+	writer *bufio.Writer
+}
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// NewTensorBoardWriter creates a tracker that writes a new event file to a directory, creating the
+// directory if it does not exist. The name of the directory is used as the run ID.
+func NewTensorBoardWriter(directory string) (*TensorBoardWriter, error) {
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	now := time.Now()
+	fileName := fmt.Sprintf("events.out.tfevents.%d.%s", now.Unix(), hostname)
+	file, err := os.Create(filepath.Join(directory, fileName))
+	if err != nil {
+		return nil, err
+	}
+	writer := &TensorBoardWriter{
+		runID:  filepath.Base(directory),
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}
+	// Every event file starts with an event that holds the version of the format.
+	event := appendDouble(nil, 1, wallTime(now))
+	event = appendBytes(event, 3, []byte("brain.Event:2"))
+	err = writer.writeRecord(event)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return writer, nil
+}
+
+// RunID returns the identifier of the run.
+func (writer *TensorBoardWriter) RunID() string {
+	return writer.runID
+}
+
+// LogParams does nothing, since TensorBoard scalar summaries do not hold parameters.
+func (writer *TensorBoardWriter) LogParams(params map[string]interface{}) error {
+	return nil
+}
+
+// LogMetrics writes each metric as a scalar at a step, in order of the metric names.
+func (writer *TensorBoardWriter) LogMetrics(step int, metrics map[string]float32) error {
+	tags := make([]string, 0, len(metrics))
+	for tag := range metrics {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		err := writer.AddScalar(tag, metrics[tag], step)
+		if err != nil {
+			return err
+		}
+	}
+	return writer.writer.Flush()
+}
+
+// LogArtifact does nothing, since TensorBoard scalar summaries do not hold artifacts.
+func (writer *TensorBoardWriter) LogArtifact(name string, path string) error {
+	return nil
+}
+
+// AddScalar writes a single scalar value with a tag at a step.
+func (writer *TensorBoardWriter) AddScalar(tag string, value float32, step int) error {
+	summaryValue := appendBytes(nil, 1, []byte(tag))
+	summaryValue = appendFloat(summaryValue, 2, value)
+	summary := appendBytes(nil, 1, summaryValue)
+	event := appendDouble(nil, 1, wallTime(time.Now()))
+	event = appendVarint(event, 2, uint64(step))
+	event = appendBytes(event, 5, summary)
+	return writer.writeRecord(event)
+}
+
+// Close writes any buffered events and closes the event file.
+func (writer *TensorBoardWriter) Close() error {
+	err := writer.writer.Flush()
+	if err != nil {
+		writer.file.Close()
+		return err
+	}
+	return writer.file.Close()
+}
+
+// writeRecord writes data in the TFRecord format, which is the length of the data and its checksum
+// followed by the data and its checksum.
+func (writer *TensorBoardWriter) writeRecord(data []byte) error {
+	header := make([]byte, 12)
+	binary.LittleEndian.PutUint64(header, uint64(len(data)))
+	binary.LittleEndian.PutUint32(header[8:], maskedCRC(header[:8]))
+	footer := make([]byte, 4)
+	binary.LittleEndian.PutUint32(footer, maskedCRC(data))
+	for _, bytes := range [][]byte{header, data, footer} {
+		_, err := writer.writer.Write(bytes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func maskedCRC(data []byte) uint32 {
+	crc := crc32.Checksum(data, crc32c)
+	return ((crc >> 15) | (crc << 17)) + 0xa282ead8
+}
+
+func wallTime(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
+
+// The events are encoded as protocol buffer messages, where each field starts with its number and
+// the type of its encoding.
+
+func appendVarint(data []byte, field int, value uint64) []byte {
+	data = appendUvarint(data, uint64(field<<3))
+	return appendUvarint(data, value)
+}
+
+func appendDouble(data []byte, field int, value float64) []byte {
+	data = appendUvarint(data, uint64(field<<3|1))
+	bytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bytes, math.Float64bits(value))
+	return append(data, bytes...)
+}
+
+func appendFloat(data []byte, field int, value float32) []byte {
+	data = appendUvarint(data, uint64(field<<3|5))
+	bytes := make([]byte, 4)
+	binary.LittleEndian.PutUint32(bytes, math.Float32bits(value))
+	return append(data, bytes...)
+}
+
+func appendBytes(data []byte, field int, value []byte) []byte {
+	data = appendUvarint(data, uint64(field<<3|2))
+	data = appendUvarint(data, uint64(len(value)))
+	return append(data, value...)
+}
+
+func appendUvarint(data []byte, value uint64) []byte {
+	bytes := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(bytes, value)
+	return append(data, bytes[:n]...)
+}
@@ -0,0 +1,111 @@
+package tracking
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type scalarEvent struct {
+	step  int
+	tag   string
+	value float32
+}
+
+func TestTensorBoardWriter(t *testing.T) {
+	directory := "tensorBoardRun"
+	writer, err := NewTensorBoardWriter(directory)
+	if err != nil {
+		t.Fatalf("Error in NewTensorBoardWriter: %s", err.Error())
+	}
+	if writer.RunID() != directory {
+		t.Errorf("Run ID should be %s, is: %s", directory, writer.RunID())
+	}
+	writer.LogMetrics(0, map[string]float32{"loss": 0.5, "learningRate": 0.1})
+	writer.AddScalar("accuracy", 0.75, 300)
+	err = writer.Close()
+	if err != nil {
+		t.Fatalf("Error in Close: %s", err.Error())
+	}
+
+	files, err := filepath.Glob(filepath.Join(directory, "events.out.tfevents.*"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Directory should have 1 event file, has: %d", len(files))
+	}
+	data, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("Error reading event file: %s", err.Error())
+	}
+
+	records := [][]byte{}
+	for len(data) > 0 {
+		length := binary.LittleEndian.Uint64(data)
+		if binary.LittleEndian.Uint32(data[8:]) != maskedCRC(data[:8]) {
+			t.Fatalf("Record %d has incorrect length checksum", len(records))
+		}
+		record := data[12 : 12+length]
+		if binary.LittleEndian.Uint32(data[12+length:]) != maskedCRC(record) {
+			t.Fatalf("Record %d has incorrect data checksum", len(records))
+		}
+		records = append(records, record)
+		data = data[16+length:]
+	}
+	if len(records) != 4 {
+		t.Fatalf("Event file should have 4 records, has: %d", len(records))
+	}
+	version := parseFields(records[0])
+	if string(version[3]) != "brain.Event:2" {
+		t.Errorf("First event should hold the file version, is: %s", version[3])
+	}
+
+	solution := []scalarEvent{{0, "learningRate", 0.1}, {0, "loss", 0.5}, {300, "accuracy", 0.75}}
+	for i, expected := range solution {
+		event := parseFields(records[i+1])
+		step, _ := binary.Uvarint(event[2])
+		value := parseFields(parseFields(event[5])[1])
+		scalar := scalarEvent{
+			step:  int(step),
+			tag:   string(value[1]),
+			value: math.Float32frombits(binary.LittleEndian.Uint32(value[2])),
+		}
+		if scalar != expected {
+			t.Errorf("Event %d should be %+v, is: %+v", i+1, expected, scalar)
+		}
+	}
+
+	err = os.RemoveAll(directory)
+	if err != nil {
+		t.Fatalf("Error removing test directory: %s", err.Error())
+	}
+}
+
+// parseFields reads the raw bytes of each field of a protocol buffer message, where varints keep
+// their encoding so they can be read with binary.Uvarint.
+func parseFields(message []byte) map[int][]byte {
+	fields := map[int][]byte{}
+	for len(message) > 0 {
+		key, n := binary.Uvarint(message)
+		message = message[n:]
+		field := int(key >> 3)
+		switch key & 7 {
+		case 0:
+			_, n = binary.Uvarint(message)
+			fields[field] = message[:n]
+			message = message[n:]
+		case 1:
+			fields[field] = message[:8]
+			message = message[8:]
+		case 2:
+			length, n := binary.Uvarint(message)
+			fields[field] = message[n : n+int(length)]
+			message = message[n+int(length):]
+		case 5:
+			fields[field] = message[:4]
+			message = message[4:]
+		}
+	}
+	return fields
+}
@@ -0,0 +1,67 @@
+package tracking
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"../nn"
+)
+
+// Tracker records the parameters, metrics and artifacts of a training run. Implementations can
+// write to a local file, such as JSONLTracker, or send the records to a tracking service.
+type Tracker interface {
+	RunID() string
+	LogParams(params map[string]interface{}) error
+	LogMetrics(step int, metrics map[string]float32) error
+	LogArtifact(name string, path string) error
+	Close() error
+}
+
+// NewRunID creates a random identifier for a training run.
+func NewRunID() string {
+	bytes := make([]byte, 8)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", bytes)
+}
+
+// Callback is a neural network callback that records the progress of training to a tracker. It
+// logs the loss and number of layers when training begins and the metrics of every epoch. If the
+// model path is set, the trained neural network is saved there and logged as an artifact when
+// training ends.
+type Callback struct {
+	Tracker   Tracker
+	ModelPath string
+}
+
+// NewCallback creates a new callback that records training to a tracker.
+func NewCallback(tracker Tracker) *Callback {
+	return &Callback{Tracker: tracker}
+}
+
+// OnTrainBegin logs the parameters of the neural network.
+func (callback *Callback) OnTrainBegin(neuralNetwork *nn.NeuralNetwork) error {
+	return callback.Tracker.LogParams(map[string]interface{}{
+		"loss":   string(neuralNetwork.Loss().Type),
+		"layers": neuralNetwork.LayerCount(),
+	})
+}
+
+// OnEpochEnd logs the metrics of an epoch, using the epoch as the step.
+func (callback *Callback) OnEpochEnd(neuralNetwork *nn.NeuralNetwork, epoch int, metrics map[string]float32) error {
+	return callback.Tracker.LogMetrics(epoch, metrics)
+}
+
+// OnTrainEnd saves the neural network to the model path, if it is set.
+func (callback *Callback) OnTrainEnd(neuralNetwork *nn.NeuralNetwork) error {
+	if callback.ModelPath == "" {
+		return nil
+	}
+	err := neuralNetwork.SaveToFile(callback.ModelPath)
+	if err != nil {
+		return err
+	}
+	return callback.Tracker.LogArtifact("model", callback.ModelPath)
+}
@@ -0,0 +1,62 @@
+package tracking
+
+import (
+	"os"
+	"testing"
+
+	"../nn"
+)
+
+func TestCallback(t *testing.T) {
+	path := "callback.jsonl"
+	modelPath := "callbackModel.json"
+	tracker, err := NewJSONLTracker(path, "")
+	if err != nil {
+		t.Fatalf("Error in NewJSONLTracker: %s", err.Error())
+	}
+	callback := NewCallback(tracker)
+	callback.ModelPath = modelPath
+
+	neuralNetwork := nn.NewNeuralNetwork()
+	neuralNetwork.Add(nn.NewDenseLayer(2, 1, nn.ActivationSigmoid))
+	neuralNetwork.AddCallback(callback)
+
+	inputs := [][][][]float32{{{{0, 1}}}, {{{1, 0}}}}
+	targets := [][][][]float32{{{{1}}}, {{{0}}}}
+	optimizer := nn.NewSGDOptimizer(0.5, 0)
+	err = neuralNetwork.TrainBatchSchedule(inputs, targets, 1, 3, nn.ConstantSchedule(0.5), optimizer)
+	if err != nil {
+		t.Fatalf("Error in TrainBatchSchedule: %s", err.Error())
+	}
+	tracker.Close()
+
+	records, err := ReadJSONL(path)
+	if err != nil {
+		t.Fatalf("Error in ReadJSONL: %s", err.Error())
+	}
+	if len(records) != 5 {
+		t.Fatalf("Number of records should be 5, is: %d", len(records))
+	}
+	if records[0].Type != RecordTypeParams || records[0].Params["loss"] != "mse" {
+		t.Errorf("First record should hold the params, is: %+v", records[0])
+	}
+	for epoch := 0; epoch < 3; epoch++ {
+		record := records[epoch+1]
+		if record.Type != RecordTypeMetrics || record.Step != epoch || record.Metrics["learningRate"] != 0.5 {
+			t.Errorf("Record of epoch %d has incorrect metrics: %+v", epoch, record)
+		}
+	}
+	if records[2].Metrics["loss"] >= records[1].Metrics["loss"] {
+		t.Errorf("Loss should decrease while training: %.4f >= %.4f", records[2].Metrics["loss"], records[1].Metrics["loss"])
+	}
+	if records[4].Type != RecordTypeArtifact || records[4].Path != modelPath {
+		t.Errorf("Last record should hold the model artifact, is: %+v", records[4])
+	}
+
+	for _, file := range []string{path, modelPath} {
+		err = os.Remove(file)
+		if err != nil {
+			t.Fatalf("Error removing test file: %s", err.Error())
+		}
+	}
+}